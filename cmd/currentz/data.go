@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdelles/currentz/internal/demo"
+)
+
+func newImportRecurringCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "import-recurring <file.csv|file.json>",
+		Short: "Create recurring transactions from a CSV or JSON file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.ImportRecurringFile(args[0])
+		},
+	}
+}
+
+func newImportTransactionsCmd(c *cli) *cobra.Command {
+	var (
+		format string
+		commit bool
+	)
+	cmd := &cobra.Command{
+		Use:   "import-transactions <file>",
+		Short: "Preview or import a QIF, YNAB or Mint file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.ImportTransactionsFile(args[0], format, commit)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "qif", "qif, ynab or mint")
+	cmd.Flags().BoolVar(&commit, "commit", false, "save the transactions instead of previewing them")
+	return cmd
+}
+
+func newExportTransactionsCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export-transactions <ynab|mint> [file.csv]",
+		Short: "Export recorded transactions as YNAB or Mint CSV",
+		Long:  "Export recorded transactions as YNAB or Mint CSV, to a file or to stdout.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := ""
+			if len(args) == 2 {
+				out = args[1]
+			}
+			return c.app.ExportTransactionsFile(args[0], out)
+		},
+	}
+}
+
+func newBackupCmd(c *cli) *cobra.Command {
+	var out, pass string
+	cmd := &cobra.Command{
+		Use:   "backup --out <file.czb>",
+		Short: "Write an encrypted backup of all data",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.BackupToFile(out, backupPassphrase(pass))
+		},
+	}
+	cmd.Flags().StringVar(&out, "out", "", "file to write the encrypted backup to (e.g. currentz.czb)")
+	cmd.Flags().StringVar(&pass, "passphrase", "", "encryption passphrase (default $CURRENTZ_BACKUP_PASSPHRASE, else prompt)")
+	_ = cmd.MarkFlagRequired("out")
+	return cmd
+}
+
+func newRestoreCmd(c *cli) *cobra.Command {
+	var (
+		in, pass string
+		yes      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "restore --in <file.czb>",
+		Short: "Replace all data with an encrypted backup",
+		// The file may also be given as the only argument.
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("accepts at most 1 arg, received %d", len(args))
+			}
+			if in == "" && len(args) == 1 {
+				in = args[0]
+			}
+			if in == "" {
+				return fmt.Errorf("usage: currentz restore --in <file.czb> [--passphrase P] [--yes]")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !yes && prompt("This replaces ALL current data. Type 'restore' to continue: ") != "restore" {
+				return fmt.Errorf("restore cancelled")
+			}
+			return c.app.RestoreFromFile(in, backupPassphrase(pass))
+		},
+	}
+	cmd.Flags().StringVar(&in, "in", "", "encrypted backup file to restore")
+	cmd.Flags().StringVar(&pass, "passphrase", "", "encryption passphrase (default $CURRENTZ_BACKUP_PASSPHRASE, else prompt)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip the confirmation prompt")
+	return cmd
+}
+
+func newSeedCmd(c *cli) *cobra.Command {
+	var opts demo.Options
+	cmd := &cobra.Command{
+		Use:   "seed [--months N] [--profile NAME]",
+		Short: "Fill an empty database with realistic sample data",
+		Long: "Fill an empty database with a made-up household's paychecks, bills and\n" +
+			"everyday and irregular spending, for development and screenshots.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.SeedSampleData(opts)
+		},
+	}
+	cmd.Flags().IntVar(&opts.Months, "months", 3, "months of history to generate")
+	cmd.Flags().StringVar(&opts.Profile, "profile", "single", "household to generate: "+strings.Join(demo.Profiles(), ", "))
+	return cmd
+}
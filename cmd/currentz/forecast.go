@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdelles/currentz/internal/app"
+)
+
+// maxForecastDays bounds --days to what a chart or script can reasonably use.
+const maxForecastDays = 730
+
+func newForecastCmd(c *cli) *cobra.Command {
+	var (
+		days   int
+		chart  app.ChartOptions
+		asJSON bool
+	)
+	cmd := &cobra.Command{
+		Use:   "forecast",
+		Short: "Project the balance forward from today",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if days < 1 || days > maxForecastDays {
+				return fmt.Errorf("--days must be between 1 and %d", maxForecastDays)
+			}
+			if chart.Step < 0 {
+				return fmt.Errorf("--step can't be negative")
+			}
+			return cobra.NoArgs(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.PrintForecast(days, chart, asJSON)
+		},
+	}
+	cmd.Flags().IntVar(&days, "days", 90, "number of days, today included")
+	cmd.Flags().IntVar(&chart.Step, "step", 0, "days per chart row, 1 for daily (default: fit about 30 rows)")
+	cmd.Flags().Float64Var(&chart.Threshold, "threshold", 0, "balance to mark on the chart and warn below")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the daily balances as JSON")
+	return cmd
+}
+
+func newCheckCmd(c *cli) *cobra.Command {
+	var (
+		days      int
+		threshold float64
+		asJSON    bool
+	)
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Exit non-zero if the forecast drops below a threshold",
+		Long: "Forecast the next --days days and print whether the balance stays at or\n" +
+			"above --threshold, for cron jobs and systemd timers to alert on. It exits\n" +
+			"with status 0 if it does, 2 if it doesn't, and 1 if the check couldn't run.",
+		Example: "  currentz check --threshold 500 --days 60 || notify-send \"Cash flow alert\"",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if days < 1 || days > maxForecastDays {
+				return fmt.Errorf("--days must be between 1 and %d", maxForecastDays)
+			}
+			return cobra.NoArgs(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.CheckForecast(days, threshold, asJSON)
+		},
+	}
+	cmd.Flags().IntVar(&days, "days", 90, "number of days, today included")
+	cmd.Flags().Float64Var(&threshold, "threshold", 0, "lowest acceptable balance")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the result as JSON")
+	return cmd
+}
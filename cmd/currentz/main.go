@@ -1,29 +1,186 @@
 package main
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 
+	"github.com/spf13/cobra"
+
+	"github.com/jdelles/currentz/internal/amount"
 	"github.com/jdelles/currentz/internal/app"
+	"github.com/jdelles/currentz/internal/buildinfo"
 	"github.com/jdelles/currentz/internal/config"
 )
 
+// cli holds the app shared by every subcommand. It is opened before a
+// command runs, so help and usage errors don't need a database.
+type cli struct {
+	app *app.FinanceApp
+
+	configPath string
+	remote     string
+	apiKey     string
+	noColor    bool
+	demo       bool
+	dryRun     bool
+}
+
+// exitBelowThreshold is the status check exits with when the forecast
+// drops below the threshold, apart from 1 for everything else going wrong.
+const exitBelowThreshold = 2
+
 func main() {
-	cfg, err := config.Load()
+	c := &cli{}
+	err := newRootCmd(c).Execute()
+	c.close()
+	if errors.Is(err, app.ErrBelowThreshold) {
+		// check has already said so.
+		os.Exit(exitBelowThreshold)
+	}
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		log.Fatalf("Error: %v", err)
 	}
+}
 
-	financeApp, err := app.NewFinanceApp(cfg)
-	if err != nil {
-		log.Fatalf("Failed to initialize app: %v", err)
+func newRootCmd(c *cli) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "currentz",
+		Short: "Personal cash flow forecaster",
+		Long: "Currentz forecasts your balance from one-off and recurring transactions.\n" +
+			"Run it without a command for the interactive menu.",
+		Version:       buildinfo.Get().String(),
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Cobra checks required flags after this hook; check them
+			// first so a usage mistake doesn't need a database.
+			if err := cmd.ValidateRequiredFlags(); err != nil {
+				return err
+			}
+			app.SetColor(!c.noColor)
+			return c.open(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.Run()
+		},
 	}
-	defer func() {
-		if err := financeApp.Close(); err != nil {
-			log.Printf("shutdown error: (%T) %v", err, err)
+	root.PersistentFlags().StringVar(&c.configPath, "config", "",
+		"config file (default $CURRENTZ_CONFIG, or "+config.DefaultPath()+")")
+	root.PersistentFlags().StringVar(&c.remote, "remote", "",
+		"manage a Currentz server through its HTTP API, e.g. https://host:8080 ($CURRENTZ_REMOTE overrides)")
+	root.PersistentFlags().StringVar(&c.apiKey, "api-key", "",
+		"API key for --remote ($CURRENTZ_API_KEY overrides)")
+	root.PersistentFlags().BoolVar(&c.demo, "demo", false,
+		"explore with made-up sample data kept in memory; nothing is saved ($CURRENTZ_DEMO overrides)")
+	root.PersistentFlags().BoolVar(&c.noColor, "no-color", false,
+		"print plain text without colors (also off when NO_COLOR is set or output isn't a terminal)")
+	root.PersistentFlags().BoolVar(&c.dryRun, "dry-run", false,
+		"check and report what a command would change without saving any of it")
+	root.CompletionOptions.DisableDefaultCmd = true
+	root.SetVersionTemplate("currentz {{.Version}}\n")
+	// Usage is only worth printing when the command line itself is wrong.
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return fmt.Errorf("%w\n\n%s", err, cmd.UsageString())
+	})
+
+	root.AddCommand(
+		newTUICmd(c),
+		newTxCmd(c),
+		newForecastCmd(c),
+		newCheckCmd(c),
+		newRecurringCmd(c),
+		newImportRecurringCmd(c),
+		newImportTransactionsCmd(c),
+		newExportTransactionsCmd(c),
+		newBackupCmd(c),
+		newRestoreCmd(c),
+		newSeedCmd(c),
+		newUserCmd(c),
+	)
+	c.allowDryRun(root)
+	return root
+}
+
+// allowDryRun makes cmd and its subcommands run inside the app's DryRun
+// when --dry-run is set.
+func (c *cli) allowDryRun(cmd *cobra.Command) {
+	for _, sub := range cmd.Commands() {
+		c.allowDryRun(sub)
+	}
+	run := cmd.RunE
+	if run == nil {
+		return
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if !c.dryRun {
+			return run(cmd, args)
 		}
-	}()
+		err := c.app.DryRun(func() error { return run(cmd, args) })
+		if err == nil {
+			fmt.Fprintln(os.Stderr, "Dry run: nothing was saved.")
+		}
+		return err
+	}
+}
+
+func (c *cli) open(cmd *cobra.Command) error {
+	flags := config.Flags{}
+	if cmd.Flags().Changed("remote") {
+		flags["remote.url"] = c.remote
+	}
+	if cmd.Flags().Changed("api-key") {
+		flags["remote.api_key"] = c.apiKey
+	}
+	if cmd.Flags().Changed("demo") {
+		flags["demo"] = strconv.FormatBool(c.demo)
+	}
+	cfg, err := config.Load(c.configPath, flags)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	amount.SetDefault(cfg.Locale)
 
-	if err := financeApp.Run(); err != nil {
-		log.Fatalf("Application error: %v", err)
+	c.app, err = app.NewFinanceApp(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize app: %w", err)
+	}
+	if cfg.Demo {
+		fmt.Fprintln(os.Stderr, "Demo mode: sample data only, kept in memory. Changes are lost on exit.")
 	}
+	return nil
+}
+
+func (c *cli) close() {
+	if c.app == nil {
+		return
+	}
+	if err := c.app.Close(); err != nil {
+		log.Printf("shutdown error: (%T) %v", err, err)
+	}
+	c.app = nil
+}
+
+// backupPassphrase picks the passphrase from the flag, then the environment,
+// then asks.
+func backupPassphrase(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if env := os.Getenv("CURRENTZ_BACKUP_PASSPHRASE"); env != "" {
+		return env
+	}
+	return prompt("Backup passphrase: ")
+}
+
+func prompt(label string) string {
+	fmt.Print(label)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	return strings.TrimSpace(scanner.Text())
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+func newRecurringCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recurring",
+		Short: "Add and list recurring transactions",
+	}
+	cmd.AddCommand(newRecurringAddCmd(c), newRecurringListCmd(c))
+	return cmd
+}
+
+func newRecurringAddCmd(c *cli) *cobra.Command {
+	// Flags map onto the import file's columns so both follow the same rules.
+	fields := map[string]*string{}
+	var prorate, businessDay bool
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Create a recurring income or expense",
+		Example: "  currentz recurring add --desc Rent --type expense --amount 1200 --interval monthly --start 2025-01-01 --day 1\n" +
+			"  currentz recurring add --desc Paycheck --type income --amount 2500 --interval biweekly --start 2025-01-03 --day fri\n" +
+			"  currentz recurring add --desc \"Laptop installments\" --type expense --amount 150 --interval monthly --start 2025-02-01 --times 12\n" +
+			"  currentz recurring add --desc Salary --type income --amount 4200 --interval monthly --day 31 --business-day\n" +
+			"  currentz recurring add --desc \"Book club\" --type expense --amount 15 --interval monthly --week 2 --day fri\n" +
+			"  currentz recurring add --desc \"Estimated tax\" --type expense --amount 900 --rrule \"FREQ=YEARLY;BYMONTH=1,4,6,9;BYMONTHDAY=15\"\n" +
+			"  currentz recurring add --desc Netflix --type expense --amount 15.49 --interval monthly --day 12 --category streaming --account 2",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			row := make(map[string]string, len(fields)+1)
+			for column, v := range fields {
+				row[column] = *v
+			}
+			row["prorate"] = strconv.FormatBool(prorate)
+			row["business_day"] = strconv.FormatBool(businessDay)
+			in, err := service.ParseRecurringRow(row)
+			if err != nil {
+				return err
+			}
+			return c.app.AddRecurring(in)
+		},
+	}
+	f := cmd.Flags()
+	flag := func(name, column, usage string) {
+		fields[column] = f.String(name, "", usage)
+	}
+	flag("desc", "description", "description")
+	flag("type", "type", "income or expense")
+	flag("amount", "amount", "amount, in the locale's format")
+	flag("interval", "interval", "weekly, biweekly, monthly or yearly")
+	flag("rrule", "rrule", "iCalendar RRULE to follow instead of --interval, e.g. FREQ=MONTHLY;BYDAY=2TU")
+	flag("start", "start_date", "first occurrence (YYYY-MM-DD, default today)")
+	flag("end", "end_date", "last possible occurrence (YYYY-MM-DD)")
+	flag("times", "occurrence_count", "stop after this many occurrences, e.g. 12 for an installment plan")
+	flag("day", "day", "weekday for weekly/biweekly and with --week (e.g. fri), day of month otherwise")
+	flag("week", "week_of_month", "monthly on the nth --day of the month: 1-4 or last")
+	flag("currency", "currency", "currency code (default base currency)")
+	flag("category", "category", "category given to each occurrence")
+	flag("account", "account_id", "account ID charged, e.g. a credit card (default cash)")
+	f.BoolVar(&businessDay, "business-day", false, "move a monthly occurrence on a weekend back to the Friday before")
+	f.BoolVar(&prorate, "prorate", false, "spread a yearly bill over the months before it is due")
+	cmd.MarkFlagsOneRequired("interval", "rrule")
+	for _, name := range []string{"desc", "type", "amount"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
+
+func newRecurringListCmd(c *cli) *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recurring transactions with their next occurrence",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.ListRecurring(asJSON)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print JSON")
+	return cmd
+}
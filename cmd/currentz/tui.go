@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newTUICmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "tui",
+		Short: "Open the full-screen dashboard",
+		Long: "Open a full-screen dashboard with scrollable transaction and recurring\n" +
+			"tables and a forecast chart. Press a to add, d to delete, tab to switch\n" +
+			"panels and q to quit.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.RunTUI()
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdelles/currentz/internal/amount"
+	"github.com/jdelles/currentz/internal/app"
+)
+
+func newTxCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tx",
+		Short: "Add and list one-off transactions",
+	}
+	cmd.AddCommand(newTxAddCmd(c), newTxListCmd(c))
+	return cmd
+}
+
+func newTxAddCmd(c *cli) *cobra.Command {
+	var (
+		typ, amt, date, desc string
+		currency, category   string
+		account              int32
+	)
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Record an income or expense",
+		Example: "  currentz tx add --amount 42.50 --date 2025-06-01 --desc groceries --category food\n" +
+			"  currentz tx add --type income --amount 2500 --date 2025-06-15 --desc payday",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := app.TransactionInput{
+				Type:        strings.ToLower(typ),
+				Description: desc,
+				Currency:    currency,
+				Category:    category,
+			}
+			var err error
+			if in.Amount, err = amount.Parse(amt); err != nil {
+				return err
+			}
+			if in.Date, err = c.app.ParseDate(date); err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("account") {
+				in.AccountID = &account
+			}
+			return c.app.AddTransaction(in)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&typ, "type", "expense", "income or expense")
+	f.StringVar(&amt, "amount", "", "amount, in the locale's format")
+	f.StringVar(&date, "date", "", "date (YYYY-MM-DD, MM/DD/YYYY, today, +3d...)")
+	f.StringVar(&desc, "desc", "", "description")
+	f.StringVar(&currency, "currency", "", "currency code (default base currency)")
+	f.StringVar(&category, "category", "", "expense category")
+	f.Int32Var(&account, "account", 0, "account ID charged, e.g. a credit card (default cash)")
+	_ = cmd.MarkFlagRequired("amount")
+	_ = cmd.MarkFlagRequired("date")
+	_ = cmd.MarkFlagRequired("desc")
+	return cmd
+}
+
+func newTxListCmd(c *cli) *cobra.Command {
+	var (
+		from, to string
+		asJSON   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recorded and recurring transactions in a date range",
+		Long:  "List recorded and recurring transactions between --from and --to inclusive.\nThe range defaults to 30 days either side of today.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var start, end time.Time
+			var err error
+			if from != "" {
+				if start, err = c.app.ParseDate(from); err != nil {
+					return err
+				}
+			}
+			if to != "" {
+				if end, err = c.app.ParseDate(to); err != nil {
+					return err
+				}
+			}
+			return c.app.ListTransactions(start, end, asJSON)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&from, "from", "", "first day (YYYY-MM-DD)")
+	f.StringVar(&to, "to", "", "last day (YYYY-MM-DD)")
+	f.BoolVar(&asJSON, "json", false, "print JSON")
+	return cmd
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdelles/currentz/internal/household"
+)
+
+func newUserCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Add and list users who can log in to the server",
+	}
+	cmd.AddCommand(newUserAddCmd(c), newUserListCmd(c))
+	return cmd
+}
+
+func newUserAddCmd(c *cli) *cobra.Command {
+	var password, role string
+	var householdID int32
+	cmd := &cobra.Command{
+		Use:   "add <username>",
+		Short: "Create a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.AddUser(args[0], userPassword(password), role, householdID)
+		},
+	}
+	cmd.Flags().StringVar(&password, "password", "", "password (default $CURRENTZ_PASSWORD, else prompt)")
+	cmd.Flags().StringVar(&role, "role", "", "owner, editor or viewer (default owner for the first user, viewer after)")
+	cmd.Flags().Int32Var(&householdID, "household", household.Default, "ID of the household the user joins")
+	return cmd
+}
+
+func newUserListCmd(c *cli) *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List users",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.app.ListUsers(asJSON)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print JSON instead of a table")
+	return cmd
+}
+
+// userPassword picks the password from the flag, then the environment,
+// then asks.
+func userPassword(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if env := os.Getenv("CURRENTZ_PASSWORD"); env != "" {
+		return env
+	}
+	return prompt("Password: ")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"slices"
+
+	"github.com/jdelles/currentz/internal/api"
+	"github.com/jdelles/currentz/internal/config"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// reloader applies config file changes the server can take without a
+// restart: the CORS origins and the setting defaults, which include the
+// notification thresholds. Each change is recorded in the audit log.
+type reloader struct {
+	svc    *service.FinanceService
+	server *api.APIServer
+	// current is what the server runs with.
+	current *config.Server
+}
+
+func (r *reloader) reload(next *config.Server, err error) {
+	if err != nil {
+		log.Printf("Config file changed but can't be loaded; keeping the current settings: %v", err)
+		return
+	}
+	ctx := context.Background()
+	cur := r.current
+
+	if !slices.Equal(cur.CORSOrigins, next.CORSOrigins) {
+		r.server.SetCORSOrigins(next.CORSOrigins)
+		r.svc.RecordConfigChange(ctx, "server.cors_origins", cur.CORSOrigins, next.CORSOrigins)
+		log.Printf("Reloaded server.cors_origins: %v", next.CORSOrigins)
+		cur.CORSOrigins = next.CORSOrigins
+	}
+
+	if !reflect.DeepEqual(cur.Defaults, next.Defaults) {
+		if err := r.svc.SetSettingDefaults(next.Defaults); err != nil {
+			log.Printf("Config file changed but its setting defaults are invalid; keeping the current ones: %v", err)
+		} else {
+			r.svc.RecordConfigChange(ctx, "defaults", cur.Defaults, next.Defaults)
+			log.Println("Reloaded the setting defaults")
+			cur.Defaults = next.Defaults
+		}
+	}
+
+	// Anything else left different only takes effect on a restart.
+	rest := *next
+	rest.CORSOrigins, rest.Defaults = cur.CORSOrigins, cur.Defaults
+	if !reflect.DeepEqual(*cur, rest) {
+		log.Println("Config file changed settings that take effect when the server restarts")
+	}
+}
@@ -0,0 +1,194 @@
+// Package amount parses user-entered money amounts according to a locale's
+// decimal and grouping separators.
+package amount
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Locale describes how amounts are written: "1,234.56" in en-US is
+// "1.234,56" in de-DE.
+type Locale struct {
+	Name     string
+	Decimal  rune
+	Grouping rune
+}
+
+// Example renders 1234.56 in the locale, for error messages.
+func (l Locale) Example() string {
+	return fmt.Sprintf("1%c234%c56", l.Grouping, l.Decimal)
+}
+
+var (
+	USLocale     = Locale{Name: "en-US", Decimal: '.', Grouping: ','}
+	GermanLocale = Locale{Name: "de-DE", Decimal: ',', Grouping: '.'}
+	FrenchLocale = Locale{Name: "fr-FR", Decimal: ',', Grouping: ' '}
+	SwissLocale  = Locale{Name: "de-CH", Decimal: '.', Grouping: '\''}
+)
+
+// locales maps lower-cased locale names and language tags to their
+// separators. Bare language codes pick the most common convention.
+var locales = map[string]Locale{
+	"en":    USLocale,
+	"en-us": USLocale,
+	"en-gb": {Name: "en-GB", Decimal: '.', Grouping: ','},
+	"en-ca": {Name: "en-CA", Decimal: '.', Grouping: ','},
+	"en-au": {Name: "en-AU", Decimal: '.', Grouping: ','},
+	"de":    GermanLocale,
+	"de-de": GermanLocale,
+	"de-at": {Name: "de-AT", Decimal: ',', Grouping: '.'},
+	"de-ch": SwissLocale,
+	"es":    {Name: "es-ES", Decimal: ',', Grouping: '.'},
+	"es-es": {Name: "es-ES", Decimal: ',', Grouping: '.'},
+	"it":    {Name: "it-IT", Decimal: ',', Grouping: '.'},
+	"it-it": {Name: "it-IT", Decimal: ',', Grouping: '.'},
+	"nl":    {Name: "nl-NL", Decimal: ',', Grouping: '.'},
+	"nl-nl": {Name: "nl-NL", Decimal: ',', Grouping: '.'},
+	"pt-br": {Name: "pt-BR", Decimal: ',', Grouping: '.'},
+	"fr":    FrenchLocale,
+	"fr-fr": FrenchLocale,
+	"fr-ca": {Name: "fr-CA", Decimal: ',', Grouping: ' '},
+}
+
+// LookupLocale resolves a locale name such as "de-DE", "de_DE.UTF-8" or
+// "fr". An empty name returns USLocale.
+func LookupLocale(name string) (Locale, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if i := strings.IndexAny(key, ".@"); i >= 0 {
+		key = key[:i]
+	}
+	key = strings.ReplaceAll(key, "_", "-")
+	if key == "" || key == "c" || key == "posix" {
+		return USLocale, nil
+	}
+	if loc, ok := locales[key]; ok {
+		return loc, nil
+	}
+	if i := strings.Index(key, "-"); i > 0 {
+		if loc, ok := locales[key[:i]]; ok {
+			return loc, nil
+		}
+	}
+	return Locale{}, fmt.Errorf("unsupported locale %q", name)
+}
+
+var (
+	mu  sync.RWMutex
+	def = USLocale
+)
+
+// SetDefault changes the locale used by Parse. Call it once at startup from
+// configuration.
+func SetDefault(l Locale) {
+	mu.Lock()
+	def = l
+	mu.Unlock()
+}
+
+// Default returns the locale used by Parse.
+func Default() Locale {
+	mu.RLock()
+	defer mu.RUnlock()
+	return def
+}
+
+// ParseError explains why an amount was rejected.
+type ParseError struct {
+	Input  string
+	Locale Locale
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid amount %q: %s (expected a format like %s for %s)",
+		e.Input, e.Reason, e.Locale.Example(), e.Locale.Name)
+}
+
+// Parse reads an amount using the default locale.
+func Parse(s string) (float64, error) {
+	return ParseLocale(s, Default())
+}
+
+// ParseLocale reads an amount such as "1,234.56", "-$40" or "(12.00)"
+// written in loc. Grouping separators are optional but must split the
+// integer part into groups of three. Input that would read differently in
+// another locale is rejected rather than guessed at: a second decimal
+// separator, a grouping separator after the decimal, or more than two
+// decimal places ("1.234" in en-US is probably 1234 written in de-DE).
+func ParseLocale(s string, loc Locale) (float64, error) {
+	fail := func(reason string) (float64, error) {
+		return 0, &ParseError{Input: s, Locale: loc, Reason: reason}
+	}
+
+	v := strings.TrimSpace(s)
+	if loc.Grouping == ' ' {
+		v = strings.NewReplacer("\u00a0", " ", "\u202f", " ").Replace(v)
+	}
+	neg := false
+	if strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")") {
+		neg, v = true, strings.TrimSpace(v[1:len(v)-1])
+	}
+	if strings.HasPrefix(v, "-") {
+		neg, v = !neg, strings.TrimSpace(v[1:])
+	} else if strings.HasPrefix(v, "+") {
+		v = strings.TrimSpace(v[1:])
+	}
+	v = strings.TrimSpace(strings.Trim(v, "$€£¥"))
+	if v == "" {
+		return fail("empty")
+	}
+
+	intPart, frac := v, ""
+	if i := strings.IndexRune(v, loc.Decimal); i >= 0 {
+		intPart, frac = v[:i], v[i+len(string(loc.Decimal)):]
+		if strings.ContainsRune(frac, loc.Decimal) {
+			return fail("more than one decimal separator")
+		}
+		if strings.ContainsRune(frac, loc.Grouping) {
+			return fail("grouping separator after the decimal separator")
+		}
+		if frac == "" || !allDigits(frac) {
+			return fail("bad digits after the decimal separator")
+		}
+		if len(frac) > 2 {
+			return fail("more than two decimal places")
+		}
+	}
+
+	if intPart == "" {
+		intPart = "0"
+	}
+	groups := strings.Split(intPart, string(loc.Grouping))
+	for i, g := range groups {
+		if !allDigits(g) {
+			return fail("unexpected characters")
+		}
+		if len(groups) > 1 && ((i == 0 && (len(g) == 0 || len(g) > 3)) || (i > 0 && len(g) != 3)) {
+			return fail("digit groups must have three digits")
+		}
+	}
+
+	f, err := strconv.ParseFloat(strings.Join(groups, "")+"."+frac+"0", 64)
+	if err != nil {
+		return fail("unexpected characters")
+	}
+	if neg {
+		f = -f
+	}
+	return f, nil
+}
+
+func allDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
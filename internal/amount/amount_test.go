@@ -0,0 +1,72 @@
+package amount
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		in   string
+		loc  Locale
+		want float64
+	}{
+		{"1,234.56", USLocale, 1234.56},
+		{"1234.5", USLocale, 1234.5},
+		{"-$40", USLocale, -40},
+		{"(12.00)", USLocale, -12},
+		{"1,000,000", USLocale, 1000000},
+		{".75", USLocale, 0.75},
+		{"1.234,56", GermanLocale, 1234.56},
+		{"1.234", GermanLocale, 1234},
+		{"12,5 €", GermanLocale, 12.5},
+		{"1 234,56", FrenchLocale, 1234.56},
+		{"1 234,56", FrenchLocale, 1234.56},
+		{"1'234.56", SwissLocale, 1234.56},
+	}
+	for _, tt := range tests {
+		got, err := ParseLocale(tt.in, tt.loc)
+		require.NoError(t, err, tt.in)
+		assert.InDelta(t, tt.want, got, 1e-9, tt.in)
+	}
+}
+
+func TestParseLocaleRejectsAmbiguousInput(t *testing.T) {
+	tests := []struct {
+		in     string
+		loc    Locale
+		reason string
+	}{
+		{"1.234,56", USLocale, "grouping separator after the decimal separator"},
+		{"1.234", USLocale, "more than two decimal places"},
+		{"12,50", USLocale, "digit groups must have three digits"},
+		{"1,234.56", GermanLocale, "grouping separator after the decimal separator"},
+		{"1.5", GermanLocale, "digit groups must have three digits"},
+		{"1.2.3", USLocale, "more than one decimal separator"},
+		{"abc", USLocale, "unexpected characters"},
+		{"", USLocale, "empty"},
+	}
+	for _, tt := range tests {
+		_, err := ParseLocale(tt.in, tt.loc)
+		var pe *ParseError
+		require.ErrorAs(t, err, &pe, tt.in)
+		assert.Equal(t, tt.reason, pe.Reason, tt.in)
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	for name, want := range map[string]string{
+		"":            "en-US",
+		"de_DE.UTF-8": "de-DE",
+		"fr-BE":       "fr-FR",
+		"en_GB":       "en-GB",
+	} {
+		loc, err := LookupLocale(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, loc.Name, name)
+	}
+	_, err := LookupLocale("xx-YY")
+	assert.Error(t, err)
+}
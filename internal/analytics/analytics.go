@@ -0,0 +1,240 @@
+// Package analytics summarizes spending: how it moves from one month to
+// the next, where it goes, and how much of it is committed in advance by
+// recurring bills. It works on amounts already in one currency and knows
+// nothing of the database or exchange rates; the service layer gathers and
+// converts the data first.
+package analytics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// Top is how many growing categories and one-off expenses Summarize lists.
+const Top = 5
+
+// Transaction is a recorded or recurring amount. Amount is negative for
+// expenses.
+type Transaction struct {
+	Date        time.Time
+	Amount      money.Money
+	Category    string
+	Description string
+}
+
+// Input is what Summarize works from.
+type Input struct {
+	// Month is any day in the month to summarize.
+	Month time.Time
+	// Through is the last day counted. When it falls inside Month, the
+	// month is still under way and is compared with the same days of the
+	// month before. A zero Through counts the whole month.
+	Through time.Time
+	// Transactions are the recorded transactions of Month and the month
+	// before. Others are ignored.
+	Transactions []Transaction
+	// Recurring are the occurrences of recurring schedules in Month.
+	Recurring []Transaction
+}
+
+// Trend compares this month with the last. Percent is nil when there was
+// nothing last month to compare with.
+type Trend struct {
+	Current  float64  `json:"current"`
+	Previous float64  `json:"previous"`
+	Change   float64  `json:"change"`
+	Percent  *float64 `json:"percent"`
+}
+
+// CategoryTrend is how spending in one category moved.
+type CategoryTrend struct {
+	Category string `json:"category"`
+	Trend
+}
+
+// Expense is a single recorded expense, Amount positive.
+type Expense struct {
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Amount      float64   `json:"amount"`
+}
+
+// Insights summarizes a month. Spending and money out are positive.
+type Insights struct {
+	Month string `json:"month"`
+	// Complete is false while the month is still under way; Days is then
+	// how many of its days are counted.
+	Complete bool  `json:"complete"`
+	Days     int   `json:"days"`
+	Spending Trend `json:"spending"`
+	Income   Trend `json:"income"`
+	// AverageDailySpend is Spending.Current over Days.
+	AverageDailySpend float64 `json:"average_daily_spend"`
+	// GrowingCategories are the categories whose spending rose most,
+	// largest rise first. Uncategorized spending is left out.
+	GrowingCategories []CategoryTrend `json:"growing_categories"`
+	// BiggestOneOffs are the largest expenses not matching, by
+	// description, a recurring that falls in the month.
+	BiggestOneOffs []Expense `json:"biggest_one_off_expenses"`
+	// RecurringCosts is what recurring expenses come to over the counted
+	// days; RecurringShareOfIncome is that as a percentage of
+	// Income.Current, nil when there was no income.
+	RecurringCosts         float64  `json:"recurring_costs"`
+	RecurringShareOfIncome *float64 `json:"recurring_percent_of_income"`
+}
+
+// Summarize works out the Insights for in.Month.
+func Summarize(in Input) Insights {
+	first := time.Date(in.Month.Year(), in.Month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	through := last
+	if !in.Through.IsZero() && in.Through.Before(last) {
+		through = day(in.Through)
+	}
+	complete := through.Equal(last)
+
+	prevFirst := first.AddDate(0, -1, 0)
+	prevThrough := first.AddDate(0, 0, -1)
+	if !complete {
+		// Compare like with like: the same days of last month.
+		if d := prevFirst.AddDate(0, 0, through.Day()-1); d.Before(prevThrough) {
+			prevThrough = d
+		}
+	}
+
+	type sums struct {
+		name             string
+		current, earlier money.Money
+	}
+	var (
+		spending, income sums
+		categories       []*sums
+		expenses         []Transaction
+	)
+	index := make(map[string]*sums)
+	for _, tx := range in.Transactions {
+		d := day(tx.Date)
+		var current bool
+		switch {
+		case !d.Before(first) && !d.After(through):
+			current = true
+		case !d.Before(prevFirst) && !d.After(prevThrough):
+		default:
+			continue
+		}
+		add := func(s *sums, amt money.Money) {
+			if current {
+				s.current = s.current.Add(amt)
+			} else {
+				s.earlier = s.earlier.Add(amt)
+			}
+		}
+		if tx.Amount >= 0 {
+			add(&income, tx.Amount)
+			continue
+		}
+		spent := tx.Amount.Neg()
+		add(&spending, spent)
+		if current {
+			expenses = append(expenses, tx)
+		}
+		name := strings.TrimSpace(tx.Category)
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		line, ok := index[key]
+		if !ok {
+			line = &sums{name: name}
+			index[key] = line
+			categories = append(categories, line)
+		}
+		add(line, spent)
+	}
+
+	days := int(through.Sub(first).Hours()/24) + 1
+	out := Insights{
+		Month:             first.Format("2006-01"),
+		Complete:          complete,
+		Days:              days,
+		Spending:          trend(spending.current, spending.earlier),
+		Income:            trend(income.current, income.earlier),
+		AverageDailySpend: spending.current.Mul(1 / float64(days)).Float64(),
+		GrowingCategories: []CategoryTrend{},
+		BiggestOneOffs:    []Expense{},
+	}
+
+	for _, c := range categories {
+		if c.current > c.earlier {
+			out.GrowingCategories = append(out.GrowingCategories, CategoryTrend{Category: c.name, Trend: trend(c.current, c.earlier)})
+		}
+	}
+	sort.SliceStable(out.GrowingCategories, func(i, j int) bool {
+		a, b := out.GrowingCategories[i], out.GrowingCategories[j]
+		if a.Change != b.Change {
+			return a.Change > b.Change
+		}
+		return strings.ToLower(a.Category) < strings.ToLower(b.Category)
+	})
+	if len(out.GrowingCategories) > Top {
+		out.GrowingCategories = out.GrowingCategories[:Top]
+	}
+
+	recurring := make(map[string]bool)
+	var committed money.Money
+	for _, r := range in.Recurring {
+		recurring[strings.ToLower(strings.TrimSpace(r.Description))] = true
+		if d := day(r.Date); r.Amount < 0 && !d.Before(first) && !d.After(through) {
+			committed = committed.Add(r.Amount.Neg())
+		}
+	}
+	sort.SliceStable(expenses, func(i, j int) bool { return expenses[i].Amount < expenses[j].Amount })
+	for _, tx := range expenses {
+		if len(out.BiggestOneOffs) == Top {
+			break
+		}
+		if recurring[strings.ToLower(strings.TrimSpace(tx.Description))] {
+			continue
+		}
+		out.BiggestOneOffs = append(out.BiggestOneOffs, Expense{
+			Date:        day(tx.Date),
+			Description: tx.Description,
+			Category:    strings.TrimSpace(tx.Category),
+			Amount:      tx.Amount.Neg().Float64(),
+		})
+	}
+
+	out.RecurringCosts = committed.Float64()
+	if income.current > 0 {
+		out.RecurringShareOfIncome = percent(committed, income.current)
+	}
+	return out
+}
+
+func trend(current, previous money.Money) Trend {
+	t := Trend{
+		Current:  current.Float64(),
+		Previous: previous.Float64(),
+		Change:   current.Sub(previous).Float64(),
+	}
+	if previous != 0 {
+		t.Percent = percent(current.Sub(previous), previous)
+	}
+	return t
+}
+
+// percent is part as a percentage of whole, to one decimal place.
+func percent(part, whole money.Money) *float64 {
+	p := math.Round(float64(part)/float64(whole)*1000) / 10
+	return &p
+}
+
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
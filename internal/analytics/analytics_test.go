@@ -0,0 +1,99 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jdelles/currentz/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestSummarize(t *testing.T) {
+	tx := func(d string, amount float64, category, description string) Transaction {
+		return Transaction{Date: date(t, d), Amount: money.FromFloat(amount), Category: category, Description: description}
+	}
+	in := Input{
+		Month: date(t, "2025-09-15"),
+		Transactions: []Transaction{
+			tx("2025-08-03", -100, "Groceries", "Market"),
+			tx("2025-08-10", -50, "Dining", "Cafe"),
+			tx("2025-08-20", -1200, "Housing", "Rent"),
+			tx("2025-08-31", 3000, "", "Salary"),
+			tx("2025-09-02", -250, "groceries", "Market"),
+			tx("2025-09-05", -90, "Dining", "Cafe"),
+			tx("2025-09-12", -1200, "Housing", "Rent"),
+			tx("2025-09-18", -800, "", "Laptop"),
+			tx("2025-09-30", 4000, "", "Salary"),
+			tx("2025-07-30", -999, "Dining", "Too early"),
+		},
+		Recurring: []Transaction{
+			tx("2025-09-12", -1200, "", "rent"),
+			tx("2025-09-30", 4000, "", "Salary"),
+		},
+	}
+
+	got := Summarize(in)
+	assert.Equal(t, "2025-09", got.Month)
+	assert.True(t, got.Complete)
+	assert.Equal(t, 30, got.Days)
+	assert.Equal(t, 2340.0, got.Spending.Current)
+	assert.Equal(t, 1350.0, got.Spending.Previous)
+	assert.Equal(t, 990.0, got.Spending.Change)
+	require.NotNil(t, got.Spending.Percent)
+	assert.Equal(t, 73.3, *got.Spending.Percent)
+	assert.Equal(t, 78.0, got.AverageDailySpend)
+	assert.Equal(t, 1000.0, got.Income.Change)
+
+	require.Len(t, got.GrowingCategories, 2, "housing didn't grow; uncategorized is left out")
+	assert.Equal(t, "Groceries", got.GrowingCategories[0].Category, "categories match in any case")
+	assert.Equal(t, 150.0, got.GrowingCategories[0].Change)
+	assert.Equal(t, "Dining", got.GrowingCategories[1].Category)
+
+	require.Len(t, got.BiggestOneOffs, 3, "rent matches a recurring")
+	assert.Equal(t, "Laptop", got.BiggestOneOffs[0].Description)
+	assert.Equal(t, 800.0, got.BiggestOneOffs[0].Amount)
+	assert.Equal(t, "Market", got.BiggestOneOffs[1].Description)
+
+	assert.Equal(t, 1200.0, got.RecurringCosts)
+	require.NotNil(t, got.RecurringShareOfIncome)
+	assert.Equal(t, 30.0, *got.RecurringShareOfIncome)
+}
+
+func TestSummarizeMonthUnderWay(t *testing.T) {
+	in := Input{
+		Month:   date(t, "2025-03-01"),
+		Through: date(t, "2025-03-10"),
+		Transactions: []Transaction{
+			{Date: date(t, "2025-02-05"), Amount: money.FromFloat(-40)},
+			{Date: date(t, "2025-02-20"), Amount: money.FromFloat(-500), Description: "Later in February"},
+			{Date: date(t, "2025-03-04"), Amount: money.FromFloat(-60)},
+		},
+		Recurring: []Transaction{
+			{Date: date(t, "2025-03-20"), Amount: money.FromFloat(-100), Description: "Not yet"},
+		},
+	}
+	got := Summarize(in)
+	assert.False(t, got.Complete)
+	assert.Equal(t, 10, got.Days)
+	assert.Equal(t, 40.0, got.Spending.Previous, "only February 1-10")
+	assert.Equal(t, 6.0, got.AverageDailySpend)
+	assert.Equal(t, 0.0, got.RecurringCosts)
+	assert.Nil(t, got.RecurringShareOfIncome, "no income")
+	assert.Nil(t, got.Income.Percent)
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	got := Summarize(Input{Month: date(t, "2025-02-10")})
+	assert.Equal(t, 28, got.Days)
+	assert.NotNil(t, got.GrowingCategories)
+	assert.NotNil(t, got.BiggestOneOffs)
+	assert.Nil(t, got.Spending.Percent)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// SetAPIKey requires every request to carry key, either as
+// "Authorization: Bearer <key>" or in an X-API-Key header. An empty key
+// leaves the API open, which is only safe when it isn't reachable from
+// other machines. A logged-in user's access token works in place of the
+// key.
+func (s *APIServer) SetAPIKey(key string) {
+	s.apiKey = key
+}
+
+// requestAPIKey returns the key a request was sent with, if any.
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return bearerToken(r)
+}
+
+// apiKeyMiddleware refuses requests without the configured API key, or a
+// scoped key whose scopes allow them. Preflight requests carry no
+// credentials and always go through, as do the dashboard's static files;
+// the dashboard asks for the key itself. So do requests from a logged-in
+// user, and the requests that log in.
+func (s *APIServer) apiKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, loggedIn := service.UserFromContext(r.Context())
+		if (s.apiKey == "" && len(s.scopedKeys) == 0) || loggedIn || authPaths[r.URL.Path] ||
+			r.Method == http.MethodOptions || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := requestAPIKey(r)
+		if s.apiKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.apiKey)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		scopes, ok := s.keyScopes(key)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="currentz"`)
+			s.writeError(w, http.StatusUnauthorized, "Missing or invalid API key")
+			return
+		}
+		if need := requiredScope(r); need != "" && !scopes.allow(need) {
+			s.writeError(w, http.StatusForbidden, fmt.Sprintf("This API key doesn't have the %s scope", need))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
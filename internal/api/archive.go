@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ArchiveResponse says what POST /api/archive/run moved.
+type ArchiveResponse struct {
+	Archived int64 `json:"archived"`
+	// Before is the cutoff: transactions dated before it were archived.
+	Before string `json:"before"`
+}
+
+// handleListArchived lists archived transactions, optionally only those
+// dated from start to end.
+func (s *APIServer) handleListArchived(w http.ResponseWriter, r *http.Request) {
+	var start, end *time.Time
+	if v := r.URL.Query().Get("start"); v != "" {
+		d, err := s.parseDay(r, v)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start date: %s", err.Error()))
+			return
+		}
+		start = &d
+	}
+	if v := r.URL.Query().Get("end"); v != "" {
+		d, err := s.parseDay(r, v)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end date: %s", err.Error()))
+			return
+		}
+		end = &d
+	}
+	if start != nil && end != nil && end.Before(*start) {
+		s.writeError(w, http.StatusBadRequest, "end must not be before start")
+		return
+	}
+	txs, err := s.financeService.ListArchivedTransactions(r.Context(), start, end)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, txs)
+}
+
+// handleRunArchival archives what has outlived the retention period now,
+// rather than waiting for the hourly job.
+func (s *APIServer) handleRunArchival(w http.ResponseWriter, r *http.Request) {
+	cutoff, ok := s.financeService.ArchiveCutoff(r.Context())
+	if !ok {
+		s.writeError(w, http.StatusConflict, "No retention period is set (set retention_years in /api/settings)")
+		return
+	}
+	n, err := s.financeService.ArchiveTransactions(r.Context(), cutoff)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, ArchiveResponse{Archived: n, Before: cutoff.Format("2006-01-02")})
+}
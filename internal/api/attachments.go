@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// handleAddAttachment stores the "file" field of a multipart upload with a
+// transaction, e.g. curl -F file=@receipt.jpg.
+func (s *APIServer) handleAddAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Expected a multipart/form-data upload with a 'file' field")
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			s.writeError(w, http.StatusBadRequest, "Upload has no 'file' field")
+			return
+		}
+		if err != nil {
+			if !s.bodyTooLarge(w, err) {
+				s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid upload: %s", err.Error()))
+			}
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		att, err := s.financeService.AddAttachment(r.Context(), int32(id), part.FileName(), part)
+		if err != nil {
+			s.writeAttachmentError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusCreated, att)
+		return
+	}
+}
+
+func (s *APIServer) handleListAttachments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	atts, err := s.financeService.ListAttachments(r.Context(), int32(id))
+	if err != nil {
+		s.writeAttachmentError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, atts)
+}
+
+// handleGetAttachment downloads an attachment with the name it was uploaded
+// under. Stores that can presign links, like S3, get the client to fetch
+// the file from them instead.
+func (s *APIServer) handleGetAttachment(w http.ResponseWriter, r *http.Request) {
+	id, attID, ok := s.attachmentIDs(w, r)
+	if !ok {
+		return
+	}
+
+	url, err := s.financeService.AttachmentURL(r.Context(), id, attID)
+	if err != nil {
+		s.writeAttachmentError(w, err)
+		return
+	}
+	if url != "" {
+		http.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+
+	att, rc, err := s.financeService.OpenAttachment(r.Context(), id, attID)
+	if err != nil {
+		s.writeAttachmentError(w, err)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", att.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(att.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": att.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, rc); err != nil {
+		logf(w, "error writing attachment %d: %v", attID, err)
+	}
+}
+
+func (s *APIServer) handleDeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	id, attID, ok := s.attachmentIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.financeService.DeleteAttachment(r.Context(), id, attID); err != nil {
+		s.writeAttachmentError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) attachmentIDs(w http.ResponseWriter, r *http.Request) (int32, int32, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return 0, 0, false
+	}
+	attID, err := strconv.ParseInt(vars["attachment"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid attachment ID")
+		return 0, 0, false
+	}
+	return int32(id), int32(attID), true
+}
+
+func (s *APIServer) writeAttachmentError(w http.ResponseWriter, err error) {
+	switch {
+	case s.bodyTooLarge(w, err):
+	case errors.Is(err, service.ErrTransactionNotFound), errors.Is(err, service.ErrAttachmentNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		s.writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, service.ErrInvalidAttachment):
+		s.writeError(w, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, service.ErrStorageDisabled):
+		s.writeError(w, http.StatusNotImplemented, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jdelles/currentz/internal/auth"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// LoginRequest logs a user in.
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest swaps a refresh token for a new session, or ends the
+// session it belongs to.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// authPaths go through without an API key or access token: they are how a
+// client gets one.
+var authPaths = map[string]bool{
+	"/api/auth/login":   true,
+	"/api/auth/refresh": true,
+	"/api/auth/logout":  true,
+}
+
+func (s *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	session, err := s.financeService.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		s.writeAuthError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, session)
+}
+
+func (s *APIServer) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	token, ok := s.refreshToken(w, r)
+	if !ok {
+		return
+	}
+	session, err := s.financeService.Refresh(r.Context(), token)
+	if err != nil {
+		s.writeAuthError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, session)
+}
+
+func (s *APIServer) handleLogout(w http.ResponseWriter, r *http.Request) {
+	token, ok := s.refreshToken(w, r)
+	if !ok {
+		return
+	}
+	if err := s.financeService.Logout(r.Context(), token); err != nil {
+		s.writeAuthError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// handleMe returns the user an access token was issued to.
+func (s *APIServer) handleMe(w http.ResponseWriter, r *http.Request) {
+	u, ok := service.UserFromContext(r.Context())
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="currentz"`)
+		s.writeError(w, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, u)
+}
+
+// refreshToken decodes a RefreshRequest body, writing the error response
+// if it can't.
+func (s *APIServer) refreshToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return "", false
+	}
+	if req.RefreshToken == "" {
+		s.writeError(w, http.StatusBadRequest, "refresh_token is required")
+		return "", false
+	}
+	return req.RefreshToken, true
+}
+
+func (s *APIServer) writeAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrInvalidToken):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="currentz"`)
+		s.writeError(w, http.StatusUnauthorized, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// sessionMiddleware puts the user an access token was issued to in the
+// request context. A bearer token that isn't a JWT is left for
+// apiKeyMiddleware; one that is but doesn't check out is refused.
+func (s *APIServer) sessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if !auth.LooksLikeJWT(token) || authPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		u, err := s.financeService.Authenticate(r.Context(), token)
+		if err != nil {
+			s.writeAuthError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(service.WithUser(r.Context(), u)))
+	})
+}
+
+// bearerToken is the token in a request's Authorization header, if any.
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) > len("Bearer ") && strings.EqualFold(h[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(h[len("Bearer "):])
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// Calendar exports cover a window around today so subscribed calendars show
+// recent history and the coming year.
+const (
+	calendarLookbackDays  = 90
+	calendarLookaheadDays = 365
+)
+
+// writeRecurringICS renders a recurring's occurrences as an iCalendar feed
+// with one all-day event per occurrence.
+func writeRecurringICS(w io.Writer, r service.Recurring, occurrences []service.Transaction, now time.Time) error {
+	amount, _ := service.NumericToFloat64(r.Amount)
+	sign := "+"
+	if r.Type == "expense" {
+		sign = "-"
+	}
+	summary := fmt.Sprintf("%s (%s$%.2f)", r.Description, sign, amount)
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//currentz//recurring//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + icsEscape(r.Description),
+	}
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, occ := range occurrences {
+		d := occ.Date.Time
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:recurring-%d-%s@currentz", r.ID, d.Format("20060102")),
+			"DTSTAMP:"+stamp,
+			"DTSTART;VALUE=DATE:"+d.Format("20060102"),
+			"DTEND;VALUE=DATE:"+d.AddDate(0, 0, 1).Format("20060102"),
+			"SUMMARY:"+icsEscape(summary),
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, icsFold(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func icsEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// icsFold splits content lines longer than 75 octets as RFC 5545 requires,
+// without breaking multi-byte characters.
+func icsFold(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		n := len(string(r))
+		if width+n > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
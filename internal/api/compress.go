@@ -0,0 +1,256 @@
+package api
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Compression decides which responses are gzip- or deflate-encoded for
+// clients that accept it.
+type Compression struct {
+	// MinSize is the smallest body compressed, in bytes. Below it the
+	// savings don't pay for the CPU and the extra headers.
+	MinSize int
+	// Types are the content types compressed. An entry ending in "/"
+	// covers the whole family, so "text/" matches text/csv and text/html.
+	// No types means no compression.
+	Types []string
+}
+
+// DefaultCompression covers the API's JSON and CSV and the frontend's
+// text assets. Server-sent events are left out; they are flushed one event
+// at a time.
+var DefaultCompression = Compression{
+	MinSize: 1024,
+	Types: []string{
+		"application/json",
+		"application/javascript",
+		"application/manifest+json",
+		"image/svg+xml",
+		"text/css",
+		"text/csv",
+		"text/html",
+		"text/javascript",
+		"text/plain",
+	},
+}
+
+// SetCompression replaces DefaultCompression.
+func (s *APIServer) SetCompression(c Compression) {
+	s.compression = c
+}
+
+func (c Compression) allows(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, t := range c.Types {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// codingQ is the quality an Accept-Encoding header gives coding, falling
+// back to "*". Zero means the client doesn't take it. It reads Accept the
+// same way for a media type, minus the "type/*" wildcards.
+func codingQ(acceptEncoding, coding string) float64 {
+	wildcard := 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != coding && name != "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if name == coding {
+			return q
+		}
+		wildcard = q
+	}
+	return wildcard
+}
+
+// negotiateEncoding picks gzip or deflate, preferring gzip on a tie. An
+// empty result means the body goes out as is.
+func negotiateEncoding(acceptEncoding string) string {
+	gz, deflate := codingQ(acceptEncoding, "gzip"), codingQ(acceptEncoding, "deflate")
+	switch {
+	case gz > 0 && gz >= deflate:
+		return "gzip"
+	case deflate > 0:
+		return "deflate"
+	}
+	return ""
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	zlibWriters = sync.Pool{New: func() any { return zlib.NewWriter(io.Discard) }}
+)
+
+// compressMiddleware encodes responses the client can take and
+// s.compression allows. Bodies are held back until MinSize bytes have been
+// written, so small responses go out unchanged.
+func (s *APIServer) compressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || len(s.compression.Types) == 0 || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, config: s.compression, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter buffers the start of a body until it knows whether to
+// compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	config   Compression
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser // set once compressing
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if status < 200 {
+		// Informational responses come ahead of the real one.
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cw.status != 0 {
+		return
+	}
+	cw.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		_ = cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.config.MinSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide sends the headers, compressing if big is true and the response
+// qualifies, then writes out what was buffered.
+func (cw *compressWriter) decide(big bool) error {
+	if cw.decided {
+		return nil
+	}
+	cw.decided = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	compress := big &&
+		h.Get("Content-Encoding") == "" &&
+		h.Get("Content-Range") == "" &&
+		cw.config.allows(h.Get("Content-Type"))
+
+	if compress {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		// The encoded bytes differ, so a strong validator no longer holds.
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		if cw.encoding == "gzip" {
+			gz := gzipWriters.Get().(*gzip.Writer)
+			gz.Reset(cw.ResponseWriter)
+			cw.enc = gz
+		} else {
+			zw := zlibWriters.Get().(*zlib.Writer)
+			zw.Reset(cw.ResponseWriter)
+			cw.enc = zw
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush sends what has been written so far. A response flushed before it
+// reaches MinSize is a stream, and is only compressed if its type allows.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		_ = cw.decide(true)
+	}
+	switch enc := cw.enc.(type) {
+	case *gzip.Writer:
+		_ = enc.Flush()
+	case *zlib.Writer:
+		_ = enc.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 {
+			// The handler wrote nothing; let net/http send its default.
+			return
+		}
+		_ = cw.decide(false)
+	}
+	switch enc := cw.enc.(type) {
+	case *gzip.Writer:
+		_ = enc.Close()
+		gzipWriters.Put(enc)
+	case *zlib.Writer:
+		_ = enc.Close()
+		zlibWriters.Put(enc)
+	}
+}
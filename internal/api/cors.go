@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+)
+
+// SetCORSOrigins limits the web pages that may call the API to those
+// served from origins, such as "https://app.example.com". "*" allows any,
+// as a new server does; none allows only the server's own dashboard. It
+// can be called while the server runs.
+func (s *APIServer) SetCORSOrigins(origins []string) {
+	s.corsOrigins.Store(&origins)
+}
+
+// corsMiddleware answers browsers' cross-origin checks, allowing the
+// configured origins.
+func (s *APIServer) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allow := ""
+		origins := *s.corsOrigins.Load()
+		if slices.Contains(origins, "*") {
+			allow = "*"
+		} else {
+			// The answer depends on who's asking, so caches must keep
+			// them apart.
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(origins, origin) {
+				allow = origin
+			}
+		}
+		if allow != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allow)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Actor, X-Household, X-Request-ID")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Next-Cursor")
+		}
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
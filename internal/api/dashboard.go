@@ -0,0 +1,21 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// dashboardFiles is the single-page dashboard served at / unless
+// SetStaticFiles replaces it. It is plain HTML, CSS and JavaScript calling
+// the JSON API, so it needs no build step.
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+func dashboardFS() fs.FS {
+	files, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err) // the directory is embedded above
+	}
+	return files
+}
@@ -0,0 +1,162 @@
+// Currentz dashboard. Everything here goes through the same JSON API the
+// CLI and other clients use.
+"use strict";
+
+const keyStorage = "currentz.apiKey";
+// money formats amounts in the base currency once it is known.
+let money = new Intl.NumberFormat(undefined, { minimumFractionDigits: 2, maximumFractionDigits: 2 });
+const $ = (id) => document.getElementById(id);
+
+// api calls the server, asking for an API key once if it wants one.
+async function api(path, options = {}) {
+  const headers = { "Content-Type": "application/json", "X-Actor": "dashboard" };
+  const key = localStorage.getItem(keyStorage);
+  if (key) headers["X-API-Key"] = key;
+
+  const resp = await fetch(path, { ...options, headers });
+  if (resp.status === 401 && !options.retried) {
+    const entered = window.prompt("API key for this Currentz server:");
+    if (entered) {
+      localStorage.setItem(keyStorage, entered.trim());
+      return api(path, { ...options, retried: true });
+    }
+  }
+  const body = await resp.json().catch(() => null);
+  if (!resp.ok) {
+    throw new Error((body && body.error) || `${resp.status} ${resp.statusText}`);
+  }
+  return body;
+}
+
+function showError(err) {
+  const el = $("error");
+  el.hidden = !err;
+  el.textContent = err ? err.message : "";
+}
+
+function formatDay(iso) {
+  // Days are midnight UTC; keep them from shifting into the browser's zone.
+  return new Date(iso).toLocaleDateString(undefined, {
+    month: "short", day: "numeric", year: "numeric", timeZone: "UTC",
+  });
+}
+
+function drawChart(forecast) {
+  const svg = $("chart");
+  svg.replaceChildren();
+  $("forecast-title").textContent = `${forecast.length}-Day Forecast`;
+  if (forecast.length === 0) return;
+
+  const width = 800, height = 240, pad = 16;
+  const balances = forecast.map((d) => d.balance);
+  const lo = Math.min(0, ...balances);
+  const hi = Math.max(0, ...balances);
+  const span = hi - lo || 1;
+  const x = (i) => (forecast.length === 1 ? width / 2 : (i / (forecast.length - 1)) * width);
+  const y = (v) => pad + (1 - (v - lo) / span) * (height - 2 * pad);
+  const ns = "http://www.w3.org/2000/svg";
+  const add = (tag, attrs) => {
+    const el = document.createElementNS(ns, tag);
+    for (const [k, v] of Object.entries(attrs)) el.setAttribute(k, v);
+    svg.appendChild(el);
+    return el;
+  };
+
+  add("line", { class: "zero", x1: 0, x2: width, y1: y(0), y2: y(0) });
+  add("polyline", {
+    class: "balance",
+    points: balances.map((b, i) => `${x(i)},${y(b)}`).join(" "),
+  });
+
+  let low = 0;
+  balances.forEach((b, i) => { if (b < balances[low]) low = i; });
+  add("circle", { class: "low", cx: x(low), cy: y(balances[low]), r: 4 });
+  add("text", { x: 4, y: 12 }).textContent = money.format(hi);
+  add("text", { x: 4, y: height - 4 }).textContent = money.format(lo);
+
+  $("chart-start").textContent = formatDay(forecast[0].date);
+  $("chart-end").textContent = formatDay(forecast[forecast.length - 1].date);
+
+  const lowest = $("lowest");
+  const day = forecast[low];
+  if (day.balance < 0) {
+    lowest.className = "banner error";
+    lowest.textContent = `🚨 Your balance goes negative: ${money.format(day.balance)} on ${formatDay(day.date)}.`;
+  } else {
+    lowest.className = "banner warn";
+    lowest.textContent = `Lowest balance: ${money.format(day.balance)} on ${formatDay(day.date)}.`;
+  }
+}
+
+function drawUpcoming(transactions) {
+  const body = $("upcoming");
+  body.replaceChildren();
+  if (transactions.length === 0) {
+    const row = body.insertRow();
+    const cell = row.insertCell();
+    cell.colSpan = 3;
+    cell.textContent = "Nothing scheduled.";
+    return;
+  }
+  transactions.sort((a, b) => a.date.localeCompare(b.date));
+  for (const tx of transactions) {
+    const row = body.insertRow();
+    row.insertCell().textContent = formatDay(tx.date);
+    row.insertCell().textContent = tx.description + (tx.id === 0 ? " (recurring)" : "");
+    const amount = row.insertCell();
+    const value = Number(tx.amount);
+    amount.className = `num ${tx.type}`;
+    amount.textContent = money.format(tx.type === "expense" ? -value : value);
+  }
+}
+
+async function refresh() {
+  try {
+    const [currency, balance, forecast, upcoming] = await Promise.all([
+      api("/api/currency"),
+      api("/api/balance"),
+      api("/api/forecast"),
+      api("/api/transactions/upcoming?days=30"),
+    ]);
+    money = new Intl.NumberFormat(undefined, { style: "currency", currency: currency.base_currency });
+    $("balance").textContent = `Balance ${money.format(balance.balance)}`;
+    drawChart(forecast);
+    drawUpcoming(upcoming);
+    showError(null);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+$("add").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const form = event.target;
+  const data = new FormData(form);
+  const type = data.get("type");
+  const body = {
+    date: data.get("date"),
+    amount: data.get("amount"),
+    description: data.get("description"),
+  };
+  if (type === "expense" && data.get("category")) body.category = data.get("category");
+
+  const status = $("add-status");
+  try {
+    const result = await api(`/api/transactions/${type}`, { method: "POST", body: JSON.stringify(body) });
+    const warnings = (result && result.warnings) || [];
+    status.className = warnings.length ? "banner warn" : "banner";
+    status.textContent = ["✅ Added.", ...warnings.map((w) => `⚠️ ${w.message}`)].join(" ");
+    form.reset();
+    await refresh();
+  } catch (err) {
+    status.className = "banner error";
+    status.textContent = err.message;
+  }
+  status.hidden = false;
+});
+
+$("refresh").addEventListener("click", refresh);
+document.addEventListener("visibilitychange", () => {
+  if (!document.hidden) refresh();
+});
+refresh();
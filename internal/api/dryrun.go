@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// dryRunHeader asks for, and on the response confirms, a dry run.
+const dryRunHeader = "X-Dry-Run"
+
+// dryRunRequested reports whether r asks for a dry run, with dry_run=true
+// in the query or an X-Dry-Run: true header.
+func dryRunRequested(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("dry_run")
+	if v == "" {
+		v = r.Header.Get(dryRunHeader)
+	}
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// dryRunMiddleware runs requests that ask for a dry run inside the
+// service's DryRun: they are handled, and answered, exactly as they would
+// be, but nothing they change is kept. The response carries X-Dry-Run: true
+// so a client can tell. GET, HEAD and OPTIONS change nothing and go
+// straight through.
+func (s *APIServer) dryRunMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		dryRun, err := dryRunRequested(r)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid 'dry_run' parameter")
+			return
+		}
+		if !dryRun {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set(dryRunHeader, "true")
+		err = s.financeService.DryRun(r.Context(), func(ctx context.Context) error {
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+		if errors.Is(err, service.ErrDryRunUnsupported) {
+			s.writeError(w, http.StatusNotImplemented, err.Error())
+		} else if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// EnvelopeRequest creates or replaces an envelope. Target is optional, as
+// is StartDate, which defaults to today.
+type EnvelopeRequest struct {
+	Name          string  `json:"name"`
+	MonthlyAmount Amount  `json:"monthly_amount"`
+	Target        *Amount `json:"target,omitempty"`
+	StartDate     string  `json:"start_date,omitempty"`
+	Category      string  `json:"category,omitempty"`
+}
+
+func (s *APIServer) handleListEnvelopes(w http.ResponseWriter, r *http.Request) {
+	envelopes, err := s.financeService.ListEnvelopes(r.Context())
+	if err != nil {
+		s.writeEnvelopeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, envelopes)
+}
+
+func (s *APIServer) handleGetEnvelope(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.envelopeID(w, r)
+	if !ok {
+		return
+	}
+	envelope, err := s.financeService.GetEnvelope(r.Context(), id)
+	if err != nil {
+		s.writeEnvelopeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, envelope)
+}
+
+func (s *APIServer) handleCreateEnvelope(w http.ResponseWriter, r *http.Request) {
+	in, ok := s.envelopeInput(w, r)
+	if !ok {
+		return
+	}
+	envelope, err := s.financeService.CreateEnvelope(r.Context(), in)
+	if err != nil {
+		s.writeEnvelopeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, envelope)
+}
+
+func (s *APIServer) handleUpdateEnvelope(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.envelopeID(w, r)
+	if !ok {
+		return
+	}
+	in, ok := s.envelopeInput(w, r)
+	if !ok {
+		return
+	}
+	envelope, err := s.financeService.UpdateEnvelope(r.Context(), id, in)
+	if err != nil {
+		s.writeEnvelopeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, envelope)
+}
+
+func (s *APIServer) handleDeleteEnvelope(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.envelopeID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.financeService.DeleteEnvelope(r.Context(), id); err != nil {
+		s.writeEnvelopeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) envelopeID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid envelope ID")
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// envelopeInput decodes an EnvelopeRequest body, writing the error response
+// if it can't.
+func (s *APIServer) envelopeInput(w http.ResponseWriter, r *http.Request) (service.EnvelopeInput, bool) {
+	var req EnvelopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return service.EnvelopeInput{}, false
+	}
+	in := service.EnvelopeInput{
+		Name:          req.Name,
+		MonthlyAmount: float64(req.MonthlyAmount),
+		Category:      req.Category,
+	}
+	if req.Target != nil {
+		target := float64(*req.Target)
+		in.Target = &target
+	}
+	if req.StartDate != "" {
+		start, err := s.parseDay(r, req.StartDate)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start_date: %s", err.Error()))
+			return service.EnvelopeInput{}, false
+		}
+		in.StartDate = start
+	}
+	return in, true
+}
+
+func (s *APIServer) writeEnvelopeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrEnvelopeNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidEnvelope):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrNoExchangeRate):
+		s.writeError(w, http.StatusConflict, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// parseAvailable reads the available=true|false parameter of the forecast
+// endpoints.
+func parseAvailable(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("available")
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/household"
+)
+
+// zoneAt is a household's timezone as of a data version. It only changes
+// with a write, so it is looked up once per version rather than on every
+// poll.
+type zoneAt struct {
+	household int32
+	version   uint64
+	loc       *time.Location
+}
+
+// versionMiddleware bumps the data version after every request that could
+// have changed data, whether or not it succeeded. An extra bump only costs
+// clients one full response.
+func (s *APIServer) versionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			s.dataVersion.Add(1)
+		}
+	})
+}
+
+// etag tags what a read endpoint returns right now. Responses only change
+// when data does or when the user's day rolls over, so the tag is the data
+// version, the day, and the server's start time to tell restarts apart,
+// along with the household the data is from.
+//
+// Writes made without going through this server, such as the CLI talking to
+// the database directly, aren't seen until the day changes or the server
+// restarts.
+func (s *APIServer) etag(r *http.Request) string {
+	version := s.dataVersion.Load()
+	h := household.FromContext(r.Context())
+	zone := s.zone.Load()
+	if zone == nil || zone.version != version || zone.household != h {
+		zone = &zoneAt{household: h, version: version, loc: s.financeService.Location(r.Context())}
+		s.zone.Store(zone)
+	}
+	today := time.Now().In(zone.loc).Format("20060102")
+	return fmt.Sprintf(`W/"%s-%x-%s-%d"`, s.epoch, version, today, h)
+}
+
+// conditional adds an ETag to a read endpoint and answers 304 Not Modified
+// when the client already has the current response. The tag is taken before
+// the handler reads anything, so a write racing the read can only make it
+// stale early, never serve old data under a new tag.
+func (s *APIServer) conditional(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		etag := s.etag(r)
+		if wantsNDJSON(r) {
+			// Another representation of the same data needs its own tag.
+			etag = strings.TrimSuffix(etag, `"`) + `-ndjson"`
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Add("Vary", "Accept")
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// etagMatches compares If-None-Match against etag the weak way, as RFC 9110
+// requires for it.
+func etagMatches(ifNoneMatch, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
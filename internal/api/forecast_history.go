@@ -0,0 +1,44 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// handleForecastHistory returns the forecast snapshot taken on ?date=, or
+// without it lists the days that have one.
+func (s *APIServer) handleForecastHistory(w http.ResponseWriter, r *http.Request) {
+	dateStr := r.URL.Query().Get("date")
+	if dateStr == "" {
+		days, err := s.financeService.ForecastSnapshotDates(r.Context())
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		dates := make([]string, len(days))
+		for i, d := range days {
+			dates[i] = d.Format("2006-01-02")
+		}
+		s.writeJSON(w, http.StatusOK, map[string][]string{"dates": dates})
+		return
+	}
+
+	day, err := s.parseDay(r, dateStr)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date: %s", err.Error()))
+		return
+	}
+	snapshot, err := s.financeService.ForecastHistory(r.Context(), day)
+	if err != nil {
+		if errors.Is(err, service.ErrSnapshotNotFound) {
+			s.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, snapshot)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jdelles/currentz/internal/buildinfo"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// readyTimeout bounds the database checks behind /readyz, so a hung
+// connection fails the probe instead of stalling it.
+const readyTimeout = 2 * time.Second
+
+// HealthResponse answers the probes. Database and Schema are only filled in
+// by /readyz.
+type HealthResponse struct {
+	Status   string                `json:"status"`
+	Version  string                `json:"version"`
+	Database string                `json:"database,omitempty"`
+	Schema   *service.SchemaStatus `json:"schema,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// handleHealthz is the liveness probe: the process is up and serving. It
+// doesn't touch the database, so an outage there doesn't get the server
+// restarted for nothing.
+func (s *APIServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, HealthResponse{Status: "ok", Version: buildinfo.Version()})
+}
+
+// handleReadyz is the readiness probe: the database answers and has every
+// migration this build needs. Until then traffic should go elsewhere.
+func (s *APIServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	resp := HealthResponse{Status: "ready", Version: buildinfo.Version(), Database: "ok"}
+	if err := s.financeService.Ping(ctx); err != nil {
+		resp.Status, resp.Database, resp.Error = "unavailable", "unreachable", err.Error()
+		s.writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	schema, err := s.financeService.SchemaStatus(ctx)
+	if err != nil {
+		resp.Status, resp.Error = "unavailable", err.Error()
+		s.writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	resp.Schema = &schema
+	if schema.Pending > 0 {
+		resp.Status, resp.Error = "unavailable", "database migrations are pending"
+		s.writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *APIServer) handleGetVersion(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, buildinfo.Get())
+}
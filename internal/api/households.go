@@ -0,0 +1,370 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/household"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// householdHeader names the household a request works on. Without it, a
+// logged-in user works on the first household they joined, and requests
+// made with the API key on the default one.
+const householdHeader = "X-Household"
+
+// HouseholdRequest creates a household.
+type HouseholdRequest struct {
+	Name string `json:"name"`
+}
+
+// MemberRequest adds a user to a household. Role defaults to viewer.
+type MemberRequest struct {
+	UserID int32  `json:"user_id"`
+	Role   string `json:"role,omitempty"`
+}
+
+// InvitationRequest invites someone to a household. Role defaults to
+// viewer.
+type InvitationRequest struct {
+	Role string `json:"role,omitempty"`
+}
+
+// AcceptInvitationRequest joins the household an invitation is for.
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// householdFreePaths need no household: they pick or join one, or are
+// about the user or the server rather than any household's data.
+var householdFreePaths = map[string]bool{
+	"/api/auth/me":            true,
+	"/api/households":         true,
+	"/api/invitations/accept": true,
+}
+
+// serverPrefixes manage the server rather than a household's data, so
+// they go by a user's own role instead of their role in the household.
+var serverPrefixes = []string{
+	"/api/users",
+	"/api/admin/",
+}
+
+type householdRoleKey struct{}
+
+// householdRole is the logged-in user's role in the household a request
+// works on, if they have one there.
+func householdRole(ctx context.Context) string {
+	role, _ := ctx.Value(householdRoleKey{}).(string)
+	return role
+}
+
+func serverRoute(path string) bool {
+	for _, prefix := range serverPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// householdMiddleware puts the household a request works on in its context:
+// the one in the path of the household routes, or the X-Household header,
+// or else the logged-in user's first. Logged-in users may only work on
+// households they belong to; requests made with the API key may work on
+// any.
+func (s *APIServer) householdMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || authPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, named, err := requestHousehold(r)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid household ID")
+			return
+		}
+		ctx := r.Context()
+		u, loggedIn := service.UserFromContext(ctx)
+		free := householdFreePaths[r.URL.Path] || serverRoute(r.URL.Path)
+		switch {
+		case !loggedIn:
+			if named && id != household.Default {
+				if _, err := s.financeService.GetHousehold(ctx, id); err != nil {
+					s.writeHouseholdError(w, err)
+					return
+				}
+			}
+		case named:
+			role, err := s.financeService.MemberRole(ctx, id, u.ID)
+			if err != nil {
+				s.writeHouseholdError(w, err)
+				return
+			}
+			if role == "" && !free {
+				s.writeError(w, http.StatusForbidden, fmt.Sprintf("%s is not a member of household %d", u.Username, id))
+				return
+			}
+			ctx = context.WithValue(ctx, householdRoleKey{}, role)
+		default:
+			households, err := s.financeService.UserHouseholds(ctx, u.ID)
+			if err != nil {
+				s.writeHouseholdError(w, err)
+				return
+			}
+			if len(households) == 0 {
+				if !free {
+					s.writeError(w, http.StatusForbidden, fmt.Sprintf("%s is not a member of any household", u.Username))
+					return
+				}
+				break
+			}
+			id = households[0].ID
+			ctx = context.WithValue(ctx, householdRoleKey{}, households[0].Role)
+		}
+		next.ServeHTTP(w, r.WithContext(household.WithID(ctx, id)))
+	})
+}
+
+// requestHousehold is the household a request names, if any, and
+// household.Default if it doesn't.
+func requestHousehold(r *http.Request) (id int32, named bool, err error) {
+	v, ok := mux.Vars(r)["household"]
+	if !ok {
+		v = r.Header.Get(householdHeader)
+	}
+	if v == "" {
+		return household.Default, false, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || n <= 0 {
+		return 0, false, fmt.Errorf("invalid household %q", v)
+	}
+	return int32(n), true, nil
+}
+
+// householdPrefix serves a request for /households/{id}/api/... as the
+// same request to /api/... with the household in X-Household, for clients
+// that would rather name the household in the path.
+func householdPrefix(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["household"]
+		r = r.Clone(r.Context())
+		r.Header.Set(householdHeader, id)
+		http.StripPrefix("/households/"+id, next).ServeHTTP(w, r)
+	})
+}
+
+// handleListHouseholds lists the logged-in user's households, with their
+// role in each, or every household for the API key.
+func (s *APIServer) handleListHouseholds(w http.ResponseWriter, r *http.Request) {
+	var households []service.Household
+	var err error
+	if u, ok := service.UserFromContext(r.Context()); ok {
+		households, err = s.financeService.UserHouseholds(r.Context(), u.ID)
+	} else {
+		households, err = s.financeService.ListHouseholds(r.Context())
+	}
+	if err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, households)
+}
+
+func (s *APIServer) handleGetHousehold(w http.ResponseWriter, r *http.Request) {
+	id := household.FromContext(r.Context())
+	h, err := s.financeService.GetHousehold(r.Context(), id)
+	if err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	h.Role = householdRole(r.Context())
+	s.writeJSON(w, http.StatusOK, h)
+}
+
+// handleCreateHousehold starts a household, with the logged-in user as its
+// owner.
+func (s *APIServer) handleCreateHousehold(w http.ResponseWriter, r *http.Request) {
+	var req HouseholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	var owner int32
+	if u, ok := service.UserFromContext(r.Context()); ok {
+		owner = u.ID
+	}
+	h, err := s.financeService.CreateHousehold(r.Context(), req.Name, owner)
+	if err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, h)
+}
+
+func (s *APIServer) handleDeleteHousehold(w http.ResponseWriter, r *http.Request) {
+	if err := s.financeService.DeleteHousehold(r.Context(), household.FromContext(r.Context())); err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleListMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := s.financeService.ListHouseholdMembers(r.Context(), household.FromContext(r.Context()))
+	if err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, members)
+}
+
+// handleAddMember adds an existing user to a household straight away,
+// without an invitation.
+func (s *APIServer) handleAddMember(w http.ResponseWriter, r *http.Request) {
+	var req MemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if req.Role == "" {
+		req.Role = service.RoleViewer
+	}
+	m, err := s.financeService.AddHouseholdMember(r.Context(), household.FromContext(r.Context()), req.UserID, req.Role)
+	if err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, m)
+}
+
+func (s *APIServer) handleSetMemberRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.pathID(w, r, "user", "Invalid user ID")
+	if !ok {
+		return
+	}
+	var req UserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	m, err := s.financeService.SetHouseholdMemberRole(r.Context(), household.FromContext(r.Context()), userID, req.Role)
+	if err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, m)
+}
+
+// handleRemoveMember takes someone out of a household. Members may remove
+// themselves to leave it.
+func (s *APIServer) handleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.pathID(w, r, "user", "Invalid user ID")
+	if !ok {
+		return
+	}
+	if err := s.financeService.RemoveHouseholdMember(r.Context(), household.FromContext(r.Context()), userID); err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleListInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := s.financeService.ListInvitations(r.Context(), household.FromContext(r.Context()))
+	if err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, invitations)
+}
+
+// handleCreateInvitation returns the invitation with its token, which is
+// never shown again. Whoever is given the token accepts it to join.
+func (s *APIServer) handleCreateInvitation(w http.ResponseWriter, r *http.Request) {
+	var req InvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if req.Role == "" {
+		req.Role = service.RoleViewer
+	}
+	var invitedBy int32
+	if u, ok := service.UserFromContext(r.Context()); ok {
+		invitedBy = u.ID
+	}
+	inv, err := s.financeService.CreateInvitation(r.Context(), household.FromContext(r.Context()), req.Role, invitedBy)
+	if err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, inv)
+}
+
+func (s *APIServer) handleRevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.pathID(w, r, "invitation", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+	if err := s.financeService.RevokeInvitation(r.Context(), household.FromContext(r.Context()), id); err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// handleAcceptInvitation adds the logged-in user to the household an
+// invitation is for.
+func (s *APIServer) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	u, ok := service.UserFromContext(r.Context())
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="currentz"`)
+		s.writeError(w, http.StatusUnauthorized, "Log in to accept an invitation")
+		return
+	}
+	var req AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if req.Token == "" {
+		s.writeError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+	h, err := s.financeService.AcceptInvitation(r.Context(), req.Token, u.ID)
+	if err != nil {
+		s.writeHouseholdError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, h)
+}
+
+// pathID parses the route variable name as an ID.
+func (s *APIServer) pathID(w http.ResponseWriter, r *http.Request, name, msg string) (int32, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, msg)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func (s *APIServer) writeHouseholdError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrHouseholdNotFound), errors.Is(err, service.ErrInvitationNotFound),
+		errors.Is(err, service.ErrUserNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidHousehold), errors.Is(err, service.ErrInvalidInvitation):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Limits bounds how long requests may take and how much they may send, so
+// a slow or careless client can't tie up the server.
+type Limits struct {
+	// RequestTimeout is the deadline on each request's context, which
+	// database queries honor. The event stream is exempt.
+	RequestTimeout time.Duration
+	// MaxBodyBytes caps JSON request bodies.
+	MaxBodyBytes int64
+	// MaxUploadBytes caps imports, restores and attachments, which carry
+	// whole files.
+	MaxUploadBytes int64
+
+	// Connection timeouts for the http.Server; see its fields.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+}
+
+// DefaultLimits suits a personal server: generous for real use, short
+// enough that a stuck client lets go.
+var DefaultLimits = Limits{
+	RequestTimeout:    30 * time.Second,
+	MaxBodyBytes:      1 << 20,
+	MaxUploadBytes:    32 << 20,
+	ReadHeaderTimeout: 10 * time.Second,
+	ReadTimeout:       time.Minute,
+	WriteTimeout:      time.Minute,
+	IdleTimeout:       2 * time.Minute,
+}
+
+// uploadPaths take file-sized bodies.
+var uploadPaths = map[string]bool{
+	"/api/transactions/import": true,
+	"/api/recurring/import":    true,
+	"/api/restore":             true,
+}
+
+// isUpload reports whether path takes a file-sized body: one of
+// uploadPaths or an attachment upload.
+func isUpload(path string) bool {
+	return uploadPaths[path] || (strings.HasPrefix(path, "/api/transactions/") && strings.HasSuffix(path, "/attachments"))
+}
+
+// streamPaths stay open for as long as the client listens.
+var streamPaths = map[string]bool{
+	"/api/events": true,
+}
+
+// SetLimits replaces DefaultLimits. Zero fields mean no limit.
+func (s *APIServer) SetLimits(l Limits) {
+	s.limits = l
+}
+
+// limitsMiddleware puts a deadline on the request and caps its body.
+// Bodies that say up front they are too big are refused before reading.
+func (s *APIServer) limitsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
+			limit := s.limits.MaxBodyBytes
+			if isUpload(r.URL.Path) {
+				limit = s.limits.MaxUploadBytes
+			}
+			if limit > 0 {
+				if r.ContentLength > limit {
+					s.writeBodyTooLarge(w, limit)
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+		}
+		if s.limits.RequestTimeout > 0 && !streamPaths[r.URL.Path] {
+			ctx, cancel := context.WithTimeout(r.Context(), s.limits.RequestTimeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyTooLarge reports whether err came from reading past the body limit,
+// and if so writes the 413.
+func (s *APIServer) bodyTooLarge(w http.ResponseWriter, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	s.writeBodyTooLarge(w, tooLarge.Limit)
+	return true
+}
+
+func (s *APIServer) writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	s.writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body too large (limit is %d bytes)", limit))
+}
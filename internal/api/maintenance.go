@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// defaultMaintenanceMessage is sent when maintenance is switched on without
+// a message of its own.
+const defaultMaintenanceMessage = "Currentz is down for maintenance; changes are paused but reads still work"
+
+// MaintenanceStatus is the server's read-only switch. While Enabled, every
+// request that could change data is refused with 503 Service Unavailable.
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+}
+
+// maintenanceExempt lists the mutating routes that keep working during
+// maintenance: the switch itself, and restore, which is usually the reason
+// for turning it on.
+var maintenanceExempt = map[string]bool{
+	"/api/admin/maintenance": true,
+	"/api/restore":           true,
+}
+
+// SetMaintenance turns read-only maintenance mode on or off. An empty
+// message falls back to a generic one.
+func (s *APIServer) SetMaintenance(enabled bool, message string) {
+	status := MaintenanceStatus{Enabled: enabled}
+	if enabled {
+		status.Message = strings.TrimSpace(message)
+		if status.Message == "" {
+			status.Message = defaultMaintenanceMessage
+		}
+	}
+	s.maintenance.Store(&status)
+}
+
+// Maintenance reports whether maintenance mode is on.
+func (s *APIServer) Maintenance() MaintenanceStatus {
+	if status := s.maintenance.Load(); status != nil {
+		return *status
+	}
+	return MaintenanceStatus{}
+}
+
+// maintenanceMiddleware refuses writes while maintenance mode is on.
+// GET, HEAD and OPTIONS always go through.
+func (s *APIServer) maintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := s.Maintenance()
+		if !status.Enabled || maintenanceExempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+		default:
+			s.writeError(w, http.StatusServiceUnavailable, status.Message)
+		}
+	})
+}
+
+func (s *APIServer) handleGetMaintenance(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, s.Maintenance())
+}
+
+func (s *APIServer) handleSetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceStatus
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	s.SetMaintenance(req.Enabled, req.Message)
+	s.writeJSON(w, http.StatusOK, s.Maintenance())
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/notify"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// NotificationChannelRequest creates or replaces a notification channel.
+// Slack channels need WebhookURL; Telegram channels need BotToken and
+// ChatID. Enabled defaults to true.
+type NotificationChannelRequest struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+	BotToken   string `json:"bot_token,omitempty"`
+	ChatID     string `json:"chat_id,omitempty"`
+	Enabled    *bool  `json:"enabled,omitempty"`
+}
+
+func (s *APIServer) handleListNotificationChannels(w http.ResponseWriter, r *http.Request) {
+	channels, err := s.financeService.ListNotificationChannels(r.Context())
+	if err != nil {
+		s.writeNotificationError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, channels)
+}
+
+func (s *APIServer) handleGetNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.notificationChannelID(w, r)
+	if !ok {
+		return
+	}
+	c, err := s.financeService.GetNotificationChannel(r.Context(), id)
+	if err != nil {
+		s.writeNotificationError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, c)
+}
+
+func (s *APIServer) handleCreateNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	in, ok := s.notificationChannelInput(w, r)
+	if !ok {
+		return
+	}
+	c, err := s.financeService.CreateNotificationChannel(r.Context(), in)
+	if err != nil {
+		s.writeNotificationError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, c)
+}
+
+func (s *APIServer) handleUpdateNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.notificationChannelID(w, r)
+	if !ok {
+		return
+	}
+	in, ok := s.notificationChannelInput(w, r)
+	if !ok {
+		return
+	}
+	c, err := s.financeService.UpdateNotificationChannel(r.Context(), id, in)
+	if err != nil {
+		s.writeNotificationError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, c)
+}
+
+func (s *APIServer) handleDeleteNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.notificationChannelID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.financeService.DeleteNotificationChannel(r.Context(), id); err != nil {
+		s.writeNotificationError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// handleTestNotificationChannel sends a test message, so a channel can be
+// checked before anything worth notifying happens.
+func (s *APIServer) handleTestNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.notificationChannelID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.financeService.TestNotificationChannel(r.Context(), id); err != nil {
+		s.writeNotificationError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "sent"})
+}
+
+func (s *APIServer) notificationChannelID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid notification channel ID")
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// notificationChannelInput decodes a NotificationChannelRequest body,
+// writing the error response if it can't.
+func (s *APIServer) notificationChannelInput(w http.ResponseWriter, r *http.Request) (service.NotificationChannelInput, bool) {
+	var req NotificationChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return service.NotificationChannelInput{}, false
+	}
+	in := service.NotificationChannelInput{
+		Name: req.Name,
+		Config: notify.Config{
+			Type:       req.Type,
+			WebhookURL: req.WebhookURL,
+			BotToken:   req.BotToken,
+			ChatID:     req.ChatID,
+		},
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	return in, true
+}
+
+func (s *APIServer) writeNotificationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotificationChannelNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidNotificationChannel):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrNotificationFailed):
+		s.writeError(w, http.StatusBadGateway, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+// recoverMiddleware answers a request whose handler panicked with a 500 and
+// an ErrorResponse, instead of dropping the connection, and logs the panic
+// with its stack under the request's ID. A handler that had already
+// started its response can only have it cut short.
+func (s *APIServer) recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoverWriter{ResponseWriter: w}
+		// Headers the handler set for a response it never sent don't
+		// belong on the error.
+		header := w.Header().Clone()
+		defer func() {
+			v := recover()
+			switch v {
+			case nil:
+				return
+			case http.ErrAbortHandler:
+				// The handler gave up on purpose.
+				panic(v)
+			}
+			logf(w, "panic serving %s %s: %v\n%s", r.Method, r.URL.Path, v, debug.Stack())
+			if rw.wrote {
+				panic(http.ErrAbortHandler)
+			}
+			clear(w.Header())
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			s.writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error:     "Internal server error",
+				RequestID: w.Header().Get(requestIDHeader),
+			})
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// recoverWriter notes whether a response has been started.
+type recoverWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (rw *recoverWriter) WriteHeader(status int) {
+	if status >= 200 {
+		rw.wrote = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recoverWriter) Write(p []byte) (int, error) {
+	rw.wrote = true
+	return rw.ResponseWriter.Write(p)
+}
+
+func (rw *recoverWriter) Flush() {
+	rw.wrote = true
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *recoverWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
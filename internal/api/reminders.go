@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// defaultReminderDays is how far ahead GET /api/reminders looks when
+// days isn't given.
+const defaultReminderDays = 30
+
+// RecurringReminderRequest sets how many days before each occurrence of a
+// recurring to send a reminder. 0 reminds on the day.
+type RecurringReminderRequest struct {
+	DaysBefore int `json:"days_before"`
+}
+
+// handleRecurringReminder sets a recurring's reminder on PUT and turns it
+// off on DELETE.
+func (s *APIServer) handleRecurringReminder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid recurring transaction ID")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		err := s.financeService.ClearRecurringReminder(r.Context(), int32(id))
+		if err != nil {
+			s.writeReminderError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+		return
+	}
+
+	var req RecurringReminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	reminder, err := s.financeService.SetRecurringReminder(r.Context(), int32(id), req.DaysBefore)
+	if err != nil {
+		s.writeReminderError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, reminder)
+}
+
+// handleListReminders lists the reminders due in the next days days, and
+// those already due for bills still to come.
+func (s *APIServer) handleListReminders(w http.ResponseWriter, r *http.Request) {
+	days := defaultReminderDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d <= 0 || d > maxForecastDays {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid days (expected 1-%d)", maxForecastDays))
+			return
+		}
+		days = d
+	}
+	reminders, err := s.financeService.Reminders(r.Context(), days)
+	if err != nil {
+		s.writeReminderError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, reminders)
+}
+
+func (s *APIServer) writeReminderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrRecurringNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidReminder):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// requestIDHeader carries a request's ID, from a proxy or client that
+// assigned one, and back on every response.
+const requestIDHeader = "X-Request-ID"
+
+// requestIDMiddleware gives every request an ID, keeping the one it came
+// with if that looks sane. The ID is echoed in X-Request-ID and in error
+// bodies, and is on the context, so the service's log lines and failed
+// queries can be found from it.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(service.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID accepts up to 128 letters, digits and -_.: so that what a
+// client sends can't forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == ':') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// logf logs about the response being written to w, with its request ID.
+func logf(w http.ResponseWriter, format string, args ...any) {
+	ctx := service.WithRequestID(context.Background(), w.Header().Get(requestIDHeader))
+	service.Logf(ctx, format, args...)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/household"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// ownerPrefixes are the paths only owners may use, to read or to write:
+// they manage users, hand out all the data at once, replace it, or hold
+// other services' credentials.
+var ownerPrefixes = []string{
+	"/api/users",
+	"/api/admin/",
+	"/api/audit",
+	"/api/backup",
+	"/api/restore",
+	"/api/notifications/",
+}
+
+// readOnlyRoutes are POSTs that change nothing, so viewers may make them.
+var readOnlyRoutes = map[string]bool{
+	"/api/forecast/compare": true,
+}
+
+// selfRoutes are routes anyone may use on their own account, whatever
+// their role, by method and route template, to the variable holding the
+// user's ID.
+var selfRoutes = map[string]string{
+	"GET /api/users/{id:[0-9]+}":          "id",
+	"PUT /api/users/{id:[0-9]+}/password": "id",
+	// Leaving a household.
+	"DELETE /api/households/{household:[0-9]+}/members/{user:[0-9]+}": "user",
+}
+
+// requiredRole is the least role that may make r: reads need viewer,
+// writes editor, and ownerPrefixes owner. Managing a household needs its
+// owner, and seeing who is in it a viewer. Logging in and out, and picking
+// or joining a household, need none.
+func requiredRole(r *http.Request) string {
+	path := r.URL.Path
+	if authPaths[path] || householdFreePaths[path] {
+		return ""
+	}
+	for _, prefix := range ownerPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return service.RoleOwner
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return service.RoleViewer
+	}
+	if readOnlyRoutes[path] {
+		return service.RoleViewer
+	}
+	if strings.HasPrefix(path, "/api/households/") {
+		return service.RoleOwner
+	}
+	return service.RoleEditor
+}
+
+// ownResource reports whether r is a selfRoutes request by u about u.
+func ownResource(r *http.Request, u service.User) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	tmpl, err := route.GetPathTemplate()
+	if err != nil {
+		return false
+	}
+	v, ok := selfRoutes[r.Method+" "+tmpl]
+	return ok && mux.Vars(r)[v] == strconv.Itoa(int(u.ID))
+}
+
+// roleMiddleware refuses logged-in users requests their role doesn't
+// allow: their own role for managing the server, their role in the
+// household for everything else. Requests made with the API key aren't
+// limited: the key is for whoever runs the server.
+func (s *APIServer) roleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, ok := service.UserFromContext(r.Context())
+		if !ok || ownResource(r, u) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		need := requiredRole(r)
+		if need == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if serverRoute(r.URL.Path) {
+			if !u.HasRole(need) {
+				s.writeError(w, http.StatusForbidden, fmt.Sprintf("%s is a %s; this needs the %s role", u.Username, u.Role, need))
+				return
+			}
+		} else if role := householdRole(r.Context()); !service.RoleAllows(role, need) {
+			s.writeError(w, http.StatusForbidden, fmt.Sprintf("%s is a %s in household %d; this needs the %s role",
+				u.Username, role, household.FromContext(r.Context()), need))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,75 +1,405 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/amount"
+	"github.com/jdelles/currentz/internal/dates"
 	"github.com/jdelles/currentz/internal/service"
 )
 
 // FinanceServiceInterface defines the interface that our API depends on
 type FinanceServiceInterface interface {
 	GetAllTransactions(ctx context.Context) ([]service.Transaction, error)
-	AddIncome(ctx context.Context, date time.Time, amount float64, description string) error
-	AddExpense(ctx context.Context, date time.Time, amount float64, description string) error
+	GetTransactionsByStatus(ctx context.Context, status string) ([]service.Transaction, error)
+	ListTransactions(ctx context.Context, status string, order service.ListOrder, page service.Page) ([]service.Transaction, string, error)
+	SetTransactionStatus(ctx context.Context, id int32, status string) (service.Transaction, error)
+	AddAttachment(ctx context.Context, id int32, filename string, r io.Reader) (service.Attachment, error)
+	ListAttachments(ctx context.Context, id int32) ([]service.Attachment, error)
+	OpenAttachment(ctx context.Context, id, attID int32) (service.Attachment, io.ReadCloser, error)
+	AttachmentURL(ctx context.Context, id, attID int32) (string, error)
+	DeleteAttachment(ctx context.Context, id, attID int32) error
+	AddIncome(ctx context.Context, date time.Time, amount float64, description, currency string) error
+	RecordExpense(ctx context.Context, in service.ExpenseInput) (service.Transaction, []service.Warning, error)
 	DeleteTransaction(ctx context.Context, id int32) error
 	GetStartingBalance(ctx context.Context) (float64, error)
 	SetStartingBalance(ctx context.Context, balance float64) error
 	CreateRecurringSimple(ctx context.Context, input service.RecurringInput) (service.Recurring, error)
-	ListRecurring(ctx context.Context) ([]service.Recurring, error)
+	RecurringSummaries(ctx context.Context) ([]service.RecurringSummary, error)
+	ListRecurringSummaries(ctx context.Context, order service.ListOrder, page service.Page) ([]service.RecurringSummary, string, error)
 	DeleteRecurring(ctx context.Context, id int32) error
 	SetRecurringActive(ctx context.Context, id int32, active bool) error
 	Calculate90DayForecast(ctx context.Context, startingBalance float64) ([]service.DailyCashFlow, error)
+	Forecast(ctx context.Context, startingBalance float64, days int) ([]service.DailyCashFlow, error)
+	ForecastWithOptions(ctx context.Context, startingBalance float64, days int, opts service.ForecastOptions) ([]service.DailyCashFlow, error)
+	ForecastAll(ctx context.Context, startingBalance float64, days int, opts service.ForecastOptions) (service.AllForecasts, error)
+	ForecastAsOf(ctx context.Context, asOf time.Time) ([]service.DailyCashFlow, error)
+	ForecastHistory(ctx context.Context, day time.Time) (service.ForecastSnapshot, error)
+	ForecastSnapshotDates(ctx context.Context) ([]time.Time, error)
 	FindLowestPoint(forecast []service.DailyCashFlow) (service.DailyCashFlow, int)
-	GetUpcomingTransactions(ctx context.Context, days int) ([]service.Transaction, error)
-	GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]service.Transaction, error)
+	RecurringContributions(ctx context.Context, days int) ([]service.RecurringContribution, error)
+	MonthlyOutlook(ctx context.Context, months int) ([]service.MonthOutlook, error)
+	MonthVariance(ctx context.Context, month time.Time) (service.VarianceReport, error)
+	Insights(ctx context.Context, month time.Time) (service.SpendingInsights, error)
+	Calendar(ctx context.Context, month time.Time) (service.MonthCalendar, error)
+	SpendingHeatmap(ctx context.Context, start, end time.Time) (service.SpendingHeatmap, error)
+	TopSpending(ctx context.Context, filter service.TopSpendingFilter) (service.TopSpending, error)
+	CompareForecasts(ctx context.Context, startingBalance float64, days int, baseline, scenario service.Scenario) (service.ForecastComparison, error)
+	ListScenarios(ctx context.Context) ([]service.SavedScenario, error)
+	GetScenario(ctx context.Context, id int32) (service.SavedScenario, error)
+	CreateScenario(ctx context.Context, name string, sc service.Scenario) (service.SavedScenario, error)
+	UpdateScenario(ctx context.Context, id int32, name string, sc service.Scenario) (service.SavedScenario, error)
+	DeleteScenario(ctx context.Context, id int32) error
+	ScenarioForecast(ctx context.Context, id int32, startingBalance float64, days int) (service.ForecastComparison, error)
+	GetUpcomingTransactions(ctx context.Context, days int) ([]service.ListedTransaction, error)
+	UpcomingWindow(ctx context.Context, days int) (time.Time, time.Time)
+	GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]service.ListedTransaction, error)
+	GetTransactionsBetween(ctx context.Context, start, end time.Time) ([]service.ListedTransaction, error)
+	SubscribeEvents(ctx context.Context) (<-chan service.Event, func())
+	OnEvent(fn func(service.Event))
+	SetTransactionTax(ctx context.Context, id int32, gross, withheld float64) error
+	SetTaxRate(ctx context.Context, rate float64) error
+	EstimateTaxes(ctx context.Context, year int) (service.TaxEstimate, error)
+	TagSelfEmployment(ctx context.Context, id int32) error
+	UntagSelfEmployment(ctx context.Context, id int32) error
+	EstimateSelfEmploymentTax(ctx context.Context, year int) (service.SelfEmploymentTaxEstimate, error)
+	GenerateEstimatedPayments(ctx context.Context, year int) ([]service.Recurring, error)
+	ListAudit(ctx context.Context, filter service.AuditFilter) ([]service.AuditEntry, error)
+	ListTrash(ctx context.Context) (service.Trash, error)
+	ArchiveCutoff(ctx context.Context) (time.Time, bool)
+	ArchiveTransactions(ctx context.Context, cutoff time.Time) (int64, error)
+	ListArchivedTransactions(ctx context.Context, start, end *time.Time) ([]service.ArchivedTransaction, error)
+	Sync(ctx context.Context, since int64, limit int) (service.SyncResult, error)
+	RestoreTransaction(ctx context.Context, id int32) (service.Transaction, error)
+	RestoreRecurring(ctx context.Context, id int32) (service.Recurring, error)
+	RecurringOccurrences(ctx context.Context, id int32, start, end time.Time) (service.Recurring, []service.Transaction, error)
+	ImportTransactions(ctx context.Context, format string, r io.Reader, dryRun bool) (service.ImportResult, error)
+	ImportRecurringCSV(ctx context.Context, r io.Reader, dryRun bool) (service.RecurringImportResult, error)
+	DryRun(ctx context.Context, fn func(context.Context) error) error
+	AggregateTransactions(ctx context.Context, filter service.AggregateFilter) (service.TransactionAggregates, error)
+	GroupTransactions(ctx context.Context, txs []service.ListedTransaction, by string) (service.GroupedTransactions, error)
+	SummarizeTransactions(ctx context.Context, start, end time.Time, includeRecurring bool) (service.TransactionSummary, error)
+	ExportTransactions(ctx context.Context, format string, w io.Writer, start, end *time.Time) error
+	Backup(ctx context.Context) (service.Backup, error)
+	Restore(ctx context.Context, b service.Backup) (service.RestoreSummary, error)
+	SaveBackup(ctx context.Context) (service.StoredBackup, error)
+	ListStoredBackups(ctx context.Context) ([]service.StoredBackup, error)
+	OpenStoredBackup(ctx context.Context, name string) (io.ReadCloser, error)
+	StoredBackupURL(ctx context.Context, name string) (string, error)
+	PauseRecurring(ctx context.Context, id int32, from, until *time.Time) (service.Recurring, error)
+	SetRecurringReminder(ctx context.Context, id int32, daysBefore int) (service.RecurringReminder, error)
+	ClearRecurringReminder(ctx context.Context, id int32) error
+	Reminders(ctx context.Context, days int) ([]service.Reminder, error)
+	SuggestRecurring(ctx context.Context) ([]service.RecurringSuggestion, error)
+	AcceptRecurringSuggestion(ctx context.Context, id string) (service.Recurring, error)
+	ReanchorRecurring(ctx context.Context, id int32, in service.ReanchorInput) (service.Recurring, error)
+	BaseCurrency(ctx context.Context) (string, error)
+	SetBaseCurrency(ctx context.Context, code string) error
+	ListExchangeRates(ctx context.Context) ([]service.ExchangeRate, error)
+	SetExchangeRate(ctx context.Context, code string, rate float64) (service.ExchangeRate, error)
+	DeleteExchangeRate(ctx context.Context, code string) error
+	Budgets(ctx context.Context) (map[string]float64, error)
+	SetBudget(ctx context.Context, category string, limit float64) error
+	DeleteBudget(ctx context.Context, category string) error
+	LowBalanceThreshold(ctx context.Context) (float64, bool, error)
+	SetLowBalanceThreshold(ctx context.Context, threshold float64) error
+	ClearLowBalanceThreshold(ctx context.Context) error
+	Timezone(ctx context.Context) (string, error)
+	SetTimezone(ctx context.Context, name string) error
+	Settings(ctx context.Context) (service.Settings, error)
+	UpdateSettings(ctx context.Context, in service.SettingsUpdate) (service.Settings, error)
+	ResetSetting(ctx context.Context, key string) error
+	ForecastDays(ctx context.Context) int
+	Location(ctx context.Context) *time.Location
+	Today(ctx context.Context) time.Time
+	ListAccounts(ctx context.Context) ([]service.Account, error)
+	CreateAccount(ctx context.Context, in service.AccountInput) (service.Account, error)
+	DeleteAccount(ctx context.Context, id int32) error
+	CardStatements(ctx context.Context, id int32) ([]service.CardStatement, error)
+	SetAccountInterest(ctx context.Context, id int32, in service.InterestInput) (service.Account, error)
+	ListEnvelopes(ctx context.Context) ([]service.EnvelopeBalance, error)
+	GetEnvelope(ctx context.Context, id int32) (service.EnvelopeBalance, error)
+	CreateEnvelope(ctx context.Context, in service.EnvelopeInput) (service.Envelope, error)
+	UpdateEnvelope(ctx context.Context, id int32, in service.EnvelopeInput) (service.Envelope, error)
+	DeleteEnvelope(ctx context.Context, id int32) error
+	ListNotificationChannels(ctx context.Context) ([]service.NotificationChannel, error)
+	GetNotificationChannel(ctx context.Context, id int32) (service.NotificationChannel, error)
+	CreateNotificationChannel(ctx context.Context, in service.NotificationChannelInput) (service.NotificationChannel, error)
+	UpdateNotificationChannel(ctx context.Context, id int32, in service.NotificationChannelInput) (service.NotificationChannel, error)
+	DeleteNotificationChannel(ctx context.Context, id int32) error
+	TestNotificationChannel(ctx context.Context, id int32) error
+	Login(ctx context.Context, username, password string) (service.Session, error)
+	Refresh(ctx context.Context, refreshToken string) (service.Session, error)
+	Logout(ctx context.Context, refreshToken string) error
+	Authenticate(ctx context.Context, accessToken string) (service.User, error)
+	ListUsers(ctx context.Context) ([]service.User, error)
+	GetUser(ctx context.Context, id int32) (service.User, error)
+	CreateUser(ctx context.Context, username, password, role string) (service.User, error)
+	SetUserRole(ctx context.Context, id int32, role string) (service.User, error)
+	SetUserPassword(ctx context.Context, id int32, password string) error
+	DeleteUser(ctx context.Context, id int32) error
+	ListHouseholds(ctx context.Context) ([]service.Household, error)
+	UserHouseholds(ctx context.Context, userID int32) ([]service.Household, error)
+	GetHousehold(ctx context.Context, id int32) (service.Household, error)
+	CreateHousehold(ctx context.Context, name string, owner int32) (service.Household, error)
+	DeleteHousehold(ctx context.Context, id int32) error
+	MemberRole(ctx context.Context, householdID, userID int32) (string, error)
+	ListHouseholdMembers(ctx context.Context, householdID int32) ([]service.HouseholdMember, error)
+	AddHouseholdMember(ctx context.Context, householdID, userID int32, role string) (service.HouseholdMember, error)
+	SetHouseholdMemberRole(ctx context.Context, householdID, userID int32, role string) (service.HouseholdMember, error)
+	RemoveHouseholdMember(ctx context.Context, householdID, userID int32) error
+	CreateInvitation(ctx context.Context, householdID int32, role string, invitedBy int32) (service.Invitation, error)
+	ListInvitations(ctx context.Context, householdID int32) ([]service.Invitation, error)
+	RevokeInvitation(ctx context.Context, householdID, id int32) error
+	AcceptInvitation(ctx context.Context, token string, userID int32) (service.Household, error)
+	Ping(ctx context.Context) error
+	SchemaStatus(ctx context.Context) (service.SchemaStatus, error)
 }
 
 type APIServer struct {
 	financeService FinanceServiceInterface
+	maintenance    atomic.Pointer[MaintenanceStatus]
+	apiKey         string
+	scopedKeys     []scopedKey
+	// corsOrigins are the origins browsers may call the API from; "*" is
+	// any.
+	corsOrigins atomic.Pointer[[]string]
+	// static is the frontend served outside /api/: the built-in dashboard
+	// unless SetStaticFiles replaced it.
+	static      fs.FS
+	compression Compression
+	limits      Limits
+	// tls is set by SetTLS; nil serves plain HTTP.
+	tls *TLSOptions
+	// dataVersion counts writes, so read endpoints can tell clients their
+	// copy is still current. epoch tells this run's versions apart from the
+	// last one's.
+	dataVersion atomic.Uint64
+	epoch       string
+	zone        atomic.Pointer[zoneAt]
 }
 
 func NewAPIServer(financeService FinanceServiceInterface) *APIServer {
-	return &APIServer{
+	s := &APIServer{
 		financeService: financeService,
+		static:         dashboardFS(),
+		compression:    DefaultCompression,
+		limits:         DefaultLimits,
+		epoch:          strconv.FormatInt(time.Now().UnixNano(), 36),
 	}
+	s.SetCORSOrigins([]string{"*"})
+	// The archival job changes data without a request, so it moves the
+	// data version itself.
+	financeService.OnEvent(func(ev service.Event) {
+		if ev.Type == service.EventTransactionsArchived {
+			s.dataVersion.Add(1)
+		}
+	})
+	return s
+}
+
+// Amount is a money value in a request body. It accepts a JSON number or a
+// string written in the server's locale, e.g. "1.234,56" under de-DE.
+type Amount float64
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var f float64
+		if err := json.Unmarshal(data, &f); err != nil {
+			return err
+		}
+		*a = Amount(f)
+		return nil
+	}
+	f, err := amount.Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = Amount(f)
+	return nil
 }
 
 // JSON request/response types
 type AddTransactionRequest struct {
-	Date        string  `json:"date"`
-	Amount      float64 `json:"amount"`
-	Description string  `json:"description"`
+	Date        string `json:"date"`
+	Amount      Amount `json:"amount"`
+	Description string `json:"description"`
+	Currency    string `json:"currency,omitempty"`
+	// Category is recorded on expenses and checked against its budget.
+	Category string `json:"category,omitempty"`
+	// AccountID charges an expense to an account, such as a credit card.
+	AccountID *int32 `json:"account_id,omitempty"`
+	// Status is recorded on expenses: pending for a check that hasn't
+	// cleared yet. Empty means cleared.
+	Status string `json:"status,omitempty"`
+}
+
+// SetTransactionStatusRequest marks a transaction pending or cleared.
+type SetTransactionStatusRequest struct {
+	Status string `json:"status"`
+	// Version is the version of the record the edit is based on, when not
+	// sent in If-Match.
+	Version *int32 `json:"version,omitempty"`
+}
+
+// ExpenseCreatedResponse is returned for a new expense. Warnings are soft:
+// the expense was saved either way.
+type ExpenseCreatedResponse struct {
+	Status      string              `json:"status"`
+	Transaction service.Transaction `json:"transaction"`
+	Warnings    []service.Warning   `json:"warnings,omitempty"`
+}
+
+type CreateAccountRequest struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	StatementDay int    `json:"statement_day,omitempty"`
+	DueDay       int    `json:"due_day,omitempty"`
+}
+
+// SetAccountInterestRequest sets an account's current balance and interest
+// terms. APY is a fraction (0.045 for 4.5%); omit it to stop accruing.
+type SetAccountInterestRequest struct {
+	Balance     Amount   `json:"balance"`
+	APY         *float64 `json:"apy"`
+	Compounding string   `json:"compounding,omitempty"`
 }
 
 type SetBalanceRequest struct {
-	Balance float64 `json:"balance"`
+	Balance Amount `json:"balance"`
 }
 
 type RecurringTransactionRequest struct {
 	Description string  `json:"description"`
 	Type        string  `json:"type"`
-	Amount      float64 `json:"amount"`
+	Amount      Amount  `json:"amount"`
 	StartDate   string  `json:"start_date"`
 	Interval    string  `json:"interval"`
 	DayOfWeek   *int    `json:"day_of_week,omitempty"`
 	DayOfMonth  *int    `json:"day_of_month,omitempty"`
 	EndDate     *string `json:"end_date,omitempty"`
 	Active      bool    `json:"active"`
+	Currency    string  `json:"currency,omitempty"`
+	// Prorate spreads a yearly bill over the months before it is due in
+	// the monthly outlook.
+	Prorate bool `json:"prorate,omitempty"`
+	// OccurrenceCount ends the recurring after that many occurrences.
+	OccurrenceCount *int `json:"occurrence_count,omitempty"`
+	// WeekOfMonth puts a monthly recurring on the nth day_of_week of the
+	// month (1-4, or -1 for the last).
+	WeekOfMonth *int `json:"week_of_month,omitempty"`
+	// BusinessDay moves a monthly occurrence off a weekend to the Friday
+	// before.
+	BusinessDay bool `json:"business_day,omitempty"`
+	// RRule is an iCalendar recurrence rule used instead of interval.
+	RRule string `json:"rrule,omitempty"`
+	// Category and AccountID are given to every occurrence.
+	Category  string `json:"category,omitempty"`
+	AccountID *int32 `json:"account_id,omitempty"`
 }
 
 type SetActiveRequest struct {
 	Active bool `json:"active"`
+	// Version is the version of the record the edit is based on, when not
+	// sent in If-Match.
+	Version *int32 `json:"version,omitempty"`
+}
+
+// PauseRecurringRequest skips a recurring's occurrences from PausedFrom
+// through PausedUntil, inclusive.
+type PauseRecurringRequest struct {
+	PausedFrom  string `json:"paused_from"`
+	PausedUntil string `json:"paused_until"`
+	// Version is the version of the record the edit is based on, when not
+	// sent in If-Match.
+	Version *int32 `json:"version,omitempty"`
+}
+
+// ReanchorRecurringRequest moves a weekly/biweekly schedule. Send either
+// anchor_date or shift_weeks, not both.
+type ReanchorRecurringRequest struct {
+	AnchorDate *string `json:"anchor_date,omitempty"`
+	ShiftWeeks int     `json:"shift_weeks,omitempty"`
+	// Version is the version of the record the edit is based on, when not
+	// sent in If-Match.
+	Version *int32 `json:"version,omitempty"`
+}
+
+type SetTransactionTaxRequest struct {
+	GrossAmount Amount `json:"gross_amount"`
+	TaxWithheld Amount `json:"tax_withheld"`
+}
+
+type SetBaseCurrencyRequest struct {
+	Currency string `json:"currency"`
+}
+
+type SetExchangeRateRequest struct {
+	Rate float64 `json:"rate"`
+}
+
+// CurrencyResponse lists the base currency and the rates into it.
+type CurrencyResponse struct {
+	BaseCurrency string                 `json:"base_currency"`
+	Rates        []service.ExchangeRate `json:"rates"`
+}
+
+type SetBudgetRequest struct {
+	Limit Amount `json:"limit"`
+}
+
+type SetThresholdRequest struct {
+	Threshold Amount `json:"threshold"`
+}
+
+type TimezoneRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+// SettingsRequest changes the settings present in the body. Any key not in
+// service.SettingKeys is rejected.
+type SettingsRequest struct {
+	StartingBalance       *Amount  `json:"starting_balance"`
+	LowBalanceThreshold   *Amount  `json:"low_balance_threshold"`
+	Timezone              *string  `json:"timezone"`
+	BaseCurrency          *string  `json:"base_currency"`
+	TaxRate               *float64 `json:"tax_rate"`
+	SelfEmploymentRate    *float64 `json:"self_employment_tax_rate"`
+	ForecastDays          *int     `json:"forecast_days"`
+	LargeBillThreshold    *Amount  `json:"large_bill_threshold"`
+	RetentionYears        *int     `json:"retention_years"`
+	NotificationMinChange *Amount  `json:"notification_min_change"`
+}
+
+type SetTaxRateRequest struct {
+	Rate float64 `json:"rate"`
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
+	// RequestID names the request in the server's logs.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// TransactionListResponse is returned by list endpoints when called with
+// ?aggregates=true.
+type TransactionListResponse struct {
+	Transactions []service.ListedTransaction   `json:"transactions"`
+	Aggregates   service.TransactionAggregates `json:"aggregates"`
 }
 
 // Helper functions
@@ -77,55 +407,167 @@ func (s *APIServer) writeJSON(w http.ResponseWriter, status int, data interface{
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("error encoding response: %v", err)
+		logf(w, "error encoding response: %v", err)
 	}
 }
 
+// writeError answers with message. Server errors are logged too, under the
+// request's ID.
 func (s *APIServer) writeError(w http.ResponseWriter, status int, message string) {
-	s.writeJSON(w, status, ErrorResponse{Error: message})
+	if status >= http.StatusInternalServerError {
+		logf(w, "%d %s: %s", status, http.StatusText(status), message)
+	}
+	s.writeJSON(w, status, ErrorResponse{Error: message, RequestID: w.Header().Get(requestIDHeader)})
+}
+
+// writeDecodeError reports a request body that failed to decode. Amounts the
+// locale rejected get their own message so the client knows what to fix.
+func (s *APIServer) writeDecodeError(w http.ResponseWriter, err error) {
+	if s.bodyTooLarge(w, err) {
+		return
+	}
+	var amountErr *amount.ParseError
+	if errors.As(err, &amountErr) {
+		s.writeError(w, http.StatusBadRequest, amountErr.Error())
+		return
+	}
+	s.writeError(w, http.StatusBadRequest, "Invalid JSON")
+}
+
+// parseDay parses a date parameter as a calendar day. Relative forms such as
+// "tomorrow" are resolved against the user's today, and full timestamps are
+// moved to the day they fall on in the user's timezone, so 23:30 local time
+// is not booked on the next UTC day.
+func (s *APIServer) parseDay(r *http.Request, dateStr string) (time.Time, error) {
+	return dates.ParseDay(dateStr, s.today(r),
+		func() *time.Location { return s.financeService.Location(r.Context()) })
+}
+
+// today is the user's today, for dates.Parse.
+func (s *APIServer) today(r *http.Request) func() time.Time {
+	return func() time.Time { return s.financeService.Today(r.Context()) }
 }
 
-func parseDate(dateStr string) (time.Time, error) {
-	// Try common date formats
-	formats := []string{
-		"2006-01-02",
-		"2006-01-02T15:04:05Z",
-		"2006-01-02T15:04:05.000Z",
-		"2006-01-02T15:04:05-07:00",
+// writeTransactionList answers a list endpoint. The ?aggregates parameter
+// adds SQL-computed totals for the same filter: "true" returns them next to
+// the rows, "only" skips fetching the rows at all.
+func (s *APIServer) writeTransactionList(w http.ResponseWriter, r *http.Request, filter service.AggregateFilter, list func() ([]service.ListedTransaction, error)) {
+	mode := r.URL.Query().Get("aggregates")
+	wantAggregates := mode == "only"
+	if !wantAggregates && mode != "" {
+		b, err := strconv.ParseBool(mode)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid 'aggregates' parameter (expected true, false or only)")
+			return
+		}
+		wantAggregates = b
 	}
 
-	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
-			return t, nil
+	var aggregates service.TransactionAggregates
+	if wantAggregates {
+		var err error
+		aggregates, err = s.financeService.AggregateTransactions(r.Context(), filter)
+		if err != nil {
+			s.writeForecastError(w, err)
+			return
+		}
+		if mode == "only" {
+			s.writeJSON(w, http.StatusOK, aggregates)
+			return
 		}
 	}
-	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
-}
 
-// Transaction endpoints
-func (s *APIServer) handleGetTransactions(w http.ResponseWriter, r *http.Request) {
-	transactions, err := s.financeService.GetAllTransactions(r.Context())
+	transactions, err := list()
+	if errors.Is(err, service.ErrInvalidPage) {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if wantAggregates {
+		s.writeJSON(w, http.StatusOK, TransactionListResponse{Transactions: transactions, Aggregates: aggregates})
+		return
+	}
 	s.writeJSON(w, http.StatusOK, transactions)
 }
 
+// Transaction endpoints
+func (s *APIServer) handleGetTransactions(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && status != service.StatusPending && status != service.StatusCleared {
+		s.writeError(w, http.StatusBadRequest, "Invalid 'status' parameter (expected pending or cleared)")
+		return
+	}
+	order, ok := s.parseListOrder(w, r)
+	if !ok {
+		return
+	}
+	page, ok := s.parsePage(w, r)
+	if !ok {
+		return
+	}
+	s.writeTransactionList(w, r, service.AggregateFilter{Status: status}, func() ([]service.ListedTransaction, error) {
+		transactions, next, err := s.financeService.ListTransactions(r.Context(), status, order, page)
+		setNextCursor(w, next)
+		return service.OneOffs(transactions), err
+	})
+}
+
+// parseListOrder reads the sort and order parameters of a list endpoint,
+// answering 400 itself if they are invalid.
+func (s *APIServer) parseListOrder(w http.ResponseWriter, r *http.Request) (service.ListOrder, bool) {
+	order, err := service.ParseListOrder(r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return service.ListOrder{}, false
+	}
+	return order, true
+}
+
+// parsePage reads the limit and cursor parameters of a sorted list
+// endpoint, answering 400 itself if the limit is invalid. Without a limit
+// the whole list comes back.
+func (s *APIServer) parsePage(w http.ResponseWriter, r *http.Request) (service.Page, bool) {
+	page := service.Page{After: r.URL.Query().Get("cursor")}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > service.MaxPageLimit {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit (expected 1 to %d)", service.MaxPageLimit))
+			return service.Page{}, false
+		}
+		page.Limit = n
+	}
+	return page, true
+}
+
+// setNextCursor tells the client where the next page of a sorted list
+// starts, if there is one.
+func setNextCursor(w http.ResponseWriter, next string) {
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+}
+
 func (s *APIServer) handleAddIncome(w http.ResponseWriter, r *http.Request) {
 	var req AddTransactionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		s.writeDecodeError(w, err)
 		return
 	}
 
-	date, err := parseDate(req.Date)
+	date, err := s.parseDay(r, req.Date)
 	if err != nil {
 		s.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := s.financeService.AddIncome(r.Context(), date, req.Amount, req.Description); err != nil {
+	if err := s.financeService.AddIncome(r.Context(), date, float64(req.Amount), req.Description, req.Currency); err != nil {
+		if errors.Is(err, service.ErrInvalidCurrency) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -136,22 +578,36 @@ func (s *APIServer) handleAddIncome(w http.ResponseWriter, r *http.Request) {
 func (s *APIServer) handleAddExpense(w http.ResponseWriter, r *http.Request) {
 	var req AddTransactionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		s.writeDecodeError(w, err)
 		return
 	}
 
-	date, err := parseDate(req.Date)
+	date, err := s.parseDay(r, req.Date)
 	if err != nil {
 		s.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := s.financeService.AddExpense(r.Context(), date, req.Amount, req.Description); err != nil {
+	tx, warnings, err := s.financeService.RecordExpense(r.Context(), service.ExpenseInput{
+		Date:        date,
+		Amount:      float64(req.Amount),
+		Description: req.Description,
+		Currency:    req.Currency,
+		Category:    req.Category,
+		AccountID:   req.AccountID,
+		Status:      req.Status,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCurrency) || errors.Is(err, service.ErrInvalidAccount) ||
+			errors.Is(err, service.ErrInvalidStatus) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	s.writeJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+	s.writeJSON(w, http.StatusCreated, ExpenseCreatedResponse{Status: "success", Transaction: tx, Warnings: warnings})
 }
 
 func (s *APIServer) handleDeleteTransaction(w http.ResponseWriter, r *http.Request) {
@@ -165,6 +621,10 @@ func (s *APIServer) handleDeleteTransaction(w http.ResponseWriter, r *http.Reque
 	}
 
 	if err := s.financeService.DeleteTransaction(r.Context(), int32(id)); err != nil {
+		if errors.Is(err, service.ErrTransactionNotFound) {
+			s.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -185,11 +645,11 @@ func (s *APIServer) handleGetBalance(w http.ResponseWriter, r *http.Request) {
 func (s *APIServer) handleSetBalance(w http.ResponseWriter, r *http.Request) {
 	var req SetBalanceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		s.writeDecodeError(w, err)
 		return
 	}
 
-	if err := s.financeService.SetStartingBalance(r.Context(), req.Balance); err != nil {
+	if err := s.financeService.SetStartingBalance(r.Context(), float64(req.Balance)); err != nil {
 		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -201,11 +661,11 @@ func (s *APIServer) handleSetBalance(w http.ResponseWriter, r *http.Request) {
 func (s *APIServer) handleCreateRecurring(w http.ResponseWriter, r *http.Request) {
 	var req RecurringTransactionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		s.writeDecodeError(w, err)
 		return
 	}
 
-	startDate, err := parseDate(req.StartDate)
+	startDate, err := s.parseDay(r, req.StartDate)
 	if err != nil {
 		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start date: %s", err.Error()))
 		return
@@ -213,7 +673,7 @@ func (s *APIServer) handleCreateRecurring(w http.ResponseWriter, r *http.Request
 
 	var endDate *time.Time
 	if req.EndDate != nil {
-		ed, err := parseDate(*req.EndDate)
+		ed, err := s.parseDay(r, *req.EndDate)
 		if err != nil {
 			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end date: %s", err.Error()))
 			return
@@ -222,15 +682,23 @@ func (s *APIServer) handleCreateRecurring(w http.ResponseWriter, r *http.Request
 	}
 
 	input := service.RecurringInput{
-		Description: req.Description,
-		Type:        req.Type,
-		Amount:      req.Amount,
-		StartDate:   startDate,
-		Interval:    req.Interval,
-		DayOfWeek:   req.DayOfWeek,
-		DayOfMonth:  req.DayOfMonth,
-		EndDate:     endDate,
-		Active:      req.Active,
+		Description:     req.Description,
+		Type:            req.Type,
+		Amount:          float64(req.Amount),
+		StartDate:       startDate,
+		Interval:        req.Interval,
+		DayOfWeek:       req.DayOfWeek,
+		DayOfMonth:      req.DayOfMonth,
+		EndDate:         endDate,
+		Active:          req.Active,
+		Currency:        req.Currency,
+		Prorate:         req.Prorate,
+		OccurrenceCount: req.OccurrenceCount,
+		WeekOfMonth:     req.WeekOfMonth,
+		BusinessDay:     req.BusinessDay,
+		RRule:           req.RRule,
+		Category:        req.Category,
+		AccountID:       req.AccountID,
 	}
 
 	recurring, err := s.financeService.CreateRecurringSimple(r.Context(), input)
@@ -242,12 +710,56 @@ func (s *APIServer) handleCreateRecurring(w http.ResponseWriter, r *http.Request
 	s.writeJSON(w, http.StatusCreated, recurring)
 }
 
+// handleImportRecurring creates recurrings from a CSV file in the request
+// body, one per row. Nothing is saved unless every row is valid, and with
+// dry_run=true nothing is saved at all: the recurrings that would be
+// created are returned for review.
+func (s *APIServer) handleImportRecurring(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := dryRunRequested(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid 'dry_run' parameter")
+		return
+	}
+
+	result, err := s.financeService.ImportRecurringCSV(r.Context(), r.Body, dryRun)
+	if err != nil {
+		if s.bodyTooLarge(w, err) {
+			return
+		}
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidImport) {
+			status = http.StatusBadRequest
+		}
+		s.writeError(w, status, err.Error())
+		return
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	s.writeJSON(w, status, result)
+}
+
 func (s *APIServer) handleListRecurring(w http.ResponseWriter, r *http.Request) {
-	recurring, err := s.financeService.ListRecurring(r.Context())
+	order, ok := s.parseListOrder(w, r)
+	if !ok {
+		return
+	}
+	page, ok := s.parsePage(w, r)
+	if !ok {
+		return
+	}
+	recurring, next, err := s.financeService.ListRecurringSummaries(r.Context(), order, page)
+	if errors.Is(err, service.ErrInvalidPage) {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	setNextCursor(w, next)
 	s.writeJSON(w, http.StatusOK, recurring)
 }
 
@@ -262,6 +774,10 @@ func (s *APIServer) handleDeleteRecurring(w http.ResponseWriter, r *http.Request
 	}
 
 	if err := s.financeService.DeleteRecurring(r.Context(), int32(id)); err != nil {
+		if errors.Is(err, service.ErrRecurringNotFound) {
+			s.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -281,155 +797,1725 @@ func (s *APIServer) handleSetRecurringActive(w http.ResponseWriter, r *http.Requ
 
 	var req SetActiveRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		s.writeDecodeError(w, err)
 		return
 	}
 
-	if err := s.financeService.SetRecurringActive(r.Context(), int32(id), req.Active); err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+	ctx, ok := s.expectVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+	if err := s.financeService.SetRecurringActive(ctx, int32(id), req.Active); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRecurringNotFound):
+			s.writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrVersionConflict):
+			s.writeError(w, http.StatusConflict, err.Error())
+		default:
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
 	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
 }
 
-// Forecast endpoints
-func (s *APIServer) handleGetForecast(w http.ResponseWriter, r *http.Request) {
-	balance, err := s.financeService.GetStartingBalance(r.Context())
+func (s *APIServer) handleReanchorRecurring(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.ParseInt(idStr, 10, 32)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeError(w, http.StatusBadRequest, "Invalid recurring transaction ID")
+		return
+	}
+
+	var req ReanchorRecurringRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
 		return
 	}
 
-	forecast, err := s.financeService.Calculate90DayForecast(r.Context(), balance)
+	in := service.ReanchorInput{ShiftWeeks: req.ShiftWeeks}
+	if req.AnchorDate != nil {
+		d, err := s.parseDay(r, *req.AnchorDate)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid anchor date: %s", err.Error()))
+			return
+		}
+		in.AnchorDate = &d
+	}
+
+	ctx, ok := s.expectVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+	rec, err := s.financeService.ReanchorRecurring(ctx, int32(id), in)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		switch {
+		case errors.Is(err, service.ErrRecurringNotFound):
+			s.writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrVersionConflict):
+			s.writeError(w, http.StatusConflict, err.Error())
+		default:
+			s.writeError(w, http.StatusBadRequest, err.Error())
+		}
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, forecast)
+	setVersionTag(w, rec.Version)
+	s.writeJSON(w, http.StatusOK, rec)
 }
 
-func (s *APIServer) handleGetLowestPoint(w http.ResponseWriter, r *http.Request) {
-	balance, err := s.financeService.GetStartingBalance(r.Context())
+func (s *APIServer) handlePauseRecurring(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeError(w, http.StatusBadRequest, "Invalid recurring transaction ID")
+		return
+	}
+
+	var from, until *time.Time
+	var version *int32
+	if r.Method == http.MethodPut {
+		var req PauseRecurringRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeDecodeError(w, err)
+			return
+		}
+		version = req.Version
+		f, err := s.parseDay(r, req.PausedFrom)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid paused_from: %s", err.Error()))
+			return
+		}
+		u, err := s.parseDay(r, req.PausedUntil)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid paused_until: %s", err.Error()))
+			return
+		}
+		from, until = &f, &u
+	}
+
+	ctx, ok := s.expectVersion(w, r, version)
+	if !ok {
 		return
 	}
+	rec, err := s.financeService.PauseRecurring(ctx, int32(id), from, until)
+	switch {
+	case err == nil:
+		setVersionTag(w, rec.Version)
+		s.writeJSON(w, http.StatusOK, rec)
+	case errors.Is(err, service.ErrRecurringNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrVersionConflict):
+		s.writeError(w, http.StatusConflict, err.Error())
+	default:
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
 
-	forecast, err := s.financeService.Calculate90DayForecast(r.Context(), balance)
+func (s *APIServer) handleRecurringSuggestions(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := s.financeService.SuggestRecurring(r.Context())
 	if err != nil {
 		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	s.writeJSON(w, http.StatusOK, suggestions)
+}
 
-	lowest, index := s.financeService.FindLowestPoint(forecast)
-
-	response := map[string]interface{}{
-		"lowest_point": lowest,
-		"day_index":    index,
+func (s *APIServer) handleAcceptRecurringSuggestion(w http.ResponseWriter, r *http.Request) {
+	rec, err := s.financeService.AcceptRecurringSuggestion(r.Context(), mux.Vars(r)["id"])
+	switch {
+	case err == nil:
+		s.writeJSON(w, http.StatusCreated, rec)
+	case errors.Is(err, service.ErrSuggestionNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
 	}
-	s.writeJSON(w, http.StatusOK, response)
 }
 
-func (s *APIServer) handleGetUpcoming(w http.ResponseWriter, r *http.Request) {
-	daysStr := r.URL.Query().Get("days")
-	days := 30 // default
+func (s *APIServer) handleRecurringCalendar(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
 
-	if daysStr != "" {
-		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 {
-			days = d
-		}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid recurring transaction ID")
+		return
 	}
 
-	transactions, err := s.financeService.GetUpcomingTransactions(r.Context(), days)
+	now := time.Now().UTC()
+	today := s.financeService.Today(r.Context())
+	start := today.AddDate(0, 0, -calendarLookbackDays)
+	end := today.AddDate(0, 0, calendarLookaheadDays)
+
+	recurring, occurrences, err := s.financeService.RecurringOccurrences(r.Context(), int32(id), start, end)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeError(w, http.StatusNotFound, fmt.Sprintf("Recurring transaction %d not found", id))
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, transactions)
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"recurring-%d.ics\"", id))
+	if err := writeRecurringICS(w, recurring, occurrences, now); err != nil {
+		logf(w, "error writing calendar: %v", err)
+	}
 }
 
-func (s *APIServer) handleGetTransactionsBetween(w http.ResponseWriter, r *http.Request) {
-	startStr := r.URL.Query().Get("start")
-	endStr := r.URL.Query().Get("end")
+// Forecast endpoints
 
-	if startStr == "" || endStr == "" {
-		s.writeError(w, http.StatusBadRequest, "Both 'start' and 'end' query parameters are required")
+// maxForecastDays bounds the days parameter of GET /api/forecast.
+const maxForecastDays = service.MaxForecastDays
+
+func (s *APIServer) handleGetForecast(w http.ResponseWriter, r *http.Request) {
+	if asOfStr := r.URL.Query().Get("as_of"); asOfStr != "" {
+		asOf, err := s.parseDay(r, asOfStr)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid as_of date: %s", err.Error()))
+			return
+		}
+		forecast, err := s.financeService.ForecastAsOf(r.Context(), asOf)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAsOf) {
+				s.writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.writeForecastError(w, err)
+			return
+		}
+		s.writeForecast(w, r, forecast)
 		return
 	}
 
-	start, err := parseDate(startStr)
-	if err != nil {
-		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start date: %s", err.Error()))
+	days, opts, ok := s.forecastParams(w, r)
+	if !ok {
 		return
 	}
 
-	end, err := parseDate(endStr)
+	balance, err := s.financeService.GetStartingBalance(r.Context())
 	if err != nil {
-		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end date: %s", err.Error()))
+		s.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	transactions, err := s.financeService.GetTransactionsWithRecurringsBetween(r.Context(), start, end)
+	var forecast []service.DailyCashFlow
+	if opts == (service.ForecastOptions{}) {
+		forecast, err = s.financeService.Forecast(r.Context(), balance, days)
+	} else {
+		forecast, err = s.financeService.ForecastWithOptions(r.Context(), balance, days, opts)
+	}
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeForecastError(w, err)
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, transactions)
+	s.writeForecast(w, r, forecast)
 }
 
-// CORS middleware
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// handleGetAllForecasts forecasts every account at once, along with the
+// total. It takes the same parameters as GET /api/forecast except as_of.
+func (s *APIServer) handleGetAllForecasts(w http.ResponseWriter, r *http.Request) {
+	days, opts, ok := s.forecastParams(w, r)
+	if !ok {
+		return
+	}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	balance, err := s.financeService.GetStartingBalance(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	all, err := s.financeService.ForecastAll(r.Context(), balance, days, opts)
+	if err != nil {
+		s.writeForecastError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, all)
 }
 
-func (s *APIServer) SetupRoutes() *mux.Router {
-	r := mux.NewRouter()
+// forecastParams reads the days, include_pending and available parameters
+// of the forecast endpoints, and writes the error response if it can't.
+func (s *APIServer) forecastParams(w http.ResponseWriter, r *http.Request) (int, service.ForecastOptions, bool) {
+	var days int
+	if daysStr := r.URL.Query().Get("days"); daysStr == "" {
+		days = s.financeService.ForecastDays(r.Context())
+	} else {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d <= 0 || d > maxForecastDays {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid days (expected 1-%d)", maxForecastDays))
+			return 0, service.ForecastOptions{}, false
+		}
+		days = d
+	}
 
-	// Apply CORS middleware
-	r.Use(corsMiddleware)
+	var opts service.ForecastOptions
+	if v := r.URL.Query().Get("include_pending"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid 'include_pending' parameter (expected true or false)")
+			return 0, service.ForecastOptions{}, false
+		}
+		opts.ExcludePending = !b
+	}
+	available, err := parseAvailable(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid 'available' parameter (expected true or false)")
+		return 0, service.ForecastOptions{}, false
+	}
+	opts.Envelopes = available
+	return days, opts, true
+}
 
-	// Catch-all OPTIONS handler so preflights always match
-	r.PathPrefix("/").Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// corsMiddleware already set the headers; just OK it.
-		w.WriteHeader(http.StatusOK)
-	})
+// ndjsonType is newline-delimited JSON: one record per line.
+const ndjsonType = "application/x-ndjson"
 
-	// Transaction routes
-	r.HandleFunc("/api/transactions", s.handleGetTransactions).Methods("GET")
-	r.HandleFunc("/api/transactions/income", s.handleAddIncome).Methods("POST")
-	r.HandleFunc("/api/transactions/expense", s.handleAddExpense).Methods("POST")
-	r.HandleFunc("/api/transactions/{id:[0-9]+}", s.handleDeleteTransaction).Methods("DELETE")
-	r.HandleFunc("/api/transactions/between", s.handleGetTransactionsBetween).Methods("GET")
-	r.HandleFunc("/api/transactions/upcoming", s.handleGetUpcoming).Methods("GET")
+// ndjsonFlushEvery is how many forecast days go out per chunk, so a client
+// can draw a year-long forecast a month at a time.
+const ndjsonFlushEvery = 31
 
-	// Balance routes
-	r.HandleFunc("/api/balance", s.handleGetBalance).Methods("GET")
-	r.HandleFunc("/api/balance", s.handleSetBalance).Methods("PUT")
+// wantsNDJSON reports whether the client's Accept header prefers NDJSON to
+// a JSON array.
+func wantsNDJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	q := codingQ(accept, ndjsonType)
+	return q > 0 && q >= codingQ(accept, "application/json")
+}
 
-	// Recurring transaction routes
-	r.HandleFunc("/api/recurring", s.handleCreateRecurring).Methods("POST")
-	r.HandleFunc("/api/recurring", s.handleListRecurring).Methods("GET")
+// writeForecast sends forecast as a JSON array, or one day per line when the
+// client asked for NDJSON.
+func (s *APIServer) writeForecast(w http.ResponseWriter, r *http.Request, forecast []service.DailyCashFlow) {
+	if !wantsNDJSON(r) {
+		s.writeJSON(w, http.StatusOK, forecast)
+		return
+	}
+	w.Header().Set("Content-Type", ndjsonType)
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	for i, day := range forecast {
+		if err := enc.Encode(day); err != nil {
+			logf(w, "error streaming forecast: %v", err)
+			return
+		}
+		if flusher != nil && (i+1)%ndjsonFlushEvery == 0 {
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *APIServer) handleGetMonthlyOutlook(w http.ResponseWriter, r *http.Request) {
+	months := 12
+	if monthsStr := r.URL.Query().Get("months"); monthsStr != "" {
+		m, err := strconv.Atoi(monthsStr)
+		if err != nil || m <= 0 || m > 60 {
+			s.writeError(w, http.StatusBadRequest, "Invalid months (expected 1-60)")
+			return
+		}
+		months = m
+	}
+
+	outlook, err := s.financeService.MonthlyOutlook(r.Context(), months)
+	if err != nil {
+		s.writeForecastError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, outlook)
+}
+
+// handleGetVariance compares a month's plan with what was recorded. month
+// defaults to the current one.
+func (s *APIServer) handleGetVariance(w http.ResponseWriter, r *http.Request) {
+	month, ok := s.reportMonth(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := s.financeService.MonthVariance(r.Context(), month)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMonth) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.writeForecastError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, report)
+}
+
+// handleGetInsights summarizes a month's spending trends. month defaults
+// to the current one.
+func (s *APIServer) handleGetInsights(w http.ResponseWriter, r *http.Request) {
+	month, ok := s.reportMonth(w, r)
+	if !ok {
+		return
+	}
+
+	insights, err := s.financeService.Insights(r.Context(), month)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMonth) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.writeForecastError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, insights)
+}
+
+// handleGetCalendar lays a month's transactions out by day for a calendar
+// grid. month defaults to the current one.
+func (s *APIServer) handleGetCalendar(w http.ResponseWriter, r *http.Request) {
+	month, ok := s.reportMonth(w, r)
+	if !ok {
+		return
+	}
+
+	cal, err := s.financeService.Calendar(r.Context(), month)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, cal)
+}
+
+// handleGetHeatmap reports spending per day, weekday and week for a
+// heatmap. The range defaults to the year up to today.
+func (s *APIServer) handleGetHeatmap(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := s.reportRange(w, r, func(end time.Time) time.Time { return end.AddDate(-1, 0, 1) })
+	if !ok {
+		return
+	}
+
+	heatmap, err := s.financeService.SpendingHeatmap(r.Context(), start, end)
+	if err != nil {
+		s.writeForecastError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, heatmap)
+}
+
+// handleGetTopSpending ranks where money went by payee or description.
+// The range defaults to the 30 days up to today.
+func (s *APIServer) handleGetTopSpending(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := s.reportRange(w, r, func(end time.Time) time.Time { return end.AddDate(0, 0, -29) })
+	if !ok {
+		return
+	}
+	filter := service.TopSpendingFilter{By: service.TopByPayee, Start: start, End: end, Limit: 10}
+	if by := r.URL.Query().Get("by"); by != "" {
+		filter.By = by
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = n
+	}
+	includeRecurring, err := parseIncludeRecurring(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid 'include_recurring' parameter (expected true or false)")
+		return
+	}
+	filter.IncludeRecurring = includeRecurring
+
+	top, err := s.financeService.TopSpending(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidGrouping) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.writeForecastError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, top)
+}
+
+// reportRange reads the optional start and end parameters of a report.
+// end defaults to today and start to lookback(end). It writes the error
+// response if it can't.
+func (s *APIServer) reportRange(w http.ResponseWriter, r *http.Request, lookback func(end time.Time) time.Time) (start, end time.Time, ok bool) {
+	if v := r.URL.Query().Get("end"); v != "" {
+		d, err := s.parseDay(r, v)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end date: %s", err.Error()))
+			return time.Time{}, time.Time{}, false
+		}
+		end = d
+	} else {
+		end = s.financeService.Today(r.Context())
+	}
+	if v := r.URL.Query().Get("start"); v != "" {
+		d, err := s.parseDay(r, v)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start date: %s", err.Error()))
+			return time.Time{}, time.Time{}, false
+		}
+		start = d
+	} else {
+		start = lookback(end)
+	}
+	if end.Before(start) {
+		s.writeError(w, http.StatusBadRequest, "end must not be before start")
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// reportMonth reads the month=YYYY-MM parameter of the monthly reports,
+// defaulting to the current month, and writes the error response if it
+// can't.
+func (s *APIServer) reportMonth(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	v := r.URL.Query().Get("month")
+	if v == "" {
+		return s.financeService.Today(r.Context()), true
+	}
+	m, err := time.Parse("2006-01", v)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid month (expected YYYY-MM)")
+		return time.Time{}, false
+	}
+	return m, true
+}
+
+func (s *APIServer) handleGetContributions(w http.ResponseWriter, r *http.Request) {
+	days := 90
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d <= 0 {
+			s.writeError(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = d
+	}
+
+	contributions, err := s.financeService.RecurringContributions(r.Context(), days)
+	if err != nil {
+		s.writeForecastError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, contributions)
+}
+
+// writeForecastError reports a failed forecast. A missing exchange rate is
+// something the user can fix, so it is a conflict rather than a server error.
+func (s *APIServer) writeForecastError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrNoExchangeRate) {
+		s.writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	s.writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+// handleGetLowestPoint finds the lowest day of the 90-day forecast. With
+// available=true it looks at the balance net of envelopes instead.
+func (s *APIServer) handleGetLowestPoint(w http.ResponseWriter, r *http.Request) {
+	available, err := parseAvailable(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid 'available' parameter (expected true or false)")
+		return
+	}
+	balance, err := s.financeService.GetStartingBalance(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var (
+		lowest service.DailyCashFlow
+		index  int
+	)
+	if available {
+		forecast, err := s.financeService.ForecastWithOptions(r.Context(), balance, 90, service.ForecastOptions{Envelopes: true})
+		if err != nil {
+			s.writeForecastError(w, err)
+			return
+		}
+		lowest, index = service.LowestAvailable(forecast)
+	} else {
+		forecast, err := s.financeService.Calculate90DayForecast(r.Context(), balance)
+		if err != nil {
+			s.writeForecastError(w, err)
+			return
+		}
+		lowest, index = s.financeService.FindLowestPoint(forecast)
+	}
+
+	response := map[string]interface{}{
+		"lowest_point": lowest,
+		"day_index":    index,
+	}
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+func (s *APIServer) handleGetUpcoming(w http.ResponseWriter, r *http.Request) {
+	daysStr := r.URL.Query().Get("days")
+	days := 30 // default
+
+	if daysStr != "" {
+		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 {
+			days = d
+		}
+	}
+
+	includeRecurring, err := parseIncludeRecurring(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid 'include_recurring' parameter (expected true or false)")
+		return
+	}
+	list := func() ([]service.ListedTransaction, error) {
+		if includeRecurring {
+			return s.financeService.GetUpcomingTransactions(r.Context(), days)
+		}
+		start, end := s.financeService.UpcomingWindow(r.Context(), days)
+		return s.financeService.GetTransactionsBetween(r.Context(), start, end)
+	}
+
+	if groupBy := r.URL.Query().Get("group_by"); groupBy != "" {
+		transactions, err := list()
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		grouped, err := s.financeService.GroupTransactions(r.Context(), transactions, groupBy)
+		if errors.Is(err, service.ErrInvalidGrouping) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			s.writeForecastError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, grouped)
+		return
+	}
+
+	start, end := s.financeService.UpcomingWindow(r.Context(), days)
+	filter := service.AggregateFilter{Start: &start, End: &end, IncludeRecurring: includeRecurring}
+	s.writeTransactionList(w, r, filter, list)
+}
+
+// parseIncludeRecurring reads the include_recurring=true|false parameter of
+// the listings that mix in recurring occurrences, which they do by default.
+func parseIncludeRecurring(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("include_recurring")
+	if v == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+func (s *APIServer) handleGetTransactionsBetween(w http.ResponseWriter, r *http.Request) {
+	startStr := r.URL.Query().Get("start")
+	endStr := r.URL.Query().Get("end")
+
+	if startStr == "" || endStr == "" {
+		s.writeError(w, http.StatusBadRequest, "Both 'start' and 'end' query parameters are required")
+		return
+	}
+
+	start, err := s.parseDay(r, startStr)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start date: %s", err.Error()))
+		return
+	}
+
+	end, err := s.parseDay(r, endStr)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end date: %s", err.Error()))
+		return
+	}
+
+	includeRecurring, err := parseIncludeRecurring(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid 'include_recurring' parameter (expected true or false)")
+		return
+	}
+
+	filter := service.AggregateFilter{Start: &start, End: &end, IncludeRecurring: includeRecurring}
+	s.writeTransactionList(w, r, filter, func() ([]service.ListedTransaction, error) {
+		if !includeRecurring {
+			return s.financeService.GetTransactionsBetween(r.Context(), start, end)
+		}
+		return s.financeService.GetTransactionsWithRecurringsBetween(r.Context(), start, end)
+	})
+}
+
+// handleTransactionSummary answers with the headline numbers for a date
+// range, so a dashboard doesn't have to fetch the whole list for them.
+func (s *APIServer) handleTransactionSummary(w http.ResponseWriter, r *http.Request) {
+	startStr := r.URL.Query().Get("start")
+	endStr := r.URL.Query().Get("end")
+	if startStr == "" || endStr == "" {
+		s.writeError(w, http.StatusBadRequest, "Both 'start' and 'end' query parameters are required")
+		return
+	}
+	start, err := s.parseDay(r, startStr)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start date: %s", err.Error()))
+		return
+	}
+	end, err := s.parseDay(r, endStr)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end date: %s", err.Error()))
+		return
+	}
+	if end.Before(start) {
+		s.writeError(w, http.StatusBadRequest, "end must not be before start")
+		return
+	}
+	includeRecurring, err := parseIncludeRecurring(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid 'include_recurring' parameter (expected true or false)")
+		return
+	}
+
+	summary, err := s.financeService.SummarizeTransactions(r.Context(), start, end, includeRecurring)
+	if err != nil {
+		s.writeForecastError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, summary)
+}
+
+// handleImportTransactions reads an export file from the request body. With
+// dry_run=true nothing is saved and the parsed transactions are returned for
+// review.
+func (s *APIServer) handleImportTransactions(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "qif"
+	}
+
+	dryRun, err := dryRunRequested(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid 'dry_run' parameter")
+		return
+	}
+
+	result, err := s.financeService.ImportTransactions(r.Context(), format, r.Body, dryRun)
+	if err != nil {
+		if s.bodyTooLarge(w, err) {
+			return
+		}
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidImport) {
+			status = http.StatusBadRequest
+		}
+		s.writeError(w, status, err.Error())
+		return
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	s.writeJSON(w, status, result)
+}
+
+// handleExportTransactions downloads stored transactions as CSV laid out
+// for YNAB or Mint. Optional start and end dates narrow the range.
+func (s *APIServer) handleExportTransactions(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		s.writeError(w, http.StatusBadRequest, "'format' query parameter is required (ynab or mint)")
+		return
+	}
+
+	var start, end *time.Time
+	if v := r.URL.Query().Get("start"); v != "" {
+		d, err := s.parseDay(r, v)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start date: %s", err.Error()))
+			return
+		}
+		start = &d
+	}
+	if v := r.URL.Query().Get("end"); v != "" {
+		d, err := s.parseDay(r, v)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end date: %s", err.Error()))
+			return
+		}
+		end = &d
+	}
+
+	// Buffer so a failure part way through can still be reported as an error.
+	var buf bytes.Buffer
+	if err := s.financeService.ExportTransactions(r.Context(), format, &buf, start, end); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidExport) {
+			status = http.StatusBadRequest
+		}
+		s.writeError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"currentz-%s.csv\"", strings.ToLower(format)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		logf(w, "error writing export: %v", err)
+	}
+}
+
+// Tax endpoints
+func (s *APIServer) handleSetTransactionTax(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	var req SetTransactionTaxRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+
+	if err := s.financeService.SetTransactionTax(r.Context(), int32(id), float64(req.GrossAmount), float64(req.TaxWithheld)); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleSetTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	var req SetTransactionStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if req.Status == "" {
+		s.writeError(w, http.StatusBadRequest, "status is required (pending or cleared)")
+		return
+	}
+
+	ctx, ok := s.expectVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+	tx, err := s.financeService.SetTransactionStatus(ctx, int32(id), req.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTransactionNotFound):
+			s.writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrVersionConflict):
+			s.writeError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrInvalidStatus):
+			s.writeError(w, http.StatusBadRequest, err.Error())
+		default:
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	setVersionTag(w, tx.Version)
+	s.writeJSON(w, http.StatusOK, tx)
+}
+
+func (s *APIServer) handleSetTaxRate(w http.ResponseWriter, r *http.Request) {
+	var req SetTaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+
+	if err := s.financeService.SetTaxRate(r.Context(), req.Rate); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleGetTaxEstimate(w http.ResponseWriter, r *http.Request) {
+	year := time.Now().Year()
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		y, err := strconv.Atoi(yearStr)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid year")
+			return
+		}
+		year = y
+	}
+
+	estimate, err := s.financeService.EstimateTaxes(r.Context(), year)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, estimate)
+}
+
+func (s *APIServer) handleTagSelfEmployment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+	if err := s.financeService.TagSelfEmployment(r.Context(), int32(id)); err != nil {
+		s.writeSelfEmploymentError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleUntagSelfEmployment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+	if err := s.financeService.UntagSelfEmployment(r.Context(), int32(id)); err != nil {
+		s.writeSelfEmploymentError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleGetSelfEmploymentTax(w http.ResponseWriter, r *http.Request) {
+	year, ok := s.taxYear(w, r)
+	if !ok {
+		return
+	}
+	estimate, err := s.financeService.EstimateSelfEmploymentTax(r.Context(), year)
+	if err != nil {
+		s.writeSelfEmploymentError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, estimate)
+}
+
+func (s *APIServer) handleGenerateEstimatedPayments(w http.ResponseWriter, r *http.Request) {
+	year, ok := s.taxYear(w, r)
+	if !ok {
+		return
+	}
+	recurring, err := s.financeService.GenerateEstimatedPayments(r.Context(), year)
+	if err != nil {
+		s.writeSelfEmploymentError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, recurring)
+}
+
+// taxYear reads the year parameter, defaulting to the current year.
+func (s *APIServer) taxYear(w http.ResponseWriter, r *http.Request) (int, bool) {
+	yearStr := r.URL.Query().Get("year")
+	if yearStr == "" {
+		return s.financeService.Today(r.Context()).Year(), true
+	}
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid year")
+		return 0, false
+	}
+	return year, true
+}
+
+func (s *APIServer) writeSelfEmploymentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrNotIncome):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrSelfEmploymentRateNotSet),
+		errors.Is(err, service.ErrNoSelfEmploymentIncome),
+		errors.Is(err, service.ErrNoExchangeRate):
+		s.writeError(w, http.StatusConflict, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// Audit endpoints
+func (s *APIServer) handleGetAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := service.AuditFilter{
+		Entity:   q.Get("entity"),
+		EntityID: q.Get("entity_id"),
+		Action:   q.Get("action"),
+		Actor:    q.Get("actor"),
+	}
+
+	if sinceStr := q.Get("since"); sinceStr != "" {
+		since, err := dates.Parse(sinceStr, s.today(r))
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid since date: %s", err.Error()))
+			return
+		}
+		filter.Since = &since
+	}
+	if untilStr := q.Get("until"); untilStr != "" {
+		until, err := dates.Parse(untilStr, s.today(r))
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid until date: %s", err.Error()))
+			return
+		}
+		filter.Until = &until
+	}
+	if limitStr := q.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			filter.Limit = l
+		}
+	}
+
+	entries, err := s.financeService.ListAudit(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, entries)
+}
+
+// Trash endpoints
+func (s *APIServer) handleGetTrash(w http.ResponseWriter, r *http.Request) {
+	trash, err := s.financeService.ListTrash(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, trash)
+}
+
+func (s *APIServer) handleRestoreFromTrash(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	switch kind := r.URL.Query().Get("kind"); kind {
+	case "", "transaction":
+		tx, err := s.financeService.RestoreTransaction(r.Context(), int32(id))
+		if err != nil {
+			s.writeError(w, http.StatusNotFound, fmt.Sprintf("Transaction %d is not in the trash", id))
+			return
+		}
+		s.writeJSON(w, http.StatusOK, tx)
+	case "recurring":
+		rec, err := s.financeService.RestoreRecurring(r.Context(), int32(id))
+		if err != nil {
+			s.writeError(w, http.StatusNotFound, fmt.Sprintf("Recurring transaction %d is not in the trash", id))
+			return
+		}
+		s.writeJSON(w, http.StatusOK, rec)
+	default:
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid kind %q (expected transaction|recurring)", kind))
+	}
+}
+
+// Budget endpoints
+func (s *APIServer) handleGetBudgets(w http.ResponseWriter, r *http.Request) {
+	budgets, err := s.financeService.Budgets(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, budgets)
+}
+
+func (s *APIServer) handleSetBudget(w http.ResponseWriter, r *http.Request) {
+	var req SetBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if err := s.financeService.SetBudget(r.Context(), mux.Vars(r)["category"], float64(req.Limit)); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleDeleteBudget(w http.ResponseWriter, r *http.Request) {
+	if err := s.financeService.DeleteBudget(r.Context(), mux.Vars(r)["category"]); err != nil {
+		s.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleGetThreshold(w http.ResponseWriter, r *http.Request) {
+	threshold, ok, err := s.financeService.LowBalanceThreshold(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		s.writeJSON(w, http.StatusOK, map[string]interface{}{"threshold": nil})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]float64{"threshold": threshold})
+}
+
+func (s *APIServer) handleSetThreshold(w http.ResponseWriter, r *http.Request) {
+	var req SetThresholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if err := s.financeService.SetLowBalanceThreshold(r.Context(), float64(req.Threshold)); err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleClearThreshold(w http.ResponseWriter, r *http.Request) {
+	if err := s.financeService.ClearLowBalanceThreshold(r.Context()); err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// Account endpoints
+func (s *APIServer) handleListAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := s.financeService.ListAccounts(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, accounts)
+}
+
+func (s *APIServer) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
+	var req CreateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	acc, err := s.financeService.CreateAccount(r.Context(), service.AccountInput{
+		Name:         req.Name,
+		Type:         req.Type,
+		StatementDay: req.StatementDay,
+		DueDay:       req.DueDay,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAccount) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, acc)
+}
+
+func (s *APIServer) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+	err = s.financeService.DeleteAccount(r.Context(), int32(id))
+	switch {
+	case err == nil:
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+	case errors.Is(err, service.ErrAccountNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrAccountInUse):
+		s.writeError(w, http.StatusConflict, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func (s *APIServer) handleSetAccountInterest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+	var req SetAccountInterestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	acc, err := s.financeService.SetAccountInterest(r.Context(), int32(id), service.InterestInput{
+		Balance:     float64(req.Balance),
+		APY:         req.APY,
+		Compounding: req.Compounding,
+	})
+	switch {
+	case err == nil:
+		s.writeJSON(w, http.StatusOK, acc)
+	case errors.Is(err, service.ErrAccountNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidAccount):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func (s *APIServer) handleCardStatements(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+	statements, err := s.financeService.CardStatements(r.Context(), int32(id))
+	switch {
+	case err == nil:
+		s.writeJSON(w, http.StatusOK, statements)
+	case errors.Is(err, service.ErrAccountNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidAccount):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrNoExchangeRate):
+		s.writeError(w, http.StatusConflict, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// Timezone endpoints
+func (s *APIServer) handleGetTimezone(w http.ResponseWriter, r *http.Request) {
+	name, err := s.financeService.Timezone(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TimezoneRequest{Timezone: name})
+}
+
+func (s *APIServer) handleSetTimezone(w http.ResponseWriter, r *http.Request) {
+	var req TimezoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if err := s.financeService.SetTimezone(r.Context(), req.Timezone); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// Settings endpoints
+func (s *APIServer) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.financeService.Settings(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, settings)
+}
+
+func (s *APIServer) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var req SettingsRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown setting %s (expected one of %s)",
+				field, strings.Join(service.SettingKeys, ", ")))
+			return
+		}
+		s.writeDecodeError(w, err)
+		return
+	}
+	in := service.SettingsUpdate{
+		Timezone:           req.Timezone,
+		BaseCurrency:       req.BaseCurrency,
+		TaxRate:            req.TaxRate,
+		SelfEmploymentRate: req.SelfEmploymentRate,
+		ForecastDays:       req.ForecastDays,
+		RetentionYears:     req.RetentionYears,
+	}
+	if req.StartingBalance != nil {
+		in.StartingBalance = (*float64)(req.StartingBalance)
+	}
+	if req.LowBalanceThreshold != nil {
+		in.LowBalanceThreshold = (*float64)(req.LowBalanceThreshold)
+	}
+	if req.LargeBillThreshold != nil {
+		in.LargeBillThreshold = (*float64)(req.LargeBillThreshold)
+	}
+	if req.NotificationMinChange != nil {
+		in.NotificationMinChange = (*float64)(req.NotificationMinChange)
+	}
+	settings, err := s.financeService.UpdateSettings(r.Context(), in)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSetting) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, settings)
+}
+
+// handleResetSetting puts one setting back to its default.
+func (s *APIServer) handleResetSetting(w http.ResponseWriter, r *http.Request) {
+	if err := s.financeService.ResetSetting(r.Context(), mux.Vars(r)["key"]); err != nil {
+		if errors.Is(err, service.ErrUnknownSetting) {
+			s.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// Currency endpoints
+func (s *APIServer) handleGetCurrency(w http.ResponseWriter, r *http.Request) {
+	base, err := s.financeService.BaseCurrency(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rates, err := s.financeService.ListExchangeRates(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, CurrencyResponse{BaseCurrency: base, Rates: rates})
+}
+
+func (s *APIServer) handleSetBaseCurrency(w http.ResponseWriter, r *http.Request) {
+	var req SetBaseCurrencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if err := s.financeService.SetBaseCurrency(r.Context(), req.Currency); err != nil {
+		if errors.Is(err, service.ErrInvalidCurrency) {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleSetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	var req SetExchangeRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	rate, err := s.financeService.SetExchangeRate(r.Context(), mux.Vars(r)["code"], req.Rate)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, rate)
+}
+
+func (s *APIServer) handleDeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	err := s.financeService.DeleteExchangeRate(r.Context(), mux.Vars(r)["code"])
+	switch {
+	case err == nil:
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+	case errors.Is(err, service.ErrInvalidCurrency):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrNoExchangeRate):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// Backup endpoints
+func (s *APIServer) handleBackup(w http.ResponseWriter, r *http.Request) {
+	backup, err := s.financeService.Backup(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filename := fmt.Sprintf("currentz-backup-%s.json", backup.CreatedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	s.writeJSON(w, http.StatusOK, backup)
+}
+
+// handleSaveBackup takes a backup and keeps it in blob storage.
+func (s *APIServer) handleSaveBackup(w http.ResponseWriter, r *http.Request) {
+	stored, err := s.financeService.SaveBackup(r.Context())
+	if err != nil {
+		s.writeStoredBackupError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, stored)
+}
+
+func (s *APIServer) handleListStoredBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := s.financeService.ListStoredBackups(r.Context())
+	if err != nil {
+		s.writeStoredBackupError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, backups)
+}
+
+// handleGetStoredBackup downloads a stored backup, through a presigned link
+// when the store has them.
+func (s *APIServer) handleGetStoredBackup(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	url, err := s.financeService.StoredBackupURL(r.Context(), name)
+	if err != nil {
+		s.writeStoredBackupError(w, err)
+		return
+	}
+	if url != "" {
+		http.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+
+	rc, err := s.financeService.OpenStoredBackup(r.Context(), name)
+	if err != nil {
+		s.writeStoredBackupError(w, err)
+		return
+	}
+	defer rc.Close()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, rc); err != nil {
+		logf(w, "error writing backup %s: %v", name, err)
+	}
+}
+
+func (s *APIServer) writeStoredBackupError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrBackupNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrStorageDisabled):
+		s.writeError(w, http.StatusNotImplemented, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// handleRestore replaces all data with a document from GET /api/backup.
+func (s *APIServer) handleRestore(w http.ResponseWriter, r *http.Request) {
+	var backup service.Backup
+	if err := json.NewDecoder(r.Body).Decode(&backup); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+
+	summary, err := s.financeService.Restore(r.Context(), backup)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidBackup) {
+			status = http.StatusBadRequest
+		}
+		s.writeError(w, status, err.Error())
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, summary)
+}
+
+// Event stream endpoint
+
+// eventHeartbeatInterval keeps idle SSE connections from being closed by proxies
+var eventHeartbeatInterval = 30 * time.Second
+
+func (s *APIServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	events, cancel := s.financeService.SubscribeEvents(r.Context())
+	defer cancel()
+
+	// The stream outlives the server's write timeout by design.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	// Comment line so clients see the stream open immediately
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				logf(w, "error encoding event: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, payload)
+			flusher.Flush()
+		}
+	}
+}
+
+// actorMiddleware attributes mutations to the caller in the audit log,
+// using the X-Actor header when a client identifies itself. A logged-in
+// user is always attributed by username.
+func actorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Header.Get("X-Actor")
+		if u, ok := service.UserFromContext(r.Context()); ok {
+			actor = u.Username
+		} else if actor == "" {
+			actor = "api"
+		}
+		next.ServeHTTP(w, r.WithContext(service.WithActor(r.Context(), actor)))
+	})
+}
+
+// SetupRoutes returns the server's handler. Every API route is served
+// under /households/{id} too, as if the household were in X-Household.
+func (s *APIServer) SetupRoutes() *mux.Router {
+	api := s.routes()
+	r := mux.NewRouter()
+	r.PathPrefix("/households/{household:[0-9]+}/api/").Handler(householdPrefix(api))
+	r.PathPrefix("/").Handler(api)
+	return r
+}
+
+func (s *APIServer) routes() *mux.Router {
+	r := mux.NewRouter()
+
+	// Number the request before anything can fail it
+	r.Use(requestIDMiddleware)
+
+	// Compress next so error responses from the other middleware are
+	// covered too
+	r.Use(s.compressMiddleware)
+
+	// Apply CORS middleware
+	r.Use(s.corsMiddleware)
+
+	// Answer a panicking handler with a 500 rather than a dropped
+	// connection
+	r.Use(s.recoverMiddleware)
+	r.Use(s.sessionMiddleware)
+	r.Use(s.apiKeyMiddleware)
+	r.Use(s.householdMiddleware)
+	r.Use(s.roleMiddleware)
+	r.Use(actorMiddleware)
+	r.Use(s.maintenanceMiddleware)
+	r.Use(s.versionMiddleware)
+	r.Use(s.limitsMiddleware)
+	r.Use(s.dryRunMiddleware)
+
+	// Catch-all OPTIONS handler so preflights always match
+	r.PathPrefix("/").Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// corsMiddleware already set the headers; just OK it.
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Admin routes
+	r.HandleFunc("/api/admin/maintenance", s.handleGetMaintenance).Methods("GET")
+	r.HandleFunc("/api/admin/maintenance", s.handleSetMaintenance).Methods("PUT")
+	r.HandleFunc("/api/version", s.handleGetVersion).Methods("GET")
+
+	// Transaction routes
+	r.HandleFunc("/api/transactions", s.conditional(s.handleGetTransactions)).Methods("GET")
+	r.HandleFunc("/api/transactions/income", s.handleAddIncome).Methods("POST")
+	r.HandleFunc("/api/transactions/expense", s.handleAddExpense).Methods("POST")
+	r.HandleFunc("/api/transactions/{id:[0-9]+}", s.handleDeleteTransaction).Methods("DELETE")
+	r.HandleFunc("/api/transactions/between", s.conditional(s.handleGetTransactionsBetween)).Methods("GET")
+	r.HandleFunc("/api/transactions/upcoming", s.conditional(s.handleGetUpcoming)).Methods("GET")
+	r.HandleFunc("/api/transactions/summary", s.conditional(s.handleTransactionSummary)).Methods("GET")
+	r.HandleFunc("/api/transactions/import", s.handleImportTransactions).Methods("POST")
+	r.HandleFunc("/api/transactions/export", s.handleExportTransactions).Methods("GET")
+	r.HandleFunc("/api/transactions/{id:[0-9]+}/tax", s.handleSetTransactionTax).Methods("PUT")
+	r.HandleFunc("/api/transactions/{id:[0-9]+}/self-employment", s.handleTagSelfEmployment).Methods("PUT")
+	r.HandleFunc("/api/transactions/{id:[0-9]+}/self-employment", s.handleUntagSelfEmployment).Methods("DELETE")
+	r.HandleFunc("/api/transactions/{id:[0-9]+}/status", s.handleSetTransactionStatus).Methods("PUT")
+	r.HandleFunc("/api/transactions/{id:[0-9]+}/attachments", s.handleAddAttachment).Methods("POST")
+	r.HandleFunc("/api/transactions/{id:[0-9]+}/attachments", s.handleListAttachments).Methods("GET")
+	r.HandleFunc("/api/transactions/{id:[0-9]+}/attachments/{attachment:[0-9]+}", s.handleGetAttachment).Methods("GET")
+	r.HandleFunc("/api/transactions/{id:[0-9]+}/attachments/{attachment:[0-9]+}", s.handleDeleteAttachment).Methods("DELETE")
+
+	// Balance routes
+	r.HandleFunc("/api/balance", s.conditional(s.handleGetBalance)).Methods("GET")
+	r.HandleFunc("/api/balance", s.handleSetBalance).Methods("PUT")
+
+	// Recurring transaction routes
+	r.HandleFunc("/api/recurring", s.handleCreateRecurring).Methods("POST")
+	r.HandleFunc("/api/recurring", s.conditional(s.handleListRecurring)).Methods("GET")
+	r.HandleFunc("/api/recurring/import", s.handleImportRecurring).Methods("POST")
 	r.HandleFunc("/api/recurring/{id:[0-9]+}", s.handleDeleteRecurring).Methods("DELETE")
 	r.HandleFunc("/api/recurring/{id:[0-9]+}/active", s.handleSetRecurringActive).Methods("PUT")
+	r.HandleFunc("/api/recurring/{id:[0-9]+}/anchor", s.handleReanchorRecurring).Methods("PUT")
+	r.HandleFunc("/api/recurring/{id:[0-9]+}/pause", s.handlePauseRecurring).Methods("PUT", "DELETE")
+	r.HandleFunc("/api/recurring/suggestions", s.handleRecurringSuggestions).Methods("GET")
+	r.HandleFunc("/api/recurring/suggestions/{id:[0-9a-f]+}/accept", s.handleAcceptRecurringSuggestion).Methods("POST")
+	r.HandleFunc("/api/recurring/{id:[0-9]+}/calendar.ics", s.handleRecurringCalendar).Methods("GET")
+	r.HandleFunc("/api/recurring/{id:[0-9]+}/reminder", s.handleRecurringReminder).Methods("PUT", "DELETE")
+	r.HandleFunc("/api/reminders", s.handleListReminders).Methods("GET")
 
 	// Forecast routes
-	r.HandleFunc("/api/forecast", s.handleGetForecast).Methods("GET")
-	r.HandleFunc("/api/forecast/lowest", s.handleGetLowestPoint).Methods("GET")
+	r.HandleFunc("/api/forecast", s.conditional(s.handleGetForecast)).Methods("GET")
+	r.HandleFunc("/api/forecast/all", s.conditional(s.handleGetAllForecasts)).Methods("GET")
+	r.HandleFunc("/api/forecast/lowest", s.conditional(s.handleGetLowestPoint)).Methods("GET")
+	r.HandleFunc("/api/forecast/history", s.conditional(s.handleForecastHistory)).Methods("GET")
+	r.HandleFunc("/api/forecast/compare", s.handleCompareForecasts).Methods("POST")
+
+	// Saved scenario routes
+	r.HandleFunc("/api/scenarios", s.conditional(s.handleListScenarios)).Methods("GET")
+	r.HandleFunc("/api/scenarios", s.handleCreateScenario).Methods("POST")
+	r.HandleFunc("/api/scenarios/{id:[0-9]+}", s.conditional(s.handleGetScenario)).Methods("GET")
+	r.HandleFunc("/api/scenarios/{id:[0-9]+}", s.handleUpdateScenario).Methods("PUT")
+	r.HandleFunc("/api/scenarios/{id:[0-9]+}", s.handleDeleteScenario).Methods("DELETE")
+	r.HandleFunc("/api/scenarios/{id:[0-9]+}/forecast", s.conditional(s.handleScenarioForecast)).Methods("GET")
+	r.HandleFunc("/api/forecast/contributions", s.conditional(s.handleGetContributions)).Methods("GET")
+	r.HandleFunc("/api/forecast/monthly", s.conditional(s.handleGetMonthlyOutlook)).Methods("GET")
+	r.HandleFunc("/api/forecast/threshold", s.conditional(s.handleGetThreshold)).Methods("GET")
+	r.HandleFunc("/api/reports/variance", s.conditional(s.handleGetVariance)).Methods("GET")
+	r.HandleFunc("/api/reports/heatmap", s.conditional(s.handleGetHeatmap)).Methods("GET")
+	r.HandleFunc("/api/reports/top", s.conditional(s.handleGetTopSpending)).Methods("GET")
+	r.HandleFunc("/api/insights", s.conditional(s.handleGetInsights)).Methods("GET")
+	r.HandleFunc("/api/calendar", s.conditional(s.handleGetCalendar)).Methods("GET")
+	r.HandleFunc("/api/forecast/threshold", s.handleSetThreshold).Methods("PUT")
+	r.HandleFunc("/api/forecast/threshold", s.handleClearThreshold).Methods("DELETE")
+
+	// Tax routes
+	r.HandleFunc("/api/taxes/estimate", s.handleGetTaxEstimate).Methods("GET")
+	r.HandleFunc("/api/taxes/rate", s.handleSetTaxRate).Methods("PUT")
+	r.HandleFunc("/api/taxes/self-employment", s.handleGetSelfEmploymentTax).Methods("GET")
+	r.HandleFunc("/api/taxes/self-employment/payments", s.handleGenerateEstimatedPayments).Methods("POST")
+
+	// Audit routes
+	r.HandleFunc("/api/audit", s.handleGetAudit).Methods("GET")
+
+	// Trash routes
+	r.HandleFunc("/api/trash", s.handleGetTrash).Methods("GET")
+	r.HandleFunc("/api/trash/{id:[0-9]+}/restore", s.handleRestoreFromTrash).Methods("POST")
+
+	// Archive routes
+	r.HandleFunc("/api/archive/transactions", s.handleListArchived).Methods("GET")
+	r.HandleFunc("/api/archive/run", s.handleRunArchival).Methods("POST")
+
+	// Sync routes
+	r.HandleFunc("/api/sync", s.handleSync).Methods("GET")
+
+	// Budget routes
+	r.HandleFunc("/api/budgets", s.conditional(s.handleGetBudgets)).Methods("GET")
+	r.HandleFunc("/api/budgets/{category}", s.handleSetBudget).Methods("PUT")
+	r.HandleFunc("/api/budgets/{category}", s.handleDeleteBudget).Methods("DELETE")
+
+	// Account routes
+	r.HandleFunc("/api/accounts", s.conditional(s.handleListAccounts)).Methods("GET")
+	r.HandleFunc("/api/accounts", s.handleCreateAccount).Methods("POST")
+	r.HandleFunc("/api/accounts/{id:[0-9]+}", s.handleDeleteAccount).Methods("DELETE")
+	r.HandleFunc("/api/accounts/{id:[0-9]+}/statements", s.handleCardStatements).Methods("GET")
+	r.HandleFunc("/api/accounts/{id:[0-9]+}/interest", s.handleSetAccountInterest).Methods("PUT")
+
+	// Envelope routes
+	r.HandleFunc("/api/envelopes", s.conditional(s.handleListEnvelopes)).Methods("GET")
+	r.HandleFunc("/api/envelopes", s.handleCreateEnvelope).Methods("POST")
+	r.HandleFunc("/api/envelopes/{id:[0-9]+}", s.conditional(s.handleGetEnvelope)).Methods("GET")
+	r.HandleFunc("/api/envelopes/{id:[0-9]+}", s.handleUpdateEnvelope).Methods("PUT")
+	r.HandleFunc("/api/envelopes/{id:[0-9]+}", s.handleDeleteEnvelope).Methods("DELETE")
+
+	// Notification routes
+	r.HandleFunc("/api/notifications/channels", s.conditional(s.handleListNotificationChannels)).Methods("GET")
+	r.HandleFunc("/api/notifications/channels", s.handleCreateNotificationChannel).Methods("POST")
+	r.HandleFunc("/api/notifications/channels/{id:[0-9]+}", s.conditional(s.handleGetNotificationChannel)).Methods("GET")
+	r.HandleFunc("/api/notifications/channels/{id:[0-9]+}", s.handleUpdateNotificationChannel).Methods("PUT")
+	r.HandleFunc("/api/notifications/channels/{id:[0-9]+}", s.handleDeleteNotificationChannel).Methods("DELETE")
+	r.HandleFunc("/api/notifications/channels/{id:[0-9]+}/test", s.handleTestNotificationChannel).Methods("POST")
+
+	// Session routes
+	r.HandleFunc("/api/auth/login", s.handleLogin).Methods("POST")
+	r.HandleFunc("/api/auth/refresh", s.handleRefresh).Methods("POST")
+	r.HandleFunc("/api/auth/logout", s.handleLogout).Methods("POST")
+	r.HandleFunc("/api/auth/me", s.handleMe).Methods("GET")
+
+	// User routes
+	r.HandleFunc("/api/users", s.handleListUsers).Methods("GET")
+	r.HandleFunc("/api/users", s.handleCreateUser).Methods("POST")
+	r.HandleFunc("/api/users/{id:[0-9]+}", s.handleGetUser).Methods("GET")
+	r.HandleFunc("/api/users/{id:[0-9]+}", s.handleDeleteUser).Methods("DELETE")
+	r.HandleFunc("/api/users/{id:[0-9]+}/role", s.handleSetUserRole).Methods("PUT")
+	r.HandleFunc("/api/users/{id:[0-9]+}/password", s.handleSetUserPassword).Methods("PUT")
+
+	// Household routes
+	r.HandleFunc("/api/households", s.handleListHouseholds).Methods("GET")
+	r.HandleFunc("/api/households", s.handleCreateHousehold).Methods("POST")
+	r.HandleFunc("/api/households/{household:[0-9]+}", s.handleGetHousehold).Methods("GET")
+	r.HandleFunc("/api/households/{household:[0-9]+}", s.handleDeleteHousehold).Methods("DELETE")
+	r.HandleFunc("/api/households/{household:[0-9]+}/members", s.handleListMembers).Methods("GET")
+	r.HandleFunc("/api/households/{household:[0-9]+}/members", s.handleAddMember).Methods("POST")
+	r.HandleFunc("/api/households/{household:[0-9]+}/members/{user:[0-9]+}/role", s.handleSetMemberRole).Methods("PUT")
+	r.HandleFunc("/api/households/{household:[0-9]+}/members/{user:[0-9]+}", s.handleRemoveMember).Methods("DELETE")
+	r.HandleFunc("/api/households/{household:[0-9]+}/invitations", s.handleListInvitations).Methods("GET")
+	r.HandleFunc("/api/households/{household:[0-9]+}/invitations", s.handleCreateInvitation).Methods("POST")
+	r.HandleFunc("/api/households/{household:[0-9]+}/invitations/{invitation:[0-9]+}", s.handleRevokeInvitation).Methods("DELETE")
+	r.HandleFunc("/api/invitations/accept", s.handleAcceptInvitation).Methods("POST")
+
+	// Settings routes
+	r.HandleFunc("/api/settings", s.conditional(s.handleGetSettings)).Methods("GET")
+	r.HandleFunc("/api/settings", s.handleUpdateSettings).Methods("PUT")
+	r.HandleFunc("/api/settings/{key}", s.handleResetSetting).Methods("DELETE")
+
+	// Timezone routes
+	r.HandleFunc("/api/timezone", s.handleGetTimezone).Methods("GET")
+	r.HandleFunc("/api/timezone", s.handleSetTimezone).Methods("PUT")
+
+	// Currency routes
+	r.HandleFunc("/api/currency", s.handleGetCurrency).Methods("GET")
+	r.HandleFunc("/api/currency/base", s.handleSetBaseCurrency).Methods("PUT")
+	r.HandleFunc("/api/currency/rates/{code}", s.handleSetExchangeRate).Methods("PUT")
+	r.HandleFunc("/api/currency/rates/{code}", s.handleDeleteExchangeRate).Methods("DELETE")
+
+	// Backup routes
+	r.HandleFunc("/api/backup", s.handleBackup).Methods("GET")
+	r.HandleFunc("/api/restore", s.handleRestore).Methods("POST")
+	r.HandleFunc("/api/backups", s.handleSaveBackup).Methods("POST")
+	r.HandleFunc("/api/backups", s.handleListStoredBackups).Methods("GET")
+	r.HandleFunc("/api/backups/{name}", s.handleGetStoredBackup).Methods("GET")
+
+	// Live update stream
+	r.HandleFunc("/api/events", s.handleEvents).Methods("GET")
+
+	// Probes for load balancers and orchestrators, outside /api/ so they
+	// never need the API key
+	r.HandleFunc("/healthz", s.handleHealthz).Methods("GET", "HEAD")
+	r.HandleFunc("/readyz", s.handleReadyz).Methods("GET", "HEAD")
+
+	// Web frontend: the dashboard, or the files given to SetStaticFiles.
+	// Unknown /api/ paths still 404 instead of falling back to index.html.
+	r.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+		return !strings.HasPrefix(r.URL.Path, "/api/")
+	}).Methods("GET", "HEAD").Handler(newStaticHandler(s.static))
 
 	return r
 }
@@ -437,22 +2523,155 @@ func (s *APIServer) SetupRoutes() *mux.Router {
 func (s *APIServer) Start(addr string) error {
 	router := s.SetupRoutes()
 
-	log.Printf("Starting API server on %s", addr)
+	scheme := "http"
+	if s.tls != nil {
+		scheme = "https"
+	}
+	log.Printf("Starting API server on %s (%s)", addr, scheme)
 	log.Println("Available endpoints:")
+	log.Println("  GET    / - Web dashboard (or the frontend in CURRENTZ_STATIC_DIR)")
+	log.Println("  GET    /healthz - Liveness probe")
+	log.Println("  GET    /readyz - Readiness probe (database reachable, migrations applied)")
+	log.Println("  GET    /api/version - Build version, commit and date")
 	log.Println("  GET    /api/transactions - Get all transactions")
+	log.Println("  GET    /api/transactions?sort=date|amount|description&order=asc|desc - Get transactions sorted")
+	log.Println("         (list endpoints accept ?aggregates=true|only for count and totals)")
 	log.Println("  POST   /api/transactions/income - Add income")
 	log.Println("  POST   /api/transactions/expense - Add expense")
 	log.Println("  DELETE /api/transactions/{id} - Delete transaction")
 	log.Println("  GET    /api/transactions/between?start=DATE&end=DATE - Get transactions in range")
 	log.Println("  GET    /api/transactions/upcoming?days=N - Get upcoming transactions")
+	log.Println("  GET    /api/transactions/upcoming?group_by=day|week - Get upcoming transactions grouped, with totals")
+	log.Println("  GET    /api/transactions/between|upcoming?include_recurring=false - Leave out recurring occurrences")
+	log.Println("  GET    /api/transactions/summary?start=DATE&end=DATE - Get totals, largest expense and average daily spend")
+	log.Println("  POST   /api/transactions/import?format=qif|ynab|mint&dry_run=true - Import transactions from a QIF, YNAB or Mint file")
+	log.Println("  GET    /api/transactions/export?format=ynab|mint&start=&end= - Export transactions as YNAB or Mint CSV")
+	log.Println("  PUT    /api/transactions/{id}/tax - Tag income with gross pay and withholding")
+	log.Println("  PUT    /api/transactions/{id}/self-employment - Tag income as self-employment (DELETE to untag)")
+	log.Println("  PUT    /api/transactions/{id}/status - Mark a transaction pending or cleared")
+	log.Println("  POST   /api/transactions/{id}/attachments - Upload a receipt (multipart field \"file\")")
+	log.Println("  GET    /api/transactions/{id}/attachments - List a transaction's attachments")
+	log.Println("  GET    /api/transactions/{id}/attachments/{attachment} - Download an attachment")
+	log.Println("  DELETE /api/transactions/{id}/attachments/{attachment} - Delete an attachment")
 	log.Println("  GET    /api/balance - Get starting balance")
 	log.Println("  PUT    /api/balance - Set starting balance")
 	log.Println("  POST   /api/recurring - Create recurring transaction")
 	log.Println("  GET    /api/recurring - List recurring transactions")
+	log.Println("  GET    /api/recurring?sort=date|amount|description&order=asc|desc - List recurring transactions sorted")
+	log.Println("  POST   /api/recurring/import?dry_run=true - Create recurring transactions from a CSV file")
 	log.Println("  DELETE /api/recurring/{id} - Delete recurring transaction")
 	log.Println("  PUT    /api/recurring/{id}/active - Set recurring transaction active status")
-	log.Println("  GET    /api/forecast - Get 90-day forecast")
-	log.Println("  GET    /api/forecast/lowest - Get lowest balance point in forecast")
+	log.Println("  PUT    /api/recurring/{id}/anchor - Move a weekly/biweekly schedule to another week")
+	log.Println("  PUT    /api/recurring/{id}/pause - Skip a recurring's occurrences for a date range")
+	log.Println("  DELETE /api/recurring/{id}/pause - Resume a paused recurring")
+	log.Println("  GET    /api/recurring/suggestions - Suggest recurrings from repeating transactions")
+	log.Println("  POST   /api/recurring/suggestions/{id}/accept - Create the suggested recurring")
+	log.Println("  GET    /api/recurring/{id}/calendar.ics - Export recurring occurrences as iCalendar")
+	log.Println("  PUT    /api/recurring/{id}/reminder - Remind of each occurrence some days before it's due")
+	log.Println("  DELETE /api/recurring/{id}/reminder - Stop reminders for a recurring")
+	log.Println("  GET    /api/reminders?days=30 - List upcoming bill reminders")
+	log.Println("  GET    /api/forecast?days=90&available=true - Get balance forecast (default 90 days), optionally with the balance net of envelopes")
+	log.Println("  GET    /api/forecast (Accept: application/x-ndjson) - Stream the forecast one day per line")
+	log.Println("  GET    /api/forecast/all?days=90 - Forecast every account at once, with the total")
+	log.Println("  GET    /api/forecast/lowest?available=true - Get lowest balance point in forecast, optionally net of envelopes")
+	log.Println("  GET    /api/forecast/history?date=2025-08-01 - Get the forecast as it was computed that day, or list the days kept")
+	log.Println("  POST   /api/forecast/compare - Compare a what-if scenario with the forecast, or two scenarios, day by day")
+	log.Println("  GET    /api/scenarios - List saved what-if scenarios")
+	log.Println("  POST   /api/scenarios - Save a named what-if scenario")
+	log.Println("  GET    /api/scenarios/{id} - Get a saved scenario")
+	log.Println("  PUT    /api/scenarios/{id} - Replace a saved scenario")
+	log.Println("  DELETE /api/scenarios/{id} - Delete a saved scenario")
+	log.Println("  GET    /api/scenarios/{id}/forecast?days=90 - Compare a saved scenario with the current forecast")
+	log.Println("  GET    /api/forecast/contributions?days=90 - Rank recurrings by their effect on the forecast")
+	log.Println("  GET    /api/forecast/monthly?months=12 - Monthly totals, with prorated yearly bills accrued")
+	log.Println("  GET    /api/forecast/threshold - Get low-balance warning threshold")
+	log.Println("  PUT    /api/forecast/threshold - Set low-balance warning threshold")
+	log.Println("  DELETE /api/forecast/threshold - Turn off low-balance warnings")
+	log.Println("  GET    /api/reports/variance?month=YYYY-MM - Compare a month's forecast with what was recorded")
+	log.Println("  GET    /api/reports/heatmap?start=DATE&end=DATE - Get spending per day, weekday and week")
+	log.Println("  GET    /api/reports/top?by=payee|description&limit=10 - Rank the biggest spending sinks")
+	log.Println("  GET    /api/insights?month=YYYY-MM - Summarize spending trends against the month before")
+	log.Println("  GET    /api/calendar?month=YYYY-MM - List a month's transactions by day with daily totals")
+	log.Println("  GET    /api/taxes/estimate?year=N - Get projected taxes owed vs withheld")
+	log.Println("  PUT    /api/taxes/rate - Set effective annual tax rate")
+	log.Println("  GET    /api/taxes/self-employment?year=N - Get estimated tax on self-employment income")
+	log.Println("  POST   /api/taxes/self-employment/payments?year=N - Set up quarterly estimated payments")
+	log.Println("  GET    /api/audit?entity=&entity_id=&action=&actor=&since=&until=&limit= - Query audit log")
+	log.Println("  GET    /api/trash - List deleted transactions and recurrings")
+	log.Println("  POST   /api/trash/{id}/restore?kind=transaction|recurring - Restore a deleted item")
+	log.Println("  GET    /api/archive/transactions?start=YYYY-MM-DD&end=YYYY-MM-DD - List archived transactions")
+	log.Println("  POST   /api/archive/run - Archive transactions older than the retention period now")
+	log.Println("  GET    /api/sync?since=0&limit=500 - Transactions, recurrings and settings changed since a cursor")
+	log.Println("  GET    /api/budgets - List monthly category budgets")
+	log.Println("  PUT    /api/budgets/{category} - Set a category's monthly budget")
+	log.Println("  DELETE /api/budgets/{category} - Remove a category's budget")
+	log.Println("  GET    /api/accounts - List accounts")
+	log.Println("  POST   /api/accounts - Create a checking, savings or credit card account")
+	log.Println("  DELETE /api/accounts/{id} - Delete an account with no transactions")
+	log.Println("  GET    /api/accounts/{id}/statements - List a credit card's statements not yet due")
+	log.Println("  PUT    /api/accounts/{id}/interest - Set an account's balance and APY for the forecast")
+	log.Println("  GET    /api/envelopes - List envelopes with what each holds today")
+	log.Println("  POST   /api/envelopes - Create an envelope that sets money aside each month")
+	log.Println("  GET    /api/envelopes/{id} - Get an envelope")
+	log.Println("  PUT    /api/envelopes/{id} - Replace an envelope")
+	log.Println("  DELETE /api/envelopes/{id} - Delete an envelope")
+	log.Println("  GET    /api/notifications/channels - List Slack and Telegram notification channels")
+	log.Println("  POST   /api/notifications/channels - Add a notification channel")
+	log.Println("  GET    /api/notifications/channels/{id} - Get a notification channel")
+	log.Println("  PUT    /api/notifications/channels/{id} - Replace a notification channel")
+	log.Println("  DELETE /api/notifications/channels/{id} - Delete a notification channel")
+	log.Println("  POST   /api/notifications/channels/{id}/test - Send a test notification")
+	log.Println("  POST   /api/auth/login - Log in, for an access token and a refresh token")
+	log.Println("  POST   /api/auth/refresh - Swap a refresh token for new tokens")
+	log.Println("  POST   /api/auth/logout - End the session a refresh token belongs to")
+	log.Println("  GET    /api/auth/me - Get the logged-in user")
+	log.Println("  GET    /api/users - List users (owners only)")
+	log.Println("  POST   /api/users - Create a user with the owner, editor or viewer role")
+	log.Println("  GET    /api/users/{id} - Get a user")
+	log.Println("  DELETE /api/users/{id} - Delete a user")
+	log.Println("  PUT    /api/users/{id}/role - Change a user's role")
+	log.Println("  PUT    /api/users/{id}/password - Change a password (users may change their own)")
+	log.Println("  GET    /api/households - List your households (every household with the API key)")
+	log.Println("  POST   /api/households - Create a household you own")
+	log.Println("  GET    /api/households/{id} - Get a household")
+	log.Println("  DELETE /api/households/{id} - Delete a household and all its data")
+	log.Println("  GET    /api/households/{id}/members - List a household's members")
+	log.Println("  POST   /api/households/{id}/members - Add a user to a household")
+	log.Println("  PUT    /api/households/{id}/members/{user}/role - Change a member's role")
+	log.Println("  DELETE /api/households/{id}/members/{user} - Remove a member (members may leave)")
+	log.Println("  GET    /api/households/{id}/invitations - List a household's invitations")
+	log.Println("  POST   /api/households/{id}/invitations - Invite someone, returning the token once")
+	log.Println("  DELETE /api/households/{id}/invitations/{invitation} - Revoke an invitation")
+	log.Println("  POST   /api/invitations/accept - Join the household an invitation is for")
+	log.Println("         (other routes work on the X-Household header's household, or under /households/{id}/api/...)")
+	log.Println("  GET    /api/timezone - Get the timezone that decides today's date")
+	log.Println("  PUT    /api/timezone - Set the timezone (IANA name)")
+	log.Println("  GET    /api/currency - Get base currency and exchange rates")
+	log.Println("  PUT    /api/currency/base - Set base currency")
+	log.Println("  PUT    /api/currency/rates/{code} - Set exchange rate into the base currency")
+	log.Println("  DELETE /api/currency/rates/{code} - Remove an exchange rate")
+	log.Println("  GET    /api/backup - Download all data as a JSON backup")
+	log.Println("  POST   /api/restore - Replace all data with a JSON backup")
+	log.Println("  POST   /api/backups - Save a backup to blob storage")
+	log.Println("  GET    /api/backups - List stored backups")
+	log.Println("  GET    /api/backups/{name} - Download a stored backup")
+	log.Println("  GET    /api/admin/maintenance - Show whether maintenance mode is on")
+	log.Println("  PUT    /api/admin/maintenance - Turn read-only maintenance mode on or off")
+	log.Println("  GET    /api/events - Stream live data change events (SSE)")
+	if status := s.Maintenance(); status.Enabled {
+		log.Printf("Maintenance mode is on: %s", status.Message)
+	}
 
-	return http.ListenAndServe(addr, router)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           router,
+		ReadHeaderTimeout: s.limits.ReadHeaderTimeout,
+		ReadTimeout:       s.limits.ReadTimeout,
+		WriteTimeout:      s.limits.WriteTimeout,
+		IdleTimeout:       s.limits.IdleTimeout,
+	}
+	if s.tls != nil {
+		return s.serveTLS(srv)
+	}
+	return srv.ListenAndServe()
 }
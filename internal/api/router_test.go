@@ -1,17 +1,37 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"math/big"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/buildinfo"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/household"
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/jdelles/currentz/internal/notify"
 	"github.com/jdelles/currentz/internal/service"
+	"github.com/jdelles/currentz/internal/storage/blob"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -19,6 +39,7 @@ import (
 
 type MockFinanceService struct {
 	mock.Mock
+	hooks []func(service.Event)
 }
 
 func (m *MockFinanceService) GetAllTransactions(ctx context.Context) ([]service.Transaction, error) {
@@ -26,16 +47,55 @@ func (m *MockFinanceService) GetAllTransactions(ctx context.Context) ([]service.
 	return args.Get(0).([]service.Transaction), args.Error(1)
 }
 
-func (m *MockFinanceService) AddIncome(ctx context.Context, date time.Time, amount float64, description string) error {
-	args := m.Called(ctx, date, amount, description)
+func (m *MockFinanceService) GetTransactionsByStatus(ctx context.Context, status string) ([]service.Transaction, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]service.Transaction), args.Error(1)
+}
+
+func (m *MockFinanceService) SetTransactionStatus(ctx context.Context, id int32, status string) (service.Transaction, error) {
+	args := m.Called(ctx, id, status)
+	return args.Get(0).(service.Transaction), args.Error(1)
+}
+
+func (m *MockFinanceService) AddAttachment(ctx context.Context, id int32, filename string, r io.Reader) (service.Attachment, error) {
+	args := m.Called(ctx, id, filename, r)
+	return args.Get(0).(service.Attachment), args.Error(1)
+}
+
+func (m *MockFinanceService) ListAttachments(ctx context.Context, id int32) ([]service.Attachment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]service.Attachment), args.Error(1)
+}
+
+func (m *MockFinanceService) OpenAttachment(ctx context.Context, id, attID int32) (service.Attachment, io.ReadCloser, error) {
+	args := m.Called(ctx, id, attID)
+	if args.Get(1) == nil {
+		return args.Get(0).(service.Attachment), nil, args.Error(2)
+	}
+	return args.Get(0).(service.Attachment), args.Get(1).(io.ReadCloser), args.Error(2)
+}
+
+func (m *MockFinanceService) AttachmentURL(ctx context.Context, id, attID int32) (string, error) {
+	args := m.Called(ctx, id, attID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockFinanceService) DeleteAttachment(ctx context.Context, id, attID int32) error {
+	args := m.Called(ctx, id, attID)
 	return args.Error(0)
 }
 
-func (m *MockFinanceService) AddExpense(ctx context.Context, date time.Time, amount float64, description string) error {
-	args := m.Called(ctx, date, amount, description)
+func (m *MockFinanceService) AddIncome(ctx context.Context, date time.Time, amount float64, description, currency string) error {
+	args := m.Called(ctx, date, amount, description, currency)
 	return args.Error(0)
 }
 
+func (m *MockFinanceService) RecordExpense(ctx context.Context, in service.ExpenseInput) (service.Transaction, []service.Warning, error) {
+	args := m.Called(ctx, in)
+	warnings, _ := args.Get(1).([]service.Warning)
+	return args.Get(0).(service.Transaction), warnings, args.Error(2)
+}
+
 func (m *MockFinanceService) DeleteTransaction(ctx context.Context, id int32) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -56,9 +116,9 @@ func (m *MockFinanceService) CreateRecurringSimple(ctx context.Context, input se
 	return args.Get(0).(service.Recurring), args.Error(1)
 }
 
-func (m *MockFinanceService) ListRecurring(ctx context.Context) ([]service.Recurring, error) {
+func (m *MockFinanceService) RecurringSummaries(ctx context.Context) ([]service.RecurringSummary, error) {
 	args := m.Called(ctx)
-	return args.Get(0).([]service.Recurring), args.Error(1)
+	return args.Get(0).([]service.RecurringSummary), args.Error(1)
 }
 
 func (m *MockFinanceService) DeleteRecurring(ctx context.Context, id int32) error {
@@ -76,336 +136,4799 @@ func (m *MockFinanceService) Calculate90DayForecast(ctx context.Context, startin
 	return args.Get(0).([]service.DailyCashFlow), args.Error(1)
 }
 
+func (m *MockFinanceService) Forecast(ctx context.Context, startingBalance float64, days int) ([]service.DailyCashFlow, error) {
+	args := m.Called(ctx, startingBalance, days)
+	return args.Get(0).([]service.DailyCashFlow), args.Error(1)
+}
+
+func (m *MockFinanceService) ForecastWithOptions(ctx context.Context, startingBalance float64, days int, opts service.ForecastOptions) ([]service.DailyCashFlow, error) {
+	args := m.Called(ctx, startingBalance, days, opts)
+	return args.Get(0).([]service.DailyCashFlow), args.Error(1)
+}
+
+func (m *MockFinanceService) Calendar(ctx context.Context, month time.Time) (service.MonthCalendar, error) {
+	args := m.Called(ctx, month)
+	return args.Get(0).(service.MonthCalendar), args.Error(1)
+}
+
+func (m *MockFinanceService) SpendingHeatmap(ctx context.Context, start, end time.Time) (service.SpendingHeatmap, error) {
+	args := m.Called(ctx, start, end)
+	return args.Get(0).(service.SpendingHeatmap), args.Error(1)
+}
+
+func (m *MockFinanceService) TopSpending(ctx context.Context, filter service.TopSpendingFilter) (service.TopSpending, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(service.TopSpending), args.Error(1)
+}
+
+func (m *MockFinanceService) Insights(ctx context.Context, month time.Time) (service.SpendingInsights, error) {
+	args := m.Called(ctx, month)
+	return args.Get(0).(service.SpendingInsights), args.Error(1)
+}
+
+func (m *MockFinanceService) ForecastHistory(ctx context.Context, day time.Time) (service.ForecastSnapshot, error) {
+	args := m.Called(ctx, day)
+	return args.Get(0).(service.ForecastSnapshot), args.Error(1)
+}
+
+func (m *MockFinanceService) ForecastSnapshotDates(ctx context.Context) ([]time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]time.Time), args.Error(1)
+}
+
+func (m *MockFinanceService) ForecastAll(ctx context.Context, startingBalance float64, days int, opts service.ForecastOptions) (service.AllForecasts, error) {
+	args := m.Called(ctx, startingBalance, days, opts)
+	return args.Get(0).(service.AllForecasts), args.Error(1)
+}
+
+func (m *MockFinanceService) ForecastAsOf(ctx context.Context, asOf time.Time) ([]service.DailyCashFlow, error) {
+	args := m.Called(ctx, asOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.DailyCashFlow), args.Error(1)
+}
+
 func (m *MockFinanceService) FindLowestPoint(forecast []service.DailyCashFlow) (service.DailyCashFlow, int) {
 	args := m.Called(forecast)
 	return args.Get(0).(service.DailyCashFlow), args.Get(1).(int)
 }
 
-func (m *MockFinanceService) GetUpcomingTransactions(ctx context.Context, days int) ([]service.Transaction, error) {
+func (m *MockFinanceService) GetUpcomingTransactions(ctx context.Context, days int) ([]service.ListedTransaction, error) {
 	args := m.Called(ctx, days)
-	return args.Get(0).([]service.Transaction), args.Error(1)
+	return args.Get(0).([]service.ListedTransaction), args.Error(1)
 }
 
-func (m *MockFinanceService) GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]service.Transaction, error) {
+func (m *MockFinanceService) GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]service.ListedTransaction, error) {
 	args := m.Called(ctx, start, end)
-	return args.Get(0).([]service.Transaction), args.Error(1)
+	return args.Get(0).([]service.ListedTransaction), args.Error(1)
 }
 
-// Test helper to create a test server
-func setupTestServer(mockService FinanceServiceInterface) *httptest.Server {
-	// Create an API server that uses our mock interface
-	apiServer := NewAPIServer(mockService)
-	router := apiServer.SetupRoutes()
-	return httptest.NewServer(router)
+func (m *MockFinanceService) SummarizeTransactions(ctx context.Context, start, end time.Time, includeRecurring bool) (service.TransactionSummary, error) {
+	args := m.Called(ctx, start, end, includeRecurring)
+	return args.Get(0).(service.TransactionSummary), args.Error(1)
 }
 
-// Test structures for table-driven tests
-type testCase struct {
-	name           string
-	method         string
-	path           string
-	body           interface{}
-	mockSetup      func(*MockFinanceService)
-	expectedStatus int
-	validateBody   func(*testing.T, []byte)
+func (m *MockFinanceService) ListTransactions(ctx context.Context, status string, order service.ListOrder, page service.Page) ([]service.Transaction, string, error) {
+	args := m.Called(ctx, status, order, page)
+	return args.Get(0).([]service.Transaction), args.String(1), args.Error(2)
 }
 
-func TestTransactionEndpoints(t *testing.T) {
-	tests := []testCase{
-		{
-			name:   "GET /api/transactions - success",
-			method: "GET",
-			path:   "/api/transactions",
-			mockSetup: func(m *MockFinanceService) {
-				m.On("GetAllTransactions", mock.Anything).Return([]service.Transaction{
-					{ID: 1, Description: "Test transaction"},
-				}, nil)
-			},
-			expectedStatus: http.StatusOK,
-			validateBody: func(t *testing.T, body []byte) {
-				var transactions []service.Transaction
-				err := json.Unmarshal(body, &transactions)
-				require.NoError(t, err)
-				assert.Len(t, transactions, 1)
-				assert.Equal(t, "Test transaction", transactions[0].Description)
-			},
-		},
-		{
-			name:   "GET /api/transactions - service error",
-			method: "GET",
-			path:   "/api/transactions",
-			mockSetup: func(m *MockFinanceService) {
-				m.On("GetAllTransactions", mock.Anything).Return([]service.Transaction{}, fmt.Errorf("database error"))
-			},
-			expectedStatus: http.StatusInternalServerError,
-			validateBody: func(t *testing.T, body []byte) {
-				var errResp ErrorResponse
-				err := json.Unmarshal(body, &errResp)
-				require.NoError(t, err)
-				assert.Contains(t, errResp.Error, "database error")
-			},
-		},
-		{
-			name:   "POST /api/transactions/income - success",
-			method: "POST",
-			path:   "/api/transactions/income",
-			body: AddTransactionRequest{
-				Date:        "2025-09-15",
-				Amount:      1000.50,
-				Description: "Salary",
-			},
-			mockSetup: func(m *MockFinanceService) {
-				expectedDate, _ := time.Parse("2006-01-02", "2025-09-15")
-				m.On("AddIncome", mock.Anything, expectedDate, 1000.50, "Salary").Return(nil)
-			},
-			expectedStatus: http.StatusCreated,
-			validateBody: func(t *testing.T, body []byte) {
-				var resp map[string]string
-				err := json.Unmarshal(body, &resp)
-				require.NoError(t, err)
-				assert.Equal(t, "success", resp["status"])
-			},
-		},
-		{
-			name:   "POST /api/transactions/income - invalid date",
-			method: "POST",
-			path:   "/api/transactions/income",
-			body: AddTransactionRequest{
-				Date:        "invalid-date",
-				Amount:      1000.50,
-				Description: "Salary",
-			},
-			mockSetup:      func(m *MockFinanceService) {},
-			expectedStatus: http.StatusBadRequest,
-			validateBody: func(t *testing.T, body []byte) {
-				var errResp ErrorResponse
-				err := json.Unmarshal(body, &errResp)
-				require.NoError(t, err)
-				assert.Contains(t, errResp.Error, "unable to parse date")
-			},
-		},
-		{
-			name:   "POST /api/transactions/expense - success",
-			method: "POST",
-			path:   "/api/transactions/expense",
-			body: AddTransactionRequest{
-				Date:        "2025-09-15",
-				Amount:      500.25,
-				Description: "Groceries",
-			},
-			mockSetup: func(m *MockFinanceService) {
-				expectedDate, _ := time.Parse("2006-01-02", "2025-09-15")
-				m.On("AddExpense", mock.Anything, expectedDate, 500.25, "Groceries").Return(nil)
-			},
-			expectedStatus: http.StatusCreated,
-		},
-		{
-			name:   "DELETE /api/transactions/123 - success",
-			method: "DELETE",
-			path:   "/api/transactions/123",
-			mockSetup: func(m *MockFinanceService) {
-				m.On("DeleteTransaction", mock.Anything, int32(123)).Return(nil)
-			},
-			expectedStatus: http.StatusOK,
-		},
-		{
-			name:           "DELETE /api/transactions/invalid - bad ID",
-			method:         "DELETE",
-			path:           "/api/transactions/invalid",
-			mockSetup:      func(m *MockFinanceService) {},
-			expectedStatus: http.StatusMethodNotAllowed,
-		},
-	}
+func (m *MockFinanceService) ListRecurringSummaries(ctx context.Context, order service.ListOrder, page service.Page) ([]service.RecurringSummary, string, error) {
+	args := m.Called(ctx, order, page)
+	return args.Get(0).([]service.RecurringSummary), args.String(1), args.Error(2)
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockFinanceService)
-			tt.mockSetup(mockService)
+func (m *MockFinanceService) GetTransactionsBetween(ctx context.Context, start, end time.Time) ([]service.ListedTransaction, error) {
+	args := m.Called(ctx, start, end)
+	return args.Get(0).([]service.ListedTransaction), args.Error(1)
+}
 
-			server := setupTestServer(mockService)
-			defer server.Close()
+func (m *MockFinanceService) SubscribeEvents(ctx context.Context) (<-chan service.Event, func()) {
+	args := m.Called(ctx)
+	return args.Get(0).(<-chan service.Event), args.Get(1).(func())
+}
 
-			var body []byte
-			var err error
-			if tt.body != nil {
-				body, err = json.Marshal(tt.body)
-				require.NoError(t, err)
-			}
+// OnEvent is called by every NewAPIServer, so it keeps the hooks instead
+// of being mocked.
+func (m *MockFinanceService) OnEvent(fn func(service.Event)) {
+	m.hooks = append(m.hooks, fn)
+}
 
-			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewBuffer(body))
-			require.NoError(t, err)
+func (m *MockFinanceService) SetTransactionTax(ctx context.Context, id int32, gross, withheld float64) error {
+	args := m.Called(ctx, id, gross, withheld)
+	return args.Error(0)
+}
 
-			if tt.body != nil {
-				req.Header.Set("Content-Type", "application/json")
-			}
+func (m *MockFinanceService) SetTaxRate(ctx context.Context, rate float64) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
 
-			resp, err := http.DefaultClient.Do(req)
-			require.NoError(t, err)
-			defer func() {
-				if err := resp.Body.Close(); err != nil {
-					t.Errorf("failed to close body: %v", err)
-				}
-			}()
+func (m *MockFinanceService) EstimateTaxes(ctx context.Context, year int) (service.TaxEstimate, error) {
+	args := m.Called(ctx, year)
+	return args.Get(0).(service.TaxEstimate), args.Error(1)
+}
 
-			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+func (m *MockFinanceService) TagSelfEmployment(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
 
-			if tt.validateBody != nil {
-				var respBody bytes.Buffer
-				_, err := respBody.ReadFrom(resp.Body)
-				require.NoError(t, err)
-				tt.validateBody(t, respBody.Bytes())
-			}
+func (m *MockFinanceService) UntagSelfEmployment(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
 
-			mockService.AssertExpectations(t)
-		})
-	}
+func (m *MockFinanceService) EstimateSelfEmploymentTax(ctx context.Context, year int) (service.SelfEmploymentTaxEstimate, error) {
+	args := m.Called(ctx, year)
+	return args.Get(0).(service.SelfEmploymentTaxEstimate), args.Error(1)
 }
 
-func TestBalanceEndpoints(t *testing.T) {
-	tests := []testCase{
-		{
-			name:   "GET /api/balance - success",
-			method: "GET",
-			path:   "/api/balance",
-			mockSetup: func(m *MockFinanceService) {
-				m.On("GetStartingBalance", mock.Anything).Return(5000.75, nil)
-			},
-			expectedStatus: http.StatusOK,
-			validateBody: func(t *testing.T, body []byte) {
-				var resp map[string]float64
-				err := json.Unmarshal(body, &resp)
-				require.NoError(t, err)
-				assert.Equal(t, 5000.75, resp["balance"])
-			},
-		},
-		{
-			name:   "PUT /api/balance - success",
-			method: "PUT",
-			path:   "/api/balance",
-			body: SetBalanceRequest{
-				Balance: 10000.00,
-			},
-			mockSetup: func(m *MockFinanceService) {
-				m.On("SetStartingBalance", mock.Anything, 10000.00).Return(nil)
-			},
-			expectedStatus: http.StatusOK,
-		},
-	}
+func (m *MockFinanceService) GenerateEstimatedPayments(ctx context.Context, year int) ([]service.Recurring, error) {
+	args := m.Called(ctx, year)
+	return args.Get(0).([]service.Recurring), args.Error(1)
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockFinanceService)
-			tt.mockSetup(mockService)
+func (m *MockFinanceService) CompareForecasts(ctx context.Context, startingBalance float64, days int, baseline, scenario service.Scenario) (service.ForecastComparison, error) {
+	args := m.Called(ctx, startingBalance, days, baseline, scenario)
+	return args.Get(0).(service.ForecastComparison), args.Error(1)
+}
 
-			server := setupTestServer(mockService)
-			defer server.Close()
+func (m *MockFinanceService) ListAudit(ctx context.Context, filter service.AuditFilter) ([]service.AuditEntry, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]service.AuditEntry), args.Error(1)
+}
 
-			var body []byte
-			var err error
+func (m *MockFinanceService) ListTrash(ctx context.Context) (service.Trash, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(service.Trash), args.Error(1)
+}
+
+func (m *MockFinanceService) ArchiveCutoff(ctx context.Context) (time.Time, bool) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Bool(1)
+}
+
+func (m *MockFinanceService) ArchiveTransactions(ctx context.Context, cutoff time.Time) (int64, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockFinanceService) ListArchivedTransactions(ctx context.Context, start, end *time.Time) ([]service.ArchivedTransaction, error) {
+	args := m.Called(ctx, start, end)
+	return args.Get(0).([]service.ArchivedTransaction), args.Error(1)
+}
+
+func (m *MockFinanceService) Sync(ctx context.Context, since int64, limit int) (service.SyncResult, error) {
+	args := m.Called(ctx, since, limit)
+	return args.Get(0).(service.SyncResult), args.Error(1)
+}
+
+func (m *MockFinanceService) RestoreTransaction(ctx context.Context, id int32) (service.Transaction, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(service.Transaction), args.Error(1)
+}
+
+func (m *MockFinanceService) RestoreRecurring(ctx context.Context, id int32) (service.Recurring, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(service.Recurring), args.Error(1)
+}
+
+func (m *MockFinanceService) RecurringOccurrences(ctx context.Context, id int32, start, end time.Time) (service.Recurring, []service.Transaction, error) {
+	args := m.Called(ctx, id, start, end)
+	return args.Get(0).(service.Recurring), args.Get(1).([]service.Transaction), args.Error(2)
+}
+
+func (m *MockFinanceService) ImportTransactions(ctx context.Context, format string, r io.Reader, dryRun bool) (service.ImportResult, error) {
+	args := m.Called(ctx, format, r, dryRun)
+	return args.Get(0).(service.ImportResult), args.Error(1)
+}
+
+func (m *MockFinanceService) ImportRecurringCSV(ctx context.Context, r io.Reader, dryRun bool) (service.RecurringImportResult, error) {
+	args := m.Called(ctx, r, dryRun)
+	return args.Get(0).(service.RecurringImportResult), args.Error(1)
+}
+
+// mockDryRunKey marks the context DryRun hands its function, so tests can
+// check a call was made inside one.
+type mockDryRunKey struct{}
+
+func (m *MockFinanceService) DryRun(ctx context.Context, fn func(context.Context) error) error {
+	return fn(context.WithValue(ctx, mockDryRunKey{}, true))
+}
+
+func (m *MockFinanceService) AggregateTransactions(ctx context.Context, filter service.AggregateFilter) (service.TransactionAggregates, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(service.TransactionAggregates), args.Error(1)
+}
+
+func (m *MockFinanceService) GroupTransactions(ctx context.Context, txs []service.ListedTransaction, by string) (service.GroupedTransactions, error) {
+	args := m.Called(ctx, txs, by)
+	return args.Get(0).(service.GroupedTransactions), args.Error(1)
+}
+
+func (m *MockFinanceService) ExportTransactions(ctx context.Context, format string, w io.Writer, start, end *time.Time) error {
+	args := m.Called(ctx, format, w, start, end)
+	if args.Error(0) == nil {
+		_, _ = io.WriteString(w, "Date,Payee,Category,Memo,Outflow,Inflow\n")
+	}
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) Backup(ctx context.Context) (service.Backup, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(service.Backup), args.Error(1)
+}
+
+func (m *MockFinanceService) Restore(ctx context.Context, b service.Backup) (service.RestoreSummary, error) {
+	args := m.Called(ctx, b)
+	return args.Get(0).(service.RestoreSummary), args.Error(1)
+}
+
+func (m *MockFinanceService) SaveBackup(ctx context.Context) (service.StoredBackup, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(service.StoredBackup), args.Error(1)
+}
+
+func (m *MockFinanceService) ListStoredBackups(ctx context.Context) ([]service.StoredBackup, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]service.StoredBackup), args.Error(1)
+}
+
+func (m *MockFinanceService) OpenStoredBackup(ctx context.Context, name string) (io.ReadCloser, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockFinanceService) StoredBackupURL(ctx context.Context, name string) (string, error) {
+	args := m.Called(ctx, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockFinanceService) ReanchorRecurring(ctx context.Context, id int32, in service.ReanchorInput) (service.Recurring, error) {
+	args := m.Called(ctx, id, in)
+	return args.Get(0).(service.Recurring), args.Error(1)
+}
+
+func (m *MockFinanceService) PauseRecurring(ctx context.Context, id int32, from, until *time.Time) (service.Recurring, error) {
+	args := m.Called(ctx, id, from, until)
+	return args.Get(0).(service.Recurring), args.Error(1)
+}
+
+func (m *MockFinanceService) SetRecurringReminder(ctx context.Context, id int32, daysBefore int) (service.RecurringReminder, error) {
+	args := m.Called(ctx, id, daysBefore)
+	return args.Get(0).(service.RecurringReminder), args.Error(1)
+}
+
+func (m *MockFinanceService) ClearRecurringReminder(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) Reminders(ctx context.Context, days int) ([]service.Reminder, error) {
+	args := m.Called(ctx, days)
+	return args.Get(0).([]service.Reminder), args.Error(1)
+}
+
+func (m *MockFinanceService) SuggestRecurring(ctx context.Context) ([]service.RecurringSuggestion, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]service.RecurringSuggestion), args.Error(1)
+}
+
+func (m *MockFinanceService) AcceptRecurringSuggestion(ctx context.Context, id string) (service.Recurring, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(service.Recurring), args.Error(1)
+}
+
+func (m *MockFinanceService) BaseCurrency(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockFinanceService) SetBaseCurrency(ctx context.Context, code string) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) ListExchangeRates(ctx context.Context) ([]service.ExchangeRate, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]service.ExchangeRate), args.Error(1)
+}
+
+func (m *MockFinanceService) SetExchangeRate(ctx context.Context, code string, rate float64) (service.ExchangeRate, error) {
+	args := m.Called(ctx, code, rate)
+	return args.Get(0).(service.ExchangeRate), args.Error(1)
+}
+
+func (m *MockFinanceService) DeleteExchangeRate(ctx context.Context, code string) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) Budgets(ctx context.Context) (map[string]float64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[string]float64), args.Error(1)
+}
+
+func (m *MockFinanceService) SetBudget(ctx context.Context, category string, limit float64) error {
+	args := m.Called(ctx, category, limit)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) DeleteBudget(ctx context.Context, category string) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) LowBalanceThreshold(ctx context.Context) (float64, bool, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(float64), args.Bool(1), args.Error(2)
+}
+
+func (m *MockFinanceService) SetLowBalanceThreshold(ctx context.Context, threshold float64) error {
+	args := m.Called(ctx, threshold)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) ClearLowBalanceThreshold(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) RecurringContributions(ctx context.Context, days int) ([]service.RecurringContribution, error) {
+	args := m.Called(ctx, days)
+	return args.Get(0).([]service.RecurringContribution), args.Error(1)
+}
+
+func (m *MockFinanceService) MonthlyOutlook(ctx context.Context, months int) ([]service.MonthOutlook, error) {
+	args := m.Called(ctx, months)
+	return args.Get(0).([]service.MonthOutlook), args.Error(1)
+}
+
+func (m *MockFinanceService) ListScenarios(ctx context.Context) ([]service.SavedScenario, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]service.SavedScenario), args.Error(1)
+}
+
+func (m *MockFinanceService) GetScenario(ctx context.Context, id int32) (service.SavedScenario, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(service.SavedScenario), args.Error(1)
+}
+
+func (m *MockFinanceService) CreateScenario(ctx context.Context, name string, sc service.Scenario) (service.SavedScenario, error) {
+	args := m.Called(ctx, name, sc)
+	return args.Get(0).(service.SavedScenario), args.Error(1)
+}
+
+func (m *MockFinanceService) UpdateScenario(ctx context.Context, id int32, name string, sc service.Scenario) (service.SavedScenario, error) {
+	args := m.Called(ctx, id, name, sc)
+	return args.Get(0).(service.SavedScenario), args.Error(1)
+}
+
+func (m *MockFinanceService) DeleteScenario(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) ScenarioForecast(ctx context.Context, id int32, startingBalance float64, days int) (service.ForecastComparison, error) {
+	args := m.Called(ctx, id, startingBalance, days)
+	return args.Get(0).(service.ForecastComparison), args.Error(1)
+}
+
+func (m *MockFinanceService) ListEnvelopes(ctx context.Context) ([]service.EnvelopeBalance, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]service.EnvelopeBalance), args.Error(1)
+}
+
+func (m *MockFinanceService) GetEnvelope(ctx context.Context, id int32) (service.EnvelopeBalance, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(service.EnvelopeBalance), args.Error(1)
+}
+
+func (m *MockFinanceService) CreateEnvelope(ctx context.Context, in service.EnvelopeInput) (service.Envelope, error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(service.Envelope), args.Error(1)
+}
+
+func (m *MockFinanceService) UpdateEnvelope(ctx context.Context, id int32, in service.EnvelopeInput) (service.Envelope, error) {
+	args := m.Called(ctx, id, in)
+	return args.Get(0).(service.Envelope), args.Error(1)
+}
+
+func (m *MockFinanceService) DeleteEnvelope(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) ListNotificationChannels(ctx context.Context) ([]service.NotificationChannel, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]service.NotificationChannel), args.Error(1)
+}
+
+func (m *MockFinanceService) GetNotificationChannel(ctx context.Context, id int32) (service.NotificationChannel, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(service.NotificationChannel), args.Error(1)
+}
+
+func (m *MockFinanceService) CreateNotificationChannel(ctx context.Context, in service.NotificationChannelInput) (service.NotificationChannel, error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(service.NotificationChannel), args.Error(1)
+}
+
+func (m *MockFinanceService) UpdateNotificationChannel(ctx context.Context, id int32, in service.NotificationChannelInput) (service.NotificationChannel, error) {
+	args := m.Called(ctx, id, in)
+	return args.Get(0).(service.NotificationChannel), args.Error(1)
+}
+
+func (m *MockFinanceService) DeleteNotificationChannel(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) TestNotificationChannel(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) Login(ctx context.Context, username, password string) (service.Session, error) {
+	args := m.Called(ctx, username, password)
+	return args.Get(0).(service.Session), args.Error(1)
+}
+
+func (m *MockFinanceService) Refresh(ctx context.Context, refreshToken string) (service.Session, error) {
+	args := m.Called(ctx, refreshToken)
+	return args.Get(0).(service.Session), args.Error(1)
+}
+
+func (m *MockFinanceService) Logout(ctx context.Context, refreshToken string) error {
+	args := m.Called(ctx, refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) Authenticate(ctx context.Context, accessToken string) (service.User, error) {
+	args := m.Called(ctx, accessToken)
+	return args.Get(0).(service.User), args.Error(1)
+}
+
+func (m *MockFinanceService) ListUsers(ctx context.Context) ([]service.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]service.User), args.Error(1)
+}
+
+func (m *MockFinanceService) GetUser(ctx context.Context, id int32) (service.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(service.User), args.Error(1)
+}
+
+func (m *MockFinanceService) CreateUser(ctx context.Context, username, password, role string) (service.User, error) {
+	args := m.Called(ctx, username, password, role)
+	return args.Get(0).(service.User), args.Error(1)
+}
+
+func (m *MockFinanceService) SetUserRole(ctx context.Context, id int32, role string) (service.User, error) {
+	args := m.Called(ctx, id, role)
+	return args.Get(0).(service.User), args.Error(1)
+}
+
+func (m *MockFinanceService) SetUserPassword(ctx context.Context, id int32, password string) error {
+	args := m.Called(ctx, id, password)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) DeleteUser(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) ListHouseholds(ctx context.Context) ([]service.Household, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]service.Household), args.Error(1)
+}
+
+func (m *MockFinanceService) UserHouseholds(ctx context.Context, userID int32) ([]service.Household, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]service.Household), args.Error(1)
+}
+
+func (m *MockFinanceService) GetHousehold(ctx context.Context, id int32) (service.Household, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(service.Household), args.Error(1)
+}
+
+func (m *MockFinanceService) CreateHousehold(ctx context.Context, name string, owner int32) (service.Household, error) {
+	args := m.Called(ctx, name, owner)
+	return args.Get(0).(service.Household), args.Error(1)
+}
+
+func (m *MockFinanceService) DeleteHousehold(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) MemberRole(ctx context.Context, householdID, userID int32) (string, error) {
+	args := m.Called(ctx, householdID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockFinanceService) ListHouseholdMembers(ctx context.Context, householdID int32) ([]service.HouseholdMember, error) {
+	args := m.Called(ctx, householdID)
+	return args.Get(0).([]service.HouseholdMember), args.Error(1)
+}
+
+func (m *MockFinanceService) AddHouseholdMember(ctx context.Context, householdID, userID int32, role string) (service.HouseholdMember, error) {
+	args := m.Called(ctx, householdID, userID, role)
+	return args.Get(0).(service.HouseholdMember), args.Error(1)
+}
+
+func (m *MockFinanceService) SetHouseholdMemberRole(ctx context.Context, householdID, userID int32, role string) (service.HouseholdMember, error) {
+	args := m.Called(ctx, householdID, userID, role)
+	return args.Get(0).(service.HouseholdMember), args.Error(1)
+}
+
+func (m *MockFinanceService) RemoveHouseholdMember(ctx context.Context, householdID, userID int32) error {
+	args := m.Called(ctx, householdID, userID)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) CreateInvitation(ctx context.Context, householdID int32, role string, invitedBy int32) (service.Invitation, error) {
+	args := m.Called(ctx, householdID, role, invitedBy)
+	return args.Get(0).(service.Invitation), args.Error(1)
+}
+
+func (m *MockFinanceService) ListInvitations(ctx context.Context, householdID int32) ([]service.Invitation, error) {
+	args := m.Called(ctx, householdID)
+	return args.Get(0).([]service.Invitation), args.Error(1)
+}
+
+func (m *MockFinanceService) RevokeInvitation(ctx context.Context, householdID, id int32) error {
+	args := m.Called(ctx, householdID, id)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) AcceptInvitation(ctx context.Context, token string, userID int32) (service.Household, error) {
+	args := m.Called(ctx, token, userID)
+	return args.Get(0).(service.Household), args.Error(1)
+}
+
+func (m *MockFinanceService) MonthVariance(ctx context.Context, month time.Time) (service.VarianceReport, error) {
+	args := m.Called(ctx, month)
+	return args.Get(0).(service.VarianceReport), args.Error(1)
+}
+
+func (m *MockFinanceService) UpcomingWindow(ctx context.Context, days int) (time.Time, time.Time) {
+	args := m.Called(ctx, days)
+	return args.Get(0).(time.Time), args.Get(1).(time.Time)
+}
+
+func (m *MockFinanceService) Timezone(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockFinanceService) SetTimezone(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) Settings(ctx context.Context) (service.Settings, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(service.Settings), args.Error(1)
+}
+
+func (m *MockFinanceService) UpdateSettings(ctx context.Context, in service.SettingsUpdate) (service.Settings, error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(service.Settings), args.Error(1)
+}
+
+func (m *MockFinanceService) ResetSetting(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) ForecastDays(ctx context.Context) int {
+	args := m.Called(ctx)
+	return args.Int(0)
+}
+
+func (m *MockFinanceService) Location(ctx context.Context) *time.Location {
+	args := m.Called(ctx)
+	return args.Get(0).(*time.Location)
+}
+
+func (m *MockFinanceService) Today(ctx context.Context) time.Time {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time)
+}
+
+func (m *MockFinanceService) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) SchemaStatus(ctx context.Context) (service.SchemaStatus, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(service.SchemaStatus), args.Error(1)
+}
+
+func (m *MockFinanceService) ListAccounts(ctx context.Context) ([]service.Account, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]service.Account), args.Error(1)
+}
+
+func (m *MockFinanceService) CreateAccount(ctx context.Context, in service.AccountInput) (service.Account, error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(service.Account), args.Error(1)
+}
+
+func (m *MockFinanceService) DeleteAccount(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFinanceService) CardStatements(ctx context.Context, id int32) ([]service.CardStatement, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]service.CardStatement), args.Error(1)
+}
+
+func (m *MockFinanceService) SetAccountInterest(ctx context.Context, id int32, in service.InterestInput) (service.Account, error) {
+	args := m.Called(ctx, id, in)
+	return args.Get(0).(service.Account), args.Error(1)
+}
+
+// Test helper to create a test server
+// withUTC lets the mock answer the timezone lookups behind ETags. Tests
+// that care about the zone set their own expectation first.
+func withUTC(m *MockFinanceService) *MockFinanceService {
+	m.On("Location", mock.Anything).Return(time.UTC).Maybe()
+	return m
+}
+
+func setupTestServer(mockService FinanceServiceInterface) *httptest.Server {
+	if m, ok := mockService.(*MockFinanceService); ok {
+		withUTC(m)
+	}
+	// Create an API server that uses our mock interface
+	apiServer := NewAPIServer(mockService)
+	router := apiServer.SetupRoutes()
+	return httptest.NewServer(router)
+}
+
+// Test structures for table-driven tests
+type testCase struct {
+	name           string
+	method         string
+	path           string
+	body           interface{}
+	header         map[string]string
+	mockSetup      func(*MockFinanceService)
+	expectedStatus int
+	validateBody   func(*testing.T, []byte)
+}
+
+// atVersion matches a context carrying the version an edit is based on.
+func atVersion(version int32) any {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		v, ok := service.ExpectedVersion(ctx)
+		return ok && v == version
+	})
+}
+
+func TestTransactionEndpoints(t *testing.T) {
+	tests := []testCase{
+		{
+			name:   "GET /api/transactions - success",
+			method: "GET",
+			path:   "/api/transactions",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListTransactions", mock.Anything, "", service.ListOrder{}, service.Page{}).Return([]service.Transaction{
+					{ID: 1, Description: "Test transaction"},
+				}, "", nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var transactions []service.Transaction
+				err := json.Unmarshal(body, &transactions)
+				require.NoError(t, err)
+				assert.Len(t, transactions, 1)
+				assert.Equal(t, "Test transaction", transactions[0].Description)
+			},
+		},
+		{
+			name:   "GET /api/transactions - service error",
+			method: "GET",
+			path:   "/api/transactions",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListTransactions", mock.Anything, "", service.ListOrder{}, service.Page{}).Return([]service.Transaction{}, "", fmt.Errorf("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			validateBody: func(t *testing.T, body []byte) {
+				var errResp ErrorResponse
+				err := json.Unmarshal(body, &errResp)
+				require.NoError(t, err)
+				assert.Contains(t, errResp.Error, "database error")
+			},
+		},
+		{
+			name:   "GET /api/transactions?aggregates=true - rows and totals",
+			method: "GET",
+			path:   "/api/transactions?aggregates=true",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("AggregateTransactions", mock.Anything, service.AggregateFilter{}).Return(service.TransactionAggregates{
+					Count: 2, TotalIncome: 1000, TotalExpense: -250, Net: 750,
+				}, nil)
+				m.On("ListTransactions", mock.Anything, "", service.ListOrder{}, service.Page{}).Return([]service.Transaction{{ID: 1}, {ID: 2}}, "", nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp TransactionListResponse
+				err := json.Unmarshal(body, &resp)
+				require.NoError(t, err)
+				assert.Len(t, resp.Transactions, 2)
+				assert.Equal(t, int64(2), resp.Aggregates.Count)
+				assert.Equal(t, 750.0, resp.Aggregates.Net)
+			},
+		},
+		{
+			name:   "GET /api/transactions/between?aggregates=only - totals without rows",
+			method: "GET",
+			path:   "/api/transactions/between?start=2025-01-01&end=2025-01-31&aggregates=only",
+			mockSetup: func(m *MockFinanceService) {
+				start, _ := time.Parse("2006-01-02", "2025-01-01")
+				end, _ := time.Parse("2006-01-02", "2025-01-31")
+				m.On("AggregateTransactions", mock.Anything, service.AggregateFilter{Start: &start, End: &end, IncludeRecurring: true}).
+					Return(service.TransactionAggregates{Count: 4, TotalIncome: 5000, TotalExpense: -1200, Net: 3800}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var agg service.TransactionAggregates
+				err := json.Unmarshal(body, &agg)
+				require.NoError(t, err)
+				assert.Equal(t, int64(4), agg.Count)
+				assert.Equal(t, -1200.0, agg.TotalExpense)
+			},
+		},
+		{
+			name:   "GET /api/transactions?aggregates=only - missing exchange rate",
+			method: "GET",
+			path:   "/api/transactions?aggregates=only",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("AggregateTransactions", mock.Anything, service.AggregateFilter{}).
+					Return(service.TransactionAggregates{}, fmt.Errorf("%w for GBP (base currency is USD)", service.ErrNoExchangeRate))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "GET /api/transactions?aggregates=maybe - bad parameter",
+			method:         "GET",
+			path:           "/api/transactions?aggregates=maybe",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "POST /api/transactions/income - success",
+			method: "POST",
+			path:   "/api/transactions/income",
+			body: AddTransactionRequest{
+				Date:        "2025-09-15",
+				Amount:      1000.50,
+				Description: "Salary",
+			},
+			mockSetup: func(m *MockFinanceService) {
+				expectedDate, _ := time.Parse("2006-01-02", "2025-09-15")
+				m.On("AddIncome", mock.Anything, expectedDate, 1000.50, "Salary", "").Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp map[string]string
+				err := json.Unmarshal(body, &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "success", resp["status"])
+			},
+		},
+		{
+			name:   "POST /api/transactions/income - foreign currency",
+			method: "POST",
+			path:   "/api/transactions/income",
+			body: AddTransactionRequest{
+				Date:        "2025-09-15",
+				Amount:      800,
+				Description: "Freelance",
+				Currency:    "eur",
+			},
+			mockSetup: func(m *MockFinanceService) {
+				expectedDate, _ := time.Parse("2006-01-02", "2025-09-15")
+				m.On("AddIncome", mock.Anything, expectedDate, 800.0, "Freelance", "eur").Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/transactions/income - invalid currency",
+			method: "POST",
+			path:   "/api/transactions/income",
+			body: AddTransactionRequest{
+				Date:        "2025-09-15",
+				Amount:      800,
+				Description: "Freelance",
+				Currency:    "euro",
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("AddIncome", mock.Anything, mock.Anything, 800.0, "Freelance", "euro").
+					Return(fmt.Errorf("%w \"EURO\"", service.ErrInvalidCurrency))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "POST /api/transactions/income - invalid date",
+			method: "POST",
+			path:   "/api/transactions/income",
+			body: AddTransactionRequest{
+				Date:        "invalid-date",
+				Amount:      1000.50,
+				Description: "Salary",
+			},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				var errResp ErrorResponse
+				err := json.Unmarshal(body, &errResp)
+				require.NoError(t, err)
+				assert.Contains(t, errResp.Error, "unable to parse date")
+			},
+		},
+		{
+			name:   "POST /api/transactions/income - locale string amount",
+			method: "POST",
+			path:   "/api/transactions/income",
+			body:   map[string]string{"date": "2025-09-15", "amount": "1,234.56", "description": "Bonus"},
+			mockSetup: func(m *MockFinanceService) {
+				expectedDate, _ := time.Parse("2006-01-02", "2025-09-15")
+				m.On("AddIncome", mock.Anything, expectedDate, 1234.56, "Bonus", "").Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "POST /api/transactions/income - amount in wrong locale",
+			method:         "POST",
+			path:           "/api/transactions/income",
+			body:           map[string]string{"date": "2025-09-15", "amount": "1.234,56", "description": "Bonus"},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				var errResp ErrorResponse
+				err := json.Unmarshal(body, &errResp)
+				require.NoError(t, err)
+				assert.Contains(t, errResp.Error, `invalid amount "1.234,56"`)
+				assert.Contains(t, errResp.Error, "1,234.56")
+			},
+		},
+		{
+			name:   "POST /api/transactions/expense - success",
+			method: "POST",
+			path:   "/api/transactions/expense",
+			body: AddTransactionRequest{
+				Date:        "2025-09-15",
+				Amount:      500.25,
+				Description: "Groceries",
+			},
+			mockSetup: func(m *MockFinanceService) {
+				expectedDate, _ := time.Parse("2006-01-02", "2025-09-15")
+				in := service.ExpenseInput{Date: expectedDate, Amount: 500.25, Description: "Groceries"}
+				m.On("RecordExpense", mock.Anything, in).Return(service.Transaction{ID: 9, Description: "Groceries"}, nil, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp map[string]json.RawMessage
+				require.NoError(t, json.Unmarshal(body, &resp))
+				assert.JSONEq(t, `"success"`, string(resp["status"]))
+				assert.NotContains(t, resp, "warnings")
+			},
+		},
+		{
+			name:   "POST /api/transactions/expense - over budget warning",
+			method: "POST",
+			path:   "/api/transactions/expense",
+			body: AddTransactionRequest{
+				Date:        "2025-09-15",
+				Amount:      120,
+				Description: "Dinner out",
+				Category:    "Dining",
+			},
+			mockSetup: func(m *MockFinanceService) {
+				expectedDate, _ := time.Parse("2006-01-02", "2025-09-15")
+				in := service.ExpenseInput{Date: expectedDate, Amount: 120, Description: "Dinner out", Category: "Dining"}
+				m.On("RecordExpense", mock.Anything, in).Return(service.Transaction{ID: 10}, []service.Warning{
+					{Code: service.WarningOverBudget, Category: "Dining", Month: "2025-09", Budget: 300, Spent: 340},
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp ExpenseCreatedResponse
+				require.NoError(t, json.Unmarshal(body, &resp))
+				assert.Equal(t, int32(10), resp.Transaction.ID)
+				require.Len(t, resp.Warnings, 1)
+				assert.Equal(t, service.WarningOverBudget, resp.Warnings[0].Code)
+				assert.Equal(t, 340.0, resp.Warnings[0].Spent)
+			},
+		},
+		{
+			name:   "DELETE /api/transactions/123 - success",
+			method: "DELETE",
+			path:   "/api/transactions/123",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteTransaction", mock.Anything, int32(123)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "DELETE /api/transactions/404 - not found",
+			method: "DELETE",
+			path:   "/api/transactions/404",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteTransaction", mock.Anything, int32(404)).Return(fmt.Errorf("%w: 404", service.ErrTransactionNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "PUT /api/transactions/5/tax - success",
+			method: "PUT",
+			path:   "/api/transactions/5/tax",
+			body: SetTransactionTaxRequest{
+				GrossAmount: 3000.00,
+				TaxWithheld: 450.00,
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetTransactionTax", mock.Anything, int32(5), 3000.00, 450.00).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/transactions/5/status - clear a check",
+			method: "PUT",
+			path:   "/api/transactions/5/status",
+			body:   SetTransactionStatusRequest{Status: "cleared"},
+			header: map[string]string{"If-Match": `"3"`},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetTransactionStatus", atVersion(3), int32(5), "cleared").
+					Return(service.Transaction{ID: 5, Status: service.StatusCleared, Version: 4}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var tx service.Transaction
+				require.NoError(t, json.Unmarshal(body, &tx))
+				assert.Equal(t, service.StatusCleared, tx.Status)
+			},
+		},
+		{
+			name:   "PUT /api/transactions/5/status - invalid status",
+			method: "PUT",
+			path:   "/api/transactions/5/status",
+			body:   SetTransactionStatusRequest{Status: "bounced"},
+			header: map[string]string{"If-Match": `"3"`},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetTransactionStatus", mock.Anything, int32(5), "bounced").
+					Return(service.Transaction{}, fmt.Errorf("%w \"bounced\"", service.ErrInvalidStatus))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "PUT /api/transactions/5/status - status required",
+			method:         "PUT",
+			path:           "/api/transactions/5/status",
+			body:           SetTransactionStatusRequest{},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/transactions/404/status - not found",
+			method: "PUT",
+			path:   "/api/transactions/404/status",
+			body:   SetTransactionStatusRequest{Status: "pending"},
+			header: map[string]string{"If-Match": "*"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetTransactionStatus", mock.Anything, int32(404), "pending").
+					Return(service.Transaction{}, fmt.Errorf("%w: 404", service.ErrTransactionNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "PUT /api/transactions/5/status - no version",
+			method:         "PUT",
+			path:           "/api/transactions/5/status",
+			body:           SetTransactionStatusRequest{Status: "pending"},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:           "PUT /api/transactions/5/status - bad If-Match",
+			method:         "PUT",
+			path:           "/api/transactions/5/status",
+			body:           SetTransactionStatusRequest{Status: "pending"},
+			header:         map[string]string{"If-Match": `"abc"`},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/transactions/5/status - changed meanwhile",
+			method: "PUT",
+			path:   "/api/transactions/5/status",
+			body:   map[string]any{"status": "pending", "version": 2},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetTransactionStatus", atVersion(2), int32(5), "pending").
+					Return(service.Transaction{}, fmt.Errorf("%w: transaction 5 is at version 3, not 2", service.ErrVersionConflict))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "GET /api/transactions?sort=amount&order=desc",
+			method: "GET",
+			path:   "/api/transactions?sort=amount&order=desc",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListTransactions", mock.Anything, "", service.ListOrder{Sort: service.SortAmount, Desc: true}, service.Page{}).
+					Return([]service.Transaction{{ID: 2}, {ID: 1}}, "", nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/transactions?sort=category - invalid",
+			method:         "GET",
+			path:           "/api/transactions?sort=category",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/transactions?order=up - invalid",
+			method:         "GET",
+			path:           "/api/transactions?order=up",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/transactions?limit=2&cursor=abc - paged",
+			method: "GET",
+			path:   "/api/transactions?sort=amount&limit=2&cursor=abc",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListTransactions", mock.Anything, "", service.ListOrder{Sort: service.SortAmount}, service.Page{Limit: 2, After: "abc"}).
+					Return([]service.Transaction{{ID: 3}, {ID: 4}}, "def", nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/transactions?limit=0 - invalid",
+			method:         "GET",
+			path:           "/api/transactions?limit=0",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/transactions?cursor=stale - invalid page",
+			method: "GET",
+			path:   "/api/transactions?sort=description&limit=10&cursor=stale",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListTransactions", mock.Anything, "", service.ListOrder{Sort: service.SortDescription}, service.Page{Limit: 10, After: "stale"}).
+					Return([]service.Transaction{}, "", fmt.Errorf("%w: cursor \"stale\"", service.ErrInvalidPage))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/transactions?status=pending - pending only",
+			method: "GET",
+			path:   "/api/transactions?status=pending&aggregates=true",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("AggregateTransactions", mock.Anything, service.AggregateFilter{Status: "pending"}).
+					Return(service.TransactionAggregates{Count: 1, TotalExpense: -120, Net: -120}, nil)
+				m.On("ListTransactions", mock.Anything, "pending", service.ListOrder{}, service.Page{}).
+					Return([]service.Transaction{{ID: 7, Status: service.StatusPending}}, "", nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp TransactionListResponse
+				require.NoError(t, json.Unmarshal(body, &resp))
+				require.Len(t, resp.Transactions, 1)
+				assert.Equal(t, service.StatusPending, resp.Transactions[0].Status)
+				assert.Equal(t, int64(1), resp.Aggregates.Count)
+			},
+		},
+		{
+			name:           "GET /api/transactions?status - invalid",
+			method:         "GET",
+			path:           "/api/transactions?status=bounced",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "DELETE /api/transactions/invalid - bad ID",
+			method:         "DELETE",
+			path:           "/api/transactions/invalid",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			var err error
+			if tt.body != nil {
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			if tt.body != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBalanceEndpoints(t *testing.T) {
+	tests := []testCase{
+		{
+			name:   "GET /api/balance - success",
+			method: "GET",
+			path:   "/api/balance",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetStartingBalance", mock.Anything).Return(5000.75, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp map[string]float64
+				err := json.Unmarshal(body, &resp)
+				require.NoError(t, err)
+				assert.Equal(t, 5000.75, resp["balance"])
+			},
+		},
+		{
+			name:   "PUT /api/balance - success",
+			method: "PUT",
+			path:   "/api/balance",
+			body: SetBalanceRequest{
+				Balance: 10000.00,
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetStartingBalance", mock.Anything, 10000.00).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			var err error
+			if tt.body != nil {
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			if tt.body != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecurringEndpoints(t *testing.T) {
+	cardID := int32(2)
+
+	tests := []testCase{
+		{
+			name:   "GET /api/recurring?sort=description",
+			method: "GET",
+			path:   "/api/recurring?sort=description",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListRecurringSummaries", mock.Anything, service.ListOrder{Sort: service.SortDescription}, service.Page{}).
+					Return([]service.RecurringSummary{}, "", nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/recurring?limit=1 - encrypted description sort",
+			method: "GET",
+			path:   "/api/recurring?sort=description&limit=1",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListRecurringSummaries", mock.Anything, service.ListOrder{Sort: service.SortDescription}, service.Page{Limit: 1}).
+					Return([]service.RecurringSummary{}, "", fmt.Errorf("%w: descriptions are encrypted", service.ErrInvalidPage))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/recurring?order=sideways - invalid",
+			method:         "GET",
+			path:           "/api/recurring?order=sideways",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/recurring - success",
+			method: "GET",
+			path:   "/api/recurring",
+			mockSetup: func(m *MockFinanceService) {
+				next := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+				m.On("ListRecurringSummaries", mock.Anything, service.ListOrder{}, service.Page{}).Return([]service.RecurringSummary{{
+					Recurring:      service.Recurring{ID: 1, Description: "Monthly rent"},
+					NextOccurrence: &next,
+					UpcomingCount:  1,
+					Annualized:     -14400,
+				}}, "", nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var recurring []map[string]interface{}
+				err := json.Unmarshal(body, &recurring)
+				require.NoError(t, err)
+				require.Len(t, recurring, 1)
+				assert.Equal(t, "Monthly rent", recurring[0]["description"])
+				assert.Equal(t, "2025-10-01T00:00:00Z", recurring[0]["next_occurrence"])
+				assert.Nil(t, recurring[0]["last_occurrence"])
+				assert.Equal(t, 1.0, recurring[0]["occurrences_next_30_days"])
+				assert.Equal(t, -14400.0, recurring[0]["annualized_amount"])
+			},
+		},
+		{
+			name:   "POST /api/recurring - success",
+			method: "POST",
+			path:   "/api/recurring",
+			body: RecurringTransactionRequest{
+				Description: "Monthly rent",
+				Type:        "expense",
+				Amount:      1200.00,
+				StartDate:   "2025-09-01",
+				Interval:    "monthly",
+				DayOfMonth:  intPtr(1),
+				Active:      true,
+			},
+			mockSetup: func(m *MockFinanceService) {
+				expectedStartDate, _ := time.Parse("2006-01-02", "2025-09-01")
+				expectedInput := service.RecurringInput{
+					Description: "Monthly rent",
+					Type:        "expense",
+					Amount:      1200.00,
+					StartDate:   expectedStartDate,
+					Interval:    "monthly",
+					DayOfMonth:  intPtr(1),
+					Active:      true,
+				}
+				m.On("CreateRecurringSimple", mock.Anything, expectedInput).Return(service.Recurring{
+					ID: 1, Description: "Monthly rent",
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/recurring - occurrence count",
+			method: "POST",
+			path:   "/api/recurring",
+			body: RecurringTransactionRequest{
+				Description:     "Car loan",
+				Type:            "expense",
+				Amount:          350.00,
+				StartDate:       "2025-09-15",
+				Interval:        "monthly",
+				DayOfMonth:      intPtr(15),
+				OccurrenceCount: intPtr(12),
+				Active:          true,
+			},
+			mockSetup: func(m *MockFinanceService) {
+				expectedStartDate, _ := time.Parse("2006-01-02", "2025-09-15")
+				expectedInput := service.RecurringInput{
+					Description:     "Car loan",
+					Type:            "expense",
+					Amount:          350.00,
+					StartDate:       expectedStartDate,
+					Interval:        "monthly",
+					DayOfMonth:      intPtr(15),
+					OccurrenceCount: intPtr(12),
+					Active:          true,
+				}
+				m.On("CreateRecurringSimple", mock.Anything, expectedInput).Return(service.Recurring{
+					ID: 2, Description: "Car loan",
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/recurring - rrule",
+			method: "POST",
+			path:   "/api/recurring",
+			body: RecurringTransactionRequest{
+				Description: "Salary",
+				Type:        "income",
+				Amount:      4200.00,
+				StartDate:   "2025-01-01",
+				RRule:       "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+				Active:      true,
+			},
+			mockSetup: func(m *MockFinanceService) {
+				expectedStartDate, _ := time.Parse("2006-01-02", "2025-01-01")
+				expectedInput := service.RecurringInput{
+					Description: "Salary",
+					Type:        "income",
+					Amount:      4200.00,
+					StartDate:   expectedStartDate,
+					RRule:       "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+					Active:      true,
+				}
+				m.On("CreateRecurringSimple", mock.Anything, expectedInput).Return(service.Recurring{
+					ID: 3, Description: "Salary", Interval: database.RecurrenceIntervalCustom,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/recurring - category and account",
+			method: "POST",
+			path:   "/api/recurring",
+			body: RecurringTransactionRequest{
+				Description: "Streaming",
+				Type:        "expense",
+				Amount:      15.49,
+				StartDate:   "2025-01-12",
+				Interval:    "monthly",
+				Active:      true,
+				Category:    "Subscriptions",
+				AccountID:   &cardID,
+			},
+			mockSetup: func(m *MockFinanceService) {
+				expectedStartDate, _ := time.Parse("2006-01-02", "2025-01-12")
+				expectedInput := service.RecurringInput{
+					Description: "Streaming",
+					Type:        "expense",
+					Amount:      15.49,
+					StartDate:   expectedStartDate,
+					Interval:    "monthly",
+					Active:      true,
+					Category:    "Subscriptions",
+					AccountID:   &cardID,
+				}
+				m.On("CreateRecurringSimple", mock.Anything, expectedInput).Return(service.Recurring{
+					ID: 4, Description: "Streaming", Interval: database.RecurrenceIntervalMonthly,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "DELETE /api/recurring/1 - success",
+			method: "DELETE",
+			path:   "/api/recurring/1",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteRecurring", mock.Anything, int32(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "DELETE /api/recurring/404 - not found",
+			method: "DELETE",
+			path:   "/api/recurring/404",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteRecurring", mock.Anything, int32(404)).Return(fmt.Errorf("%w: 404", service.ErrRecurringNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "GET /api/recurring/1/calendar.ics - success",
+			method: "GET",
+			path:   "/api/recurring/1/calendar.ics",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Today", mock.Anything).Return(time.Date(2025, 9, 15, 0, 0, 0, 0, time.UTC))
+				rent := service.Recurring{ID: 1, Description: "Rent, apt 4", Type: "expense", Amount: pgNumeric("1200.00")}
+				m.On("RecurringOccurrences", mock.Anything, int32(1), mock.Anything, mock.Anything).Return(rent, []service.Transaction{
+					{Date: pgDate("2025-10-01"), Description: "Rent, apt 4"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				ics := string(body)
+				assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+				assert.Contains(t, ics, "DTSTART;VALUE=DATE:20251001\r\n")
+				assert.Contains(t, ics, "SUMMARY:Rent\\, apt 4 (-$1200.00)\r\n")
+				assert.Contains(t, ics, "UID:recurring-1-20251001@currentz")
+			},
+		},
+		{
+			name:   "GET /api/recurring/9/calendar.ics - not found",
+			method: "GET",
+			path:   "/api/recurring/9/calendar.ics",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Today", mock.Anything).Return(time.Date(2025, 9, 15, 0, 0, 0, 0, time.UTC))
+				m.On("RecurringOccurrences", mock.Anything, int32(9), mock.Anything, mock.Anything).Return(service.Recurring{}, []service.Transaction(nil), fmt.Errorf("no rows in result set"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "PUT /api/recurring/1/active - success",
+			method: "PUT",
+			path:   "/api/recurring/1/active",
+			body:   map[string]any{"active": false, "version": 2},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetRecurringActive", atVersion(2), int32(1), false).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/recurring/1/active - changed meanwhile",
+			method: "PUT",
+			path:   "/api/recurring/1/active",
+			body:   SetActiveRequest{Active: true},
+			header: map[string]string{"If-Match": `W/"2"`},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetRecurringActive", atVersion(2), int32(1), true).
+					Return(fmt.Errorf("%w: recurring 1", service.ErrVersionConflict))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "PUT /api/recurring/2/anchor - shift one week",
+			method: "PUT",
+			path:   "/api/recurring/2/anchor",
+			body:   ReanchorRecurringRequest{ShiftWeeks: 1},
+			header: map[string]string{"If-Match": `"1"`},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ReanchorRecurring", mock.Anything, int32(2), service.ReanchorInput{ShiftWeeks: 1}).
+					Return(service.Recurring{ID: 2, StartDate: pgDate("2025-01-10")}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var rec service.Recurring
+				require.NoError(t, json.Unmarshal(body, &rec))
+				assert.Equal(t, int32(2), rec.ID)
+				assert.Equal(t, "2025-01-10", rec.StartDate.Time.Format("2006-01-02"))
+			},
+		},
+		{
+			name:   "PUT /api/recurring/2/anchor - explicit date",
+			method: "PUT",
+			path:   "/api/recurring/2/anchor",
+			body:   map[string]any{"anchor_date": "2025-01-17", "version": 1},
+			mockSetup: func(m *MockFinanceService) {
+				anchor, _ := time.Parse("2006-01-02", "2025-01-17")
+				m.On("ReanchorRecurring", mock.Anything, int32(2), service.ReanchorInput{AnchorDate: &anchor}).
+					Return(service.Recurring{ID: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/recurring/3/anchor - monthly rejected",
+			method: "PUT",
+			path:   "/api/recurring/3/anchor",
+			body:   ReanchorRecurringRequest{ShiftWeeks: -1},
+			header: map[string]string{"If-Match": `"1"`},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ReanchorRecurring", mock.Anything, int32(3), service.ReanchorInput{ShiftWeeks: -1}).
+					Return(service.Recurring{}, fmt.Errorf("only weekly and biweekly recurrings can be re-anchored"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/recurring/4/pause - two months",
+			method: "PUT",
+			path:   "/api/recurring/4/pause",
+			body:   map[string]any{"paused_from": "2025-07-01", "paused_until": "2025-08-31", "version": 5},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("PauseRecurring", mock.Anything, int32(4), mock.MatchedBy(func(d *time.Time) bool {
+					return d != nil && d.Format("2006-01-02") == "2025-07-01"
+				}), mock.MatchedBy(func(d *time.Time) bool {
+					return d != nil && d.Format("2006-01-02") == "2025-08-31"
+				})).Return(service.Recurring{ID: 4, PausedFrom: pgDate("2025-07-01"), PausedUntil: pgDate("2025-08-31")}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var rec service.Recurring
+				require.NoError(t, json.Unmarshal(body, &rec))
+				assert.Equal(t, "2025-08-31", rec.PausedUntil.Time.Format("2006-01-02"))
+			},
+		},
+		{
+			name:           "PUT /api/recurring/4/pause - missing end",
+			method:         "PUT",
+			path:           "/api/recurring/4/pause",
+			body:           map[string]string{"paused_from": "2025-07-01"},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/recurring/suggestions",
+			method: "GET",
+			path:   "/api/recurring/suggestions",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SuggestRecurring", mock.Anything).Return([]service.RecurringSuggestion{
+					{ID: "a1b2c3d4e5f6", Description: "Netflix", Type: "expense", Amount: 15.49, Interval: "monthly", Occurrences: 4},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got []service.RecurringSuggestion
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got, 1)
+				assert.Equal(t, "a1b2c3d4e5f6", got[0].ID)
+			},
+		},
+		{
+			name:   "POST /api/recurring/suggestions/a1b2c3d4e5f6/accept",
+			method: "POST",
+			path:   "/api/recurring/suggestions/a1b2c3d4e5f6/accept",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("AcceptRecurringSuggestion", mock.Anything, "a1b2c3d4e5f6").Return(service.Recurring{ID: 7, Description: "Netflix"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/recurring/suggestions/ffffffffffff/accept - gone",
+			method: "POST",
+			path:   "/api/recurring/suggestions/ffffffffffff/accept",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("AcceptRecurringSuggestion", mock.Anything, "ffffffffffff").Return(service.Recurring{}, fmt.Errorf("%w: ffffffffffff", service.ErrSuggestionNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "DELETE /api/recurring/4/pause - resume",
+			method: "DELETE",
+			path:   "/api/recurring/4/pause",
+			header: map[string]string{"If-Match": `"6"`},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("PauseRecurring", atVersion(6), int32(4), (*time.Time)(nil), (*time.Time)(nil)).Return(service.Recurring{ID: 4}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "DELETE /api/recurring/4/pause - no version",
+			method:         "DELETE",
+			path:           "/api/recurring/4/pause",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:   "DELETE /api/recurring/9/pause - not found",
+			method: "DELETE",
+			path:   "/api/recurring/9/pause",
+			header: map[string]string{"If-Match": "*"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("PauseRecurring", mock.Anything, int32(9), (*time.Time)(nil), (*time.Time)(nil)).Return(service.Recurring{}, fmt.Errorf("%w: 9", service.ErrRecurringNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			var err error
+			if tt.body != nil {
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			if tt.body != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestForecastEndpoints(t *testing.T) {
+	tests := []testCase{
+		{
+			name:   "GET /api/forecast - success",
+			method: "GET",
+			path:   "/api/forecast",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ForecastDays", mock.Anything).Return(90)
+				m.On("GetStartingBalance", mock.Anything).Return(5000.00, nil)
+				m.On("Forecast", mock.Anything, 5000.00, 90).Return([]service.DailyCashFlow{
+					{Date: time.Now(), Balance: 5000.00, Change: 0},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var forecast []service.DailyCashFlow
+				err := json.Unmarshal(body, &forecast)
+				require.NoError(t, err)
+				assert.Len(t, forecast, 1)
+				assert.Equal(t, 5000.00, forecast[0].Balance)
+			},
+		},
+		{
+			name:   "GET /api/forecast - horizon from settings",
+			method: "GET",
+			path:   "/api/forecast",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ForecastDays", mock.Anything).Return(30)
+				m.On("GetStartingBalance", mock.Anything).Return(5000.00, nil)
+				m.On("Forecast", mock.Anything, 5000.00, 30).Return([]service.DailyCashFlow{
+					{Date: time.Now(), Balance: 5000.00, Change: 0},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/forecast?include_pending=false - cleared only",
+			method: "GET",
+			path:   "/api/forecast?days=14&include_pending=false",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetStartingBalance", mock.Anything).Return(5000.00, nil)
+				m.On("ForecastWithOptions", mock.Anything, 5000.00, 14, service.ForecastOptions{ExcludePending: true}).
+					Return([]service.DailyCashFlow{{Date: time.Now(), Balance: 5000.00}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/forecast?include_pending - invalid",
+			method:         "GET",
+			path:           "/api/forecast?days=14&include_pending=maybe",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/forecast?days - custom horizon",
+			method: "GET",
+			path:   "/api/forecast?days=14",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetStartingBalance", mock.Anything).Return(5000.00, nil)
+				m.On("Forecast", mock.Anything, 5000.00, 14).Return([]service.DailyCashFlow{
+					{Date: time.Now(), Balance: 5000.00, Change: 0},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/forecast?days - out of range",
+			method:         "GET",
+			path:           "/api/forecast?days=0",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/forecast?as_of - reconstructs past forecast",
+			method: "GET",
+			path:   "/api/forecast?as_of=2025-06-01",
+			mockSetup: func(m *MockFinanceService) {
+				asOf := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+				m.On("ForecastAsOf", mock.Anything, asOf).Return([]service.DailyCashFlow{
+					{Date: asOf, Balance: 1200.00, Change: 0},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var forecast []service.DailyCashFlow
+				require.NoError(t, json.Unmarshal(body, &forecast))
+				require.Len(t, forecast, 1)
+				assert.Equal(t, 1200.00, forecast[0].Balance)
+			},
+		},
+		{
+			name:           "GET /api/forecast?as_of - bad date",
+			method:         "GET",
+			path:           "/api/forecast?as_of=June",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/forecast?as_of - future date",
+			method: "GET",
+			path:   "/api/forecast?as_of=2999-01-01",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ForecastAsOf", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: in the future", service.ErrInvalidAsOf))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/forecast/all - every account and the total",
+			method: "GET",
+			path:   "/api/forecast/all?days=2&include_pending=false",
+			mockSetup: func(m *MockFinanceService) {
+				today := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+				m.On("GetStartingBalance", mock.Anything).Return(5000.00, nil)
+				m.On("ForecastAll", mock.Anything, 5000.00, 2, service.ForecastOptions{ExcludePending: true}).Return(service.AllForecasts{
+					Accounts: map[int32]service.AccountForecast{
+						1: {Account: service.Account{ID: 1, Name: "Visa", Type: service.AccountCreditCard},
+							Forecast: []service.DailyCashFlow{{Date: today, Balance: -60}, {Date: today.AddDate(0, 0, 1), Balance: -60}}},
+					},
+					Total: []service.DailyCashFlow{{Date: today, Balance: 5000}, {Date: today.AddDate(0, 0, 1), Balance: 4900}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got struct {
+					Accounts map[string]struct {
+						Account  service.Account         `json:"account"`
+						Forecast []service.DailyCashFlow `json:"forecast"`
+					} `json:"accounts"`
+					Total []service.DailyCashFlow `json:"total"`
+				}
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Contains(t, got.Accounts, "1")
+				assert.Equal(t, "Visa", got.Accounts["1"].Account.Name)
+				assert.Equal(t, -60.0, got.Accounts["1"].Forecast[1].Balance)
+				require.Len(t, got.Total, 2)
+				assert.Equal(t, 4900.0, got.Total[1].Balance)
+			},
+		},
+		{
+			name:           "GET /api/forecast/all - days out of range",
+			method:         "GET",
+			path:           "/api/forecast/all?days=0",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/forecast/history - snapshot for a day",
+			method: "GET",
+			path:   "/api/forecast/history?date=2025-08-01",
+			mockSetup: func(m *MockFinanceService) {
+				day := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+				m.On("ForecastHistory", mock.Anything, day).Return(service.ForecastSnapshot{
+					Date:            day,
+					StartingBalance: 1500,
+					Forecast:        []service.DailyCashFlow{{Date: day, Balance: 1500}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var snapshot service.ForecastSnapshot
+				require.NoError(t, json.Unmarshal(body, &snapshot))
+				assert.Equal(t, 1500.0, snapshot.StartingBalance)
+				require.Len(t, snapshot.Forecast, 1)
+			},
+		},
+		{
+			name:   "GET /api/forecast/history - no snapshot",
+			method: "GET",
+			path:   "/api/forecast/history?date=2025-07-01",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ForecastHistory", mock.Anything, mock.Anything).
+					Return(service.ForecastSnapshot{}, fmt.Errorf("%w: 2025-07-01", service.ErrSnapshotNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "GET /api/forecast/history - list days",
+			method: "GET",
+			path:   "/api/forecast/history",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ForecastSnapshotDates", mock.Anything).Return([]time.Time{
+					time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+					time.Date(2025, 8, 2, 0, 0, 0, 0, time.UTC),
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got map[string][]string
+				require.NoError(t, json.Unmarshal(body, &got))
+				assert.Equal(t, []string{"2025-08-01", "2025-08-02"}, got["dates"])
+			},
+		},
+		{
+			name:           "GET /api/forecast/history - bad date",
+			method:         "GET",
+			path:           "/api/forecast/history?date=August",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/forecast/contributions - default horizon",
+			method: "GET",
+			path:   "/api/forecast/contributions",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("RecurringContributions", mock.Anything, 90).Return([]service.RecurringContribution{
+					{ID: 3, Description: "Salary", Type: "income", Total: 17500, Occurrences: 7},
+					{ID: 2, Description: "Rent", Type: "expense", Total: -3600, Occurrences: 3},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got []service.RecurringContribution
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got, 2)
+				assert.Equal(t, "Salary", got[0].Description)
+				assert.Equal(t, -3600.0, got[1].Total)
+			},
+		},
+		{
+			name:   "GET /api/forecast/contributions - custom horizon",
+			method: "GET",
+			path:   "/api/forecast/contributions?days=365",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("RecurringContributions", mock.Anything, 365).Return([]service.RecurringContribution{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/forecast/contributions - bad days",
+			method:         "GET",
+			path:           "/api/forecast/contributions?days=-1",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/forecast/monthly - default months",
+			method: "GET",
+			path:   "/api/forecast/monthly",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("MonthlyOutlook", mock.Anything, 12).Return([]service.MonthOutlook{
+					{Month: "2025-03", Expense: -1200, Net: -1200, AccruedExpense: -100, AccruedNet: -100},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got []service.MonthOutlook
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got, 1)
+				assert.Equal(t, -1200.0, got[0].Expense)
+				assert.Equal(t, -100.0, got[0].AccruedExpense)
+			},
+		},
+		{
+			name:           "GET /api/forecast/monthly - bad months",
+			method:         "GET",
+			path:           "/api/forecast/monthly?months=0",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/reports/variance - month",
+			method: "GET",
+			path:   "/api/reports/variance?month=2025-09",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("MonthVariance", mock.Anything, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)).Return(service.VarianceReport{
+					Month:    "2025-09",
+					Complete: true,
+					Categories: []service.CategoryVariance{
+						{Category: "Groceries", VarianceAmounts: service.VarianceAmounts{Projected: -400, Actual: -450, Variance: -50}},
+					},
+					Total: service.VarianceAmounts{Projected: -400, Actual: -450, Variance: -50},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.JSONEq(t, `{"month":"2025-09","complete":true,
+					"categories":[{"category":"Groceries","projected":-400,"actual":-450,"variance":-50}],
+					"total":{"projected":-400,"actual":-450,"variance":-50}}`, string(body))
+			},
+		},
+		{
+			name:   "GET /api/reports/variance - future month",
+			method: "GET",
+			path:   "/api/reports/variance?month=2999-01",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("MonthVariance", mock.Anything, mock.Anything).Return(service.VarianceReport{}, fmt.Errorf("%w: 2999-01 hasn't started", service.ErrInvalidMonth))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/reports/variance - bad month",
+			method:         "GET",
+			path:           "/api/reports/variance?month=September",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/insights - month",
+			method: "GET",
+			path:   "/api/insights?month=2025-09",
+			mockSetup: func(m *MockFinanceService) {
+				share := 30.0
+				m.On("Insights", mock.Anything, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)).Return(service.SpendingInsights{
+					Month:                  "2025-09",
+					Complete:               true,
+					Days:                   30,
+					AverageDailySpend:      78,
+					RecurringCosts:         1200,
+					RecurringShareOfIncome: &share,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got map[string]interface{}
+				require.NoError(t, json.Unmarshal(body, &got))
+				assert.Equal(t, "2025-09", got["month"])
+				assert.Equal(t, 78.0, got["average_daily_spend"])
+				assert.Equal(t, 30.0, got["recurring_percent_of_income"])
+			},
+		},
+		{
+			name:   "GET /api/insights - future month",
+			method: "GET",
+			path:   "/api/insights?month=2999-01",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Insights", mock.Anything, mock.Anything).Return(service.SpendingInsights{}, fmt.Errorf("%w: 2999-01 hasn't started", service.ErrInvalidMonth))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/insights - bad month",
+			method:         "GET",
+			path:           "/api/insights?month=Sept",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/reports/heatmap - range",
+			method: "GET",
+			path:   "/api/reports/heatmap?start=2025-09-01&end=2025-09-30",
+			mockSetup: func(m *MockFinanceService) {
+				start, end := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)
+				m.On("SpendingHeatmap", mock.Anything, start, end).Return(service.SpendingHeatmap{
+					Start: start,
+					End:   end,
+					Days: []service.HeatmapDay{
+						{Date: start, Year: 2025, Week: 36, Weekday: 1, HeatmapTotal: service.HeatmapTotal{Count: 2, Spent: 50}},
+					},
+					Weekdays:    []service.HeatmapWeekday{{Weekday: 1, HeatmapTotal: service.HeatmapTotal{Count: 2, Spent: 50}}},
+					MaxDaySpent: 50,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got struct {
+					Days        []map[string]interface{} `json:"days"`
+					MaxDaySpent float64                  `json:"max_day_spent"`
+				}
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got.Days, 1)
+				assert.Equal(t, 36.0, got.Days[0]["week"])
+				assert.Equal(t, 1.0, got.Days[0]["weekday"])
+				assert.Equal(t, 50.0, got.Days[0]["spent"])
+				assert.Equal(t, 50.0, got.MaxDaySpent)
+			},
+		},
+		{
+			name:   "GET /api/reports/heatmap - defaults to the past year",
+			method: "GET",
+			path:   "/api/reports/heatmap",
+			mockSetup: func(m *MockFinanceService) {
+				today := time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)
+				m.On("Today", mock.Anything).Return(today)
+				m.On("SpendingHeatmap", mock.Anything, time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC), today).Return(service.SpendingHeatmap{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/reports/heatmap - missing exchange rate",
+			method: "GET",
+			path:   "/api/reports/heatmap?start=2025-09-01&end=2025-09-30",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SpendingHeatmap", mock.Anything, mock.Anything, mock.Anything).
+					Return(service.SpendingHeatmap{}, fmt.Errorf("%w for GBP (base currency is USD)", service.ErrNoExchangeRate))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "GET /api/reports/heatmap - end before start",
+			method:         "GET",
+			path:           "/api/reports/heatmap?start=2025-09-30&end=2025-09-01",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/reports/top - by description",
+			method: "GET",
+			path:   "/api/reports/top?by=description&limit=3&start=2025-09-01&end=2025-09-30&include_recurring=false",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("TopSpending", mock.Anything, service.TopSpendingFilter{
+					By:    service.TopByDescription,
+					Start: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+					End:   time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC),
+					Limit: 3,
+				}).Return(service.TopSpending{
+					By:    service.TopByDescription,
+					Top:   []service.SpendingSink{{Name: "Groceries", Count: 4, Spent: 320, Percent: 80}},
+					Count: 5,
+					Spent: 400,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got struct {
+					Top   []map[string]interface{} `json:"top"`
+					Spent float64                  `json:"spent"`
+				}
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got.Top, 1)
+				assert.Equal(t, "Groceries", got.Top[0]["name"])
+				assert.Equal(t, 4.0, got.Top[0]["count"])
+				assert.Equal(t, 80.0, got.Top[0]["percent"])
+				assert.Equal(t, 400.0, got.Spent)
+			},
+		},
+		{
+			name:   "GET /api/reports/top - defaults",
+			method: "GET",
+			path:   "/api/reports/top",
+			mockSetup: func(m *MockFinanceService) {
+				today := time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)
+				m.On("Today", mock.Anything).Return(today)
+				m.On("TopSpending", mock.Anything, service.TopSpendingFilter{
+					By: service.TopByPayee, Start: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), End: today, Limit: 10, IncludeRecurring: true,
+				}).Return(service.TopSpending{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/reports/top - bad grouping",
+			method: "GET",
+			path:   "/api/reports/top?by=merchant&start=2025-09-01&end=2025-09-30",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("TopSpending", mock.Anything, mock.Anything).Return(service.TopSpending{}, fmt.Errorf("%w: \"merchant\"", service.ErrInvalidGrouping))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/reports/top - missing exchange rate",
+			method: "GET",
+			path:   "/api/reports/top?start=2025-09-01&end=2025-09-30",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("TopSpending", mock.Anything, mock.Anything).
+					Return(service.TopSpending{}, fmt.Errorf("%w for GBP (base currency is USD)", service.ErrNoExchangeRate))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "GET /api/reports/top - bad limit",
+			method:         "GET",
+			path:           "/api/reports/top?limit=0&start=2025-09-01&end=2025-09-30",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/calendar - month",
+			method: "GET",
+			path:   "/api/calendar?month=2025-09",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Calendar", mock.Anything, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)).Return(service.MonthCalendar{
+					Month:        "2025-09",
+					FirstWeekday: 1,
+					DaysInMonth:  30,
+					Days: map[string]service.CalendarDay{
+						"2025-09-01": {
+							Transactions: service.OneOffs([]service.Transaction{{ID: 7, Description: "Rent", Type: "expense"}}),
+							Totals:       service.TransactionAggregates{Count: 1, TotalExpense: -1000, Net: -1000},
+						},
+						"2025-09-02": {Transactions: []service.ListedTransaction{}},
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got struct {
+					Month        string `json:"month"`
+					FirstWeekday int    `json:"first_weekday"`
+					Days         map[string]struct {
+						Transactions []map[string]interface{} `json:"transactions"`
+						Totals       map[string]interface{}   `json:"totals"`
+					} `json:"days"`
+				}
+				require.NoError(t, json.Unmarshal(body, &got))
+				assert.Equal(t, "2025-09", got.Month)
+				assert.Equal(t, 1, got.FirstWeekday)
+				require.Len(t, got.Days["2025-09-01"].Transactions, 1)
+				assert.Equal(t, "Rent", got.Days["2025-09-01"].Transactions[0]["description"])
+				assert.Equal(t, -1000.0, got.Days["2025-09-01"].Totals["net"])
+				assert.NotNil(t, got.Days["2025-09-02"].Transactions)
+			},
+		},
+		{
+			name:           "GET /api/calendar - bad month",
+			method:         "GET",
+			path:           "/api/calendar?month=09-2025",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "POST /api/forecast/compare - scenario vs forecast",
+			method: "POST",
+			path:   "/api/forecast/compare",
+			body: map[string]interface{}{
+				"days": 30,
+				"scenario": map[string]interface{}{
+					"transactions": []map[string]interface{}{{"date": "2025-09-05", "type": "expense", "amount": "1200", "description": "Laptop"}},
+					"recurring":    []map[string]interface{}{{"description": "Gym", "type": "expense", "amount": 40, "start_date": "2025-09-01", "interval": "monthly"}},
+				},
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetStartingBalance", mock.Anything).Return(3000.0, nil)
+				m.On("CompareForecasts", mock.Anything, 3000.0, 30, service.Scenario{}, service.Scenario{
+					Transactions: []service.ScenarioTransaction{
+						{Date: time.Date(2025, 9, 5, 0, 0, 0, 0, time.UTC), Type: "expense", Amount: 1200, Description: "Laptop"},
+					},
+					Recurring: []service.RecurringInput{
+						{Description: "Gym", Type: "expense", Amount: 40, StartDate: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Interval: "monthly", Active: true},
+					},
+				}).Return(service.ForecastComparison{
+					EndingBalance: service.ForecastDelta{Baseline: 3000, Scenario: 1760, Delta: -1240},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var cmp service.ForecastComparison
+				require.NoError(t, json.Unmarshal(body, &cmp))
+				assert.Equal(t, -1240.0, cmp.EndingBalance.Delta)
+			},
+		},
+		{
+			name:   "POST /api/forecast/compare - invalid scenario",
+			method: "POST",
+			path:   "/api/forecast/compare",
+			body: CompareForecastRequest{
+				Days:     30,
+				Baseline: &ScenarioRequest{},
+				Scenario: ScenarioRequest{Transactions: []ScenarioTransactionRequest{{Date: "2025-09-05", Type: "gift", Amount: 5}}},
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetStartingBalance", mock.Anything).Return(3000.0, nil)
+				m.On("CompareForecasts", mock.Anything, 3000.0, 30, mock.Anything, mock.Anything).
+					Return(service.ForecastComparison{}, fmt.Errorf("scenario: %w: transaction 1: invalid type", service.ErrInvalidScenario))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "POST /api/forecast/compare - bad date",
+			method: "POST",
+			path:   "/api/forecast/compare",
+			body: CompareForecastRequest{
+				Days:     30,
+				Scenario: ScenarioRequest{Transactions: []ScenarioTransactionRequest{{Date: "soon", Type: "income", Amount: 5}}},
+			},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/forecast/lowest - success",
+			method: "GET",
+			path:   "/api/forecast/lowest",
+			mockSetup: func(m *MockFinanceService) {
+				forecast := []service.DailyCashFlow{
+					{Date: time.Now(), Balance: 5000.00, Change: 0},
+				}
+				m.On("GetStartingBalance", mock.Anything).Return(5000.00, nil)
+				m.On("Calculate90DayForecast", mock.Anything, 5000.00).Return(forecast, nil)
+				m.On("FindLowestPoint", forecast).Return(forecast[0], 0)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp map[string]interface{}
+				err := json.Unmarshal(body, &resp)
+				require.NoError(t, err)
+				assert.Contains(t, resp, "lowest_point")
+				assert.Contains(t, resp, "day_index")
+				assert.Equal(t, float64(0), resp["day_index"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestQueryParameterEndpoints(t *testing.T) {
+	tests := []testCase{
+		{
+			name:   "GET /api/transactions/upcoming - default days",
+			method: "GET",
+			path:   "/api/transactions/upcoming",
+			mockSetup: func(m *MockFinanceService) {
+				today := time.Date(2025, 9, 15, 0, 0, 0, 0, time.UTC)
+				m.On("UpcomingWindow", mock.Anything, 30).Return(today, today.AddDate(0, 0, 30))
+				m.On("GetUpcomingTransactions", mock.Anything, 30).Return([]service.ListedTransaction{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/transactions/upcoming?days=7",
+			method: "GET",
+			path:   "/api/transactions/upcoming?days=7",
+			mockSetup: func(m *MockFinanceService) {
+				today := time.Date(2025, 9, 15, 0, 0, 0, 0, time.UTC)
+				m.On("UpcomingWindow", mock.Anything, 7).Return(today, today.AddDate(0, 0, 7))
+				m.On("GetUpcomingTransactions", mock.Anything, 7).Return([]service.ListedTransaction{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/transactions/upcoming?group_by=week",
+			method: "GET",
+			path:   "/api/transactions/upcoming?group_by=week",
+			mockSetup: func(m *MockFinanceService) {
+				txs := service.OneOffs([]service.Transaction{
+					{ID: 1, Type: "income", Date: pgDate("2025-09-15"), Amount: pgNumeric("2500.00")},
+					{ID: 2, Type: "expense", Date: pgDate("2025-09-17"), Amount: pgNumeric("-1200.00")},
+				})
+				m.On("GetUpcomingTransactions", mock.Anything, 30).Return(txs, nil)
+				m.On("GroupTransactions", mock.Anything, txs, service.GroupByWeek).Return(service.GroupedTransactions{
+					GroupBy: service.GroupByWeek,
+					Groups: []service.TransactionGroup{{
+						Start:        time.Date(2025, 9, 15, 0, 0, 0, 0, time.UTC),
+						Transactions: txs,
+						Totals:       service.TransactionAggregates{Count: 2, TotalIncome: 2500, TotalExpense: -1200, Net: 1300},
+					}},
+					Totals: service.TransactionAggregates{Count: 2, TotalIncome: 2500, TotalExpense: -1200, Net: 1300},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var grouped service.GroupedTransactions
+				require.NoError(t, json.Unmarshal(body, &grouped))
+				require.Len(t, grouped.Groups, 1)
+				assert.Len(t, grouped.Groups[0].Transactions, 2)
+				assert.Equal(t, 1300.0, grouped.Groups[0].Totals.Net)
+				assert.Equal(t, 2500.0, grouped.Totals.TotalIncome)
+			},
+		},
+		{
+			name:   "GET /api/transactions/upcoming?group_by=month - invalid",
+			method: "GET",
+			path:   "/api/transactions/upcoming?group_by=month",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetUpcomingTransactions", mock.Anything, 30).Return([]service.ListedTransaction{}, nil)
+				m.On("GroupTransactions", mock.Anything, []service.ListedTransaction{}, "month").
+					Return(service.GroupedTransactions{}, fmt.Errorf("%w: \"month\"", service.ErrInvalidGrouping))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/transactions/upcoming?group_by=day - missing exchange rate",
+			method: "GET",
+			path:   "/api/transactions/upcoming?group_by=day",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetUpcomingTransactions", mock.Anything, 30).Return([]service.ListedTransaction{}, nil)
+				m.On("GroupTransactions", mock.Anything, []service.ListedTransaction{}, service.GroupByDay).
+					Return(service.GroupedTransactions{}, fmt.Errorf("%w for GBP (base currency is USD)", service.ErrNoExchangeRate))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "GET /api/transactions/between - success",
+			method: "GET",
+			path:   "/api/transactions/between?start=2025-09-01&end=2025-09-30",
+			mockSetup: func(m *MockFinanceService) {
+				start, _ := time.Parse("2006-01-02", "2025-09-01")
+				end, _ := time.Parse("2006-01-02", "2025-09-30")
+				m.On("GetTransactionsWithRecurringsBetween", mock.Anything, start, end).Return([]service.ListedTransaction{
+					{Transaction: service.Transaction{ID: 4, Type: "expense", Date: pgDate("2025-09-03"), Amount: pgNumeric("-25.00")}, Source: service.SourceOneOff},
+					{
+						Transaction:    service.Transaction{Type: "expense", Date: pgDate("2025-09-05"), Amount: pgNumeric("-1200.00")},
+						Source:         service.SourceRecurring,
+						RecurringID:    pgtype.Int4{Int32: 7, Valid: true},
+						OccurrenceDate: pgDate("2025-09-05"),
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var txs []map[string]any
+				require.NoError(t, json.Unmarshal(body, &txs))
+				require.Len(t, txs, 2)
+				assert.Equal(t, "one_off", txs[0]["source"])
+				assert.Nil(t, txs[0]["recurring_id"])
+				assert.Nil(t, txs[0]["occurrence_date"])
+				assert.Equal(t, "recurring", txs[1]["source"])
+				assert.Equal(t, 7.0, txs[1]["recurring_id"])
+				assert.Equal(t, "2025-09-05", txs[1]["occurrence_date"])
+			},
+		},
+		{
+			name:   "GET /api/transactions/between?include_recurring=false",
+			method: "GET",
+			path:   "/api/transactions/between?start=2025-09-01&end=2025-09-30&include_recurring=false",
+			mockSetup: func(m *MockFinanceService) {
+				start, _ := time.Parse("2006-01-02", "2025-09-01")
+				end, _ := time.Parse("2006-01-02", "2025-09-30")
+				m.On("GetTransactionsBetween", mock.Anything, start, end).Return(service.OneOffs([]service.Transaction{
+					{ID: 4, Type: "expense", Date: pgDate("2025-09-03"), Amount: pgNumeric("-25.00")},
+				}), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/transactions/between?include_recurring=maybe - invalid",
+			method:         "GET",
+			path:           "/api/transactions/between?start=2025-09-01&end=2025-09-30&include_recurring=maybe",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/transactions/upcoming?include_recurring=false&aggregates=true",
+			method: "GET",
+			path:   "/api/transactions/upcoming?include_recurring=false&aggregates=true",
+			mockSetup: func(m *MockFinanceService) {
+				today := time.Date(2025, 9, 15, 0, 0, 0, 0, time.UTC)
+				end := today.AddDate(0, 0, 30)
+				m.On("UpcomingWindow", mock.Anything, 30).Return(today, end)
+				m.On("AggregateTransactions", mock.Anything, service.AggregateFilter{Start: &today, End: &end}).
+					Return(service.TransactionAggregates{}, nil)
+				m.On("GetTransactionsBetween", mock.Anything, today, end).Return([]service.ListedTransaction{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/transactions/summary",
+			method: "GET",
+			path:   "/api/transactions/summary?start=2025-09-01&end=2025-09-30&include_recurring=false",
+			mockSetup: func(m *MockFinanceService) {
+				start, _ := time.Parse("2006-01-02", "2025-09-01")
+				end, _ := time.Parse("2006-01-02", "2025-09-30")
+				m.On("SummarizeTransactions", mock.Anything, start, end, false).Return(service.TransactionSummary{
+					TransactionAggregates: service.TransactionAggregates{Count: 2, TotalIncome: 3000, TotalExpense: -1500, Net: 1500},
+					LargestExpense: &service.ListedTransaction{
+						Transaction: service.Transaction{ID: 4, Description: "Rent", Amount: pgNumeric("-1500.00")},
+						Source:      service.SourceOneOff,
+					},
+					AverageDailySpend: 50,
+					Days:              30,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var summary map[string]any
+				require.NoError(t, json.Unmarshal(body, &summary))
+				assert.Equal(t, 1500.0, summary["net"])
+				assert.Equal(t, 50.0, summary["average_daily_spend"])
+				assert.Equal(t, 30.0, summary["days"])
+				largest, ok := summary["largest_expense"].(map[string]any)
+				require.True(t, ok)
+				assert.Equal(t, "Rent", largest["description"])
+			},
+		},
+		{
+			name:   "GET /api/transactions/summary - missing exchange rate",
+			method: "GET",
+			path:   "/api/transactions/summary?start=2025-09-01&end=2025-09-30",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SummarizeTransactions", mock.Anything, mock.Anything, mock.Anything, true).
+					Return(service.TransactionSummary{}, fmt.Errorf("%w for GBP (base currency is USD)", service.ErrNoExchangeRate))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "GET /api/transactions/summary - end before start",
+			method:         "GET",
+			path:           "/api/transactions/summary?start=2025-09-30&end=2025-09-01",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/transactions/summary - missing end",
+			method:         "GET",
+			path:           "/api/transactions/summary?start=2025-09-01",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/taxes/estimate?year=2025",
+			method: "GET",
+			path:   "/api/taxes/estimate?year=2025",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("EstimateTaxes", mock.Anything, 2025).Return(service.TaxEstimate{
+					Year: 2025, ProjectedSurprise: 1200.00,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var est service.TaxEstimate
+				err := json.Unmarshal(body, &est)
+				require.NoError(t, err)
+				assert.Equal(t, 2025, est.Year)
+				assert.Equal(t, 1200.00, est.ProjectedSurprise)
+			},
+		},
+		{
+			name:           "GET /api/taxes/estimate?year=abc - bad year",
+			method:         "GET",
+			path:           "/api/taxes/estimate?year=abc",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/transactions/4/self-employment - tag",
+			method: "PUT",
+			path:   "/api/transactions/4/self-employment",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("TagSelfEmployment", mock.Anything, int32(4)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/transactions/5/self-employment - expense",
+			method: "PUT",
+			path:   "/api/transactions/5/self-employment",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("TagSelfEmployment", mock.Anything, int32(5)).Return(fmt.Errorf("%w: 5", service.ErrNotIncome))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "DELETE /api/transactions/9/self-employment - not found",
+			method: "DELETE",
+			path:   "/api/transactions/9/self-employment",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("UntagSelfEmployment", mock.Anything, int32(9)).Return(fmt.Errorf("%w: 9", service.ErrTransactionNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "GET /api/taxes/self-employment?year=2025",
+			method: "GET",
+			path:   "/api/taxes/self-employment?year=2025",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("EstimateSelfEmploymentTax", mock.Anything, 2025).Return(service.SelfEmploymentTaxEstimate{
+					Year: 2025, ProjectedTax: 8000, QuarterlyPayment: 2000,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var est service.SelfEmploymentTaxEstimate
+				require.NoError(t, json.Unmarshal(body, &est))
+				assert.Equal(t, 2000.0, est.QuarterlyPayment)
+			},
+		},
+		{
+			name:   "GET /api/taxes/self-employment - no rate",
+			method: "GET",
+			path:   "/api/taxes/self-employment?year=2025",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("EstimateSelfEmploymentTax", mock.Anything, 2025).Return(service.SelfEmploymentTaxEstimate{}, service.ErrSelfEmploymentRateNotSet)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "POST /api/taxes/self-employment/payments - current year",
+			method: "POST",
+			path:   "/api/taxes/self-employment/payments",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Today", mock.Anything).Return(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))
+				m.On("GenerateEstimatedPayments", mock.Anything, 2025).Return([]service.Recurring{
+					{ID: 1, Description: "Estimated tax Q1"},
+					{ID: 2, Description: "Estimated tax Q2"},
+					{ID: 3, Description: "Estimated tax Q3"},
+					{ID: 4, Description: "Estimated tax Q4"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var recs []service.Recurring
+				require.NoError(t, json.Unmarshal(body, &recs))
+				assert.Len(t, recs, 4)
+			},
+		},
+		{
+			name:   "GET /api/audit - filtered",
+			method: "GET",
+			path:   "/api/audit?entity=transaction&action=delete&since=2025-09-01&limit=5",
+			mockSetup: func(m *MockFinanceService) {
+				since, _ := time.Parse("2006-01-02", "2025-09-01")
+				m.On("ListAudit", mock.Anything, service.AuditFilter{
+					Entity: "transaction",
+					Action: "delete",
+					Since:  &since,
+					Limit:  5,
+				}).Return([]service.AuditEntry{
+					{ID: 1, Actor: "api", Action: "delete", Entity: "transaction", EntityID: "7"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var entries []service.AuditEntry
+				err := json.Unmarshal(body, &entries)
+				require.NoError(t, err)
+				assert.Len(t, entries, 1)
+				assert.Equal(t, "7", entries[0].EntityID)
+			},
+		},
+		{
+			name:           "GET /api/transactions/between - missing parameters",
+			method:         "GET",
+			path:           "/api/transactions/between?start=2025-09-01",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				var errResp ErrorResponse
+				err := json.Unmarshal(body, &errResp)
+				require.NoError(t, err)
+				assert.Contains(t, strings.ToLower(errResp.Error), "required")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTrashEndpoints(t *testing.T) {
+	tests := []testCase{
+		{
+			name:   "GET /api/trash - success",
+			method: "GET",
+			path:   "/api/trash",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListTrash", mock.Anything).Return(service.Trash{
+					Transactions: []service.Transaction{{ID: 3, Description: "Coffee"}},
+					Recurring:    []service.Recurring{},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var trash service.Trash
+				err := json.Unmarshal(body, &trash)
+				require.NoError(t, err)
+				assert.Len(t, trash.Transactions, 1)
+				assert.Empty(t, trash.Recurring)
+			},
+		},
+		{
+			name:   "POST /api/trash/3/restore - transaction",
+			method: "POST",
+			path:   "/api/trash/3/restore",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("RestoreTransaction", mock.Anything, int32(3)).Return(service.Transaction{ID: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "POST /api/trash/2/restore?kind=recurring - recurring",
+			method: "POST",
+			path:   "/api/trash/2/restore?kind=recurring",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("RestoreRecurring", mock.Anything, int32(2)).Return(service.Recurring{ID: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "POST /api/trash/9/restore - not in trash",
+			method: "POST",
+			path:   "/api/trash/9/restore",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("RestoreTransaction", mock.Anything, int32(9)).Return(service.Transaction{}, fmt.Errorf("no rows in result set"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "POST /api/trash/1/restore?kind=bogus - bad kind",
+			method:         "POST",
+			path:           "/api/trash/1/restore?kind=bogus",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArchiveEndpoints(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)
+	cutoff := time.Date(2023, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name:   "GET /api/archive/transactions - date range",
+			method: "GET",
+			path:   "/api/archive/transactions?start=2020-01-01&end=2020-12-31",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListArchivedTransactions", mock.Anything, &start, &end).Return([]service.ArchivedTransaction{
+					{ID: 4, Description: "Rent", Type: "expense", Currency: "USD"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var txs []service.ArchivedTransaction
+				require.NoError(t, json.Unmarshal(body, &txs))
+				require.Len(t, txs, 1)
+				assert.Equal(t, "Rent", txs[0].Description)
+			},
+		},
+		{
+			name:   "GET /api/archive/transactions - everything",
+			method: "GET",
+			path:   "/api/archive/transactions",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListArchivedTransactions", mock.Anything, (*time.Time)(nil), (*time.Time)(nil)).
+					Return([]service.ArchivedTransaction{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/archive/transactions - end before start",
+			method:         "GET",
+			path:           "/api/archive/transactions?start=2020-12-31&end=2020-01-01",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "POST /api/archive/run - success",
+			method: "POST",
+			path:   "/api/archive/run",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ArchiveCutoff", mock.Anything).Return(cutoff, true)
+				m.On("ArchiveTransactions", mock.Anything, cutoff).Return(int64(12), nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp ArchiveResponse
+				require.NoError(t, json.Unmarshal(body, &resp))
+				assert.Equal(t, ArchiveResponse{Archived: 12, Before: "2023-10-17"}, resp)
+			},
+		},
+		{
+			name:   "POST /api/archive/run - no retention period",
+			method: "POST",
+			path:   "/api/archive/run",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ArchiveCutoff", mock.Anything).Return(time.Time{}, false)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSyncEndpoint(t *testing.T) {
+	tests := []testCase{
+		{
+			name:   "GET /api/sync - everything",
+			method: "GET",
+			path:   "/api/sync",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Sync", mock.Anything, int64(0), service.DefaultSyncLimit).Return(service.SyncResult{
+					Cursor: 12,
+					Transactions: service.SyncChanges[service.Transaction, int32]{
+						Created: []service.Transaction{{ID: 3, Description: "Coffee"}},
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var res service.SyncResult
+				require.NoError(t, json.Unmarshal(body, &res))
+				assert.Equal(t, int64(12), res.Cursor)
+				require.Len(t, res.Transactions.Created, 1)
+				assert.Equal(t, "Coffee", res.Transactions.Created[0].Description)
+			},
+		},
+		{
+			name:   "GET /api/sync?since=12&limit=2 - changes",
+			method: "GET",
+			path:   "/api/sync?since=12&limit=2",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Sync", mock.Anything, int64(12), 2).Return(service.SyncResult{
+					Cursor: 14,
+					More:   true,
+					Recurring: service.SyncChanges[service.Recurring, int32]{
+						Deleted: []int32{5},
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.Contains(t, string(body), `"has_more":true`)
+				assert.Contains(t, string(body), `"deleted":[5]`)
+			},
+		},
+		{
+			name:   "GET /api/sync?since=99 - stale cursor",
+			method: "GET",
+			path:   "/api/sync?since=99",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Sync", mock.Anything, int64(99), service.DefaultSyncLimit).Return(service.SyncResult{}, service.ErrStaleCursor)
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:           "GET /api/sync?since=abc - bad cursor",
+			method:         "GET",
+			path:           "/api/sync?since=abc",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/sync?limit=0 - bad limit",
+			method:         "GET",
+			path:           "/api/sync?limit=0",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + tt.path)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportTransactions(t *testing.T) {
+	qif := "!Type:Bank\nD01/15/2025\nT-42.50\nPGrocer\nLFood\n^\n"
+	preview := service.ImportResult{
+		DryRun:  true,
+		Format:  "qif",
+		Entries: 1,
+		Transactions: []service.Transaction{
+			{Description: "Grocer", Type: "expense", Category: pgtype.Text{String: "Food", Valid: true}},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		mockSetup      func(*MockFinanceService)
+		expectedStatus int
+	}{
+		{
+			name: "dry run previews",
+			path: "/api/transactions/import?format=qif&dry_run=true",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ImportTransactions", mock.Anything, "qif", mock.Anything, true).Return(preview, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "commit creates",
+			path: "/api/transactions/import",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ImportTransactions", mock.Anything, "qif", mock.Anything, false).Return(service.ImportResult{Format: "qif", Entries: 1}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "bad file",
+			path: "/api/transactions/import?format=ofx",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ImportTransactions", mock.Anything, "ofx", mock.Anything, false).Return(service.ImportResult{}, fmt.Errorf("%w: unsupported format", service.ErrInvalidImport))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "bad dry_run",
+			path:           "/api/transactions/import?dry_run=maybe",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			resp, err := http.Post(server.URL+tt.path, "application/qif", strings.NewReader(qif))
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportRecurring(t *testing.T) {
+	csv := "description,type,amount,interval,day\nRent,expense,1200,monthly,1\n"
+
+	tests := []struct {
+		name           string
+		path           string
+		mockSetup      func(*MockFinanceService)
+		expectedStatus int
+	}{
+		{
+			name: "dry run previews",
+			path: "/api/recurring/import?dry_run=true",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ImportRecurringCSV", mock.Anything, mock.Anything, true).Return(service.RecurringImportResult{
+					DryRun: true, Rows: 1, Recurring: []service.Recurring{{Description: "Rent"}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "commit creates",
+			path: "/api/recurring/import",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ImportRecurringCSV", mock.Anything, mock.Anything, false).Return(service.RecurringImportResult{
+					Rows: 1, Recurring: []service.Recurring{{ID: 1, Description: "Rent"}},
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "invalid row",
+			path: "/api/recurring/import",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ImportRecurringCSV", mock.Anything, mock.Anything, false).Return(service.RecurringImportResult{},
+					fmt.Errorf("%w: row 1: amount must be positive", service.ErrInvalidImport))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "bad dry_run",
+			path:           "/api/recurring/import?dry_run=maybe",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			resp, err := http.Post(server.URL+tt.path, "text/csv", strings.NewReader(csv))
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("failed to close body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDryRunMiddleware(t *testing.T) {
+	inDryRun := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Value(mockDryRunKey{}) != nil })
+	notInDryRun := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Value(mockDryRunKey{}) == nil })
+
+	tests := []struct {
+		name           string
+		path           string
+		header         string
+		ctx            interface{}
+		expectedStatus int
+		expectedHeader string
+	}{
+		{name: "query", path: "/api/balance?dry_run=true", ctx: inDryRun, expectedStatus: http.StatusOK, expectedHeader: "true"},
+		{name: "header", path: "/api/balance", header: "true", ctx: inDryRun, expectedStatus: http.StatusOK, expectedHeader: "true"},
+		{name: "off", path: "/api/balance?dry_run=false", ctx: notInDryRun, expectedStatus: http.StatusOK},
+		{name: "absent", path: "/api/balance", ctx: notInDryRun, expectedStatus: http.StatusOK},
+		{name: "invalid", path: "/api/balance?dry_run=maybe", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			if tt.ctx != nil {
+				mockService.On("SetStartingBalance", tt.ctx, 100.0).Return(nil)
+			}
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodPut, server.URL+tt.path, strings.NewReader(`{"balance":100}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set("X-Dry-Run", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tt.expectedHeader, resp.Header.Get("X-Dry-Run"))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportTransactions(t *testing.T) {
+	t.Run("ynab download", func(t *testing.T) {
+		mockService := new(MockFinanceService)
+		start, _ := time.Parse("2006-01-02", "2025-01-01")
+		mockService.On("ExportTransactions", mock.Anything, "ynab", mock.Anything, &start, (*time.Time)(nil)).Return(nil)
+		server := setupTestServer(mockService)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/api/transactions/export?format=ynab&start=2025-01-01")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "currentz-ynab.csv")
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(body), "Date,Payee"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		mockService := new(MockFinanceService)
+		mockService.On("ExportTransactions", mock.Anything, "quicken", mock.Anything, (*time.Time)(nil), (*time.Time)(nil)).
+			Return(fmt.Errorf("%w: unsupported format", service.ErrInvalidExport))
+		server := setupTestServer(mockService)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/api/transactions/export?format=quicken")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing format", func(t *testing.T) {
+		server := setupTestServer(new(MockFinanceService))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/api/transactions/export")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestBackupEndpoints(t *testing.T) {
+	backup := service.Backup{
+		Version:      service.BackupVersion,
+		CreatedAt:    time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		Transactions: []service.Transaction{{ID: 7, Description: "Rent", Type: "expense", Amount: pgNumeric("-1200.00"), Date: pgDate("2025-02-01")}},
+		Recurring:    []service.Recurring{},
+		Settings:     []database.Settings{{Key: "starting_balance", Value: "500.00"}},
+		TaxDetails:   []database.IncomeTaxDetails{},
+	}
+
+	tests := []testCase{
+		{
+			name:   "GET /api/backup - success",
+			method: "GET",
+			path:   "/api/backup",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Backup", mock.Anything).Return(backup, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got service.Backup
+				require.NoError(t, json.Unmarshal(body, &got))
+				assert.Equal(t, service.BackupVersion, got.Version)
+				require.Len(t, got.Transactions, 1)
+				assert.Equal(t, int32(7), got.Transactions[0].ID)
+				assert.Equal(t, "500.00", got.Settings[0].Value)
+			},
+		},
+		{
+			name:   "POST /api/restore - round trip",
+			method: "POST",
+			path:   "/api/restore",
+			body:   backup,
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Restore", mock.Anything, mock.MatchedBy(func(b service.Backup) bool {
+					return b.Version == service.BackupVersion && len(b.Transactions) == 1 && b.Transactions[0].Description == "Rent"
+				})).Return(service.RestoreSummary{Transactions: 1, Settings: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var summary service.RestoreSummary
+				require.NoError(t, json.Unmarshal(body, &summary))
+				assert.Equal(t, 1, summary.Transactions)
+			},
+		},
+		{
+			name:   "POST /api/restore - unsupported version",
+			method: "POST",
+			path:   "/api/restore",
+			body:   map[string]int{"version": 99},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Restore", mock.Anything, mock.Anything).Return(service.RestoreSummary{}, fmt.Errorf("%w: unsupported version 99", service.ErrInvalidBackup))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBudgetEndpoints(t *testing.T) {
+	tests := []testCase{
+		{
+			name:   "GET /api/forecast/threshold - unset",
+			method: "GET",
+			path:   "/api/forecast/threshold",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("LowBalanceThreshold", mock.Anything).Return(0.0, false, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.JSONEq(t, `{"threshold":null}`, string(body))
+			},
+		},
+		{
+			name:   "PUT /api/forecast/threshold - success",
+			method: "PUT",
+			path:   "/api/forecast/threshold",
+			body:   SetThresholdRequest{Threshold: 250},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetLowBalanceThreshold", mock.Anything, 250.0).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/budgets/Dining - success",
+			method: "PUT",
+			path:   "/api/budgets/Dining",
+			body:   SetBudgetRequest{Limit: 300},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetBudget", mock.Anything, "Dining", 300.0).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/budgets - success",
+			method: "GET",
+			path:   "/api/budgets",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Budgets", mock.Anything).Return(map[string]float64{"dining": 300}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.JSONEq(t, `{"dining":300}`, string(body))
+			},
+		},
+		{
+			name:   "DELETE /api/forecast/threshold - success",
+			method: "DELETE",
+			path:   "/api/forecast/threshold",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ClearLowBalanceThreshold", mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "DELETE /api/budgets/travel - missing",
+			method: "DELETE",
+			path:   "/api/budgets/travel",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteBudget", mock.Anything, "travel").Return(fmt.Errorf(`no budget for "travel"`))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCurrencyEndpoints(t *testing.T) {
+	eur := service.ExchangeRate{Currency: "EUR", Rate: pgNumeric("1.08")}
+
+	tests := []testCase{
+		{
+			name:   "GET /api/currency - success",
+			method: "GET",
+			path:   "/api/currency",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("BaseCurrency", mock.Anything).Return("USD", nil)
+				m.On("ListExchangeRates", mock.Anything).Return([]service.ExchangeRate{eur}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp CurrencyResponse
+				require.NoError(t, json.Unmarshal(body, &resp))
+				assert.Equal(t, "USD", resp.BaseCurrency)
+				require.Len(t, resp.Rates, 1)
+				assert.Equal(t, "EUR", resp.Rates[0].Currency)
+			},
+		},
+		{
+			name:   "PUT /api/currency/base - success",
+			method: "PUT",
+			path:   "/api/currency/base",
+			body:   SetBaseCurrencyRequest{Currency: "EUR"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetBaseCurrency", mock.Anything, "EUR").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/currency/base - invalid code",
+			method: "PUT",
+			path:   "/api/currency/base",
+			body:   SetBaseCurrencyRequest{Currency: "E1"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetBaseCurrency", mock.Anything, "E1").Return(fmt.Errorf("%w \"E1\"", service.ErrInvalidCurrency))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/currency/rates/EUR - success",
+			method: "PUT",
+			path:   "/api/currency/rates/EUR",
+			body:   SetExchangeRateRequest{Rate: 1.08},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetExchangeRate", mock.Anything, "EUR", 1.08).Return(eur, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/currency/rates/EUR - non-positive rate",
+			method: "PUT",
+			path:   "/api/currency/rates/EUR",
+			body:   SetExchangeRateRequest{Rate: 0},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetExchangeRate", mock.Anything, "EUR", 0.0).Return(service.ExchangeRate{}, fmt.Errorf("rate must be positive"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "DELETE /api/currency/rates/GBP - not found",
+			method: "DELETE",
+			path:   "/api/currency/rates/GBP",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteExchangeRate", mock.Anything, "GBP").Return(fmt.Errorf("%w for GBP", service.ErrNoExchangeRate))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "GET /api/forecast - missing rate",
+			method: "GET",
+			path:   "/api/forecast",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ForecastDays", mock.Anything).Return(90)
+				m.On("GetStartingBalance", mock.Anything).Return(100.0, nil)
+				m.On("Forecast", mock.Anything, 100.0, 90).Return([]service.DailyCashFlow(nil), fmt.Errorf("%w for EUR", service.ErrNoExchangeRate))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccountEndpoints(t *testing.T) {
+	visa := service.Account{
+		ID:           1,
+		Name:         "Visa",
+		Type:         service.AccountCreditCard,
+		StatementDay: pgtype.Int4{Int32: 20, Valid: true},
+		DueDay:       pgtype.Int4{Int32: 15, Valid: true},
+	}
+	cardID := int32(1)
+
+	tests := []testCase{
+		{
+			name:   "GET /api/accounts - success",
+			method: "GET",
+			path:   "/api/accounts",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListAccounts", mock.Anything).Return([]service.Account{visa}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "POST /api/accounts - credit card",
+			method: "POST",
+			path:   "/api/accounts",
+			body:   CreateAccountRequest{Name: "Visa", Type: "credit_card", StatementDay: 20, DueDay: 15},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateAccount", mock.Anything, service.AccountInput{Name: "Visa", Type: "credit_card", StatementDay: 20, DueDay: 15}).Return(visa, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validateBody: func(t *testing.T, body []byte) {
+				var acc service.Account
+				require.NoError(t, json.Unmarshal(body, &acc))
+				assert.Equal(t, int32(15), acc.DueDay.Int32)
+			},
+		},
+		{
+			name:   "POST /api/accounts - card without due day",
+			method: "POST",
+			path:   "/api/accounts",
+			body:   CreateAccountRequest{Name: "Visa", Type: "credit_card", StatementDay: 20},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateAccount", mock.Anything, mock.Anything).Return(service.Account{}, fmt.Errorf("%w: due_day must be 1-31", service.ErrInvalidAccount))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "DELETE /api/accounts/1 - has transactions",
+			method: "DELETE",
+			path:   "/api/accounts/1",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteAccount", mock.Anything, int32(1)).Return(fmt.Errorf("%w: Visa", service.ErrAccountInUse))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "DELETE /api/accounts/9 - not found",
+			method: "DELETE",
+			path:   "/api/accounts/9",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteAccount", mock.Anything, int32(9)).Return(fmt.Errorf("%w: 9", service.ErrAccountNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "GET /api/accounts/1/statements - success",
+			method: "GET",
+			path:   "/api/accounts/1/statements",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CardStatements", mock.Anything, int32(1)).Return([]service.CardStatement{{
+					AccountID:    1,
+					ClosesOn:     time.Date(2025, 9, 20, 0, 0, 0, 0, time.UTC),
+					DueOn:        time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC),
+					Balance:      80,
+					Transactions: 2,
+				}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var statements []service.CardStatement
+				require.NoError(t, json.Unmarshal(body, &statements))
+				require.Len(t, statements, 1)
+				assert.Equal(t, 80.0, statements[0].Balance)
+			},
+		},
+		{
+			name:   "GET /api/accounts/2/statements - not a card",
+			method: "GET",
+			path:   "/api/accounts/2/statements",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CardStatements", mock.Anything, int32(2)).Return([]service.CardStatement(nil), fmt.Errorf("%w: Checking is not a credit card", service.ErrInvalidAccount))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/accounts/2/interest - success",
+			method: "PUT",
+			path:   "/api/accounts/2/interest",
+			body:   map[string]any{"balance": "10000", "apy": 0.045, "compounding": "daily"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetAccountInterest", mock.Anything, int32(2), mock.MatchedBy(func(in service.InterestInput) bool {
+					return in.Balance == 10000 && in.APY != nil && *in.APY == 0.045 && in.Compounding == "daily"
+				})).Return(service.Account{ID: 2, Name: "Savings", Type: service.AccountSavings, Compounding: "daily"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/accounts/1/interest - credit card",
+			method: "PUT",
+			path:   "/api/accounts/1/interest",
+			body:   SetAccountInterestRequest{Balance: 500},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetAccountInterest", mock.Anything, int32(1), mock.Anything).Return(service.Account{}, fmt.Errorf("%w: interest is only projected for checking and savings accounts", service.ErrInvalidAccount))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/accounts/9/interest - not found",
+			method: "PUT",
+			path:   "/api/accounts/9/interest",
+			body:   SetAccountInterestRequest{Balance: 500},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetAccountInterest", mock.Anything, int32(9), mock.Anything).Return(service.Account{}, fmt.Errorf("%w: 9", service.ErrAccountNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "POST /api/transactions/expense - charged to a card",
+			method: "POST",
+			path:   "/api/transactions/expense",
+			body: AddTransactionRequest{
+				Date:        "2025-09-05",
+				Amount:      60,
+				Description: "Groceries",
+				AccountID:   &cardID,
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("RecordExpense", mock.Anything, mock.MatchedBy(func(in service.ExpenseInput) bool {
+					return in.AccountID != nil && *in.AccountID == 1
+				})).Return(service.Transaction{ID: 7, AccountID: pgtype.Int4{Int32: 1, Valid: true}}, []service.Warning(nil), nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEnvelopeEndpoints(t *testing.T) {
+	car := service.Envelope{
+		ID:            1,
+		Name:          "Car repairs",
+		MonthlyAmount: pgNumeric("200.00"),
+		StartDate:     pgDate("2025-09-01"),
+		Category:      pgtype.Text{String: "Car", Valid: true},
+	}
+	target := 1500.0
+	available := func(v float64) *float64 { return &v }
+
+	tests := []testCase{
+		{
+			name:   "GET /api/envelopes - success",
+			method: "GET",
+			path:   "/api/envelopes",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListEnvelopes", mock.Anything).Return([]service.EnvelopeBalance{{Envelope: car, Balance: 400}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got []map[string]interface{}
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got, 1)
+				assert.Equal(t, "Car repairs", got[0]["name"])
+				assert.Equal(t, 400.0, got[0]["balance"])
+			},
+		},
+		{
+			name:   "POST /api/envelopes - success",
+			method: "POST",
+			path:   "/api/envelopes",
+			body:   map[string]interface{}{"name": "Car repairs", "monthly_amount": "200", "target": 1500, "start_date": "2025-09-01", "category": "Car"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateEnvelope", mock.Anything, service.EnvelopeInput{
+					Name:          "Car repairs",
+					MonthlyAmount: 200,
+					Target:        &target,
+					StartDate:     time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+					Category:      "Car",
+				}).Return(car, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/envelopes - duplicate name",
+			method: "POST",
+			path:   "/api/envelopes",
+			body:   EnvelopeRequest{Name: "Car repairs", MonthlyAmount: 50},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateEnvelope", mock.Anything, mock.Anything).Return(service.Envelope{}, fmt.Errorf("%w: an envelope named \"Car repairs\" already exists", service.ErrInvalidEnvelope))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "POST /api/envelopes - bad start date",
+			method:         "POST",
+			path:           "/api/envelopes",
+			body:           EnvelopeRequest{Name: "Gifts", MonthlyAmount: 50, StartDate: "someday"},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/envelopes/9 - not found",
+			method: "GET",
+			path:   "/api/envelopes/9",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetEnvelope", mock.Anything, int32(9)).Return(service.EnvelopeBalance{}, fmt.Errorf("%w: 9", service.ErrEnvelopeNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "PUT /api/envelopes/1 - success",
+			method: "PUT",
+			path:   "/api/envelopes/1",
+			body:   EnvelopeRequest{Name: "Car repairs", MonthlyAmount: 250},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("UpdateEnvelope", mock.Anything, int32(1), service.EnvelopeInput{Name: "Car repairs", MonthlyAmount: 250}).Return(car, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "DELETE /api/envelopes/1 - success",
+			method: "DELETE",
+			path:   "/api/envelopes/1",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteEnvelope", mock.Anything, int32(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/forecast?available=true - net of envelopes",
+			method: "GET",
+			path:   "/api/forecast?days=2&available=true",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetStartingBalance", mock.Anything).Return(1000.0, nil)
+				m.On("ForecastWithOptions", mock.Anything, 1000.0, 2, service.ForecastOptions{Envelopes: true}).Return([]service.DailyCashFlow{
+					{Balance: 1000, Available: available(600)},
+					{Balance: 900, Available: available(500)},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got []service.DailyCashFlow
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got, 2)
+				require.NotNil(t, got[1].Available)
+				assert.Equal(t, 500.0, *got[1].Available)
+			},
+		},
+		{
+			name:           "GET /api/forecast?available=maybe - bad flag",
+			method:         "GET",
+			path:           "/api/forecast?days=7&available=maybe",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/forecast/lowest?available=true - lowest available day",
+			method: "GET",
+			path:   "/api/forecast/lowest?available=true",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetStartingBalance", mock.Anything).Return(1000.0, nil)
+				m.On("ForecastWithOptions", mock.Anything, 1000.0, 90, service.ForecastOptions{Envelopes: true}).Return([]service.DailyCashFlow{
+					{Balance: 1000, Available: available(600)},
+					{Balance: 700, Available: available(650)},
+					{Balance: 900, Available: available(100)},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got struct {
+					LowestPoint service.DailyCashFlow `json:"lowest_point"`
+					DayIndex    int                   `json:"day_index"`
+				}
+				require.NoError(t, json.Unmarshal(body, &got))
+				assert.Equal(t, 2, got.DayIndex)
+				assert.Equal(t, 900.0, got.LowestPoint.Balance)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReminderEndpoints(t *testing.T) {
+	due := time.Date(2025, 9, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name:   "PUT /api/recurring/1/reminder - success",
+			method: "PUT",
+			path:   "/api/recurring/1/reminder",
+			body:   RecurringReminderRequest{DaysBefore: 3},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetRecurringReminder", mock.Anything, int32(1), 3).
+					Return(service.RecurringReminder{RecurringID: 1, DaysBefore: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.JSONEq(t, `{"recurring_id":1,"days_before":3}`, string(body))
+			},
+		},
+		{
+			name:   "PUT /api/recurring/1/reminder - too far ahead",
+			method: "PUT",
+			path:   "/api/recurring/1/reminder",
+			body:   RecurringReminderRequest{DaysBefore: 90},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetRecurringReminder", mock.Anything, int32(1), 90).
+					Return(service.RecurringReminder{}, fmt.Errorf("%w: days_before 90 (expected 0-60)", service.ErrInvalidReminder))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/recurring/9/reminder - not found",
+			method: "PUT",
+			path:   "/api/recurring/9/reminder",
+			body:   RecurringReminderRequest{DaysBefore: 1},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetRecurringReminder", mock.Anything, int32(9), 1).
+					Return(service.RecurringReminder{}, fmt.Errorf("%w: 9", service.ErrRecurringNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "DELETE /api/recurring/1/reminder - success",
+			method: "DELETE",
+			path:   "/api/recurring/1/reminder",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ClearRecurringReminder", mock.Anything, int32(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/reminders - default days",
+			method: "GET",
+			path:   "/api/reminders",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Reminders", mock.Anything, 30).Return([]service.Reminder{{
+					RecurringID: 1, Description: "Rent", Type: "expense", Amount: -1200, Currency: "USD",
+					DueDate: due, RemindOn: due.AddDate(0, 0, -3), DaysBefore: 3,
+				}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got []service.Reminder
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got, 1)
+				assert.Equal(t, "Rent", got[0].Description)
+				assert.False(t, got[0].Sent)
+			},
+		},
+		{
+			name:           "GET /api/reminders - invalid days",
+			method:         "GET",
+			path:           "/api/reminders?days=0",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNotificationChannelEndpoints(t *testing.T) {
+	slack := service.NotificationChannelInput{
+		Name:    "Family",
+		Config:  notify.Config{Type: notify.TypeSlack, WebhookURL: "https://hooks.slack.com/services/T0/B0/secret"},
+		Enabled: true,
+	}
+	channel := service.NotificationChannel{
+		ID: 1, Name: "Family", Type: notify.TypeSlack, Enabled: true,
+		Config: notify.Config{Type: notify.TypeSlack, WebhookURL: "https://hooks.slack.com/…"},
+	}
+
+	tests := []testCase{
+		{
+			name:   "GET /api/notifications/channels - success",
+			method: "GET",
+			path:   "/api/notifications/channels",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListNotificationChannels", mock.Anything).Return([]service.NotificationChannel{channel}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.NotContains(t, string(body), "secret")
+				var got []service.NotificationChannel
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got, 1)
+				assert.Equal(t, "Family", got[0].Name)
+			},
+		},
+		{
+			name:   "POST /api/notifications/channels - enabled by default",
+			method: "POST",
+			path:   "/api/notifications/channels",
+			body: NotificationChannelRequest{
+				Name: "Family", Type: "slack", WebhookURL: "https://hooks.slack.com/services/T0/B0/secret",
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateNotificationChannel", mock.Anything, slack).Return(channel, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/notifications/channels - invalid",
+			method: "POST",
+			path:   "/api/notifications/channels",
+			body:   NotificationChannelRequest{Name: "Phone", Type: "telegram"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateNotificationChannel", mock.Anything, mock.Anything).
+					Return(service.NotificationChannel{}, fmt.Errorf("%w: bot_token is required", service.ErrInvalidNotificationChannel))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/notifications/channels/1 - disable",
+			method: "PUT",
+			path:   "/api/notifications/channels/1",
+			body: NotificationChannelRequest{
+				Name: "Family", Type: "slack", WebhookURL: "https://hooks.slack.com/services/T0/B0/secret", Enabled: new(bool),
+			},
+			mockSetup: func(m *MockFinanceService) {
+				off := slack
+				off.Enabled = false
+				m.On("UpdateNotificationChannel", mock.Anything, int32(1), off).Return(channel, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/notifications/channels/9 - not found",
+			method: "GET",
+			path:   "/api/notifications/channels/9",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetNotificationChannel", mock.Anything, int32(9)).
+					Return(service.NotificationChannel{}, fmt.Errorf("%w: 9", service.ErrNotificationChannelNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "DELETE /api/notifications/channels/1 - success",
+			method: "DELETE",
+			path:   "/api/notifications/channels/1",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteNotificationChannel", mock.Anything, int32(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "POST /api/notifications/channels/1/test - success",
+			method: "POST",
+			path:   "/api/notifications/channels/1/test",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("TestNotificationChannel", mock.Anything, int32(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "POST /api/notifications/channels/1/test - delivery failed",
+			method: "POST",
+			path:   "/api/notifications/channels/1/test",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("TestNotificationChannel", mock.Anything, int32(1)).
+					Return(fmt.Errorf("%w: slack: 404 Not Found", service.ErrNotificationFailed))
+			},
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+func TestAuthEndpoints(t *testing.T) {
+	session := service.Session{
+		AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, RefreshToken: "refresh",
+		User: service.User{ID: 1, Username: "alex"},
+	}
+
+	tests := []testCase{
+		{
+			name:   "POST /api/auth/login - success",
+			method: "POST",
+			path:   "/api/auth/login",
+			body:   LoginRequest{Username: "alex", Password: "correct horse"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Login", mock.Anything, "alex", "correct horse").Return(session, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got service.Session
+				require.NoError(t, json.Unmarshal(body, &got))
+				assert.Equal(t, "access", got.AccessToken)
+				assert.Equal(t, "refresh", got.RefreshToken)
+				assert.Equal(t, "alex", got.User.Username)
+			},
+		},
+		{
+			name:   "POST /api/auth/login - wrong password",
+			method: "POST",
+			path:   "/api/auth/login",
+			body:   LoginRequest{Username: "alex", Password: "wrong horse"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Login", mock.Anything, "alex", "wrong horse").Return(service.Session{}, service.ErrInvalidCredentials)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "POST /api/auth/refresh - success",
+			method: "POST",
+			path:   "/api/auth/refresh",
+			body:   RefreshRequest{RefreshToken: "old"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Refresh", mock.Anything, "old").Return(session, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "POST /api/auth/refresh - revoked",
+			method: "POST",
+			path:   "/api/auth/refresh",
+			body:   RefreshRequest{RefreshToken: "spent"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Refresh", mock.Anything, "spent").Return(service.Session{}, service.ErrInvalidToken)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "POST /api/auth/refresh - missing token",
+			method:         "POST",
+			path:           "/api/auth/refresh",
+			body:           RefreshRequest{},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "POST /api/auth/logout - success",
+			method: "POST",
+			path:   "/api/auth/logout",
+			body:   RefreshRequest{RefreshToken: "refresh"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Logout", mock.Anything, "refresh").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/auth/me - not logged in",
+			method:         "GET",
+			path:           "/api/auth/me",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+func TestUserEndpoints(t *testing.T) {
+	pat := service.User{ID: 2, Username: "pat", Role: service.RoleViewer}
+
+	tests := []testCase{
+		{
+			name:   "GET /api/users - success",
+			method: "GET",
+			path:   "/api/users",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListUsers", mock.Anything).Return([]service.User{pat}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.Contains(t, string(body), `"role":"viewer"`)
+				assert.NotContains(t, string(body), "password")
+			},
+		},
+		{
+			name:   "POST /api/users - role defaults to viewer",
+			method: "POST",
+			path:   "/api/users",
+			body:   UserRequest{Username: "pat", Password: "correct horse"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateUser", mock.Anything, "pat", "correct horse", service.RoleViewer).Return(pat, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/users - unknown role",
+			method: "POST",
+			path:   "/api/users",
+			body:   UserRequest{Username: "pat", Password: "correct horse", Role: "admin"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateUser", mock.Anything, "pat", "correct horse", "admin").
+					Return(service.User{}, fmt.Errorf("%w: role \"admin\"", service.ErrInvalidUser))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/users/9 - not found",
+			method: "GET",
+			path:   "/api/users/9",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetUser", mock.Anything, int32(9)).Return(service.User{}, fmt.Errorf("%w: 9", service.ErrUserNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "PUT /api/users/2/role - success",
+			method: "PUT",
+			path:   "/api/users/2/role",
+			body:   UserRoleRequest{Role: service.RoleEditor},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetUserRole", mock.Anything, int32(2), service.RoleEditor).
+					Return(service.User{ID: 2, Username: "pat", Role: service.RoleEditor}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/users/2/password - success",
+			method: "PUT",
+			path:   "/api/users/2/password",
+			body:   UserPasswordRequest{Password: "battery staple"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetUserPassword", mock.Anything, int32(2), "battery staple").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "DELETE /api/users/1 - last owner",
+			method: "DELETE",
+			path:   "/api/users/1",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteUser", mock.Anything, int32(1)).Return(fmt.Errorf("%w: alex is the last owner", service.ErrInvalidUser))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHouseholdEndpoints(t *testing.T) {
+	cabin := service.Household{ID: 2, Name: "Cabin"}
+	inHousehold := func(id int32) any {
+		return mock.MatchedBy(func(ctx context.Context) bool { return household.FromContext(ctx) == id })
+	}
+
+	tests := []testCase{
+		{
+			name:   "GET /api/households - every household with the API key",
+			method: "GET",
+			path:   "/api/households",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListHouseholds", mock.Anything).Return([]service.Household{{ID: 1, Name: "Home"}, cabin}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.Contains(t, string(body), `"name":"Cabin"`)
+			},
+		},
+		{
+			name:   "POST /api/households - success",
+			method: "POST",
+			path:   "/api/households",
+			body:   HouseholdRequest{Name: "Cabin"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateHousehold", mock.Anything, "Cabin", int32(0)).Return(cabin, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "GET /api/households/9 - not found",
+			method: "GET",
+			path:   "/api/households/9",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetHousehold", mock.Anything, int32(9)).Return(service.Household{}, fmt.Errorf("%w: 9", service.ErrHouseholdNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "DELETE /api/households/1 - the default household stays",
+			method: "DELETE",
+			path:   "/api/households/1",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteHousehold", mock.Anything, int32(1)).Return(fmt.Errorf("%w: the default household can't be deleted", service.ErrInvalidHousehold))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "PUT /api/households/2/members/3/role - success",
+			method: "PUT",
+			path:   "/api/households/2/members/3/role",
+			body:   UserRoleRequest{Role: service.RoleEditor},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetHousehold", mock.Anything, int32(2)).Return(cabin, nil)
+				m.On("SetHouseholdMemberRole", inHousehold(2), int32(2), int32(3), service.RoleEditor).
+					Return(service.HouseholdMember{UserID: 3, Username: "pat", Role: service.RoleEditor}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "POST /api/households/1/invitations - role defaults to viewer",
+			method: "POST",
+			path:   "/api/households/1/invitations",
+			body:   InvitationRequest{},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateInvitation", mock.Anything, int32(1), service.RoleViewer, int32(0)).
+					Return(service.Invitation{ID: 5, HouseholdID: 1, Role: service.RoleViewer, Token: "abc"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.Contains(t, string(body), `"token":"abc"`)
+			},
+		},
+		{
+			name:   "DELETE /api/households/1/invitations/5 - not found",
+			method: "DELETE",
+			path:   "/api/households/1/invitations/5",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("RevokeInvitation", mock.Anything, int32(1), int32(5)).Return(fmt.Errorf("%w: 5", service.ErrInvitationNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "POST /api/invitations/accept - needs a login",
+			method:         "POST",
+			path:           "/api/invitations/accept",
+			body:           AcceptInvitationRequest{Token: "abc"},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "GET /households/2/api/balance - household in the path",
+			method: "GET",
+			path:   "/households/2/api/balance",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetHousehold", mock.Anything, int32(2)).Return(cabin, nil)
+				m.On("GetStartingBalance", inHousehold(2)).Return(25.0, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /households/0/api/balance - invalid household",
+			method:         "GET",
+			path:           "/households/0/api/balance",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
 			if tt.body != nil {
+				var err error
 				body, err = json.Marshal(tt.body)
 				require.NoError(t, err)
 			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
 
-			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewBuffer(body))
+			resp, err := http.DefaultClient.Do(req)
 			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSavedScenarioEndpoints(t *testing.T) {
+	laptop := service.Scenario{
+		Transactions: []service.ScenarioTransaction{
+			{Date: time.Date(2025, 9, 5, 0, 0, 0, 0, time.UTC), Type: "expense", Amount: 1200, Description: "Laptop"},
+		},
+	}
+	saved := service.SavedScenario{ID: 1, Name: "New laptop", Scenario: laptop}
+
+	tests := []testCase{
+		{
+			name:   "GET /api/scenarios - success",
+			method: "GET",
+			path:   "/api/scenarios",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("ListScenarios", mock.Anything).Return([]service.SavedScenario{saved}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var got []service.SavedScenario
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got, 1)
+				assert.Equal(t, "New laptop", got[0].Name)
+				assert.Equal(t, 1200.0, got[0].Scenario.Transactions[0].Amount)
+			},
+		},
+		{
+			name:   "POST /api/scenarios - success",
+			method: "POST",
+			path:   "/api/scenarios",
+			body: SavedScenarioRequest{
+				Name: "New laptop",
+				Scenario: ScenarioRequest{Transactions: []ScenarioTransactionRequest{
+					{Date: "2025-09-05", Type: "expense", Amount: 1200, Description: "Laptop"},
+				}},
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateScenario", mock.Anything, "New laptop", laptop).Return(saved, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/scenarios - duplicate name",
+			method: "POST",
+			path:   "/api/scenarios",
+			body:   SavedScenarioRequest{Name: "New laptop"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("CreateScenario", mock.Anything, "New laptop", service.Scenario{}).
+					Return(service.SavedScenario{}, fmt.Errorf("%w: a scenario named \"New laptop\" already exists", service.ErrInvalidScenario))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "POST /api/scenarios - bad date",
+			method: "POST",
+			path:   "/api/scenarios",
+			body: SavedScenarioRequest{
+				Name:     "New laptop",
+				Scenario: ScenarioRequest{Transactions: []ScenarioTransactionRequest{{Date: "soon", Type: "expense", Amount: 1}}},
+			},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "GET /api/scenarios/9 - not found",
+			method: "GET",
+			path:   "/api/scenarios/9",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetScenario", mock.Anything, int32(9)).Return(service.SavedScenario{}, fmt.Errorf("%w: 9", service.ErrScenarioNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "PUT /api/scenarios/1 - success",
+			method: "PUT",
+			path:   "/api/scenarios/1",
+			body:   SavedScenarioRequest{Name: "No laptop"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("UpdateScenario", mock.Anything, int32(1), "No laptop", service.Scenario{}).
+					Return(service.SavedScenario{ID: 1, Name: "No laptop"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "DELETE /api/scenarios/1 - success",
+			method: "DELETE",
+			path:   "/api/scenarios/1",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("DeleteScenario", mock.Anything, int32(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GET /api/scenarios/1/forecast - success",
+			method: "GET",
+			path:   "/api/scenarios/1/forecast?days=30",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetStartingBalance", mock.Anything).Return(3000.0, nil)
+				m.On("ScenarioForecast", mock.Anything, int32(1), 3000.0, 30).Return(service.ForecastComparison{
+					EndingBalance: service.ForecastDelta{Baseline: 3000, Scenario: 1800, Delta: -1200},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var cmp service.ForecastComparison
+				require.NoError(t, json.Unmarshal(body, &cmp))
+				assert.Equal(t, -1200.0, cmp.EndingBalance.Delta)
+			},
+		},
+		{
+			name:   "GET /api/scenarios/9/forecast - not found",
+			method: "GET",
+			path:   "/api/scenarios/9/forecast?days=30",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("GetStartingBalance", mock.Anything).Return(3000.0, nil)
+				m.On("ScenarioForecast", mock.Anything, int32(9), 3000.0, 30).
+					Return(service.ForecastComparison{}, fmt.Errorf("%w: 9", service.ErrScenarioNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "GET /api/scenarios/1/forecast - invalid days",
+			method:         "GET",
+			path:           "/api/scenarios/1/forecast?days=0",
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
 
+			var body []byte
 			if tt.body != nil {
-				req.Header.Set("Content-Type", "application/json")
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
 			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
 
 			resp, err := http.DefaultClient.Do(req)
 			require.NoError(t, err)
-			defer func() {
-				if err := resp.Body.Close(); err != nil {
-					t.Errorf("failed to close body: %v", err)
-				}
-			}()
+			defer func() { _ = resp.Body.Close() }()
 
 			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
-
 			if tt.validateBody != nil {
 				var respBody bytes.Buffer
 				_, err := respBody.ReadFrom(resp.Body)
 				require.NoError(t, err)
 				tt.validateBody(t, respBody.Bytes())
 			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTimezoneEndpoints(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	tests := []testCase{
+		{
+			name:   "GET /api/timezone - success",
+			method: "GET",
+			path:   "/api/timezone",
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Timezone", mock.Anything).Return("America/Los_Angeles", nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp TimezoneRequest
+				require.NoError(t, json.Unmarshal(body, &resp))
+				assert.Equal(t, "America/Los_Angeles", resp.Timezone)
+			},
+		},
+		{
+			name:   "PUT /api/timezone - success",
+			method: "PUT",
+			path:   "/api/timezone",
+			body:   TimezoneRequest{Timezone: "America/Los_Angeles"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetTimezone", mock.Anything, "America/Los_Angeles").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "PUT /api/timezone - unknown zone",
+			method: "PUT",
+			path:   "/api/timezone",
+			body:   TimezoneRequest{Timezone: "Mars/Olympus"},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("SetTimezone", mock.Anything, "Mars/Olympus").Return(fmt.Errorf("unknown timezone %q", "Mars/Olympus"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "POST /api/transactions/income - timestamp booked on the local day",
+			method: "POST",
+			path:   "/api/transactions/income",
+			body: AddTransactionRequest{
+				Date:        "2025-06-02T05:30:00Z",
+				Amount:      100,
+				Description: "Late deposit",
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Location", mock.Anything).Return(la)
+				m.On("AddIncome", mock.Anything, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), 100.0, "Late deposit", "").Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "POST /api/transactions/income - relative date from the user's today",
+			method: "POST",
+			path:   "/api/transactions/income",
+			body: AddTransactionRequest{
+				Date:        "tomorrow",
+				Amount:      100,
+				Description: "Refund",
+			},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("Today", mock.Anything).Return(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+				m.On("AddIncome", mock.Anything, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), 100.0, "Refund", "").Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFinanceService)
+			tt.mockSetup(mockService)
+
+			server := setupTestServer(mockService)
+			defer server.Close()
+
+			var body []byte
+			if tt.body != nil {
+				var err error
+				body, err = json.Marshal(tt.body)
+				require.NoError(t, err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
 
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
 			mockService.AssertExpectations(t)
 		})
 	}
 }
 
-func TestRecurringEndpoints(t *testing.T) {
+func TestSettingsEndpoints(t *testing.T) {
+	threshold, days, zone := 250.0, 60, "Europe/Berlin"
+
 	tests := []testCase{
 		{
-			name:   "GET /api/recurring - success",
+			name:   "GET /api/settings - success",
 			method: "GET",
-			path:   "/api/recurring",
+			path:   "/api/settings",
 			mockSetup: func(m *MockFinanceService) {
-				m.On("ListRecurring", mock.Anything).Return([]service.Recurring{
-					{ID: 1, Description: "Monthly rent"},
+				m.On("Settings", mock.Anything).Return(service.Settings{
+					StartingBalance: 1200, Timezone: "UTC", BaseCurrency: "USD", ForecastDays: 90,
 				}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			validateBody: func(t *testing.T, body []byte) {
-				var recurring []service.Recurring
-				err := json.Unmarshal(body, &recurring)
-				require.NoError(t, err)
-				assert.Len(t, recurring, 1)
-				assert.Equal(t, "Monthly rent", recurring[0].Description)
+				var resp map[string]any
+				require.NoError(t, json.Unmarshal(body, &resp))
+				assert.Equal(t, 1200.0, resp["starting_balance"])
+				assert.Nil(t, resp["low_balance_threshold"])
+				assert.Equal(t, 90.0, resp["forecast_days"])
+				assert.Len(t, resp, len(service.SettingKeys))
 			},
 		},
 		{
-			name:   "POST /api/recurring - success",
-			method: "POST",
-			path:   "/api/recurring",
-			body: RecurringTransactionRequest{
-				Description: "Monthly rent",
-				Type:        "expense",
-				Amount:      1200.00,
-				StartDate:   "2025-09-01",
-				Interval:    "monthly",
-				DayOfMonth:  intPtr(1),
-				Active:      true,
+			name:   "PUT /api/settings - partial update",
+			method: "PUT",
+			path:   "/api/settings",
+			body:   map[string]any{"low_balance_threshold": "250.00", "forecast_days": 60, "timezone": zone},
+			mockSetup: func(m *MockFinanceService) {
+				m.On("UpdateSettings", mock.Anything, service.SettingsUpdate{
+					LowBalanceThreshold: &threshold, ForecastDays: &days, Timezone: &zone,
+				}).Return(service.Settings{LowBalanceThreshold: &threshold, ForecastDays: days, Timezone: zone}, nil)
 			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var resp service.Settings
+				require.NoError(t, json.Unmarshal(body, &resp))
+				assert.Equal(t, 60, resp.ForecastDays)
+			},
+		},
+		{
+			name:           "PUT /api/settings - unknown key",
+			method:         "PUT",
+			path:           "/api/settings",
+			body:           map[string]any{"theme": "dark"},
+			mockSetup:      func(m *MockFinanceService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.Contains(t, string(body), `\"theme\"`)
+				assert.Contains(t, string(body), "forecast_days")
+			},
+		},
+		{
+			name:   "PUT /api/settings - invalid value",
+			method: "PUT",
+			path:   "/api/settings",
+			body:   map[string]any{"forecast_days": 0},
 			mockSetup: func(m *MockFinanceService) {
-				expectedStartDate, _ := time.Parse("2006-01-02", "2025-09-01")
-				expectedInput := service.RecurringInput{
-					Description: "Monthly rent",
-					Type:        "expense",
-					Amount:      1200.00,
-					StartDate:   expectedStartDate,
-					Interval:    "monthly",
-					DayOfMonth:  intPtr(1),
-					Active:      true,
-				}
-				m.On("CreateRecurringSimple", mock.Anything, expectedInput).Return(service.Recurring{
-					ID: 1, Description: "Monthly rent",
-				}, nil)
+				zero := 0
+				m.On("UpdateSettings", mock.Anything, service.SettingsUpdate{ForecastDays: &zero}).
+					Return(service.Settings{}, fmt.Errorf("%w: forecast days 0", service.ErrInvalidSetting))
 			},
-			expectedStatus: http.StatusCreated,
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:   "DELETE /api/recurring/1 - success",
+			name:   "DELETE /api/settings/{key} - reset",
 			method: "DELETE",
-			path:   "/api/recurring/1",
+			path:   "/api/settings/tax_rate",
 			mockSetup: func(m *MockFinanceService) {
-				m.On("DeleteRecurring", mock.Anything, int32(1)).Return(nil)
+				m.On("ResetSetting", mock.Anything, "tax_rate").Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
 		{
-			name:   "PUT /api/recurring/1/active - success",
-			method: "PUT",
-			path:   "/api/recurring/1/active",
-			body: SetActiveRequest{
-				Active: false,
-			},
+			name:   "DELETE /api/settings/{key} - unknown key",
+			method: "DELETE",
+			path:   "/api/settings/theme",
 			mockSetup: func(m *MockFinanceService) {
-				m.On("SetRecurringActive", mock.Anything, int32(1), false).Return(nil)
+				m.On("ResetSetting", mock.Anything, "theme").Return(fmt.Errorf("%w %q", service.ErrUnknownSetting, "theme"))
 			},
-			expectedStatus: http.StatusOK,
+			expectedStatus: http.StatusNotFound,
 		},
 	}
 
@@ -418,208 +4941,962 @@ func TestRecurringEndpoints(t *testing.T) {
 			defer server.Close()
 
 			var body []byte
-			var err error
 			if tt.body != nil {
+				var err error
 				body, err = json.Marshal(tt.body)
 				require.NoError(t, err)
 			}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(body))
+			require.NoError(t, err)
 
-			req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewBuffer(body))
+			resp, err := http.DefaultClient.Do(req)
 			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.validateBody != nil {
+				var respBody bytes.Buffer
+				_, err := respBody.ReadFrom(resp.Body)
+				require.NoError(t, err)
+				tt.validateBody(t, respBody.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCORSHeaders(t *testing.T) {
+	mockService := new(MockFinanceService)
+	server := setupTestServer(mockService)
+	defer server.Close()
+
+	// Test OPTIONS request
+	req, err := http.NewRequest("OPTIONS", server.URL+"/api/transactions", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("failed to close body: %v", err)
+		}
+	}()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, resp.Header.Get("Access-Control-Allow-Methods"), "GET")
+	assert.Contains(t, resp.Header.Get("Access-Control-Allow-Methods"), "POST")
+	assert.Equal(t, "X-Next-Cursor", resp.Header.Get("Access-Control-Expose-Headers"))
+}
+
+func TestRequestID(t *testing.T) {
+	mockService := new(MockFinanceService)
+	mockService.On("ListTransactions", mock.Anything, "", service.ListOrder{}, service.Page{}).Return([]service.Transaction{}, "", fmt.Errorf("database error"))
+	router := NewAPIServer(withUTC(mockService)).SetupRoutes()
+
+	get := func(id string) (*httptest.ResponseRecorder, ErrorResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
+		if id != "" {
+			req.Header.Set("X-Request-ID", id)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var body ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body
+	}
+
+	// A new ID, in the header and the error body alike.
+	rec, body := get("")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Len(t, rec.Header().Get("X-Request-ID"), 16)
+	assert.Equal(t, rec.Header().Get("X-Request-ID"), body.RequestID)
+
+	// A caller's own ID is kept, unless it could garble the logs.
+	rec, body = get("lb-7f3a:42")
+	assert.Equal(t, "lb-7f3a:42", rec.Header().Get("X-Request-ID"))
+	assert.Equal(t, "lb-7f3a:42", body.RequestID)
+	rec, _ = get("bad id\nforged log line")
+	assert.Len(t, rec.Header().Get("X-Request-ID"), 16)
+}
+
+func TestPanicRecovery(t *testing.T) {
+	mockService := withUTC(new(MockFinanceService))
+	mockService.On("ListTransactions", mock.Anything, "", service.ListOrder{}, service.Page{}).Run(func(mock.Arguments) {
+		panic("boom")
+	})
+	router := NewAPIServer(mockService).SetupRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
+	req.Header.Set("X-Request-ID", "panicky")
+	rec := httptest.NewRecorder()
+	require.NotPanics(t, func() { router.ServeHTTP(rec, req) })
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Empty(t, rec.Header().Get("ETag"), "headers meant for the lost response are dropped")
+	var body ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, ErrorResponse{Error: "Internal server error", RequestID: "panicky"}, body)
+}
+
+func TestNextCursorHeader(t *testing.T) {
+	mockService := withUTC(new(MockFinanceService))
+	mockService.On("ListTransactions", mock.Anything, "", service.ListOrder{}, service.Page{Limit: 1}).
+		Return([]service.Transaction{{ID: 1}}, "next-page", nil)
+	mockService.On("ListTransactions", mock.Anything, "", service.ListOrder{}, service.Page{Limit: 1, After: "next-page"}).
+		Return([]service.Transaction{{ID: 2}}, "", nil)
+	router := NewAPIServer(mockService).SetupRoutes()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+	assert.Equal(t, "next-page", get("/api/transactions?limit=1").Header().Get("X-Next-Cursor"))
+	assert.Empty(t, get("/api/transactions?limit=1&cursor=next-page").Header().Get("X-Next-Cursor"), "none on the last page")
+}
+
+func TestCORSOrigins(t *testing.T) {
+	s := NewAPIServer(new(MockFinanceService))
+	s.SetCORSOrigins([]string{"https://app.example.com"})
+	router := s.SetupRoutes()
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/api/transactions", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := preflight("https://app.example.com")
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+
+	rec = preflight("https://evil.example.com")
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	mockService := new(MockFinanceService)
+	mockService.On("ListTransactions", mock.Anything, "", service.ListOrder{}, service.Page{}).Return([]service.Transaction{}, "", nil)
+	mockService.On("AddIncome", mock.Anything, mock.Anything, 100.0, "Refund", "").Return(nil)
+
+	apiServer := NewAPIServer(withUTC(mockService))
+	apiServer.SetMaintenance(true, "")
+	server := httptest.NewServer(apiServer.SetupRoutes())
+	defer server.Close()
+
+	do := func(method, path, body string) (int, ErrorResponse) {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Errorf("failed to close body: %v", err)
+			}
+		}()
+		var e ErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return resp.StatusCode, e
+	}
+	income := `{"date":"2025-09-01","amount":100,"description":"Refund"}`
+
+	status, e := do("POST", "/api/transactions/income", income)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, defaultMaintenanceMessage, e.Error)
+
+	status, _ = do("GET", "/api/transactions", "")
+	assert.Equal(t, http.StatusOK, status, "reads keep working")
+
+	status, _ = do("PUT", "/api/admin/maintenance", `{"enabled":false}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.False(t, apiServer.Maintenance().Enabled)
+
+	status, _ = do("POST", "/api/transactions/income", income)
+	assert.Equal(t, http.StatusCreated, status)
+	mockService.AssertNumberOfCalls(t, "AddIncome", 1)
+}
+
+func TestAPIKey(t *testing.T) {
+	mockService := new(MockFinanceService)
+	mockService.On("GetStartingBalance", mock.Anything).Return(100.0, nil)
+
+	apiServer := NewAPIServer(withUTC(mockService))
+	apiServer.SetAPIKey("s3cret")
+	server := httptest.NewServer(apiServer.SetupRoutes())
+	defer server.Close()
+
+	get := func(header, value string) int {
+		req, err := http.NewRequest("GET", server.URL+"/api/balance", nil)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("", ""))
+	assert.Equal(t, http.StatusUnauthorized, get("Authorization", "Bearer wrong"))
+	assert.Equal(t, http.StatusOK, get("Authorization", "Bearer s3cret"))
+	assert.Equal(t, http.StatusOK, get("X-API-Key", "s3cret"))
+	mockService.AssertNumberOfCalls(t, "GetStartingBalance", 2)
+}
+
+func TestScopedKeys(t *testing.T) {
+	svc := service.NewFinanceService(memdb.New())
+	apiServer := NewAPIServer(svc)
+	require.NoError(t, apiServer.SetScopedKeys(map[string][]string{
+		"widget":  {"read:forecast", "read:transactions"},
+		"bills":   {"write:recurring"},
+		"reader":  {"read"},
+		"admin-k": {"admin"},
+	}))
+	router := apiServer.SetupRoutes()
+
+	do := func(method, path, key string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Scoped keys alone still close the API.
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/transactions", ""))
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/transactions", "nope"))
+
+	assert.Equal(t, http.StatusOK, do("GET", "/api/transactions", "widget"))
+	assert.Equal(t, http.StatusForbidden, do("DELETE", "/api/transactions/1", "widget"))
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/recurring", "widget"))
+
+	// Writing a part covers reading it.
+	assert.Equal(t, http.StatusOK, do("GET", "/api/recurring", "bills"))
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/api/recurring/99", "bills"))
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/balance", "bills"))
+
+	// Owner-only parts need admin, even to read.
+	assert.Equal(t, http.StatusOK, do("GET", "/api/balance", "reader"))
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/audit", "reader"))
+	assert.Equal(t, http.StatusOK, do("GET", "/api/audit", "admin-k"))
+
+	err := apiServer.SetScopedKeys(map[string][]string{"k": {"read:transaction"}})
+	assert.ErrorContains(t, err, `unknown scope "read:transaction"`)
+}
+
+// TestSessions logs in against the in-memory database and uses the
+// session in place of the API key.
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(memdb.New())
+	_, err := svc.CreateUser(ctx, "alex", "correct horse", service.RoleOwner)
+	require.NoError(t, err)
+
+	apiServer := NewAPIServer(svc)
+	apiServer.SetAPIKey("s3cret")
+	server := httptest.NewServer(apiServer.SetupRoutes())
+	defer server.Close()
+
+	do := func(method, path, token string, body any) (int, []byte) {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, err := http.NewRequest(method, server.URL+path, &buf)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, data
+	}
+
+	status, _ := do("POST", "/api/auth/login", "", LoginRequest{Username: "alex", Password: "wrong horse"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, body := do("POST", "/api/auth/login", "", LoginRequest{Username: "alex", Password: "correct horse"})
+	require.Equal(t, http.StatusOK, status, string(body))
+	var session service.Session
+	require.NoError(t, json.Unmarshal(body, &session))
+
+	status, body = do("GET", "/api/auth/me", session.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, string(body), `"username":"alex"`)
+	status, _ = do("GET", "/api/balance", session.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, status, "access token in place of the key")
+
+	// Mutations are attributed to the user, whatever X-Actor says.
+	status, _ = do("PUT", "/api/balance", session.AccessToken, SetBalanceRequest{Balance: 100})
+	require.Equal(t, http.StatusOK, status)
+	entries, err := svc.ListAudit(ctx, service.AuditFilter{Entity: service.EntitySetting})
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, "alex", entries[0].Actor)
+
+	// A forged token is refused rather than treated as an API key.
+	parts := strings.Split(session.AccessToken, ".")
+	status, _ = do("GET", "/api/balance", parts[0]+"."+parts[1]+".forged", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, body = do("POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken})
+	require.Equal(t, http.StatusOK, status, string(body))
+	var next service.Session
+	require.NoError(t, json.Unmarshal(body, &next))
+	status, _ = do("POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, status, "refresh tokens work once")
+
+	status, _ = do("POST", "/api/auth/logout", "", RefreshRequest{RefreshToken: next.RefreshToken})
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = do("POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: next.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+// TestRoles checks what each role may do, logged in against the in-memory
+// database.
+func TestRoles(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(memdb.New())
+	for name, role := range map[string]string{"alex": service.RoleOwner, "sam": service.RoleEditor, "pat": service.RoleViewer} {
+		_, err := svc.CreateUser(ctx, name, "correct horse", role)
+		require.NoError(t, err)
+	}
+
+	apiServer := NewAPIServer(svc)
+	apiServer.SetAPIKey("s3cret")
+	server := httptest.NewServer(apiServer.SetupRoutes())
+	defer server.Close()
+
+	tokens := map[string]string{"key": "s3cret"}
+	ids := map[string]int32{}
+	for _, name := range []string{"alex", "sam", "pat"} {
+		session, err := svc.Login(ctx, name, "correct horse")
+		require.NoError(t, err)
+		tokens[name] = session.AccessToken
+		ids[name] = session.User.ID
+	}
+
+	do := func(who, method, path string, body any) int {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, err := http.NewRequest(method, server.URL+path, &buf)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+tokens[who])
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+	balance := SetBalanceRequest{Balance: 100}
+
+	// Viewers read, and make read-only POSTs.
+	assert.Equal(t, http.StatusOK, do("pat", "GET", "/api/balance", nil))
+	assert.Equal(t, http.StatusForbidden, do("pat", "PUT", "/api/balance", balance))
+	assert.NotEqual(t, http.StatusForbidden, do("pat", "POST", "/api/forecast/compare", map[string]any{}))
+
+	// Editors change data but not users, backups or the audit log.
+	assert.Equal(t, http.StatusOK, do("sam", "PUT", "/api/balance", balance))
+	for _, path := range []string{"/api/users", "/api/backup", "/api/audit"} {
+		assert.Equal(t, http.StatusForbidden, do("sam", "GET", path, nil), path)
+		assert.Equal(t, http.StatusOK, do("alex", "GET", path, nil), path)
+		assert.Equal(t, http.StatusOK, do("key", "GET", path, nil), path)
+	}
+
+	// Everyone can see their own account and change their own password,
+	// but only owners can touch anyone else's or change roles.
+	self := fmt.Sprintf("/api/users/%d", ids["pat"])
+	assert.Equal(t, http.StatusOK, do("pat", "GET", self, nil))
+	assert.Equal(t, http.StatusForbidden, do("pat", "GET", fmt.Sprintf("/api/users/%d", ids["sam"]), nil))
+	assert.Equal(t, http.StatusForbidden, do("pat", "PUT", self+"/role", UserRoleRequest{Role: service.RoleOwner}))
+	assert.Equal(t, http.StatusForbidden, do("sam", "PUT", self+"/password", UserPasswordRequest{Password: "battery staple"}))
+	assert.Equal(t, http.StatusOK, do("pat", "PUT", self+"/password", UserPasswordRequest{Password: "battery staple"}))
+
+	// A new role applies to the next request: the household role to its
+	// data, the user's own role to managing the server.
+	member := fmt.Sprintf("/api/households/1/members/%d/role", ids["pat"])
+	assert.Equal(t, http.StatusForbidden, do("pat", "PUT", member, UserRoleRequest{Role: service.RoleOwner}))
+	assert.Equal(t, http.StatusOK, do("alex", "PUT", member, UserRoleRequest{Role: service.RoleEditor}))
+	assert.Equal(t, http.StatusOK, do("pat", "PUT", "/api/balance", balance))
+	assert.Equal(t, http.StatusForbidden, do("pat", "GET", "/api/users", nil))
+	assert.Equal(t, http.StatusOK, do("alex", "PUT", self+"/role", UserRoleRequest{Role: service.RoleOwner}))
+	assert.Equal(t, http.StatusOK, do("pat", "GET", "/api/users", nil))
+}
+
+func TestHouseholds(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(memdb.New())
+	for name, role := range map[string]string{"alex": service.RoleOwner, "sam": service.RoleEditor} {
+		_, err := svc.CreateUser(ctx, name, "correct horse", role)
+		require.NoError(t, err)
+	}
+
+	server := httptest.NewServer(NewAPIServer(svc).SetupRoutes())
+	defer server.Close()
+
+	tokens := map[string]string{}
+	ids := map[string]int32{}
+	for _, name := range []string{"alex", "sam"} {
+		session, err := svc.Login(ctx, name, "correct horse")
+		require.NoError(t, err)
+		tokens[name] = session.AccessToken
+		ids[name] = session.User.ID
+	}
+
+	do := func(who, method, path string, body any, out any, header ...string) int {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, err := http.NewRequest(method, server.URL+path, &buf)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+tokens[who])
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		if out != nil && resp.StatusCode < 300 {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+		}
+		return resp.StatusCode
+	}
+	balance := func(who string, header ...string) float64 {
+		var got map[string]float64
+		require.Equal(t, http.StatusOK, do(who, "GET", "/api/balance", nil, &got, header...))
+		return got["balance"]
+	}
+
+	var cabin service.Household
+	require.Equal(t, http.StatusCreated, do("alex", "POST", "/api/households", HouseholdRequest{Name: "Cabin"}, &cabin))
+	assert.Equal(t, service.RoleOwner, cabin.Role)
+	inCabin := fmt.Sprint(cabin.ID)
+
+	// The household comes from the header or the path, else the user's
+	// first one.
+	require.Equal(t, http.StatusOK, do("alex", "PUT", "/api/balance", SetBalanceRequest{Balance: 50}, nil, "X-Household", inCabin))
+	require.Equal(t, http.StatusOK, do("alex", "PUT", "/api/balance", SetBalanceRequest{Balance: 10}, nil))
+	assert.Equal(t, 50.0, balance("alex", "X-Household", inCabin))
+	assert.Equal(t, 10.0, balance("alex"))
+	var got map[string]float64
+	require.Equal(t, http.StatusOK, do("alex", "GET", "/households/"+inCabin+"/api/balance", nil, &got))
+	assert.Equal(t, 50.0, got["balance"])
+
+	// Only members get in.
+	assert.Equal(t, http.StatusForbidden, do("sam", "GET", "/api/balance", nil, nil, "X-Household", inCabin))
+	assert.Equal(t, http.StatusForbidden, do("sam", "GET", "/households/"+inCabin+"/api/balance", nil, nil))
+	assert.Equal(t, http.StatusForbidden, do("sam", "POST", "/api/households/"+inCabin+"/invitations", InvitationRequest{}, nil))
+
+	// An invitation lets them in with its role, once.
+	var inv service.Invitation
+	require.Equal(t, http.StatusCreated, do("alex", "POST", "/api/households/"+inCabin+"/invitations", InvitationRequest{}, &inv))
+	require.NotEmpty(t, inv.Token)
+	var joined service.Household
+	require.Equal(t, http.StatusOK, do("sam", "POST", "/api/invitations/accept", AcceptInvitationRequest{Token: inv.Token}, &joined))
+	assert.Equal(t, service.RoleViewer, joined.Role)
+	assert.Equal(t, http.StatusBadRequest, do("sam", "POST", "/api/invitations/accept", AcceptInvitationRequest{Token: inv.Token}, nil))
+	assert.Equal(t, 50.0, balance("sam", "X-Household", inCabin))
+	assert.Equal(t, http.StatusForbidden, do("sam", "PUT", "/api/balance", SetBalanceRequest{Balance: 0}, nil, "X-Household", inCabin))
+	assert.Equal(t, http.StatusOK, do("sam", "PUT", "/api/balance", SetBalanceRequest{Balance: 10}, nil), "still an editor at home")
+
+	var households []service.Household
+	require.Equal(t, http.StatusOK, do("sam", "GET", "/api/households", nil, &households))
+	assert.Len(t, households, 2)
+	var members []service.HouseholdMember
+	require.Equal(t, http.StatusOK, do("sam", "GET", "/api/households/"+inCabin+"/members", nil, &members))
+	assert.Len(t, members, 2)
+
+	// Members may leave; the last owner may not.
+	leave := fmt.Sprintf("/api/households/%s/members/%d", inCabin, ids["sam"])
+	assert.Equal(t, http.StatusOK, do("sam", "DELETE", leave, nil, nil))
+	assert.Equal(t, http.StatusForbidden, do("sam", "GET", "/api/balance", nil, nil, "X-Household", inCabin))
+	owner := fmt.Sprintf("/api/households/%s/members/%d", inCabin, ids["alex"])
+	assert.Equal(t, http.StatusBadRequest, do("alex", "DELETE", owner, nil, nil))
+
+	assert.Equal(t, http.StatusOK, do("alex", "DELETE", "/api/households/"+inCabin, nil, nil))
+	assert.Equal(t, http.StatusForbidden, do("alex", "GET", "/api/balance", nil, nil, "X-Household", inCabin))
+}
+
+func TestDashboard(t *testing.T) {
+	apiServer := NewAPIServer(new(MockFinanceService))
+	apiServer.SetAPIKey("s3cret")
+	server := httptest.NewServer(apiServer.SetupRoutes())
+	defer server.Close()
+
+	get := func(path string) (*http.Response, string) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	// The page and its assets load without the key; the data behind them
+	// doesn't.
+	resp, body := get("/")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+	assert.Contains(t, body, `<script src="/assets/app.js">`)
+
+	resp, body = get("/assets/app.js")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "javascript")
+	assert.Contains(t, body, "/api/forecast")
+
+	resp, _ = get("/assets/missing.js")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = get("/api/balance")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestStaticFiles(t *testing.T) {
+	bundle := strings.Repeat("console.log('currentz');\n", 100)
+	apiServer := NewAPIServer(new(MockFinanceService))
+	require.Error(t, apiServer.SetStaticFiles(fstest.MapFS{}), "no index.html")
+	require.NoError(t, apiServer.SetStaticFiles(fstest.MapFS{
+		"index.html":               {Data: []byte("<div id=app></div>")},
+		"assets/index-4f3a2b1c.js": {Data: []byte(bundle)},
+		"robots.txt":               {Data: []byte("User-agent: *")},
+	}))
+	server := httptest.NewServer(apiServer.SetupRoutes())
+	defer server.Close()
+
+	// Keep the client from decompressing, to see what was sent.
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	get := func(path string, header ...string) (*http.Response, string) {
+		req, err := http.NewRequest("GET", server.URL+path, nil)
+		require.NoError(t, err)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	// Client-side routes fall back to index.html, which is revalidated.
+	for _, path := range []string{"/", "/settings/profile", "/index.html"} {
+		resp, body := get(path)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Equal(t, "<div id=app></div>", body, path)
+		assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"), path)
+	}
+
+	resp, body := get("/robots.txt")
+	assert.Equal(t, "User-agent: *", body)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+
+	// Fingerprinted assets are cached for good and gzipped when asked.
+	resp, body = get("/assets/index-4f3a2b1c.js")
+	assert.Equal(t, bundle, body)
+	assert.Contains(t, resp.Header.Get("Cache-Control"), "immutable")
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	resp, body = get("/assets/index-4f3a2b1c.js", "Accept-Encoding", "gzip")
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	zr, err := gzip.NewReader(strings.NewReader(body))
+	require.NoError(t, err)
+	plain, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, bundle, string(plain))
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+
+	resp, _ = get("/assets/index-4f3a2b1c.js", "Accept-Encoding", "gzip;q=0")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+	resp, _ = get("/assets/index-4f3a2b1c.js", "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	// Missing assets and API paths never get index.html.
+	resp, _ = get("/assets/missing.js")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, body = get("/api/nope")
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	assert.NotContains(t, body, "app")
+}
+
+func TestCompression(t *testing.T) {
+	var txs []service.Transaction
+	for i := 0; i < 50; i++ {
+		txs = append(txs, service.Transaction{ID: int32(i + 1), Description: "Groceries at the corner shop", Type: "expense"})
+	}
+	mockService := new(MockFinanceService)
+	mockService.On("ListTransactions", mock.Anything, "", service.ListOrder{}, service.Page{}).Return(txs, "", nil)
+	mockService.On("GetStartingBalance", mock.Anything).Return(100.0, nil)
+	apiServer := NewAPIServer(withUTC(mockService))
+	server := httptest.NewServer(apiServer.SetupRoutes())
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	get := func(path, acceptEncoding string) (*http.Response, []byte) {
+		req, err := http.NewRequest("GET", server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+	decodeList := func(r io.Reader) []service.Transaction {
+		var got []service.Transaction
+		require.NoError(t, json.NewDecoder(r).Decode(&got))
+		return got
+	}
+
+	resp, body := get("/api/transactions", "br, gzip;q=0.8, deflate;q=0.5")
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Contains(t, resp.Header.Values("Vary"), "Accept-Encoding")
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	assert.Len(t, decodeList(zr), 50)
+
+	resp, body = get("/api/transactions", "deflate")
+	assert.Equal(t, "deflate", resp.Header.Get("Content-Encoding"))
+	fr, err := zlib.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	assert.Len(t, decodeList(fr), 50)
+
+	resp, body = get("/api/transactions", "")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	assert.Len(t, decodeList(bytes.NewReader(body)), 50)
+
+	// Small bodies aren't worth it.
+	resp, _ = get("/api/balance", "gzip")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+	apiServer.SetCompression(Compression{MinSize: 10, Types: []string{"text/"}})
+	server.Config.Handler = apiServer.SetupRoutes()
+	resp, _ = get("/api/transactions", "gzip")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"), "JSON not in the allowlist")
+
+	apiServer.SetCompression(Compression{MinSize: 10, Types: []string{"application/json"}})
+	server.Config.Handler = apiServer.SetupRoutes()
+	resp, _ = get("/api/balance", "gzip")
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                          "",
+		"gzip":                      "gzip",
+		"GZIP, deflate":             "gzip",
+		"deflate":                   "deflate",
+		"gzip;q=0.5, deflate":       "deflate",
+		"gzip;q=0":                  "",
+		"*":                         "gzip",
+		"*;q=0.5, gzip;q=0":         "deflate",
+		"br":                        "",
+		"identity":                  "",
+		"gzip;q=0.8, deflate;q=0.8": "gzip",
+	}
+	for header, want := range tests {
+		assert.Equal(t, want, negotiateEncoding(header), header)
+	}
+}
+
+func TestETag(t *testing.T) {
+	mockService := new(MockFinanceService)
+	mockService.On("ForecastDays", mock.Anything).Return(90)
+	mockService.On("GetStartingBalance", mock.Anything).Return(1000.0, nil)
+	mockService.On("Forecast", mock.Anything, 1000.0, 90).Return([]service.DailyCashFlow{{Balance: 1000}}, nil).Twice()
+	mockService.On("SetStartingBalance", mock.Anything, 1000.0).Return(nil)
+	server := setupTestServer(mockService)
+	defer server.Close()
+
+	get := func(ifNoneMatch string) *http.Response {
+		req, err := http.NewRequest("GET", server.URL+"/api/forecast", nil)
+		require.NoError(t, err)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	resp := get("")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`), etag)
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	// Nothing changed, so the forecast isn't even computed.
+	resp = get(etag)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Equal(t, etag, resp.Header.Get("ETag"))
+	resp = get(`"stale", ` + strings.TrimPrefix(etag, "W/"))
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode, "strong form of the tag in a list")
+
+	req, err := http.NewRequest("PUT", server.URL+"/api/balance", strings.NewReader(`{"balance": 1000}`))
+	require.NoError(t, err)
+	put, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = put.Body.Close()
+	require.Equal(t, http.StatusOK, put.StatusCode)
 
-			if tt.body != nil {
-				req.Header.Set("Content-Type", "application/json")
-			}
+	resp = get(etag)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "a write invalidates the tag")
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
 
-			resp, err := http.DefaultClient.Do(req)
-			require.NoError(t, err)
-			defer func() {
-				if err := resp.Body.Close(); err != nil {
-					t.Errorf("failed to close body: %v", err)
-				}
-			}()
+	mockService.AssertExpectations(t)
+}
 
-			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+// TestETagArchival checks that the archival job, which runs outside any
+// request, still moves the tag on.
+func TestETagArchival(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(memdb.New())
+	server := setupTestServer(svc)
+	defer server.Close()
 
-			if tt.validateBody != nil {
-				var respBody bytes.Buffer
-				_, err := respBody.ReadFrom(resp.Body)
-				require.NoError(t, err)
-				tt.validateBody(t, respBody.Bytes())
-			}
+	_, _, err := svc.RecordExpense(ctx, service.ExpenseInput{Date: svc.Today(ctx).AddDate(-1, 0, 0), Amount: 30, Description: "Groceries"})
+	require.NoError(t, err)
 
-			mockService.AssertExpectations(t)
-		})
+	get := func(ifNoneMatch string) *http.Response {
+		req, err := http.NewRequest("GET", server.URL+"/api/transactions", nil)
+		require.NoError(t, err)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp
 	}
+
+	etag := get("").Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	require.Equal(t, http.StatusNotModified, get(etag).StatusCode)
+
+	n, err := svc.ArchiveTransactions(ctx, svc.Today(ctx))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	resp := get(etag)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "archiving invalidates the tag")
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
 }
 
-func TestForecastEndpoints(t *testing.T) {
-	tests := []testCase{
-		{
-			name:   "GET /api/forecast - success",
-			method: "GET",
-			path:   "/api/forecast",
-			mockSetup: func(m *MockFinanceService) {
-				m.On("GetStartingBalance", mock.Anything).Return(5000.00, nil)
-				m.On("Calculate90DayForecast", mock.Anything, 5000.00).Return([]service.DailyCashFlow{
-					{Date: time.Now(), Balance: 5000.00, Change: 0},
-				}, nil)
-			},
-			expectedStatus: http.StatusOK,
-			validateBody: func(t *testing.T, body []byte) {
-				var forecast []service.DailyCashFlow
-				err := json.Unmarshal(body, &forecast)
-				require.NoError(t, err)
-				assert.Len(t, forecast, 1)
-				assert.Equal(t, 5000.00, forecast[0].Balance)
-			},
-		},
-		{
-			name:   "GET /api/forecast/lowest - success",
-			method: "GET",
-			path:   "/api/forecast/lowest",
-			mockSetup: func(m *MockFinanceService) {
-				forecast := []service.DailyCashFlow{
-					{Date: time.Now(), Balance: 5000.00, Change: 0},
-				}
-				m.On("GetStartingBalance", mock.Anything).Return(5000.00, nil)
-				m.On("Calculate90DayForecast", mock.Anything, 5000.00).Return(forecast, nil)
-				m.On("FindLowestPoint", forecast).Return(forecast[0], 0)
-			},
-			expectedStatus: http.StatusOK,
-			validateBody: func(t *testing.T, body []byte) {
-				var resp map[string]interface{}
-				err := json.Unmarshal(body, &resp)
-				require.NoError(t, err)
-				assert.Contains(t, resp, "lowest_point")
-				assert.Contains(t, resp, "day_index")
-				assert.Equal(t, float64(0), resp["day_index"])
-			},
-		},
+func TestETagMatches(t *testing.T) {
+	etag := `W/"abc-1-20250601"`
+	assert.True(t, etagMatches(etag, etag))
+	assert.True(t, etagMatches(`"abc-1-20250601"`, etag))
+	assert.True(t, etagMatches(`"x", W/"abc-1-20250601"`, etag))
+	assert.True(t, etagMatches("*", etag))
+	assert.False(t, etagMatches("", etag))
+	assert.False(t, etagMatches(`W/"abc-2-20250601"`, etag))
+}
+
+func TestForecastNDJSON(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	var forecast []service.DailyCashFlow
+	for i := 0; i < 40; i++ {
+		forecast = append(forecast, service.DailyCashFlow{Date: start.AddDate(0, 0, i), Balance: float64(100 - i)})
 	}
+	mockService := new(MockFinanceService)
+	mockService.On("GetStartingBalance", mock.Anything).Return(100.0, nil)
+	mockService.On("Forecast", mock.Anything, 100.0, 40).Return(forecast, nil)
+	server := setupTestServer(mockService)
+	defer server.Close()
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockFinanceService)
-			tt.mockSetup(mockService)
+	get := func(accept string) (*http.Response, []byte) {
+		req, err := http.NewRequest("GET", server.URL+"/api/forecast?days=40", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
 
-			server := setupTestServer(mockService)
-			defer server.Close()
+	resp, body := get("application/x-ndjson")
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	require.Len(t, lines, 40)
+	var last service.DailyCashFlow
+	require.NoError(t, json.Unmarshal([]byte(lines[39]), &last))
+	assert.Equal(t, 61.0, last.Balance)
+	ndjsonTag := resp.Header.Get("ETag")
 
-			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
-			require.NoError(t, err)
+	resp, body = get("application/json, application/x-ndjson;q=0.5")
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var days []service.DailyCashFlow
+	require.NoError(t, json.Unmarshal(body, &days))
+	assert.Len(t, days, 40)
+	assert.NotEqual(t, ndjsonTag, resp.Header.Get("ETag"))
+}
 
-			resp, err := http.DefaultClient.Do(req)
-			require.NoError(t, err)
-			defer func() {
-				if err := resp.Body.Close(); err != nil {
-					t.Errorf("failed to close body: %v", err)
-				}
-			}()
+func TestHealthProbes(t *testing.T) {
+	probe := func(m *MockFinanceService, path string) (int, HealthResponse) {
+		server := setupTestServer(m)
+		defer server.Close()
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		var body HealthResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
 
-			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+	// Liveness never asks the database.
+	status, body := probe(new(MockFinanceService), "/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body.Status)
+	assert.NotEmpty(t, body.Version)
 
-			if tt.validateBody != nil {
-				var respBody bytes.Buffer
-				_, err := respBody.ReadFrom(resp.Body)
-				require.NoError(t, err)
-				tt.validateBody(t, respBody.Bytes())
-			}
+	server := setupTestServer(new(MockFinanceService))
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/api/version")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	var info buildinfo.Info
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	assert.Equal(t, body.Version, info.Version)
+	assert.NotEmpty(t, info.GoVersion)
 
-			mockService.AssertExpectations(t)
-		})
-	}
+	m := new(MockFinanceService)
+	m.On("Ping", mock.Anything).Return(nil)
+	m.On("SchemaStatus", mock.Anything).Return(service.SchemaStatus{Current: 12, Expected: 12}, nil)
+	status, body = probe(m, "/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ready", body.Status)
+	assert.Equal(t, int64(12), body.Schema.Current)
+
+	m = new(MockFinanceService)
+	m.On("Ping", mock.Anything).Return(fmt.Errorf("connection refused"))
+	status, body = probe(m, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "unreachable", body.Database)
+
+	m = new(MockFinanceService)
+	m.On("Ping", mock.Anything).Return(nil)
+	m.On("SchemaStatus", mock.Anything).Return(service.SchemaStatus{Current: 10, Expected: 12, Pending: 2}, nil)
+	status, body = probe(m, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, int64(2), body.Schema.Pending)
 }
 
-func TestQueryParameterEndpoints(t *testing.T) {
-	tests := []testCase{
-		{
-			name:   "GET /api/transactions/upcoming - default days",
-			method: "GET",
-			path:   "/api/transactions/upcoming",
-			mockSetup: func(m *MockFinanceService) {
-				m.On("GetUpcomingTransactions", mock.Anything, 30).Return([]service.Transaction{}, nil)
-			},
-			expectedStatus: http.StatusOK,
-		},
-		{
-			name:   "GET /api/transactions/upcoming?days=7",
-			method: "GET",
-			path:   "/api/transactions/upcoming?days=7",
-			mockSetup: func(m *MockFinanceService) {
-				m.On("GetUpcomingTransactions", mock.Anything, 7).Return([]service.Transaction{}, nil)
-			},
-			expectedStatus: http.StatusOK,
-		},
-		{
-			name:   "GET /api/transactions/between - success",
-			method: "GET",
-			path:   "/api/transactions/between?start=2025-09-01&end=2025-09-30",
-			mockSetup: func(m *MockFinanceService) {
-				start, _ := time.Parse("2006-01-02", "2025-09-01")
-				end, _ := time.Parse("2006-01-02", "2025-09-30")
-				m.On("GetTransactionsWithRecurringsBetween", mock.Anything, start, end).Return([]service.Transaction{}, nil)
-			},
-			expectedStatus: http.StatusOK,
-		},
-		{
-			name:           "GET /api/transactions/between - missing parameters",
-			method:         "GET",
-			path:           "/api/transactions/between?start=2025-09-01",
-			mockSetup:      func(m *MockFinanceService) {},
-			expectedStatus: http.StatusBadRequest,
-			validateBody: func(t *testing.T, body []byte) {
-				var errResp ErrorResponse
-				err := json.Unmarshal(body, &errResp)
-				require.NoError(t, err)
-				assert.Contains(t, strings.ToLower(errResp.Error), "required")
-			},
-		},
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		httpsAddr, host, target, want string
+	}{
+		{":443", "example.com", "/api/forecast?days=30", "https://example.com/api/forecast?days=30"},
+		{":443", "example.com:80", "/", "https://example.com/"},
+		{":8443", "example.com:8080", "/readyz", "https://example.com:8443/readyz"},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockFinanceService)
-			tt.mockSetup(mockService)
-
-			server := setupTestServer(mockService)
-			defer server.Close()
+		req := httptest.NewRequest("POST", tt.target, nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		redirectToHTTPS(tt.httpsAddr).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, tt.want, rec.Header().Get("Location"))
+	}
+}
 
-			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
-			require.NoError(t, err)
+func TestSetTLS(t *testing.T) {
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour), DNSNames: []string{"localhost"}}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile := dir+"/cert.pem", dir+"/key.pem"
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
 
-			resp, err := http.DefaultClient.Do(req)
-			require.NoError(t, err)
-			defer func() {
-				if err := resp.Body.Close(); err != nil {
-					t.Errorf("failed to close body: %v", err)
-				}
-			}()
+	s := NewAPIServer(new(MockFinanceService))
+	assert.NoError(t, s.SetTLS(TLSOptions{CertFile: certFile, KeyFile: keyFile}))
+	assert.NoError(t, s.SetTLS(TLSOptions{AutocertDomains: []string{"example.com"}, AutocertCacheDir: dir}))
 
-			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+	assert.Error(t, s.SetTLS(TLSOptions{}))
+	assert.Error(t, s.SetTLS(TLSOptions{CertFile: certFile}), "no key")
+	assert.Error(t, s.SetTLS(TLSOptions{CertFile: keyFile, KeyFile: certFile}), "swapped")
+	assert.Error(t, s.SetTLS(TLSOptions{CertFile: certFile, KeyFile: keyFile, AutocertDomains: []string{"example.com"}}))
+	assert.Error(t, s.SetTLS(TLSOptions{AutocertDomains: []string{"example.com"}}), "no cache")
+}
 
-			if tt.validateBody != nil {
-				var respBody bytes.Buffer
-				_, err := respBody.ReadFrom(resp.Body)
-				require.NoError(t, err)
-				tt.validateBody(t, respBody.Bytes())
-			}
+func TestLimits(t *testing.T) {
+	mockService := new(MockFinanceService)
+	mockService.On("SetStartingBalance", mock.Anything, 5.0).Return(nil)
+	mockService.On("GetStartingBalance", mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})).Return(5.0, nil)
+	apiServer := NewAPIServer(withUTC(mockService))
+	apiServer.SetLimits(Limits{RequestTimeout: time.Minute, MaxBodyBytes: 64, MaxUploadBytes: 256})
+	server := httptest.NewServer(apiServer.SetupRoutes())
+	defer server.Close()
 
-			mockService.AssertExpectations(t)
-		})
+	do := func(method, path string, body io.Reader) (int, string) {
+		req, err := http.NewRequest(method, server.URL+path, body)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		var e ErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return resp.StatusCode, e.Error
 	}
+	padded := `{"balance": 5` + strings.Repeat(" ", 100) + `}`
+
+	status, _ := do("PUT", "/api/balance", strings.NewReader(`{"balance": 5}`))
+	assert.Equal(t, http.StatusOK, status)
+
+	status, msg := do("PUT", "/api/balance", strings.NewReader(padded))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status, "Content-Length over the limit")
+	assert.Contains(t, msg, "64 bytes")
+
+	// Without a Content-Length the limit hits while decoding.
+	status, _ = do("PUT", "/api/balance", io.MultiReader(strings.NewReader(padded)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status, "chunked body over the limit")
+
+	// Imports get the larger upload limit, and still a 413 past it.
+	status, msg = do("POST", "/api/restore", io.MultiReader(strings.NewReader(`{"version": 1, "x": "`+strings.Repeat("a", 300)+`"}`)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.Contains(t, msg, "256 bytes")
+
+	// Handlers run with a deadline.
+	status, _ = do("GET", "/api/balance", nil)
+	assert.Equal(t, http.StatusOK, status)
 }
 
-func TestCORSHeaders(t *testing.T) {
+func TestEventStream(t *testing.T) {
+	events := make(chan service.Event, 1)
+	events <- service.Event{Type: service.EventTransactionCreated, Time: time.Now()}
+
 	mockService := new(MockFinanceService)
+	mockService.On("SubscribeEvents", mock.Anything).Return((<-chan service.Event)(events), func() {})
 	server := setupTestServer(mockService)
 	defer server.Close()
 
-	// Test OPTIONS request
-	req, err := http.NewRequest("OPTIONS", server.URL+"/api/transactions", nil)
-	require.NoError(t, err)
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := http.Get(server.URL + "/api/events")
 	require.NoError(t, err)
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -628,12 +5905,239 @@ func TestCORSHeaders(t *testing.T) {
 	}()
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
-	assert.Contains(t, resp.Header.Get("Access-Control-Allow-Methods"), "GET")
-	assert.Contains(t, resp.Header.Get("Access-Control-Allow-Methods"), "POST")
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 2 || !strings.HasPrefix(lines[len(lines)-1], "data:") {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, ":") {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, "event: "+service.EventTransactionCreated, lines[0])
+	assert.Contains(t, lines[1], `"type":"transaction.created"`)
+
+	mockService.AssertExpectations(t)
 }
 
 // Helper function for int pointers
 func intPtr(i int) *int {
 	return &i
 }
+
+func pgNumeric(s string) pgtype.Numeric {
+	var n pgtype.Numeric
+	_ = n.Scan(s)
+	return n
+}
+
+func pgDate(s string) pgtype.Date {
+	t, _ := time.Parse("2006-01-02", s)
+	return pgtype.Date{Time: t, Valid: true}
+}
+
+// TestMemDB drives the real service through the API, backed by memdb
+// instead of mocks.
+func TestAttachments(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(memdb.New())
+	store := blob.NewMemory()
+	svc.SetBlobStore(store)
+	svc.SetMaxAttachmentBytes(1024)
+	server := setupTestServer(svc)
+	defer server.Close()
+
+	tx, _, err := svc.RecordExpense(ctx, service.ExpenseInput{Date: svc.Today(ctx), Amount: 42, Description: "Hardware store"})
+	require.NoError(t, err)
+	base := fmt.Sprintf("%s/api/transactions/%d/attachments", server.URL, tx.ID)
+
+	upload := func(field, filename string, content []byte) (*http.Response, []byte) {
+		t.Helper()
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		fw, err := mw.CreateFormFile(field, filename)
+		require.NoError(t, err)
+		_, err = fw.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+		resp, err := http.Post(base, mw.FormDataContentType(), &buf)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	pdf := []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n%%EOF\n")
+	resp, body := upload("file", `C:\Scans\receipt "May".pdf`, pdf)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(body))
+	var att service.Attachment
+	require.NoError(t, json.Unmarshal(body, &att))
+	assert.Equal(t, "application/pdf", att.ContentType)
+	assert.Equal(t, "receipt May.pdf", att.Filename)
+	assert.Equal(t, int64(len(pdf)), att.Size)
+
+	resp, body = upload("file", "notes.txt", []byte("just some text"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode, string(body))
+	resp, _ = upload("file", "big.pdf", append(pdf, make([]byte, 2048)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	resp, _ = upload("document", "receipt.pdf", pdf)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, 1, store.Len(), "rejected uploads leave nothing behind")
+
+	resp, err = http.Get(base)
+	require.NoError(t, err)
+	var list []service.Attachment
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	_ = resp.Body.Close()
+	require.Len(t, list, 1)
+
+	resp, err = http.Get(fmt.Sprintf("%s/%d", base, att.ID))
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, pdf, body)
+	assert.Equal(t, "application/pdf", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="receipt May.pdf"`, resp.Header.Get("Content-Disposition"))
+
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/%d", base, att.ID), nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 0, store.Len())
+
+	resp, err = http.Get(fmt.Sprintf("%s/%d", base, att.ID))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, err = http.Get(server.URL + "/api/transactions/999/attachments")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestStoredBackups(t *testing.T) {
+	svc := service.NewFinanceService(memdb.New())
+	server := setupTestServer(svc)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/backups", "application/json", nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode, "no blob store configured")
+
+	svc.SetBlobStore(blob.NewMemory())
+	resp, err = http.Post(server.URL+"/api/backups", "application/json", nil)
+	require.NoError(t, err)
+	var saved service.StoredBackup
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&saved))
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(saved.Name, "currentz-backup-"))
+
+	resp, err = http.Get(server.URL + "/api/backups")
+	require.NoError(t, err)
+	var list []service.StoredBackup
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	_ = resp.Body.Close()
+	require.Len(t, list, 1)
+	assert.Equal(t, saved.Name, list[0].Name)
+
+	resp, err = http.Get(server.URL + "/api/backups/" + saved.Name)
+	require.NoError(t, err)
+	var b service.Backup
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&b))
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, service.BackupVersion, b.Version)
+
+	resp, err = http.Get(server.URL + "/api/backups/currentz-backup-19990101T000000Z.json")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPresignedDownloads(t *testing.T) {
+	m := new(MockFinanceService)
+	m.On("AttachmentURL", mock.Anything, int32(3), int32(4)).Return("https://bucket.example/attachments/abc?X-Amz-Signature=1", nil)
+	m.On("StoredBackupURL", mock.Anything, "currentz-backup-20250301T120000Z.json").Return("https://bucket.example/backups/b?X-Amz-Signature=2", nil)
+	server := setupTestServer(m)
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	for path, want := range map[string]string{
+		"/api/transactions/3/attachments/4":                  "https://bucket.example/attachments/abc?X-Amz-Signature=1",
+		"/api/backups/currentz-backup-20250301T120000Z.json": "https://bucket.example/backups/b?X-Amz-Signature=2",
+	} {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusFound, resp.StatusCode, path)
+		assert.Equal(t, want, resp.Header.Get("Location"), path)
+	}
+	m.AssertExpectations(t)
+}
+
+func TestMemDB(t *testing.T) {
+	svc := service.NewFinanceService(memdb.New())
+	server := setupTestServer(svc)
+	defer server.Close()
+
+	do := func(method, path string, body any) (int, []byte) {
+		t.Helper()
+		var r io.Reader
+		if body != nil {
+			b, err := json.Marshal(body)
+			require.NoError(t, err)
+			r = bytes.NewReader(b)
+		}
+		req, err := http.NewRequest(method, server.URL+path, r)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, out
+	}
+
+	tomorrow := svc.Today(context.Background()).AddDate(0, 0, 1).Format("2006-01-02")
+	status, _ := do("PUT", "/api/balance", map[string]any{"balance": 1000})
+	require.Equal(t, http.StatusOK, status)
+	status, _ = do("POST", "/api/recurring", map[string]any{
+		"description": "Gym", "type": "expense", "amount": 200, "start_date": tomorrow, "interval": "monthly", "active": true,
+	})
+	require.Equal(t, http.StatusCreated, status)
+	status, body := do("POST", "/api/transactions/expense", map[string]any{
+		"date": tomorrow, "amount": "50.00", "description": "Groceries", "category": "food",
+	})
+	require.Equal(t, http.StatusCreated, status, string(body))
+	var created ExpenseCreatedResponse
+	require.NoError(t, json.Unmarshal(body, &created))
+
+	status, body = do("GET", "/api/forecast?days=3", nil)
+	require.Equal(t, http.StatusOK, status)
+	var forecast []service.DailyCashFlow
+	require.NoError(t, json.Unmarshal(body, &forecast))
+	require.Len(t, forecast, 3)
+	assert.Equal(t, 750.0, forecast[2].Balance)
+
+	status, _ = do("DELETE", fmt.Sprintf("/api/transactions/%d", created.Transaction.ID), nil)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = do("DELETE", fmt.Sprintf("/api/transactions/%d", created.Transaction.ID), nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, body = do("GET", "/api/transactions", nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, "[]", string(body))
+
+	status, _ = do("GET", "/readyz", nil)
+	assert.Equal(t, http.StatusOK, status)
+}
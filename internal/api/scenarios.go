@@ -0,0 +1,265 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// ScenarioRequest is a what-if: transactions and recurrings to lay over the
+// real data without saving them.
+type ScenarioRequest struct {
+	Transactions []ScenarioTransactionRequest  `json:"transactions,omitempty"`
+	Recurring    []RecurringTransactionRequest `json:"recurring,omitempty"`
+}
+
+// ScenarioTransactionRequest is a hypothetical one-off income or expense.
+type ScenarioTransactionRequest struct {
+	Date        string `json:"date"`
+	Type        string `json:"type"`
+	Amount      Amount `json:"amount"`
+	Description string `json:"description,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+}
+
+// CompareForecastRequest compares two scenarios over the same days. Leave
+// Baseline out to compare Scenario with the real forecast.
+type CompareForecastRequest struct {
+	Days     int              `json:"days,omitempty"`
+	Baseline *ScenarioRequest `json:"baseline,omitempty"`
+	Scenario ScenarioRequest  `json:"scenario"`
+}
+
+// SavedScenarioRequest creates or replaces a saved scenario.
+type SavedScenarioRequest struct {
+	Name     string          `json:"name"`
+	Scenario ScenarioRequest `json:"scenario"`
+}
+
+func (s *APIServer) handleCompareForecasts(w http.ResponseWriter, r *http.Request) {
+	var req CompareForecastRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	days := req.Days
+	if days == 0 {
+		days = s.financeService.ForecastDays(r.Context())
+	} else if days < 0 || days > maxForecastDays {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid days (expected 1-%d)", maxForecastDays))
+		return
+	}
+
+	var baseline service.Scenario
+	if req.Baseline != nil {
+		sc, err := s.scenario(r, *req.Baseline)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid baseline: %s", err.Error()))
+			return
+		}
+		baseline = sc
+	}
+	scenario, err := s.scenario(r, req.Scenario)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid scenario: %s", err.Error()))
+		return
+	}
+
+	balance, err := s.financeService.GetStartingBalance(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cmp, err := s.financeService.CompareForecasts(r.Context(), balance, days, baseline, scenario)
+	if err != nil {
+		s.writeScenarioError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, cmp)
+}
+
+// scenario parses the dates in req, which the service checks the rest of.
+func (s *APIServer) scenario(r *http.Request, req ScenarioRequest) (service.Scenario, error) {
+	var sc service.Scenario
+	for i, t := range req.Transactions {
+		date, err := s.parseDay(r, t.Date)
+		if err != nil {
+			return service.Scenario{}, fmt.Errorf("transaction %d: date: %w", i+1, err)
+		}
+		sc.Transactions = append(sc.Transactions, service.ScenarioTransaction{
+			Date:        date,
+			Type:        t.Type,
+			Amount:      float64(t.Amount),
+			Description: t.Description,
+			Currency:    t.Currency,
+		})
+	}
+	for i, rec := range req.Recurring {
+		start, err := s.parseDay(r, rec.StartDate)
+		if err != nil {
+			return service.Scenario{}, fmt.Errorf("recurring %d: start_date: %w", i+1, err)
+		}
+		var end *time.Time
+		if rec.EndDate != nil {
+			d, err := s.parseDay(r, *rec.EndDate)
+			if err != nil {
+				return service.Scenario{}, fmt.Errorf("recurring %d: end_date: %w", i+1, err)
+			}
+			end = &d
+		}
+		sc.Recurring = append(sc.Recurring, service.RecurringInput{
+			Description:     rec.Description,
+			Type:            rec.Type,
+			Amount:          float64(rec.Amount),
+			StartDate:       start,
+			Interval:        rec.Interval,
+			DayOfWeek:       rec.DayOfWeek,
+			DayOfMonth:      rec.DayOfMonth,
+			EndDate:         end,
+			Active:          true,
+			Currency:        rec.Currency,
+			OccurrenceCount: rec.OccurrenceCount,
+			WeekOfMonth:     rec.WeekOfMonth,
+			BusinessDay:     rec.BusinessDay,
+			RRule:           rec.RRule,
+		})
+	}
+	return sc, nil
+}
+
+func (s *APIServer) handleListScenarios(w http.ResponseWriter, r *http.Request) {
+	scenarios, err := s.financeService.ListScenarios(r.Context())
+	if err != nil {
+		s.writeScenarioError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, scenarios)
+}
+
+func (s *APIServer) handleGetScenario(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.scenarioID(w, r)
+	if !ok {
+		return
+	}
+	saved, err := s.financeService.GetScenario(r.Context(), id)
+	if err != nil {
+		s.writeScenarioError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, saved)
+}
+
+func (s *APIServer) handleCreateScenario(w http.ResponseWriter, r *http.Request) {
+	name, sc, ok := s.savedScenarioInput(w, r)
+	if !ok {
+		return
+	}
+	saved, err := s.financeService.CreateScenario(r.Context(), name, sc)
+	if err != nil {
+		s.writeScenarioError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, saved)
+}
+
+func (s *APIServer) handleUpdateScenario(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.scenarioID(w, r)
+	if !ok {
+		return
+	}
+	name, sc, ok := s.savedScenarioInput(w, r)
+	if !ok {
+		return
+	}
+	saved, err := s.financeService.UpdateScenario(r.Context(), id, name, sc)
+	if err != nil {
+		s.writeScenarioError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, saved)
+}
+
+func (s *APIServer) handleDeleteScenario(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.scenarioID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.financeService.DeleteScenario(r.Context(), id); err != nil {
+		s.writeScenarioError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// handleScenarioForecast compares a saved scenario with the real forecast
+// as the data stands now.
+func (s *APIServer) handleScenarioForecast(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.scenarioID(w, r)
+	if !ok {
+		return
+	}
+	var days int
+	if daysStr := r.URL.Query().Get("days"); daysStr == "" {
+		days = s.financeService.ForecastDays(r.Context())
+	} else {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d <= 0 || d > maxForecastDays {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid days (expected 1-%d)", maxForecastDays))
+			return
+		}
+		days = d
+	}
+	balance, err := s.financeService.GetStartingBalance(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cmp, err := s.financeService.ScenarioForecast(r.Context(), id, balance, days)
+	if err != nil {
+		s.writeScenarioError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, cmp)
+}
+
+func (s *APIServer) scenarioID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid scenario ID")
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// savedScenarioInput decodes a SavedScenarioRequest body, writing the error
+// response if it can't.
+func (s *APIServer) savedScenarioInput(w http.ResponseWriter, r *http.Request) (string, service.Scenario, bool) {
+	var req SavedScenarioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return "", service.Scenario{}, false
+	}
+	sc, err := s.scenario(r, req.Scenario)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid scenario: %s", err.Error()))
+		return "", service.Scenario{}, false
+	}
+	return req.Name, sc, true
+}
+
+func (s *APIServer) writeScenarioError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrScenarioNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidScenario):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		s.writeForecastError(w, err)
+	}
+}
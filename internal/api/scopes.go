@@ -0,0 +1,125 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// ScopeAdmin lets a scoped key do anything the API key can.
+const ScopeAdmin = "admin"
+
+// scopeSet is the scopes one key carries.
+type scopeSet map[string]bool
+
+// scopedKey is an API key limited to its scopes.
+type scopedKey struct {
+	key    string
+	scopes scopeSet
+}
+
+// SetScopedKeys adds API keys, alongside the one SetAPIKey sets, that may
+// only make the requests their scopes allow:
+//
+//	read:<part>   read one part of the API, e.g. read:transactions for /api/transactions
+//	write:<part>  read and change it
+//	read, write   the same for every part, except what only owners may use
+//	admin         everything
+//
+// So a read-only dashboard widget can be given read:forecast and nothing
+// else. An unknown scope is an error.
+func (s *APIServer) SetScopedKeys(keys map[string][]string) error {
+	parts := apiParts(s.routes())
+	s.scopedKeys = nil
+	for key, scopes := range keys {
+		set := scopeSet{}
+		for _, scope := range scopes {
+			action, part, hasPart := strings.Cut(scope, ":")
+			switch {
+			case scope == ScopeAdmin, scope == "read", scope == "write":
+			case hasPart && (action == "read" || action == "write") && slices.Contains(parts, part):
+			default:
+				return fmt.Errorf("unknown scope %q (expected admin, read, write, or read: or write: with one of %s)",
+					scope, strings.Join(parts, ", "))
+			}
+			set[scope] = true
+		}
+		if key == "" || len(set) == 0 {
+			return fmt.Errorf("a scoped key needs a key and at least one scope")
+		}
+		s.scopedKeys = append(s.scopedKeys, scopedKey{key: key, scopes: set})
+	}
+	return nil
+}
+
+// keyScopes finds the scopes of key, if it's one of the scoped keys.
+func (s *APIServer) keyScopes(key string) (scopeSet, bool) {
+	var found scopeSet
+	for _, k := range s.scopedKeys {
+		// Compare them all, so the time taken doesn't give away which.
+		if subtle.ConstantTimeCompare([]byte(key), []byte(k.key)) == 1 {
+			found = k.scopes
+		}
+	}
+	return found, found != nil
+}
+
+// requiredScope is the scope r needs, following requiredRole: reading its
+// part of the API where a viewer would do, writing it where an editor
+// would, and admin where only an owner may.
+func requiredScope(r *http.Request) string {
+	part := apiPart(r.URL.Path)
+	switch requiredRole(r) {
+	case "":
+		return ""
+	case service.RoleViewer:
+		return "read:" + part
+	case service.RoleEditor:
+		return "write:" + part
+	}
+	return ScopeAdmin
+}
+
+// allow reports whether the scopes include need, or one that covers it.
+func (set scopeSet) allow(need string) bool {
+	if set[ScopeAdmin] || set[need] {
+		return true
+	}
+	action, part, _ := strings.Cut(need, ":")
+	switch action {
+	case "read":
+		return set["read"] || set["write"] || set["write:"+part]
+	case "write":
+		return set["write"]
+	}
+	return false
+}
+
+// apiPart is the part of the API path is in: "transactions" for
+// /api/transactions/5.
+func apiPart(path string) string {
+	part, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/"), "/")
+	return part
+}
+
+// apiParts lists the parts of the API r serves.
+func apiParts(r *mux.Router) []string {
+	var parts []string
+	_ = r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tmpl, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(tmpl, "/api/") {
+			return nil
+		}
+		if part := apiPart(tmpl); !slices.Contains(parts, part) && !strings.HasPrefix(part, "{") {
+			parts = append(parts, part)
+		}
+		return nil
+	})
+	slices.Sort(parts)
+	return parts
+}
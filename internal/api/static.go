@@ -0,0 +1,136 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SetStaticFiles serves fsys at / in place of the built-in dashboard, for
+// example a React or Vue build. fsys must have an index.html at its root.
+// Paths outside /api/ that aren't files get index.html, so the frontend's
+// own router can handle them.
+func (s *APIServer) SetStaticFiles(fsys fs.FS) error {
+	if _, err := fs.Stat(fsys, "index.html"); err != nil {
+		return fmt.Errorf("static files have no index.html: %w", err)
+	}
+	s.static = fsys
+	return nil
+}
+
+// fingerprinted matches build output named after its content, such as
+// index-4f3a2b1c.js or main.8e9f0a1b.css. Those never change and can be
+// cached for good; everything else is revalidated on each use.
+var fingerprinted = regexp.MustCompile(`[.-][0-9A-Za-z_]{8,}\.[0-9a-z]+$`)
+
+// staticHandler serves a frontend build with a history-mode fallback.
+type staticHandler struct {
+	files fs.FS
+	etags sync.Map // path -> ETag, for files without a modification time
+}
+
+func newStaticHandler(files fs.FS) *staticHandler {
+	return &staticHandler{files: files}
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "index.html"
+	}
+
+	info, err := fs.Stat(h.files, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, "index.html")
+		info, err = fs.Stat(h.files, name)
+	}
+	if err != nil {
+		// A missing asset is a real 404. Anything else is a page the
+		// frontend's router knows about.
+		if path.Ext(name) != "" && path.Ext(name) != ".html" {
+			http.NotFound(w, r)
+			return
+		}
+		name = "index.html"
+		if info, err = fs.Stat(h.files, name); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	if fingerprinted.MatchString(name) {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	ctype := mime.TypeByExtension(path.Ext(name))
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ctype)
+
+	etag, err := h.etag(name, info)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// A build step may have compressed the file already. Otherwise
+	// compressMiddleware takes care of it.
+	if codingQ(r.Header.Get("Accept-Encoding"), "gzip") > 0 {
+		if gz, err := fs.Stat(h.files, name+".gz"); err == nil && !gz.IsDir() {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+`-gzip"`)
+			h.serveFile(w, r, name+".gz", gz.ModTime())
+			return
+		}
+	}
+
+	w.Header().Set("ETag", etag)
+	h.serveFile(w, r, name, info.ModTime())
+}
+
+// serveFile hands off to http.ServeContent, which takes care of ranges and
+// conditional requests.
+func (h *staticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, modTime time.Time) {
+	f, err := h.files.Open(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		http.Error(w, "static file is not seekable", http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, name, modTime, rs)
+}
+
+// etag identifies a file's content: by size and modification time when it
+// has one, and otherwise, as embedded files don't, by a hash of the bytes.
+func (h *staticHandler) etag(name string, info fs.FileInfo) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()), nil
+	}
+	if tag, ok := h.etags.Load(name); ok {
+		return tag.(string), nil
+	}
+	b, err := fs.ReadFile(h.files, name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	tag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	h.etags.Store(name, tag)
+	return tag, nil
+}
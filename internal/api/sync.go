@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// handleSync returns what changed after ?since=, the cursor from the last
+// sync, or everything without one. ?limit= caps the records returned; when
+// there are more, has_more is set and the next request continues from the
+// returned cursor.
+func (s *APIServer) handleSync(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var since int64
+	if v := q.Get("since"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			s.writeError(w, http.StatusBadRequest, "Invalid since cursor")
+			return
+		}
+		since = n
+	}
+	limit := service.DefaultSyncLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > service.MaxSyncLimit {
+			s.writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(service.MaxSyncLimit))
+			return
+		}
+		limit = n
+	}
+
+	res, err := s.financeService.Sync(r.Context(), since, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrStaleCursor) {
+			s.writeError(w, http.StatusGone, err.Error()+"; sync again from 0")
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSOptions serves the API over HTTPS, from certificate files or with
+// certificates issued by Let's Encrypt. Set CertFile and KeyFile, or
+// AutocertDomains, not both.
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+
+	// AutocertDomains are the host names certificates are requested for.
+	// Let's Encrypt must be able to reach this server on port 443, or on
+	// port 80 through RedirectAddr.
+	AutocertDomains []string
+	// AutocertCacheDir keeps issued certificates across restarts, which
+	// matters: Let's Encrypt rate-limits reissuing them.
+	AutocertCacheDir string
+	// AutocertEmail is given to Let's Encrypt for expiry notices.
+	AutocertEmail string
+
+	// RedirectAddr, e.g. ":80", listens for plain HTTP and redirects it to
+	// HTTPS. With autocert it also answers Let's Encrypt's challenges.
+	RedirectAddr string
+}
+
+// SetTLS makes Start serve HTTPS.
+func (s *APIServer) SetTLS(opts TLSOptions) error {
+	files := opts.CertFile != "" || opts.KeyFile != ""
+	switch {
+	case files && len(opts.AutocertDomains) > 0:
+		return errors.New("use either a certificate and key or autocert, not both")
+	case files && (opts.CertFile == "" || opts.KeyFile == ""):
+		return errors.New("a certificate needs both a cert file and a key file")
+	case !files && len(opts.AutocertDomains) == 0:
+		return errors.New("TLS needs a certificate and key or autocert domains")
+	case len(opts.AutocertDomains) > 0 && opts.AutocertCacheDir == "":
+		return errors.New("autocert needs a cache directory")
+	}
+	if files {
+		// Fail at startup, not on the first handshake.
+		if _, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile); err != nil {
+			return fmt.Errorf("loading certificate: %w", err)
+		}
+	}
+	s.tls = &opts
+	return nil
+}
+
+// serveTLS runs srv over HTTPS, plus the redirect listener if there is one.
+func (s *APIServer) serveTLS(srv *http.Server) error {
+	opts := s.tls
+	redirect := redirectToHTTPS(srv.Addr)
+
+	if len(opts.AutocertDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.AutocertDomains...),
+			Cache:      autocert.DirCache(opts.AutocertCacheDir),
+			Email:      opts.AutocertEmail,
+		}
+		srv.TLSConfig = m.TLSConfig()
+		redirect = m.HTTPHandler(redirect)
+	}
+
+	if opts.RedirectAddr != "" {
+		go func() {
+			log.Printf("Redirecting HTTP on %s to HTTPS", opts.RedirectAddr)
+			if err := http.ListenAndServe(opts.RedirectAddr, redirect); err != nil {
+				log.Printf("HTTP redirect listener stopped: %v", err)
+			}
+		}()
+	}
+	return srv.ListenAndServeTLS(opts.CertFile, opts.KeyFile)
+}
+
+// redirectToHTTPS sends plain HTTP requests to the same path over HTTPS on
+// httpsAddr's port. 308 keeps the method and body of a stray POST.
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// UserRequest creates a user. Role defaults to viewer.
+type UserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role,omitempty"`
+}
+
+// UserRoleRequest changes a user's role.
+type UserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// UserPasswordRequest changes a user's password.
+type UserPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+func (s *APIServer) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.financeService.ListUsers(r.Context())
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, users)
+}
+
+func (s *APIServer) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.userID(w, r)
+	if !ok {
+		return
+	}
+	u, err := s.financeService.GetUser(r.Context(), id)
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, u)
+}
+
+func (s *APIServer) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if req.Role == "" {
+		req.Role = service.RoleViewer
+	}
+	u, err := s.financeService.CreateUser(r.Context(), req.Username, req.Password, req.Role)
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, u)
+}
+
+func (s *APIServer) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.userID(w, r)
+	if !ok {
+		return
+	}
+	var req UserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	u, err := s.financeService.SetUserRole(r.Context(), id, req.Role)
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, u)
+}
+
+// handleSetUserPassword changes a password and ends the user's sessions,
+// so they log in again with it.
+func (s *APIServer) handleSetUserPassword(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.userID(w, r)
+	if !ok {
+		return
+	}
+	var req UserPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeDecodeError(w, err)
+		return
+	}
+	if err := s.financeService.SetUserPassword(r.Context(), id, req.Password); err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.userID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.financeService.DeleteUser(r.Context(), id); err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (s *APIServer) userID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func (s *APIServer) writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		s.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidUser):
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// expectVersion puts the version of the record an edit is based on into
+// the request's context, for the service to refuse the edit if the record
+// has changed since. It comes from If-Match, or else from the version in
+// the request body. Edits have to send one, so two clients can't silently
+// overwrite each other; without it this answers 428 Precondition Required
+// and returns false. If-Match: * edits whatever version is current.
+func (s *APIServer) expectVersion(w http.ResponseWriter, r *http.Request, body *int32) (context.Context, bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch {
+	case ifMatch == "*":
+		return r.Context(), true
+	case ifMatch != "":
+		version, err := parseVersionTag(ifMatch)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		return service.WithVersion(r.Context(), version), true
+	case body != nil:
+		return service.WithVersion(r.Context(), *body), true
+	}
+	s.writeError(w, http.StatusPreconditionRequired,
+		`send the version this edit is based on, as If-Match: "<version>" or "version" in the body`)
+	return nil, false
+}
+
+// parseVersionTag reads a version from an entity tag, "3" or W/"3".
+func parseVersionTag(tag string) (int32, error) {
+	v := strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+	version, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid If-Match %s: want the record's version, as in \"3\"", tag)
+	}
+	return int32(version), nil
+}
+
+// setVersionTag tags a response with the version of the record in it, to
+// send back in If-Match with the next edit.
+func setVersionTag(w http.ResponseWriter, version int32) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
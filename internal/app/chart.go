@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// ChartOptions tunes DisplayChart. The zero value gives a chart of about
+// maxChartRows rows with $0 marked.
+type ChartOptions struct {
+	// Step is the number of days each row covers; 1 plots every day.
+	// Zero picks the smallest step that fits in maxChartRows rows.
+	Step int
+	// Threshold is marked with a vertical line, and rows that dip below it
+	// are flagged. Zero shows where the balance goes negative.
+	Threshold float64
+	// Width is the bar width in columns; zero means defaultChartWidth.
+	Width int
+}
+
+const (
+	maxChartRows      = 30
+	defaultChartWidth = 40
+)
+
+// barEighths draws a bar's last, partly filled column.
+var barEighths = []rune("▏▎▍▌▋▊▉")
+
+func DisplayChart(forecast []service.DailyCashFlow, opts ChartOptions) {
+	fmt.Printf("\n📊 %d-Day Cash Flow Forecast\n", len(forecast))
+	fmt.Println("=" + strings.Repeat("=", 60))
+
+	if len(forecast) == 0 {
+		fmt.Println("No forecast data available.")
+		return
+	}
+	fmt.Print(renderChart(forecast, opts))
+}
+
+func renderChart(forecast []service.DailyCashFlow, opts ChartOptions) string {
+	step := opts.Step
+	if step <= 0 {
+		step = (len(forecast) + maxChartRows - 1) / maxChartRows
+	}
+	width := opts.Width
+	if width <= 0 {
+		width = defaultChartWidth
+	}
+
+	minBalance, maxBalance := forecast[0].Balance, forecast[0].Balance
+	for _, day := range forecast {
+		minBalance = math.Min(minBalance, day.Balance)
+		maxBalance = math.Max(maxBalance, day.Balance)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Balance Range: $%.2f to $%.2f\n", minBalance, maxBalance)
+	if step > 1 {
+		fmt.Fprintf(&b, "Each row is %d days at their lowest balance.\n", step)
+	}
+	fmt.Fprintf(&b, "┊ marks $%.2f\n\n", opts.Threshold)
+
+	// Scale so the threshold always lands on the chart.
+	lo := math.Min(minBalance, opts.Threshold)
+	hi := math.Max(maxBalance, opts.Threshold)
+	span := hi - lo
+	if span == 0 {
+		span = 1
+	}
+	mark := min(int((opts.Threshold-lo)/span*float64(width)), width-1)
+
+	for i := 0; i < len(forecast); i += step {
+		// A row stands for its lowest day so a short dip isn't skipped over.
+		day := forecast[i]
+		for _, d := range forecast[i:min(i+step, len(forecast))] {
+			if d.Balance < day.Balance {
+				day = d
+			}
+		}
+
+		eighths := int(math.Round((day.Balance - lo) / span * float64(width*8)))
+		cells := []rune(strings.Repeat(" ", width))
+		for c := 0; c < eighths/8; c++ {
+			cells[c] = '█'
+		}
+		if rem := eighths % 8; rem > 0 {
+			cells[eighths/8] = barEighths[rem-1]
+		}
+		if cells[mark] == ' ' {
+			cells[mark] = '┊'
+		}
+
+		bar := string(cells)
+		flag := ""
+		if day.Balance < opts.Threshold {
+			bar = expenseStyle.Render(bar)
+			flag = " ⚠️"
+		}
+		fmt.Fprintf(&b, "%s │%s│ $%10.2f%s\n", day.Date.Format("Jan 02"), bar, day.Balance, flag)
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+func chartDays(balances ...float64) []service.DailyCashFlow {
+	fc := make([]service.DailyCashFlow, len(balances))
+	for i, b := range balances {
+		fc[i] = service.DailyCashFlow{Date: time.Date(2025, 6, 1+i, 0, 0, 0, 0, time.UTC), Balance: b}
+	}
+	return fc
+}
+
+func TestRenderChart(t *testing.T) {
+	SetColor(false)
+
+	out := renderChart(chartDays(100, 50, -100, 25), ChartOptions{Step: 1, Width: 4})
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	assert.Equal(t, []string{
+		"Balance Range: $-100.00 to $100.00",
+		"┊ marks $0.00",
+		"",
+		"Jun 01 │████│ $    100.00",
+		"Jun 02 │███ │ $     50.00",
+		"Jun 03 │  ┊ │ $   -100.00 ⚠️",
+		"Jun 04 │██▌ │ $     25.00",
+	}, lines)
+}
+
+func TestRenderChartStepKeepsLowestDay(t *testing.T) {
+	SetColor(false)
+
+	out := renderChart(chartDays(100, 10, 80, 90), ChartOptions{Step: 2, Width: 8, Threshold: 50})
+	assert.Contains(t, out, "Each row is 2 days")
+	assert.Contains(t, out, "Jun 02 │   ┊    │ $     10.00 ⚠️")
+	assert.Contains(t, out, "Jun 03 │██████▎ │ $     80.00")
+	assert.NotContains(t, out, "Jun 01")
+}
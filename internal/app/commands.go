@@ -0,0 +1,291 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// TransactionInput is a one-off income or expense. Category and AccountID
+// only apply to expenses.
+type TransactionInput struct {
+	Type        string
+	Date        time.Time
+	Amount      float64
+	Description string
+	Currency    string
+	Category    string
+	AccountID   *int32
+}
+
+// AddTransaction records a one-off income or expense and prints any budget
+// or low-balance warnings it triggers.
+func (fa *FinanceApp) AddTransaction(in TransactionInput) error {
+	return fa.addTransaction(service.WithActor(fa.context(), "cli"), in)
+}
+
+func (fa *FinanceApp) addTransaction(ctx context.Context, in TransactionInput) error {
+	warnings, err := fa.recordTransaction(ctx, in)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Added %s: $%.2f on %s\n", in.Type, in.Amount, in.Date.Format("Jan 2, 2006"))
+	for _, w := range warnings {
+		fmt.Printf("⚠️  %s\n", w.Message)
+	}
+	return nil
+}
+
+// recordTransaction saves in without printing anything.
+func (fa *FinanceApp) recordTransaction(ctx context.Context, in TransactionInput) ([]service.Warning, error) {
+	switch in.Type {
+	case "income":
+		if in.Category != "" || in.AccountID != nil {
+			return nil, fmt.Errorf("category and account only apply to expenses")
+		}
+		if err := fa.service.AddIncome(ctx, in.Date, in.Amount, in.Description, in.Currency); err != nil {
+			return nil, fmt.Errorf("failed to add income: %w", err)
+		}
+		return nil, nil
+	case "expense":
+		_, warnings, err := fa.service.RecordExpense(ctx, service.ExpenseInput{
+			Date:        in.Date,
+			Amount:      in.Amount,
+			Description: in.Description,
+			Currency:    in.Currency,
+			Category:    in.Category,
+			AccountID:   in.AccountID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add expense: %w", err)
+		}
+		return warnings, nil
+	default:
+		return nil, fmt.Errorf("invalid type %q (expected income or expense)", in.Type)
+	}
+}
+
+// ListTransactions prints recorded and recurring transactions between from
+// and to inclusive. A zero from or to defaults to 30 days before or after
+// today.
+func (fa *FinanceApp) ListTransactions(from, to time.Time, asJSON bool) error {
+	ctx := fa.context()
+	today := fa.service.Today(ctx)
+	if from.IsZero() {
+		from = today.AddDate(0, 0, -30)
+	}
+	if to.IsZero() {
+		to = today.AddDate(0, 0, 30)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("--to is before --from")
+	}
+
+	transactions, err := fa.service.GetTransactionsWithRecurringsBetween(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to load transactions: %w", err)
+	}
+	if asJSON {
+		return printJSON(transactions)
+	}
+	if len(transactions) == 0 {
+		fmt.Printf("No transactions between %s and %s.\n", from.Format("2006-01-02"), to.Format("2006-01-02"))
+		return nil
+	}
+	printTransactions(transactions)
+	return nil
+}
+
+// PrintForecast prints the balance forecast for the next days days, either
+// as a chart and summary or as JSON.
+func (fa *FinanceApp) PrintForecast(days int, chart ChartOptions, asJSON bool) error {
+	ctx := fa.context()
+	startingBalance, err := fa.service.GetStartingBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get starting balance: %w", err)
+	}
+	forecast, err := fa.service.Forecast(ctx, startingBalance, days)
+	if err != nil {
+		return fmt.Errorf("failed to generate forecast: %w", err)
+	}
+	if asJSON {
+		return printJSON(forecast)
+	}
+	DisplayChart(forecast, chart)
+	DisplaySummary(forecast, startingBalance, fa.service)
+	return nil
+}
+
+// ErrBelowThreshold is returned by CheckForecast when the balance is
+// forecast to drop below the threshold.
+var ErrBelowThreshold = errors.New("forecast drops below the threshold")
+
+// CheckResult is what CheckForecast found.
+type CheckResult struct {
+	Threshold float64 `json:"threshold"`
+	Days      int     `json:"days"`
+	// Lowest is the lowest day, the first if there are several.
+	Lowest service.DailyCashFlow `json:"lowest"`
+	// FirstBelow is the first day under the threshold, if any is.
+	FirstBelow *service.DailyCashFlow `json:"first_below,omitempty"`
+	// DaysBelow counts the days under the threshold.
+	DaysBelow int `json:"days_below"`
+}
+
+// OK reports whether the balance stays at or above the threshold.
+func (r CheckResult) OK() bool {
+	return r.FirstBelow == nil
+}
+
+// checkForecast looks for the days forecast to fall under threshold.
+func checkForecast(forecast []service.DailyCashFlow, threshold float64) CheckResult {
+	r := CheckResult{Threshold: threshold, Days: len(forecast)}
+	for i, day := range forecast {
+		if i == 0 || day.Balance < r.Lowest.Balance {
+			r.Lowest = day
+		}
+		if day.Balance < threshold {
+			if r.FirstBelow == nil {
+				r.FirstBelow = &forecast[i]
+			}
+			r.DaysBelow++
+		}
+	}
+	return r
+}
+
+// CheckForecast prints whether the balance stays at or above threshold over
+// the next days days, for cron jobs and timers to alert on. It returns
+// ErrBelowThreshold if it doesn't.
+func (fa *FinanceApp) CheckForecast(days int, threshold float64, asJSON bool) error {
+	ctx := fa.context()
+	startingBalance, err := fa.service.GetStartingBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get starting balance: %w", err)
+	}
+	forecast, err := fa.service.Forecast(ctx, startingBalance, days)
+	if err != nil {
+		return fmt.Errorf("failed to generate forecast: %w", err)
+	}
+	if len(forecast) == 0 {
+		return fmt.Errorf("no forecast data available")
+	}
+
+	r := checkForecast(forecast, threshold)
+	if asJSON {
+		if err := printJSON(r); err != nil {
+			return err
+		}
+	} else {
+		const layout = "Jan 2, 2006"
+		if r.OK() {
+			fmt.Printf("OK: the balance stays at or above $%.2f for the next %d days; the lowest is $%.2f on %s.\n",
+				threshold, r.Days, r.Lowest.Balance, r.Lowest.Date.Format(layout))
+		} else {
+			fmt.Printf("ALERT: the balance drops below $%.2f on %s, to $%.2f; the lowest is $%.2f on %s, and %d of the next %d days are below.\n",
+				threshold, r.FirstBelow.Date.Format(layout), r.FirstBelow.Balance,
+				r.Lowest.Balance, r.Lowest.Date.Format(layout), r.DaysBelow, r.Days)
+		}
+	}
+	if !r.OK() {
+		return ErrBelowThreshold
+	}
+	return nil
+}
+
+// AddRecurring creates a recurring transaction.
+func (fa *FinanceApp) AddRecurring(in service.RecurringInput) error {
+	ctx := service.WithActor(fa.context(), "cli")
+	r, err := fa.service.CreateRecurringSimple(ctx, in)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ [%d] %s (%s, %s)\n", r.ID, r.Description, r.Type, r.Interval)
+	return nil
+}
+
+// ListRecurring prints every recurring with its next occurrence.
+func (fa *FinanceApp) ListRecurring(asJSON bool) error {
+	rs, err := fa.service.RecurringSummaries(fa.context())
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(rs)
+	}
+	if len(rs) == 0 {
+		fmt.Println("No recurring transactions.")
+		return nil
+	}
+	printRecurring(rs)
+	return nil
+}
+
+func printTransactions(transactions []service.ListedTransaction) {
+	t := newTable("ID", "Date", "Type", "Amount", "Description").alignRight(0, 3)
+	for _, tx := range transactions {
+		amount, _ := service.NumericToFloat64(tx.Amount)
+		if tx.Type == "expense" {
+			amount = -amount
+		}
+
+		t.add(typeTone(tx.Type), listedID(tx), tx.Date.Time.Format("Jan 02, 2006"), tx.Type, money(amount), tx.Description)
+	}
+	t.print()
+}
+
+// listedID labels a listed transaction by its ID, or a recurring occurrence
+// by its recurring's ID after an R.
+func listedID(tx service.ListedTransaction) string {
+	if tx.Source == service.SourceRecurring {
+		return fmt.Sprintf("R%d", tx.RecurringID.Int32)
+	}
+	return fmt.Sprintf("%d", tx.ID)
+}
+
+func printRecurring(rs []service.RecurringSummary) {
+	t := newTable("ID", "Active", "Type", "Amount", "Every", "Next", "Description").alignRight(0, 3)
+	for _, r := range rs {
+		amt, err := service.NumericToFloat64(r.Amount)
+		if err != nil {
+			fmt.Printf("⚠️  could not parse amount for id=%d (%q): %v; using $0.00\n",
+				r.ID, r.Description, err)
+			amt = 0
+		}
+		active, tone := "yes", typeTone(r.Type)
+		if !r.Active {
+			active, tone = "no", mutedStyle
+		}
+		next := "none"
+		if r.NextOccurrence != nil {
+			next = r.NextOccurrence.Format("2006-01-02")
+		}
+		every := string(r.Interval)
+		if r.WeekOfMonth.Valid && r.DayOfWeek.Valid {
+			ordinal := [...]string{"last", "1st", "2nd", "3rd", "4th"}[max(r.WeekOfMonth.Int32, 0)]
+			every = fmt.Sprintf("%s, %s %s", every, ordinal, time.Weekday(r.DayOfWeek.Int32).String()[:3])
+		}
+		if r.BusinessDay {
+			every += ", business days"
+		}
+		if r.Rrule.Valid {
+			every = r.Rrule.String
+		}
+		if r.RemainingOccurrences != nil {
+			every = fmt.Sprintf("%s, %d left", every, *r.RemainingOccurrences)
+		}
+		t.add(tone, fmt.Sprintf("%d", r.ID), active, r.Type, money(amt), every, next, r.Description)
+	}
+	t.print()
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+func TestCheckForecast(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	forecast := func(balances ...float64) []service.DailyCashFlow {
+		fc := make([]service.DailyCashFlow, len(balances))
+		for i, b := range balances {
+			fc[i] = service.DailyCashFlow{Date: start.AddDate(0, 0, i), Balance: b}
+		}
+		return fc
+	}
+
+	r := checkForecast(forecast(900, 600, 500, 700), 500)
+	assert.True(t, r.OK(), "the threshold itself is fine")
+	assert.Equal(t, 500.0, r.Lowest.Balance)
+	assert.Equal(t, 4, r.Days)
+
+	r = checkForecast(forecast(900, 450, 300, 300, 800, 100), 500)
+	assert.False(t, r.OK())
+	require.NotNil(t, r.FirstBelow)
+	assert.Equal(t, start.AddDate(0, 0, 1), r.FirstBelow.Date)
+	assert.Equal(t, 100.0, r.Lowest.Balance)
+	assert.Equal(t, start.AddDate(0, 0, 5), r.Lowest.Date)
+	assert.Equal(t, 4, r.DaysBelow)
+
+	r = checkForecast(forecast(300, 200, 200), 500)
+	assert.Equal(t, start.AddDate(0, 0, 1), r.Lowest.Date, "the first of equal lows")
+}
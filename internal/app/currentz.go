@@ -1,29 +1,129 @@
 package app
 
 import (
-	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/peterh/liner"
+
+	"github.com/jdelles/currentz/internal/amount"
+	"github.com/jdelles/currentz/internal/backupfile"
 	"github.com/jdelles/currentz/internal/config"
+	"github.com/jdelles/currentz/internal/dates"
+	"github.com/jdelles/currentz/internal/demo"
 	"github.com/jdelles/currentz/internal/service"
+	"github.com/jdelles/currentz/pkg/client"
+)
+
+// Service is the part of the service layer the CLI uses. It is satisfied
+// by *service.FinanceService for direct database access and by
+// *client.Client for a server reached over HTTP.
+type Service interface {
+	GetStartingBalance(ctx context.Context) (float64, error)
+	SetStartingBalance(ctx context.Context, balance float64) error
+	AddIncome(ctx context.Context, date time.Time, amount float64, description, currency string) error
+	RecordExpense(ctx context.Context, in service.ExpenseInput) (service.Transaction, []service.Warning, error)
+	GetAllTransactions(ctx context.Context) ([]service.Transaction, error)
+	DeleteTransaction(ctx context.Context, id int32) error
+	GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]service.ListedTransaction, error)
+	GetUpcomingTransactions(ctx context.Context, days int) ([]service.ListedTransaction, error)
+	Forecast(ctx context.Context, startingBalance float64, days int) ([]service.DailyCashFlow, error)
+	FindLowestPoint(forecast []service.DailyCashFlow) (service.DailyCashFlow, int)
+	Today(ctx context.Context) time.Time
+	RecurringSummaries(ctx context.Context) ([]service.RecurringSummary, error)
+	CreateRecurringSimple(ctx context.Context, in service.RecurringInput) (service.Recurring, error)
+	ImportRecurring(ctx context.Context, inputs []service.RecurringInput) ([]service.Recurring, error)
+	DeleteRecurring(ctx context.Context, id int32) error
+	SetRecurringActive(ctx context.Context, id int32, active bool) error
+	ReanchorRecurring(ctx context.Context, id int32, in service.ReanchorInput) (service.Recurring, error)
+	ImportTransactions(ctx context.Context, format string, r io.Reader, dryRun bool) (service.ImportResult, error)
+	ExportTransactions(ctx context.Context, format string, w io.Writer, start, end *time.Time) error
+	Backup(ctx context.Context) (service.Backup, error)
+	Restore(ctx context.Context, b service.Backup) (service.RestoreSummary, error)
+	DryRun(ctx context.Context, fn func(context.Context) error) error
+	Close() error
+}
+
+var (
+	_ Service = (*service.FinanceService)(nil)
+	_ Service = (*client.Client)(nil)
 )
 
 type FinanceApp struct {
-	service *service.FinanceService
+	service Service
+	// ctx is what commands run under: a DryRun's context while one is
+	// going, or nil.
+	ctx context.Context
 }
 
+// context is the context commands start from.
+func (fa *FinanceApp) context() context.Context {
+	if fa.ctx != nil {
+		return fa.ctx
+	}
+	return context.Background()
+}
+
+// DryRun runs fn with every change it makes thrown away afterwards, though
+// it is checked and reported as usual. Against a server, each change is
+// sent as a dry run of its own.
+func (fa *FinanceApp) DryRun(fn func() error) error {
+	return fa.service.DryRun(fa.context(), func(ctx context.Context) error {
+		prev := fa.ctx
+		fa.ctx = ctx
+		defer func() { fa.ctx = prev }()
+		return fn()
+	})
+}
+
+// NewFinanceApp connects to the database, or to the server's HTTP API when
+// cfg.RemoteURL is set, or to a store of sample data when cfg.Demo is. A
+// server has setting defaults of its own, so cfg.Defaults only apply to the
+// database and demo data.
 func NewFinanceApp(cfg *config.Config) (*FinanceApp, error) {
+	if cfg.Demo {
+		svc, err := demo.Open(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		if err := svc.SetSettingDefaults(cfg.Defaults); err != nil {
+			return nil, err
+		}
+		return &FinanceApp{service: svc}, nil
+	}
+	if cfg.RemoteURL != "" {
+		c, err := client.New(cfg.RemoteURL, client.Options{APIKey: cfg.APIKey, Actor: "cli"})
+		if err != nil {
+			return nil, err
+		}
+		return &FinanceApp{service: c}, nil
+	}
+
 	ctx := context.Background()
 	svc, err := service.NewFinanceServiceFromURL(ctx, cfg.DatabaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init service: %w", err)
 	}
+	if err := svc.SetSettingDefaults(cfg.Defaults); err != nil {
+		_ = svc.Close()
+		return nil, err
+	}
+	if cfg.EncryptionKey != nil {
+		if err := svc.SetEncryptionKey(cfg.EncryptionKey); err != nil {
+			_ = svc.Close()
+			return nil, err
+		}
+	}
 	return &FinanceApp{service: svc}, nil
 }
 
@@ -31,7 +131,9 @@ func (fa *FinanceApp) Run() error {
 	fmt.Println("💵 Personal Finance Cash Flow Forecaster")
 	fmt.Println("========================================")
 
-	ctx := context.Background()
+	ctx := service.WithActor(fa.context(), "cli")
+	openLine()
+	defer closeLine()
 
 	startingBalance, err := fa.service.GetStartingBalance(ctx)
 	if err != nil {
@@ -49,6 +151,229 @@ func (fa *FinanceApp) Run() error {
 	return fa.mainLoop(ctx)
 }
 
+// ImportRecurringFile bulk-creates recurring transactions from a CSV or
+// JSON file (chosen by extension) and prints what was created.
+func (fa *FinanceApp) ImportRecurringFile(path string) error {
+	ctx := service.WithActor(fa.context(), "cli")
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	var inputs []service.RecurringInput
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		inputs, err = service.ParseRecurringJSON(f)
+	} else {
+		inputs, err = service.ParseRecurringCSV(f)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid import file %s:\n%w", path, err)
+	}
+
+	created, err := fa.service.ImportRecurring(ctx, inputs)
+	for _, r := range created {
+		fmt.Printf("✅ [%d] %s (%s, %s)\n", r.ID, r.Description, r.Type, r.Interval)
+	}
+	if err != nil {
+		return fmt.Errorf("import stopped after %d of %d: %w", len(created), len(inputs), err)
+	}
+	fmt.Printf("Imported %d recurring transactions.\n", len(created))
+	return nil
+}
+
+// ImportTransactionsFile reads a QIF, YNAB or Mint file and prints the
+// transactions it contains. Nothing is saved unless commit is true.
+func (fa *FinanceApp) ImportTransactionsFile(path, format string, commit bool) error {
+	ctx := service.WithActor(fa.context(), "cli")
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	result, err := fa.service.ImportTransactions(ctx, format, f, !commit)
+	if len(result.Transactions) > 0 {
+		t := newTable("Date", "Amount", "Description", "Category").alignRight(1)
+		for _, tx := range result.Transactions {
+			amt, _ := service.NumericToFloat64(tx.Amount)
+			t.add(typeTone(tx.Type), tx.Date.Time.Format("2006-01-02"), fmt.Sprintf("%.2f", amt), tx.Description, tx.Category.String)
+		}
+		t.print()
+	}
+	if err != nil {
+		return err
+	}
+	if !commit {
+		fmt.Printf("Preview: %d transactions from %d entries. Re-run with --commit to import.\n", len(result.Transactions), result.Entries)
+		return nil
+	}
+	fmt.Printf("Imported %d transactions.\n", len(result.Transactions))
+	return nil
+}
+
+// ExportTransactionsFile writes every transaction as YNAB or Mint CSV to
+// path, or to stdout when path is empty.
+func (fa *FinanceApp) ExportTransactionsFile(format, path string) error {
+	ctx := fa.context()
+	if path == "" {
+		return fa.service.ExportTransactions(ctx, format, os.Stdout, nil, nil)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := fa.service.ExportTransactions(ctx, format, f, nil, nil); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported transactions to %s\n", path)
+	return nil
+}
+
+// BackupToFile writes an encrypted backup of all data to path.
+func (fa *FinanceApp) BackupToFile(path, passphrase string) error {
+	ctx := fa.context()
+
+	b, err := fa.service.Backup(ctx)
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	sealed, err := backupfile.Seal(plaintext, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, sealed, 0o600); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Backed up %d transactions and %d recurring to %s\n", len(b.Transactions), len(b.Recurring), path)
+	return nil
+}
+
+// SeedSampleData fills an empty database with a profile's made-up
+// history, for development and screenshots. It needs direct database
+// access, and refuses a database that already holds transactions or
+// recurrings so real data is never mixed with sample data.
+func (fa *FinanceApp) SeedSampleData(opts demo.Options) error {
+	ctx := service.WithActor(fa.context(), "seed")
+
+	svc, ok := fa.service.(*service.FinanceService)
+	if !ok {
+		return fmt.Errorf("seed needs direct database access; it can't run with --remote")
+	}
+	txs, err := svc.GetAllTransactions(ctx)
+	if err != nil {
+		return err
+	}
+	recs, err := svc.RecurringSummaries(ctx)
+	if err != nil {
+		return err
+	}
+	if len(txs) > 0 || len(recs) > 0 {
+		return fmt.Errorf("database already has %d transactions and %d recurring; seed only fills an empty one", len(txs), len(recs))
+	}
+
+	if err := demo.Seed(ctx, svc, opts); err != nil {
+		return err
+	}
+	if txs, err = svc.GetAllTransactions(ctx); err != nil {
+		return err
+	}
+	if recs, err = svc.RecurringSummaries(ctx); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Seeded %d transactions and %d recurring\n", len(txs), len(recs))
+	return nil
+}
+
+// AddUser creates a user who can log in to the server's web UI, as a
+// member of a household. Without a role, the first user is an owner and
+// later ones viewers. It needs direct database access.
+func (fa *FinanceApp) AddUser(username, password, role string, household int32) error {
+	ctx := service.WithHousehold(service.WithActor(fa.context(), "cli"), household)
+	svc, ok := fa.service.(*service.FinanceService)
+	if !ok {
+		return fmt.Errorf("managing users needs direct database access; it can't run with --remote")
+	}
+	if role == "" {
+		users, err := svc.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		role = service.RoleViewer
+		if len(users) == 0 {
+			role = service.RoleOwner
+		}
+	}
+	u, err := svc.CreateUser(ctx, username, password, role)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ [%d] %s (%s of household %d)\n", u.ID, u.Username, u.Role, household)
+	return nil
+}
+
+// ListUsers prints the users who can log in.
+func (fa *FinanceApp) ListUsers(asJSON bool) error {
+	svc, ok := fa.service.(*service.FinanceService)
+	if !ok {
+		return fmt.Errorf("managing users needs direct database access; it can't run with --remote")
+	}
+	users, err := svc.ListUsers(fa.context())
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(users)
+	}
+	if len(users) == 0 {
+		fmt.Println("No users.")
+		return nil
+	}
+	t := newTable("ID", "Username", "Role", "Created").alignRight(0)
+	for _, u := range users {
+		t.add(lipgloss.NewStyle(), fmt.Sprintf("%d", u.ID), u.Username, u.Role, u.CreatedAt.Format("Jan 02, 2006"))
+	}
+	t.print()
+	return nil
+}
+
+// RestoreFromFile replaces all data with an encrypted backup from path.
+func (fa *FinanceApp) RestoreFromFile(path, passphrase string) error {
+	ctx := service.WithActor(fa.context(), "cli")
+
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plaintext, err := backupfile.Open(sealed, passphrase)
+	if err != nil {
+		return err
+	}
+	var b service.Backup
+	if err := json.Unmarshal(plaintext, &b); err != nil {
+		return fmt.Errorf("decode backup: %w", err)
+	}
+	summary, err := fa.service.Restore(ctx, b)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Restored %d transactions, %d recurring, %d settings from %s (taken %s)\n",
+		summary.Transactions, summary.Recurring, summary.Settings, path, b.CreatedAt.Format("2006-01-02 15:04"))
+	return nil
+}
+
 func (fa *FinanceApp) Close() error {
 	if fa.service != nil {
 		if err := fa.service.Close(); err != nil {
@@ -60,7 +385,7 @@ func (fa *FinanceApp) Close() error {
 
 func (fa *FinanceApp) setupStartingBalance(ctx context.Context) error {
 	balanceStr := getUserInput("Enter your current account balance: $")
-	balance, err := strconv.ParseFloat(balanceStr, 64)
+	balance, err := amount.Parse(balanceStr)
 	if err != nil {
 		fmt.Println("Invalid balance. Using $0.")
 		balance = 0
@@ -81,7 +406,11 @@ func (fa *FinanceApp) mainLoop(ctx context.Context) error {
 		fmt.Println("7. Update Starting Balance")
 		fmt.Println("8. Exit")
 
-		choice := getUserInput("Choose an option (1-8): ")
+		choice, err := readLine("Choose an option (1-8): ")
+		if errors.Is(err, io.EOF) || errors.Is(err, liner.ErrPromptAborted) {
+			fmt.Println("\nGoodbye!")
+			return nil
+		}
 
 		switch choice {
 		case "1":
@@ -122,54 +451,72 @@ func (fa *FinanceApp) mainLoop(ctx context.Context) error {
 }
 
 func (fa *FinanceApp) addIncome(ctx context.Context) error {
-	dateStr := getUserInput("Enter date (YYYY-MM-DD or MM/DD/YYYY): ")
-	date, err := parseDate(dateStr)
+	dateStr := getUserInput("Enter date (YYYY-MM-DD, MM/DD/YYYY, today, +3d...): ")
+	date, err := fa.ParseDate(dateStr)
 	if err != nil {
 		return fmt.Errorf("error parsing date: %w", err)
 	}
 
 	amountStr := getUserInput("Enter income amount: $")
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	amount, err := amount.Parse(amountStr)
 	if err != nil {
-		return fmt.Errorf("invalid amount: %w", err)
+		return err
 	}
 
 	description := getUserInput("Enter description: ")
-
-	if err := fa.service.AddIncome(ctx, date, amount, description); err != nil {
-		return fmt.Errorf("failed to add income: %w", err)
-	}
-
-	fmt.Printf("✅ Added income: $%.2f on %s\n", amount, date.Format("Jan 2, 2006"))
-	return nil
+	currency := getUserInput("Enter currency (blank for base currency): ")
+
+	return fa.addTransaction(ctx, TransactionInput{
+		Type:        "income",
+		Date:        date,
+		Amount:      amount,
+		Description: description,
+		Currency:    currency,
+	})
 }
 
 func (fa *FinanceApp) addExpense(ctx context.Context) error {
-	dateStr := getUserInput("Enter date (YYYY-MM-DD or MM/DD/YYYY): ")
-	date, err := parseDate(dateStr)
+	dateStr := getUserInput("Enter date (YYYY-MM-DD, MM/DD/YYYY, today, +3d...): ")
+	date, err := fa.ParseDate(dateStr)
 	if err != nil {
 		return fmt.Errorf("error parsing date: %w", err)
 	}
 
 	amountStr := getUserInput("Enter expense amount: $")
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	amount, err := amount.Parse(amountStr)
 	if err != nil {
-		return fmt.Errorf("invalid amount: %w", err)
+		return err
 	}
 
 	description := getUserInput("Enter description: ")
+	currency := getUserInput("Enter currency (blank for base currency): ")
+	category := getUserInput("Enter category (optional): ")
 
-	if err := fa.service.AddExpense(ctx, date, amount, description); err != nil {
-		return fmt.Errorf("failed to add expense: %w", err)
-	}
-
-	fmt.Printf("✅ Added expense: $%.2f on %s\n", amount, date.Format("Jan 2, 2006"))
-	return nil
+	var accountID *int32
+	if accountStr := getUserInput("Enter account ID, e.g. a credit card (blank for cash): "); accountStr != "" {
+		id, err := strconv.ParseInt(accountStr, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		id32 := int32(id)
+		accountID = &id32
+	}
+
+	return fa.addTransaction(ctx, TransactionInput{
+		Type:        "expense",
+		Date:        date,
+		Amount:      amount,
+		Description: description,
+		Currency:    currency,
+		Category:    category,
+		AccountID:   accountID,
+	})
 }
 
 func (fa *FinanceApp) viewTransactions(ctx context.Context) error {
-	start := time.Now().AddDate(0, 0, -30).Truncate(24 * time.Hour)
-	end := time.Now().AddDate(0, 0, 30).Truncate(24 * time.Hour)
+	today := fa.service.Today(ctx)
+	start := today.AddDate(0, 0, -30)
+	end := today.AddDate(0, 0, 30)
 
 	transactions, err := fa.service.GetTransactionsWithRecurringsBetween(ctx, start, end)
 	if err != nil {
@@ -183,30 +530,7 @@ func (fa *FinanceApp) viewTransactions(ctx context.Context) error {
 
 	fmt.Println("\n📋 Transactions (Past 30 days → Next 30 days)")
 	fmt.Println("=" + strings.Repeat("=", 70))
-
-	for _, tx := range transactions {
-		symbol := "💰"
-		amount, _ := service.NumericToFloat64(tx.Amount)
-		displayAmount := amount
-
-		if tx.Type == "expense" {
-			symbol = "💸"
-			displayAmount = -amount
-		}
-
-		id := tx.ID
-		idLabel := fmt.Sprintf("%d", id)
-		if id == 0 {
-			idLabel = "R"
-		}
-
-		fmt.Printf("[%s] %s %s | $%8.2f | %s\n",
-			idLabel,
-			symbol,
-			tx.Date.Time.Format("Jan 02, 2006"),
-			displayAmount,
-			tx.Description)
-	}
+	printTransactions(transactions)
 	return nil
 }
 
@@ -235,7 +559,7 @@ func (fa *FinanceApp) deleteTransaction(ctx context.Context) error {
 		return fmt.Errorf("failed to delete transaction: %w", err)
 	}
 
-	fmt.Printf("✅ Transaction %d deleted successfully.\n", id)
+	fmt.Printf("✅ Transaction %d moved to trash.\n", id)
 	return nil
 }
 
@@ -245,12 +569,12 @@ func (fa *FinanceApp) generateForecast(ctx context.Context) error {
 		return fmt.Errorf("failed to get starting balance: %w", err)
 	}
 
-	forecast, err := fa.service.Calculate90DayForecast(ctx, startingBalance)
+	forecast, err := fa.service.Forecast(ctx, startingBalance, 90)
 	if err != nil {
 		return fmt.Errorf("failed to generate forecast: %w", err)
 	}
 
-	DisplayChart(forecast)
+	DisplayChart(forecast, ChartOptions{})
 	DisplaySummary(forecast, startingBalance, fa.service)
 
 	// Show upcoming transactions
@@ -271,25 +595,22 @@ func (fa *FinanceApp) generateForecast(ctx context.Context) error {
 		return upcoming[i].Date.Time.Before(upcoming[j].Date.Time)
 	})
 
-	today := time.Now()
+	today := fa.service.Today(ctx)
+	t := newTable("Date", "In", "Amount", "Description").alignRight(1, 2)
 	for _, tx := range upcoming {
-		symbol := "💰"
 		amount, _ := service.NumericToFloat64(tx.Amount)
-		displayAmount := amount
-
 		if tx.Type == "expense" {
-			symbol = "💸"
-			displayAmount = -amount
+			amount = -amount
 		}
 
 		daysFromNow := int(tx.Date.Time.Sub(today).Hours() / 24)
-		fmt.Printf("%s %s (%2d days) | $%10.2f | %s\n",
-			symbol,
+		t.add(typeTone(tx.Type),
 			tx.Date.Time.Format("Jan 02"),
-			daysFromNow,
-			displayAmount,
+			fmt.Sprintf("%d days", daysFromNow),
+			money(amount),
 			tx.Description)
 	}
+	t.print()
 
 	return nil
 }
@@ -300,11 +621,13 @@ func (fa *FinanceApp) manageRecurring(ctx context.Context) error {
 	fmt.Println("2. Add")
 	fmt.Println("3. Delete")
 	fmt.Println("4. Toggle Active")
-	choice := getUserInput("Choose (1-4): ")
+	fmt.Println("5. Import from File (CSV/JSON)")
+	fmt.Println("6. Shift Weekly/Biweekly to Another Week")
+	choice := getUserInput("Choose (1-6): ")
 
 	switch choice {
 	case "1":
-		rs, err := fa.service.ListRecurring(ctx)
+		rs, err := fa.service.RecurringSummaries(ctx)
 		if err != nil {
 			return err
 		}
@@ -312,33 +635,19 @@ func (fa *FinanceApp) manageRecurring(ctx context.Context) error {
 			fmt.Println("No recurring transactions.")
 			return nil
 		}
-		for _, r := range rs {
-			active := "✅"
-			if !r.Active {
-				active = "❌"
-			}
-			amt, err := service.NumericToFloat64(r.Amount)
-			if err != nil {
-				fmt.Printf("⚠️  could not parse amount for id=%d (%q): %v; using $0.00\n",
-					r.ID, r.Description, err)
-				amt = 0
-			}
-			freq := string(r.Interval)
-			fmt.Printf("[%2d] %s | %-7s | $%10.2f | %-9s | start %s | %s\n",
-				r.ID, active, r.Type, amt, freq, r.StartDate.Time.Format("2006-01-02"), r.Description)
-		}
+		printRecurring(rs)
 	case "2":
 		desc := getUserInput("Description: ")
 		typ := strings.ToLower(getUserInput("Type (income/expense): "))
 
-		amtStr := getUserInput("Amount (e.g., 1500.00): ")
-		amt, err := strconv.ParseFloat(amtStr, 64)
+		amtStr := getUserInput(fmt.Sprintf("Amount (e.g., %s): ", amount.Default().Example()))
+		amt, err := amount.Parse(amtStr)
 		if err != nil {
-			return fmt.Errorf("invalid amount: %w", err)
+			return err
 		}
 
-		startStr := getUserInput("Start date (YYYY-MM-DD): ")
-		start, err := parseDate(startStr)
+		startStr := getUserInput("Start date (YYYY-MM-DD, today, next friday...): ")
+		start, err := fa.ParseDate(startStr)
 		if err != nil {
 			return fmt.Errorf("invalid start date: %w", err)
 		}
@@ -371,7 +680,7 @@ func (fa *FinanceApp) manageRecurring(ctx context.Context) error {
 		var end *time.Time
 		endStr := strings.TrimSpace(getUserInput("End date (YYYY-MM-DD, blank = none): "))
 		if endStr != "" {
-			e, err := parseDate(endStr)
+			e, err := fa.ParseDate(endStr)
 			if err != nil {
 				return fmt.Errorf("invalid end date: %w", err)
 			}
@@ -410,35 +719,32 @@ func (fa *FinanceApp) manageRecurring(ctx context.Context) error {
 			return err
 		}
 		fmt.Println("✅ Updated.")
+	case "5":
+		path := getUserInput("Path to CSV or JSON file: ")
+		return fa.ImportRecurringFile(path)
+	case "6":
+		idStr := getUserInput("ID to shift: ")
+		id, _ := strconv.Atoi(idStr)
+		weeksStr := getUserInput("Shift by how many weeks (e.g., 1 or -1): ")
+		weeks, err := strconv.Atoi(strings.TrimSpace(weeksStr))
+		if err != nil || weeks == 0 {
+			return fmt.Errorf("invalid week count: %q", weeksStr)
+		}
+		rec, err := fa.service.ReanchorRecurring(ctx, int32(id), service.ReanchorInput{ShiftWeeks: weeks})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Now anchored on %s.\n", rec.StartDate.Time.Format("2006-01-02"))
 	default:
 		fmt.Println("Cancelled.")
 	}
 	return nil
 }
 
-func parseDate(input string) (time.Time, error) {
-	formats := []string{
-		"2006-01-02",
-		"01/02/2006",
-		"1/2/2006",
-		"Jan 2, 2006",
-		"January 2, 2006",
-	}
-
-	for _, format := range formats {
-		if date, err := time.Parse(format, input); err == nil {
-			return date, nil
-		}
-	}
-
-	return time.Time{}, fmt.Errorf("unable to parse date: %s", input)
-}
-
-func getUserInput(prompt string) string {
-	fmt.Print(prompt)
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	return strings.TrimSpace(scanner.Text())
+// ParseDate reads a date as typed at the CLI: 2006-01-02, 01/02/2006, or a
+// shortcut such as "tomorrow", "next friday" or "+3d" from the user's today.
+func (fa *FinanceApp) ParseDate(input string) (time.Time, error) {
+	return dates.Parse(input, func() time.Time { return fa.service.Today(fa.context()) })
 }
 
 func (fa *FinanceApp) updateStartingBalance(ctx context.Context) error {
@@ -450,7 +756,7 @@ func (fa *FinanceApp) updateStartingBalance(ctx context.Context) error {
 	fmt.Printf("Current starting balance: $%.2f\n", currentBalance)
 
 	balanceStr := getUserInput("Enter new starting balance: $")
-	balance, err := strconv.ParseFloat(balanceStr, 64)
+	balance, err := amount.Parse(balanceStr)
 	if err != nil {
 		return fmt.Errorf("invalid balance: %w", err)
 	}
@@ -463,61 +769,7 @@ func (fa *FinanceApp) updateStartingBalance(ctx context.Context) error {
 	return nil
 }
 
-func DisplayChart(forecast []service.DailyCashFlow) {
-	fmt.Println("\n📊 90-Day Cash Flow Forecast")
-	fmt.Println("=" + strings.Repeat("=", 60))
-
-	if len(forecast) == 0 {
-		fmt.Println("No forecast data available.")
-		return
-	}
-
-	minBalance := forecast[0].Balance
-	maxBalance := forecast[0].Balance
-	for _, day := range forecast {
-		if day.Balance < minBalance {
-			minBalance = day.Balance
-		}
-		if day.Balance > maxBalance {
-			maxBalance = day.Balance
-		}
-	}
-
-	fmt.Printf("Balance Range: $%.2f to $%.2f\n\n", minBalance, maxBalance)
-
-	chartWidth := 30
-
-	rangeSpan := maxBalance - minBalance
-	if rangeSpan == 0 {
-		rangeSpan = 1
-	}
-
-	for i := 0; i < len(forecast); i += 7 {
-		day := forecast[i]
-
-		ratio := (day.Balance - minBalance) / rangeSpan
-		if ratio < 0 {
-			ratio = 0
-		}
-		if ratio > 1 {
-			ratio = 1
-		}
-		fill := int(ratio*float64(chartWidth) + 0.5)
-		if fill > chartWidth {
-			fill = chartWidth
-		}
-
-		bar := strings.Repeat("█", fill) + strings.Repeat(".", chartWidth-fill)
-
-		fmt.Printf("%s │%s│ $%10.2f\n",
-			day.Date.Format("Jan 02"),
-			bar,
-			day.Balance,
-		)
-	}
-}
-
-func DisplaySummary(forecast []service.DailyCashFlow, startingBalance float64, fs *service.FinanceService) {
+func DisplaySummary(forecast []service.DailyCashFlow, startingBalance float64, fs Service) {
 	if len(forecast) == 0 {
 		fmt.Println("No forecast data available.")
 		return
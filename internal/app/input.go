@@ -0,0 +1,91 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+// line edits prompt input while the interactive menu runs: arrow keys,
+// Ctrl-R search, and history kept across sessions. Outside Run it is nil
+// and prompts read plain lines from stdin.
+var (
+	line  *liner.State
+	stdin = bufio.NewReader(os.Stdin)
+)
+
+// historyFile is where prompt history is kept between sessions.
+func historyFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".currentz_history")
+}
+
+// openLine starts line editing and loads the saved history.
+func openLine() {
+	line = liner.NewLiner()
+	line.SetCtrlCAborts(true)
+	if f, err := os.Open(historyFile()); err == nil {
+		_, _ = line.ReadHistory(f)
+		_ = f.Close()
+	}
+}
+
+// closeLine saves the history and gives the terminal back.
+func closeLine() {
+	if line == nil {
+		return
+	}
+	if path := historyFile(); path != "" {
+		if f, err := os.Create(path); err == nil {
+			_, _ = line.WriteHistory(f)
+			_ = f.Close()
+		}
+	}
+	_ = line.Close()
+	line = nil
+}
+
+// readLine prompts for one line. It fails with io.EOF once input ends and
+// with liner.ErrPromptAborted on Ctrl-C.
+func readLine(prompt string) (string, error) {
+	if line == nil {
+		fmt.Print(prompt)
+		s, err := stdin.ReadString('\n')
+		if s != "" {
+			err = nil
+		}
+		return strings.TrimSpace(s), err
+	}
+
+	// liner rejects control characters in the prompt itself.
+	trimmed := strings.TrimLeft(prompt, "\n")
+	fmt.Print(prompt[:len(prompt)-len(trimmed)])
+	s, err := line.Prompt(trimmed)
+	if err != nil {
+		return "", err
+	}
+	s = strings.TrimSpace(s)
+	if s != "" {
+		line.AppendHistory(s)
+	}
+	return s, nil
+}
+
+func getUserInput(prompt string) string {
+	s, err := readLine(prompt)
+	if errors.Is(err, liner.ErrPromptAborted) {
+		// Ctrl-C quits, as it did before prompts put the terminal in raw
+		// mode.
+		closeLine()
+		os.Exit(130)
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/muesli/termenv"
+)
+
+var (
+	headerStyle  = lipgloss.NewStyle().Bold(true)
+	incomeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	expenseStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	mutedStyle   = lipgloss.NewStyle().Faint(true)
+)
+
+// SetColor turns colored output on or off. Color is already off when
+// stdout isn't a terminal or NO_COLOR is set; this is for forcing it off
+// regardless, as scripts do with --no-color.
+func SetColor(enabled bool) {
+	if !enabled {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// textTable lines rows up under a header row. Columns marked right-aligned
+// hold amounts; a row's tone colors every cell in it.
+type textTable struct {
+	headers []string
+	right   map[int]bool
+	rows    [][]string
+	tones   []lipgloss.Style
+}
+
+func newTable(headers ...string) *textTable {
+	return &textTable{headers: headers, right: map[int]bool{}}
+}
+
+func (t *textTable) alignRight(cols ...int) *textTable {
+	for _, c := range cols {
+		t.right[c] = true
+	}
+	return t
+}
+
+func (t *textTable) add(tone lipgloss.Style, cells ...string) {
+	t.rows = append(t.rows, cells)
+	t.tones = append(t.tones, tone)
+}
+
+func (t *textTable) String() string {
+	tbl := table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderTop(false).BorderBottom(false).BorderLeft(false).BorderRight(false).
+		BorderColumn(false).
+		BorderStyle(mutedStyle).
+		Headers(t.headers...).
+		Rows(t.rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			s := lipgloss.NewStyle()
+			if row == table.HeaderRow {
+				s = headerStyle
+			} else if row >= 0 && row < len(t.tones) {
+				s = t.tones[row]
+			}
+			s = s.PaddingRight(2)
+			if t.right[col] {
+				s = s.Align(lipgloss.Right)
+			}
+			return s
+		})
+	// Trailing padding on the last column is only noise in a pipe.
+	lines := strings.Split(tbl.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *textTable) print() {
+	fmt.Println(t.String())
+}
+
+// typeTone colors a row by transaction type.
+func typeTone(txType string) lipgloss.Style {
+	switch txType {
+	case "income":
+		return incomeStyle
+	case "expense":
+		return expenseStyle
+	}
+	return lipgloss.NewStyle()
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("$%.2f", v)
+}
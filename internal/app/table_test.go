@@ -0,0 +1,24 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextTableAlignsColumns(t *testing.T) {
+	SetColor(false)
+
+	tbl := newTable("ID", "Amount", "Description").alignRight(0, 1)
+	tbl.add(typeTone("expense"), "7", money(-12.5), "A rather long description")
+	tbl.add(typeTone("income"), "123", money(2500), "Pay")
+
+	lines := strings.Split(tbl.String(), "\n")
+	assert.Equal(t, []string{
+		" ID    Amount  Description",
+		strings.Repeat("─", 42),
+		"  7   $-12.50  A rather long description",
+		"123  $2500.00  Pay",
+	}, lines)
+}
@@ -0,0 +1,567 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jdelles/currentz/internal/amount"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// RunTUI starts the full-screen dashboard. It offers the same actions as
+// the numbered menu in Run.
+func (fa *FinanceApp) RunTUI() error {
+	p := tea.NewProgram(newTUIModel(fa), tea.WithAltScreen())
+	_, err := p.Run()
+	return err
+}
+
+type tuiView int
+
+const (
+	viewTransactions tuiView = iota
+	viewForecast
+	viewRecurring
+)
+
+var tuiViewNames = []string{"Transactions", "Forecast", "Recurring"}
+
+var (
+	tuiTitleStyle     = lipgloss.NewStyle().Bold(true)
+	tuiTabStyle       = lipgloss.NewStyle().Padding(0, 1)
+	tuiActiveTabStyle = tuiTabStyle.Reverse(true)
+	tuiHelpStyle      = lipgloss.NewStyle().Faint(true)
+	tuiErrorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	tuiWarnStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	tuiIncomeStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	tuiExpenseStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// tuiDataMsg carries everything the dashboard shows, loaded in one go.
+type tuiDataMsg struct {
+	txs      []service.ListedTransaction
+	recs     []service.RecurringSummary
+	forecast []service.DailyCashFlow
+	balance  float64
+	err      error
+}
+
+// tuiDoneMsg reports the outcome of an add, delete or toggle.
+type tuiDoneMsg struct {
+	status string
+	err    error
+}
+
+// tuiForm is a column of labelled text inputs, each given as a label and
+// an initial value. submit turns the values into a command, or reports
+// what's wrong with them.
+type tuiForm struct {
+	title  string
+	labels []string
+	inputs []textinput.Model
+	focus  int
+	err    error
+	submit func(values []string) (tea.Cmd, error)
+}
+
+func newTUIForm(title string, fields [][2]string, submit func([]string) (tea.Cmd, error)) *tuiForm {
+	f := &tuiForm{title: title, submit: submit}
+	for i, field := range fields {
+		in := textinput.New()
+		in.SetValue(field[1])
+		in.Width = 40
+		if i == 0 {
+			in.Focus()
+		}
+		f.labels = append(f.labels, field[0])
+		f.inputs = append(f.inputs, in)
+	}
+	return f
+}
+
+func (f *tuiForm) move(delta int) {
+	f.inputs[f.focus].Blur()
+	f.focus = (f.focus + delta + len(f.inputs)) % len(f.inputs)
+	f.inputs[f.focus].Focus()
+}
+
+func (f *tuiForm) values() []string {
+	out := make([]string, len(f.inputs))
+	for i, in := range f.inputs {
+		out[i] = strings.TrimSpace(in.Value())
+	}
+	return out
+}
+
+func (f *tuiForm) View() string {
+	var b strings.Builder
+	b.WriteString(tuiTitleStyle.Render(f.title) + "\n\n")
+	for i, in := range f.inputs {
+		fmt.Fprintf(&b, "%-12s %s\n", f.labels[i], in.View())
+	}
+	if f.err != nil {
+		b.WriteString("\n" + tuiErrorStyle.Render(f.err.Error()) + "\n")
+	}
+	b.WriteString("\n" + tuiHelpStyle.Render("tab/↓ next • shift+tab/↑ previous • enter save • esc cancel"))
+	return b.String()
+}
+
+type tuiModel struct {
+	fa            *FinanceApp
+	ctx           context.Context
+	view          tuiView
+	width, height int
+
+	txTable  table.Model
+	recTable table.Model
+	txs      []service.ListedTransaction
+	recs     []service.RecurringSummary
+	forecast []service.DailyCashFlow
+	balance  float64
+
+	form      *tuiForm
+	confirm   string
+	onConfirm tea.Cmd
+	status    string
+	err       error
+}
+
+func newTUIModel(fa *FinanceApp) tuiModel {
+	styles := table.DefaultStyles()
+	styles.Header = styles.Header.Bold(true).BorderStyle(lipgloss.NormalBorder()).BorderBottom(true)
+	styles.Selected = styles.Selected.Reverse(true).Foreground(lipgloss.NoColor{})
+
+	txTable := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "ID", Width: 5},
+			{Title: "Date", Width: 10},
+			{Title: "Type", Width: 7},
+			{Title: "Amount", Width: 12},
+			{Title: "Description", Width: 36},
+		}),
+		table.WithFocused(true),
+		table.WithStyles(styles),
+	)
+	recTable := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "ID", Width: 4},
+			{Title: "On", Width: 3},
+			{Title: "Type", Width: 7},
+			{Title: "Amount", Width: 12},
+			{Title: "Interval", Width: 9},
+			{Title: "Next", Width: 10},
+			{Title: "Description", Width: 30},
+		}),
+		table.WithFocused(true),
+		table.WithStyles(styles),
+	)
+	return tuiModel{
+		fa:       fa,
+		ctx:      service.WithActor(fa.context(), "cli"),
+		txTable:  txTable,
+		recTable: recTable,
+	}
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return m.load()
+}
+
+// load fetches transactions from 30 days back to 30 days ahead, the
+// forecast and the recurrings.
+func (m tuiModel) load() tea.Cmd {
+	fa, ctx := m.fa, m.ctx
+	return func() tea.Msg {
+		var msg tuiDataMsg
+		today := fa.service.Today(ctx)
+		if msg.txs, msg.err = fa.service.GetTransactionsWithRecurringsBetween(ctx, today.AddDate(0, 0, -30), today.AddDate(0, 0, 30)); msg.err != nil {
+			return msg
+		}
+		if msg.balance, msg.err = fa.service.GetStartingBalance(ctx); msg.err != nil {
+			return msg
+		}
+		if msg.forecast, msg.err = fa.service.Forecast(ctx, msg.balance, 90); msg.err != nil {
+			return msg
+		}
+		msg.recs, msg.err = fa.service.RecurringSummaries(ctx)
+		return msg
+	}
+}
+
+// run performs a change in the background and reports it as a tuiDoneMsg.
+func (m tuiModel) run(status string, fn func(ctx context.Context) error) tea.Cmd {
+	ctx := m.ctx
+	return func() tea.Msg {
+		if err := fn(ctx); err != nil {
+			return tuiDoneMsg{err: err}
+		}
+		return tuiDoneMsg{status: status}
+	}
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		h := max(msg.Height-8, 3)
+		m.txTable.SetHeight(h)
+		m.recTable.SetHeight(h)
+		return m, nil
+
+	case tuiDataMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.txs, m.recs, m.forecast, m.balance = msg.txs, msg.recs, msg.forecast, msg.balance
+			m.txTable.SetRows(transactionRows(m.txs))
+			m.recTable.SetRows(recurringRows(m.recs))
+		}
+		return m, nil
+
+	case tuiDoneMsg:
+		m.status, m.err = msg.status, msg.err
+		return m, m.load()
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.form != nil {
+			return m.updateForm(msg)
+		}
+		if m.confirm != "" {
+			cmd := m.onConfirm
+			m.confirm, m.onConfirm = "", nil
+			if msg.String() == "y" {
+				return m, cmd
+			}
+			m.status = "Cancelled."
+			return m, nil
+		}
+		return m.updateKeys(msg)
+	}
+	return m, nil
+}
+
+func (m tuiModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := m.form
+	switch msg.String() {
+	case "esc":
+		m.form = nil
+		return m, nil
+	case "tab", "down":
+		f.move(1)
+		return m, nil
+	case "shift+tab", "up":
+		f.move(-1)
+		return m, nil
+	case "enter":
+		cmd, err := f.submit(f.values())
+		if err != nil {
+			f.err = err
+			return m, nil
+		}
+		m.form = nil
+		return m, cmd
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return m, cmd
+}
+
+func (m tuiModel) updateKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "tab":
+		m.view = (m.view + 1) % tuiView(len(tuiViewNames))
+		return m, nil
+	case "shift+tab":
+		m.view = (m.view + tuiView(len(tuiViewNames)) - 1) % tuiView(len(tuiViewNames))
+		return m, nil
+	case "1", "2", "3":
+		m.view = tuiView(msg.String()[0] - '1')
+		return m, nil
+	case "r":
+		return m, m.load()
+	}
+
+	switch m.view {
+	case viewTransactions:
+		switch msg.String() {
+		case "a":
+			m.form = m.transactionForm()
+			return m, nil
+		case "d", "delete":
+			if i := m.txTable.Cursor(); i < len(m.txs) {
+				tx := m.txs[i]
+				if tx.Source == service.SourceRecurring {
+					m.status = fmt.Sprintf("Projected occurrences can't be deleted; pause or delete recurring %d instead.", tx.RecurringID.Int32)
+					return m, nil
+				}
+				m.confirm = fmt.Sprintf("Move transaction %d (%s) to the trash? (y/n)", tx.ID, tx.Description)
+				m.onConfirm = m.run(fmt.Sprintf("Transaction %d moved to trash.", tx.ID), func(ctx context.Context) error {
+					return m.fa.service.DeleteTransaction(ctx, tx.ID)
+				})
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.txTable, cmd = m.txTable.Update(msg)
+		return m, cmd
+
+	case viewRecurring:
+		i := m.recTable.Cursor()
+		switch msg.String() {
+		case "a":
+			m.form = m.recurringForm()
+			return m, nil
+		case "d", "delete":
+			if i < len(m.recs) {
+				r := m.recs[i]
+				m.confirm = fmt.Sprintf("Move recurring %d (%s) to the trash? (y/n)", r.ID, r.Description)
+				m.onConfirm = m.run(fmt.Sprintf("Recurring %d moved to trash.", r.ID), func(ctx context.Context) error {
+					return m.fa.service.DeleteRecurring(ctx, r.ID)
+				})
+			}
+			return m, nil
+		case " ", "t":
+			if i < len(m.recs) {
+				r := m.recs[i]
+				state := "paused"
+				if !r.Active {
+					state = "resumed"
+				}
+				return m, m.run(fmt.Sprintf("Recurring %d %s.", r.ID, state), func(ctx context.Context) error {
+					return m.fa.service.SetRecurringActive(ctx, r.ID, !r.Active)
+				})
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.recTable, cmd = m.recTable.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m tuiModel) transactionForm() *tuiForm {
+	today := m.fa.service.Today(m.ctx).Format("2006-01-02")
+	return newTUIForm("Add Transaction", [][2]string{
+		{"Type", "expense"},
+		{"Date", today},
+		{"Amount", ""},
+		{"Description", ""},
+		{"Category", ""},
+	}, func(v []string) (tea.Cmd, error) {
+		in := TransactionInput{Type: strings.ToLower(v[0]), Description: v[3], Category: v[4]}
+		var err error
+		if in.Date, err = m.fa.ParseDate(v[1]); err != nil {
+			return nil, err
+		}
+		if in.Amount, err = amount.Parse(v[2]); err != nil {
+			return nil, err
+		}
+		if in.Description == "" {
+			return nil, fmt.Errorf("description is required")
+		}
+		fa, ctx := m.fa, m.ctx
+		return func() tea.Msg {
+			warnings, err := fa.recordTransaction(ctx, in)
+			if err != nil {
+				return tuiDoneMsg{err: err}
+			}
+			status := fmt.Sprintf("Added %s: %.2f on %s.", in.Type, in.Amount, in.Date.Format("Jan 2, 2006"))
+			for _, w := range warnings {
+				status += " ⚠️  " + w.Message
+			}
+			return tuiDoneMsg{status: status}
+		}, nil
+	})
+}
+
+func (m tuiModel) recurringForm() *tuiForm {
+	today := m.fa.service.Today(m.ctx).Format("2006-01-02")
+	return newTUIForm("Add Recurring", [][2]string{
+		{"Description", ""},
+		{"Type", "expense"},
+		{"Amount", ""},
+		{"Interval", "monthly"},
+		{"Start", today},
+		{"Day", ""},
+	}, func(v []string) (tea.Cmd, error) {
+		// Same fields and rules as an import file row.
+		in, err := service.ParseRecurringRow(map[string]string{
+			"description": v[0],
+			"type":        v[1],
+			"amount":      v[2],
+			"interval":    v[3],
+			"start_date":  v[4],
+			"day":         v[5],
+		})
+		if err != nil {
+			return nil, err
+		}
+		return m.run(fmt.Sprintf("Recurring %q saved.", in.Description), func(ctx context.Context) error {
+			_, err := m.fa.service.CreateRecurringSimple(ctx, in)
+			return err
+		}), nil
+	})
+}
+
+func transactionRows(txs []service.ListedTransaction) []table.Row {
+	rows := make([]table.Row, len(txs))
+	for i, tx := range txs {
+		amt, _ := service.NumericToFloat64(tx.Amount)
+		if tx.Type == "expense" {
+			amt = -amt
+		}
+		rows[i] = table.Row{listedID(tx), tx.Date.Time.Format("2006-01-02"), tx.Type, fmt.Sprintf("%12.2f", amt), tx.Description}
+	}
+	return rows
+}
+
+func recurringRows(rs []service.RecurringSummary) []table.Row {
+	rows := make([]table.Row, len(rs))
+	for i, r := range rs {
+		active := "✗"
+		if r.Active {
+			active = "✓"
+		}
+		next := "none"
+		if r.NextOccurrence != nil {
+			next = r.NextOccurrence.Format("2006-01-02")
+		}
+		amt, _ := service.NumericToFloat64(r.Amount)
+		rows[i] = table.Row{strconv.Itoa(int(r.ID)), active, r.Type, fmt.Sprintf("%12.2f", amt), string(r.Interval), next, r.Description}
+	}
+	return rows
+}
+
+func (m tuiModel) View() string {
+	var b strings.Builder
+	tabs := make([]string, len(tuiViewNames))
+	for i, name := range tuiViewNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if tuiView(i) == m.view {
+			tabs[i] = tuiActiveTabStyle.Render(label)
+		} else {
+			tabs[i] = tuiTabStyle.Render(label)
+		}
+	}
+	b.WriteString(tuiTitleStyle.Render("💵 Currentz") + "  " + lipgloss.JoinHorizontal(lipgloss.Top, tabs...) + "\n\n")
+
+	if m.form != nil {
+		b.WriteString(m.form.View())
+		return b.String()
+	}
+
+	switch m.view {
+	case viewTransactions:
+		b.WriteString(m.txTable.View())
+	case viewForecast:
+		b.WriteString(m.forecastView())
+	case viewRecurring:
+		b.WriteString(m.recTable.View())
+	}
+	b.WriteString("\n\n")
+
+	switch {
+	case m.confirm != "":
+		b.WriteString(tuiWarnStyle.Render(m.confirm))
+	case m.err != nil:
+		b.WriteString(tuiErrorStyle.Render("Error: " + m.err.Error()))
+	case m.status != "":
+		b.WriteString(m.status)
+	}
+	b.WriteString("\n" + tuiHelpStyle.Render(m.helpLine()))
+	return b.String()
+}
+
+func (m tuiModel) helpLine() string {
+	keys := "tab/1-3 switch • r refresh • q quit"
+	switch m.view {
+	case viewTransactions:
+		return "↑/↓ scroll • a add • d delete • " + keys
+	case viewRecurring:
+		return "↑/↓ scroll • a add • d delete • space pause/resume • " + keys
+	}
+	return keys
+}
+
+func (m tuiModel) forecastView() string {
+	fc := m.forecast
+	if len(fc) == 0 {
+		return "No forecast data available."
+	}
+	width := max(m.width-2, 20)
+	balances := make([]float64, len(fc))
+	for i, d := range fc {
+		balances[i] = d.Balance
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d-day forecast\n\n", len(fc))
+	b.WriteString(sparkline(balances, width) + "\n")
+	start, end := fc[0].Date.Format("Jan 02"), fc[len(fc)-1].Date.Format("Jan 02")
+	gap := max(min(width, len(fc))-len(start)-len(end), 1)
+	b.WriteString(tuiHelpStyle.Render(start+strings.Repeat(" ", gap)+end) + "\n\n")
+
+	lowest, lowestDay := m.fa.service.FindLowestPoint(fc)
+	last := fc[len(fc)-1].Balance
+	net := fmt.Sprintf("%+.2f", last-m.balance)
+	if last >= m.balance {
+		net = tuiIncomeStyle.Render(net)
+	} else {
+		net = tuiExpenseStyle.Render(net)
+	}
+	fmt.Fprintf(&b, "Starting balance  %12.2f\n", m.balance)
+	fmt.Fprintf(&b, "Ending balance    %12.2f  (%s)\n", last, net)
+	fmt.Fprintf(&b, "Lowest balance    %12.2f  on %s, in %d days\n", lowest.Balance, lowest.Date.Format("Jan 2, 2006"), lowestDay)
+	if lowest.Balance < 0 {
+		b.WriteString(tuiErrorStyle.Render(fmt.Sprintf("🚨 You will go negative by %.2f", -lowest.Balance)) + "\n")
+	}
+	return b.String()
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as one row of block characters at most width
+// wide. When there are more values than columns, each column shows the
+// lowest value it covers so dips aren't averaged away.
+func sparkline(values []float64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	cols := min(width, len(values))
+	points := make([]float64, cols)
+	for c := range points {
+		from, to := c*len(values)/cols, (c+1)*len(values)/cols
+		points[c] = values[from]
+		for _, v := range values[from:to] {
+			points[c] = math.Min(points[c], v)
+		}
+	}
+
+	lo, hi := points[0], points[0]
+	for _, p := range points {
+		lo, hi = math.Min(lo, p), math.Max(hi, p)
+	}
+	var b strings.Builder
+	for _, p := range points {
+		level := 0
+		if hi > lo {
+			level = int((p - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
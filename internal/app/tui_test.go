@@ -0,0 +1,16 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▃▅█", sparkline([]float64{0, 1, 2, 3}, 10))
+	assert.Equal(t, "▁▁▁", sparkline([]float64{5, 5, 5}, 10), "flat line")
+	assert.Equal(t, "", sparkline(nil, 10))
+
+	// Squeezed into fewer columns, each keeps the lowest value it covers.
+	assert.Equal(t, "█▁█", sparkline([]float64{9, 9, 0, 9, 9, 9}, 3))
+}
@@ -0,0 +1,170 @@
+// Package auth issues and checks the credentials behind user sessions:
+// short-lived access tokens (JWTs signed with HMAC-SHA256), opaque refresh
+// tokens, and bcrypt password hashes. Where users and refresh tokens are
+// stored is up to the caller; only refresh token hashes need keeping.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidToken is returned for a token that is malformed, signed
+	// with another key or algorithm, or expired.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrWeakSecret is returned for a signing secret too short to be safe.
+	ErrWeakSecret = errors.New("signing secret must be at least 32 bytes")
+)
+
+// MinSecretLen is the shortest secret Sign and Verify accept.
+const MinSecretLen = 32
+
+// Claims is what an access token says about its bearer.
+type Claims struct {
+	// Subject is the user ID, as a decimal string.
+	Subject   string `json:"sub"`
+	Username  string `json:"name"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	// ID tells tokens apart; it is random.
+	ID string `json:"jti"`
+}
+
+// header is the only JOSE header Sign writes and Verify accepts.
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Sign returns c as a compact JWT signed with secret.
+func Sign(c Claims, secret []byte) (string, error) {
+	if len(secret) < MinSecretLen {
+		return "", ErrWeakSecret
+	}
+	if c.ID == "" {
+		c.ID = randomString(16)
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	signing := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signing + "." + signature(signing, secret), nil
+}
+
+// Verify checks token's signature and expiry as of now and returns its
+// claims.
+func Verify(token string, secret []byte, now time.Time) (Claims, error) {
+	if len(secret) < MinSecretLen {
+		return Claims{}, ErrWeakSecret
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return Claims{}, ErrInvalidToken
+	}
+	want := signature(parts[0]+"."+parts[1], secret)
+	if !hmac.Equal([]byte(parts[2]), []byte(want)) {
+		return Claims{}, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if c.Subject == "" || now.Unix() >= c.ExpiresAt {
+		return Claims{}, ErrInvalidToken
+	}
+	return c, nil
+}
+
+// LooksLikeJWT tells an access token apart from an API key without
+// checking it.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2 && strings.HasPrefix(token, header+".")
+}
+
+func signature(signing string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signing))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewRefreshToken returns a random refresh token to give the client and
+// the hash of it to store.
+func NewRefreshToken() (token, hash string) {
+	token = randomString(32)
+	return token, HashToken(token)
+}
+
+// HashToken is the SHA-256 of a refresh token, hex encoded. Refresh tokens
+// are long and random, so a fast hash is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() []byte {
+	b := make([]byte, MinSecretLen)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("auth: reading random bytes: %v", err))
+	}
+	return b
+}
+
+// MinPasswordLen is the shortest password HashPassword accepts.
+const MinPasswordLen = 8
+
+// ErrWeakPassword is returned by HashPassword for a password that is too
+// short, or too long for bcrypt.
+var ErrWeakPassword = fmt.Errorf("password must be %d to 72 bytes", MinPasswordLen)
+
+// HashPassword hashes password with bcrypt for storing.
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLen || len(password) > 72 {
+		return "", ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches hash. An empty hash takes
+// as long to check as a real one and never matches, so callers can check
+// unknown users without revealing that they are unknown.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// dummyHash is a bcrypt hash of nothing anyone would type, at the default
+// cost. It is made on first use to keep it out of start-up time.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("currentz: no such user"), bcrypt.DefaultCost)
+	return hash
+})
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("auth: reading random bytes: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	secret := NewSecret()
+	now := time.Unix(1_750_000_000, 0)
+	c := Claims{Subject: "7", Username: "alex", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+
+	token, err := Sign(c, secret)
+	require.NoError(t, err)
+	assert.True(t, LooksLikeJWT(token))
+	assert.False(t, LooksLikeJWT("an-api-key"))
+
+	got, err := Verify(token, secret, now)
+	require.NoError(t, err)
+	assert.Equal(t, "7", got.Subject)
+	assert.Equal(t, "alex", got.Username)
+	assert.NotEmpty(t, got.ID)
+
+	_, err = Verify(token, secret, now.Add(time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidToken, "expired")
+	_, err = Verify(token, NewSecret(), now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "other key")
+
+	parts := strings.Split(token, ".")
+	forged := `{"alg":"none"}`
+	_, err = Verify(strings.Join([]string{forged, parts[1], ""}, "."), secret, now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "alg none")
+	_, err = Verify(parts[0]+"."+parts[1]+"x."+parts[2], secret, now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "tampered payload")
+
+	_, err = Sign(c, []byte("short"))
+	assert.ErrorIs(t, err, ErrWeakSecret)
+}
+
+func TestRefreshToken(t *testing.T) {
+	token, hash := NewRefreshToken()
+	other, _ := NewRefreshToken()
+	assert.NotEqual(t, token, other)
+	assert.Equal(t, hash, HashToken(token))
+	assert.Len(t, hash, 64)
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	require.NoError(t, err)
+	assert.True(t, CheckPassword(hash, "correct horse"))
+	assert.False(t, CheckPassword(hash, "wrong horse"))
+	assert.False(t, CheckPassword("", "correct horse"))
+
+	_, err = HashPassword("short")
+	assert.ErrorIs(t, err, ErrWeakPassword)
+}
@@ -0,0 +1,106 @@
+// Package backupfile seals backup documents with a passphrase so they can
+// be stored somewhere untrusted, like a synced folder.
+//
+// A sealed file is a fixed header followed by the AES-256-GCM ciphertext:
+//
+//	magic "CZB" | version (1 byte) | PBKDF2 iterations (uint32 BE) | salt (16) | nonce (12) | ciphertext
+//
+// The key is PBKDF2-HMAC-SHA256 of the passphrase and salt. The header is
+// authenticated along with the data, so tampering with any byte is caught.
+package backupfile
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	magic      = "CZB"
+	version    = 1
+	saltSize   = 16
+	nonceSize  = 12
+	keySize    = 32
+	headerSize = len(magic) + 1 + 4 + saltSize + nonceSize
+
+	// Iterations is the PBKDF2 work factor for new files, per current OWASP
+	// guidance for HMAC-SHA256. Files record their own count, so this can
+	// be raised without breaking old backups.
+	Iterations = 600_000
+)
+
+// ErrDecrypt is returned when a file cannot be opened, which almost always
+// means the passphrase is wrong. GCM cannot tell that apart from a
+// corrupted file.
+var ErrDecrypt = errors.New("wrong passphrase or corrupted backup file")
+
+// Seal encrypts plaintext under passphrase.
+func Seal(plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	header[len(magic)] = version
+	binary.BigEndian.PutUint32(header[len(magic)+1:], Iterations)
+	salt := header[len(magic)+5 : len(magic)+5+saltSize]
+	nonce := header[headerSize-nonceSize:]
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(passphrase, salt, Iterations)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(header, nonce, plaintext, header), nil
+}
+
+// Open decrypts a file produced by Seal.
+func Open(data []byte, passphrase string) ([]byte, error) {
+	if len(data) < headerSize || !bytes.HasPrefix(data, []byte(magic)) {
+		return nil, fmt.Errorf("not a currentz backup file")
+	}
+	if v := data[len(magic)]; v != version {
+		return nil, fmt.Errorf("unsupported backup file version %d", v)
+	}
+	header := data[:headerSize]
+	iterations := int(binary.BigEndian.Uint32(header[len(magic)+1:]))
+	salt := header[len(magic)+5 : len(magic)+5+saltSize]
+	nonce := header[headerSize-nonceSize:]
+
+	gcm, err := newGCM(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, data[headerSize:], header)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations < 1 {
+		return nil, fmt.Errorf("invalid iteration count %d", iterations)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,36 @@
+package backupfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	plaintext := []byte(`{"version":1,"transactions":[]}`)
+
+	sealed, err := Seal(plaintext, "correct horse")
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "transactions")
+
+	opened, err := Open(sealed, "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	_, err = Open(sealed, "wrong horse")
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(magic)+5] ^= 0xff // flip a salt byte
+	_, err = Open(tampered, "correct horse")
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestOpenRejectsOtherFiles(t *testing.T) {
+	_, err := Open([]byte(`{"version":1}`), "x")
+	assert.ErrorContains(t, err, "not a currentz backup file")
+
+	_, err = Seal([]byte("data"), "")
+	assert.Error(t, err)
+}
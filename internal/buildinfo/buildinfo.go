@@ -0,0 +1,74 @@
+// Package buildinfo reports which build of Currentz is running.
+//
+// Release builds stamp it with ldflags (see the Makefile):
+//
+//	-X github.com/jdelles/currentz/internal/buildinfo.version=v1.4.0
+//	-X github.com/jdelles/currentz/internal/buildinfo.commit=3f2a9c1
+//	-X github.com/jdelles/currentz/internal/buildinfo.date=2025-06-01T12:00:00Z
+//
+// Anything left unset falls back to what the Go toolchain recorded, so a
+// plain `go build` from a checkout still reports its commit.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the stamped build info, filling gaps from the toolchain's.
+func Get() Info {
+	info := Info{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "(devel)"
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	return info
+}
+
+// Version is the version alone, e.g. "v1.4.0" or "(devel)".
+func Version() string {
+	return Get().Version
+}
+
+// String is the one-line form used by --version and the server log.
+func (i Info) String() string {
+	s := i.Version
+	if i.Commit != "" {
+		s += " (" + i.Commit
+		if i.Date != "" {
+			s += ", built " + i.Date
+		}
+		s += ")"
+	}
+	return fmt.Sprintf("%s %s", s, i.GoVersion)
+}
@@ -0,0 +1,22 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPrefersStampedValues(t *testing.T) {
+	defer func(v, c, d string) { version, commit, date = v, c, d }(version, commit, date)
+	version, commit, date = "v1.4.0", "3f2a9c1d8e7b6a5f", "2025-06-01T12:00:00Z"
+
+	info := Get()
+	assert.Equal(t, "v1.4.0", info.Version)
+	assert.Equal(t, "3f2a9c1d8e7b", info.Commit, "shortened")
+	assert.Equal(t, "v1.4.0 (3f2a9c1d8e7b, built 2025-06-01T12:00:00Z) "+runtime.Version(), info.String())
+}
+
+func TestVersionIsNeverEmpty(t *testing.T) {
+	assert.NotEmpty(t, Version())
+}
@@ -1,19 +1,567 @@
+// Package config gathers the CLI's and the server's settings. Each one is
+// taken from the first of these that sets it:
+//
+//  1. an environment variable, such as DB_URL or CURRENTZ_LOCALE
+//  2. a command-line flag, such as --remote
+//  3. the config file, --config or DefaultPath
+//  4. a built-in default
+//
+// Secrets, such as the database password and API keys, can instead be read
+// from a file named by the variable with _FILE on the end, as in
+// DB_PASSWORD_FILE, or written as a reference to a file or secret store;
+// see package secrets.
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/amount"
+	"github.com/jdelles/currentz/internal/fieldcrypt"
+	"github.com/jdelles/currentz/internal/secrets"
+	"github.com/jdelles/currentz/internal/service"
+	"github.com/jdelles/currentz/internal/storage/blob"
 )
 
+// Flags are the values given on the command line, by config file key, e.g.
+// "remote.url". Flags that weren't given are left out.
+type Flags map[string]string
+
 type Config struct {
 	DatabaseURL string
+	// EncryptionKey, when set, encrypts descriptions at rest.
+	EncryptionKey []byte
+	// Locale decides how typed amounts are read, e.g. "1.234,56" for de-DE.
+	Locale amount.Locale
+	// RemoteURL, when set, points the CLI at a Currentz server's HTTP API
+	// instead of a database. APIKey authenticates with that server.
+	RemoteURL string
+	APIKey    string
+	// Demo runs against an in-memory store of sample data instead of a
+	// database. Nothing is saved.
+	Demo bool
+	// Defaults stand in for the settings a household hasn't set.
+	Defaults service.SettingDefaults
+}
+
+// Profile names the environment a server runs in. Each one sets the
+// defaults that suit it; anything configured explicitly still wins.
+type Profile string
+
+const (
+	// Development allows any origin and no credentials, and serves demo
+	// data unless a database is configured.
+	Development Profile = "development"
+	// Staging allows only the dashboard's own origin and requires an API
+	// key.
+	Staging Profile = "staging"
+	// Production is Staging that also refuses demo mode.
+	Production Profile = "production"
+)
+
+// Server is the API server's configuration.
+type Server struct {
+	// Profile is empty when none is chosen, which keeps the defaults of a
+	// development server without its demo data.
+	Profile Profile
+	Port    string
+	// DatabaseURL is empty when none is configured.
+	DatabaseURL   string
+	EncryptionKey []byte
+	Pool          service.PoolOptions
+	Locale        amount.Locale
+	Demo          bool
+	// APIKey, when set, is required of every request.
+	APIKey string
+	// ScopedKeys are further API keys, each allowed only its scopes, such
+	// as read:transactions.
+	ScopedKeys map[string][]string
+	// JWTSecret, when set, signs access tokens so sessions survive a
+	// restart.
+	JWTSecret string
+	// RequireAuth refuses to start without an APIKey.
+	RequireAuth bool
+	// CORSOrigins are the origins web pages may call the API from; "*" is
+	// any, and none is only the dashboard's own.
+	CORSOrigins []string
+	// StaticDir, when set, is served in place of the built-in dashboard.
+	StaticDir          string
+	Maintenance        bool
+	MaintenanceMessage string
+	Defaults           service.SettingDefaults
+
+	// TLSCert and TLSKey serve HTTPS from certificate files, or
+	// AutocertDomains from Let's Encrypt certificates cached in
+	// AutocertCache. HTTPRedirectAddr, when set, redirects plain HTTP
+	// there to HTTPS.
+	TLSCert          string
+	TLSKey           string
+	AutocertDomains  []string
+	AutocertEmail    string
+	AutocertCache    string
+	HTTPRedirectAddr string
+
+	// RequestTimeout, MaxBodyBytes and MaxUploadBytes replace the API's
+	// default limits when set.
+	RequestTimeout *time.Duration
+	MaxBodyBytes   *int64
+	MaxUploadBytes *int64
+	// MaxAttachmentBytes caps one attachment; zero keeps the default.
+	MaxAttachmentBytes int64
+	// CompressMinSize and CompressTypes replace the API's default
+	// compression when set. No types turns compression off.
+	CompressMinSize *int
+	CompressTypes   []string
+
+	// Blob says where attachments and stored backups are kept. An empty
+	// Backend means nowhere.
+	Blob blob.Config
+}
+
+// TLS reports whether the server is set to serve HTTPS.
+func (s *Server) TLS() bool {
+	return s.TLSCert != "" || s.TLSKey != "" || len(s.AutocertDomains) > 0
+}
+
+// Load reads the CLI's configuration from the environment, flags and the
+// config file at path, or at DefaultPath if path is empty.
+func Load(path string, flags Flags) (*Config, error) {
+	src, err := newSources(path, flags)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	src.bool(&cfg.Demo, "demo", "CURRENTZ_DEMO")
+	src.string(&cfg.RemoteURL, "remote.url", "CURRENTZ_REMOTE")
+	src.secret(&cfg.APIKey, "remote.api_key", "CURRENTZ_API_KEY")
+	src.database(&cfg.DatabaseURL, "DB_URL")
+	src.encryptionKey(&cfg.EncryptionKey)
+	src.locale(&cfg.Locale)
+	src.defaults(&cfg.Defaults)
+	if err := src.err(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cfg.Demo && cfg.RemoteURL != "":
+		return nil, fmt.Errorf("demo mode and a remote server can't be used together")
+	case cfg.Demo || cfg.RemoteURL != "":
+	case cfg.DatabaseURL == "":
+		return nil, fmt.Errorf("DB_URL not set. Run `make dev-setup`, create .env from .env.example, or set database.url in %s", src.file.path)
+	}
+	return cfg, nil
+}
+
+// LoadServer reads the server's configuration from the environment, flags
+// and the config file at path, or at DefaultPath if path is empty.
+func LoadServer(path string, flags Flags) (*Server, error) {
+	src, err := newSources(path, flags)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Server{
+		Port: "8080",
+		Pool: service.PoolOptions{ConnectTimeout: 30 * time.Second},
+	}
+	src.profile(&cfg.Profile)
+	switch cfg.Profile {
+	case Staging, Production:
+		cfg.RequireAuth = true
+	default:
+		cfg.CORSOrigins = []string{"*"}
+	}
+	src.port(&cfg.Port)
+	src.database(&cfg.DatabaseURL, "DATABASE_URL")
+	src.encryptionKey(&cfg.EncryptionKey)
+	src.int32(&cfg.Pool.MaxConns, "database.max_conns", "CURRENTZ_DB_MAX_CONNS")
+	src.int32(&cfg.Pool.MinConns, "database.min_conns", "CURRENTZ_DB_MIN_CONNS")
+	src.duration(&cfg.Pool.HealthCheckPeriod, "database.health_check_period", "CURRENTZ_DB_HEALTH_CHECK_PERIOD")
+	src.duration(&cfg.Pool.ConnectTimeout, "database.connect_timeout", "CURRENTZ_DB_CONNECT_TIMEOUT")
+	src.bool(&cfg.Demo, "demo", "CURRENTZ_DEMO")
+	src.secret(&cfg.APIKey, "server.api_key", "CURRENTZ_API_KEY")
+	src.scopedKeys(&cfg.ScopedKeys)
+	src.secret(&cfg.JWTSecret, "server.jwt_secret", "CURRENTZ_JWT_SECRET")
+	src.bool(&cfg.RequireAuth, "server.require_auth", "CURRENTZ_REQUIRE_AUTH")
+	src.list(&cfg.CORSOrigins, "server.cors_origins", "CURRENTZ_CORS_ORIGINS")
+	src.string(&cfg.StaticDir, "server.static_dir", "CURRENTZ_STATIC_DIR")
+	src.bool(&cfg.Maintenance, "server.maintenance", "CURRENTZ_MAINTENANCE")
+	src.string(&cfg.MaintenanceMessage, "server.maintenance_message", "CURRENTZ_MAINTENANCE_MESSAGE")
+	src.locale(&cfg.Locale)
+	src.defaults(&cfg.Defaults)
+	src.string(&cfg.TLSCert, "server.tls_cert", "CURRENTZ_TLS_CERT")
+	src.string(&cfg.TLSKey, "server.tls_key", "CURRENTZ_TLS_KEY")
+	src.list(&cfg.AutocertDomains, "server.autocert_domains", "CURRENTZ_AUTOCERT_DOMAINS")
+	src.string(&cfg.AutocertEmail, "server.autocert_email", "CURRENTZ_AUTOCERT_EMAIL")
+	src.string(&cfg.AutocertCache, "server.autocert_cache", "CURRENTZ_AUTOCERT_CACHE")
+	src.string(&cfg.HTTPRedirectAddr, "server.http_redirect_addr", "CURRENTZ_HTTP_REDIRECT_ADDR")
+	set(src, &cfg.RequestTimeout, "server.request_timeout", "CURRENTZ_REQUEST_TIMEOUT", optional(parseDuration))
+	set(src, &cfg.MaxBodyBytes, "server.max_body_bytes", "CURRENTZ_MAX_BODY_BYTES", optional(parseSize))
+	set(src, &cfg.MaxUploadBytes, "server.max_upload_bytes", "CURRENTZ_MAX_UPLOAD_BYTES", optional(parseSize))
+	set(src, &cfg.MaxAttachmentBytes, "server.max_attachment_bytes", "CURRENTZ_MAX_ATTACHMENT_BYTES", parseSize)
+	set(src, &cfg.CompressMinSize, "server.compress_min_size", "CURRENTZ_COMPRESS_MIN_SIZE", optional(func(v string) (int, error) {
+		n, err := parseSize(v)
+		return int(n), err
+	}))
+	src.compressTypes(&cfg.CompressTypes)
+	src.blob(&cfg.Blob)
+	if err := src.err(); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.AutocertDomains) > 0 && cfg.AutocertCache == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("set CURRENTZ_AUTOCERT_CACHE or server.autocert_cache: %w", err)
+		}
+		cfg.AutocertCache = filepath.Join(dir, "currentz", "autocert")
+	}
+
+	switch {
+	case cfg.HTTPRedirectAddr != "" && !cfg.TLS():
+		return nil, errors.New("CURRENTZ_HTTP_REDIRECT_ADDR or server.http_redirect_addr needs TLS to redirect to")
+	case cfg.Profile == Production && cfg.Demo:
+		return nil, fmt.Errorf("demo mode can't be used with the production profile")
+	case cfg.RequireAuth && cfg.APIKey == "" && len(cfg.ScopedKeys) == 0 && !cfg.Demo:
+		return nil, errors.New("an API key is required (server.require_auth): set CURRENTZ_API_KEY or server.api_key")
+	case cfg.Profile == Development && cfg.DatabaseURL == "":
+		cfg.Demo = true
+	}
+	return cfg, nil
+}
+
+// sources looks a key up in the environment, the flags and the file in
+// turn, collecting an error naming the key for each bad value.
+type sources struct {
+	flags Flags
+	file  *file
+	errs  []error
+}
+
+func newSources(path string, flags Flags) (*sources, error) {
+	path, named := resolvePath(path)
+	f, err := readFile(path, named)
+	if err != nil {
+		return nil, err
+	}
+	return &sources{flags: flags, file: f}, nil
+}
+
+// lookup finds key's value, and names where it came from for errors.
+// resolvePath picks the config file: path, else CURRENTZ_CONFIG, else the
+// default. named is false for the default, which needn't exist.
+func resolvePath(path string) (resolved string, named bool) {
+	if path == "" {
+		path = os.Getenv("CURRENTZ_CONFIG")
+	}
+	if path == "" {
+		return DefaultPath(), false
+	}
+	return path, true
+}
+
+func (s *sources) lookup(key, env string) (value, from string, ok bool) {
+	if v := os.Getenv(env); v != "" {
+		return v, env, true
+	}
+	if v, ok := s.flags[key]; ok {
+		return v, fmt.Sprintf("%s (command line)", key), true
+	}
+	if v, ok := s.file.values[key]; ok {
+		return v.value, fmt.Sprintf("%s (%s line %d)", key, s.file.path, v.line), true
+	}
+	return "", "", false
+}
+
+// set parses key's value into dst, if it has one.
+func set[T any](s *sources, dst *T, key, env string, parse func(string) (T, error)) {
+	v, from, ok := s.lookup(key, env)
+	if !ok {
+		return
+	}
+	parsed, err := parse(strings.TrimSpace(v))
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: %w", from, err))
+		return
+	}
+	*dst = parsed
+}
+
+func (s *sources) err() error {
+	return errors.Join(s.errs...)
+}
+
+func (s *sources) string(dst *string, key, env string) {
+	set(s, dst, key, env, func(v string) (string, error) { return v, nil })
+}
+
+// secret reads a value that may be kept out of the environment and the
+// file: from the file named by env_FILE, or from a reference such as
+// file:/run/secrets/api_key or vault:secret/data/currentz#api_key.
+func (s *sources) secret(dst *string, key, env string) {
+	v, from, ok := s.lookup(key, env)
+	if path := os.Getenv(env + "_FILE"); path != "" && os.Getenv(env) == "" {
+		v, from, ok = "file:"+path, env+"_FILE", true
+	}
+	if !ok {
+		return
+	}
+	v, err := secrets.Resolve(context.Background(), strings.TrimSpace(v))
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: %w", from, err))
+		return
+	}
+	*dst = v
+}
+
+// scopedKeys reads API keys and their scopes, written "<key>=<scope>,..."
+// and separated by spaces or newlines, as in
+// "k3y=read:forecast k3y2=read,write:recurring". They're secrets, so can
+// come from a file too.
+func (s *sources) scopedKeys(dst *map[string][]string) {
+	var v string
+	s.secret(&v, "server.api_keys", "CURRENTZ_API_KEYS")
+	if v == "" {
+		return
+	}
+	keys := map[string][]string{}
+	for i, entry := range strings.Fields(v) {
+		// A base64 key can end in '='; scopes never hold one.
+		at := strings.LastIndex(entry, "=")
+		if at <= 0 || at == len(entry)-1 {
+			s.errs = append(s.errs, fmt.Errorf("CURRENTZ_API_KEYS or server.api_keys: key %d: expected <key>=<scope>,...", i+1))
+			continue
+		}
+		keys[entry[:at]] = strings.Split(entry[at+1:], ",")
+	}
+	*dst = keys
+}
+
+// encryptionKey reads the key descriptions are encrypted at rest with. It's
+// a secret, so can come from a file or a secret store; an aws-kms: data
+// key works as it is.
+func (s *sources) encryptionKey(dst *[]byte) {
+	var v string
+	s.secret(&v, "database.encryption_key", "CURRENTZ_ENCRYPTION_KEY")
+	if v == "" {
+		return
+	}
+	key, err := fieldcrypt.ParseKey(v)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("CURRENTZ_ENCRYPTION_KEY or database.encryption_key: %w", err))
+		return
+	}
+	*dst = key
+}
+
+// database reads the database URL, filling in database.password if that's
+// set apart from it.
+func (s *sources) database(dst *string, env string) {
+	s.secret(dst, "database.url", env)
+	var password string
+	s.secret(&password, "database.password", "DB_PASSWORD")
+	if password != "" && *dst != "" {
+		*dst = withPassword(*dst, password)
+	}
+}
+
+// withPassword sets the password in a connection string, either a
+// postgres:// URL or key=value pairs.
+func withPassword(conn, password string) string {
+	if u, err := url.Parse(conn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		u.User = url.UserPassword(u.User.Username(), password)
+		return u.String()
+	}
+	quoted := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(password)
+	return conn + " password='" + quoted + "'"
+}
+
+// list reads comma-separated values, such as "a, b".
+func (s *sources) list(dst *[]string, key, env string) {
+	set(s, dst, key, env, func(v string) ([]string, error) {
+		list := []string{}
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	})
+}
+
+// profile reads the environment the server runs in, from APP_ENV or the
+// profile key. dev, stage and prod are accepted for short.
+func (s *sources) profile(dst *Profile) {
+	set(s, dst, "profile", "APP_ENV", func(v string) (Profile, error) {
+		switch strings.ToLower(v) {
+		case "dev", "development":
+			return Development, nil
+		case "stage", "staging":
+			return Staging, nil
+		case "prod", "production":
+			return Production, nil
+		}
+		return "", fmt.Errorf("unknown profile %q (expected development, staging or production)", v)
+	})
+}
+
+func (s *sources) bool(dst *bool, key, env string) {
+	set(s, dst, key, env, func(v string) (bool, error) {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("invalid value %q (expected true or false)", v)
+		}
+		return b, nil
+	})
+}
+
+func (s *sources) int32(dst *int32, key, env string) {
+	set(s, dst, key, env, func(v string) (int32, error) {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid value %q (expected a whole number, 0 or more)", v)
+		}
+		return int32(n), nil
+	})
+}
+
+func (s *sources) duration(dst *time.Duration, key, env string) {
+	set(s, dst, key, env, parseDuration)
+}
+
+func parseDuration(v string) (time.Duration, error) {
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid value %q (expected a duration such as 30s or 5m)", v)
+	}
+	return d, nil
+}
+
+// parseSize reads a size in bytes, 0 or more.
+func parseSize(v string) (int64, error) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (expected a number of bytes, 0 or more)", v)
+	}
+	return n, nil
+}
+
+// optional wraps parse for a setting whose absence keeps a default that
+// lives elsewhere, so zero can still be set.
+func optional[T any](parse func(string) (T, error)) func(string) (*T, error) {
+	return func(v string) (*T, error) {
+		parsed, err := parse(v)
+		if err != nil {
+			return nil, err
+		}
+		return &parsed, nil
+	}
+}
+
+// compressTypes reads the content types to compress, lowercased. "none"
+// turns compression off.
+func (s *sources) compressTypes(dst *[]string) {
+	s.list(dst, "server.compress_types", "CURRENTZ_COMPRESS_TYPES")
+	if *dst == nil {
+		return
+	}
+	types := []string{}
+	for _, t := range *dst {
+		if t = strings.ToLower(t); t != "none" {
+			types = append(types, t)
+		}
+	}
+	*dst = types
+}
+
+// blob reads the blob storage settings. The backend defaults to s3 when a
+// bucket is named and to local when a directory is. The S3 settings fall
+// back to the usual AWS variables.
+func (s *sources) blob(dst *blob.Config) {
+	s.string(&dst.Backend, "blob.backend", "CURRENTZ_BLOB_BACKEND")
+	s.string(&dst.Dir, "blob.dir", "CURRENTZ_BLOB_DIR")
+	s.string(&dst.S3.Endpoint, "blob.s3.endpoint", "CURRENTZ_S3_ENDPOINT")
+	s.string(&dst.S3.Region, "blob.s3.region", firstSet("CURRENTZ_S3_REGION", "AWS_REGION"))
+	s.string(&dst.S3.Bucket, "blob.s3.bucket", "CURRENTZ_S3_BUCKET")
+	s.secret(&dst.S3.AccessKeyID, "blob.s3.access_key_id", firstSet("CURRENTZ_S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"))
+	s.secret(&dst.S3.SecretAccessKey, "blob.s3.secret_access_key", firstSet("CURRENTZ_S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"))
+	s.secret(&dst.S3.SessionToken, "blob.s3.session_token", "AWS_SESSION_TOKEN")
+	s.bool(&dst.S3.PathStyle, "blob.s3.path_style", "CURRENTZ_S3_PATH_STYLE")
+	if dst.Backend != "" {
+		return
+	}
+	switch {
+	case dst.S3.Bucket != "":
+		dst.Backend = "s3"
+	case dst.Dir != "":
+		dst.Backend = "local"
+	}
+}
+
+// firstSet returns the first of the named variables that is set, directly
+// or with _FILE, or the first name if none is.
+func firstSet(envs ...string) string {
+	for _, env := range envs {
+		if os.Getenv(env) != "" || os.Getenv(env+"_FILE") != "" {
+			return env
+		}
+	}
+	return envs[0]
+}
+
+func (s *sources) port(dst *string) {
+	set(s, dst, "server.port", "PORT", func(v string) (string, error) {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("invalid port %q (expected 1-65535)", v)
+		}
+		return v, nil
+	})
+}
+
+func (s *sources) locale(dst *amount.Locale) {
+	*dst = amount.USLocale
+	set(s, dst, "locale", "CURRENTZ_LOCALE", amount.LookupLocale)
+}
+
+// defaults reads the household setting defaults.
+func (s *sources) defaults(dst *service.SettingDefaults) {
+	set(s, &dst.Timezone, "timezone", "CURRENTZ_TIMEZONE", func(v string) (string, error) {
+		if _, err := time.LoadLocation(v); err != nil || v == "" {
+			return "", fmt.Errorf("unknown timezone %q", v)
+		}
+		return v, nil
+	})
+	set(s, &dst.BaseCurrency, "currency", "CURRENTZ_CURRENCY", service.NormalizeCurrency)
+	set(s, &dst.ForecastDays, "forecast_days", "CURRENTZ_FORECAST_DAYS", func(v string) (int, error) {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > service.MaxForecastDays {
+			return 0, fmt.Errorf("invalid value %q (expected 1-%d)", v, service.MaxForecastDays)
+		}
+		return n, nil
+	})
+	set(s, &dst.LowBalanceThreshold, "notifications.low_balance_threshold", "CURRENTZ_LOW_BALANCE_THRESHOLD", parseAmount(false))
+	set(s, &dst.LargeBillThreshold, "notifications.large_bill_threshold", "CURRENTZ_LARGE_BILL_THRESHOLD", parseAmount(true))
+	set(s, &dst.NotificationMinChange, "notifications.min_change", "CURRENTZ_NOTIFICATION_MIN_CHANGE", parseAmount(true))
 }
 
-func Load() (*Config, error) {
-	dbURL := strings.TrimSpace(os.Getenv("DB_URL"))
-	if dbURL == "" {
-		return nil, fmt.Errorf("DB_URL not set. Run `make dev-setup` or create .env from .env.example")
+// parseAmount reads a plain number such as 500 or -20.50, which must be
+// above zero if positive is set.
+func parseAmount(positive bool) func(string) (*float64, error) {
+	return func(v string) (*float64, error) {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount %q", v)
+		}
+		if positive && f <= 0 {
+			return nil, fmt.Errorf("invalid amount %q (expected more than 0)", v)
+		}
+		return &f, nil
 	}
-	return &Config{DatabaseURL: dbURL}, nil
 }
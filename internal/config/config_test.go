@@ -0,0 +1,375 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv unsets every variable Load reads, for the rest of the test.
+func clearEnv(t *testing.T) {
+	for _, env := range []string{
+		"CURRENTZ_CONFIG", "XDG_CONFIG_HOME", "DB_URL", "DATABASE_URL", "PORT",
+		"CURRENTZ_DEMO", "CURRENTZ_REMOTE", "CURRENTZ_API_KEY", "CURRENTZ_LOCALE",
+		"CURRENTZ_TIMEZONE", "CURRENTZ_CURRENCY", "CURRENTZ_FORECAST_DAYS",
+		"CURRENTZ_LOW_BALANCE_THRESHOLD", "CURRENTZ_LARGE_BILL_THRESHOLD", "CURRENTZ_NOTIFICATION_MIN_CHANGE",
+		"CURRENTZ_DB_MAX_CONNS", "CURRENTZ_DB_MIN_CONNS", "CURRENTZ_DB_HEALTH_CHECK_PERIOD",
+		"CURRENTZ_DB_CONNECT_TIMEOUT", "CURRENTZ_JWT_SECRET", "CURRENTZ_STATIC_DIR",
+		"CURRENTZ_MAINTENANCE", "CURRENTZ_MAINTENANCE_MESSAGE", "DB_PASSWORD",
+		"DB_URL_FILE", "DATABASE_URL_FILE", "DB_PASSWORD_FILE", "CURRENTZ_API_KEY_FILE",
+		"CURRENTZ_JWT_SECRET_FILE", "APP_ENV", "CURRENTZ_REQUIRE_AUTH", "CURRENTZ_CORS_ORIGINS",
+		"CURRENTZ_API_KEYS", "CURRENTZ_API_KEYS_FILE", "CURRENTZ_TLS_CERT", "CURRENTZ_TLS_KEY",
+		"CURRENTZ_AUTOCERT_DOMAINS", "CURRENTZ_AUTOCERT_EMAIL", "CURRENTZ_AUTOCERT_CACHE",
+		"CURRENTZ_HTTP_REDIRECT_ADDR", "CURRENTZ_REQUEST_TIMEOUT", "CURRENTZ_MAX_BODY_BYTES",
+		"CURRENTZ_MAX_UPLOAD_BYTES", "CURRENTZ_MAX_ATTACHMENT_BYTES", "CURRENTZ_COMPRESS_MIN_SIZE",
+		"CURRENTZ_COMPRESS_TYPES", "CURRENTZ_BLOB_BACKEND", "CURRENTZ_BLOB_DIR", "CURRENTZ_S3_ENDPOINT",
+		"CURRENTZ_S3_REGION", "AWS_REGION", "CURRENTZ_S3_BUCKET", "CURRENTZ_S3_ACCESS_KEY_ID",
+		"AWS_ACCESS_KEY_ID", "CURRENTZ_S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY",
+		"CURRENTZ_S3_SECRET_ACCESS_KEY_FILE", "AWS_SESSION_TOKEN", "CURRENTZ_S3_PATH_STYLE",
+	} {
+		t.Setenv(env, "")
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, `
+database:
+  url: postgres://file
+remote:
+  url: https://file.example.com
+  api_key: file-key
+locale: de-DE
+timezone: Europe/Berlin
+currency: eur
+notifications:
+  low_balance_threshold: 250
+  min_change: 25
+`)
+
+	cfg, err := Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://file", cfg.DatabaseURL)
+	assert.Equal(t, "https://file.example.com", cfg.RemoteURL)
+	assert.Equal(t, "file-key", cfg.APIKey)
+	assert.Equal(t, "de-DE", cfg.Locale.Name)
+	assert.Equal(t, "Europe/Berlin", cfg.Defaults.Timezone)
+	assert.Equal(t, "EUR", cfg.Defaults.BaseCurrency)
+	require.NotNil(t, cfg.Defaults.LowBalanceThreshold)
+	assert.Equal(t, 250.0, *cfg.Defaults.LowBalanceThreshold)
+	assert.Nil(t, cfg.Defaults.LargeBillThreshold)
+	require.NotNil(t, cfg.Defaults.NotificationMinChange)
+	assert.Equal(t, 25.0, *cfg.Defaults.NotificationMinChange)
+
+	// A flag beats the file, and the environment beats both.
+	flags := Flags{"remote.url": "https://flag.example.com", "remote.api_key": "flag-key"}
+	t.Setenv("CURRENTZ_API_KEY", "env-key")
+	cfg, err = Load(path, flags)
+	require.NoError(t, err)
+	assert.Equal(t, "https://flag.example.com", cfg.RemoteURL)
+	assert.Equal(t, "env-key", cfg.APIKey)
+}
+
+func TestLoadDefaultPath(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	// No file at the default path is fine, so long as nothing is missing.
+	_, err := Load("", nil)
+	assert.ErrorContains(t, err, "DB_URL not set")
+	cfg, err := Load("", Flags{"demo": "true"})
+	require.NoError(t, err)
+	assert.True(t, cfg.Demo)
+	assert.Equal(t, "en-US", cfg.Locale.Name)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "currentz"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "currentz", "config.yaml"), []byte("demo: true\n"), 0o600))
+	cfg, err = Load("", nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.Demo)
+
+	// A file asked for by name has to be there.
+	_, err = Load(filepath.Join(dir, "missing.yaml"), nil)
+	assert.Error(t, err)
+}
+
+func TestLoadServer(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, `
+server:
+  port: 9000
+  maintenance: true
+  maintenance_message: back soon
+database:
+  max_conns: 20
+  health_check_period: 1m
+`)
+	t.Setenv("CURRENTZ_DB_MAX_CONNS", "5")
+
+	cfg, err := LoadServer(path, Flags{"server.port": "9001"})
+	require.NoError(t, err)
+	assert.Equal(t, "9001", cfg.Port)
+	assert.Equal(t, int32(5), cfg.Pool.MaxConns)
+	assert.Equal(t, time.Minute, cfg.Pool.HealthCheckPeriod)
+	assert.Equal(t, 30*time.Second, cfg.Pool.ConnectTimeout, "default")
+	assert.True(t, cfg.Maintenance)
+	assert.Equal(t, "back soon", cfg.MaintenanceMessage)
+}
+
+func TestLoadServerLimits(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, `
+server:
+  autocert_domains: a.example.com, b.example.com
+  autocert_cache: /var/cache/autocert
+  http_redirect_addr: ":80"
+  request_timeout: 1m
+  max_body_bytes: 0
+  max_attachment_bytes: 2048
+  compress_types: none
+`)
+	t.Setenv("CURRENTZ_MAX_UPLOAD_BYTES", "4096")
+
+	cfg, err := LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.TLS())
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, cfg.AutocertDomains)
+	assert.Equal(t, "/var/cache/autocert", cfg.AutocertCache)
+	assert.Equal(t, ":80", cfg.HTTPRedirectAddr)
+	require.NotNil(t, cfg.RequestTimeout)
+	assert.Equal(t, time.Minute, *cfg.RequestTimeout)
+	require.NotNil(t, cfg.MaxBodyBytes)
+	assert.Equal(t, int64(0), *cfg.MaxBodyBytes, "zero is kept, not the default")
+	require.NotNil(t, cfg.MaxUploadBytes)
+	assert.Equal(t, int64(4096), *cfg.MaxUploadBytes)
+	assert.Equal(t, int64(2048), cfg.MaxAttachmentBytes)
+	assert.Nil(t, cfg.CompressMinSize, "unset keeps the default")
+	assert.Equal(t, []string{}, cfg.CompressTypes, "none turns compression off")
+
+	t.Setenv("CURRENTZ_COMPRESS_TYPES", "Text/CSV")
+	t.Setenv("CURRENTZ_COMPRESS_MIN_SIZE", "512")
+	cfg, err = LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"text/csv"}, cfg.CompressTypes)
+	require.NotNil(t, cfg.CompressMinSize)
+	assert.Equal(t, 512, *cfg.CompressMinSize)
+
+	t.Setenv("CURRENTZ_MAX_BODY_BYTES", "-1")
+	_, err = LoadServer(path, nil)
+	assert.ErrorContains(t, err, "CURRENTZ_MAX_BODY_BYTES")
+
+	path = writeConfig(t, "server:\n  http_redirect_addr: \":80\"\n")
+	t.Setenv("CURRENTZ_MAX_BODY_BYTES", "")
+	_, err = LoadServer(path, nil)
+	assert.ErrorContains(t, err, "needs TLS")
+}
+
+func TestLoadBlob(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "")
+
+	cfg, err := LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Blob.Backend, "no storage")
+
+	t.Setenv("CURRENTZ_BLOB_DIR", "/var/lib/currentz/blobs")
+	cfg, err = LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "local", cfg.Blob.Backend)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "s3_secret"), []byte("from-file\n"), 0o600))
+	path = writeConfig(t, `
+blob:
+  s3:
+    bucket: receipts
+    region: eu-west-1
+    access_key_id: file-key
+    path_style: true
+`)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "aws-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "aws-secret")
+	t.Setenv("CURRENTZ_S3_SECRET_ACCESS_KEY_FILE", filepath.Join(dir, "s3_secret"))
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+	cfg, err = LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "s3", cfg.Blob.Backend, "a bucket beats a directory")
+	assert.Equal(t, "receipts", cfg.Blob.S3.Bucket)
+	assert.Equal(t, "us-east-1", cfg.Blob.S3.Region)
+	assert.Equal(t, "aws-key", cfg.Blob.S3.AccessKeyID)
+	assert.Equal(t, "from-file", cfg.Blob.S3.SecretAccessKey, "the currentz variable beats the AWS one")
+	assert.Equal(t, "token", cfg.Blob.S3.SessionToken)
+	assert.True(t, cfg.Blob.S3.PathStyle)
+}
+
+func TestLoadServerProfiles(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "")
+
+	// No profile: open to any origin, no key needed, no demo data.
+	cfg, err := LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Profile(""), cfg.Profile)
+	assert.Equal(t, []string{"*"}, cfg.CORSOrigins)
+	assert.False(t, cfg.RequireAuth)
+	assert.False(t, cfg.Demo)
+
+	t.Setenv("APP_ENV", "dev")
+	cfg, err = LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Development, cfg.Profile)
+	assert.True(t, cfg.Demo, "no database, so demo data")
+	t.Setenv("DATABASE_URL", "postgres://db")
+	cfg, err = LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.False(t, cfg.Demo)
+
+	t.Setenv("APP_ENV", "staging")
+	_, err = LoadServer(path, nil)
+	assert.ErrorContains(t, err, "an API key is required")
+	t.Setenv("CURRENTZ_API_KEY", "key")
+	cfg, err = LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.RequireAuth)
+	assert.Empty(t, cfg.CORSOrigins)
+
+	// Explicit settings beat the profile's.
+	t.Setenv("APP_ENV", "Production")
+	t.Setenv("CURRENTZ_CORS_ORIGINS", "https://a.example.com, https://b.example.com")
+	cfg, err = LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Production, cfg.Profile)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.CORSOrigins)
+	t.Setenv("CURRENTZ_DEMO", "true")
+	_, err = LoadServer(path, nil)
+	assert.ErrorContains(t, err, "production profile")
+
+	t.Setenv("APP_ENV", "qa")
+	_, err = LoadServer(path, nil)
+	assert.ErrorContains(t, err, `APP_ENV: unknown profile "qa"`)
+}
+
+func TestLoadScopedKeys(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "server:\n  api_keys: \"w1dget=read:forecast,read:transactions  YmlsbHM==write:recurring\"\n")
+	cfg, err := LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"w1dget":   {"read:forecast", "read:transactions"},
+		"YmlsbHM=": {"write:recurring"},
+	}, cfg.ScopedKeys)
+
+	// A scoped key is enough for a profile that requires one.
+	t.Setenv("APP_ENV", "production")
+	_, err = LoadServer(path, nil)
+	require.NoError(t, err)
+
+	t.Setenv("CURRENTZ_API_KEYS", "k3y=admin no-scopes")
+	_, err = LoadServer(path, nil)
+	assert.ErrorContains(t, err, "key 2: expected <key>=<scope>,...")
+}
+
+func TestLoadSecrets(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("p@ss:word\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jwt_secret"), []byte("from-file\n"), 0o600))
+	path := writeConfig(t, `
+database:
+  url: postgres://currentz@db:5432/currentz?sslmode=disable
+  password: in-the-file
+server:
+  jwt_secret: file:`+filepath.Join(dir, "jwt_secret")+`
+`)
+
+	cfg, err := LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://currentz:in-the-file@db:5432/currentz?sslmode=disable", cfg.DatabaseURL)
+	assert.Equal(t, "from-file", cfg.JWTSecret)
+
+	// DB_PASSWORD_FILE beats the file, and is escaped into the URL.
+	t.Setenv("DB_PASSWORD_FILE", filepath.Join(dir, "db_password"))
+	cfg, err = LoadServer(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://currentz:p%40ss%3Aword@db:5432/currentz?sslmode=disable", cfg.DatabaseURL)
+
+	// So does DB_PASSWORD itself; key=value connection strings work too.
+	t.Setenv("DB_PASSWORD", "it's")
+	t.Setenv("DB_URL", "host=db user=currentz")
+	cli, err := Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `host=db user=currentz password='it\'s'`, cli.DatabaseURL)
+
+	t.Setenv("CURRENTZ_API_KEY_FILE", filepath.Join(dir, "missing"))
+	_, err = LoadServer(path, nil)
+	assert.ErrorContains(t, err, "CURRENTZ_API_KEY_FILE: ")
+}
+
+func TestLoadErrorsNameTheKey(t *testing.T) {
+	clearEnv(t)
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		wants   []string
+	}{
+		{
+			name:    "unknown key",
+			content: "database:\n  urll: postgres://x\n",
+			wants:   []string{`line 2: unknown key "database.urll"`},
+		},
+		{
+			name:    "value for a section",
+			content: "server: 8080\n",
+			wants:   []string{"server: expected a section"},
+		},
+		{
+			name:    "bad values, all of them",
+			content: "demo: true\ndatabase:\n  max_conns: lots\ntimezone: Mars/Olympus\nnotifications:\n  large_bill_threshold: 0\n",
+			wants: []string{
+				"database.max_conns (",
+				"line 3): invalid value \"lots\"",
+				"timezone (",
+				"unknown timezone \"Mars/Olympus\"",
+				"notifications.large_bill_threshold (",
+			},
+		},
+		{
+			name:    "bad environment variable",
+			content: "demo: true\n",
+			env:     map[string]string{"CURRENTZ_CURRENCY": "euro"},
+			wants:   []string{"CURRENTZ_CURRENCY:"},
+		},
+		{
+			name:    "not YAML",
+			content: "demo: [true\n",
+			wants:   []string{"config.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := writeConfig(t, tt.content)
+			_, err := LoadServer(path, nil)
+			require.Error(t, err)
+			for _, want := range tt.wants {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileKeys lists every key a config file may set, sections joined with
+// dots as in "database.url".
+var fileKeys = []string{
+	"database.url",
+	"database.password",
+	"database.encryption_key",
+	"database.max_conns",
+	"database.min_conns",
+	"database.health_check_period",
+	"database.connect_timeout",
+	"server.port",
+	"server.api_key",
+	"server.api_keys",
+	"server.jwt_secret",
+	"server.require_auth",
+	"server.cors_origins",
+	"server.static_dir",
+	"server.maintenance",
+	"server.maintenance_message",
+	"server.tls_cert",
+	"server.tls_key",
+	"server.autocert_domains",
+	"server.autocert_email",
+	"server.autocert_cache",
+	"server.http_redirect_addr",
+	"server.request_timeout",
+	"server.max_body_bytes",
+	"server.max_upload_bytes",
+	"server.max_attachment_bytes",
+	"server.compress_min_size",
+	"server.compress_types",
+	"blob.backend",
+	"blob.dir",
+	"blob.s3.endpoint",
+	"blob.s3.region",
+	"blob.s3.bucket",
+	"blob.s3.access_key_id",
+	"blob.s3.secret_access_key",
+	"blob.s3.session_token",
+	"blob.s3.path_style",
+	"remote.url",
+	"remote.api_key",
+	"profile",
+	"demo",
+	"locale",
+	"timezone",
+	"currency",
+	"forecast_days",
+	"notifications.low_balance_threshold",
+	"notifications.large_bill_threshold",
+	"notifications.min_change",
+}
+
+// fileValue is one key's value as written in the file.
+type fileValue struct {
+	value string
+	line  int
+}
+
+// file is a parsed config file: the keys it sets, by dotted name.
+type file struct {
+	path   string
+	values map[string]fileValue
+}
+
+// DefaultPath is where the config file is looked for when no other is
+// named: $XDG_CONFIG_HOME/currentz/config.yaml, or
+// ~/.config/currentz/config.yaml.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "currentz", "config.yaml")
+}
+
+// readFile parses the config file at path. A missing file is only an error
+// if it was asked for by name; at the default path it just sets nothing.
+func readFile(path string, named bool) (*file, error) {
+	f := &file{path: path, values: map[string]fileValue{}}
+	if path == "" {
+		return f, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !named {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return f, nil
+	}
+	if err := f.flatten(doc.Content[0], ""); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// flatten records the values under n, a mapping, with their keys prefixed
+// by prefix.
+func (f *file) flatten(n *yaml.Node, prefix string) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected keys and values", n.Line)
+	}
+	var errs []error
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		key := prefix + k.Value
+		section := isSection(key)
+		switch {
+		case !section && !slices.Contains(fileKeys, key):
+			errs = append(errs, fmt.Errorf("line %d: unknown key %q", k.Line, key))
+		case v.Tag == "!!null":
+			// Written but left empty: unset.
+		case section:
+			if v.Kind != yaml.MappingNode {
+				errs = append(errs, fmt.Errorf("line %d: %s: expected a section of keys", v.Line, key))
+				continue
+			}
+			if err := f.flatten(v, key+"."); err != nil {
+				errs = append(errs, err)
+			}
+		case v.Kind != yaml.ScalarNode:
+			errs = append(errs, fmt.Errorf("line %d: %s: expected a single value", v.Line, key))
+		default:
+			f.values[key] = fileValue{value: v.Value, line: v.Line}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isSection reports whether key holds other keys, like "database".
+func isSection(key string) bool {
+	return slices.ContainsFunc(fileKeys, func(k string) bool {
+		return strings.HasPrefix(k, key+".")
+	})
+}
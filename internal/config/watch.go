@@ -0,0 +1,46 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Watch checks the config file LoadServer reads every interval and, when
+// it has changed, loads the server settings again and passes them to
+// reload, or the error that stopped them loading. Creating or removing the
+// file counts as a change. It checks in the background until ctx is done.
+func Watch(ctx context.Context, path string, flags Flags, interval time.Duration, reload func(*Server, error)) {
+	resolved, _ := resolvePath(path)
+	last := stamp(resolved)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if now := stamp(resolved); now != last {
+				last = now
+				reload(LoadServer(path, flags))
+			}
+		}
+	}()
+}
+
+// fileStamp tells versions of a file apart without reading it.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func stamp(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
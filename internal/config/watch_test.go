@@ -0,0 +1,68 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "server:\n  cors_origins: https://a.example.com\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type result struct {
+		cfg *Server
+		err error
+	}
+	reloads := make(chan result, 10)
+	Watch(ctx, path, nil, 5*time.Millisecond, func(cfg *Server, err error) {
+		reloads <- result{cfg, err}
+	})
+
+	rewrite := func(content string, age time.Duration) {
+		// Written aside and moved into place, so the watcher never sees it
+		// half done. Each gets its own time, as filesystems with coarse
+		// timestamps could otherwise hide a write.
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0o600))
+		mod := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(tmp, mod, mod))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+	next := func() result {
+		select {
+		case r := <-reloads:
+			return r
+		case <-time.After(2 * time.Second):
+			t.Fatal("no reload")
+			return result{}
+		}
+	}
+
+	rewrite("server:\n  cors_origins: https://b.example.com\n", time.Hour)
+	r := next()
+	require.NoError(t, r.err)
+	assert.Equal(t, []string{"https://b.example.com"}, r.cfg.CORSOrigins)
+
+	// A broken file is reported, and fixing it reloads again.
+	rewrite("server:\n  cors_origins: [\n", 2*time.Hour)
+	r = next()
+	assert.Error(t, r.err)
+	rewrite("notifications:\n  low_balance_threshold: 100\n", 3*time.Hour)
+	r = next()
+	require.NoError(t, r.err)
+	require.NotNil(t, r.cfg.Defaults.LowBalanceThreshold)
+	assert.Equal(t, 100.0, *r.cfg.Defaults.LowBalanceThreshold)
+
+	select {
+	case r := <-reloads:
+		t.Fatalf("reloaded without a change: %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -18,6 +18,7 @@ const (
 	RecurrenceIntervalBiweekly RecurrenceInterval = "biweekly"
 	RecurrenceIntervalMonthly  RecurrenceInterval = "monthly"
 	RecurrenceIntervalYearly   RecurrenceInterval = "yearly"
+	RecurrenceIntervalCustom   RecurrenceInterval = "custom"
 )
 
 func (e *RecurrenceInterval) Scan(src interface{}) error {
@@ -55,17 +56,170 @@ func (ns NullRecurrenceInterval) Value() (driver.Value, error) {
 	return string(ns.RecurrenceInterval), nil
 }
 
+type Accounts struct {
+	ID           int32            `json:"id"`
+	Name         string           `json:"name"`
+	Type         string           `json:"type"`
+	StatementDay pgtype.Int4      `json:"statement_day"`
+	DueDay       pgtype.Int4      `json:"due_day"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	Balance      pgtype.Numeric   `json:"balance"`
+	Apy          pgtype.Numeric   `json:"apy"`
+	Compounding  string           `json:"compounding"`
+}
+
+type ArchivedTransactions struct {
+	ID          int32            `json:"id"`
+	Date        pgtype.Date      `json:"date"`
+	Amount      pgtype.Numeric   `json:"amount"`
+	Description string           `json:"description"`
+	Type        string           `json:"type"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	Category    pgtype.Text      `json:"category"`
+	Currency    string           `json:"currency"`
+	AccountID   pgtype.Int4      `json:"account_id"`
+	ArchivedAt  pgtype.Timestamp `json:"archived_at"`
+}
+
+type Attachments struct {
+	ID            int32            `json:"id"`
+	TransactionID int32            `json:"transaction_id"`
+	Filename      string           `json:"filename"`
+	ContentType   string           `json:"content_type"`
+	Size          int64            `json:"size"`
+	StorageKey    string           `json:"storage_key"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID         int64            `json:"id"`
+	Actor      string           `json:"actor"`
+	Action     string           `json:"action"`
+	Entity     string           `json:"entity"`
+	EntityID   string           `json:"entity_id"`
+	BeforeData []byte           `json:"before_data"`
+	AfterData  []byte           `json:"after_data"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type Envelopes struct {
+	ID            int32            `json:"id"`
+	Name          string           `json:"name"`
+	MonthlyAmount pgtype.Numeric   `json:"monthly_amount"`
+	Target        pgtype.Numeric   `json:"target"`
+	StartDate     pgtype.Date      `json:"start_date"`
+	Category      pgtype.Text      `json:"category"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type ExchangeRates struct {
+	Currency  string           `json:"currency"`
+	Rate      pgtype.Numeric   `json:"rate"`
+	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+}
+
+type ForecastSnapshots struct {
+	SnapshotDate    pgtype.Date      `json:"snapshot_date"`
+	StartingBalance pgtype.Numeric   `json:"starting_balance"`
+	Forecast        []byte           `json:"forecast"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
+type HouseholdInvitations struct {
+	ID          int32            `json:"id"`
+	HouseholdID int32            `json:"household_id"`
+	Role        string           `json:"role"`
+	TokenHash   string           `json:"token_hash"`
+	InvitedBy   pgtype.Int4      `json:"invited_by"`
+	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+	AcceptedBy  pgtype.Int4      `json:"accepted_by"`
+	AcceptedAt  pgtype.Timestamp `json:"accepted_at"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type HouseholdMembers struct {
+	HouseholdID int32            `json:"household_id"`
+	UserID      int32            `json:"user_id"`
+	Role        string           `json:"role"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type Households struct {
+	ID        int32            `json:"id"`
+	Name      string           `json:"name"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type IncomeTaxDetails struct {
+	TransactionID int32          `json:"transaction_id"`
+	GrossAmount   pgtype.Numeric `json:"gross_amount"`
+	TaxWithheld   pgtype.Numeric `json:"tax_withheld"`
+}
+
+type NotificationChannels struct {
+	ID        int32            `json:"id"`
+	Name      string           `json:"name"`
+	Type      string           `json:"type"`
+	Config    []byte           `json:"config"`
+	Enabled   bool             `json:"enabled"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type NotificationsSent struct {
+	Kind   string           `json:"kind"`
+	Key    string           `json:"key"`
+	SentAt pgtype.Timestamp `json:"sent_at"`
+	Metric pgtype.Numeric   `json:"metric"`
+}
+
+type RecurringReminders struct {
+	RecurringID int32 `json:"recurring_id"`
+	DaysBefore  int32 `json:"days_before"`
+}
+
 type RecurringTransactions struct {
-	ID          int32              `json:"id"`
-	Description string             `json:"description"`
-	Type        string             `json:"type"`
-	Amount      pgtype.Numeric     `json:"amount"`
-	StartDate   pgtype.Date        `json:"start_date"`
-	Interval    RecurrenceInterval `json:"interval"`
-	DayOfWeek   pgtype.Int4        `json:"day_of_week"`
-	DayOfMonth  pgtype.Int4        `json:"day_of_month"`
-	EndDate     pgtype.Date        `json:"end_date"`
-	Active      bool               `json:"active"`
+	ID              int32              `json:"id"`
+	Description     string             `json:"description"`
+	Type            string             `json:"type"`
+	Amount          pgtype.Numeric     `json:"amount"`
+	StartDate       pgtype.Date        `json:"start_date"`
+	Interval        RecurrenceInterval `json:"interval"`
+	DayOfWeek       pgtype.Int4        `json:"day_of_week"`
+	DayOfMonth      pgtype.Int4        `json:"day_of_month"`
+	EndDate         pgtype.Date        `json:"end_date"`
+	Active          bool               `json:"active"`
+	DeletedAt       pgtype.Timestamp   `json:"deleted_at"`
+	Currency        string             `json:"currency"`
+	Prorate         bool               `json:"prorate"`
+	PausedFrom      pgtype.Date        `json:"paused_from"`
+	PausedUntil     pgtype.Date        `json:"paused_until"`
+	Version         int32              `json:"version"`
+	OccurrenceCount pgtype.Int4        `json:"occurrence_count"`
+	WeekOfMonth     pgtype.Int4        `json:"week_of_month"`
+	BusinessDay     bool               `json:"business_day"`
+	Rrule           pgtype.Text        `json:"rrule"`
+	Category        pgtype.Text        `json:"category"`
+	AccountID       pgtype.Int4        `json:"account_id"`
+}
+
+type RefreshTokens struct {
+	TokenHash string           `json:"token_hash"`
+	UserID    int32            `json:"user_id"`
+	Family    string           `json:"family"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	RevokedAt pgtype.Timestamp `json:"revoked_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type Scenarios struct {
+	ID         int32            `json:"id"`
+	Name       string           `json:"name"`
+	Definition []byte           `json:"definition"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type SelfEmploymentIncome struct {
+	TransactionID int32 `json:"transaction_id"`
 }
 
 type Settings struct {
@@ -74,6 +228,13 @@ type Settings struct {
 	UpdatedAt pgtype.Timestamp `json:"updated_at"`
 }
 
+type SyncChanges struct {
+	Entity     string `json:"entity"`
+	EntityID   string `json:"entity_id"`
+	Seq        int64  `json:"seq"`
+	CreatedSeq int64  `json:"created_seq"`
+}
+
 type Transactions struct {
 	ID          int32            `json:"id"`
 	Date        pgtype.Date      `json:"date"`
@@ -81,4 +242,18 @@ type Transactions struct {
 	Description string           `json:"description"`
 	Type        string           `json:"type"`
 	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	DeletedAt   pgtype.Timestamp `json:"deleted_at"`
+	Category    pgtype.Text      `json:"category"`
+	Currency    string           `json:"currency"`
+	AccountID   pgtype.Int4      `json:"account_id"`
+	Status      string           `json:"status"`
+	Version     int32            `json:"version"`
+}
+
+type Users struct {
+	ID           int32            `json:"id"`
+	Username     string           `json:"username"`
+	PasswordHash string           `json:"password_hash"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	Role         string           `json:"role"`
 }
@@ -6,26 +6,196 @@ package database
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	// Accepting an invitation that was already used changes nothing, so the
+	// second of two racing accepts sees 0 rows.
+	AcceptHouseholdInvitation(ctx context.Context, arg AcceptHouseholdInvitationParams) (int64, error)
+	AddHouseholdMember(ctx context.Context, arg AddHouseholdMemberParams) (HouseholdMembers, error)
+	// Moves cleared transactions dated before the cutoff into the archive.
+	// Pending ones still count toward the forecast, and ones with attachments
+	// keep them, so both stay.
+	ArchiveTransactions(ctx context.Context, cutoff pgtype.Date) (int64, error)
+	CountHouseholdOwners(ctx context.Context, householdID int32) (int64, error)
+	CountUsersByRole(ctx context.Context, role string) (int64, error)
+	CreateAccount(ctx context.Context, arg CreateAccountParams) (Accounts, error)
+	CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachments, error)
+	CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) error
+	CreateEnvelope(ctx context.Context, arg CreateEnvelopeParams) (Envelopes, error)
+	CreateHousehold(ctx context.Context, name string) (Households, error)
+	CreateHouseholdInvitation(ctx context.Context, arg CreateHouseholdInvitationParams) (HouseholdInvitations, error)
+	CreateNotificationChannel(ctx context.Context, arg CreateNotificationChannelParams) (NotificationChannels, error)
 	CreateRecurring(ctx context.Context, arg CreateRecurringParams) (RecurringTransactions, error)
-	CreateTransaction(ctx context.Context, arg CreateTransactionParams) error
-	DeleteRecurring(ctx context.Context, id int32) error
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error
+	CreateScenario(ctx context.Context, arg CreateScenarioParams) (Scenarios, error)
+	CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transactions, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (Users, error)
+	DeleteAccount(ctx context.Context, id int32) error
+	DeleteAllAccounts(ctx context.Context) error
+	DeleteAllArchivedTransactions(ctx context.Context) error
+	DeleteAllEnvelopes(ctx context.Context) error
+	DeleteAllExchangeRates(ctx context.Context) error
+	DeleteAllNotificationChannels(ctx context.Context) error
+	DeleteAllRecurring(ctx context.Context) error
+	DeleteAllScenarios(ctx context.Context) error
+	DeleteAllSettings(ctx context.Context) error
+	DeleteAllTransactions(ctx context.Context) error
+	DeleteAttachment(ctx context.Context, arg DeleteAttachmentParams) (int64, error)
+	DeleteEnvelope(ctx context.Context, id int32) (int64, error)
+	DeleteExchangeRate(ctx context.Context, currency string) (int64, error)
+	DeleteExpiredRefreshTokens(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error)
+	DeleteHousehold(ctx context.Context, id int32) (int64, error)
+	DeleteHouseholdInvitation(ctx context.Context, arg DeleteHouseholdInvitationParams) (int64, error)
+	DeleteNotificationChannel(ctx context.Context, id int32) (int64, error)
+	DeleteRecurring(ctx context.Context, id int32) (int64, error)
+	DeleteRecurringReminder(ctx context.Context, recurringID int32) (int64, error)
+	DeleteScenario(ctx context.Context, id int32) (int64, error)
 	DeleteSetting(ctx context.Context, key string) error
-	DeleteTransaction(ctx context.Context, id int32) error
+	DeleteTransaction(ctx context.Context, id int32) (int64, error)
+	DeleteUser(ctx context.Context, id int32) (int64, error)
+	GetAccountByID(ctx context.Context, id int32) (Accounts, error)
 	GetAllSettings(ctx context.Context) ([]Settings, error)
 	GetAllTransactions(ctx context.Context) ([]Transactions, error)
+	GetAttachment(ctx context.Context, arg GetAttachmentParams) (Attachments, error)
+	// Per-day totals for the forecast, kept apart by currency for conversion
+	// and by account for card due dates and interest.
+	GetDailyTransactionSums(ctx context.Context, arg GetDailyTransactionSumsParams) ([]GetDailyTransactionSumsRow, error)
+	GetEnvelope(ctx context.Context, id int32) (Envelopes, error)
+	GetForecastSnapshot(ctx context.Context, snapshotDate pgtype.Date) (ForecastSnapshots, error)
+	GetHousehold(ctx context.Context, id int32) (Households, error)
+	GetHouseholdInvitationByToken(ctx context.Context, tokenHash string) (HouseholdInvitations, error)
+	GetHouseholdMember(ctx context.Context, arg GetHouseholdMemberParams) (HouseholdMembers, error)
+	// The biggest expense in each currency from start_date to end_date, the
+	// earliest of any tied for it. Which is biggest overall depends on the
+	// exchange rates.
+	GetLargestExpenses(ctx context.Context, arg GetLargestExpensesParams) ([]Transactions, error)
+	GetNotificationChannel(ctx context.Context, id int32) (NotificationChannels, error)
+	// Pending transactions from before the forecast starts. The bank balance
+	// doesn't include them yet, so the forecast still has to.
+	GetPendingTransactionsBefore(ctx context.Context, date pgtype.Date) ([]Transactions, error)
 	GetRecurringByID(ctx context.Context, id int32) (RecurringTransactions, error)
+	// Deleted ones included, as for GetTransactionsByIDs.
+	GetRecurringsByIDs(ctx context.Context, ids []int32) ([]RecurringTransactions, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (RefreshTokens, error)
+	GetScenario(ctx context.Context, id int32) (Scenarios, error)
+	GetSelfEmploymentIncomeBetween(ctx context.Context, arg GetSelfEmploymentIncomeBetweenParams) ([]GetSelfEmploymentIncomeBetweenRow, error)
 	GetSetting(ctx context.Context, key string) (string, error)
+	// Spending per day from start_date to end_date, with the ISO week and
+	// weekday (1 for Monday) each day falls in, for the spending heatmap. Days
+	// are kept apart by currency for conversion. idx_transactions_spending
+	// covers it.
+	GetSpendingHeatmap(ctx context.Context, arg GetSpendingHeatmapParams) ([]GetSpendingHeatmapRow, error)
+	GetSyncCursor(ctx context.Context) (int64, error)
+	GetTaxTotalsBetween(ctx context.Context, arg GetTaxTotalsBetweenParams) (GetTaxTotalsBetweenRow, error)
+	// Totals kept apart by currency, to be converted to the base currency
+	// before they are added up. A NULL start_date or end_date leaves that side
+	// of the range open, and a NULL status takes every status.
+	GetTransactionAggregatesByCurrency(ctx context.Context, arg GetTransactionAggregatesByCurrencyParams) ([]GetTransactionAggregatesByCurrencyRow, error)
 	GetTransactionByID(ctx context.Context, id int32) (Transactions, error)
 	GetTransactionsByDateRange(ctx context.Context, arg GetTransactionsByDateRangeParams) ([]Transactions, error)
+	// Deleted ones included, so sync can tell them apart from missing ones.
+	GetTransactionsByIDs(ctx context.Context, ids []int32) ([]Transactions, error)
+	GetTransactionsByStatus(ctx context.Context, status string) ([]Transactions, error)
 	GetTransactionsByType(ctx context.Context, type_ string) ([]Transactions, error)
+	GetUserByID(ctx context.Context, id int32) (Users, error)
+	GetUserByUsername(ctx context.Context, username string) (Users, error)
+	InsertBackupAccount(ctx context.Context, arg InsertBackupAccountParams) error
+	InsertBackupArchivedTransaction(ctx context.Context, arg InsertBackupArchivedTransactionParams) error
+	InsertBackupEnvelope(ctx context.Context, arg InsertBackupEnvelopeParams) error
+	InsertBackupExchangeRate(ctx context.Context, arg InsertBackupExchangeRateParams) error
+	InsertBackupNotificationChannel(ctx context.Context, arg InsertBackupNotificationChannelParams) error
+	InsertBackupRecurring(ctx context.Context, arg InsertBackupRecurringParams) error
+	InsertBackupScenario(ctx context.Context, arg InsertBackupScenarioParams) error
+	InsertBackupSetting(ctx context.Context, arg InsertBackupSettingParams) error
+	InsertBackupTransaction(ctx context.Context, arg InsertBackupTransactionParams) error
+	// The figure the latest notification of kind reported, leaving out those
+	// that reported none.
+	LastNotificationMetric(ctx context.Context, kind string) (pgtype.Numeric, error)
+	ListAccounts(ctx context.Context) ([]Accounts, error)
+	ListAccountsForBackup(ctx context.Context) ([]Accounts, error)
 	ListActiveRecurring(ctx context.Context) ([]RecurringTransactions, error)
+	ListArchivedTransactions(ctx context.Context, arg ListArchivedTransactionsParams) ([]ArchivedTransactions, error)
+	ListArchivedTransactionsForBackup(ctx context.Context) ([]ArchivedTransactions, error)
+	ListAttachments(ctx context.Context, transactionID int32) ([]Attachments, error)
+	ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error)
+	ListAuditHistory(ctx context.Context, entities []string) ([]AuditLog, error)
+	ListDeletedRecurring(ctx context.Context) ([]RecurringTransactions, error)
+	ListDeletedTransactions(ctx context.Context) ([]Transactions, error)
+	ListEnvelopes(ctx context.Context) ([]Envelopes, error)
+	ListEnvelopesForBackup(ctx context.Context) ([]Envelopes, error)
+	ListExchangeRates(ctx context.Context) ([]ExchangeRates, error)
+	ListExchangeRatesForBackup(ctx context.Context) ([]ExchangeRates, error)
+	ListForecastSnapshotDates(ctx context.Context) ([]pgtype.Date, error)
+	ListHouseholdInvitations(ctx context.Context, householdID int32) ([]HouseholdInvitations, error)
+	ListHouseholdMembers(ctx context.Context, householdID int32) ([]ListHouseholdMembersRow, error)
+	ListHouseholds(ctx context.Context) ([]Households, error)
+	ListIncomeTaxDetails(ctx context.Context) ([]IncomeTaxDetails, error)
+	ListNotificationChannels(ctx context.Context) ([]NotificationChannels, error)
+	ListNotificationChannelsForBackup(ctx context.Context) ([]NotificationChannels, error)
+	// Attachments whose transactions PurgeDeletedTransactions is about to
+	// remove, so their blobs can be removed too.
+	ListPurgeableAttachments(ctx context.Context, cutoff pgtype.Timestamp) ([]Attachments, error)
 	ListRecurring(ctx context.Context) ([]RecurringTransactions, error)
-	SetRecurringActive(ctx context.Context, arg SetRecurringActiveParams) error
+	ListRecurringForBackup(ctx context.Context) ([]RecurringTransactions, error)
+	ListRecurringReminders(ctx context.Context) ([]RecurringReminders, error)
+	// Live recurrings sorted on sort_key (date, meaning start_date, amount or
+	// description) either way, or by id alone when it is empty. Ties are
+	// broken and pages taken as in ListTransactionsSorted.
+	ListRecurringSorted(ctx context.Context, arg ListRecurringSortedParams) ([]RecurringTransactions, error)
+	ListScenarios(ctx context.Context) ([]Scenarios, error)
+	ListScenariosForBackup(ctx context.Context) ([]Scenarios, error)
+	ListSelfEmploymentIncome(ctx context.Context) ([]int32, error)
+	// The records changed after since, oldest change first.
+	ListSyncChanges(ctx context.Context, arg ListSyncChangesParams) ([]SyncChanges, error)
+	ListTransactionsForBackup(ctx context.Context) ([]Transactions, error)
+	// Live transactions, of one status when given, sorted on sort_key (date,
+	// amount or description) either way. id breaks ties in the same direction,
+	// so equal keys always come back in the same order. Descriptions compare
+	// byte by byte, as Go's strings do. Pages are keyset ones: given after_id
+	// and the sort key of the row it names, rows start just after that row,
+	// and page_size caps how many come back.
+	ListTransactionsSorted(ctx context.Context, arg ListTransactionsSortedParams) ([]Transactions, error)
+	ListUserHouseholds(ctx context.Context, userID int32) ([]ListUserHouseholdsRow, error)
+	ListUsers(ctx context.Context) ([]Users, error)
+	NotificationSent(ctx context.Context, arg NotificationSentParams) (bool, error)
+	PurgeDeletedRecurring(ctx context.Context, cutoff pgtype.Timestamp) (int64, error)
+	PurgeDeletedTransactions(ctx context.Context, cutoff pgtype.Timestamp) (int64, error)
+	RecordNotification(ctx context.Context, arg RecordNotificationParams) error
+	RemoveHouseholdMember(ctx context.Context, arg RemoveHouseholdMemberParams) (int64, error)
+	ResetAccountsSequence(ctx context.Context) error
+	ResetEnvelopesSequence(ctx context.Context) error
+	ResetNotificationChannelsSequence(ctx context.Context) error
+	ResetRecurringSequence(ctx context.Context) error
+	ResetScenariosSequence(ctx context.Context) error
+	ResetTransactionsSequence(ctx context.Context) error
+	RestoreRecurring(ctx context.Context, id int32) (RecurringTransactions, error)
+	RestoreTransaction(ctx context.Context, id int32) (Transactions, error)
+	// Revoking an already revoked token changes nothing, so a refresh that
+	// lost a race sees 0 rows.
+	RevokeRefreshToken(ctx context.Context, tokenHash string) (int64, error)
+	RevokeRefreshTokenFamily(ctx context.Context, family string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int32) error
+	SetAccountInterest(ctx context.Context, arg SetAccountInterestParams) (Accounts, error)
+	SetHouseholdMemberRole(ctx context.Context, arg SetHouseholdMemberRoleParams) (int64, error)
+	SetRecurringActive(ctx context.Context, arg SetRecurringActiveParams) (RecurringTransactions, error)
+	SetRecurringPause(ctx context.Context, arg SetRecurringPauseParams) (RecurringTransactions, error)
+	SetRecurringReminder(ctx context.Context, arg SetRecurringReminderParams) (RecurringReminders, error)
+	SetTransactionStatus(ctx context.Context, arg SetTransactionStatusParams) (Transactions, error)
+	SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (int64, error)
+	SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error)
+	TagSelfEmploymentIncome(ctx context.Context, transactionID int32) error
+	UntagSelfEmploymentIncome(ctx context.Context, transactionID int32) (int64, error)
+	UpdateEnvelope(ctx context.Context, arg UpdateEnvelopeParams) (Envelopes, error)
+	UpdateNotificationChannel(ctx context.Context, arg UpdateNotificationChannelParams) (NotificationChannels, error)
 	UpdateRecurring(ctx context.Context, arg UpdateRecurringParams) (RecurringTransactions, error)
+	UpdateScenario(ctx context.Context, arg UpdateScenarioParams) (Scenarios, error)
 	UpdateSetting(ctx context.Context, arg UpdateSettingParams) error
+	UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRates, error)
+	UpsertForecastSnapshot(ctx context.Context, arg UpsertForecastSnapshotParams) error
+	UpsertIncomeTaxDetail(ctx context.Context, arg UpsertIncomeTaxDetailParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -21,7 +21,15 @@ INSERT INTO recurring_transactions (
   day_of_week,
   day_of_month,
   end_date,
-  active
+  active,
+  currency,
+  prorate,
+  occurrence_count,
+  week_of_month,
+  business_day,
+  rrule,
+  category,
+  account_id
 ) VALUES (
   $1,
   $2,
@@ -31,21 +39,37 @@ INSERT INTO recurring_transactions (
   $6,
   $7,
   $8,
-  $9
+  $9,
+  $10,
+  $11,
+  $12,
+  $13,
+  $14,
+  $15,
+  $16,
+  $17
 )
-RETURNING id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active
+RETURNING id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id
 `
 
 type CreateRecurringParams struct {
-	Description string             `json:"description"`
-	Type        string             `json:"type"`
-	Amount      pgtype.Numeric     `json:"amount"`
-	StartDate   pgtype.Date        `json:"start_date"`
-	Interval    RecurrenceInterval `json:"interval"`
-	DayOfWeek   pgtype.Int4        `json:"day_of_week"`
-	DayOfMonth  pgtype.Int4        `json:"day_of_month"`
-	EndDate     pgtype.Date        `json:"end_date"`
-	Active      bool               `json:"active"`
+	Description     string             `json:"description"`
+	Type            string             `json:"type"`
+	Amount          pgtype.Numeric     `json:"amount"`
+	StartDate       pgtype.Date        `json:"start_date"`
+	Interval        RecurrenceInterval `json:"interval"`
+	DayOfWeek       pgtype.Int4        `json:"day_of_week"`
+	DayOfMonth      pgtype.Int4        `json:"day_of_month"`
+	EndDate         pgtype.Date        `json:"end_date"`
+	Active          bool               `json:"active"`
+	Currency        string             `json:"currency"`
+	Prorate         bool               `json:"prorate"`
+	OccurrenceCount pgtype.Int4        `json:"occurrence_count"`
+	WeekOfMonth     pgtype.Int4        `json:"week_of_month"`
+	BusinessDay     bool               `json:"business_day"`
+	Rrule           pgtype.Text        `json:"rrule"`
+	Category        pgtype.Text        `json:"category"`
+	AccountID       pgtype.Int4        `json:"account_id"`
 }
 
 func (q *Queries) CreateRecurring(ctx context.Context, arg CreateRecurringParams) (RecurringTransactions, error) {
@@ -59,6 +83,14 @@ func (q *Queries) CreateRecurring(ctx context.Context, arg CreateRecurringParams
 		arg.DayOfMonth,
 		arg.EndDate,
 		arg.Active,
+		arg.Currency,
+		arg.Prorate,
+		arg.OccurrenceCount,
+		arg.WeekOfMonth,
+		arg.BusinessDay,
+		arg.Rrule,
+		arg.Category,
+		arg.AccountID,
 	)
 	var i RecurringTransactions
 	err := row.Scan(
@@ -72,21 +104,38 @@ func (q *Queries) CreateRecurring(ctx context.Context, arg CreateRecurringParams
 		&i.DayOfMonth,
 		&i.EndDate,
 		&i.Active,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Prorate,
+		&i.PausedFrom,
+		&i.PausedUntil,
+		&i.Version,
+		&i.OccurrenceCount,
+		&i.WeekOfMonth,
+		&i.BusinessDay,
+		&i.Rrule,
+		&i.Category,
+		&i.AccountID,
 	)
 	return i, err
 }
 
-const deleteRecurring = `-- name: DeleteRecurring :exec
-DELETE FROM recurring_transactions WHERE id = $1
+const deleteRecurring = `-- name: DeleteRecurring :execrows
+UPDATE recurring_transactions
+SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
-func (q *Queries) DeleteRecurring(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteRecurring, id)
-	return err
+func (q *Queries) DeleteRecurring(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRecurring, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const getRecurringByID = `-- name: GetRecurringByID :one
-SELECT id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active FROM recurring_transactions WHERE id = $1
+SELECT id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id FROM recurring_transactions WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetRecurringByID(ctx context.Context, id int32) (RecurringTransactions, error) {
@@ -103,12 +152,24 @@ func (q *Queries) GetRecurringByID(ctx context.Context, id int32) (RecurringTran
 		&i.DayOfMonth,
 		&i.EndDate,
 		&i.Active,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Prorate,
+		&i.PausedFrom,
+		&i.PausedUntil,
+		&i.Version,
+		&i.OccurrenceCount,
+		&i.WeekOfMonth,
+		&i.BusinessDay,
+		&i.Rrule,
+		&i.Category,
+		&i.AccountID,
 	)
 	return i, err
 }
 
 const listActiveRecurring = `-- name: ListActiveRecurring :many
-SELECT id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active FROM recurring_transactions WHERE active = TRUE
+SELECT id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id FROM recurring_transactions WHERE active = TRUE AND deleted_at IS NULL
 `
 
 func (q *Queries) ListActiveRecurring(ctx context.Context) ([]RecurringTransactions, error) {
@@ -131,6 +192,65 @@ func (q *Queries) ListActiveRecurring(ctx context.Context) ([]RecurringTransacti
 			&i.DayOfMonth,
 			&i.EndDate,
 			&i.Active,
+			&i.DeletedAt,
+			&i.Currency,
+			&i.Prorate,
+			&i.PausedFrom,
+			&i.PausedUntil,
+			&i.Version,
+			&i.OccurrenceCount,
+			&i.WeekOfMonth,
+			&i.BusinessDay,
+			&i.Rrule,
+			&i.Category,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedRecurring = `-- name: ListDeletedRecurring :many
+SELECT id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id FROM recurring_transactions WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC
+`
+
+func (q *Queries) ListDeletedRecurring(ctx context.Context) ([]RecurringTransactions, error) {
+	rows, err := q.db.Query(ctx, listDeletedRecurring)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RecurringTransactions{}
+	for rows.Next() {
+		var i RecurringTransactions
+		if err := rows.Scan(
+			&i.ID,
+			&i.Description,
+			&i.Type,
+			&i.Amount,
+			&i.StartDate,
+			&i.Interval,
+			&i.DayOfWeek,
+			&i.DayOfMonth,
+			&i.EndDate,
+			&i.Active,
+			&i.DeletedAt,
+			&i.Currency,
+			&i.Prorate,
+			&i.PausedFrom,
+			&i.PausedUntil,
+			&i.Version,
+			&i.OccurrenceCount,
+			&i.WeekOfMonth,
+			&i.BusinessDay,
+			&i.Rrule,
+			&i.Category,
+			&i.AccountID,
 		); err != nil {
 			return nil, err
 		}
@@ -143,7 +263,7 @@ func (q *Queries) ListActiveRecurring(ctx context.Context) ([]RecurringTransacti
 }
 
 const listRecurring = `-- name: ListRecurring :many
-SELECT id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active FROM recurring_transactions ORDER BY id
+SELECT id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id FROM recurring_transactions WHERE deleted_at IS NULL ORDER BY id
 `
 
 func (q *Queries) ListRecurring(ctx context.Context) ([]RecurringTransactions, error) {
@@ -166,6 +286,107 @@ func (q *Queries) ListRecurring(ctx context.Context) ([]RecurringTransactions, e
 			&i.DayOfMonth,
 			&i.EndDate,
 			&i.Active,
+			&i.DeletedAt,
+			&i.Currency,
+			&i.Prorate,
+			&i.PausedFrom,
+			&i.PausedUntil,
+			&i.Version,
+			&i.OccurrenceCount,
+			&i.WeekOfMonth,
+			&i.BusinessDay,
+			&i.Rrule,
+			&i.Category,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecurringSorted = `-- name: ListRecurringSorted :many
+SELECT id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id FROM recurring_transactions
+WHERE deleted_at IS NULL
+  AND CASE
+    WHEN $1::text = 'date' AND $2::bool THEN (start_date, id) < ($3::date, $4::int)
+    WHEN $1::text = 'date' THEN (start_date, id) > ($3::date, $4::int)
+    WHEN $1::text = 'amount' AND $2::bool THEN (amount, id) < ($5::numeric, $4::int)
+    WHEN $1::text = 'amount' THEN (amount, id) > ($5::numeric, $4::int)
+    WHEN $1::text = 'description' AND $2::bool THEN (description COLLATE "C", id) < ($6::text COLLATE "C", $4::int)
+    WHEN $1::text = 'description' THEN (description COLLATE "C", id) > ($6::text COLLATE "C", $4::int)
+    WHEN $2::bool THEN id < $4::int
+    ELSE id > $4::int
+  END IS NOT FALSE
+ORDER BY
+  CASE WHEN $1::text = 'date' AND NOT $2::bool THEN start_date END ASC,
+  CASE WHEN $1::text = 'date' AND $2::bool THEN start_date END DESC,
+  CASE WHEN $1::text = 'amount' AND NOT $2::bool THEN amount END ASC,
+  CASE WHEN $1::text = 'amount' AND $2::bool THEN amount END DESC,
+  CASE WHEN $1::text = 'description' AND NOT $2::bool THEN description COLLATE "C" END ASC,
+  CASE WHEN $1::text = 'description' AND $2::bool THEN description COLLATE "C" END DESC,
+  CASE WHEN NOT $2::bool THEN id END ASC,
+  CASE WHEN $2::bool THEN id END DESC
+LIMIT $7::int
+`
+
+type ListRecurringSortedParams struct {
+	SortKey          string         `json:"sort_key"`
+	Descending       bool           `json:"descending"`
+	AfterDate        pgtype.Date    `json:"after_date"`
+	AfterID          pgtype.Int4    `json:"after_id"`
+	AfterAmount      pgtype.Numeric `json:"after_amount"`
+	AfterDescription pgtype.Text    `json:"after_description"`
+	PageSize         pgtype.Int4    `json:"page_size"`
+}
+
+// Live recurrings sorted on sort_key (date, meaning start_date, amount or
+// description) either way, or by id alone when it is empty. Ties are
+// broken and pages taken as in ListTransactionsSorted.
+func (q *Queries) ListRecurringSorted(ctx context.Context, arg ListRecurringSortedParams) ([]RecurringTransactions, error) {
+	rows, err := q.db.Query(ctx, listRecurringSorted,
+		arg.SortKey,
+		arg.Descending,
+		arg.AfterDate,
+		arg.AfterID,
+		arg.AfterAmount,
+		arg.AfterDescription,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RecurringTransactions{}
+	for rows.Next() {
+		var i RecurringTransactions
+		if err := rows.Scan(
+			&i.ID,
+			&i.Description,
+			&i.Type,
+			&i.Amount,
+			&i.StartDate,
+			&i.Interval,
+			&i.DayOfWeek,
+			&i.DayOfMonth,
+			&i.EndDate,
+			&i.Active,
+			&i.DeletedAt,
+			&i.Currency,
+			&i.Prorate,
+			&i.PausedFrom,
+			&i.PausedUntil,
+			&i.Version,
+			&i.OccurrenceCount,
+			&i.WeekOfMonth,
+			&i.BusinessDay,
+			&i.Rrule,
+			&i.Category,
+			&i.AccountID,
 		); err != nil {
 			return nil, err
 		}
@@ -177,20 +398,146 @@ func (q *Queries) ListRecurring(ctx context.Context) ([]RecurringTransactions, e
 	return items, nil
 }
 
-const setRecurringActive = `-- name: SetRecurringActive :exec
+const purgeDeletedRecurring = `-- name: PurgeDeletedRecurring :execrows
+DELETE FROM recurring_transactions
+WHERE deleted_at IS NOT NULL AND deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedRecurring(ctx context.Context, cutoff pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedRecurring, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreRecurring = `-- name: RestoreRecurring :one
 UPDATE recurring_transactions
-SET active = $1
-WHERE id = $2
+SET deleted_at = NULL, version = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id
+`
+
+func (q *Queries) RestoreRecurring(ctx context.Context, id int32) (RecurringTransactions, error) {
+	row := q.db.QueryRow(ctx, restoreRecurring, id)
+	var i RecurringTransactions
+	err := row.Scan(
+		&i.ID,
+		&i.Description,
+		&i.Type,
+		&i.Amount,
+		&i.StartDate,
+		&i.Interval,
+		&i.DayOfWeek,
+		&i.DayOfMonth,
+		&i.EndDate,
+		&i.Active,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Prorate,
+		&i.PausedFrom,
+		&i.PausedUntil,
+		&i.Version,
+		&i.OccurrenceCount,
+		&i.WeekOfMonth,
+		&i.BusinessDay,
+		&i.Rrule,
+		&i.Category,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const setRecurringActive = `-- name: SetRecurringActive :one
+UPDATE recurring_transactions
+SET active = $1, version = version + 1
+WHERE id = $2 AND version = $3 AND deleted_at IS NULL
+RETURNING id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id
 `
 
 type SetRecurringActiveParams struct {
-	Active bool  `json:"active"`
-	ID     int32 `json:"id"`
+	Active  bool  `json:"active"`
+	ID      int32 `json:"id"`
+	Version int32 `json:"version"`
 }
 
-func (q *Queries) SetRecurringActive(ctx context.Context, arg SetRecurringActiveParams) error {
-	_, err := q.db.Exec(ctx, setRecurringActive, arg.Active, arg.ID)
-	return err
+func (q *Queries) SetRecurringActive(ctx context.Context, arg SetRecurringActiveParams) (RecurringTransactions, error) {
+	row := q.db.QueryRow(ctx, setRecurringActive, arg.Active, arg.ID, arg.Version)
+	var i RecurringTransactions
+	err := row.Scan(
+		&i.ID,
+		&i.Description,
+		&i.Type,
+		&i.Amount,
+		&i.StartDate,
+		&i.Interval,
+		&i.DayOfWeek,
+		&i.DayOfMonth,
+		&i.EndDate,
+		&i.Active,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Prorate,
+		&i.PausedFrom,
+		&i.PausedUntil,
+		&i.Version,
+		&i.OccurrenceCount,
+		&i.WeekOfMonth,
+		&i.BusinessDay,
+		&i.Rrule,
+		&i.Category,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const setRecurringPause = `-- name: SetRecurringPause :one
+UPDATE recurring_transactions
+SET paused_from = $1, paused_until = $2, version = version + 1
+WHERE id = $3 AND version = $4 AND deleted_at IS NULL
+RETURNING id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id
+`
+
+type SetRecurringPauseParams struct {
+	PausedFrom  pgtype.Date `json:"paused_from"`
+	PausedUntil pgtype.Date `json:"paused_until"`
+	ID          int32       `json:"id"`
+	Version     int32       `json:"version"`
+}
+
+func (q *Queries) SetRecurringPause(ctx context.Context, arg SetRecurringPauseParams) (RecurringTransactions, error) {
+	row := q.db.QueryRow(ctx, setRecurringPause,
+		arg.PausedFrom,
+		arg.PausedUntil,
+		arg.ID,
+		arg.Version,
+	)
+	var i RecurringTransactions
+	err := row.Scan(
+		&i.ID,
+		&i.Description,
+		&i.Type,
+		&i.Amount,
+		&i.StartDate,
+		&i.Interval,
+		&i.DayOfWeek,
+		&i.DayOfMonth,
+		&i.EndDate,
+		&i.Active,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Prorate,
+		&i.PausedFrom,
+		&i.PausedUntil,
+		&i.Version,
+		&i.OccurrenceCount,
+		&i.WeekOfMonth,
+		&i.BusinessDay,
+		&i.Rrule,
+		&i.Category,
+		&i.AccountID,
+	)
+	return i, err
 }
 
 const updateRecurring = `-- name: UpdateRecurring :one
@@ -204,22 +551,40 @@ SET
   day_of_week  = $6,
   day_of_month = $7,
   end_date     = $8,
-  active       = $9
-WHERE id = $10
-RETURNING id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active
+  active       = $9,
+  currency     = $10,
+  prorate      = $11,
+  occurrence_count = $12,
+  week_of_month = $13,
+  business_day = $14,
+  rrule        = $15,
+  category     = $16,
+  account_id   = $17,
+  version      = version + 1
+WHERE id = $18 AND version = $19 AND deleted_at IS NULL
+RETURNING id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id
 `
 
 type UpdateRecurringParams struct {
-	Description string             `json:"description"`
-	Type        string             `json:"type"`
-	Amount      pgtype.Numeric     `json:"amount"`
-	StartDate   pgtype.Date        `json:"start_date"`
-	Interval    RecurrenceInterval `json:"interval"`
-	DayOfWeek   pgtype.Int4        `json:"day_of_week"`
-	DayOfMonth  pgtype.Int4        `json:"day_of_month"`
-	EndDate     pgtype.Date        `json:"end_date"`
-	Active      bool               `json:"active"`
-	ID          int32              `json:"id"`
+	Description     string             `json:"description"`
+	Type            string             `json:"type"`
+	Amount          pgtype.Numeric     `json:"amount"`
+	StartDate       pgtype.Date        `json:"start_date"`
+	Interval        RecurrenceInterval `json:"interval"`
+	DayOfWeek       pgtype.Int4        `json:"day_of_week"`
+	DayOfMonth      pgtype.Int4        `json:"day_of_month"`
+	EndDate         pgtype.Date        `json:"end_date"`
+	Active          bool               `json:"active"`
+	Currency        string             `json:"currency"`
+	Prorate         bool               `json:"prorate"`
+	OccurrenceCount pgtype.Int4        `json:"occurrence_count"`
+	WeekOfMonth     pgtype.Int4        `json:"week_of_month"`
+	BusinessDay     bool               `json:"business_day"`
+	Rrule           pgtype.Text        `json:"rrule"`
+	Category        pgtype.Text        `json:"category"`
+	AccountID       pgtype.Int4        `json:"account_id"`
+	ID              int32              `json:"id"`
+	Version         int32              `json:"version"`
 }
 
 func (q *Queries) UpdateRecurring(ctx context.Context, arg UpdateRecurringParams) (RecurringTransactions, error) {
@@ -233,7 +598,16 @@ func (q *Queries) UpdateRecurring(ctx context.Context, arg UpdateRecurringParams
 		arg.DayOfMonth,
 		arg.EndDate,
 		arg.Active,
+		arg.Currency,
+		arg.Prorate,
+		arg.OccurrenceCount,
+		arg.WeekOfMonth,
+		arg.BusinessDay,
+		arg.Rrule,
+		arg.Category,
+		arg.AccountID,
 		arg.ID,
+		arg.Version,
 	)
 	var i RecurringTransactions
 	err := row.Scan(
@@ -247,6 +621,18 @@ func (q *Queries) UpdateRecurring(ctx context.Context, arg UpdateRecurringParams
 		&i.DayOfMonth,
 		&i.EndDate,
 		&i.Active,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Prorate,
+		&i.PausedFrom,
+		&i.PausedUntil,
+		&i.Version,
+		&i.OccurrenceCount,
+		&i.WeekOfMonth,
+		&i.BusinessDay,
+		&i.Rrule,
+		&i.Category,
+		&i.AccountID,
 	)
 	return i, err
 }
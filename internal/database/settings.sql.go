@@ -56,7 +56,7 @@ func (q *Queries) GetSetting(ctx context.Context, key string) (string, error) {
 const updateSetting = `-- name: UpdateSetting :exec
 INSERT INTO settings (key, value, updated_at)
 VALUES ($1, $2, CURRENT_TIMESTAMP)
-ON CONFLICT (key)
+ON CONFLICT ON CONSTRAINT settings_pkey
 DO UPDATE SET value = $2, updated_at = CURRENT_TIMESTAMP
 `
 
@@ -11,9 +11,10 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const createTransaction = `-- name: CreateTransaction :exec
-INSERT INTO transactions (date, amount, description, type)
-VALUES ($1, $2, $3, $4)
+const createTransaction = `-- name: CreateTransaction :one
+INSERT INTO transactions (date, amount, description, type, category, currency, account_id, status)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
 `
 
 type CreateTransactionParams struct {
@@ -21,30 +22,59 @@ type CreateTransactionParams struct {
 	Amount      pgtype.Numeric `json:"amount"`
 	Description string         `json:"description"`
 	Type        string         `json:"type"`
+	Category    pgtype.Text    `json:"category"`
+	Currency    string         `json:"currency"`
+	AccountID   pgtype.Int4    `json:"account_id"`
+	Status      string         `json:"status"`
 }
 
-func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) error {
-	_, err := q.db.Exec(ctx, createTransaction,
+func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transactions, error) {
+	row := q.db.QueryRow(ctx, createTransaction,
 		arg.Date,
 		arg.Amount,
 		arg.Description,
 		arg.Type,
+		arg.Category,
+		arg.Currency,
+		arg.AccountID,
+		arg.Status,
 	)
-	return err
+	var i Transactions
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Amount,
+		&i.Description,
+		&i.Type,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.Category,
+		&i.Currency,
+		&i.AccountID,
+		&i.Status,
+		&i.Version,
+	)
+	return i, err
 }
 
-const deleteTransaction = `-- name: DeleteTransaction :exec
-DELETE FROM transactions WHERE id = $1
+const deleteTransaction = `-- name: DeleteTransaction :execrows
+UPDATE transactions
+SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
-func (q *Queries) DeleteTransaction(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteTransaction, id)
-	return err
+func (q *Queries) DeleteTransaction(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTransaction, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const getAllTransactions = `-- name: GetAllTransactions :many
-SELECT id, date, amount, description, type, created_at
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
 FROM transactions
+WHERE deleted_at IS NULL
 ORDER BY date ASC
 `
 
@@ -64,6 +94,274 @@ func (q *Queries) GetAllTransactions(ctx context.Context) ([]Transactions, error
 			&i.Description,
 			&i.Type,
 			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.Category,
+			&i.Currency,
+			&i.AccountID,
+			&i.Status,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDailyTransactionSums = `-- name: GetDailyTransactionSums :many
+SELECT date, currency, account_id, SUM(amount)::numeric AS total
+FROM transactions
+WHERE deleted_at IS NULL
+  AND date BETWEEN $1 AND $2
+  AND ($3::boolean OR status = 'cleared')
+GROUP BY date, currency, account_id
+ORDER BY date ASC
+`
+
+type GetDailyTransactionSumsParams struct {
+	StartDate      pgtype.Date `json:"start_date"`
+	EndDate        pgtype.Date `json:"end_date"`
+	IncludePending bool        `json:"include_pending"`
+}
+
+type GetDailyTransactionSumsRow struct {
+	Date      pgtype.Date    `json:"date"`
+	Currency  string         `json:"currency"`
+	AccountID pgtype.Int4    `json:"account_id"`
+	Total     pgtype.Numeric `json:"total"`
+}
+
+// Per-day totals for the forecast, kept apart by currency for conversion
+// and by account for card due dates and interest.
+func (q *Queries) GetDailyTransactionSums(ctx context.Context, arg GetDailyTransactionSumsParams) ([]GetDailyTransactionSumsRow, error) {
+	rows, err := q.db.Query(ctx, getDailyTransactionSums, arg.StartDate, arg.EndDate, arg.IncludePending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetDailyTransactionSumsRow{}
+	for rows.Next() {
+		var i GetDailyTransactionSumsRow
+		if err := rows.Scan(
+			&i.Date,
+			&i.Currency,
+			&i.AccountID,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLargestExpenses = `-- name: GetLargestExpenses :many
+SELECT DISTINCT ON (currency) id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE deleted_at IS NULL
+  AND type = 'expense'
+  AND date BETWEEN $1 AND $2
+ORDER BY currency ASC, amount ASC, date ASC, id ASC
+`
+
+type GetLargestExpensesParams struct {
+	StartDate pgtype.Date `json:"start_date"`
+	EndDate   pgtype.Date `json:"end_date"`
+}
+
+// The biggest expense in each currency from start_date to end_date, the
+// earliest of any tied for it. Which is biggest overall depends on the
+// exchange rates.
+func (q *Queries) GetLargestExpenses(ctx context.Context, arg GetLargestExpensesParams) ([]Transactions, error) {
+	rows, err := q.db.Query(ctx, getLargestExpenses, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transactions{}
+	for rows.Next() {
+		var i Transactions
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Amount,
+			&i.Description,
+			&i.Type,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.Category,
+			&i.Currency,
+			&i.AccountID,
+			&i.Status,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingTransactionsBefore = `-- name: GetPendingTransactionsBefore :many
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE status = 'pending' AND deleted_at IS NULL AND date < $1
+ORDER BY date ASC
+`
+
+// Pending transactions from before the forecast starts. The bank balance
+// doesn't include them yet, so the forecast still has to.
+func (q *Queries) GetPendingTransactionsBefore(ctx context.Context, date pgtype.Date) ([]Transactions, error) {
+	rows, err := q.db.Query(ctx, getPendingTransactionsBefore, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transactions{}
+	for rows.Next() {
+		var i Transactions
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Amount,
+			&i.Description,
+			&i.Type,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.Category,
+			&i.Currency,
+			&i.AccountID,
+			&i.Status,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSpendingHeatmap = `-- name: GetSpendingHeatmap :many
+SELECT
+  date,
+  currency,
+  EXTRACT(ISOYEAR FROM date)::int AS iso_year,
+  EXTRACT(WEEK FROM date)::int AS week,
+  EXTRACT(ISODOW FROM date)::int AS weekday,
+  COUNT(*) AS count,
+  (-SUM(amount))::numeric AS spent
+FROM transactions
+WHERE deleted_at IS NULL
+  AND type = 'expense'
+  AND date BETWEEN $1 AND $2
+GROUP BY date, currency
+ORDER BY date ASC, currency ASC
+`
+
+type GetSpendingHeatmapParams struct {
+	StartDate pgtype.Date `json:"start_date"`
+	EndDate   pgtype.Date `json:"end_date"`
+}
+
+type GetSpendingHeatmapRow struct {
+	Date     pgtype.Date    `json:"date"`
+	Currency string         `json:"currency"`
+	IsoYear  int32          `json:"iso_year"`
+	Week     int32          `json:"week"`
+	Weekday  int32          `json:"weekday"`
+	Count    int64          `json:"count"`
+	Spent    pgtype.Numeric `json:"spent"`
+}
+
+// Spending per day from start_date to end_date, with the ISO week and
+// weekday (1 for Monday) each day falls in, for the spending heatmap. Days
+// are kept apart by currency for conversion. idx_transactions_spending
+// covers it.
+func (q *Queries) GetSpendingHeatmap(ctx context.Context, arg GetSpendingHeatmapParams) ([]GetSpendingHeatmapRow, error) {
+	rows, err := q.db.Query(ctx, getSpendingHeatmap, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetSpendingHeatmapRow{}
+	for rows.Next() {
+		var i GetSpendingHeatmapRow
+		if err := rows.Scan(
+			&i.Date,
+			&i.Currency,
+			&i.IsoYear,
+			&i.Week,
+			&i.Weekday,
+			&i.Count,
+			&i.Spent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionAggregatesByCurrency = `-- name: GetTransactionAggregatesByCurrency :many
+SELECT
+  currency,
+  COUNT(*) AS count,
+  COALESCE(SUM(amount) FILTER (WHERE type = 'income'), 0)::numeric AS total_income,
+  COALESCE(SUM(amount) FILTER (WHERE type = 'expense'), 0)::numeric AS total_expense
+FROM transactions
+WHERE deleted_at IS NULL
+  AND ($1::date IS NULL OR date >= $1)
+  AND ($2::date IS NULL OR date <= $2)
+  AND ($3::text IS NULL OR status = $3)
+GROUP BY currency
+ORDER BY currency ASC
+`
+
+type GetTransactionAggregatesByCurrencyParams struct {
+	StartDate pgtype.Date `json:"start_date"`
+	EndDate   pgtype.Date `json:"end_date"`
+	Status    pgtype.Text `json:"status"`
+}
+
+type GetTransactionAggregatesByCurrencyRow struct {
+	Currency     string         `json:"currency"`
+	Count        int64          `json:"count"`
+	TotalIncome  pgtype.Numeric `json:"total_income"`
+	TotalExpense pgtype.Numeric `json:"total_expense"`
+}
+
+// Totals kept apart by currency, to be converted to the base currency
+// before they are added up. A NULL start_date or end_date leaves that side
+// of the range open, and a NULL status takes every status.
+func (q *Queries) GetTransactionAggregatesByCurrency(ctx context.Context, arg GetTransactionAggregatesByCurrencyParams) ([]GetTransactionAggregatesByCurrencyRow, error) {
+	rows, err := q.db.Query(ctx, getTransactionAggregatesByCurrency, arg.StartDate, arg.EndDate, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTransactionAggregatesByCurrencyRow{}
+	for rows.Next() {
+		var i GetTransactionAggregatesByCurrencyRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.Count,
+			&i.TotalIncome,
+			&i.TotalExpense,
 		); err != nil {
 			return nil, err
 		}
@@ -76,9 +374,9 @@ func (q *Queries) GetAllTransactions(ctx context.Context) ([]Transactions, error
 }
 
 const getTransactionByID = `-- name: GetTransactionByID :one
-SELECT id, date, amount, description, type, created_at
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
 FROM transactions
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetTransactionByID(ctx context.Context, id int32) (Transactions, error) {
@@ -91,14 +389,21 @@ func (q *Queries) GetTransactionByID(ctx context.Context, id int32) (Transaction
 		&i.Description,
 		&i.Type,
 		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.Category,
+		&i.Currency,
+		&i.AccountID,
+		&i.Status,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getTransactionsByDateRange = `-- name: GetTransactionsByDateRange :many
-SELECT id, date, amount, description, type, created_at
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
 FROM transactions
 WHERE date BETWEEN $1 AND $2
+  AND deleted_at IS NULL
 ORDER BY date ASC
 `
 
@@ -123,6 +428,52 @@ func (q *Queries) GetTransactionsByDateRange(ctx context.Context, arg GetTransac
 			&i.Description,
 			&i.Type,
 			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.Category,
+			&i.Currency,
+			&i.AccountID,
+			&i.Status,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionsByStatus = `-- name: GetTransactionsByStatus :many
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE status = $1 AND deleted_at IS NULL
+ORDER BY date ASC
+`
+
+func (q *Queries) GetTransactionsByStatus(ctx context.Context, status string) ([]Transactions, error) {
+	rows, err := q.db.Query(ctx, getTransactionsByStatus, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transactions{}
+	for rows.Next() {
+		var i Transactions
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Amount,
+			&i.Description,
+			&i.Type,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.Category,
+			&i.Currency,
+			&i.AccountID,
+			&i.Status,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -135,9 +486,9 @@ func (q *Queries) GetTransactionsByDateRange(ctx context.Context, arg GetTransac
 }
 
 const getTransactionsByType = `-- name: GetTransactionsByType :many
-SELECT id, date, amount, description, type, created_at
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
 FROM transactions
-WHERE type = $1
+WHERE type = $1 AND deleted_at IS NULL
 ORDER BY date ASC
 `
 
@@ -157,6 +508,52 @@ func (q *Queries) GetTransactionsByType(ctx context.Context, type_ string) ([]Tr
 			&i.Description,
 			&i.Type,
 			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.Category,
+			&i.Currency,
+			&i.AccountID,
+			&i.Status,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedTransactions = `-- name: ListDeletedTransactions :many
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) ListDeletedTransactions(ctx context.Context) ([]Transactions, error) {
+	rows, err := q.db.Query(ctx, listDeletedTransactions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transactions{}
+	for rows.Next() {
+		var i Transactions
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Amount,
+			&i.Description,
+			&i.Type,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.Category,
+			&i.Currency,
+			&i.AccountID,
+			&i.Status,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -167,3 +564,162 @@ func (q *Queries) GetTransactionsByType(ctx context.Context, type_ string) ([]Tr
 	}
 	return items, nil
 }
+
+const listTransactionsSorted = `-- name: ListTransactionsSorted :many
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE deleted_at IS NULL
+  AND ($1::text IS NULL OR status = $1)
+  AND CASE
+    WHEN $2::text = 'date' AND $3::bool THEN (date, id) < ($4::date, $5::int)
+    WHEN $2::text = 'date' THEN (date, id) > ($4::date, $5::int)
+    WHEN $2::text = 'amount' AND $3::bool THEN (amount, id) < ($6::numeric, $5::int)
+    WHEN $2::text = 'amount' THEN (amount, id) > ($6::numeric, $5::int)
+    WHEN $2::text = 'description' AND $3::bool THEN (description COLLATE "C", id) < ($7::text COLLATE "C", $5::int)
+    WHEN $2::text = 'description' THEN (description COLLATE "C", id) > ($7::text COLLATE "C", $5::int)
+    WHEN $3::bool THEN id < $5::int
+    ELSE id > $5::int
+  END IS NOT FALSE
+ORDER BY
+  CASE WHEN $2::text = 'date' AND NOT $3::bool THEN date END ASC,
+  CASE WHEN $2::text = 'date' AND $3::bool THEN date END DESC,
+  CASE WHEN $2::text = 'amount' AND NOT $3::bool THEN amount END ASC,
+  CASE WHEN $2::text = 'amount' AND $3::bool THEN amount END DESC,
+  CASE WHEN $2::text = 'description' AND NOT $3::bool THEN description COLLATE "C" END ASC,
+  CASE WHEN $2::text = 'description' AND $3::bool THEN description COLLATE "C" END DESC,
+  CASE WHEN NOT $3::bool THEN id END ASC,
+  CASE WHEN $3::bool THEN id END DESC
+LIMIT $8::int
+`
+
+type ListTransactionsSortedParams struct {
+	Status           pgtype.Text    `json:"status"`
+	SortKey          string         `json:"sort_key"`
+	Descending       bool           `json:"descending"`
+	AfterDate        pgtype.Date    `json:"after_date"`
+	AfterID          pgtype.Int4    `json:"after_id"`
+	AfterAmount      pgtype.Numeric `json:"after_amount"`
+	AfterDescription pgtype.Text    `json:"after_description"`
+	PageSize         pgtype.Int4    `json:"page_size"`
+}
+
+// Live transactions, of one status when given, sorted on sort_key (date,
+// amount or description) either way. id breaks ties in the same direction,
+// so equal keys always come back in the same order. Descriptions compare
+// byte by byte, as Go's strings do. Pages are keyset ones: given after_id
+// and the sort key of the row it names, rows start just after that row,
+// and page_size caps how many come back.
+func (q *Queries) ListTransactionsSorted(ctx context.Context, arg ListTransactionsSortedParams) ([]Transactions, error) {
+	rows, err := q.db.Query(ctx, listTransactionsSorted,
+		arg.Status,
+		arg.SortKey,
+		arg.Descending,
+		arg.AfterDate,
+		arg.AfterID,
+		arg.AfterAmount,
+		arg.AfterDescription,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transactions{}
+	for rows.Next() {
+		var i Transactions
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Amount,
+			&i.Description,
+			&i.Type,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.Category,
+			&i.Currency,
+			&i.AccountID,
+			&i.Status,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedTransactions = `-- name: PurgeDeletedTransactions :execrows
+DELETE FROM transactions
+WHERE deleted_at IS NOT NULL AND deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedTransactions(ctx context.Context, cutoff pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedTransactions, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreTransaction = `-- name: RestoreTransaction :one
+UPDATE transactions
+SET deleted_at = NULL, version = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+`
+
+func (q *Queries) RestoreTransaction(ctx context.Context, id int32) (Transactions, error) {
+	row := q.db.QueryRow(ctx, restoreTransaction, id)
+	var i Transactions
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Amount,
+		&i.Description,
+		&i.Type,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.Category,
+		&i.Currency,
+		&i.AccountID,
+		&i.Status,
+		&i.Version,
+	)
+	return i, err
+}
+
+const setTransactionStatus = `-- name: SetTransactionStatus :one
+UPDATE transactions
+SET status = $2, version = version + 1
+WHERE id = $1 AND version = $3 AND deleted_at IS NULL
+RETURNING id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+`
+
+type SetTransactionStatusParams struct {
+	ID      int32  `json:"id"`
+	Status  string `json:"status"`
+	Version int32  `json:"version"`
+}
+
+func (q *Queries) SetTransactionStatus(ctx context.Context, arg SetTransactionStatusParams) (Transactions, error) {
+	row := q.db.QueryRow(ctx, setTransactionStatus, arg.ID, arg.Status, arg.Version)
+	var i Transactions
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Amount,
+		&i.Description,
+		&i.Type,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.Category,
+		&i.Currency,
+		&i.AccountID,
+		&i.Status,
+		&i.Version,
+	)
+	return i, err
+}
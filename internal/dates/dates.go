@@ -0,0 +1,227 @@
+// Package dates parses the dates people type: calendar dates in the common
+// layouts, timestamps, and shortcuts relative to today such as "tomorrow",
+// "eom", "next friday" or "+7d". The API and the CLI both use it, so a date
+// that works in one works in the other.
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dayLayouts are calendar days, tried in order. US month-first order wins
+// over day-first for slashes, as it always has here.
+var dayLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"20060102",
+	"01/02/2006",
+	"1/2/2006",
+	"01-02-2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"January 2, 2006",
+	"January 2 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Mon, Jan 2, 2006",
+	"Monday, January 2, 2006",
+}
+
+// yearlessLayouts are days without a year, which is taken from today.
+var yearlessLayouts = []string{
+	"Jan 2",
+	"January 2",
+	"2 Jan",
+	"2 January",
+}
+
+// timestampLayouts carry a time of day and a zone. RFC 3339 covers
+// fractional seconds and any offset, including Z.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05Z0700",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// Parse reads input as a date or timestamp. Relative forms are resolved
+// against the day today returns, which is only called for them:
+//
+//	today, tomorrow, yesterday
+//	friday, next friday     the first Friday after today
+//	som, eom, soy, eoy      start or end of this month or year
+//	+3d, -2w, +1m, +1y      days, weeks, months or years from today
+//	Jun 5                   June 5 this year
+//
+// Input is case-insensitive. Calendar days come back as midnight UTC;
+// timestamps keep their offset.
+func Parse(input string, today func() time.Time) (time.Time, error) {
+	t, _, err := parse(input, today)
+	return t, err
+}
+
+// ParseDay is Parse for a calendar day. A timestamp is moved to the day it
+// falls on in the zone loc returns, so 23:30 local time is not taken for
+// the next UTC day.
+func ParseDay(input string, today func() time.Time, loc func() *time.Location) (time.Time, error) {
+	t, isDay, err := parse(input, today)
+	if err != nil || isDay {
+		return t, err
+	}
+	return day(t.In(loc())), nil
+}
+
+func parse(input string, today func() time.Time) (time.Time, bool, error) {
+	s := strings.Join(strings.Fields(input), " ")
+	if s == "" {
+		return time.Time{}, false, fmt.Errorf("unable to parse date: %s", input)
+	}
+	for _, layout := range dayLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true, nil
+		}
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, strings.ToUpper(s)); err == nil {
+			return t, false, nil
+		}
+	}
+	if t, ok := relative(strings.ToLower(s), today); ok {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("unable to parse date: %s", input)
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// named are the relative days that are just a word or two.
+var named = map[string]func(today time.Time) time.Time{
+	"today":     func(t time.Time) time.Time { return t },
+	"tomorrow":  func(t time.Time) time.Time { return t.AddDate(0, 0, 1) },
+	"yesterday": func(t time.Time) time.Time { return t.AddDate(0, 0, -1) },
+	"som":       startOfMonth,
+	"bom":       startOfMonth,
+	"eom":       endOfMonth,
+	"soy":       startOfYear,
+	"boy":       startOfYear,
+	"eoy":       endOfYear,
+
+	"start of month": startOfMonth,
+	"end of month":   endOfMonth,
+	"start of year":  startOfYear,
+	"end of year":    endOfYear,
+	"next month":     func(t time.Time) time.Time { return addMonths(t, 1) },
+	"next week":      func(t time.Time) time.Time { return t.AddDate(0, 0, 7) },
+}
+
+func relative(s string, now func() time.Time) (time.Time, bool) {
+	if !looksRelative(s) {
+		return time.Time{}, false
+	}
+	today := day(now())
+
+	if f, ok := named[s]; ok {
+		return f(today), true
+	}
+	if wd, ok := weekdays[strings.TrimPrefix(s, "next ")]; ok {
+		ahead := (int(wd)-int(today.Weekday())+6)%7 + 1
+		return today.AddDate(0, 0, ahead), true
+	}
+	for _, layout := range yearlessLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			d := time.Date(today.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+			// Feb 29 in a common year is no date at all, not Mar 1.
+			return d, d.Month() == t.Month()
+		}
+	}
+	return offset(s, today)
+}
+
+// offset reads "+3d", "-2w", "+1m" or "+1y". Months and years keep the day
+// of month where they can and otherwise stop at the month's last day, so
+// Jan 31 +1m is Feb 28.
+func offset(s string, today time.Time) (time.Time, bool) {
+	if len(s) < 3 || (s[0] != '+' && s[0] != '-') {
+		return time.Time{}, false
+	}
+	if s[1] < '0' || s[1] > '9' {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(s[1 : len(s)-1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	if s[0] == '-' {
+		n = -n
+	}
+	switch s[len(s)-1] {
+	case 'd':
+		return today.AddDate(0, 0, n), true
+	case 'w':
+		return today.AddDate(0, 0, 7*n), true
+	case 'm':
+		return addMonths(today, n), true
+	case 'y':
+		return addMonths(today, 12*n), true
+	}
+	return time.Time{}, false
+}
+
+// looksRelative screens out input relative can't parse before today is
+// looked up, which may mean a database query.
+func looksRelative(s string) bool {
+	if _, ok := named[s]; ok {
+		return true
+	}
+	if _, ok := weekdays[strings.TrimPrefix(s, "next ")]; ok {
+		return true
+	}
+	if len(s) >= 3 && (s[0] == '+' || s[0] == '-') {
+		return true
+	}
+	for _, layout := range yearlessLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func endOfMonth(t time.Time) time.Time {
+	return startOfMonth(t).AddDate(0, 1, -1)
+}
+
+func startOfYear(t time.Time) time.Time {
+	return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func endOfYear(t time.Time) time.Time {
+	return time.Date(t.Year(), time.December, 31, 0, 0, 0, 0, time.UTC)
+}
+
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	last := endOfMonth(first).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
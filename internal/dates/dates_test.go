@@ -0,0 +1,203 @@
+package dates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A Wednesday.
+var today = time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
+
+func fixedToday() time.Time { return today }
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseLayouts(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2025-06-15", date(2025, 6, 15)},
+		{"2025/06/15", date(2025, 6, 15)},
+		{"20250615", date(2025, 6, 15)},
+		{"06/15/2025", date(2025, 6, 15)},
+		{"6/5/2025", date(2025, 6, 5)},
+		{"06-15-2025", date(2025, 6, 15)},
+		{"Jun 15, 2025", date(2025, 6, 15)},
+		{"jun 15 2025", date(2025, 6, 15)},
+		{"June 15, 2025", date(2025, 6, 15)},
+		{"JUNE 15 2025", date(2025, 6, 15)},
+		{"15 Jun 2025", date(2025, 6, 15)},
+		{"15 june 2025", date(2025, 6, 15)},
+		{"Sun, Jun 15, 2025", date(2025, 6, 15)},
+		{"Sunday, June 15, 2025", date(2025, 6, 15)},
+		{"  2025-06-15\n", date(2025, 6, 15)},
+		{"2024-02-29", date(2024, 2, 29)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, fixedToday)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestParseTimestamps(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2025-06-15T10:30:00Z", time.Date(2025, 6, 15, 10, 30, 0, 0, time.UTC)},
+		{"2025-06-15t10:30:00z", time.Date(2025, 6, 15, 10, 30, 0, 0, time.UTC)},
+		{"2025-06-15T10:30:00.000Z", time.Date(2025, 6, 15, 10, 30, 0, 0, time.UTC)},
+		{"2025-06-15T10:30:00.123456789Z", time.Date(2025, 6, 15, 10, 30, 0, 123456789, time.UTC)},
+		{"2025-06-15T10:30:00-07:00", time.Date(2025, 6, 15, 17, 30, 0, 0, time.UTC)},
+		{"2025-06-15T10:30:00+0200", time.Date(2025, 6, 15, 8, 30, 0, 0, time.UTC)},
+		{"Sun, 15 Jun 2025 10:30:00 +0000", time.Date(2025, 6, 15, 10, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, fixedToday)
+		require.NoError(t, err, tt.in)
+		assert.True(t, tt.want.Equal(got), "%s: got %v", tt.in, got)
+	}
+}
+
+func TestParseRelative(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"today", today},
+		{"TODAY", today},
+		{" Tomorrow ", date(2025, 6, 5)},
+		{"yesterday", date(2025, 6, 3)},
+
+		// Weekdays are the first one after today, never today itself.
+		{"thursday", date(2025, 6, 5)},
+		{"fri", date(2025, 6, 6)},
+		{"next friday", date(2025, 6, 6)},
+		{"next  Sat", date(2025, 6, 7)},
+		{"sunday", date(2025, 6, 8)},
+		{"tues", date(2025, 6, 10)},
+		{"wednesday", date(2025, 6, 11)},
+
+		{"som", date(2025, 6, 1)},
+		{"bom", date(2025, 6, 1)},
+		{"start of month", date(2025, 6, 1)},
+		{"eom", date(2025, 6, 30)},
+		{"End of Month", date(2025, 6, 30)},
+		{"soy", date(2025, 1, 1)},
+		{"eoy", date(2025, 12, 31)},
+		{"next week", date(2025, 6, 11)},
+		{"next month", date(2025, 7, 4)},
+
+		{"+0d", today},
+		{"+3d", date(2025, 6, 7)},
+		{"-4d", date(2025, 5, 31)},
+		{"+30d", date(2025, 7, 4)},
+		{"+2w", date(2025, 6, 18)},
+		{"-2w", date(2025, 5, 21)},
+		{"+1m", date(2025, 7, 4)},
+		{"-6m", date(2024, 12, 4)},
+		{"+1y", date(2026, 6, 4)},
+		{"+7D", date(2025, 6, 11)},
+
+		{"Jul 4", date(2025, 7, 4)},
+		{"july 4", date(2025, 7, 4)},
+		{"4 Jul", date(2025, 7, 4)},
+		{"Jan 1", date(2025, 1, 1)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, fixedToday)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestParseRelativeKeepsMonthEnds(t *testing.T) {
+	jan31 := func() time.Time { return date(2025, 1, 31) }
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"+1m", date(2025, 2, 28)},
+		{"+2m", date(2025, 3, 31)},
+		{"-2m", date(2024, 11, 30)},
+		{"eom", date(2025, 1, 31)},
+		{"next month", date(2025, 2, 28)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, jan31)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	leap := func() time.Time { return date(2024, 2, 29) }
+	got, err := Parse("+1y", leap)
+	require.NoError(t, err)
+	assert.Equal(t, date(2025, 2, 28), got)
+	got, err = Parse("eom", leap)
+	require.NoError(t, err)
+	assert.Equal(t, date(2024, 2, 29), got)
+}
+
+func TestParseResolvesAgainstTodaysCalendarDay(t *testing.T) {
+	// Today may arrive with a time of day; only its date counts.
+	late := func() time.Time { return time.Date(2025, 6, 4, 23, 59, 0, 0, time.UTC) }
+	got, err := Parse("tomorrow", late)
+	require.NoError(t, err)
+	assert.Equal(t, date(2025, 6, 5), got)
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, in := range []string{
+		"", "   ", "soon", "next", "next year", "fridays",
+		"+d", "+-3d", "+3", "+3x", "3d", "++3d",
+		"2025-13-01", "2025-02-30", "2025-6-5x", "13/01/2025",
+		"Feb 29", "Foo 5", "Jun 31",
+	} {
+		_, err := Parse(in, fixedToday)
+		assert.Error(t, err, "%q", in)
+	}
+}
+
+func TestParseOnlyLooksUpTodayForRelativeInput(t *testing.T) {
+	panics := func() time.Time { panic("today looked up") }
+	for _, in := range []string{"2025-06-15", "2025-06-15T10:30:00Z", "garbage", ""} {
+		assert.NotPanics(t, func() { _, _ = Parse(in, panics) }, in)
+	}
+}
+
+func TestParseDay(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	loc := func() *time.Location { return ny }
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		// 02:30 UTC is still the evening before in New York.
+		{"2025-06-05T02:30:00Z", date(2025, 6, 4)},
+		{"2025-06-05T12:00:00Z", date(2025, 6, 5)},
+		{"2025-06-05T23:30:00-04:00", date(2025, 6, 5)},
+		{"2025-06-05", date(2025, 6, 5)},
+		{"tomorrow", date(2025, 6, 5)},
+	}
+	for _, tt := range tests {
+		got, err := ParseDay(tt.in, fixedToday, loc)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	// Calendar days never need the zone.
+	noZone := func() *time.Location { panic("zone looked up") }
+	assert.NotPanics(t, func() { _, _ = ParseDay("2025-06-05", fixedToday, noZone) })
+
+	_, err = ParseDay("nope", fixedToday, loc)
+	assert.EqualError(t, err, "unable to parse date: nope")
+}
@@ -0,0 +1,95 @@
+package demo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	svc, err := Open(ctx)
+	require.NoError(t, err)
+
+	balance, err := svc.GetStartingBalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4250.0, balance)
+
+	txs, err := svc.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, txs)
+	for _, tx := range txs {
+		assert.False(t, tx.Date.Time.After(svc.Today(ctx)), "sample spending is in the past")
+	}
+
+	recs, err := svc.RecurringSummaries(ctx)
+	require.NoError(t, err)
+	assert.Len(t, recs, len(singleRecurrings(svc.Today(ctx))))
+
+	forecast, err := svc.Forecast(ctx, balance, 60)
+	require.NoError(t, err)
+	require.Len(t, forecast, 60)
+	moved := false
+	for _, day := range forecast {
+		moved = moved || day.Change != 0
+	}
+	assert.True(t, moved, "recurrings show up in the forecast")
+
+	require.NoError(t, svc.Ping(ctx))
+	schema, err := svc.SchemaStatus(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, schema.Pending)
+}
+
+func TestSeedIsRepeatable(t *testing.T) {
+	ctx := context.Background()
+	a, err := Open(ctx)
+	require.NoError(t, err)
+	b, err := Open(ctx)
+	require.NoError(t, err)
+
+	txA, err := a.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	txB, err := b.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, len(txA), len(txB))
+	for i := range txA {
+		assert.Equal(t, txA[i].Description, txB[i].Description)
+		assert.Equal(t, txA[i].Amount, txB[i].Amount)
+	}
+}
+
+func TestSeedProfiles(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(memdb.New())
+	require.NoError(t, Seed(ctx, svc, Options{Profile: "family", Months: 12}))
+
+	recs, err := svc.RecurringSummaries(ctx)
+	require.NoError(t, err)
+	assert.Len(t, recs, len(familyRecurrings(svc.Today(ctx))))
+
+	txs, err := svc.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	earliest := svc.Today(ctx)
+	irregular := 0
+	for _, tx := range txs {
+		if tx.Date.Time.Before(earliest) {
+			earliest = tx.Date.Time
+		}
+		if tx.Category.String == "irregular" {
+			irregular++
+		}
+	}
+	assert.True(t, earliest.Before(svc.Today(ctx).AddDate(0, -11, 0)), "a year of history")
+	assert.NotZero(t, irregular, "the odd big expense")
+
+	err = Seed(ctx, service.NewFinanceService(memdb.New()), Options{Profile: "retired"})
+	assert.ErrorContains(t, err, "family, single")
+	err = Seed(ctx, service.NewFinanceService(memdb.New()), Options{Months: -1})
+	assert.Error(t, err)
+}
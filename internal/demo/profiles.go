@@ -0,0 +1,131 @@
+package demo
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// A profile is one made-up household: what it starts with, the bills and
+// paychecks it has, and how it spends day to day.
+type profile struct {
+	startingBalance float64
+	savings         float64
+	recurrings      func(start time.Time) []service.RecurringInput
+	budgets         map[string]float64
+	purchases       []purchase
+}
+
+// A purchase happens on a given day with probability perDay, for an amount
+// between min and max at one of places. A low perDay and a wide range make
+// the irregular expenses: repairs, vet bills, gifts.
+type purchase struct {
+	category string
+	places   []string
+	perDay   float64
+	min, max float64
+	onCard   bool
+}
+
+var profiles = map[string]profile{
+	"single": {
+		startingBalance: 4250,
+		savings:         12000,
+		recurrings:      singleRecurrings,
+		budgets: map[string]float64{
+			"groceries":     650,
+			"dining":        250,
+			"transport":     180,
+			"entertainment": 120,
+		},
+		purchases: []purchase{
+			{"groceries", []string{"Trader Joe's", "Safeway", "Costco", "Farmers market"}, 0.35, 18, 145, false},
+			{"dining", []string{"Chipotle", "Blue Bottle Coffee", "Pho Saigon", "Pizza night", "Sushi Ran"}, 0.3, 6, 68, true},
+			{"transport", []string{"Shell", "Chevron", "Parking garage", "Transit card reload"}, 0.15, 12, 64, false},
+			{"entertainment", []string{"Movie tickets", "Steam", "Bookshop", "Concert tickets"}, 0.06, 10, 85, true},
+			{"household", []string{"Target", "Home Depot", "IKEA"}, 0.07, 15, 120, true},
+			{"health", []string{"CVS Pharmacy", "Copay"}, 0.03, 10, 60, false},
+			{"irregular", []string{"Car repair", "Wedding gift", "Flight home", "Laptop repair"}, 0.01, 150, 900, true},
+		},
+	},
+	"family": {
+		startingBalance: 6800,
+		savings:         21500,
+		recurrings:      familyRecurrings,
+		budgets: map[string]float64{
+			"groceries":     1300,
+			"dining":        300,
+			"transport":     420,
+			"kids":          350,
+			"entertainment": 150,
+		},
+		purchases: []purchase{
+			{"groceries", []string{"Costco", "Kroger", "Aldi", "Whole Foods", "Farmers market"}, 0.5, 25, 260, false},
+			{"dining", []string{"Chick-fil-A", "Olive Garden", "Panera", "Pizza night", "Ice cream"}, 0.2, 12, 95, true},
+			{"transport", []string{"Shell", "Costco Gas", "Car wash", "Parking"}, 0.25, 15, 85, false},
+			{"kids", []string{"School lunch top-up", "Field trip", "Kids' shoes", "Birthday party gift", "Art supplies"}, 0.12, 8, 75, true},
+			{"household", []string{"Target", "Home Depot", "Walmart", "Amazon"}, 0.15, 12, 160, true},
+			{"health", []string{"Pediatrician copay", "CVS Pharmacy", "Dentist copay"}, 0.05, 15, 120, false},
+			{"entertainment", []string{"Zoo tickets", "Movie night", "Bowling", "Netflix rental"}, 0.05, 15, 110, true},
+			{"irregular", []string{"Car repair", "Vet bill", "Urgent care", "Appliance repair", "Holiday gifts"}, 0.015, 180, 1400, true},
+		},
+	},
+}
+
+// Profiles lists the profile names Seed accepts, sorted.
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func singleRecurrings(start time.Time) []service.RecurringInput {
+	day := func(n int) *int { return &n }
+	return []service.RecurringInput{
+		{Description: "Paycheck - Acme Corp", Type: "income", Amount: 2380, StartDate: firstFriday(start), Interval: "biweekly", Active: true},
+		{Description: "Rent", Type: "expense", Amount: 1725, StartDate: start, Interval: "monthly", DayOfMonth: day(1), Active: true},
+		{Description: "Electric & Gas", Type: "expense", Amount: 142.5, StartDate: start, Interval: "monthly", DayOfMonth: day(12), Active: true},
+		{Description: "Internet", Type: "expense", Amount: 69.99, StartDate: start, Interval: "monthly", DayOfMonth: day(20), Active: true},
+		{Description: "Phone plan", Type: "expense", Amount: 85, StartDate: start, Interval: "monthly", DayOfMonth: day(22), Active: true},
+		{Description: "Car payment", Type: "expense", Amount: 389.12, StartDate: start, Interval: "monthly", DayOfMonth: day(5), Active: true},
+		{Description: "Gym membership", Type: "expense", Amount: 49, StartDate: start, Interval: "monthly", DayOfMonth: day(3), Active: true},
+		{Description: "Streaming bundle", Type: "expense", Amount: 22.99, StartDate: start, Interval: "monthly", DayOfMonth: day(9), Active: true},
+		{Description: "Savings transfer", Type: "expense", Amount: 300, StartDate: start, Interval: "monthly", DayOfMonth: day(16), Active: true},
+		{Description: "Car insurance (annual)", Type: "expense", Amount: 1146, StartDate: start.AddDate(0, 4, 0), Interval: "yearly", Active: true, Prorate: true},
+		{Description: "Amazon Prime", Type: "expense", Amount: 139, StartDate: start.AddDate(0, 7, 0), Interval: "yearly", Active: true},
+		{Description: "Side gig - tutoring", Type: "income", Amount: 160, StartDate: start.AddDate(0, 0, 2), Interval: "weekly", Active: true},
+	}
+}
+
+func familyRecurrings(start time.Time) []service.RecurringInput {
+	day := func(n int) *int { return &n }
+	return []service.RecurringInput{
+		{Description: "Paycheck - Northwind Health", Type: "income", Amount: 2960, StartDate: firstFriday(start), Interval: "biweekly", Active: true},
+		{Description: "Paycheck - Riverside Schools", Type: "income", Amount: 2215, StartDate: start, Interval: "monthly", DayOfMonth: day(15), Active: true},
+		{Description: "Paycheck - Riverside Schools", Type: "income", Amount: 2215, StartDate: start, Interval: "monthly", DayOfMonth: day(31), Active: true},
+		{Description: "Mortgage", Type: "expense", Amount: 2340, StartDate: start, Interval: "monthly", DayOfMonth: day(1), Active: true},
+		{Description: "Daycare", Type: "expense", Amount: 1180, StartDate: start, Interval: "monthly", DayOfMonth: day(1), Active: true},
+		{Description: "Electric", Type: "expense", Amount: 168.4, StartDate: start, Interval: "monthly", DayOfMonth: day(14), Active: true},
+		{Description: "Water & Sewer", Type: "expense", Amount: 74.2, StartDate: start, Interval: "monthly", DayOfMonth: day(21), Active: true},
+		{Description: "Internet", Type: "expense", Amount: 79.99, StartDate: start, Interval: "monthly", DayOfMonth: day(20), Active: true},
+		{Description: "Phone plan (family)", Type: "expense", Amount: 160, StartDate: start, Interval: "monthly", DayOfMonth: day(22), Active: true},
+		{Description: "Minivan payment", Type: "expense", Amount: 512.37, StartDate: start, Interval: "monthly", DayOfMonth: day(7), Active: true},
+		{Description: "Swim lessons", Type: "expense", Amount: 45, StartDate: start.AddDate(0, 0, 5), Interval: "weekly", Active: true},
+		{Description: "Piano lessons", Type: "expense", Amount: 120, StartDate: start.AddDate(0, 0, 3), Interval: "biweekly", Active: true},
+		{Description: "529 contribution", Type: "expense", Amount: 250, StartDate: start, Interval: "monthly", DayOfMonth: day(16), Active: true},
+		{Description: "Streaming bundle", Type: "expense", Amount: 34.99, StartDate: start, Interval: "monthly", DayOfMonth: day(9), Active: true},
+		{Description: "Home insurance (annual)", Type: "expense", Amount: 1680, StartDate: start.AddDate(0, 5, 0), Interval: "yearly", Active: true, Prorate: true},
+		{Description: "Car insurance (annual)", Type: "expense", Amount: 2210, StartDate: start.AddDate(0, 2, 0), Interval: "yearly", Active: true, Prorate: true},
+		{Description: "Property tax", Type: "expense", Amount: 3875, StartDate: start.AddDate(0, 8, 0), Interval: "yearly", Active: true},
+		{Description: "Tax refund", Type: "income", Amount: 1940, StartDate: start.AddDate(0, 3, 0), Interval: "yearly", Active: true},
+	}
+}
+
+// firstFriday is the first Friday on or after start, where paydays land.
+func firstFriday(start time.Time) time.Time {
+	return start.AddDate(0, 0, (int(time.Friday)-int(start.Weekday())+7)%7)
+}
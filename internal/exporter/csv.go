@@ -0,0 +1,123 @@
+// Package exporter writes transactions in the file layouts other
+// personal-finance tools import.
+package exporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{"ynab", "mint"}
+
+// Supports reports whether format names a known export format.
+func Supports(format string) bool {
+	for _, f := range Formats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// ynabHeader is the layout YNAB's file import maps without prompting.
+var ynabHeader = []string{"Date", "Payee", "Category", "Memo", "Outflow", "Inflow"}
+
+// mintHeader matches Mint's "Export all transactions" download.
+var mintHeader = []string{"Date", "Description", "Original Description", "Amount", "Transaction Type", "Category", "Account Name", "Labels", "Notes"}
+
+// mintAccount fills Mint's Account Name column, which has no equivalent here.
+const mintAccount = "currentz"
+
+// WriteCSV writes txs as CSV in the given format ("ynab" or "mint").
+func WriteCSV(w io.Writer, format string, txs []database.Transactions) error {
+	var (
+		header []string
+		row    func(database.Transactions) ([]string, error)
+	)
+	switch strings.ToLower(format) {
+	case "ynab":
+		header, row = ynabHeader, ynabRow
+	case "mint":
+		header, row = mintHeader, mintRow
+	default:
+		return fmt.Errorf("unsupported export format %q (expected %s)", format, strings.Join(Formats, " or "))
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		rec, err := row(tx)
+		if err != nil {
+			return fmt.Errorf("transaction %d: %w", tx.ID, err)
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func ynabRow(tx database.Transactions) ([]string, error) {
+	amt, err := amountOf(tx)
+	if err != nil {
+		return nil, err
+	}
+	outflow, inflow := "", ""
+	if amt < 0 {
+		outflow = formatAmount(-amt)
+	} else {
+		inflow = formatAmount(amt)
+	}
+	return []string{
+		tx.Date.Time.Format("01/02/2006"),
+		tx.Description,
+		tx.Category.String,
+		"",
+		outflow,
+		inflow,
+	}, nil
+}
+
+func mintRow(tx database.Transactions) ([]string, error) {
+	amt, err := amountOf(tx)
+	if err != nil {
+		return nil, err
+	}
+	kind := "credit"
+	if amt < 0 {
+		kind = "debit"
+	}
+	return []string{
+		tx.Date.Time.Format("1/02/2006"),
+		tx.Description,
+		tx.Description,
+		formatAmount(math.Abs(amt)),
+		kind,
+		tx.Category.String,
+		mintAccount,
+		"",
+		"",
+	}, nil
+}
+
+func amountOf(tx database.Transactions) (float64, error) {
+	f, err := tx.Amount.Float64Value()
+	if err != nil {
+		return 0, err
+	}
+	return f.Float64, nil
+}
+
+func formatAmount(f float64) string {
+	return money.FromFloat(f).String()
+}
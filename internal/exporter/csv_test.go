@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tx(id int32, date string, amt string, desc, category string) database.Transactions {
+	d, _ := time.Parse("2006-01-02", date)
+	var n pgtype.Numeric
+	_ = n.Scan(amt)
+	return database.Transactions{
+		ID:          id,
+		Date:        pgtype.Date{Time: d, Valid: true},
+		Amount:      n,
+		Description: desc,
+		Category:    pgtype.Text{String: category, Valid: category != ""},
+	}
+}
+
+var sample = []database.Transactions{
+	tx(1, "2025-01-03", "2500.00", "Paycheck", "Salary"),
+	tx(2, "2025-01-15", "-1200.50", "Rent, January", "Housing"),
+}
+
+func TestWriteCSVYNAB(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, "ynab", sample))
+	assert.Equal(t, "Date,Payee,Category,Memo,Outflow,Inflow\n"+
+		"01/03/2025,Paycheck,Salary,,,2500.00\n"+
+		"01/15/2025,\"Rent, January\",Housing,,1200.50,\n", buf.String())
+}
+
+func TestWriteCSVMint(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, "MINT", sample))
+	assert.Equal(t, "Date,Description,Original Description,Amount,Transaction Type,Category,Account Name,Labels,Notes\n"+
+		"1/03/2025,Paycheck,Paycheck,2500.00,credit,Salary,currentz,,\n"+
+		"1/15/2025,\"Rent, January\",\"Rent, January\",1200.50,debit,Housing,currentz,,\n", buf.String())
+}
+
+func TestWriteCSVUnknownFormat(t *testing.T) {
+	assert.Error(t, WriteCSV(&bytes.Buffer{}, "ofx", sample))
+	assert.False(t, Supports("ofx"))
+	assert.True(t, Supports("YNAB"))
+}
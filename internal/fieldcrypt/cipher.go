@@ -0,0 +1,97 @@
+// Package fieldcrypt encrypts the free-text columns that say the most about
+// someone's finances, transaction and recurring descriptions (which is
+// where imported payee names end up), before they reach the database. Each
+// value is sealed with AES-256-GCM under its own random nonce.
+//
+// Values written before encryption was turned on are read as they are, so
+// it can be enabled on an existing database; they're encrypted the next
+// time they're written.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length of a key: AES-256.
+const KeySize = 32
+
+// prefix marks an encrypted value, and the format it's in.
+const prefix = "enc1:"
+
+// ErrDecrypt is returned for a value that doesn't open with the key,
+// usually because it was written under another.
+var ErrDecrypt = errors.New("fieldcrypt: can't decrypt value (wrong encryption key?)")
+
+// Cipher encrypts and decrypts column values with one key.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher returns a Cipher for a KeySize-byte key.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("fieldcrypt: key is %d bytes; it must be %d", len(key), KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// ParseKey reads a key written in hex or base64, as from
+// `openssl rand -hex 32` or `openssl rand -base64 32`.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(s); err == nil && len(key) == KeySize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("encryption key must be %d bytes, in hex or base64 (e.g. openssl rand -hex 32)", KeySize)
+}
+
+// Encrypt seals s. The empty string is left as it is.
+func (c *Cipher) Encrypt(s string) string {
+	if s == "" {
+		return s
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(s), nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed)
+}
+
+// Decrypt opens a value Encrypt sealed. Anything else, such as a value
+// written before encryption was turned on, is returned as it is.
+func (c *Cipher) Decrypt(s string) (string, error) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(s[len(prefix):])
+	n := c.aead.NonceSize()
+	if err != nil || len(sealed) < n {
+		return "", ErrDecrypt
+	}
+	plain, err := c.aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
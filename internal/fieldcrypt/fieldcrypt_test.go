@@ -0,0 +1,147 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/memdb"
+)
+
+func testCipher(t *testing.T, b byte) *Cipher {
+	t.Helper()
+	c, err := NewCipher(bytes.Repeat([]byte{b}, KeySize))
+	require.NoError(t, err)
+	return c
+}
+
+func TestCipher(t *testing.T) {
+	c := testCipher(t, 1)
+
+	a, b := c.Encrypt("Rent to J. Smith"), c.Encrypt("Rent to J. Smith")
+	assert.True(t, strings.HasPrefix(a, "enc1:"))
+	assert.NotEqual(t, a, b, "each value gets its own nonce")
+	plain, err := c.Decrypt(a)
+	require.NoError(t, err)
+	assert.Equal(t, "Rent to J. Smith", plain)
+
+	// Written before encryption was on.
+	plain, err = c.Decrypt("Groceries")
+	require.NoError(t, err)
+	assert.Equal(t, "Groceries", plain)
+	assert.Equal(t, "", c.Encrypt(""))
+
+	_, err = testCipher(t, 2).Decrypt(a)
+	assert.ErrorIs(t, err, ErrDecrypt)
+	_, err = c.Decrypt("enc1:!!")
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = NewCipher([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestParseKey(t *testing.T) {
+	want := bytes.Repeat([]byte{0xab}, KeySize)
+	for _, s := range []string{
+		strings.Repeat("ab", KeySize),
+		"q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s=",
+		"q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s\n",
+	} {
+		key, err := ParseKey(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, key)
+	}
+	_, err := ParseKey("abab")
+	assert.Error(t, err)
+}
+
+func TestQuerier(t *testing.T) {
+	ctx := context.Background()
+	raw := memdb.New()
+	q := Wrap(raw, testCipher(t, 1))
+
+	tx, err := q.CreateTransaction(ctx, database.CreateTransactionParams{
+		Date:        pgtype.Date{Valid: true},
+		Description: "Paycheck from Acme",
+		Type:        "income",
+		Currency:    "USD",
+		Status:      "cleared",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Paycheck from Acme", tx.Description)
+
+	stored, err := raw.GetTransactionByID(ctx, tx.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored.Description, "Acme")
+
+	all, err := q.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "Paycheck from Acme", all[0].Description)
+
+	r, err := q.CreateRecurring(ctx, database.CreateRecurringParams{
+		Description: "Therapist",
+		Type:        "expense",
+		Interval:    "monthly",
+		Currency:    "USD",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Therapist", r.Description)
+	storedR, err := raw.GetRecurringByID(ctx, r.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, storedR.Description, "Therapist")
+
+	// The audit log's copy is sealed too.
+	after, _ := json.Marshal(map[string]any{"id": r.ID, "description": "Therapist"})
+	require.NoError(t, q.CreateAuditEntry(ctx, database.CreateAuditEntryParams{
+		Actor: "test", Action: "create", Entity: "recurring", EntityID: "1", AfterData: after,
+	}))
+	rawEntries, err := raw.ListAuditEntries(ctx, database.ListAuditEntriesParams{RowLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, rawEntries, 1)
+	assert.NotContains(t, string(rawEntries[0].AfterData), "Therapist")
+	entries, err := q.ListAuditEntries(ctx, database.ListAuditEntriesParams{RowLimit: 10})
+	require.NoError(t, err)
+	assert.Contains(t, string(entries[0].AfterData), `"description":"Therapist"`)
+
+	// Sorting on description sorts the plaintext, not the ciphertext.
+	for _, d := range []string{"Zoo tickets", "Apples", "Mortgage"} {
+		_, err := q.CreateTransaction(ctx, database.CreateTransactionParams{
+			Date: pgtype.Date{Valid: true}, Description: d, Type: "expense", Currency: "USD", Status: "pending",
+		})
+		require.NoError(t, err)
+	}
+	sorted, err := q.ListTransactionsSorted(ctx, database.ListTransactionsSortedParams{
+		Status: pgtype.Text{String: "pending", Valid: true}, SortKey: "description", Descending: true,
+	})
+	require.NoError(t, err)
+	var descriptions []string
+	for _, tx := range sorted {
+		descriptions = append(descriptions, tx.Description)
+	}
+	assert.Equal(t, []string{"Zoo tickets", "Mortgage", "Apples"}, descriptions)
+
+	// Under another key, reads fail rather than return ciphertext.
+	_, err = Wrap(raw, testCipher(t, 2)).GetAllTransactions(ctx)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	// Archiving moves the ciphertext as it is.
+	n, err := q.ArchiveTransactions(ctx, pgtype.Date{Time: time.Now(), Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+	archived, err := q.ListArchivedTransactions(ctx, database.ListArchivedTransactionsParams{})
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, "Paycheck from Acme", archived[0].Description)
+	rawArchived, err := raw.ListArchivedTransactionsForBackup(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, rawArchived[0].Description, "Acme")
+}
@@ -0,0 +1,291 @@
+package fieldcrypt
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// Querier encrypts descriptions on their way into the database and
+// decrypts them on the way out, for the queries it wraps. Audit entries
+// keep copies of the rows they record, so their descriptions are
+// encrypted too.
+type Querier struct {
+	database.Querier
+	c *Cipher
+}
+
+// Wrap returns q with descriptions encrypted under c.
+func Wrap(q database.Querier, c *Cipher) *Querier {
+	return &Querier{Querier: q, c: c}
+}
+
+// Writes.
+
+func (q *Querier) CreateTransaction(ctx context.Context, arg database.CreateTransactionParams) (database.Transactions, error) {
+	arg.Description = q.c.Encrypt(arg.Description)
+	return q.transaction(q.Querier.CreateTransaction(ctx, arg))
+}
+
+func (q *Querier) InsertBackupTransaction(ctx context.Context, arg database.InsertBackupTransactionParams) error {
+	arg.Description = q.c.Encrypt(arg.Description)
+	return q.Querier.InsertBackupTransaction(ctx, arg)
+}
+
+func (q *Querier) InsertBackupArchivedTransaction(ctx context.Context, arg database.InsertBackupArchivedTransactionParams) error {
+	arg.Description = q.c.Encrypt(arg.Description)
+	return q.Querier.InsertBackupArchivedTransaction(ctx, arg)
+}
+
+func (q *Querier) CreateRecurring(ctx context.Context, arg database.CreateRecurringParams) (database.RecurringTransactions, error) {
+	arg.Description = q.c.Encrypt(arg.Description)
+	return q.recurring(q.Querier.CreateRecurring(ctx, arg))
+}
+
+func (q *Querier) UpdateRecurring(ctx context.Context, arg database.UpdateRecurringParams) (database.RecurringTransactions, error) {
+	arg.Description = q.c.Encrypt(arg.Description)
+	return q.recurring(q.Querier.UpdateRecurring(ctx, arg))
+}
+
+func (q *Querier) InsertBackupRecurring(ctx context.Context, arg database.InsertBackupRecurringParams) error {
+	arg.Description = q.c.Encrypt(arg.Description)
+	return q.Querier.InsertBackupRecurring(ctx, arg)
+}
+
+func (q *Querier) CreateAuditEntry(ctx context.Context, arg database.CreateAuditEntryParams) error {
+	arg.BeforeData = q.auditData(arg.BeforeData, q.encrypt)
+	arg.AfterData = q.auditData(arg.AfterData, q.encrypt)
+	return q.Querier.CreateAuditEntry(ctx, arg)
+}
+
+// Reads.
+
+func (q *Querier) GetTransactionByID(ctx context.Context, id int32) (database.Transactions, error) {
+	return q.transaction(q.Querier.GetTransactionByID(ctx, id))
+}
+
+func (q *Querier) RestoreTransaction(ctx context.Context, id int32) (database.Transactions, error) {
+	return q.transaction(q.Querier.RestoreTransaction(ctx, id))
+}
+
+func (q *Querier) SetTransactionStatus(ctx context.Context, arg database.SetTransactionStatusParams) (database.Transactions, error) {
+	return q.transaction(q.Querier.SetTransactionStatus(ctx, arg))
+}
+
+func (q *Querier) GetAllTransactions(ctx context.Context) ([]database.Transactions, error) {
+	return q.transactions(q.Querier.GetAllTransactions(ctx))
+}
+
+func (q *Querier) GetLargestExpenses(ctx context.Context, arg database.GetLargestExpensesParams) ([]database.Transactions, error) {
+	return q.transactions(q.Querier.GetLargestExpenses(ctx, arg))
+}
+
+func (q *Querier) GetPendingTransactionsBefore(ctx context.Context, date pgtype.Date) ([]database.Transactions, error) {
+	return q.transactions(q.Querier.GetPendingTransactionsBefore(ctx, date))
+}
+
+func (q *Querier) GetTransactionsByDateRange(ctx context.Context, arg database.GetTransactionsByDateRangeParams) ([]database.Transactions, error) {
+	return q.transactions(q.Querier.GetTransactionsByDateRange(ctx, arg))
+}
+
+func (q *Querier) GetTransactionsByIDs(ctx context.Context, ids []int32) ([]database.Transactions, error) {
+	return q.transactions(q.Querier.GetTransactionsByIDs(ctx, ids))
+}
+
+func (q *Querier) GetTransactionsByStatus(ctx context.Context, status string) ([]database.Transactions, error) {
+	return q.transactions(q.Querier.GetTransactionsByStatus(ctx, status))
+}
+
+func (q *Querier) GetTransactionsByType(ctx context.Context, type_ string) ([]database.Transactions, error) {
+	return q.transactions(q.Querier.GetTransactionsByType(ctx, type_))
+}
+
+func (q *Querier) ListDeletedTransactions(ctx context.Context) ([]database.Transactions, error) {
+	return q.transactions(q.Querier.ListDeletedTransactions(ctx))
+}
+
+func (q *Querier) ListTransactionsForBackup(ctx context.Context) ([]database.Transactions, error) {
+	return q.transactions(q.Querier.ListTransactionsForBackup(ctx))
+}
+
+// ListTransactionsSorted sorts by description again once it is decrypted:
+// the database could only order the ciphertext. That only works on the
+// whole list, so the service doesn't page lists sorted on description.
+func (q *Querier) ListTransactionsSorted(ctx context.Context, arg database.ListTransactionsSortedParams) ([]database.Transactions, error) {
+	ts, err := q.transactions(q.Querier.ListTransactionsSorted(ctx, arg))
+	if err == nil && arg.SortKey == "description" {
+		slices.SortFunc(ts, byDescription(arg.Descending, func(t database.Transactions) (string, int32) { return t.Description, t.ID }))
+	}
+	return ts, err
+}
+
+func (q *Querier) ListArchivedTransactions(ctx context.Context, arg database.ListArchivedTransactionsParams) ([]database.ArchivedTransactions, error) {
+	return q.archived(q.Querier.ListArchivedTransactions(ctx, arg))
+}
+
+func (q *Querier) ListArchivedTransactionsForBackup(ctx context.Context) ([]database.ArchivedTransactions, error) {
+	return q.archived(q.Querier.ListArchivedTransactionsForBackup(ctx))
+}
+
+func (q *Querier) GetRecurringByID(ctx context.Context, id int32) (database.RecurringTransactions, error) {
+	return q.recurring(q.Querier.GetRecurringByID(ctx, id))
+}
+
+func (q *Querier) RestoreRecurring(ctx context.Context, id int32) (database.RecurringTransactions, error) {
+	return q.recurring(q.Querier.RestoreRecurring(ctx, id))
+}
+
+func (q *Querier) SetRecurringActive(ctx context.Context, arg database.SetRecurringActiveParams) (database.RecurringTransactions, error) {
+	return q.recurring(q.Querier.SetRecurringActive(ctx, arg))
+}
+
+func (q *Querier) SetRecurringPause(ctx context.Context, arg database.SetRecurringPauseParams) (database.RecurringTransactions, error) {
+	return q.recurring(q.Querier.SetRecurringPause(ctx, arg))
+}
+
+func (q *Querier) GetRecurringsByIDs(ctx context.Context, ids []int32) ([]database.RecurringTransactions, error) {
+	return q.recurrings(q.Querier.GetRecurringsByIDs(ctx, ids))
+}
+
+func (q *Querier) ListActiveRecurring(ctx context.Context) ([]database.RecurringTransactions, error) {
+	return q.recurrings(q.Querier.ListActiveRecurring(ctx))
+}
+
+func (q *Querier) ListDeletedRecurring(ctx context.Context) ([]database.RecurringTransactions, error) {
+	return q.recurrings(q.Querier.ListDeletedRecurring(ctx))
+}
+
+func (q *Querier) ListRecurring(ctx context.Context) ([]database.RecurringTransactions, error) {
+	return q.recurrings(q.Querier.ListRecurring(ctx))
+}
+
+// ListRecurringSorted sorts by description again, as
+// ListTransactionsSorted does.
+func (q *Querier) ListRecurringSorted(ctx context.Context, arg database.ListRecurringSortedParams) ([]database.RecurringTransactions, error) {
+	rs, err := q.recurrings(q.Querier.ListRecurringSorted(ctx, arg))
+	if err == nil && arg.SortKey == "description" {
+		slices.SortFunc(rs, byDescription(arg.Descending, func(r database.RecurringTransactions) (string, int32) { return r.Description, r.ID }))
+	}
+	return rs, err
+}
+
+func (q *Querier) ListRecurringForBackup(ctx context.Context) ([]database.RecurringTransactions, error) {
+	return q.recurrings(q.Querier.ListRecurringForBackup(ctx))
+}
+
+func (q *Querier) ListAuditEntries(ctx context.Context, arg database.ListAuditEntriesParams) ([]database.AuditLog, error) {
+	return q.audit(q.Querier.ListAuditEntries(ctx, arg))
+}
+
+func (q *Querier) ListAuditHistory(ctx context.Context, entities []string) ([]database.AuditLog, error) {
+	return q.audit(q.Querier.ListAuditHistory(ctx, entities))
+}
+
+func (q *Querier) transaction(t database.Transactions, err error) (database.Transactions, error) {
+	if err != nil {
+		return t, err
+	}
+	t.Description, err = q.c.Decrypt(t.Description)
+	return t, err
+}
+
+func (q *Querier) transactions(ts []database.Transactions, err error) ([]database.Transactions, error) {
+	for i := range ts {
+		if err != nil {
+			break
+		}
+		ts[i], err = q.transaction(ts[i], nil)
+	}
+	return ts, err
+}
+
+// archived decrypts archived rows, which kept the descriptions the
+// transactions had.
+func (q *Querier) archived(ts []database.ArchivedTransactions, err error) ([]database.ArchivedTransactions, error) {
+	for i := range ts {
+		if err != nil {
+			break
+		}
+		ts[i].Description, err = q.c.Decrypt(ts[i].Description)
+	}
+	return ts, err
+}
+
+func (q *Querier) recurring(r database.RecurringTransactions, err error) (database.RecurringTransactions, error) {
+	if err != nil {
+		return r, err
+	}
+	r.Description, err = q.c.Decrypt(r.Description)
+	return r, err
+}
+
+func (q *Querier) recurrings(rs []database.RecurringTransactions, err error) ([]database.RecurringTransactions, error) {
+	for i := range rs {
+		if err != nil {
+			break
+		}
+		rs[i], err = q.recurring(rs[i], nil)
+	}
+	return rs, err
+}
+
+// byDescription orders rows as the sorted list queries do when sorting on
+// description: byte by byte, then by id, both reversed when descending.
+func byDescription[T any](descending bool, key func(T) (string, int32)) func(a, b T) int {
+	return func(a, b T) int {
+		da, ia := key(a)
+		db, ib := key(b)
+		c := strings.Compare(da, db)
+		if c == 0 {
+			c = cmp.Compare(ia, ib)
+		}
+		if descending {
+			return -c
+		}
+		return c
+	}
+}
+
+func (q *Querier) audit(entries []database.AuditLog, err error) ([]database.AuditLog, error) {
+	if err != nil {
+		return entries, err
+	}
+	for i := range entries {
+		entries[i].BeforeData = q.auditData(entries[i].BeforeData, q.c.Decrypt)
+		entries[i].AfterData = q.auditData(entries[i].AfterData, q.c.Decrypt)
+	}
+	return entries, nil
+}
+
+func (q *Querier) encrypt(s string) (string, error) {
+	return q.c.Encrypt(s), nil
+}
+
+// auditData applies fn to the description in an audit entry's copy of a
+// row, if it has one. A description that won't decrypt is left sealed:
+// the history is still worth showing.
+func (q *Querier) auditData(data []byte, fn func(string) (string, error)) []byte {
+	var row map[string]json.RawMessage
+	if len(data) == 0 || json.Unmarshal(data, &row) != nil {
+		return data
+	}
+	var desc string
+	if json.Unmarshal(row["description"], &desc) != nil {
+		return data
+	}
+	desc, err := fn(desc)
+	if err != nil {
+		return data
+	}
+	row["description"], _ = json.Marshal(desc)
+	out, err := json.Marshal(row)
+	if err != nil {
+		return data
+	}
+	return out
+}
@@ -0,0 +1,24 @@
+// Package household carries which household a request works on, from the
+// API down to the database layers that keep households' data apart.
+package household
+
+import "context"
+
+// Default is the household every installation starts with. Work that names
+// no household, such as the CLI's, is on this one.
+const Default int32 = 1
+
+type ctxKey struct{}
+
+// WithID returns a copy of ctx working on household id.
+func WithID(ctx context.Context, id int32) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext is the household ctx works on, Default if it names none.
+func FromContext(ctx context.Context) int32 {
+	if id, ok := ctx.Value(ctxKey{}).(int32); ok {
+		return id
+	}
+	return Default
+}
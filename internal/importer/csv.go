@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/amount"
+)
+
+// ParseYNABCSV reads a YNAB register export or import file. Columns are
+// matched by header name; Outflow and Inflow become a signed amount.
+func ParseYNABCSV(r io.Reader) ([]Entry, error) {
+	return parseCSV(r, func(row map[string]string) (Entry, error) {
+		date, err := parseCSVDate(row["date"])
+		if err != nil {
+			return Entry{}, err
+		}
+		out, err := parseCSVAmount(row["outflow"])
+		if err != nil {
+			return Entry{}, err
+		}
+		in, err := parseCSVAmount(row["inflow"])
+		if err != nil {
+			return Entry{}, err
+		}
+		return Entry{
+			Date:     date,
+			Amount:   in - out,
+			Payee:    row["payee"],
+			Memo:     row["memo"],
+			Category: row["category"],
+		}, nil
+	})
+}
+
+// ParseMintCSV reads Mint's transaction export. Amounts are unsigned there;
+// the Transaction Type column says whether money left (debit) or arrived.
+func ParseMintCSV(r io.Reader) ([]Entry, error) {
+	return parseCSV(r, func(row map[string]string) (Entry, error) {
+		date, err := parseCSVDate(row["date"])
+		if err != nil {
+			return Entry{}, err
+		}
+		amt, err := parseCSVAmount(row["amount"])
+		if err != nil {
+			return Entry{}, err
+		}
+		if amt < 0 {
+			amt = -amt
+		}
+		switch strings.ToLower(row["transaction type"]) {
+		case "debit":
+			amt = -amt
+		case "credit":
+		default:
+			return Entry{}, fmt.Errorf("invalid transaction type %q (expected debit or credit)", row["transaction type"])
+		}
+		return Entry{
+			Date:     date,
+			Amount:   amt,
+			Payee:    row["description"],
+			Memo:     row["notes"],
+			Category: row["category"],
+		}, nil
+	})
+}
+
+func parseCSV(r io.Reader, entry func(map[string]string) (Entry, error)) ([]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	for i, h := range header {
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff")
+		}
+		header[i] = strings.ToLower(strings.TrimSpace(h))
+	}
+
+	var out []Entry
+	for n := 1; ; n++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, h := range header {
+			if i < len(rec) {
+				row[h] = strings.TrimSpace(rec[i])
+			}
+		}
+		e, err := entry(row)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", n, err)
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// parseCSVDate accepts the US month/day order both tools export, plus ISO
+// dates.
+func parseCSVDate(s string) (time.Time, error) {
+	for _, layout := range []string{"01/02/2006", "1/2/2006", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse date: %s", s)
+}
+
+// parseCSVAmount reads the US-formatted amounts YNAB and Mint write. Blank
+// cells (an empty Inflow, say) count as zero.
+func parseCSVAmount(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return amount.ParseLocale(s, amount.USLocale)
+}
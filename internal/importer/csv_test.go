@@ -0,0 +1,43 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseYNABCSV(t *testing.T) {
+	csv := "\ufeffDate,Payee,Category,Memo,Outflow,Inflow\n" +
+		"01/03/2025,Paycheck,Salary,,,\"$2,500.00\"\n" +
+		"01/15/2025,Landlord,Housing,January,1200.50,\n"
+	entries, err := ParseYNABCSV(strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC), entries[0].Date)
+	assert.Equal(t, 2500.00, entries[0].Amount)
+	assert.Equal(t, "Salary", entries[0].Category)
+	assert.Equal(t, -1200.50, entries[1].Amount)
+	assert.Equal(t, "January", entries[1].Memo)
+}
+
+func TestParseMintCSV(t *testing.T) {
+	csv := `"Date","Description","Original Description","Amount","Transaction Type","Category","Account Name","Labels","Notes"
+"1/03/2025","Paycheck","ACME PAYROLL","2500.00","credit","Paycheck","Checking","",""
+"1/15/2025","Landlord","ZELLE","1200.50","debit","Mortgage & Rent","Checking","","January"
+`
+	entries, err := ParseMintCSV(strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, 2500.00, entries[0].Amount)
+	assert.Equal(t, -1200.50, entries[1].Amount)
+	assert.Equal(t, "Mortgage & Rent", entries[1].Category)
+	assert.Equal(t, "January", entries[1].Memo)
+
+	_, err = ParseMintCSV(strings.NewReader("Date,Description,Amount,Transaction Type\n1/03/2025,X,5,refund\n"))
+	assert.ErrorContains(t, err, "row 1: invalid transaction type")
+}
@@ -0,0 +1,194 @@
+// Package importer parses bank and personal-finance export files into
+// entries the service layer can turn into transactions.
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// Entry is one transaction read from an export file. Amount is signed:
+// positive for money in, negative for money out.
+type Entry struct {
+	Date     time.Time `json:"date"`
+	Amount   float64   `json:"amount"`
+	Payee    string    `json:"payee"`
+	Memo     string    `json:"memo,omitempty"`
+	Category string    `json:"category,omitempty"`
+	Splits   []Split   `json:"splits,omitempty"`
+}
+
+// Split is one line of a split transaction.
+type Split struct {
+	Amount   float64 `json:"amount"`
+	Category string  `json:"category,omitempty"`
+	Memo     string  `json:"memo,omitempty"`
+}
+
+// qifCashTypes are the !Type sections that hold ordinary register entries.
+// Investment, category, and memorized-transaction lists are skipped.
+var qifCashTypes = map[string]bool{
+	"bank":  true,
+	"cash":  true,
+	"ccard": true,
+	"oth a": true,
+	"oth l": true,
+}
+
+// ParseQIF reads register entries from a Quicken Interchange Format file.
+// Dates are read as US month/day order, which is what Quicken and most
+// banks emit.
+func ParseQIF(r io.Reader) ([]Entry, error) {
+	sc := bufio.NewScanner(r)
+	var (
+		out     []Entry
+		cur     Entry
+		split   *Split
+		dirty   bool
+		inCash  = true
+		lineNum int
+	)
+
+	flushSplit := func() {
+		if split != nil {
+			cur.Splits = append(cur.Splits, *split)
+			split = nil
+		}
+	}
+
+	for sc.Scan() {
+		lineNum++
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		if strings.HasPrefix(line, "!") {
+			header := strings.ToLower(strings.TrimSpace(line))
+			if strings.HasPrefix(header, "!type:") {
+				inCash = qifCashTypes[strings.TrimPrefix(header, "!type:")]
+			} else if strings.HasPrefix(header, "!account") {
+				inCash = false
+			}
+			continue
+		}
+
+		code, value := line[0], strings.TrimSpace(line[1:])
+		if code == '^' {
+			if inCash && dirty {
+				flushSplit()
+				out = append(out, cur)
+			}
+			cur, split, dirty = Entry{}, nil, false
+			continue
+		}
+		if !inCash {
+			continue
+		}
+
+		dirty = true
+		switch code {
+		case 'D':
+			d, err := parseQIFDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			cur.Date = d
+		case 'T', 'U':
+			amt, err := parseQIFAmount(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			cur.Amount = amt
+		case 'P':
+			cur.Payee = value
+		case 'M':
+			cur.Memo = value
+		case 'L':
+			cur.Category = qifCategory(value)
+		case 'S':
+			flushSplit()
+			split = &Split{Category: qifCategory(value)}
+		case 'E':
+			if split != nil {
+				split.Memo = value
+			}
+		case '$':
+			if split != nil {
+				amt, err := parseQIFAmount(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+				split.Amount = amt
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if inCash && dirty {
+		flushSplit()
+		out = append(out, cur)
+	}
+
+	for i, e := range out {
+		if e.Date.IsZero() {
+			return nil, fmt.Errorf("entry %d (%q) has no date", i+1, e.Payee)
+		}
+	}
+	return out, nil
+}
+
+// parseQIFDate accepts the common QIF date spellings: 1/2/2025, 01/02/25,
+// 1/ 2'25 (apostrophe before the year for 2000+), and 2025-01-02.
+func parseQIFDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	norm := strings.NewReplacer(" ", "", "'", "/", "-", "/", ".", "/").Replace(s)
+	parts := strings.Split(norm, "/")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("unable to parse date: %s", s)
+	}
+	m, err1 := strconv.Atoi(parts[0])
+	d, err2 := strconv.Atoi(parts[1])
+	y, err3 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || err3 != nil || m < 1 || m > 12 || d < 1 || d > 31 {
+		return time.Time{}, fmt.Errorf("unable to parse date: %s", s)
+	}
+	if y < 100 {
+		if y < 70 {
+			y += 2000
+		} else {
+			y += 1900
+		}
+	}
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC), nil
+}
+
+func parseQIFAmount(s string) (float64, error) {
+	clean := strings.NewReplacer(",", "", "$", "", " ", "").Replace(s)
+	v, err := money.Parse(clean)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return v.Float64(), nil
+}
+
+// qifCategory strips transfer brackets ("[Savings]") and class suffixes
+// ("Food/Business") so only the category name remains.
+func qifCategory(s string) string {
+	if i := strings.Index(s, "/"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.Trim(strings.TrimSpace(s), "[]")
+}
@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQIF(t *testing.T) {
+	qif := `!Account
+NChecking
+TBank
+^
+!Type:Bank
+D1/15/2025
+T-1,200.00
+PLandlord
+LHousing:Rent
+^
+D2/ 1'25
+T2500.00
+PAcme Corp
+MPaycheck
+LSalary
+^
+D02/03/25
+T-100.00
+PCostco
+SGroceries
+$-60.00
+SHousehold/Business
+EPaper towels
+$-40.00
+^
+!Type:Invst
+D1/20/2025
+T-500.00
+^
+`
+	entries, err := ParseQIF(strings.NewReader(qif))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), entries[0].Date)
+	assert.Equal(t, -1200.00, entries[0].Amount)
+	assert.Equal(t, "Housing:Rent", entries[0].Category)
+
+	assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), entries[1].Date)
+	assert.Equal(t, "Paycheck", entries[1].Memo)
+
+	require.Len(t, entries[2].Splits, 2)
+	assert.Equal(t, Split{Amount: -60, Category: "Groceries"}, entries[2].Splits[0])
+	assert.Equal(t, Split{Amount: -40, Category: "Household", Memo: "Paper towels"}, entries[2].Splits[1])
+}
+
+func TestParseQIFRejectsBadInput(t *testing.T) {
+	_, err := ParseQIF(strings.NewReader("!Type:Bank\nD13/45/2025\nT1\n^\n"))
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = ParseQIF(strings.NewReader("!Type:Bank\nT1\nPNo date\n^\n"))
+	assert.ErrorContains(t, err, "has no date")
+}
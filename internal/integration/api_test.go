@@ -0,0 +1,100 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/api"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+func TestAPI(t *testing.T) {
+	svc := openService(t)
+	server := httptest.NewServer(api.NewAPIServer(svc).SetupRoutes())
+	defer server.Close()
+
+	do := func(method, path string, body any, header ...string) *http.Response {
+		t.Helper()
+		var r io.Reader
+		if body != nil {
+			b, err := json.Marshal(body)
+			require.NoError(t, err)
+			r = bytes.NewReader(b)
+		}
+		req, err := http.NewRequest(method, server.URL+path, r)
+		require.NoError(t, err)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+	decode := func(resp *http.Response, v any) {
+		t.Helper()
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+
+	resp := do("GET", "/readyz", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	today := svc.Today(context.Background())
+	tomorrow := today.AddDate(0, 0, 1).Format("2006-01-02")
+
+	resp = do("PUT", "/api/balance", map[string]any{"balance": "800.00"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do("POST", "/api/transactions/income", map[string]any{"date": tomorrow, "amount": 1200, "description": "Paycheck"})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp = do("POST", "/api/recurring", map[string]any{
+		"description": "Rent", "type": "expense", "amount": 950, "start_date": tomorrow, "interval": "monthly", "active": true,
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var rent service.Recurring
+	decode(resp, &rent)
+
+	resp = do("GET", "/api/forecast?days=3", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	var forecast []service.DailyCashFlow
+	decode(resp, &forecast)
+	require.Len(t, forecast, 3)
+	assert.Equal(t, 1050.0, forecast[1].Balance)
+
+	// Nothing changed, so the client's copy is still good.
+	resp = do("GET", "/api/forecast?days=3", nil, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp = do("PUT", fmt.Sprintf("/api/recurring/%d/active", rent.ID), map[string]any{"active": false})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do("GET", "/api/forecast?days=3", nil, "If-None-Match", etag)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	decode(resp, &forecast)
+	assert.Equal(t, 2000.0, forecast[1].Balance, "inactive recurrings drop out")
+
+	resp = do("GET", "/api/transactions/between?start="+tomorrow+"&end="+tomorrow, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var between []service.Transaction
+	decode(resp, &between)
+	require.Len(t, between, 1)
+	assert.Equal(t, "Paycheck", between[0].Description)
+
+	resp = do("DELETE", fmt.Sprintf("/api/transactions/%d", between[0].ID), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do("GET", "/api/trash", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var trash service.Trash
+	decode(resp, &trash)
+	assert.Len(t, trash.Transactions, 1)
+}
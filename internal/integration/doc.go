@@ -0,0 +1,10 @@
+// Package integration holds end-to-end tests that run the service and API
+// against a real Postgres, migrated with the files in sql/migrations. They
+// are behind the integration build tag:
+//
+//	go test -tags=integration ./internal/integration/
+//
+// By default a throwaway postgres container is started with testcontainers,
+// which needs Docker. Set CURRENTZ_TEST_DATABASE_URL to use an existing
+// database instead; the tests empty every table in it.
+package integration
@@ -0,0 +1,119 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+const postgresImage = "postgres:16-alpine"
+
+// The database every test shares. Tests call reset first and don't run in
+// parallel.
+var (
+	dbURL string
+	sqlDB *sql.DB
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	ctx := context.Background()
+
+	dbURL = os.Getenv("CURRENTZ_TEST_DATABASE_URL")
+	if dbURL == "" {
+		container, err := postgres.Run(ctx, postgresImage,
+			postgres.WithDatabase("currentz"),
+			postgres.WithUsername("currentz"),
+			postgres.WithPassword("currentz"),
+			postgres.BasicWaitStrategies(),
+		)
+		if err != nil {
+			log.Printf("starting postgres: %v", err)
+			return 1
+		}
+		defer func() {
+			if err := testcontainers.TerminateContainer(container); err != nil {
+				log.Printf("stopping postgres: %v", err)
+			}
+		}()
+		if dbURL, err = container.ConnectionString(ctx, "sslmode=disable"); err != nil {
+			log.Printf("postgres connection string: %v", err)
+			return 1
+		}
+	}
+
+	var err error
+	if sqlDB, err = sql.Open("pgx", dbURL); err != nil {
+		log.Printf("opening database: %v", err)
+		return 1
+	}
+	defer func() { _ = sqlDB.Close() }()
+	if err := migrate(sqlDB); err != nil {
+		log.Printf("migrating: %v", err)
+		return 1
+	}
+	return m.Run()
+}
+
+// migrate brings the database up to date the way `make migrate-up` does.
+func migrate(db *sql.DB) error {
+	if err := goose.SetDialect("postgres"); err != nil {
+		return err
+	}
+	goose.SetLogger(goose.NopLogger())
+	return goose.Up(db, "../../sql/migrations")
+}
+
+// reset empties every table and restarts the ID sequences, leaving the
+// schema and goose's bookkeeping alone.
+func reset(t *testing.T) {
+	t.Helper()
+	_, err := sqlDB.Exec(`TRUNCATE transactions, income_tax_details, recurring_transactions,
+		settings, accounts, exchange_rates, audit_log, sync_changes RESTART IDENTITY CASCADE`)
+	if err != nil {
+		t.Fatalf("resetting database: %v", err)
+	}
+}
+
+// openService resets the database and returns a service on it, closed when
+// the test ends.
+func openService(t *testing.T) *service.FinanceService {
+	t.Helper()
+	reset(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	svc, err := service.OpenFinanceService(ctx, dbURL, service.PoolOptions{ConnectTimeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("opening service: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("closing service: %v", err)
+		}
+	})
+	return svc
+}
+
+func mustDate(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(fmt.Sprintf("bad test date %q: %v", s, err))
+	}
+	return t
+}
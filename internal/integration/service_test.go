@@ -0,0 +1,430 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/fieldcrypt"
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+func TestSchemaIsCurrent(t *testing.T) {
+	svc := openService(t)
+	ctx := context.Background()
+
+	require.NoError(t, svc.Ping(ctx))
+	status, err := svc.SchemaStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(service.SchemaVersion), status.Current)
+	assert.Zero(t, status.Pending)
+}
+
+func TestForecast(t *testing.T) {
+	svc := openService(t)
+	ctx := context.Background()
+	today := svc.Today(ctx)
+
+	require.NoError(t, svc.SetStartingBalance(ctx, 1000))
+	require.NoError(t, svc.AddIncome(ctx, today.AddDate(0, 0, 1), 500, "Bonus", ""))
+	_, _, err := svc.RecordExpense(ctx, service.ExpenseInput{Date: today.AddDate(0, 0, 2), Amount: 200.25, Description: "Groceries"})
+	require.NoError(t, err)
+	_, err = svc.CreateRecurringSimple(ctx, service.RecurringInput{
+		Description: "Gym", Type: "expense", Amount: 49.75, StartDate: today.AddDate(0, 0, 3), Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+	// In the trash, so it must not count.
+	require.NoError(t, svc.AddIncome(ctx, today.AddDate(0, 0, 1), 10000, "Mistake", ""))
+	txs, err := svc.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	for _, tx := range txs {
+		if tx.Description == "Mistake" {
+			require.NoError(t, svc.DeleteTransaction(ctx, tx.ID))
+		}
+	}
+
+	forecast, err := svc.Forecast(ctx, 1000, 5)
+	require.NoError(t, err)
+	require.Len(t, forecast, 5)
+	var balances []float64
+	for _, day := range forecast {
+		balances = append(balances, day.Balance)
+	}
+	assert.Equal(t, []float64{1000, 1500, 1299.75, 1250, 1250}, balances)
+
+	lowest, idx := svc.FindLowestPoint(forecast)
+	assert.Equal(t, 1000.0, lowest.Balance)
+	assert.Zero(t, idx)
+}
+
+// TestMatchesMemDB runs the same history through Postgres and memdb and
+// checks the service can't tell them apart, which keeps the unit tests
+// built on memdb honest.
+func TestMatchesMemDB(t *testing.T) {
+	ctx := context.Background()
+	pg := openService(t)
+	mem := service.NewFinanceService(memdb.New())
+	for _, svc := range []*service.FinanceService{pg, mem} {
+		populate(t, ctx, svc)
+	}
+
+	pgForecast, err := pg.Forecast(ctx, 2500, 120)
+	require.NoError(t, err)
+	memForecast, err := mem.Forecast(ctx, 2500, 120)
+	require.NoError(t, err)
+	assert.Equal(t, memForecast, pgForecast)
+
+	today := pg.Today(ctx)
+	start, end := today.AddDate(0, -2, 0), today.AddDate(0, 2, 0)
+	pgBetween, err := pg.GetTransactionsWithRecurringsBetween(ctx, start, end)
+	require.NoError(t, err)
+	memBetween, err := mem.GetTransactionsWithRecurringsBetween(ctx, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, summarizeListed(memBetween), summarizeListed(pgBetween))
+
+	// The sorted lists come back in the same order.
+	for _, order := range []service.ListOrder{
+		{Sort: service.SortAmount}, {Sort: service.SortDescription, Desc: true}, {Sort: service.SortDate, Desc: true},
+	} {
+		pgSorted, _, err := pg.ListTransactions(ctx, "", order, service.Page{})
+		require.NoError(t, err)
+		memSorted, _, err := mem.ListTransactions(ctx, "", order, service.Page{})
+		require.NoError(t, err)
+		assert.Equal(t, inOrder(memSorted), inOrder(pgSorted), "%+v", order)
+
+		// Paging through it gives the same rows in the same order.
+		var paged []service.Transaction
+		page := service.Page{Limit: 2}
+		for {
+			txs, next, err := pg.ListTransactions(ctx, "", order, page)
+			require.NoError(t, err)
+			paged = append(paged, txs...)
+			if next == "" {
+				break
+			}
+			page.After = next
+		}
+		assert.Equal(t, inOrder(pgSorted), inOrder(paged), "paged %+v", order)
+	}
+
+	pgAgg, err := pg.AggregateTransactions(ctx, service.AggregateFilter{})
+	require.NoError(t, err)
+	memAgg, err := mem.AggregateTransactions(ctx, service.AggregateFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, memAgg, pgAgg)
+
+	pgSummary, err := pg.SummarizeTransactions(ctx, start, end, true)
+	require.NoError(t, err)
+	memSummary, err := mem.SummarizeTransactions(ctx, start, end, true)
+	require.NoError(t, err)
+	assert.Equal(t, memSummary.TransactionAggregates, pgSummary.TransactionAggregates)
+	assert.Equal(t, memSummary.AverageDailySpend, pgSummary.AverageDailySpend)
+
+	pgHeatmap, err := pg.SpendingHeatmap(ctx, start, end)
+	require.NoError(t, err)
+	memHeatmap, err := mem.SpendingHeatmap(ctx, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, memHeatmap, pgHeatmap)
+
+	filter := service.TopSpendingFilter{By: service.TopByPayee, Start: start, End: end, IncludeRecurring: true}
+	pgTop, err := pg.TopSpending(ctx, filter)
+	require.NoError(t, err)
+	memTop, err := mem.TopSpending(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, memTop, pgTop)
+}
+
+// TestRecurringExpansion checks the schedules that are easy to get wrong
+// against rows read back from Postgres.
+func TestRecurringExpansion(t *testing.T) {
+	svc := openService(t)
+	ctx := context.Background()
+	day := func(n int) *int { return &n }
+	friday := int(time.Friday)
+	end := mustDate("2025-06-30")
+
+	inputs := []service.RecurringInput{
+		{Description: "Month end", Type: "expense", Amount: 10, StartDate: mustDate("2025-01-31"), Interval: "monthly", DayOfMonth: day(31), Active: true},
+		{Description: "Payday", Type: "income", Amount: 2000, StartDate: mustDate("2025-01-01"), Interval: "biweekly", DayOfWeek: &friday, Active: true},
+		{Description: "Leap day", Type: "expense", Amount: 5, StartDate: mustDate("2024-02-29"), Interval: "yearly", Active: true},
+		{Description: "Short run", Type: "expense", Amount: 1, StartDate: mustDate("2025-03-03"), Interval: "weekly", EndDate: &end, Active: true},
+		{Description: "Inactive", Type: "expense", Amount: 1, StartDate: mustDate("2025-01-01"), Interval: "weekly", Active: false},
+	}
+	ids := map[string]int32{}
+	for _, in := range inputs {
+		r, err := svc.CreateRecurringSimple(ctx, in)
+		require.NoError(t, err, in.Description)
+		ids[in.Description] = r.ID
+	}
+	from, until := mustDate("2025-04-01"), mustDate("2025-04-30")
+	_, err := svc.PauseRecurring(ctx, ids["Short run"], &from, &until)
+	require.NoError(t, err)
+
+	occ, err := svc.ExpandRecurringBetween(ctx, mustDate("2025-01-01"), mustDate("2025-12-31"))
+	require.NoError(t, err)
+	dates := map[string][]string{}
+	for _, tx := range occ {
+		dates[tx.Description] = append(dates[tx.Description], tx.Date.Time.Format("2006-01-02"))
+	}
+	for _, d := range dates {
+		sort.Strings(d)
+	}
+
+	assert.Equal(t, []string{
+		"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30", "2025-05-31", "2025-06-30",
+		"2025-07-31", "2025-08-31", "2025-09-30", "2025-10-31", "2025-11-30", "2025-12-31",
+	}, dates["Month end"])
+
+	require.Len(t, dates["Payday"], 26)
+	assert.Equal(t, "2025-01-03", dates["Payday"][0], "snapped to the first Friday")
+	for i := 1; i < len(dates["Payday"]); i++ {
+		prev, cur := mustDate(dates["Payday"][i-1]), mustDate(dates["Payday"][i])
+		assert.Equal(t, 14*24*time.Hour, cur.Sub(prev))
+	}
+
+	assert.Equal(t, []string{"2025-02-28"}, dates["Leap day"], "clamped in a common year")
+
+	for _, d := range dates["Short run"] {
+		assert.False(t, d >= "2025-04-01" && d <= "2025-04-30", "paused on %s", d)
+		assert.LessOrEqual(t, d, "2025-06-30", "after the end date")
+	}
+	assert.Len(t, dates["Short run"], 18-4)
+	assert.Empty(t, dates["Inactive"])
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	svc := openService(t)
+	ctx := context.Background()
+	populate(t, ctx, svc)
+
+	before, err := svc.Forecast(ctx, 2500, 60)
+	require.NoError(t, err)
+	backup, err := svc.Backup(ctx)
+	require.NoError(t, err)
+
+	reset(t)
+	summary, err := svc.Restore(ctx, backup)
+	require.NoError(t, err)
+	assert.Equal(t, len(backup.Transactions), summary.Transactions)
+
+	after, err := svc.Forecast(ctx, 2500, 60)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	// Sequences carry on past the restored IDs.
+	require.NoError(t, svc.AddIncome(ctx, svc.Today(ctx), 1, "After restore", ""))
+}
+
+// TestSyncTriggers checks the triggers behind sync record what memdb does:
+// new, changed and deleted records, however they were written.
+func TestSyncTriggers(t *testing.T) {
+	ctx := context.Background()
+	pg := openService(t)
+	mem := service.NewFinanceService(memdb.New())
+	for _, svc := range []*service.FinanceService{pg, mem} {
+		populate(t, ctx, svc)
+	}
+
+	deltas := make([]service.SyncResult, 2)
+	for i, svc := range []*service.FinanceService{pg, mem} {
+		all, err := svc.Sync(ctx, 0, 0)
+		require.NoError(t, err)
+		require.NotEmpty(t, all.Transactions.Created)
+		require.NotEmpty(t, all.Recurring.Created)
+
+		first, last := all.Transactions.Created[0], all.Recurring.Created[0]
+		require.NoError(t, svc.DeleteTransaction(ctx, first.ID))
+		require.NoError(t, svc.SetRecurringActive(ctx, last.ID, false))
+		require.NoError(t, svc.AddIncome(ctx, svc.Today(ctx), 42, "Refund", ""))
+		require.NoError(t, svc.SetStartingBalance(ctx, 2600))
+
+		deltas[i], err = svc.Sync(ctx, all.Cursor, 0)
+		require.NoError(t, err)
+		assert.Greater(t, deltas[i].Cursor, all.Cursor)
+	}
+
+	pgDelta, memDelta := deltas[0], deltas[1]
+	assert.Equal(t, memDelta.Transactions.Deleted, pgDelta.Transactions.Deleted)
+	assert.Equal(t, summarize(memDelta.Transactions.Created), summarize(pgDelta.Transactions.Created))
+	require.Len(t, pgDelta.Recurring.Updated, 1)
+	assert.False(t, pgDelta.Recurring.Updated[0].Active)
+	require.Len(t, pgDelta.Settings.Updated, 1)
+	assert.Equal(t, memDelta.Settings.Updated[0].Value, pgDelta.Settings.Updated[0].Value)
+}
+
+func TestVersionConflicts(t *testing.T) {
+	ctx := context.Background()
+	pg := openService(t)
+	mem := service.NewFinanceService(memdb.New())
+	for _, svc := range []*service.FinanceService{pg, mem} {
+		populate(t, ctx, svc)
+		recs, err := svc.ListRecurring(ctx)
+		require.NoError(t, err)
+		rec := recs[0]
+		require.Equal(t, int32(1), rec.Version)
+
+		require.NoError(t, svc.SetRecurringActive(service.WithVersion(ctx, 1), rec.ID, false))
+		paused, err := svc.PauseRecurring(service.WithVersion(ctx, 2), rec.ID, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), paused.Version)
+
+		err = svc.SetRecurringActive(service.WithVersion(ctx, 2), rec.ID, true)
+		assert.ErrorIs(t, err, service.ErrVersionConflict)
+		_, err = svc.PauseRecurring(ctx, rec.ID, nil, nil)
+		assert.NoError(t, err, "no version given")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	pg := openService(t)
+	mem := service.NewFinanceService(memdb.New())
+	for _, svc := range []*service.FinanceService{pg, mem} {
+		populate(t, ctx, svc)
+		before, err := svc.GetAllTransactions(ctx)
+		require.NoError(t, err)
+		backup, err := svc.Backup(ctx)
+		require.NoError(t, err)
+
+		err = svc.DryRun(ctx, func(ctx context.Context) error {
+			require.NoError(t, svc.AddIncome(ctx, svc.Today(ctx), 99, "bonus", ""))
+			txs, err := svc.GetAllTransactions(ctx)
+			require.NoError(t, err)
+			assert.Len(t, txs, len(before)+1, "sees its own changes")
+
+			// Restore runs in a transaction of its own, nested in the
+			// dry run's.
+			_, err = svc.Restore(ctx, service.Backup{Version: backup.Version})
+			require.NoError(t, err)
+			txs, err = svc.GetAllTransactions(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, txs)
+			return nil
+		})
+		require.NoError(t, err)
+
+		after, err := svc.GetAllTransactions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, summarize(before), summarize(after))
+
+		// An error is passed back, and nothing is kept either.
+		err = svc.DryRun(ctx, func(ctx context.Context) error {
+			return svc.DeleteTransaction(ctx, -1)
+		})
+		assert.ErrorIs(t, err, service.ErrTransactionNotFound)
+	}
+}
+
+// populate records a small but varied history around today: cash and card
+// spending, recurrings on each interval, and an account earning interest.
+func populate(t *testing.T, ctx context.Context, svc *service.FinanceService) {
+	t.Helper()
+	today := svc.Today(ctx)
+	day := func(n int) *int { return &n }
+
+	require.NoError(t, svc.SetStartingBalance(ctx, 2500))
+	card, err := svc.CreateAccount(ctx, service.AccountInput{Name: "Card", Type: service.AccountCreditCard, StatementDay: 20, DueDay: 12})
+	require.NoError(t, err)
+	savings, err := svc.CreateAccount(ctx, service.AccountInput{Name: "Savings", Type: service.AccountSavings})
+	require.NoError(t, err)
+	apy := 0.04
+	_, err = svc.SetAccountInterest(ctx, savings.ID, service.InterestInput{Balance: 5000, APY: &apy, Compounding: service.CompoundDaily})
+	require.NoError(t, err)
+
+	for _, in := range []service.RecurringInput{
+		{Description: "Salary", Type: "income", Amount: 3100, StartDate: today.AddDate(0, -3, 4), Interval: "biweekly", Active: true},
+		{Description: "Rent", Type: "expense", Amount: 1400, StartDate: today.AddDate(0, -3, 0), Interval: "monthly", DayOfMonth: day(1), Active: true},
+		{Description: "Utilities", Type: "expense", Amount: 120.4, StartDate: today.AddDate(0, -3, 0), Interval: "monthly", DayOfMonth: day(31), Active: true},
+		{Description: "Lessons", Type: "expense", Amount: 35, StartDate: today.AddDate(0, -1, 0), Interval: "weekly", Active: true},
+		{Description: "Insurance", Type: "expense", Amount: 960, StartDate: today.AddDate(0, 1, 0), Interval: "yearly", Active: true, Prorate: true},
+	} {
+		_, err := svc.CreateRecurringSimple(ctx, in)
+		require.NoError(t, err, in.Description)
+	}
+
+	for i := -45; i <= 30; i += 3 {
+		in := service.ExpenseInput{
+			Date:        today.AddDate(0, 0, i),
+			Amount:      float64(20+i%7) + 0.37,
+			Description: fmt.Sprintf("Purchase %d", i),
+			Category:    "groceries",
+		}
+		if i%2 == 0 {
+			in.AccountID = &card.ID
+		}
+		_, _, err := svc.RecordExpense(ctx, in)
+		require.NoError(t, err)
+	}
+}
+
+// summarize turns transactions into comparable lines. Recurring
+// occurrences have no ID and come back in no particular order.
+func summarize(txs []service.Transaction) []string {
+	var out []string
+	for _, tx := range txs {
+		amt, _ := service.NumericToMoney(tx.Amount)
+		out = append(out, fmt.Sprintf("%s %s %s %s", tx.Date.Time.Format("2006-01-02"), tx.Description, amt, tx.Currency))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// inOrder is summarize without the sorting.
+func inOrder(txs []service.Transaction) []string {
+	var out []string
+	for _, tx := range txs {
+		amt, _ := service.NumericToMoney(tx.Amount)
+		out = append(out, fmt.Sprintf("%s %s %s", tx.Date.Time.Format("2006-01-02"), tx.Description, amt))
+	}
+	return out
+}
+
+// summarizeListed is summarize with where each transaction comes from.
+func summarizeListed(txs []service.ListedTransaction) []string {
+	var out []string
+	for _, tx := range txs {
+		amt, _ := service.NumericToMoney(tx.Amount)
+		out = append(out, fmt.Sprintf("%s %s %s %s %s", tx.Date.Time.Format("2006-01-02"), tx.Description, amt, tx.Currency, tx.Source))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// TestEncryptionAtRest checks only ciphertext reaches Postgres, including
+// the rows a restore writes in a transaction.
+func TestEncryptionAtRest(t *testing.T) {
+	svc := openService(t)
+	ctx := context.Background()
+	require.NoError(t, svc.SetEncryptionKey(bytes.Repeat([]byte{7}, fieldcrypt.KeySize)))
+
+	require.NoError(t, svc.AddIncome(ctx, svc.Today(ctx), 100, "Paycheck from Acme", ""))
+	backup, err := svc.Backup(ctx)
+	require.NoError(t, err)
+	require.Len(t, backup.Transactions, 1)
+	assert.Equal(t, "Paycheck from Acme", backup.Transactions[0].Description)
+	reset(t)
+	_, err = svc.Restore(ctx, backup)
+	require.NoError(t, err)
+
+	plain, err := service.OpenFinanceService(ctx, dbURL, service.PoolOptions{})
+	require.NoError(t, err)
+	defer func() { _ = plain.Close() }()
+	txs, err := plain.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.True(t, strings.HasPrefix(txs[0].Description, "enc1:"), txs[0].Description)
+
+	txs, err = svc.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Paycheck from Acme", txs[0].Description)
+}
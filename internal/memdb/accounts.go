@@ -0,0 +1,134 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) CreateAccount(ctx context.Context, arg database.CreateAccountParams) (database.Accounts, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	a := database.Accounts{
+		ID:           db.nextAccount,
+		Name:         arg.Name,
+		Type:         arg.Type,
+		StatementDay: arg.StatementDay,
+		DueDay:       arg.DueDay,
+		CreatedAt:    now(),
+		Balance:      zero(),
+		Compounding:  "monthly",
+	}
+	if err := checkAccount(a); err != nil {
+		return database.Accounts{}, err
+	}
+	if _, ok := db.accounts[a.ID]; ok {
+		return database.Accounts{}, duplicateKey("accounts")
+	}
+	db.nextAccount++
+	db.accounts[a.ID] = a
+	return a, nil
+}
+
+func (db *DB) DeleteAccount(ctx context.Context, id int32) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, t := range db.transactions {
+		if t.AccountID.Valid && t.AccountID.Int32 == id {
+			return stillReferenced("transactions")
+		}
+	}
+	for _, r := range db.recurring {
+		if r.AccountID.Valid && r.AccountID.Int32 == id {
+			return stillReferenced("recurring_transactions")
+		}
+	}
+	delete(db.accounts, id)
+	db.unlinkArchived(func(account int32) bool { return account == id })
+	return nil
+}
+
+func (db *DB) DeleteAllAccounts(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, t := range db.transactions {
+		if t.AccountID.Valid {
+			return stillReferenced("transactions")
+		}
+	}
+	for _, r := range db.recurring {
+		if r.AccountID.Valid {
+			return stillReferenced("recurring_transactions")
+		}
+	}
+	clear(db.accounts)
+	db.unlinkArchived(func(int32) bool { return true })
+	return nil
+}
+
+func (db *DB) GetAccountByID(ctx context.Context, id int32) (database.Accounts, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	a, ok := db.accounts[id]
+	if !ok {
+		return database.Accounts{}, pgx.ErrNoRows
+	}
+	return a, nil
+}
+
+func (db *DB) InsertBackupAccount(ctx context.Context, arg database.InsertBackupAccountParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.accounts[arg.ID]; ok {
+		return duplicateKey("accounts")
+	}
+	a := database.Accounts(arg)
+	if err := checkAccount(a); err != nil {
+		return err
+	}
+	db.accounts[a.ID] = a
+	return nil
+}
+
+func (db *DB) ListAccounts(ctx context.Context) ([]database.Accounts, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return sortedByID(db.accounts, func(a database.Accounts) int32 { return a.ID }), nil
+}
+
+func (db *DB) ListAccountsForBackup(ctx context.Context) ([]database.Accounts, error) {
+	db = db.scope(ctx)
+	return db.ListAccounts(ctx)
+}
+
+func (db *DB) ResetAccountsSequence(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.nextAccount = maxKey(db.accounts) + 1
+	return nil
+}
+
+func (db *DB) SetAccountInterest(ctx context.Context, arg database.SetAccountInterestParams) (database.Accounts, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	a, ok := db.accounts[arg.ID]
+	if !ok {
+		return database.Accounts{}, pgx.ErrNoRows
+	}
+	a.Balance, a.Apy, a.Compounding = arg.Balance, arg.Apy, arg.Compounding
+	if err := checkAccount(a); err != nil {
+		return database.Accounts{}, err
+	}
+	db.accounts[a.ID] = a
+	return a, nil
+}
@@ -0,0 +1,113 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) ArchiveTransactions(ctx context.Context, cutoff pgtype.Date) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if !cutoff.Valid {
+		return 0, nil
+	}
+	withAttachments := make(map[int32]bool)
+	for _, a := range db.attachments {
+		withAttachments[a.TransactionID] = true
+	}
+	var n int64
+	for id, t := range db.transactions {
+		if t.DeletedAt.Valid || t.Status != "cleared" || !t.Date.Time.Before(day(cutoff).Time) || withAttachments[id] {
+			continue
+		}
+		if _, ok := db.archived[id]; ok {
+			return 0, duplicateKey("archived_transactions")
+		}
+		db.archived[id] = database.ArchivedTransactions{
+			ID:          t.ID,
+			Date:        t.Date,
+			Amount:      t.Amount,
+			Description: t.Description,
+			Type:        t.Type,
+			CreatedAt:   t.CreatedAt,
+			Category:    t.Category,
+			Currency:    t.Currency,
+			AccountID:   t.AccountID,
+			ArchivedAt:  now(),
+		}
+		delete(db.transactions, id)
+		db.changed(syncTransaction, id, false)
+		delete(db.taxDetails, id)
+		delete(db.selfEmployed, id)
+		n++
+	}
+	return n, nil
+}
+
+func (db *DB) DeleteAllArchivedTransactions(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	clear(db.archived)
+	return nil
+}
+
+func (db *DB) InsertBackupArchivedTransaction(ctx context.Context, arg database.InsertBackupArchivedTransactionParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.archived[arg.ID]; ok {
+		return duplicateKey("archived_transactions")
+	}
+	t := database.ArchivedTransactions(arg)
+	t.Date = day(t.Date)
+	err := checkTransaction(db, database.Transactions{Type: t.Type, Status: "cleared", AccountID: t.AccountID})
+	if err != nil {
+		return err
+	}
+	db.archived[t.ID] = t
+	return nil
+}
+
+func (db *DB) ListArchivedTransactions(ctx context.Context, arg database.ListArchivedTransactionsParams) ([]database.ArchivedTransactions, error) {
+	db = db.scope(ctx)
+	out := db.listArchived(func(t database.ArchivedTransactions) bool { return between(t.Date, arg.StartDate, arg.EndDate) })
+	slices.SortStableFunc(out, func(a, b database.ArchivedTransactions) int { return a.Date.Time.Compare(b.Date.Time) })
+	return out, nil
+}
+
+func (db *DB) ListArchivedTransactionsForBackup(ctx context.Context) ([]database.ArchivedTransactions, error) {
+	db = db.scope(ctx)
+	return db.listArchived(func(database.ArchivedTransactions) bool { return true }), nil
+}
+
+// listArchived returns the matching archived transactions by ID.
+func (db *DB) listArchived(keep func(database.ArchivedTransactions) bool) []database.ArchivedTransactions {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := []database.ArchivedTransactions{}
+	for _, t := range db.archived {
+		if keep(t) {
+			out = append(out, t)
+		}
+	}
+	slices.SortFunc(out, func(a, b database.ArchivedTransactions) int { return cmp.Compare(a.ID, b.ID) })
+	return out
+}
+
+// unlinkArchived clears the account of archived transactions in the
+// accounts match picks, as ON DELETE SET NULL does. db.mu must be held.
+func (db *DB) unlinkArchived(match func(account int32) bool) {
+	for id, t := range db.archived {
+		if t.AccountID.Valid && match(t.AccountID.Int32) {
+			t.AccountID = pgtype.Int4{}
+			db.archived[id] = t
+		}
+	}
+}
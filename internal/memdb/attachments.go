@@ -0,0 +1,89 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) CreateAttachment(ctx context.Context, arg database.CreateAttachmentParams) (database.Attachments, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	a := database.Attachments{
+		ID:            db.nextAttachment,
+		TransactionID: arg.TransactionID,
+		Filename:      arg.Filename,
+		ContentType:   arg.ContentType,
+		Size:          arg.Size,
+		StorageKey:    arg.StorageKey,
+		CreatedAt:     now(),
+	}
+	if err := checkAttachment(db, a); err != nil {
+		return database.Attachments{}, err
+	}
+	db.nextAttachment++
+	db.attachments[a.ID] = a
+	return a, nil
+}
+
+func (db *DB) DeleteAttachment(ctx context.Context, arg database.DeleteAttachmentParams) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	a, ok := db.attachments[arg.ID]
+	if !ok || a.TransactionID != arg.TransactionID {
+		return 0, nil
+	}
+	delete(db.attachments, arg.ID)
+	return 1, nil
+}
+
+func (db *DB) GetAttachment(ctx context.Context, arg database.GetAttachmentParams) (database.Attachments, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	a, ok := db.attachments[arg.ID]
+	if !ok || a.TransactionID != arg.TransactionID {
+		return database.Attachments{}, pgx.ErrNoRows
+	}
+	return a, nil
+}
+
+func (db *DB) ListAttachments(ctx context.Context, transactionID int32) ([]database.Attachments, error) {
+	db = db.scope(ctx)
+	return db.listAttachments(func(a database.Attachments) bool { return a.TransactionID == transactionID }), nil
+}
+
+func (db *DB) ListPurgeableAttachments(ctx context.Context, cutoff pgtype.Timestamp) ([]database.Attachments, error) {
+	db = db.scope(ctx)
+	return db.listAttachments(func(a database.Attachments) bool {
+		t := db.transactions[a.TransactionID]
+		return t.DeletedAt.Valid && cutoff.Valid && t.DeletedAt.Time.Before(cutoff.Time)
+	}), nil
+}
+
+func (db *DB) listAttachments(keep func(database.Attachments) bool) []database.Attachments {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := []database.Attachments{}
+	for _, a := range sortedByID(db.attachments, func(a database.Attachments) int32 { return a.ID }) {
+		if keep(a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// deleteAttachmentsOf is the ON DELETE CASCADE from transactions. The
+// caller holds db.mu.
+func (db *DB) deleteAttachmentsOf(transactionID int32) {
+	for id, a := range db.attachments {
+		if a.TransactionID == transactionID {
+			delete(db.attachments, id)
+		}
+	}
+}
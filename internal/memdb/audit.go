@@ -0,0 +1,60 @@
+package memdb
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) CreateAuditEntry(ctx context.Context, arg database.CreateAuditEntryParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.audit = append(db.audit, database.AuditLog{
+		ID:         db.nextAudit,
+		Actor:      arg.Actor,
+		Action:     arg.Action,
+		Entity:     arg.Entity,
+		EntityID:   arg.EntityID,
+		BeforeData: arg.BeforeData,
+		AfterData:  arg.AfterData,
+		CreatedAt:  now(),
+	})
+	db.nextAudit++
+	return nil
+}
+
+func (db *DB) ListAuditEntries(ctx context.Context, arg database.ListAuditEntriesParams) ([]database.AuditLog, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var out []database.AuditLog
+	for i := len(db.audit) - 1; i >= 0 && len(out) < int(arg.RowLimit); i-- {
+		e := db.audit[i]
+		switch {
+		case arg.Entity.Valid && e.Entity != arg.Entity.String,
+			arg.EntityID.Valid && e.EntityID != arg.EntityID.String,
+			arg.Action.Valid && e.Action != arg.Action.String,
+			arg.Actor.Valid && e.Actor != arg.Actor.String,
+			arg.Since.Valid && e.CreatedAt.Time.Before(arg.Since.Time),
+			arg.Until.Valid && !e.CreatedAt.Time.Before(arg.Until.Time):
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (db *DB) ListAuditHistory(ctx context.Context, entities []string) ([]database.AuditLog, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var out []database.AuditLog
+	for _, e := range db.audit {
+		if slices.Contains(entities, e.Entity) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
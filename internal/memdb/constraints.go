@@ -0,0 +1,274 @@
+package memdb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// The CHECK and foreign key constraints from sql/migrations, enforced with
+// the errors Postgres would return, so validation gaps in the service show
+// up in tests instead of in production.
+
+func checkTransaction(db *DB, t database.Transactions) error {
+	if t.Type != "income" && t.Type != "expense" {
+		return checkViolation("transactions", "transactions_type_check")
+	}
+	if t.Status != "pending" && t.Status != "cleared" {
+		return checkViolation("transactions", "transactions_status_check")
+	}
+	if t.AccountID.Valid {
+		if _, ok := db.accounts[t.AccountID.Int32]; !ok {
+			return &pgconn.PgError{
+				Code:           "23503",
+				Message:        `insert or update on table "transactions" violates foreign key constraint "transactions_account_id_fkey"`,
+				TableName:      "transactions",
+				ConstraintName: "transactions_account_id_fkey",
+			}
+		}
+	}
+	return nil
+}
+
+func checkAttachment(db *DB, a database.Attachments) error {
+	if a.Size < 0 {
+		return checkViolation("attachments", "attachments_size_check")
+	}
+	if _, ok := db.transactions[a.TransactionID]; !ok {
+		return &pgconn.PgError{
+			Code:           "23503",
+			Message:        `insert or update on table "attachments" violates foreign key constraint "attachments_transaction_id_fkey"`,
+			TableName:      "attachments",
+			ConstraintName: "attachments_transaction_id_fkey",
+		}
+	}
+	for _, other := range db.attachments {
+		if other.StorageKey == a.StorageKey {
+			return &pgconn.PgError{
+				Code:           "23505",
+				Message:        `duplicate key value violates unique constraint "attachments_storage_key_key"`,
+				TableName:      "attachments",
+				ConstraintName: "attachments_storage_key_key",
+			}
+		}
+	}
+	return nil
+}
+
+func checkRecurring(db *DB, r database.RecurringTransactions) error {
+	if r.AccountID.Valid {
+		if _, ok := db.accounts[r.AccountID.Int32]; !ok {
+			return &pgconn.PgError{
+				Code:           "23503",
+				Message:        `insert or update on table "recurring_transactions" violates foreign key constraint "recurring_transactions_account_id_fkey"`,
+				TableName:      "recurring_transactions",
+				ConstraintName: "recurring_transactions_account_id_fkey",
+			}
+		}
+	}
+	switch {
+	case r.Type != "income" && r.Type != "expense":
+		return checkViolation("recurring_transactions", "recurring_transactions_type_check")
+	case r.DayOfWeek.Valid && (r.DayOfWeek.Int32 < 0 || r.DayOfWeek.Int32 > 6):
+		return checkViolation("recurring_transactions", "recurring_transactions_day_of_week_check")
+	case !dayOfMonth(r.DayOfMonth):
+		return checkViolation("recurring_transactions", "recurring_transactions_day_of_month_check")
+	case r.Prorate && r.Interval != database.RecurrenceIntervalYearly:
+		return checkViolation("recurring_transactions", "recurring_prorate_yearly")
+	case r.PausedFrom.Valid != r.PausedUntil.Valid,
+		r.PausedFrom.Valid && r.PausedFrom.Time.After(r.PausedUntil.Time):
+		return checkViolation("recurring_transactions", "recurring_pause_range")
+	case r.OccurrenceCount.Valid && r.OccurrenceCount.Int32 <= 0:
+		return checkViolation("recurring_transactions", "recurring_occurrence_count_positive")
+	case r.WeekOfMonth.Valid && (r.WeekOfMonth.Int32 < 1 || r.WeekOfMonth.Int32 > 4) && r.WeekOfMonth.Int32 != -1:
+		return checkViolation("recurring_transactions", "recurring_week_of_month_range")
+	case r.Rrule.Valid != (r.Interval == database.RecurrenceIntervalCustom):
+		return checkViolation("recurring_transactions", "recurring_rrule_custom")
+	}
+	switch r.Interval {
+	case database.RecurrenceIntervalWeekly, database.RecurrenceIntervalBiweekly,
+		database.RecurrenceIntervalMonthly, database.RecurrenceIntervalYearly,
+		database.RecurrenceIntervalCustom:
+		return nil
+	}
+	return &pgconn.PgError{
+		Code:    "22P02",
+		Message: fmt.Sprintf(`invalid input value for enum recurrence_interval: "%s"`, r.Interval),
+	}
+}
+
+func checkAccount(a database.Accounts) error {
+	switch {
+	case a.Type != "checking" && a.Type != "savings" && a.Type != "credit_card":
+		return checkViolation("accounts", "accounts_type_check")
+	case !dayOfMonth(a.StatementDay):
+		return checkViolation("accounts", "accounts_statement_day_check")
+	case !dayOfMonth(a.DueDay):
+		return checkViolation("accounts", "accounts_due_day_check")
+	case a.Type == "credit_card" && (!a.StatementDay.Valid || !a.DueDay.Valid):
+		return checkViolation("accounts", "accounts_check")
+	case a.Compounding != "daily" && a.Compounding != "monthly":
+		return checkViolation("accounts", "accounts_compounding_check")
+	case a.Apy.Valid && (sign(a.Apy) < 0 || cmpNumeric(a.Apy, one()) >= 0):
+		return checkViolation("accounts", "accounts_apy_check")
+	}
+	return nil
+}
+
+func checkEnvelope(db *DB, e database.Envelopes) error {
+	switch {
+	case sign(e.MonthlyAmount) <= 0:
+		return checkViolation("envelopes", "envelopes_monthly_amount_check")
+	case e.Target.Valid && sign(e.Target) <= 0:
+		return checkViolation("envelopes", "envelopes_target_check")
+	}
+	for _, other := range db.envelopes {
+		if other.ID != e.ID && other.Name == e.Name {
+			return &pgconn.PgError{
+				Code:           "23505",
+				Message:        `duplicate key value violates unique constraint "envelopes_name_key"`,
+				TableName:      "envelopes",
+				ConstraintName: "envelopes_name_key",
+			}
+		}
+	}
+	return nil
+}
+
+func checkRate(r database.ExchangeRates) error {
+	if sign(r.Rate) <= 0 {
+		return checkViolation("exchange_rates", "exchange_rates_rate_check")
+	}
+	return nil
+}
+
+// checkScenario checks the unique name and that definition parses as
+// JSON, as the JSONB column would.
+func checkScenario(db *DB, sc database.Scenarios) error {
+	if !json.Valid(sc.Definition) {
+		return invalidJSON()
+	}
+	for _, other := range db.scenarios {
+		if other.ID != sc.ID && other.Name == sc.Name {
+			return &pgconn.PgError{
+				Code:           "23505",
+				Message:        `duplicate key value violates unique constraint "scenarios_name_key"`,
+				TableName:      "scenarios",
+				ConstraintName: "scenarios_name_key",
+			}
+		}
+	}
+	return nil
+}
+
+func checkChannel(db *DB, c database.NotificationChannels) error {
+	if c.Type != "slack" && c.Type != "telegram" {
+		return checkViolation("notification_channels", "notification_channels_type_check")
+	}
+	if !json.Valid(c.Config) {
+		return invalidJSON()
+	}
+	for _, other := range db.channels {
+		if other.ID != c.ID && other.Name == c.Name {
+			return &pgconn.PgError{
+				Code:           "23505",
+				Message:        `duplicate key value violates unique constraint "notification_channels_name_key"`,
+				TableName:      "notification_channels",
+				ConstraintName: "notification_channels_name_key",
+			}
+		}
+	}
+	return nil
+}
+
+// checkUser checks that username is unique and role known, as the
+// table's constraints would.
+func checkUser(db *DB, u database.Users) error {
+	if !validRole(u.Role) {
+		return checkViolation("users", "users_role_check")
+	}
+	for _, other := range db.users {
+		if other.ID != u.ID && other.Username == u.Username {
+			return &pgconn.PgError{
+				Code:           "23505",
+				Message:        `duplicate key value violates unique constraint "users_username_key"`,
+				TableName:      "users",
+				ConstraintName: "users_username_key",
+			}
+		}
+	}
+	return nil
+}
+
+// validRole is the CHECK on the role columns.
+func validRole(role string) bool {
+	switch role {
+	case "owner", "editor", "viewer":
+		return true
+	}
+	return false
+}
+
+// missingHousehold is the error for a row naming a household that doesn't
+// exist.
+func missingHousehold(table string) error {
+	return &pgconn.PgError{
+		Code:           "23503",
+		Message:        fmt.Sprintf(`insert or update on table "%s" violates foreign key constraint "%s_household_id_fkey"`, table, table),
+		TableName:      table,
+		ConstraintName: table + "_household_id_fkey",
+	}
+}
+
+// checkSnapshot checks that forecast parses as JSON, as the JSONB column
+// would.
+func checkSnapshot(s database.ForecastSnapshots) error {
+	if !json.Valid(s.Forecast) {
+		return invalidJSON()
+	}
+	return nil
+}
+
+func invalidJSON() error {
+	return &pgconn.PgError{
+		Code:    "22P02",
+		Message: "invalid input syntax for type json",
+	}
+}
+
+// dayOfMonth is the BETWEEN 1 AND 31 check on a nullable day column.
+func dayOfMonth(d pgtype.Int4) bool {
+	return !d.Valid || (d.Int32 >= 1 && d.Int32 <= 31)
+}
+
+func checkViolation(table, constraint string) error {
+	return &pgconn.PgError{
+		Code:           "23514",
+		Message:        fmt.Sprintf(`new row for relation "%s" violates check constraint "%s"`, table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func duplicateKey(table string) error {
+	return &pgconn.PgError{
+		Code:           "23505",
+		Message:        fmt.Sprintf(`duplicate key value violates unique constraint "%s_pkey"`, table),
+		TableName:      table,
+		ConstraintName: table + "_pkey",
+	}
+}
+
+// stillReferenced is the error for deleting an account rows in table use.
+func stillReferenced(table string) error {
+	return &pgconn.PgError{
+		Code:           "23503",
+		Message:        fmt.Sprintf(`update or delete on table "accounts" violates foreign key constraint "%s_account_id_fkey" on table "%s"`, table, table),
+		TableName:      table,
+		ConstraintName: table + "_account_id_fkey",
+	}
+}
@@ -0,0 +1,72 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) DeleteAllExchangeRates(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	clear(db.rates)
+	return nil
+}
+
+func (db *DB) DeleteExchangeRate(ctx context.Context, currency string) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.rates[currency]; !ok {
+		return 0, nil
+	}
+	delete(db.rates, currency)
+	return 1, nil
+}
+
+func (db *DB) InsertBackupExchangeRate(ctx context.Context, arg database.InsertBackupExchangeRateParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.rates[arg.Currency]; ok {
+		return duplicateKey("exchange_rates")
+	}
+	r := database.ExchangeRates(arg)
+	if err := checkRate(r); err != nil {
+		return err
+	}
+	db.rates[r.Currency] = r
+	return nil
+}
+
+func (db *DB) ListExchangeRates(ctx context.Context) ([]database.ExchangeRates, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := make([]database.ExchangeRates, 0, len(db.rates))
+	for _, r := range db.rates {
+		out = append(out, r)
+	}
+	slices.SortFunc(out, func(a, b database.ExchangeRates) int { return cmp.Compare(a.Currency, b.Currency) })
+	return out, nil
+}
+
+func (db *DB) ListExchangeRatesForBackup(ctx context.Context) ([]database.ExchangeRates, error) {
+	db = db.scope(ctx)
+	return db.ListExchangeRates(ctx)
+}
+
+func (db *DB) UpsertExchangeRate(ctx context.Context, arg database.UpsertExchangeRateParams) (database.ExchangeRates, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r := database.ExchangeRates{Currency: arg.Currency, Rate: arg.Rate, UpdatedAt: now()}
+	if err := checkRate(r); err != nil {
+		return database.ExchangeRates{}, err
+	}
+	db.rates[r.Currency] = r
+	return r, nil
+}
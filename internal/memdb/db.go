@@ -0,0 +1,376 @@
+// Package memdb implements database.Querier in memory, for tests and demo
+// mode. Each method does what its query in sql/queries does against
+// Postgres, down to the ordering, soft deletes, "no rows" errors and
+// constraint violations the service relies on, so code written against a
+// Querier behaves the same on either.
+//
+//	svc := service.NewFinanceService(memdb.New())
+//
+// A DB is safe for concurrent use. There are no database transactions:
+// service methods that would run in one apply each query as it comes. What
+// a rolled back transaction is for, trying something out and throwing it
+// away, Sandbox does with a copy of the data.
+//
+// Each household's data is kept in a DB of its own, picked by the household
+// in the context, where Postgres's row security would filter one set of
+// tables. Users, sessions and the households themselves are shared.
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/household"
+)
+
+// DB holds every table in maps keyed by primary key. The zero value is not
+// usable; call New.
+type DB struct {
+	mu sync.Mutex
+
+	transactions map[int32]database.Transactions
+	archived     map[int32]database.ArchivedTransactions
+	recurring    map[int32]database.RecurringTransactions
+	reminders    map[int32]database.RecurringReminders
+	accounts     map[int32]database.Accounts
+	settings     map[string]database.Settings
+	rates        map[string]database.ExchangeRates
+	taxDetails   map[int32]database.IncomeTaxDetails
+	selfEmployed map[int32]bool
+	attachments  map[int32]database.Attachments
+	envelopes    map[int32]database.Envelopes
+	scenarios    map[int32]database.Scenarios
+	snapshots    map[time.Time]database.ForecastSnapshots
+	channels     map[int32]database.NotificationChannels
+	sent         map[sentKey]database.NotificationsSent
+	users        map[int32]database.Users
+	// refreshTokens is keyed by token_hash.
+	refreshTokens map[string]database.RefreshTokens
+	audit         []database.AuditLog
+	changes       map[syncKey]database.SyncChanges
+
+	households  map[int32]database.Households
+	members     map[memberKey]database.HouseholdMembers
+	invitations map[int32]database.HouseholdInvitations
+	// scoped holds the data of the households other than the default one,
+	// which is db's own. Each has its own SERIALs, where Postgres shares
+	// them; ids only mean anything within a household either way.
+	scoped map[int32]*DB
+
+	// Next values of the SERIAL columns.
+	nextTransaction int32
+	nextRecurring   int32
+	nextAccount     int32
+	nextAttachment  int32
+	nextEnvelope    int32
+	nextScenario    int32
+	nextChannel     int32
+	nextUser        int32
+	nextAudit       int64
+	nextHousehold   int32
+	nextInvitation  int32
+	nextSync        int64
+}
+
+var _ database.Querier = (*DB)(nil)
+
+// New returns an empty database, as if freshly migrated: with no data,
+// and the default household.
+func New() *DB {
+	db := newTables()
+	db.households = map[int32]database.Households{
+		household.Default: {ID: household.Default, Name: "Home", CreatedAt: now()},
+	}
+	db.members = make(map[memberKey]database.HouseholdMembers)
+	db.invitations = make(map[int32]database.HouseholdInvitations)
+	db.scoped = make(map[int32]*DB)
+	db.nextHousehold = household.Default + 1
+	db.nextInvitation = 1
+	return db
+}
+
+// newTables returns a DB for one household's data.
+func newTables() *DB {
+	return &DB{
+		transactions:    make(map[int32]database.Transactions),
+		archived:        make(map[int32]database.ArchivedTransactions),
+		recurring:       make(map[int32]database.RecurringTransactions),
+		reminders:       make(map[int32]database.RecurringReminders),
+		accounts:        make(map[int32]database.Accounts),
+		settings:        make(map[string]database.Settings),
+		rates:           make(map[string]database.ExchangeRates),
+		taxDetails:      make(map[int32]database.IncomeTaxDetails),
+		selfEmployed:    make(map[int32]bool),
+		attachments:     make(map[int32]database.Attachments),
+		envelopes:       make(map[int32]database.Envelopes),
+		scenarios:       make(map[int32]database.Scenarios),
+		snapshots:       make(map[time.Time]database.ForecastSnapshots),
+		channels:        make(map[int32]database.NotificationChannels),
+		sent:            make(map[sentKey]database.NotificationsSent),
+		users:           make(map[int32]database.Users),
+		refreshTokens:   make(map[string]database.RefreshTokens),
+		changes:         make(map[syncKey]database.SyncChanges),
+		nextTransaction: 1,
+		nextRecurring:   1,
+		nextAccount:     1,
+		nextAttachment:  1,
+		nextEnvelope:    1,
+		nextScenario:    1,
+		nextChannel:     1,
+		nextUser:        1,
+		nextAudit:       1,
+		nextSync:        1,
+	}
+}
+
+// scope is the DB holding the data of the household ctx works on.
+func (db *DB) scope(ctx context.Context) *DB {
+	db = db.shared(ctx)
+	id := household.FromContext(ctx)
+	if id == household.Default {
+		return db
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	s, ok := db.scoped[id]
+	if !ok {
+		s = newTables()
+		db.scoped[id] = s
+	}
+	return s
+}
+
+type sandboxKey struct{}
+
+// Sandbox returns a context for work on a copy of db as it is now, every
+// household's data included. Whatever is done with that context changes the
+// copy only, and is lost with it.
+func (db *DB) Sandbox(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sandboxKey{}, db.shared(ctx).clone())
+}
+
+// shared is the DB holding the tables every household shares: db itself,
+// or the copy a Sandbox context works on.
+func (db *DB) shared(ctx context.Context) *DB {
+	if s, ok := ctx.Value(sandboxKey{}).(*DB); ok {
+		return s
+	}
+	return db
+}
+
+// clone returns a copy of db's tables, and of each household's.
+func (db *DB) clone() *DB {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	c := &DB{
+		transactions:    maps.Clone(db.transactions),
+		archived:        maps.Clone(db.archived),
+		recurring:       maps.Clone(db.recurring),
+		reminders:       maps.Clone(db.reminders),
+		accounts:        maps.Clone(db.accounts),
+		settings:        maps.Clone(db.settings),
+		rates:           maps.Clone(db.rates),
+		taxDetails:      maps.Clone(db.taxDetails),
+		selfEmployed:    maps.Clone(db.selfEmployed),
+		attachments:     maps.Clone(db.attachments),
+		envelopes:       maps.Clone(db.envelopes),
+		scenarios:       maps.Clone(db.scenarios),
+		snapshots:       maps.Clone(db.snapshots),
+		channels:        maps.Clone(db.channels),
+		sent:            maps.Clone(db.sent),
+		users:           maps.Clone(db.users),
+		refreshTokens:   maps.Clone(db.refreshTokens),
+		audit:           slices.Clone(db.audit),
+		changes:         maps.Clone(db.changes),
+		households:      maps.Clone(db.households),
+		members:         maps.Clone(db.members),
+		invitations:     maps.Clone(db.invitations),
+		nextTransaction: db.nextTransaction,
+		nextRecurring:   db.nextRecurring,
+		nextAccount:     db.nextAccount,
+		nextAttachment:  db.nextAttachment,
+		nextEnvelope:    db.nextEnvelope,
+		nextScenario:    db.nextScenario,
+		nextChannel:     db.nextChannel,
+		nextUser:        db.nextUser,
+		nextAudit:       db.nextAudit,
+		nextHousehold:   db.nextHousehold,
+		nextInvitation:  db.nextInvitation,
+		nextSync:        db.nextSync,
+	}
+	if db.scoped != nil {
+		c.scoped = make(map[int32]*DB, len(db.scoped))
+		for id, s := range db.scoped {
+			c.scoped[id] = s.clone()
+		}
+	}
+	return c
+}
+
+// Ping always succeeds; there is nothing to reach. It also tells the
+// service's health checks that no migrations are needed.
+func (db *DB) Ping(ctx context.Context) error {
+	return nil
+}
+
+// now is CURRENT_TIMESTAMP as pgx reads back a TIMESTAMP column.
+func now() pgtype.Timestamp {
+	return pgtype.Timestamp{Time: time.Now().UTC().Truncate(time.Microsecond), Valid: true}
+}
+
+// day drops the time of day, as storing into a DATE column does.
+func day(d pgtype.Date) pgtype.Date {
+	if !d.Valid {
+		return d
+	}
+	y, m, dd := d.Time.Date()
+	return pgtype.Date{Time: time.Date(y, m, dd, 0, 0, 0, 0, time.UTC), Valid: true}
+}
+
+// between is date BETWEEN start AND end, where a NULL bound (as the
+// sqlc.narg filters pass) leaves that side open.
+func between(d, start, end pgtype.Date) bool {
+	if start.Valid && d.Time.Before(day(start).Time) {
+		return false
+	}
+	return !end.Valid || !d.Time.After(day(end).Time)
+}
+
+func zero() pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(0), Valid: true}
+}
+
+// add sums two NUMERICs exactly, at the finer of their scales. NULLs are
+// skipped, as SUM skips them.
+func add(a, b pgtype.Numeric) pgtype.Numeric {
+	if !b.Valid || b.Int == nil {
+		return a
+	}
+	if !a.Valid || a.Int == nil {
+		return b
+	}
+	x, y := new(big.Int).Set(a.Int), new(big.Int).Set(b.Int)
+	exp := min(a.Exp, b.Exp)
+	x.Mul(x, pow10(a.Exp-exp))
+	y.Mul(y, pow10(b.Exp-exp))
+	return pgtype.Numeric{Int: x.Add(x, y), Exp: exp, Valid: true}
+}
+
+// neg is -n, NULL staying NULL.
+func neg(n pgtype.Numeric) pgtype.Numeric {
+	if !n.Valid || n.Int == nil {
+		return n
+	}
+	return pgtype.Numeric{Int: new(big.Int).Neg(n.Int), Exp: n.Exp, Valid: true}
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func one() pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(1), Valid: true}
+}
+
+func rat(n pgtype.Numeric) *big.Rat {
+	if n.Int == nil {
+		return new(big.Rat)
+	}
+	r := new(big.Rat).SetInt(n.Int)
+	if n.Exp >= 0 {
+		return r.Mul(r, new(big.Rat).SetInt(pow10(n.Exp)))
+	}
+	return r.Quo(r, new(big.Rat).SetInt(pow10(-n.Exp)))
+}
+
+func sign(n pgtype.Numeric) int {
+	return rat(n).Sign()
+}
+
+func cmpNumeric(a, b pgtype.Numeric) int {
+	return rat(a).Cmp(rat(b))
+}
+
+func sortedByID[K cmp.Ordered, V any](m map[K]V, id func(V) K) []V {
+	out := make([]V, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	slices.SortFunc(out, func(a, b V) int { return cmp.Compare(id(a), id(b)) })
+	return out
+}
+
+// sortKeys are the columns the sorted list queries order rows on.
+type sortKeys struct {
+	date        pgtype.Date
+	amount      pgtype.Numeric
+	description string
+	id          int32
+}
+
+// orderBy compares rows the way the sorted list queries do: on key (date,
+// amount or description, or none), then on id, all reversed when
+// descending. Descriptions compare as strings do, which COLLATE "C" makes
+// Postgres do too.
+func orderBy[V any](key string, descending bool, keys func(V) sortKeys) func(a, b V) int {
+	return func(a, b V) int {
+		ka, kb := keys(a), keys(b)
+		var c int
+		switch key {
+		case "date":
+			c = ka.date.Time.Compare(kb.date.Time)
+		case "amount":
+			c = cmpNumeric(ka.amount, kb.amount)
+		case "description":
+			c = strings.Compare(ka.description, kb.description)
+		}
+		if c == 0 {
+			c = cmp.Compare(ka.id, kb.id)
+		}
+		if descending {
+			return -c
+		}
+		return c
+	}
+}
+
+// keyset is the page the sorted list queries ask for: the rows after the
+// one whose sort keys are after, if valid, at most size of them.
+type keyset struct {
+	after sortKeys
+	valid bool
+	size  pgtype.Int4
+}
+
+// page cuts rows, already in orderBy's order on key, down to ks.
+func page[V any](rows []V, key string, descending bool, ks keyset, keys func(V) sortKeys) []V {
+	if ks.valid {
+		compare := orderBy(key, descending, func(k sortKeys) sortKeys { return k })
+		i := 0
+		for i < len(rows) && compare(keys(rows[i]), ks.after) <= 0 {
+			i++
+		}
+		rows = rows[i:]
+	}
+	if ks.size.Valid && len(rows) > int(ks.size.Int32) {
+		rows = rows[:max(0, ks.size.Int32)]
+	}
+	return rows
+}
+
+func maxKey[V any](m map[int32]V) int32 {
+	var n int32
+	for id := range m {
+		n = max(n, id)
+	}
+	return n
+}
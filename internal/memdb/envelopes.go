@@ -0,0 +1,116 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) CreateEnvelope(ctx context.Context, arg database.CreateEnvelopeParams) (database.Envelopes, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	e := database.Envelopes{
+		ID:            db.nextEnvelope,
+		Name:          arg.Name,
+		MonthlyAmount: arg.MonthlyAmount,
+		Target:        arg.Target,
+		StartDate:     day(arg.StartDate),
+		Category:      arg.Category,
+		CreatedAt:     now(),
+	}
+	if err := checkEnvelope(db, e); err != nil {
+		return database.Envelopes{}, err
+	}
+	db.nextEnvelope++
+	db.envelopes[e.ID] = e
+	return e, nil
+}
+
+func (db *DB) DeleteAllEnvelopes(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	clear(db.envelopes)
+	return nil
+}
+
+func (db *DB) DeleteEnvelope(ctx context.Context, id int32) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.envelopes[id]; !ok {
+		return 0, nil
+	}
+	delete(db.envelopes, id)
+	return 1, nil
+}
+
+func (db *DB) GetEnvelope(ctx context.Context, id int32) (database.Envelopes, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	e, ok := db.envelopes[id]
+	if !ok {
+		return database.Envelopes{}, pgx.ErrNoRows
+	}
+	return e, nil
+}
+
+func (db *DB) InsertBackupEnvelope(ctx context.Context, arg database.InsertBackupEnvelopeParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.envelopes[arg.ID]; ok {
+		return duplicateKey("envelopes")
+	}
+	e := database.Envelopes(arg)
+	e.StartDate = day(e.StartDate)
+	if err := checkEnvelope(db, e); err != nil {
+		return err
+	}
+	db.envelopes[e.ID] = e
+	return nil
+}
+
+func (db *DB) ListEnvelopes(ctx context.Context) ([]database.Envelopes, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return sortedByID(db.envelopes, func(e database.Envelopes) int32 { return e.ID }), nil
+}
+
+func (db *DB) ListEnvelopesForBackup(ctx context.Context) ([]database.Envelopes, error) {
+	db = db.scope(ctx)
+	return db.ListEnvelopes(ctx)
+}
+
+func (db *DB) ResetEnvelopesSequence(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.nextEnvelope = maxKey(db.envelopes) + 1
+	return nil
+}
+
+func (db *DB) UpdateEnvelope(ctx context.Context, arg database.UpdateEnvelopeParams) (database.Envelopes, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	e, ok := db.envelopes[arg.ID]
+	if !ok {
+		return database.Envelopes{}, pgx.ErrNoRows
+	}
+	e.Name = arg.Name
+	e.MonthlyAmount = arg.MonthlyAmount
+	e.Target = arg.Target
+	e.StartDate = day(arg.StartDate)
+	e.Category = arg.Category
+	if err := checkEnvelope(db, e); err != nil {
+		return database.Envelopes{}, err
+	}
+	db.envelopes[e.ID] = e
+	return e, nil
+}
@@ -0,0 +1,51 @@
+package memdb
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) GetForecastSnapshot(ctx context.Context, snapshotDate pgtype.Date) (database.ForecastSnapshots, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	s, ok := db.snapshots[snapshotDate.Time]
+	if !ok {
+		return database.ForecastSnapshots{}, pgx.ErrNoRows
+	}
+	return s, nil
+}
+
+func (db *DB) ListForecastSnapshotDates(ctx context.Context) ([]pgtype.Date, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := make([]pgtype.Date, 0, len(db.snapshots))
+	for _, s := range db.snapshots {
+		out = append(out, s.SnapshotDate)
+	}
+	slices.SortFunc(out, func(a, b pgtype.Date) int { return a.Time.Compare(b.Time) })
+	return out, nil
+}
+
+func (db *DB) UpsertForecastSnapshot(ctx context.Context, arg database.UpsertForecastSnapshotParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	s := database.ForecastSnapshots{
+		SnapshotDate:    arg.SnapshotDate,
+		StartingBalance: arg.StartingBalance,
+		Forecast:        slices.Clone(arg.Forecast),
+		CreatedAt:       now(),
+	}
+	if err := checkSnapshot(s); err != nil {
+		return err
+	}
+	db.snapshots[s.SnapshotDate.Time] = s
+	return nil
+}
@@ -0,0 +1,274 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// memberKey is the primary key of household_members.
+type memberKey struct {
+	household int32
+	user      int32
+}
+
+func (db *DB) AcceptHouseholdInvitation(ctx context.Context, arg database.AcceptHouseholdInvitationParams) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	inv, ok := db.invitations[arg.ID]
+	if !ok || inv.AcceptedAt.Valid {
+		return 0, nil
+	}
+	inv.AcceptedBy = arg.AcceptedBy
+	inv.AcceptedAt = now()
+	db.invitations[inv.ID] = inv
+	return 1, nil
+}
+
+func (db *DB) AddHouseholdMember(ctx context.Context, arg database.AddHouseholdMemberParams) (database.HouseholdMembers, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if !validRole(arg.Role) {
+		return database.HouseholdMembers{}, checkViolation("household_members", "household_members_role_check")
+	}
+	if _, ok := db.households[arg.HouseholdID]; !ok {
+		return database.HouseholdMembers{}, missingHousehold("household_members")
+	}
+	if _, ok := db.users[arg.UserID]; !ok {
+		return database.HouseholdMembers{}, &pgconn.PgError{
+			Code:           "23503",
+			Message:        `insert or update on table "household_members" violates foreign key constraint "household_members_user_id_fkey"`,
+			TableName:      "household_members",
+			ConstraintName: "household_members_user_id_fkey",
+		}
+	}
+	key := memberKey{arg.HouseholdID, arg.UserID}
+	if _, ok := db.members[key]; ok {
+		return database.HouseholdMembers{}, duplicateKey("household_members")
+	}
+	m := database.HouseholdMembers{
+		HouseholdID: arg.HouseholdID,
+		UserID:      arg.UserID,
+		Role:        arg.Role,
+		CreatedAt:   now(),
+	}
+	db.members[key] = m
+	return m, nil
+}
+
+func (db *DB) CountHouseholdOwners(ctx context.Context, householdID int32) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var n int64
+	for _, m := range db.members {
+		if m.HouseholdID == householdID && m.Role == "owner" {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (db *DB) CreateHousehold(ctx context.Context, name string) (database.Households, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	h := database.Households{ID: db.nextHousehold, Name: name, CreatedAt: now()}
+	db.nextHousehold++
+	db.households[h.ID] = h
+	return h, nil
+}
+
+func (db *DB) CreateHouseholdInvitation(ctx context.Context, arg database.CreateHouseholdInvitationParams) (database.HouseholdInvitations, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if !validRole(arg.Role) {
+		return database.HouseholdInvitations{}, checkViolation("household_invitations", "household_invitations_role_check")
+	}
+	if _, ok := db.households[arg.HouseholdID]; !ok {
+		return database.HouseholdInvitations{}, missingHousehold("household_invitations")
+	}
+	for _, other := range db.invitations {
+		if other.TokenHash == arg.TokenHash {
+			return database.HouseholdInvitations{}, &pgconn.PgError{
+				Code:           "23505",
+				Message:        `duplicate key value violates unique constraint "household_invitations_token_hash_key"`,
+				TableName:      "household_invitations",
+				ConstraintName: "household_invitations_token_hash_key",
+			}
+		}
+	}
+	inv := database.HouseholdInvitations{
+		ID:          db.nextInvitation,
+		HouseholdID: arg.HouseholdID,
+		Role:        arg.Role,
+		TokenHash:   arg.TokenHash,
+		InvitedBy:   arg.InvitedBy,
+		ExpiresAt:   arg.ExpiresAt,
+		CreatedAt:   now(),
+	}
+	db.nextInvitation++
+	db.invitations[inv.ID] = inv
+	return inv, nil
+}
+
+func (db *DB) DeleteHousehold(ctx context.Context, id int32) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.households[id]; !ok {
+		return 0, nil
+	}
+	delete(db.households, id)
+	for key := range db.members {
+		if key.household == id {
+			delete(db.members, key)
+		}
+	}
+	for invID, inv := range db.invitations {
+		if inv.HouseholdID == id {
+			delete(db.invitations, invID)
+		}
+	}
+	// Its data goes with it. The service never deletes the default
+	// household, whose data is db's own.
+	delete(db.scoped, id)
+	return 1, nil
+}
+
+func (db *DB) DeleteHouseholdInvitation(ctx context.Context, arg database.DeleteHouseholdInvitationParams) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	inv, ok := db.invitations[arg.ID]
+	if !ok || inv.HouseholdID != arg.HouseholdID {
+		return 0, nil
+	}
+	delete(db.invitations, arg.ID)
+	return 1, nil
+}
+
+func (db *DB) GetHousehold(ctx context.Context, id int32) (database.Households, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	h, ok := db.households[id]
+	if !ok {
+		return database.Households{}, pgx.ErrNoRows
+	}
+	return h, nil
+}
+
+func (db *DB) GetHouseholdInvitationByToken(ctx context.Context, tokenHash string) (database.HouseholdInvitations, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, inv := range db.invitations {
+		if inv.TokenHash == tokenHash {
+			return inv, nil
+		}
+	}
+	return database.HouseholdInvitations{}, pgx.ErrNoRows
+}
+
+func (db *DB) GetHouseholdMember(ctx context.Context, arg database.GetHouseholdMemberParams) (database.HouseholdMembers, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	m, ok := db.members[memberKey{arg.HouseholdID, arg.UserID}]
+	if !ok {
+		return database.HouseholdMembers{}, pgx.ErrNoRows
+	}
+	return m, nil
+}
+
+func (db *DB) ListHouseholdInvitations(ctx context.Context, householdID int32) ([]database.HouseholdInvitations, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := []database.HouseholdInvitations{}
+	for _, inv := range sortedByID(db.invitations, func(inv database.HouseholdInvitations) int32 { return inv.ID }) {
+		if inv.HouseholdID == householdID {
+			out = append(out, inv)
+		}
+	}
+	return out, nil
+}
+
+func (db *DB) ListHouseholdMembers(ctx context.Context, householdID int32) ([]database.ListHouseholdMembersRow, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := []database.ListHouseholdMembersRow{}
+	for _, u := range sortedByID(db.users, func(u database.Users) int32 { return u.ID }) {
+		if m, ok := db.members[memberKey{householdID, u.ID}]; ok {
+			out = append(out, database.ListHouseholdMembersRow{
+				UserID:    u.ID,
+				Username:  u.Username,
+				Role:      m.Role,
+				CreatedAt: m.CreatedAt,
+			})
+		}
+	}
+	return out, nil
+}
+
+func (db *DB) ListHouseholds(ctx context.Context) ([]database.Households, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return sortedByID(db.households, func(h database.Households) int32 { return h.ID }), nil
+}
+
+func (db *DB) ListUserHouseholds(ctx context.Context, userID int32) ([]database.ListUserHouseholdsRow, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := []database.ListUserHouseholdsRow{}
+	for _, h := range sortedByID(db.households, func(h database.Households) int32 { return h.ID }) {
+		if m, ok := db.members[memberKey{h.ID, userID}]; ok {
+			out = append(out, database.ListUserHouseholdsRow{
+				ID:        h.ID,
+				Name:      h.Name,
+				CreatedAt: h.CreatedAt,
+				Role:      m.Role,
+			})
+		}
+	}
+	return out, nil
+}
+
+func (db *DB) RemoveHouseholdMember(ctx context.Context, arg database.RemoveHouseholdMemberParams) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	key := memberKey{arg.HouseholdID, arg.UserID}
+	if _, ok := db.members[key]; !ok {
+		return 0, nil
+	}
+	delete(db.members, key)
+	return 1, nil
+}
+
+func (db *DB) SetHouseholdMemberRole(ctx context.Context, arg database.SetHouseholdMemberRoleParams) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	key := memberKey{arg.HouseholdID, arg.UserID}
+	m, ok := db.members[key]
+	if !ok {
+		return 0, nil
+	}
+	if !validRole(arg.Role) {
+		return 0, checkViolation("household_members", "household_members_role_check")
+	}
+	m.Role = arg.Role
+	db.members[key] = m
+	return 1, nil
+}
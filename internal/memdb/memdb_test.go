@@ -0,0 +1,1125 @@
+package memdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/notify"
+	"github.com/jdelles/currentz/internal/service"
+	"github.com/jdelles/currentz/internal/storage/blob"
+)
+
+func date(s string) pgtype.Date {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return pgtype.Date{Time: t, Valid: true}
+}
+
+func num(s string) pgtype.Numeric {
+	var n pgtype.Numeric
+	if err := n.Scan(s); err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func pgCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+func addTx(t *testing.T, db *DB, day, amount, typ string) database.Transactions {
+	t.Helper()
+	tx, err := db.CreateTransaction(context.Background(), database.CreateTransactionParams{
+		Date: date(day), Amount: num(amount), Description: typ + " " + day, Type: typ, Currency: "USD", Status: "cleared",
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestTransactions(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	late := addTx(t, db, "2025-03-10", "-40.00", "expense")
+	early := addTx(t, db, "2025-03-01", "1000.00", "income")
+	addTx(t, db, "2025-04-01", "-5.50", "expense")
+	assert.Equal(t, int32(1), late.ID)
+	assert.Equal(t, int32(2), early.ID)
+
+	all, err := db.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, early.ID, all[0].ID, "ordered by date")
+
+	march, err := db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
+		Date: date("2025-03-01"), Date_2: date("2025-03-31"),
+	})
+	require.NoError(t, err)
+	assert.Len(t, march, 2)
+
+	n, err := db.DeleteTransaction(ctx, late.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = db.DeleteTransaction(ctx, late.ID)
+	require.NoError(t, err)
+	assert.Zero(t, n, "already in the trash")
+	_, err = db.GetTransactionByID(ctx, late.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	deleted, err := db.ListDeletedTransactions(ctx)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.True(t, deleted[0].DeletedAt.Valid)
+
+	restored, err := db.RestoreTransaction(ctx, late.ID)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+	_, err = db.RestoreTransaction(ctx, late.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestTransactionsSorted(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	rent := addTx(t, db, "2025-03-01", "-1200.00", "expense")
+	pay := addTx(t, db, "2025-03-01", "2500.00", "income")
+	coffee := addTx(t, db, "2025-02-20", "-4.50", "expense")
+	pending := addTx(t, db, "2025-02-25", "-4.50", "expense")
+	_, err := db.SetTransactionStatus(ctx, database.SetTransactionStatusParams{ID: pending.ID, Status: "pending", Version: pending.Version})
+	require.NoError(t, err)
+
+	ids := func(arg database.ListTransactionsSortedParams) []int32 {
+		txs, err := db.ListTransactionsSorted(ctx, arg)
+		require.NoError(t, err)
+		var out []int32
+		for _, tx := range txs {
+			out = append(out, tx.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []int32{coffee.ID, pending.ID, rent.ID, pay.ID}, ids(database.ListTransactionsSortedParams{SortKey: "date"}))
+	assert.Equal(t, []int32{pay.ID, rent.ID, pending.ID, coffee.ID}, ids(database.ListTransactionsSortedParams{SortKey: "date", Descending: true}),
+		"ties reversed too")
+	assert.Equal(t, []int32{rent.ID, coffee.ID, pending.ID, pay.ID}, ids(database.ListTransactionsSortedParams{SortKey: "amount"}))
+	assert.Equal(t, []int32{coffee.ID, pending.ID, rent.ID, pay.ID}, ids(database.ListTransactionsSortedParams{SortKey: "description"}))
+	assert.Equal(t, []int32{pending.ID}, ids(database.ListTransactionsSortedParams{
+		Status: pgtype.Text{String: "pending", Valid: true}, SortKey: "amount",
+	}))
+
+	// A page starts just after the row its keys name, ties broken on ID.
+	assert.Equal(t, []int32{pending.ID, pay.ID}, ids(database.ListTransactionsSortedParams{
+		SortKey:     "amount",
+		AfterAmount: coffee.Amount,
+		AfterID:     pgtype.Int4{Int32: coffee.ID, Valid: true},
+	}))
+	assert.Equal(t, []int32{rent.ID}, ids(database.ListTransactionsSortedParams{
+		SortKey:    "date",
+		Descending: true,
+		AfterDate:  pay.Date,
+		AfterID:    pgtype.Int4{Int32: pay.ID, Valid: true},
+		PageSize:   pgtype.Int4{Int32: 1, Valid: true},
+	}))
+}
+
+func TestTransactionSums(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	addTx(t, db, "2025-03-01", "1000.00", "income")
+	addTx(t, db, "2025-03-01", "-40.25", "expense")
+	addTx(t, db, "2025-03-02", "-9.75", "expense")
+	gone := addTx(t, db, "2025-03-02", "-100.00", "expense")
+	_, err := db.DeleteTransaction(ctx, gone.ID)
+	require.NoError(t, err)
+
+	agg, err := db.GetTransactionAggregatesByCurrency(ctx, database.GetTransactionAggregatesByCurrencyParams{})
+	require.NoError(t, err)
+	require.Len(t, agg, 1)
+	assert.Equal(t, int64(3), agg[0].Count)
+	assert.Equal(t, 0, cmpNumeric(agg[0].TotalIncome, num("1000")))
+	assert.Equal(t, 0, cmpNumeric(agg[0].TotalExpense, num("-50")))
+
+	agg, err = db.GetTransactionAggregatesByCurrency(ctx, database.GetTransactionAggregatesByCurrencyParams{StartDate: date("2025-03-02")})
+	require.NoError(t, err)
+	require.Len(t, agg, 1)
+	assert.Equal(t, int64(1), agg[0].Count)
+
+	sums, err := db.GetDailyTransactionSums(ctx, database.GetDailyTransactionSumsParams{
+		StartDate: date("2025-03-01"), EndDate: date("2025-03-31"),
+	})
+	require.NoError(t, err)
+	require.Len(t, sums, 2)
+	assert.Equal(t, date("2025-03-01"), sums[0].Date)
+	assert.Equal(t, 0, cmpNumeric(sums[0].Total, num("959.75")))
+	assert.Equal(t, 0, cmpNumeric(sums[1].Total, num("-9.75")))
+}
+
+func TestTransactionSumsByCurrency(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	addTx(t, db, "2025-03-01", "1000.00", "income")
+	addTx(t, db, "2025-03-02", "-40.00", "expense")
+	rent := addTx(t, db, "2025-03-03", "-900.00", "expense")
+	train, err := db.CreateTransaction(ctx, database.CreateTransactionParams{
+		Date: date("2025-03-04"), Amount: num("-60.00"), Description: "Train", Type: "expense", Currency: "EUR", Status: "cleared",
+	})
+	require.NoError(t, err)
+	addTx(t, db, "2025-04-01", "-5000.00", "expense")
+	start, end := date("2025-03-01"), date("2025-03-31")
+
+	rows, err := db.GetTransactionAggregatesByCurrency(ctx, database.GetTransactionAggregatesByCurrencyParams{StartDate: start, EndDate: end})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "EUR", rows[0].Currency)
+	assert.Equal(t, int64(1), rows[0].Count)
+	assert.Equal(t, 0, cmpNumeric(rows[0].TotalIncome, num("0")))
+	assert.Equal(t, "USD", rows[1].Currency)
+	assert.Equal(t, int64(3), rows[1].Count)
+	assert.Equal(t, 0, cmpNumeric(rows[1].TotalExpense, num("-940")))
+
+	largest, err := db.GetLargestExpenses(ctx, database.GetLargestExpensesParams{StartDate: start, EndDate: end})
+	require.NoError(t, err)
+	require.Len(t, largest, 2, "one per currency")
+	assert.Equal(t, train.ID, largest[0].ID)
+	assert.Equal(t, rent.ID, largest[1].ID)
+}
+
+func TestTransactionStatus(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	addTx(t, db, "2025-03-01", "1000.00", "income")
+	check := addTx(t, db, "2025-03-02", "-250.00", "expense")
+	addTx(t, db, "2025-03-05", "-20.00", "expense")
+
+	pending, err := db.SetTransactionStatus(ctx, database.SetTransactionStatusParams{ID: check.ID, Status: "pending", Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", pending.Status)
+	assert.Equal(t, int32(2), pending.Version)
+	_, err = db.SetTransactionStatus(ctx, database.SetTransactionStatusParams{ID: check.ID, Status: "cleared", Version: 1})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "stale version")
+	_, err = db.SetTransactionStatus(ctx, database.SetTransactionStatusParams{ID: 99, Status: "pending", Version: 1})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	list, err := db.GetTransactionsByStatus(ctx, "pending")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, check.ID, list[0].ID)
+
+	before, err := db.GetPendingTransactionsBefore(ctx, date("2025-03-02"))
+	require.NoError(t, err)
+	assert.Empty(t, before, "strictly before")
+	before, err = db.GetPendingTransactionsBefore(ctx, date("2025-03-03"))
+	require.NoError(t, err)
+	assert.Len(t, before, 1)
+
+	sums, err := db.GetDailyTransactionSums(ctx, database.GetDailyTransactionSumsParams{
+		StartDate: date("2025-03-01"), EndDate: date("2025-03-31"),
+	})
+	require.NoError(t, err)
+	assert.Len(t, sums, 2, "pending left out")
+	sums, err = db.GetDailyTransactionSums(ctx, database.GetDailyTransactionSumsParams{
+		StartDate: date("2025-03-01"), EndDate: date("2025-03-31"), IncludePending: true,
+	})
+	require.NoError(t, err)
+	assert.Len(t, sums, 3)
+
+	agg, err := db.GetTransactionAggregatesByCurrency(ctx, database.GetTransactionAggregatesByCurrencyParams{Status: pgtype.Text{String: "cleared", Valid: true}})
+	require.NoError(t, err)
+	require.Len(t, agg, 1)
+	assert.Equal(t, int64(2), agg[0].Count)
+}
+
+func TestPendingForecast(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	today := svc.Today(ctx)
+
+	// A check written last week that the bank hasn't paid yet, one next
+	// week, and a cleared purchase the starting balance already includes.
+	_, _, err := svc.RecordExpense(ctx, service.ExpenseInput{Date: today.AddDate(0, 0, -7), Amount: 300, Description: "Check #101", Status: "pending"})
+	require.NoError(t, err)
+	_, _, err = svc.RecordExpense(ctx, service.ExpenseInput{Date: today.AddDate(0, 0, 7), Amount: 50, Description: "Check #102", Status: "pending"})
+	require.NoError(t, err)
+	_, _, err = svc.RecordExpense(ctx, service.ExpenseInput{Date: today.AddDate(0, 0, -3), Amount: 80, Description: "Groceries"})
+	require.NoError(t, err)
+
+	forecast, err := svc.Forecast(ctx, 1000, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 700.0, forecast[0].Balance, "last week's check lands today")
+	assert.Equal(t, 650.0, forecast[9].Balance)
+
+	forecast, err = svc.ForecastWithOptions(ctx, 1000, 10, service.ForecastOptions{ExcludePending: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, forecast[9].Balance)
+
+	pending, err := svc.GetTransactionsByStatus(ctx, service.StatusPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	cleared, err := svc.SetTransactionStatus(ctx, pending[0].ID, service.StatusCleared)
+	require.NoError(t, err)
+	assert.Equal(t, service.StatusCleared, cleared.Status)
+
+	forecast, err = svc.Forecast(ctx, 700, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 700.0, forecast[0].Balance, "cleared, so in the starting balance now")
+	assert.Equal(t, 650.0, forecast[9].Balance)
+
+	_, err = svc.SetTransactionStatus(ctx, pending[0].ID, "bounced")
+	assert.ErrorIs(t, err, service.ErrInvalidStatus)
+	_, err = svc.SetTransactionStatus(ctx, 999, service.StatusPending)
+	assert.ErrorIs(t, err, service.ErrTransactionNotFound)
+}
+
+func TestAttachmentPurge(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	svc := service.NewFinanceService(db)
+	store := blob.NewMemory()
+	svc.SetBlobStore(store)
+
+	tx, _, err := svc.RecordExpense(ctx, service.ExpenseInput{Date: svc.Today(ctx), Amount: 20, Description: "Lunch"})
+	require.NoError(t, err)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	att, err := svc.AddAttachment(ctx, tx.ID, "lunch.png", bytes.NewReader(png))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", att.ContentType)
+
+	require.NoError(t, svc.DeleteTransaction(ctx, tx.ID))
+	_, err = svc.ListAttachments(ctx, tx.ID)
+	assert.ErrorIs(t, err, service.ErrTransactionNotFound, "hidden while in the trash")
+	assert.Equal(t, 1, store.Len(), "kept so a restore brings it back")
+
+	n, err := svc.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, 0, store.Len())
+	left, err := db.ListPurgeableAttachments(ctx, pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true})
+	require.NoError(t, err)
+	assert.Empty(t, left)
+}
+
+func TestEnvelopes(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	today := svc.Today(ctx)
+
+	env, err := svc.CreateEnvelope(ctx, service.EnvelopeInput{Name: "Car repairs", MonthlyAmount: 100, StartDate: today.AddDate(0, 0, -10), Category: "Car"})
+	require.NoError(t, err)
+	_, err = svc.CreateEnvelope(ctx, service.EnvelopeInput{Name: "Car repairs", MonthlyAmount: 50})
+	assert.ErrorIs(t, err, service.ErrInvalidEnvelope)
+	_, err = svc.CreateEnvelope(ctx, service.EnvelopeInput{Name: "Gifts", MonthlyAmount: -5})
+	assert.ErrorIs(t, err, service.ErrInvalidEnvelope)
+
+	_, _, err = svc.RecordExpense(ctx, service.ExpenseInput{Date: today.AddDate(0, 0, 3), Amount: 30, Description: "Wiper blades", Category: "car"})
+	require.NoError(t, err)
+
+	forecast, err := svc.ForecastWithOptions(ctx, 1000, 10, service.ForecastOptions{Envelopes: true})
+	require.NoError(t, err)
+	require.NotNil(t, forecast[0].Available)
+	assert.Equal(t, 900.0, *forecast[0].Available)
+	assert.Equal(t, 970.0, forecast[9].Balance)
+	assert.Equal(t, 900.0, *forecast[9].Available, "paid from the envelope")
+
+	plain, err := svc.Forecast(ctx, 1000, 10)
+	require.NoError(t, err)
+	assert.Nil(t, plain[0].Available)
+
+	list, err := svc.ListEnvelopes(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, 100.0, list[0].Balance)
+
+	target := 60.0
+	_, err = svc.UpdateEnvelope(ctx, env.ID, service.EnvelopeInput{Name: "Car repairs", MonthlyAmount: 100, Target: &target, StartDate: today.AddDate(0, 0, -10), Category: "Car"})
+	require.NoError(t, err)
+	got, err := svc.GetEnvelope(ctx, env.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 60.0, got.Balance)
+
+	b, err := svc.Backup(ctx)
+	require.NoError(t, err)
+	require.Len(t, b.Envelopes, 1)
+
+	require.NoError(t, svc.DeleteEnvelope(ctx, env.ID))
+	assert.ErrorIs(t, svc.DeleteEnvelope(ctx, env.ID), service.ErrEnvelopeNotFound)
+	forecast, err = svc.ForecastWithOptions(ctx, 1000, 10, service.ForecastOptions{Envelopes: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, *forecast[0].Available)
+
+	summary, err := svc.Restore(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Envelopes)
+	_, err = svc.GetEnvelope(ctx, env.ID)
+	assert.NoError(t, err)
+}
+
+func TestSelfEmploymentTax(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	today := svc.Today(ctx)
+
+	require.NoError(t, svc.AddIncome(ctx, today, 1000, "Invoice 12", ""))
+	require.NoError(t, svc.AddIncome(ctx, today, 500, "Paycheck", ""))
+	require.NoError(t, svc.AddExpense(ctx, today, 20, "Coffee", ""))
+	txs, err := svc.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	ids := make(map[string]int32)
+	for _, tx := range txs {
+		ids[tx.Description] = tx.ID
+	}
+
+	require.NoError(t, svc.TagSelfEmployment(ctx, ids["Invoice 12"]))
+	require.NoError(t, svc.TagSelfEmployment(ctx, ids["Invoice 12"]), "tagging twice")
+	assert.ErrorIs(t, svc.TagSelfEmployment(ctx, ids["Coffee"]), service.ErrNotIncome)
+	assert.ErrorIs(t, svc.TagSelfEmployment(ctx, 999), service.ErrTransactionNotFound)
+
+	_, err = svc.EstimateSelfEmploymentTax(ctx, today.Year())
+	assert.ErrorIs(t, err, service.ErrSelfEmploymentRateNotSet)
+	plain, err := svc.Forecast(ctx, 0, 200)
+	require.NoError(t, err)
+	assert.Nil(t, plain[0].TaxReserve)
+
+	require.NoError(t, svc.SetSelfEmploymentTaxRate(ctx, 0.25))
+	est, err := svc.EstimateSelfEmploymentTax(ctx, today.Year())
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, est.IncomeToDate)
+	assert.Equal(t, 250.0, est.TaxToDate)
+	require.Len(t, est.Quarters, 4)
+
+	forecast, err := svc.Forecast(ctx, 0, 200)
+	require.NoError(t, err)
+	require.NotNil(t, forecast[0].TaxReserve)
+	assert.Equal(t, 250.0, *forecast[0].TaxReserve)
+	assert.Equal(t, 0.0, *forecast[199].TaxReserve, "paid by the due date")
+
+	recs, err := svc.GenerateEstimatedPayments(ctx, today.Year())
+	require.NoError(t, err)
+	require.Len(t, recs, 4)
+	assert.Equal(t, "Estimated tax Q1", recs[0].Description)
+	again, err := svc.GenerateEstimatedPayments(ctx, today.Year())
+	require.NoError(t, err)
+	assert.Equal(t, recs[3].ID, again[3].ID, "updated, not added")
+	all, err := svc.ListRecurring(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+	_, err = svc.GenerateEstimatedPayments(ctx, today.Year()+1)
+	assert.ErrorIs(t, err, service.ErrNoSelfEmploymentIncome)
+
+	b, err := svc.Backup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{ids["Invoice 12"]}, b.SelfEmployment)
+	require.NoError(t, svc.UntagSelfEmployment(ctx, ids["Invoice 12"]))
+	est, err = svc.EstimateSelfEmploymentTax(ctx, today.Year())
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, est.IncomeToDate)
+
+	summary, err := svc.Restore(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.SelfEmployment)
+	est, err = svc.EstimateSelfEmploymentTax(ctx, today.Year())
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, est.IncomeToDate)
+}
+
+func TestCompareForecasts(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	today := svc.Today(ctx)
+	require.NoError(t, svc.AddExpense(ctx, today.AddDate(0, 0, 2), 100, "Rent", ""))
+
+	raise := service.Scenario{Recurring: []service.RecurringInput{
+		{Description: "Raise", Type: "income", Amount: 50, StartDate: today.AddDate(0, 0, 1), Interval: "weekly"},
+	}}
+	cmp, err := svc.CompareForecasts(ctx, 1000, 10, service.Scenario{}, raise)
+	require.NoError(t, err)
+	require.Len(t, cmp.Days, 10)
+	assert.Equal(t, 0.0, cmp.Days[0].Delta)
+	assert.Equal(t, 50.0, cmp.Days[1].Delta)
+	assert.Equal(t, 100.0, cmp.EndingBalance.Delta, "two weekly raises")
+	assert.Equal(t, 900.0, cmp.LowestPoint.Baseline.Balance)
+
+	plain, err := svc.Forecast(ctx, 1000, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 900.0, plain[9].Balance, "the scenario isn't saved")
+
+	_, err = svc.CompareForecasts(ctx, 1000, 10, service.Scenario{}, service.Scenario{
+		Transactions: []service.ScenarioTransaction{{Date: today, Type: "income"}},
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidScenario)
+}
+
+func TestSavedScenarios(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	today := svc.Today(ctx)
+
+	raise := service.Scenario{Recurring: []service.RecurringInput{
+		{Description: "Raise", Type: "income", Amount: 50, StartDate: today.AddDate(0, 0, 1), Interval: "weekly"},
+	}}
+	saved, err := svc.CreateScenario(ctx, " Raise ", raise)
+	require.NoError(t, err)
+	assert.Equal(t, "Raise", saved.Name)
+
+	_, err = svc.CreateScenario(ctx, "Raise", service.Scenario{})
+	assert.ErrorIs(t, err, service.ErrInvalidScenario, "names are unique")
+	_, err = svc.CreateScenario(ctx, "Gift", service.Scenario{
+		Transactions: []service.ScenarioTransaction{{Date: today, Type: "gift", Amount: 5}},
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidScenario)
+
+	got, err := svc.GetScenario(ctx, saved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, raise.Recurring[0].Amount, got.Scenario.Recurring[0].Amount)
+	assert.True(t, raise.Recurring[0].StartDate.Equal(got.Scenario.Recurring[0].StartDate))
+
+	// The saved scenario is forecast against the data as it is now.
+	cmp, err := svc.ScenarioForecast(ctx, saved.ID, 1000, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, cmp.EndingBalance.Delta)
+	require.NoError(t, svc.AddExpense(ctx, today.AddDate(0, 0, 2), 100, "Rent", ""))
+	cmp, err = svc.ScenarioForecast(ctx, saved.ID, 1000, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 900.0, cmp.EndingBalance.Baseline)
+	assert.Equal(t, 1000.0, cmp.EndingBalance.Scenario)
+
+	_, err = svc.UpdateScenario(ctx, saved.ID, "Nothing", service.Scenario{})
+	require.NoError(t, err)
+	cmp, err = svc.ScenarioForecast(ctx, saved.ID, 1000, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, cmp.EndingBalance.Delta)
+
+	b, err := svc.Backup(ctx)
+	require.NoError(t, err)
+	require.Len(t, b.Scenarios, 1)
+	require.NoError(t, svc.DeleteScenario(ctx, saved.ID))
+	assert.ErrorIs(t, svc.DeleteScenario(ctx, saved.ID), service.ErrScenarioNotFound)
+	_, err = svc.ScenarioForecast(ctx, saved.ID, 1000, 10)
+	assert.ErrorIs(t, err, service.ErrScenarioNotFound)
+
+	summary, err := svc.Restore(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Scenarios)
+	list, err := svc.ListScenarios(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "Nothing", list[0].Name)
+}
+
+func TestForecastSnapshots(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	today := svc.Today(ctx)
+	require.NoError(t, svc.SetStartingBalance(ctx, 1000))
+
+	_, err := svc.ForecastHistory(ctx, today)
+	assert.ErrorIs(t, err, service.ErrSnapshotNotFound)
+
+	require.NoError(t, svc.SnapshotForecast(ctx))
+	require.NoError(t, svc.AddExpense(ctx, today.AddDate(0, 0, 1), 100, "Rent", ""))
+	snap, err := svc.ForecastHistory(ctx, today)
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, snap.StartingBalance)
+	require.Len(t, snap.Forecast, svc.ForecastDays(ctx))
+	assert.Equal(t, 1000.0, snap.Forecast[1].Balance, "taken before the expense")
+
+	// A later snapshot the same day replaces the earlier one.
+	require.NoError(t, svc.SnapshotForecast(ctx))
+	snap, err = svc.ForecastHistory(ctx, today)
+	require.NoError(t, err)
+	assert.Equal(t, 900.0, snap.Forecast[1].Balance)
+
+	dates, err := svc.ForecastSnapshotDates(ctx)
+	require.NoError(t, err)
+	require.Len(t, dates, 1)
+	assert.True(t, dates[0].Equal(today))
+}
+
+func TestInsights(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	today := svc.Today(ctx)
+	lastMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+
+	_, err := svc.SetExchangeRate(ctx, "EUR", 2)
+	require.NoError(t, err)
+	require.NoError(t, svc.AddIncome(ctx, lastMonth, 1000, "Salary", ""))
+	require.NoError(t, svc.AddExpense(ctx, lastMonth.AddDate(0, 0, 1), 100, "Flight", "EUR"))
+	_, err = svc.CreateRecurringSimple(ctx, service.RecurringInput{
+		Description: "Rent", Type: "expense", Amount: 300, StartDate: lastMonth, Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+
+	got, err := svc.Insights(ctx, lastMonth)
+	require.NoError(t, err)
+	assert.True(t, got.Complete)
+	assert.Equal(t, 200.0, got.Spending.Current, "converted to the base currency")
+	require.Len(t, got.BiggestOneOffs, 1)
+	assert.Equal(t, "Flight", got.BiggestOneOffs[0].Description)
+	assert.Equal(t, 300.0, got.RecurringCosts)
+	require.NotNil(t, got.RecurringShareOfIncome)
+	assert.Equal(t, 30.0, *got.RecurringShareOfIncome)
+
+	_, err = svc.Insights(ctx, today.AddDate(0, 2, 0))
+	assert.ErrorIs(t, err, service.ErrInvalidMonth)
+}
+
+func TestNotifications(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	today := svc.Today(ctx)
+
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Text string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		messages = append(messages, body.Text)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	// Nothing is worked out, or recorded as sent, without a channel.
+	require.NoError(t, svc.SetStartingBalance(ctx, 1000))
+	require.NoError(t, svc.SetLowBalanceThreshold(ctx, 500))
+	require.NoError(t, svc.SetLargeBillThreshold(ctx, 700))
+	due := today.AddDate(0, 0, 2)
+	_, err := svc.CreateRecurringSimple(ctx, service.RecurringInput{
+		Description: "Rent", Type: "expense", Amount: 800, StartDate: due, EndDate: &due, Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.SendNotifications(ctx))
+
+	c, err := svc.CreateNotificationChannel(ctx, service.NotificationChannelInput{
+		Name: "Family", Config: notify.Config{Type: "Slack", WebhookURL: hook.URL + "/services/secret"}, Enabled: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, notify.TypeSlack, c.Type)
+	assert.NotContains(t, c.Config.WebhookURL, "secret")
+	_, err = svc.CreateNotificationChannel(ctx, service.NotificationChannelInput{
+		Name: "Family", Config: notify.Config{Type: "telegram", BotToken: "t", ChatID: "1"},
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidNotificationChannel, "names are unique")
+
+	require.NoError(t, svc.TestNotificationChannel(ctx, c.ID))
+	require.NoError(t, svc.SendNotifications(ctx))
+	require.NoError(t, svc.SendNotifications(ctx))
+	mu.Lock()
+	got := strings.Join(messages, "\n")
+	sent := len(messages)
+	mu.Unlock()
+	assert.Contains(t, got, "are working")
+	assert.Contains(t, got, "*Low balance ahead*\nThe forecast balance drops to 200.00")
+	assert.Contains(t, got, "*Large bill coming up*\nRent for 800.00")
+	if today.Weekday() == time.Monday {
+		assert.Equal(t, 4, sent, "each notification goes once")
+	} else {
+		assert.Equal(t, 3, sent, "each notification goes once")
+	}
+
+	// A channel that can't deliver is an error for the test message.
+	hook.Close()
+	assert.ErrorIs(t, svc.TestNotificationChannel(ctx, c.ID), service.ErrNotificationFailed)
+
+	b, err := svc.Backup(ctx)
+	require.NoError(t, err)
+	require.Len(t, b.NotificationChannels, 1)
+	require.NoError(t, svc.DeleteNotificationChannel(ctx, c.ID))
+	_, err = svc.GetNotificationChannel(ctx, c.ID)
+	assert.ErrorIs(t, err, service.ErrNotificationChannelNotFound)
+	summary, err := svc.Restore(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.NotificationChannels)
+	_, err = svc.GetNotificationChannel(ctx, c.ID)
+	assert.NoError(t, err)
+}
+
+func TestReminders(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	today := svc.Today(ctx)
+
+	var messages []string
+	var mu sync.Mutex
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Text string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		messages = append(messages, body.Text)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	rent, err := svc.CreateRecurringSimple(ctx, service.RecurringInput{
+		Description: "Rent", Type: "expense", Amount: 1200, StartDate: today.AddDate(0, 0, 2), Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+	gym, err := svc.CreateRecurringSimple(ctx, service.RecurringInput{
+		Description: "Gym", Type: "expense", Amount: 40, StartDate: today.AddDate(0, 0, 5), Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+
+	_, err = svc.SetRecurringReminder(ctx, rent.ID, 61)
+	assert.ErrorIs(t, err, service.ErrInvalidReminder)
+	_, err = svc.SetRecurringReminder(ctx, 99, 3)
+	assert.ErrorIs(t, err, service.ErrRecurringNotFound)
+	_, err = svc.SetRecurringReminder(ctx, rent.ID, 1)
+	require.NoError(t, err)
+	_, err = svc.SetRecurringReminder(ctx, rent.ID, 3)
+	require.NoError(t, err, "replaces the first")
+	_, err = svc.SetRecurringReminder(ctx, gym.ID, 2)
+	require.NoError(t, err)
+
+	reminders, err := svc.Reminders(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, reminders, 2)
+	assert.Equal(t, "Rent", reminders[0].Description)
+	assert.True(t, reminders[0].RemindOn.Equal(today.AddDate(0, 0, -1)), "already due")
+	assert.Equal(t, -1200.0, reminders[0].Amount)
+	assert.Equal(t, "Gym", reminders[1].Description)
+	assert.True(t, reminders[1].RemindOn.Equal(today.AddDate(0, 0, 3)))
+
+	_, err = svc.CreateNotificationChannel(ctx, service.NotificationChannelInput{
+		Name: "Family", Config: notify.Config{Type: notify.TypeSlack, WebhookURL: hook.URL}, Enabled: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.SendReminders(ctx))
+	require.NoError(t, svc.SendReminders(ctx))
+	mu.Lock()
+	require.Len(t, messages, 1, "only rent is due, and only once")
+	assert.Contains(t, messages[0], "Rent for 1200.00 USD is due in 2 days")
+	mu.Unlock()
+
+	reminders, err = svc.Reminders(ctx, 7)
+	require.NoError(t, err)
+	assert.True(t, reminders[0].Sent)
+	assert.False(t, reminders[1].Sent)
+
+	require.NoError(t, svc.ClearRecurringReminder(ctx, gym.ID))
+	require.NoError(t, svc.ClearRecurringReminder(ctx, gym.ID), "already off")
+	b, err := svc.Backup(ctx)
+	require.NoError(t, err)
+	require.Len(t, b.Reminders, 1)
+	summary, err := svc.Restore(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Reminders)
+	reminders, err = svc.Reminders(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, rent.ID, reminders[0].RecurringID)
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+
+	alex, err := svc.CreateUser(ctx, " alex ", "correct horse", service.RoleOwner)
+	require.NoError(t, err)
+	assert.Equal(t, "alex", alex.Username)
+	_, err = svc.CreateUser(ctx, "alex", "another horse", service.RoleOwner)
+	assert.ErrorIs(t, err, service.ErrInvalidUser, "duplicate username")
+	_, err = svc.CreateUser(ctx, "sam", "short", service.RoleViewer)
+	assert.ErrorIs(t, err, service.ErrInvalidUser, "weak password")
+
+	_, err = svc.Login(ctx, "alex", "wrong horse")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	_, err = svc.Login(ctx, "nobody", "correct horse")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+
+	s1, err := svc.Login(ctx, "alex", "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", s1.TokenType)
+	u, err := svc.Authenticate(ctx, s1.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, alex.ID, u.ID)
+	_, err = svc.Authenticate(ctx, s1.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+
+	// Refreshing rotates the refresh token.
+	s2, err := svc.Refresh(ctx, s1.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, s1.RefreshToken, s2.RefreshToken)
+	s3, err := svc.Refresh(ctx, s2.RefreshToken)
+	require.NoError(t, err)
+
+	// Reusing a spent token ends the whole session.
+	_, err = svc.Refresh(ctx, s1.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+	_, err = svc.Refresh(ctx, s3.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrInvalidToken, "revoked with its family")
+
+	// Logging out ends one session and leaves the others.
+	a, err := svc.Login(ctx, "alex", "correct horse")
+	require.NoError(t, err)
+	b, err := svc.Login(ctx, "alex", "correct horse")
+	require.NoError(t, err)
+	require.NoError(t, svc.Logout(ctx, a.RefreshToken))
+	require.NoError(t, svc.Logout(ctx, a.RefreshToken))
+	_, err = svc.Refresh(ctx, a.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+	b, err = svc.Refresh(ctx, b.RefreshToken)
+	require.NoError(t, err)
+
+	// Changing the password logs out everywhere.
+	require.NoError(t, svc.SetUserPassword(ctx, alex.ID, "battery staple"))
+	_, err = svc.Refresh(ctx, b.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+	_, err = svc.Login(ctx, "alex", "correct horse")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+
+	// Deleting the user stops their access tokens working. The last owner
+	// has to stay.
+	c, err := svc.Login(ctx, "alex", "battery staple")
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.DeleteUser(ctx, alex.ID), service.ErrInvalidUser)
+	_, err = svc.CreateUser(ctx, "sam", "sam's password", service.RoleOwner)
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteUser(ctx, alex.ID))
+	_, err = svc.Authenticate(ctx, c.AccessToken)
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+	assert.ErrorIs(t, svc.DeleteUser(ctx, alex.ID), service.ErrUserNotFound)
+}
+
+func TestRoles(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+
+	owner, err := svc.CreateUser(ctx, "alex", "correct horse", service.RoleOwner)
+	require.NoError(t, err)
+	accountant, err := svc.CreateUser(ctx, "pat", "correct horse", service.RoleViewer)
+	require.NoError(t, err)
+	_, err = svc.CreateUser(ctx, "sam", "correct horse", "admin")
+	assert.ErrorIs(t, err, service.ErrInvalidUser)
+
+	assert.True(t, owner.HasRole(service.RoleEditor))
+	assert.True(t, accountant.HasRole(service.RoleViewer))
+	assert.False(t, accountant.HasRole(service.RoleEditor))
+	assert.False(t, accountant.HasRole("admin"))
+
+	// The role is read on each request, so a change applies at once.
+	s, err := svc.Login(ctx, "pat", "correct horse")
+	require.NoError(t, err)
+	_, err = svc.SetUserRole(ctx, accountant.ID, service.RoleEditor)
+	require.NoError(t, err)
+	u, err := svc.Authenticate(ctx, s.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, service.RoleEditor, u.Role)
+
+	_, err = svc.SetUserRole(ctx, owner.ID, service.RoleViewer)
+	assert.ErrorIs(t, err, service.ErrInvalidUser, "last owner")
+	_, err = svc.SetUserRole(ctx, accountant.ID, service.RoleOwner)
+	require.NoError(t, err)
+	_, err = svc.SetUserRole(ctx, owner.ID, service.RoleViewer)
+	require.NoError(t, err)
+	_, err = svc.SetUserRole(ctx, 99, service.RoleViewer)
+	assert.ErrorIs(t, err, service.ErrUserNotFound)
+}
+
+func TestHouseholds(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+
+	alex, err := svc.CreateUser(ctx, "alex", "correct horse", service.RoleOwner)
+	require.NoError(t, err)
+	pat, err := svc.CreateUser(ctx, "pat", "correct horse", service.RoleViewer)
+	require.NoError(t, err)
+	cabin, err := svc.CreateHousehold(ctx, "Cabin", alex.ID)
+	require.NoError(t, err)
+	assert.Equal(t, service.RoleOwner, cabin.Role)
+	inCabin := service.WithHousehold(ctx, cabin.ID)
+
+	// Each household sees only its own data.
+	require.NoError(t, svc.SetStartingBalance(ctx, 100))
+	require.NoError(t, svc.SetStartingBalance(inCabin, 25))
+	require.NoError(t, svc.AddIncome(inCabin, time.Now(), 10, "rent", ""))
+	balance, err := svc.GetStartingBalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, balance)
+	balance, err = svc.GetStartingBalance(inCabin)
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, balance)
+	home, err := svc.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, home)
+	away, err := svc.GetAllTransactions(inCabin)
+	require.NoError(t, err)
+	assert.Len(t, away, 1)
+
+	// So do event subscribers.
+	events, cancel := svc.SubscribeEvents(inCabin)
+	defer cancel()
+	require.NoError(t, svc.SetStartingBalance(ctx, 200))
+	require.NoError(t, svc.SetStartingBalance(inCabin, 50))
+	ev := <-events
+	assert.Equal(t, cabin.ID, ev.Household)
+	assert.Empty(t, events)
+
+	// An invitation is accepted once, with the role it offers.
+	inv, err := svc.CreateInvitation(ctx, cabin.ID, service.RoleEditor, alex.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, inv.Token)
+	joined, err := svc.AcceptInvitation(ctx, inv.Token, pat.ID)
+	require.NoError(t, err)
+	assert.Equal(t, cabin.ID, joined.ID)
+	assert.Equal(t, service.RoleEditor, joined.Role)
+	_, err = svc.AcceptInvitation(ctx, inv.Token, pat.ID)
+	assert.ErrorIs(t, err, service.ErrInvalidInvitation)
+	_, err = svc.AcceptInvitation(ctx, "nope", pat.ID)
+	assert.ErrorIs(t, err, service.ErrInvalidInvitation)
+	invitations, err := svc.ListInvitations(ctx, cabin.ID)
+	require.NoError(t, err)
+	require.Len(t, invitations, 1)
+	assert.Empty(t, invitations[0].Token, "only shown when created")
+	assert.Equal(t, pat.ID, *invitations[0].AcceptedBy)
+
+	households, err := svc.UserHouseholds(ctx, pat.ID)
+	require.NoError(t, err)
+	require.Len(t, households, 2)
+	assert.Equal(t, service.RoleViewer, households[0].Role)
+	assert.Equal(t, service.RoleEditor, households[1].Role)
+
+	// A household keeps an owner, and the default household stays.
+	assert.ErrorIs(t, svc.RemoveHouseholdMember(ctx, cabin.ID, alex.ID), service.ErrInvalidHousehold)
+	_, err = svc.SetHouseholdMemberRole(ctx, cabin.ID, alex.ID, service.RoleViewer)
+	assert.ErrorIs(t, err, service.ErrInvalidHousehold)
+	assert.ErrorIs(t, svc.DeleteUser(ctx, alex.ID), service.ErrInvalidUser)
+	assert.ErrorIs(t, svc.DeleteHousehold(ctx, 1), service.ErrInvalidHousehold)
+	require.NoError(t, svc.RemoveHouseholdMember(ctx, cabin.ID, pat.ID))
+	role, err := svc.MemberRole(ctx, cabin.ID, pat.ID)
+	require.NoError(t, err)
+	assert.Empty(t, role)
+
+	// Deleting a household deletes its data.
+	require.NoError(t, svc.DeleteHousehold(ctx, cabin.ID))
+	assert.ErrorIs(t, svc.DeleteHousehold(ctx, cabin.ID), service.ErrHouseholdNotFound)
+	away, err = svc.GetAllTransactions(inCabin)
+	require.NoError(t, err)
+	assert.Empty(t, away)
+}
+
+func TestSandbox(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	owner, err := svc.CreateUser(ctx, "alex", "correct horse", service.RoleOwner)
+	require.NoError(t, err)
+	cabin, err := svc.CreateHousehold(ctx, "Cabin", owner.ID)
+	require.NoError(t, err)
+	inCabin := service.WithHousehold(ctx, cabin.ID)
+	require.NoError(t, svc.AddIncome(inCabin, time.Now(), 10, "rent", ""))
+
+	events, cancel := svc.SubscribeEvents(inCabin)
+	defer cancel()
+	err = svc.DryRun(ctx, func(ctx context.Context) error {
+		assert.True(t, service.IsDryRun(ctx))
+		inCabin := service.WithHousehold(ctx, cabin.ID)
+		require.NoError(t, svc.AddIncome(inCabin, time.Now(), 20, "deposit", ""))
+		_, err := svc.CreateUser(ctx, "pat", "correct horse", service.RoleViewer)
+		require.NoError(t, err)
+		require.NoError(t, svc.DeleteHousehold(ctx, cabin.ID))
+
+		// The sandbox sees its own changes.
+		users, err := svc.ListUsers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, users, 2)
+		_, err = svc.UserHouseholds(ctx, owner.ID)
+		require.NoError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// None of them outlive it.
+	assert.Empty(t, events)
+	users, err := svc.ListUsers(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+	txs, err := svc.GetAllTransactions(inCabin)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "rent", txs[0].Description)
+	households, err := svc.UserHouseholds(ctx, owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, households, 2)
+}
+
+func TestAdd(t *testing.T) {
+	sum := add(add(zero(), num("12.50")), num("-0.125"))
+	m, err := service.NumericToMoney(sum)
+	require.NoError(t, err)
+	assert.Equal(t, "12.38", m.String())
+	assert.Equal(t, sum, add(sum, pgtype.Numeric{}), "NULLs are skipped")
+}
+
+func TestConstraints(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+
+	_, err := db.CreateTransaction(ctx, database.CreateTransactionParams{Date: date("2025-01-01"), Amount: num("1"), Type: "gift"})
+	assert.Equal(t, "23514", pgCode(err), "type check")
+
+	_, err = db.CreateTransaction(ctx, database.CreateTransactionParams{Date: date("2025-01-01"), Amount: num("1"), Type: "income", Status: "bounced"})
+	assert.Equal(t, "23514", pgCode(err), "status check")
+
+	_, err = db.CreateTransaction(ctx, database.CreateTransactionParams{
+		Date: date("2025-01-01"), Amount: num("-1"), Type: "expense", Status: "cleared", AccountID: pgtype.Int4{Int32: 9, Valid: true},
+	})
+	assert.Equal(t, "23503", pgCode(err), "no such account")
+
+	_, err = db.CreateAccount(ctx, database.CreateAccountParams{Name: "Card", Type: "credit_card"})
+	assert.Equal(t, "23514", pgCode(err), "cards need statement and due days")
+
+	card, err := db.CreateAccount(ctx, database.CreateAccountParams{
+		Name: "Card", Type: "credit_card", StatementDay: pgtype.Int4{Int32: 5, Valid: true}, DueDay: pgtype.Int4{Int32: 28, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = db.CreateTransaction(ctx, database.CreateTransactionParams{
+		Date: date("2025-01-01"), Amount: num("-1"), Type: "expense", Status: "cleared", AccountID: pgtype.Int4{Int32: card.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "23503", pgCode(db.DeleteAccount(ctx, card.ID)), "still charged to")
+
+	_, err = db.CreateRecurring(ctx, database.CreateRecurringParams{
+		Description: "Rent", Type: "expense", Amount: num("900"), StartDate: date("2025-01-01"),
+		Interval: database.RecurrenceIntervalMonthly, Prorate: true,
+	})
+	assert.Equal(t, "23514", pgCode(err), "only yearly recurrings prorate")
+
+	_, err = db.UpsertExchangeRate(ctx, database.UpsertExchangeRateParams{Currency: "EUR", Rate: num("0")})
+	assert.Equal(t, "23514", pgCode(err), "rates are positive")
+
+	err = db.UpsertIncomeTaxDetail(ctx, database.UpsertIncomeTaxDetailParams{TransactionID: 99, GrossAmount: num("1"), TaxWithheld: num("0")})
+	assert.Equal(t, "23503", pgCode(err), "no such transaction")
+}
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	require.NoError(t, db.InsertBackupTransaction(ctx, database.InsertBackupTransactionParams{
+		ID: 7, Date: date("2025-02-01"), Amount: num("10"), Type: "income", Currency: "USD", Status: "cleared",
+	}))
+	err := db.InsertBackupTransaction(ctx, database.InsertBackupTransactionParams{
+		ID: 7, Date: date("2025-02-01"), Amount: num("10"), Type: "income", Currency: "USD", Status: "cleared",
+	})
+	assert.Equal(t, "23505", pgCode(err))
+
+	require.NoError(t, db.ResetTransactionsSequence(ctx))
+	tx := addTx(t, db, "2025-02-02", "5", "income")
+	assert.Equal(t, int32(8), tx.ID, "sequence continues after the restored rows")
+
+	require.NoError(t, db.UpsertIncomeTaxDetail(ctx, database.UpsertIncomeTaxDetailParams{
+		TransactionID: 7, GrossAmount: num("12"), TaxWithheld: num("2"),
+	}))
+	totals, err := db.GetTaxTotalsBetween(ctx, database.GetTaxTotalsBetweenParams{StartDate: date("2025-01-01"), EndDate: date("2025-12-31")})
+	require.NoError(t, err)
+	assert.Equal(t, 0, cmpNumeric(totals.TotalWithheld, num("2")))
+
+	require.NoError(t, db.DeleteAllTransactions(ctx))
+	details, err := db.ListIncomeTaxDetails(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, details, "tax details cascade")
+}
+
+func TestAuditEntries(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	for _, entity := range []string{"transaction", "recurring", "transaction"} {
+		require.NoError(t, db.CreateAuditEntry(ctx, database.CreateAuditEntryParams{
+			Actor: "test", Action: "create", Entity: entity, EntityID: "1",
+		}))
+	}
+
+	entries, err := db.ListAuditEntries(ctx, database.ListAuditEntriesParams{
+		Entity: pgtype.Text{String: "transaction", Valid: true}, RowLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(3), entries[0].ID, "newest first")
+
+	entries, err = db.ListAuditEntries(ctx, database.ListAuditEntriesParams{RowLimit: 1})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	history, err := db.ListAuditHistory(ctx, []string{"recurring"})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, int64(2), history[0].ID)
+}
+
+func TestSyncChanges(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	tx, err := db.CreateTransaction(ctx, database.CreateTransactionParams{
+		Date: date("2025-03-01"), Amount: num("-5"), Description: "Tea", Type: "expense", Currency: "USD", Status: "cleared",
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateSetting(ctx, database.UpdateSettingParams{Key: "theme", Value: "dark"}))
+	require.NoError(t, db.UpdateSetting(ctx, database.UpdateSettingParams{Key: "theme", Value: "light"}))
+	n, err := db.DeleteTransaction(ctx, tx.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+	_, err = db.DeleteTransaction(ctx, tx.ID)
+	require.NoError(t, err)
+
+	changes, err := db.ListSyncChanges(ctx, database.ListSyncChangesParams{RowLimit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []database.SyncChanges{
+		{Entity: "setting", EntityID: "theme", Seq: 3, CreatedSeq: 2},
+		{Entity: "transaction", EntityID: "1", Seq: 4, CreatedSeq: 1},
+	}, changes, "one row per record, a no-op delete changing nothing")
+
+	cursor, err := db.GetSyncCursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), cursor)
+
+	changes, err = db.ListSyncChanges(ctx, database.ListSyncChangesParams{Since: 3, RowLimit: 10})
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	txs, err := db.GetTransactionsByIDs(ctx, []int32{tx.ID, 99})
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.True(t, txs[0].DeletedAt.Valid, "deleted ones included")
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewFinanceService(New())
+	require.NoError(t, svc.SetStartingBalance(ctx, 500))
+	require.NoError(t, svc.AddIncome(ctx, svc.Today(ctx).AddDate(0, 0, 1), 100, "Refund", ""))
+
+	forecast, err := svc.Forecast(ctx, 500, 3)
+	require.NoError(t, err)
+	require.Len(t, forecast, 3)
+	assert.Equal(t, 600.0, forecast[2].Balance)
+
+	txs, err := svc.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.NoError(t, svc.DeleteTransaction(ctx, txs[0].ID))
+	assert.ErrorIs(t, svc.DeleteTransaction(ctx, txs[0].ID), service.ErrTransactionNotFound)
+
+	require.NoError(t, svc.Ping(ctx))
+	schema, err := svc.SchemaStatus(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, schema.Pending)
+}
@@ -0,0 +1,158 @@
+package memdb
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// sentKey is the primary key of notifications_sent.
+type sentKey struct{ kind, key string }
+
+func (db *DB) CreateNotificationChannel(ctx context.Context, arg database.CreateNotificationChannelParams) (database.NotificationChannels, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	c := database.NotificationChannels{
+		ID:        db.nextChannel,
+		Name:      arg.Name,
+		Type:      arg.Type,
+		Config:    slices.Clone(arg.Config),
+		Enabled:   arg.Enabled,
+		CreatedAt: now(),
+	}
+	if err := checkChannel(db, c); err != nil {
+		return database.NotificationChannels{}, err
+	}
+	db.nextChannel++
+	db.channels[c.ID] = c
+	return c, nil
+}
+
+func (db *DB) DeleteAllNotificationChannels(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	clear(db.channels)
+	return nil
+}
+
+func (db *DB) DeleteNotificationChannel(ctx context.Context, id int32) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.channels[id]; !ok {
+		return 0, nil
+	}
+	delete(db.channels, id)
+	return 1, nil
+}
+
+func (db *DB) GetNotificationChannel(ctx context.Context, id int32) (database.NotificationChannels, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	c, ok := db.channels[id]
+	if !ok {
+		return database.NotificationChannels{}, pgx.ErrNoRows
+	}
+	return c, nil
+}
+
+func (db *DB) InsertBackupNotificationChannel(ctx context.Context, arg database.InsertBackupNotificationChannelParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.channels[arg.ID]; ok {
+		return duplicateKey("notification_channels")
+	}
+	c := database.NotificationChannels(arg)
+	c.Config = slices.Clone(c.Config)
+	if err := checkChannel(db, c); err != nil {
+		return err
+	}
+	db.channels[c.ID] = c
+	return nil
+}
+
+func (db *DB) LastNotificationMetric(ctx context.Context, kind string) (pgtype.Numeric, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var last *database.NotificationsSent
+	for _, n := range db.sent {
+		if n.Kind != kind || !n.Metric.Valid {
+			continue
+		}
+		if last == nil || n.SentAt.Time.After(last.SentAt.Time) ||
+			n.SentAt.Time.Equal(last.SentAt.Time) && n.Key > last.Key {
+			last = &n
+		}
+	}
+	if last == nil {
+		return pgtype.Numeric{}, pgx.ErrNoRows
+	}
+	return last.Metric, nil
+}
+
+func (db *DB) ListNotificationChannels(ctx context.Context) ([]database.NotificationChannels, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return sortedByID(db.channels, func(c database.NotificationChannels) int32 { return c.ID }), nil
+}
+
+func (db *DB) ListNotificationChannelsForBackup(ctx context.Context) ([]database.NotificationChannels, error) {
+	db = db.scope(ctx)
+	return db.ListNotificationChannels(ctx)
+}
+
+func (db *DB) NotificationSent(ctx context.Context, arg database.NotificationSentParams) (bool, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	_, ok := db.sent[sentKey{arg.Kind, arg.Key}]
+	return ok, nil
+}
+
+func (db *DB) RecordNotification(ctx context.Context, arg database.RecordNotificationParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	k := sentKey{arg.Kind, arg.Key}
+	if _, ok := db.sent[k]; !ok {
+		db.sent[k] = database.NotificationsSent{Kind: arg.Kind, Key: arg.Key, SentAt: now(), Metric: arg.Metric}
+	}
+	return nil
+}
+
+func (db *DB) ResetNotificationChannelsSequence(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.nextChannel = maxKey(db.channels) + 1
+	return nil
+}
+
+func (db *DB) UpdateNotificationChannel(ctx context.Context, arg database.UpdateNotificationChannelParams) (database.NotificationChannels, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	c, ok := db.channels[arg.ID]
+	if !ok {
+		return database.NotificationChannels{}, pgx.ErrNoRows
+	}
+	c.Name = arg.Name
+	c.Type = arg.Type
+	c.Config = slices.Clone(arg.Config)
+	c.Enabled = arg.Enabled
+	if err := checkChannel(db, c); err != nil {
+		return database.NotificationChannels{}, err
+	}
+	db.channels[c.ID] = c
+	return c, nil
+}
@@ -0,0 +1,256 @@
+package memdb
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) CreateRecurring(ctx context.Context, arg database.CreateRecurringParams) (database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r := database.RecurringTransactions{
+		ID:              db.nextRecurring,
+		Description:     arg.Description,
+		Type:            arg.Type,
+		Amount:          arg.Amount,
+		StartDate:       day(arg.StartDate),
+		Interval:        arg.Interval,
+		DayOfWeek:       arg.DayOfWeek,
+		DayOfMonth:      arg.DayOfMonth,
+		EndDate:         day(arg.EndDate),
+		Active:          arg.Active,
+		Currency:        arg.Currency,
+		Prorate:         arg.Prorate,
+		OccurrenceCount: arg.OccurrenceCount,
+		WeekOfMonth:     arg.WeekOfMonth,
+		BusinessDay:     arg.BusinessDay,
+		Rrule:           arg.Rrule,
+		Category:        arg.Category,
+		AccountID:       arg.AccountID,
+		Version:         1,
+	}
+	if err := checkRecurring(db, r); err != nil {
+		return database.RecurringTransactions{}, err
+	}
+	if _, ok := db.recurring[r.ID]; ok {
+		return database.RecurringTransactions{}, duplicateKey("recurring_transactions")
+	}
+	db.nextRecurring++
+	db.recurring[r.ID] = r
+	db.changed(syncRecurring, r.ID, true)
+	return r, nil
+}
+
+func (db *DB) DeleteAllRecurring(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for id := range db.recurring {
+		db.changed(syncRecurring, id, false)
+	}
+	clear(db.recurring)
+	clear(db.reminders)
+	return nil
+}
+
+func (db *DB) DeleteRecurring(ctx context.Context, id int32) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r, ok := db.recurring[id]
+	if !ok || r.DeletedAt.Valid {
+		return 0, nil
+	}
+	r.DeletedAt = now()
+	r.Version++
+	db.recurring[id] = r
+	db.changed(syncRecurring, id, false)
+	return 1, nil
+}
+
+func (db *DB) GetRecurringByID(ctx context.Context, id int32) (database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r, ok := db.recurring[id]
+	if !ok || r.DeletedAt.Valid {
+		return database.RecurringTransactions{}, pgx.ErrNoRows
+	}
+	return r, nil
+}
+
+func (db *DB) InsertBackupRecurring(ctx context.Context, arg database.InsertBackupRecurringParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.recurring[arg.ID]; ok {
+		return duplicateKey("recurring_transactions")
+	}
+	r := database.RecurringTransactions(arg)
+	r.StartDate, r.EndDate = day(r.StartDate), day(r.EndDate)
+	r.PausedFrom, r.PausedUntil = day(r.PausedFrom), day(r.PausedUntil)
+	if err := checkRecurring(db, r); err != nil {
+		return err
+	}
+	db.recurring[r.ID] = r
+	db.changed(syncRecurring, r.ID, true)
+	return nil
+}
+
+func (db *DB) ListActiveRecurring(ctx context.Context) ([]database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	return db.listRecurring(func(r database.RecurringTransactions) bool { return r.Active && !r.DeletedAt.Valid }), nil
+}
+
+func (db *DB) ListDeletedRecurring(ctx context.Context) ([]database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	out := db.listRecurring(func(r database.RecurringTransactions) bool { return r.DeletedAt.Valid })
+	slices.SortStableFunc(out, func(a, b database.RecurringTransactions) int {
+		return b.DeletedAt.Time.Compare(a.DeletedAt.Time)
+	})
+	return out, nil
+}
+
+func (db *DB) ListRecurring(ctx context.Context) ([]database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	return db.listRecurring(func(r database.RecurringTransactions) bool { return !r.DeletedAt.Valid }), nil
+}
+
+func (db *DB) ListRecurringSorted(ctx context.Context, arg database.ListRecurringSortedParams) ([]database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	keys := func(r database.RecurringTransactions) sortKeys {
+		return sortKeys{date: r.StartDate, amount: r.Amount, description: r.Description, id: r.ID}
+	}
+	rs := db.listRecurring(func(r database.RecurringTransactions) bool { return !r.DeletedAt.Valid })
+	slices.SortFunc(rs, orderBy(arg.SortKey, arg.Descending, keys))
+	ks := keyset{
+		after: sortKeys{date: arg.AfterDate, amount: arg.AfterAmount, description: arg.AfterDescription.String, id: arg.AfterID.Int32},
+		valid: arg.AfterID.Valid,
+		size:  arg.PageSize,
+	}
+	return page(rs, arg.SortKey, arg.Descending, ks, keys), nil
+}
+
+func (db *DB) ListRecurringForBackup(ctx context.Context) ([]database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	return db.listRecurring(func(database.RecurringTransactions) bool { return true }), nil
+}
+
+func (db *DB) listRecurring(keep func(database.RecurringTransactions) bool) []database.RecurringTransactions {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return slices.DeleteFunc(sortedByID(db.recurring, func(r database.RecurringTransactions) int32 { return r.ID }),
+		func(r database.RecurringTransactions) bool { return !keep(r) })
+}
+
+func (db *DB) PurgeDeletedRecurring(ctx context.Context, cutoff pgtype.Timestamp) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var n int64
+	for id, r := range db.recurring {
+		if r.DeletedAt.Valid && cutoff.Valid && r.DeletedAt.Time.Before(cutoff.Time) {
+			delete(db.recurring, id)
+			db.changed(syncRecurring, id, false)
+			delete(db.reminders, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (db *DB) ResetRecurringSequence(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.nextRecurring = maxKey(db.recurring) + 1
+	return nil
+}
+
+func (db *DB) RestoreRecurring(ctx context.Context, id int32) (database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r, ok := db.recurring[id]
+	if !ok || !r.DeletedAt.Valid {
+		return database.RecurringTransactions{}, pgx.ErrNoRows
+	}
+	r.DeletedAt = pgtype.Timestamp{}
+	r.Version++
+	db.recurring[id] = r
+	db.changed(syncRecurring, id, false)
+	return r, nil
+}
+
+func (db *DB) SetRecurringActive(ctx context.Context, arg database.SetRecurringActiveParams) (database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r, ok := db.recurring[arg.ID]
+	if !ok || r.DeletedAt.Valid || r.Version != arg.Version {
+		return database.RecurringTransactions{}, pgx.ErrNoRows
+	}
+	r.Active = arg.Active
+	r.Version++
+	db.recurring[arg.ID] = r
+	db.changed(syncRecurring, arg.ID, false)
+	return r, nil
+}
+
+func (db *DB) SetRecurringPause(ctx context.Context, arg database.SetRecurringPauseParams) (database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r, ok := db.recurring[arg.ID]
+	if !ok || r.DeletedAt.Valid || r.Version != arg.Version {
+		return database.RecurringTransactions{}, pgx.ErrNoRows
+	}
+	r.PausedFrom, r.PausedUntil = day(arg.PausedFrom), day(arg.PausedUntil)
+	if err := checkRecurring(db, r); err != nil {
+		return database.RecurringTransactions{}, err
+	}
+	r.Version++
+	db.recurring[arg.ID] = r
+	db.changed(syncRecurring, arg.ID, false)
+	return r, nil
+}
+
+func (db *DB) UpdateRecurring(ctx context.Context, arg database.UpdateRecurringParams) (database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r, ok := db.recurring[arg.ID]
+	if !ok || r.DeletedAt.Valid || r.Version != arg.Version {
+		return database.RecurringTransactions{}, pgx.ErrNoRows
+	}
+	r.Description = arg.Description
+	r.Type = arg.Type
+	r.Amount = arg.Amount
+	r.StartDate = day(arg.StartDate)
+	r.Interval = arg.Interval
+	r.DayOfWeek = arg.DayOfWeek
+	r.DayOfMonth = arg.DayOfMonth
+	r.EndDate = day(arg.EndDate)
+	r.Active = arg.Active
+	r.Currency = arg.Currency
+	r.Prorate = arg.Prorate
+	r.OccurrenceCount = arg.OccurrenceCount
+	r.WeekOfMonth = arg.WeekOfMonth
+	r.BusinessDay = arg.BusinessDay
+	r.Rrule = arg.Rrule
+	r.Category = arg.Category
+	r.AccountID = arg.AccountID
+	if err := checkRecurring(db, r); err != nil {
+		return database.RecurringTransactions{}, err
+	}
+	r.Version++
+	db.recurring[arg.ID] = r
+	db.changed(syncRecurring, arg.ID, false)
+	return r, nil
+}
@@ -0,0 +1,47 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) DeleteRecurringReminder(ctx context.Context, recurringID int32) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.reminders[recurringID]; !ok {
+		return 0, nil
+	}
+	delete(db.reminders, recurringID)
+	return 1, nil
+}
+
+func (db *DB) ListRecurringReminders(ctx context.Context) ([]database.RecurringReminders, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return sortedByID(db.reminders, func(r database.RecurringReminders) int32 { return r.RecurringID }), nil
+}
+
+func (db *DB) SetRecurringReminder(ctx context.Context, arg database.SetRecurringReminderParams) (database.RecurringReminders, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r := database.RecurringReminders(arg)
+	if r.DaysBefore < 0 || r.DaysBefore > 60 {
+		return database.RecurringReminders{}, checkViolation("recurring_reminders", "recurring_reminders_days_before_check")
+	}
+	if _, ok := db.recurring[r.RecurringID]; !ok {
+		return database.RecurringReminders{}, &pgconn.PgError{
+			Code:           "23503",
+			Message:        `insert or update on table "recurring_reminders" violates foreign key constraint "recurring_reminders_recurring_id_fkey"`,
+			TableName:      "recurring_reminders",
+			ConstraintName: "recurring_reminders_recurring_id_fkey",
+		}
+	}
+	db.reminders[r.RecurringID] = r
+	return r, nil
+}
@@ -0,0 +1,111 @@
+package memdb
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) CreateScenario(ctx context.Context, arg database.CreateScenarioParams) (database.Scenarios, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	sc := database.Scenarios{
+		ID:         db.nextScenario,
+		Name:       arg.Name,
+		Definition: slices.Clone(arg.Definition),
+		CreatedAt:  now(),
+	}
+	if err := checkScenario(db, sc); err != nil {
+		return database.Scenarios{}, err
+	}
+	db.nextScenario++
+	db.scenarios[sc.ID] = sc
+	return sc, nil
+}
+
+func (db *DB) DeleteAllScenarios(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	clear(db.scenarios)
+	return nil
+}
+
+func (db *DB) DeleteScenario(ctx context.Context, id int32) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.scenarios[id]; !ok {
+		return 0, nil
+	}
+	delete(db.scenarios, id)
+	return 1, nil
+}
+
+func (db *DB) GetScenario(ctx context.Context, id int32) (database.Scenarios, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	sc, ok := db.scenarios[id]
+	if !ok {
+		return database.Scenarios{}, pgx.ErrNoRows
+	}
+	return sc, nil
+}
+
+func (db *DB) InsertBackupScenario(ctx context.Context, arg database.InsertBackupScenarioParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.scenarios[arg.ID]; ok {
+		return duplicateKey("scenarios")
+	}
+	sc := database.Scenarios(arg)
+	sc.Definition = slices.Clone(sc.Definition)
+	if err := checkScenario(db, sc); err != nil {
+		return err
+	}
+	db.scenarios[sc.ID] = sc
+	return nil
+}
+
+func (db *DB) ListScenarios(ctx context.Context) ([]database.Scenarios, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return sortedByID(db.scenarios, func(sc database.Scenarios) int32 { return sc.ID }), nil
+}
+
+func (db *DB) ListScenariosForBackup(ctx context.Context) ([]database.Scenarios, error) {
+	db = db.scope(ctx)
+	return db.ListScenarios(ctx)
+}
+
+func (db *DB) ResetScenariosSequence(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.nextScenario = maxKey(db.scenarios) + 1
+	return nil
+}
+
+func (db *DB) UpdateScenario(ctx context.Context, arg database.UpdateScenarioParams) (database.Scenarios, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	sc, ok := db.scenarios[arg.ID]
+	if !ok {
+		return database.Scenarios{}, pgx.ErrNoRows
+	}
+	sc.Name = arg.Name
+	sc.Definition = slices.Clone(arg.Definition)
+	if err := checkScenario(db, sc); err != nil {
+		return database.Scenarios{}, err
+	}
+	db.scenarios[sc.ID] = sc
+	return sc, nil
+}
@@ -0,0 +1,78 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) DeleteAllSettings(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for key := range db.settings {
+		db.changed(syncSetting, key, false)
+	}
+	clear(db.settings)
+	return nil
+}
+
+func (db *DB) DeleteSetting(ctx context.Context, key string) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.settings[key]; ok {
+		delete(db.settings, key)
+		db.changed(syncSetting, key, false)
+	}
+	return nil
+}
+
+func (db *DB) GetAllSettings(ctx context.Context) ([]database.Settings, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := make([]database.Settings, 0, len(db.settings))
+	for _, st := range db.settings {
+		out = append(out, st)
+	}
+	slices.SortFunc(out, func(a, b database.Settings) int { return cmp.Compare(a.Key, b.Key) })
+	return out, nil
+}
+
+func (db *DB) GetSetting(ctx context.Context, key string) (string, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	st, ok := db.settings[key]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return st.Value, nil
+}
+
+func (db *DB) InsertBackupSetting(ctx context.Context, arg database.InsertBackupSettingParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.settings[arg.Key]; ok {
+		return duplicateKey("settings")
+	}
+	db.settings[arg.Key] = database.Settings(arg)
+	db.changed(syncSetting, arg.Key, true)
+	return nil
+}
+
+func (db *DB) UpdateSetting(ctx context.Context, arg database.UpdateSettingParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	_, exists := db.settings[arg.Key]
+	db.settings[arg.Key] = database.Settings{Key: arg.Key, Value: arg.Value, UpdatedAt: now()}
+	db.changed(syncSetting, arg.Key, !exists)
+	return nil
+}
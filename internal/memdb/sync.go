@@ -0,0 +1,73 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// The entities sync_changes tracks, as its triggers name them.
+const (
+	syncTransaction = "transaction"
+	syncRecurring   = "recurring"
+	syncSetting     = "setting"
+)
+
+type syncKey struct {
+	entity, id string
+}
+
+// changed notes a write to a row, as the sync_changes triggers do; inserted
+// is true for an INSERT. The caller holds db.mu.
+func (db *DB) changed(entity string, id any, inserted bool) {
+	k := syncKey{entity, fmt.Sprint(id)}
+	seq := db.nextSync
+	db.nextSync++
+	c, ok := db.changes[k]
+	if !ok || inserted {
+		c.CreatedSeq = seq
+	}
+	c.Entity, c.EntityID, c.Seq = k.entity, k.id, seq
+	db.changes[k] = c
+}
+
+func (db *DB) GetRecurringsByIDs(ctx context.Context, ids []int32) ([]database.RecurringTransactions, error) {
+	db = db.scope(ctx)
+	return db.listRecurring(func(r database.RecurringTransactions) bool { return slices.Contains(ids, r.ID) }), nil
+}
+
+func (db *DB) GetSyncCursor(ctx context.Context) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var cursor int64
+	for _, c := range db.changes {
+		cursor = max(cursor, c.Seq)
+	}
+	return cursor, nil
+}
+
+func (db *DB) GetTransactionsByIDs(ctx context.Context, ids []int32) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	return db.listTransactionsByID(func(t database.Transactions) bool { return slices.Contains(ids, t.ID) }), nil
+}
+
+func (db *DB) ListSyncChanges(ctx context.Context, arg database.ListSyncChangesParams) ([]database.SyncChanges, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := []database.SyncChanges{}
+	for _, c := range db.changes {
+		if c.Seq > arg.Since {
+			out = append(out, c)
+		}
+	}
+	slices.SortFunc(out, func(a, b database.SyncChanges) int { return cmp.Compare(a.Seq, b.Seq) })
+	if len(out) > int(arg.RowLimit) {
+		out = out[:max(arg.RowLimit, 0)]
+	}
+	return out, nil
+}
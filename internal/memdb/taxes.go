@@ -0,0 +1,110 @@
+package memdb
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) GetSelfEmploymentIncomeBetween(ctx context.Context, arg database.GetSelfEmploymentIncomeBetweenParams) ([]database.GetSelfEmploymentIncomeBetweenRow, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	rows := []database.GetSelfEmploymentIncomeBetweenRow{}
+	for id := range db.selfEmployed {
+		t, ok := db.transactions[id]
+		if !ok || t.DeletedAt.Valid || !between(t.Date, arg.StartDate, arg.EndDate) {
+			continue
+		}
+		rows = append(rows, database.GetSelfEmploymentIncomeBetweenRow{ID: t.ID, Date: t.Date, Amount: t.Amount, Currency: t.Currency})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Date.Time.Equal(rows[j].Date.Time) {
+			return rows[i].Date.Time.Before(rows[j].Date.Time)
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	return rows, nil
+}
+
+func (db *DB) GetTaxTotalsBetween(ctx context.Context, arg database.GetTaxTotalsBetweenParams) (database.GetTaxTotalsBetweenRow, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	row := database.GetTaxTotalsBetweenRow{TotalGross: zero(), TotalWithheld: zero()}
+	for _, d := range db.taxDetails {
+		t, ok := db.transactions[d.TransactionID]
+		if !ok || t.DeletedAt.Valid || !between(t.Date, arg.StartDate, arg.EndDate) {
+			continue
+		}
+		row.TotalGross = add(row.TotalGross, d.GrossAmount)
+		row.TotalWithheld = add(row.TotalWithheld, d.TaxWithheld)
+	}
+	return row, nil
+}
+
+func (db *DB) ListIncomeTaxDetails(ctx context.Context) ([]database.IncomeTaxDetails, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return sortedByID(db.taxDetails, func(d database.IncomeTaxDetails) int32 { return d.TransactionID }), nil
+}
+
+func (db *DB) ListSelfEmploymentIncome(ctx context.Context) ([]int32, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	ids := []int32{}
+	for id := range db.selfEmployed {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (db *DB) TagSelfEmploymentIncome(ctx context.Context, transactionID int32) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.transactions[transactionID]; !ok {
+		return &pgconn.PgError{
+			Code:           "23503",
+			Message:        `insert or update on table "self_employment_income" violates foreign key constraint "self_employment_income_transaction_id_fkey"`,
+			TableName:      "self_employment_income",
+			ConstraintName: "self_employment_income_transaction_id_fkey",
+		}
+	}
+	db.selfEmployed[transactionID] = true
+	return nil
+}
+
+func (db *DB) UntagSelfEmploymentIncome(ctx context.Context, transactionID int32) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if !db.selfEmployed[transactionID] {
+		return 0, nil
+	}
+	delete(db.selfEmployed, transactionID)
+	return 1, nil
+}
+
+func (db *DB) UpsertIncomeTaxDetail(ctx context.Context, arg database.UpsertIncomeTaxDetailParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.transactions[arg.TransactionID]; !ok {
+		return &pgconn.PgError{
+			Code:           "23503",
+			Message:        `insert or update on table "income_tax_details" violates foreign key constraint "income_tax_details_transaction_id_fkey"`,
+			TableName:      "income_tax_details",
+			ConstraintName: "income_tax_details_transaction_id_fkey",
+		}
+	}
+	db.taxDetails[arg.TransactionID] = database.IncomeTaxDetails(arg)
+	return nil
+}
@@ -0,0 +1,361 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) CreateTransaction(ctx context.Context, arg database.CreateTransactionParams) (database.Transactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t := database.Transactions{
+		ID:          db.nextTransaction,
+		Date:        day(arg.Date),
+		Amount:      arg.Amount,
+		Description: arg.Description,
+		Type:        arg.Type,
+		CreatedAt:   now(),
+		Category:    arg.Category,
+		Currency:    arg.Currency,
+		AccountID:   arg.AccountID,
+		Status:      arg.Status,
+		Version:     1,
+	}
+	if err := checkTransaction(db, t); err != nil {
+		return database.Transactions{}, err
+	}
+	if _, ok := db.transactions[t.ID]; ok {
+		return database.Transactions{}, duplicateKey("transactions")
+	}
+	db.nextTransaction++
+	db.transactions[t.ID] = t
+	db.changed(syncTransaction, t.ID, true)
+	return t, nil
+}
+
+func (db *DB) DeleteAllTransactions(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for id := range db.transactions {
+		db.changed(syncTransaction, id, false)
+	}
+	clear(db.transactions)
+	clear(db.taxDetails)
+	clear(db.selfEmployed)
+	clear(db.attachments)
+	return nil
+}
+
+func (db *DB) DeleteTransaction(ctx context.Context, id int32) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, ok := db.transactions[id]
+	if !ok || t.DeletedAt.Valid {
+		return 0, nil
+	}
+	t.DeletedAt = now()
+	t.Version++
+	db.transactions[id] = t
+	db.changed(syncTransaction, id, false)
+	return 1, nil
+}
+
+func (db *DB) GetAllTransactions(ctx context.Context) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	return db.listTransactions(func(t database.Transactions) bool { return !t.DeletedAt.Valid }), nil
+}
+
+func (db *DB) GetDailyTransactionSums(ctx context.Context, arg database.GetDailyTransactionSumsParams) ([]database.GetDailyTransactionSumsRow, error) {
+	db = db.scope(ctx)
+	type group struct {
+		date     time.Time
+		currency string
+		account  pgtype.Int4
+	}
+	var out []database.GetDailyTransactionSumsRow
+	index := make(map[group]int)
+	for _, t := range db.listTransactions(func(t database.Transactions) bool {
+		return !t.DeletedAt.Valid && between(t.Date, arg.StartDate, arg.EndDate) &&
+			(arg.IncludePending || t.Status == "cleared")
+	}) {
+		g := group{t.Date.Time, t.Currency, t.AccountID}
+		i, ok := index[g]
+		if !ok {
+			i = len(out)
+			index[g] = i
+			out = append(out, database.GetDailyTransactionSumsRow{
+				Date: t.Date, Currency: t.Currency, AccountID: t.AccountID, Total: zero(),
+			})
+		}
+		out[i].Total = add(out[i].Total, t.Amount)
+	}
+	return out, nil
+}
+
+func (db *DB) GetPendingTransactionsBefore(ctx context.Context, date pgtype.Date) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	return db.listTransactions(func(t database.Transactions) bool {
+		return !t.DeletedAt.Valid && t.Status == "pending" && date.Valid && t.Date.Time.Before(date.Time)
+	}), nil
+}
+
+func (db *DB) GetSpendingHeatmap(ctx context.Context, arg database.GetSpendingHeatmapParams) ([]database.GetSpendingHeatmapRow, error) {
+	db = db.scope(ctx)
+	out := []database.GetSpendingHeatmapRow{}
+	// BETWEEN with a NULL bound matches nothing.
+	if !arg.StartDate.Valid || !arg.EndDate.Valid {
+		return out, nil
+	}
+	for _, t := range db.filterTransactions(func(t database.Transactions) bool {
+		return !t.DeletedAt.Valid && t.Type == "expense" && between(t.Date, arg.StartDate, arg.EndDate)
+	}, func(a, b database.Transactions) int {
+		if c := a.Date.Time.Compare(b.Date.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Currency, b.Currency)
+	}) {
+		if n := len(out); n == 0 || !out[n-1].Date.Time.Equal(t.Date.Time) || out[n-1].Currency != t.Currency {
+			year, week := t.Date.Time.ISOWeek()
+			out = append(out, database.GetSpendingHeatmapRow{
+				Date:     t.Date,
+				Currency: t.Currency,
+				IsoYear:  int32(year),
+				Week:     int32(week),
+				Weekday:  int32((int(t.Date.Time.Weekday())+6)%7 + 1),
+				Spent:    zero(),
+			})
+		}
+		row := &out[len(out)-1]
+		row.Count++
+		row.Spent = add(row.Spent, neg(t.Amount))
+	}
+	return out, nil
+}
+
+func (db *DB) GetTransactionAggregatesByCurrency(ctx context.Context, arg database.GetTransactionAggregatesByCurrencyParams) ([]database.GetTransactionAggregatesByCurrencyRow, error) {
+	db = db.scope(ctx)
+	txs := db.filterTransactions(func(t database.Transactions) bool {
+		return !t.DeletedAt.Valid && between(t.Date, arg.StartDate, arg.EndDate) &&
+			(!arg.Status.Valid || t.Status == arg.Status.String)
+	}, func(a, b database.Transactions) int { return cmp.Compare(a.Currency, b.Currency) })
+	out := []database.GetTransactionAggregatesByCurrencyRow{}
+	for _, t := range txs {
+		if len(out) == 0 || out[len(out)-1].Currency != t.Currency {
+			out = append(out, database.GetTransactionAggregatesByCurrencyRow{Currency: t.Currency, TotalIncome: zero(), TotalExpense: zero()})
+		}
+		row := &out[len(out)-1]
+		row.Count++
+		switch t.Type {
+		case "income":
+			row.TotalIncome = add(row.TotalIncome, t.Amount)
+		case "expense":
+			row.TotalExpense = add(row.TotalExpense, t.Amount)
+		}
+	}
+	return out, nil
+}
+
+func (db *DB) GetTransactionByID(ctx context.Context, id int32) (database.Transactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, ok := db.transactions[id]
+	if !ok || t.DeletedAt.Valid {
+		return database.Transactions{}, pgx.ErrNoRows
+	}
+	return t, nil
+}
+
+func (db *DB) GetTransactionsByDateRange(ctx context.Context, arg database.GetTransactionsByDateRangeParams) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	// BETWEEN with a NULL bound matches nothing.
+	if !arg.Date.Valid || !arg.Date_2.Valid {
+		return nil, nil
+	}
+	return db.listTransactions(func(t database.Transactions) bool {
+		return !t.DeletedAt.Valid && between(t.Date, arg.Date, arg.Date_2)
+	}), nil
+}
+
+func (db *DB) GetTransactionsByStatus(ctx context.Context, status string) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	return db.listTransactions(func(t database.Transactions) bool { return !t.DeletedAt.Valid && t.Status == status }), nil
+}
+
+func (db *DB) ListTransactionsSorted(ctx context.Context, arg database.ListTransactionsSortedParams) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	keys := func(t database.Transactions) sortKeys {
+		return sortKeys{date: t.Date, amount: t.Amount, description: t.Description, id: t.ID}
+	}
+	txs := db.filterTransactions(func(t database.Transactions) bool {
+		return !t.DeletedAt.Valid && (!arg.Status.Valid || t.Status == arg.Status.String)
+	}, orderBy(arg.SortKey, arg.Descending, keys))
+	ks := keyset{
+		after: sortKeys{date: arg.AfterDate, amount: arg.AfterAmount, description: arg.AfterDescription.String, id: arg.AfterID.Int32},
+		valid: arg.AfterID.Valid,
+		size:  arg.PageSize,
+	}
+	return page(txs, arg.SortKey, arg.Descending, ks, keys), nil
+}
+
+func (db *DB) GetLargestExpenses(ctx context.Context, arg database.GetLargestExpensesParams) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	txs := db.filterTransactions(func(t database.Transactions) bool {
+		return !t.DeletedAt.Valid && t.Type == "expense" && between(t.Date, arg.StartDate, arg.EndDate)
+	}, func(a, b database.Transactions) int {
+		if c := cmp.Compare(a.Currency, b.Currency); c != 0 {
+			return c
+		}
+		if c := cmpNumeric(a.Amount, b.Amount); c != 0 {
+			return c
+		}
+		if c := a.Date.Time.Compare(b.Date.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	out := []database.Transactions{}
+	for _, t := range txs {
+		if len(out) == 0 || out[len(out)-1].Currency != t.Currency {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (db *DB) GetTransactionsByType(ctx context.Context, type_ string) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	return db.listTransactions(func(t database.Transactions) bool { return !t.DeletedAt.Valid && t.Type == type_ }), nil
+}
+
+func (db *DB) InsertBackupTransaction(ctx context.Context, arg database.InsertBackupTransactionParams) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.transactions[arg.ID]; ok {
+		return duplicateKey("transactions")
+	}
+	t := database.Transactions(arg)
+	t.Date = day(t.Date)
+	if err := checkTransaction(db, t); err != nil {
+		return err
+	}
+	db.transactions[t.ID] = t
+	db.changed(syncTransaction, t.ID, true)
+	return nil
+}
+
+func (db *DB) ListDeletedTransactions(ctx context.Context) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	out := db.listTransactionsByID(func(t database.Transactions) bool { return t.DeletedAt.Valid })
+	slices.SortStableFunc(out, func(a, b database.Transactions) int {
+		return b.DeletedAt.Time.Compare(a.DeletedAt.Time)
+	})
+	return out, nil
+}
+
+func (db *DB) ListTransactionsForBackup(ctx context.Context) ([]database.Transactions, error) {
+	db = db.scope(ctx)
+	return db.listTransactionsByID(func(database.Transactions) bool { return true }), nil
+}
+
+// listTransactions returns the matching transactions by date, as the
+// queries' ORDER BY date does, with ties kept in the order they were added.
+func (db *DB) listTransactions(keep func(database.Transactions) bool) []database.Transactions {
+	return db.filterTransactions(keep, func(a, b database.Transactions) int {
+		if c := a.Date.Time.Compare(b.Date.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+func (db *DB) listTransactionsByID(keep func(database.Transactions) bool) []database.Transactions {
+	return db.filterTransactions(keep, func(a, b database.Transactions) int { return cmp.Compare(a.ID, b.ID) })
+}
+
+// filterTransactions returns the transactions keep accepts, sorted by
+// compare. It filters before sorting, since most queries keep a small part
+// of a large table.
+func (db *DB) filterTransactions(keep func(database.Transactions) bool, compare func(a, b database.Transactions) int) []database.Transactions {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	out := []database.Transactions{}
+	for _, t := range db.transactions {
+		if keep(t) {
+			out = append(out, t)
+		}
+	}
+	slices.SortFunc(out, compare)
+	return out
+}
+
+func (db *DB) PurgeDeletedTransactions(ctx context.Context, cutoff pgtype.Timestamp) (int64, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var n int64
+	for id, t := range db.transactions {
+		if t.DeletedAt.Valid && cutoff.Valid && t.DeletedAt.Time.Before(cutoff.Time) {
+			delete(db.transactions, id)
+			db.changed(syncTransaction, id, false)
+			delete(db.taxDetails, id)
+			delete(db.selfEmployed, id)
+			db.deleteAttachmentsOf(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (db *DB) ResetTransactionsSequence(ctx context.Context) error {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	// Archived rows keep their IDs, which new ones mustn't take.
+	db.nextTransaction = max(maxKey(db.transactions), maxKey(db.archived)) + 1
+	return nil
+}
+
+func (db *DB) RestoreTransaction(ctx context.Context, id int32) (database.Transactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, ok := db.transactions[id]
+	if !ok || !t.DeletedAt.Valid {
+		return database.Transactions{}, pgx.ErrNoRows
+	}
+	t.DeletedAt = pgtype.Timestamp{}
+	t.Version++
+	db.transactions[id] = t
+	db.changed(syncTransaction, id, false)
+	return t, nil
+}
+
+func (db *DB) SetTransactionStatus(ctx context.Context, arg database.SetTransactionStatusParams) (database.Transactions, error) {
+	db = db.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, ok := db.transactions[arg.ID]
+	if !ok || t.DeletedAt.Valid || t.Version != arg.Version {
+		return database.Transactions{}, pgx.ErrNoRows
+	}
+	t.Status = arg.Status
+	if err := checkTransaction(db, t); err != nil {
+		return database.Transactions{}, err
+	}
+	t.Version++
+	db.transactions[arg.ID] = t
+	db.changed(syncTransaction, arg.ID, false)
+	return t, nil
+}
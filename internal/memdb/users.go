@@ -0,0 +1,223 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func (db *DB) CountUsersByRole(ctx context.Context, role string) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var n int64
+	for _, u := range db.users {
+		if u.Role == role {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (db *DB) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) error {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.users[arg.UserID]; !ok {
+		return &pgconn.PgError{
+			Code:           "23503",
+			Message:        `insert or update on table "refresh_tokens" violates foreign key constraint "refresh_tokens_user_id_fkey"`,
+			TableName:      "refresh_tokens",
+			ConstraintName: "refresh_tokens_user_id_fkey",
+		}
+	}
+	if _, ok := db.refreshTokens[arg.TokenHash]; ok {
+		return duplicateKey("refresh_tokens")
+	}
+	db.refreshTokens[arg.TokenHash] = database.RefreshTokens{
+		TokenHash: arg.TokenHash,
+		UserID:    arg.UserID,
+		Family:    arg.Family,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: now(),
+	}
+	return nil
+}
+
+func (db *DB) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.Users, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	u := database.Users{
+		ID:           db.nextUser,
+		Username:     arg.Username,
+		PasswordHash: arg.PasswordHash,
+		CreatedAt:    now(),
+		Role:         arg.Role,
+	}
+	if err := checkUser(db, u); err != nil {
+		return database.Users{}, err
+	}
+	db.nextUser++
+	db.users[u.ID] = u
+	return u, nil
+}
+
+func (db *DB) DeleteExpiredRefreshTokens(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var n int64
+	for hash, t := range db.refreshTokens {
+		if expiresAt.Valid && t.ExpiresAt.Time.Before(expiresAt.Time) {
+			delete(db.refreshTokens, hash)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (db *DB) DeleteUser(ctx context.Context, id int32) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.users[id]; !ok {
+		return 0, nil
+	}
+	delete(db.users, id)
+	for hash, t := range db.refreshTokens {
+		if t.UserID == id {
+			delete(db.refreshTokens, hash)
+		}
+	}
+	for key := range db.members {
+		if key.user == id {
+			delete(db.members, key)
+		}
+	}
+	for invID, inv := range db.invitations {
+		if inv.InvitedBy.Valid && inv.InvitedBy.Int32 == id {
+			inv.InvitedBy = pgtype.Int4{}
+		}
+		if inv.AcceptedBy.Valid && inv.AcceptedBy.Int32 == id {
+			inv.AcceptedBy = pgtype.Int4{}
+		}
+		db.invitations[invID] = inv
+	}
+	return 1, nil
+}
+
+func (db *DB) GetRefreshToken(ctx context.Context, tokenHash string) (database.RefreshTokens, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, ok := db.refreshTokens[tokenHash]
+	if !ok {
+		return database.RefreshTokens{}, pgx.ErrNoRows
+	}
+	return t, nil
+}
+
+func (db *DB) GetUserByID(ctx context.Context, id int32) (database.Users, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	u, ok := db.users[id]
+	if !ok {
+		return database.Users{}, pgx.ErrNoRows
+	}
+	return u, nil
+}
+
+func (db *DB) GetUserByUsername(ctx context.Context, username string) (database.Users, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, u := range db.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return database.Users{}, pgx.ErrNoRows
+}
+
+func (db *DB) ListUsers(ctx context.Context) ([]database.Users, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return sortedByID(db.users, func(u database.Users) int32 { return u.ID }), nil
+}
+
+func (db *DB) RevokeRefreshToken(ctx context.Context, tokenHash string) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, ok := db.refreshTokens[tokenHash]
+	if !ok || t.RevokedAt.Valid {
+		return 0, nil
+	}
+	t.RevokedAt = now()
+	db.refreshTokens[tokenHash] = t
+	return 1, nil
+}
+
+func (db *DB) RevokeRefreshTokenFamily(ctx context.Context, family string) error {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.revokeTokens(func(t database.RefreshTokens) bool { return t.Family == family })
+	return nil
+}
+
+func (db *DB) RevokeUserRefreshTokens(ctx context.Context, userID int32) error {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.revokeTokens(func(t database.RefreshTokens) bool { return t.UserID == userID })
+	return nil
+}
+
+func (db *DB) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	u, ok := db.users[arg.ID]
+	if !ok {
+		return 0, nil
+	}
+	u.PasswordHash = arg.PasswordHash
+	db.users[u.ID] = u
+	return 1, nil
+}
+
+func (db *DB) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (int64, error) {
+	db = db.shared(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	u, ok := db.users[arg.ID]
+	if !ok {
+		return 0, nil
+	}
+	u.Role = arg.Role
+	if err := checkUser(db, u); err != nil {
+		return 0, err
+	}
+	db.users[u.ID] = u
+	return 1, nil
+}
+
+// revokeTokens revokes the live refresh tokens that match. The caller holds
+// db.mu.
+func (db *DB) revokeTokens(match func(database.RefreshTokens) bool) {
+	at := now()
+	for hash, t := range db.refreshTokens {
+		if !t.RevokedAt.Valid && match(t) {
+			t.RevokedAt = at
+			db.refreshTokens[hash] = t
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package notify sends short messages to chat services. A Channel delivers
+// a Message to one destination, such as a Slack channel through an
+// incoming webhook or a Telegram chat through a bot; what to send and when
+// is up to the caller.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Channel types.
+const (
+	TypeSlack    = "slack"
+	TypeTelegram = "telegram"
+)
+
+// ErrInvalidConfig is returned by Open for a Config it can't use.
+var ErrInvalidConfig = errors.New("invalid notification channel")
+
+// Message is a notification. Title is shown in bold above Text.
+type Message struct {
+	Title string
+	Text  string
+}
+
+func (m Message) plain() string {
+	if m.Title == "" {
+		return m.Text
+	}
+	return m.Title + "\n" + m.Text
+}
+
+// Channel delivers messages to one destination. Implementations are safe
+// for concurrent use.
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config chooses and configures a Channel. Only the fields for Type are
+// used: WebhookURL for Slack, BotToken and ChatID for Telegram.
+type Config struct {
+	Type       string `json:"type"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+	BotToken   string `json:"bot_token,omitempty"`
+	ChatID     string `json:"chat_id,omitempty"`
+}
+
+// Open returns the Channel cfg describes. A nil client gets one with a
+// short timeout.
+func Open(cfg Config, client *http.Client) (Channel, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	switch cfg.Type {
+	case TypeSlack:
+		return &Slack{WebhookURL: cfg.WebhookURL, Client: client}, nil
+	default:
+		return &Telegram{BotToken: cfg.BotToken, ChatID: cfg.ChatID, Client: client}, nil
+	}
+}
+
+// Validate checks that cfg has what its Type needs.
+func (cfg Config) Validate() error {
+	switch cfg.Type {
+	case TypeSlack:
+		u, err := url.Parse(cfg.WebhookURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%w: webhook_url must be an http(s) URL", ErrInvalidConfig)
+		}
+	case TypeTelegram:
+		if strings.TrimSpace(cfg.BotToken) == "" {
+			return fmt.Errorf("%w: bot_token is required", ErrInvalidConfig)
+		}
+		if strings.TrimSpace(cfg.ChatID) == "" {
+			return fmt.Errorf("%w: chat_id is required", ErrInvalidConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q (expected slack or telegram)", ErrInvalidConfig, cfg.Type)
+	}
+	return nil
+}
+
+// Redacted is cfg with its secrets hidden, fit to show back to a user: the
+// webhook keeps only its host and the bot token its last four characters.
+func (cfg Config) Redacted() Config {
+	out := Config{Type: cfg.Type, ChatID: cfg.ChatID}
+	if cfg.WebhookURL != "" {
+		if u, err := url.Parse(cfg.WebhookURL); err == nil && u.Host != "" {
+			out.WebhookURL = u.Scheme + "://" + u.Host + "/…"
+		} else {
+			out.WebhookURL = "…"
+		}
+	}
+	if cfg.BotToken != "" {
+		out.BotToken = "…"
+		if n := len(cfg.BotToken); n > 8 {
+			out.BotToken += cfg.BotToken[n-4:]
+		}
+	}
+	return out
+}
+
+// Slack posts to a Slack incoming webhook.
+type Slack struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+func (s *Slack) Send(ctx context.Context, msg Message) error {
+	text := msg.Text
+	if msg.Title != "" {
+		text = "*" + msg.Title + "*\n" + msg.Text
+	}
+	return postJSON(ctx, s.Client, s.WebhookURL, map[string]string{"text": text})
+}
+
+// TelegramAPI is where Telegram's Bot API is served.
+const TelegramAPI = "https://api.telegram.org"
+
+// Telegram sends through a Telegram bot to one chat. BaseURL defaults to
+// TelegramAPI.
+type Telegram struct {
+	BotToken string
+	ChatID   string
+	BaseURL  string
+	Client   *http.Client
+}
+
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	base := t.BaseURL
+	if base == "" {
+		base = TelegramAPI
+	}
+	return postJSON(ctx, t.Client, base+"/bot"+t.BotToken+"/sendMessage", map[string]string{
+		"chat_id": t.ChatID,
+		"text":    msg.plain(),
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may hold a secret; don't repeat it.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("send notification: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("send notification: %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlack(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/T0/B0/secret", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	ch, err := Open(Config{Type: TypeSlack, WebhookURL: srv.URL + "/services/T0/B0/secret"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, ch.Send(context.Background(), Message{Title: "Low balance", Text: "Down to $12.00"}))
+	assert.Equal(t, "*Low balance*\nDown to $12.00", got["text"])
+}
+
+func TestTelegram(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bot123:abc/sendMessage", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	ch := &Telegram{BotToken: "123:abc", ChatID: "-42", BaseURL: srv.URL, Client: srv.Client()}
+	require.NoError(t, ch.Send(context.Background(), Message{Title: "Rent due", Text: "$1200.00 on Sep 1"}))
+	assert.Equal(t, "-42", got["chat_id"])
+	assert.Equal(t, "Rent due\n$1200.00 on Sep 1", got["text"])
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	ch := &Slack{WebhookURL: srv.URL, Client: srv.Client()}
+	err := ch.Send(context.Background(), Message{Text: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+	assert.Contains(t, err.Error(), "invalid_token")
+
+	ch = &Slack{WebhookURL: "http://127.0.0.1:1/hooks/secret", Client: srv.Client()}
+	err = ch.Send(context.Background(), Message{Text: "hi"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret", "the URL isn't repeated")
+}
+
+func TestConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Type: "email"},
+		{Type: TypeSlack},
+		{Type: TypeSlack, WebhookURL: "hooks.slack.com/services/x"},
+		{Type: TypeTelegram, ChatID: "1"},
+		{Type: TypeTelegram, BotToken: "123:abc"},
+	} {
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidConfig, "%+v", cfg)
+	}
+
+	r := Config{Type: TypeSlack, WebhookURL: "https://hooks.slack.com/services/T0/B0/secret"}.Redacted()
+	assert.Equal(t, "https://hooks.slack.com/…", r.WebhookURL)
+	r = Config{Type: TypeTelegram, BotToken: "123456:ABCDEFGHIJ", ChatID: "-42"}.Redacted()
+	assert.Equal(t, "…GHIJ", r.BotToken)
+	assert.Equal(t, "-42", r.ChatID)
+}
@@ -0,0 +1,555 @@
+// Package rrule evaluates iCalendar recurrence rules (RFC 5545, section
+// 3.3.10) on whole days, for schedules the fixed intervals can't express:
+// "the last weekday of the month", "every other month on the 1st and
+// 15th", "the second Tuesday of March, June, September and December".
+//
+// FREQ is DAILY, WEEKLY, MONTHLY or YEARLY, with INTERVAL, COUNT, UNTIL,
+// BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS and WKST. Rule parts below a day
+// (BYHOUR and the like) and BYYEARDAY and BYWEEKNO are rejected rather than
+// ignored. As in most implementations, the start date is an occurrence only
+// if the rule matches it.
+package rrule
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is a rule's FREQ: the period its other parts pick days from.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// horizonYears bounds how far past the start a rule is followed, so one
+// that never matches, such as February 30th, still ends.
+const horizonYears = 200
+
+// Day is one BYDAY entry: a weekday, and for MONTHLY and YEARLY rules
+// optionally which one of the month or year (2 for the second, -1 for the
+// last). N is 0 for every one.
+type Day struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule is a parsed recurrence rule.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count ends the rule after that many occurrences; 0 for no limit.
+	Count int
+	// Until is the last day an occurrence may fall on; zero for no limit.
+	Until      time.Time
+	ByDay      []Day
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Parse reads a rule such as "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1".
+// A leading "RRULE:" is allowed, and case doesn't matter.
+func Parse(s string) (Rule, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "RRULE:")
+	if s == "" {
+		return Rule{}, fmt.Errorf("empty rule")
+	}
+	r := Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Rule{}, fmt.Errorf("invalid rule part %q", part)
+		}
+		if seen[key] {
+			return Rule{}, fmt.Errorf("%s given twice", key)
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			switch r.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = fmt.Errorf("unsupported FREQ %q (expected DAILY, WEEKLY, MONTHLY or YEARLY)", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = positive(key, value)
+		case "COUNT":
+			r.Count, err = positive(key, value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseDays(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(key, value, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(key, value, 12)
+			for _, m := range months {
+				if m < 0 {
+					err = fmt.Errorf("invalid BYMONTH %q", strconv.Itoa(m))
+					break
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			r.BySetPos, err = parseInts(key, value, 366)
+		case "WKST":
+			wd, ok := weekdays[value]
+			if !ok {
+				err = fmt.Errorf("invalid WKST %q", value)
+			}
+			r.WeekStart = wd
+		case "BYYEARDAY", "BYWEEKNO", "BYHOUR", "BYMINUTE", "BYSECOND":
+			err = fmt.Errorf("%s is not supported", key)
+		default:
+			err = fmt.Errorf("unknown rule part %s", key)
+		}
+		if err != nil {
+			return Rule{}, err
+		}
+	}
+	return r, r.check()
+}
+
+// check rejects combinations RFC 5545 rules out.
+func (r Rule) check() error {
+	switch {
+	case r.Freq == "":
+		return fmt.Errorf("FREQ is required")
+	case r.Count > 0 && !r.Until.IsZero():
+		return fmt.Errorf("set COUNT or UNTIL, not both")
+	case r.Freq == Weekly && len(r.ByMonthDay) > 0:
+		return fmt.Errorf("BYMONTHDAY can't be used with FREQ=WEEKLY")
+	case len(r.BySetPos) > 0 && len(r.ByDay)+len(r.ByMonthDay)+len(r.ByMonth) == 0:
+		return fmt.Errorf("BYSETPOS needs another BY rule part")
+	}
+	// A numbered BYDAY counts within the month, or the year for a yearly
+	// rule without BYMONTH.
+	limit := 5
+	if r.Freq == Yearly && len(r.ByMonth) == 0 {
+		limit = 53
+	}
+	for _, d := range r.ByDay {
+		if d.N == 0 {
+			continue
+		}
+		if r.Freq != Monthly && r.Freq != Yearly {
+			return fmt.Errorf("numbered BYDAY needs FREQ=MONTHLY or YEARLY")
+		}
+		if d.N < -limit || d.N > limit {
+			return fmt.Errorf("invalid BYDAY position %d", d.N)
+		}
+	}
+	return nil
+}
+
+func positive(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return n, nil
+}
+
+// parseInts reads a comma-separated list of non-zero integers within
+// ±limit.
+func parseInts(key, value string, limit int) ([]int, error) {
+	var out []int
+	for _, s := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n == 0 || n < -limit || n > limit {
+			return nil, fmt.Errorf("invalid %s %q", key, s)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func parseDays(value string) ([]Day, error) {
+	var out []Day
+	for _, s := range strings.Split(value, ",") {
+		if len(s) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", s)
+		}
+		wd, ok := weekdays[s[len(s)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", s)
+		}
+		d := Day{Weekday: wd}
+		if pos := s[:len(s)-2]; pos != "" {
+			n, err := strconv.Atoi(pos)
+			if err != nil || n == 0 {
+				return nil, fmt.Errorf("invalid BYDAY %q", s)
+			}
+			d.N = n
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// parseUntil reads a DATE or DATE-TIME, keeping only the day.
+func parseUntil(value string) (time.Time, error) {
+	if len(value) >= 8 {
+		if t, err := time.Parse("20060102", value[:8]); err == nil {
+			if rest := value[8:]; rest == "" || len(rest) >= 7 && rest[0] == 'T' {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// String returns the rule in its canonical form, leaving out parts that
+// have their default value.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	if len(r.ByMonth) > 0 {
+		var ms []int
+		for _, m := range r.ByMonth {
+			ms = append(ms, int(m))
+		}
+		parts = append(parts, "BYMONTH="+joinInts(ms))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByDay) > 0 {
+		var ds []string
+		for _, d := range r.ByDay {
+			s := dayName(d.Weekday)
+			if d.N != 0 {
+				s = strconv.Itoa(d.N) + s
+			}
+			ds = append(ds, s)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(ds, ","))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+dayName(r.WeekStart))
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+func dayName(wd time.Weekday) string {
+	return strings.ToUpper(wd.String()[:2])
+}
+
+// Each calls fn with every occurrence on or after start, in order, until
+// fn returns false or the rule ends. Only the calendar day of start is
+// used.
+func (r Rule) Each(start time.Time, fn func(time.Time) bool) {
+	start = truncate(start)
+	r.each(start, 0, start.AddDate(horizonYears, 0, 0), fn)
+}
+
+// Between returns the occurrences of the rule starting at start that fall
+// within [from, to].
+func (r Rule) Between(start, from, to time.Time) []time.Time {
+	start, from, to = truncate(start), truncate(from), truncate(to)
+	// Without a COUNT the periods before from don't matter, so skip them.
+	skip := 0
+	if r.Count == 0 && from.After(start) {
+		skip = r.periodsBefore(start, from)
+	}
+	var out []time.Time
+	r.each(start, skip, to, func(d time.Time) bool {
+		if d.After(to) {
+			return false
+		}
+		if !d.Before(from) {
+			out = append(out, d)
+		}
+		return true
+	})
+	return out
+}
+
+// each walks the rule's periods from the skip-th up to the one holding
+// last, calling fn with each occurrence.
+func (r Rule) each(start time.Time, skip int, last time.Time, fn func(time.Time) bool) {
+	interval := max(r.Interval, 1)
+	horizon := start.AddDate(horizonYears, 0, 0)
+	if last.After(horizon) {
+		last = horizon
+	}
+	first := r.periodStart(start)
+	count := 0
+	for k := skip; ; k++ {
+		period := r.advance(first, k*interval)
+		if period.After(last) {
+			return
+		}
+		for _, d := range r.setPos(r.candidates(start, period)) {
+			if d.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && d.After(r.Until) {
+				return
+			}
+			if !fn(d) {
+				return
+			}
+			if count++; r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// periodStart returns the first day of the period containing d.
+func (r Rule) periodStart(d time.Time) time.Time {
+	switch r.Freq {
+	case Weekly:
+		return d.AddDate(0, 0, -((int(d.Weekday()) - int(r.WeekStart) + 7) % 7))
+	case Monthly:
+		return time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case Yearly:
+		return time.Date(d.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return d
+}
+
+// advance moves a period start n periods on.
+func (r Rule) advance(p time.Time, n int) time.Time {
+	switch r.Freq {
+	case Weekly:
+		return p.AddDate(0, 0, 7*n)
+	case Monthly:
+		return p.AddDate(0, n, 0)
+	case Yearly:
+		return p.AddDate(n, 0, 0)
+	}
+	return p.AddDate(0, 0, n)
+}
+
+// periodsBefore returns how many whole intervals of periods lie between
+// start's period and the one containing from, in periods.
+func (r Rule) periodsBefore(start, from time.Time) int {
+	a, b := r.periodStart(start), r.periodStart(from)
+	var n int
+	switch r.Freq {
+	case Daily:
+		n = int(b.Sub(a).Hours() / 24)
+	case Weekly:
+		n = int(b.Sub(a).Hours()/24) / 7
+	case Monthly:
+		n = (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+	case Yearly:
+		n = b.Year() - a.Year()
+	}
+	interval := max(r.Interval, 1)
+	return n / interval
+}
+
+// candidates returns, sorted, the days of the period starting at p that
+// the BY rule parts pick, before BYSETPOS. Defaults come from start.
+func (r Rule) candidates(start, p time.Time) []time.Time {
+	var out []time.Time
+	switch r.Freq {
+	case Daily:
+		if r.monthOK(p.Month()) && r.monthDayOK(p) && r.weekdayOK(p.Weekday()) {
+			out = append(out, p)
+		}
+	case Weekly:
+		for i := range 7 {
+			d := p.AddDate(0, 0, i)
+			want := d.Weekday() == start.Weekday()
+			if len(r.ByDay) > 0 {
+				want = r.weekdayOK(d.Weekday())
+			}
+			if want && r.monthOK(d.Month()) {
+				out = append(out, d)
+			}
+		}
+	case Monthly:
+		if r.monthOK(p.Month()) {
+			out = r.inMonth(start, p.Year(), p.Month())
+		}
+	case Yearly:
+		out = r.inYear(start, p.Year())
+	}
+	return out
+}
+
+// inMonth picks days of one month by BYMONTHDAY and BYDAY, or start's day
+// of the month when neither is given.
+func (r Rule) inMonth(start time.Time, y int, m time.Month) []time.Time {
+	last := daysIn(y, m)
+	var out []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, n := range r.ByMonthDay {
+			if n < 0 {
+				n += last + 1
+			}
+			if n >= 1 && n <= last {
+				d := date(y, m, n)
+				if r.weekdayOK(d.Weekday()) {
+					out = append(out, d)
+				}
+			}
+		}
+	case len(r.ByDay) > 0:
+		for _, bd := range r.ByDay {
+			out = append(out, nthInRange(date(y, m, 1), last, bd)...)
+		}
+	default:
+		if start.Day() <= last {
+			out = append(out, date(y, m, start.Day()))
+		}
+	}
+	return sortUnique(out)
+}
+
+// inYear picks days of one year. BYMONTH narrows it to months, each then
+// picked from as in a monthly rule; without it BYMONTHDAY applies to every
+// month and BYDAY to the whole year.
+func (r Rule) inYear(start time.Time, y int) []time.Time {
+	var out []time.Time
+	switch {
+	case len(r.ByMonth) > 0:
+		for _, m := range r.ByMonth {
+			out = append(out, r.inMonth(start, y, m)...)
+		}
+	case len(r.ByMonthDay) > 0:
+		for m := time.January; m <= time.December; m++ {
+			out = append(out, r.inMonth(start, y, m)...)
+		}
+	case len(r.ByDay) > 0:
+		days := 365
+		if daysIn(y, time.February) == 29 {
+			days = 366
+		}
+		for _, bd := range r.ByDay {
+			out = append(out, nthInRange(date(y, 1, 1), days, bd)...)
+		}
+	default:
+		if start.Day() <= daysIn(y, start.Month()) {
+			out = append(out, date(y, start.Month(), start.Day()))
+		}
+	}
+	return sortUnique(out)
+}
+
+// nthInRange returns the days among the n days from first that match bd:
+// every one with bd's weekday, or only the bd.N-th (from the end when
+// negative).
+func nthInRange(first time.Time, n int, bd Day) []time.Time {
+	var all []time.Time
+	d := first.AddDate(0, 0, (int(bd.Weekday)-int(first.Weekday())+7)%7)
+	for end := first.AddDate(0, 0, n); d.Before(end); d = d.AddDate(0, 0, 7) {
+		all = append(all, d)
+	}
+	switch {
+	case bd.N == 0:
+		return all
+	case bd.N > 0 && bd.N <= len(all):
+		return all[bd.N-1 : bd.N]
+	case bd.N < 0 && -bd.N <= len(all):
+		i := len(all) + bd.N
+		return all[i : i+1]
+	}
+	return nil
+}
+
+// setPos keeps the BYSETPOS-th days of a period's candidates.
+func (r Rule) setPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return days
+	}
+	var out []time.Time
+	for _, p := range r.BySetPos {
+		i := p - 1
+		if p < 0 {
+			i = len(days) + p
+		}
+		if i >= 0 && i < len(days) {
+			out = append(out, days[i])
+		}
+	}
+	return sortUnique(out)
+}
+
+func (r Rule) monthOK(m time.Month) bool {
+	return len(r.ByMonth) == 0 || slices.Contains(r.ByMonth, m)
+}
+
+func (r Rule) weekdayOK(wd time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) monthDayOK(d time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := daysIn(d.Year(), d.Month())
+	for _, n := range r.ByMonthDay {
+		if n == d.Day() || n < 0 && n+last+1 == d.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+func sortUnique(days []time.Time) []time.Time {
+	slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(days, func(a, b time.Time) bool { return a.Equal(b) })
+}
+
+func daysIn(y int, m time.Month) int {
+	return date(y, m+1, 1).AddDate(0, 0, -1).Day()
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func truncate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
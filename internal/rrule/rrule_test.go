@@ -0,0 +1,204 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func days(ts []time.Time) []string {
+	var out []string
+	for _, t := range ts {
+		out = append(out, t.Format("2006-01-02"))
+	}
+	return out
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		start    string
+		from, to string
+		want     []string
+	}{
+		{
+			name:  "last weekday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-06-30",
+			want: []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30", "2025-05-30", "2025-06-30"},
+		},
+		{
+			name:  "every other month on the 1st and 15th",
+			rule:  "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=1,15",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-06-30",
+			want: []string{"2025-01-01", "2025-01-15", "2025-03-01", "2025-03-15", "2025-05-01", "2025-05-15"},
+		},
+		{
+			name:  "2nd Tuesday of each quarter's last month",
+			rule:  "FREQ=YEARLY;BYMONTH=3,6,9,12;BYDAY=2TU",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-03-11", "2025-06-10", "2025-09-09", "2025-12-09"},
+		},
+		{
+			name:  "biweekly on two days",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-01-31",
+			want: []string{"2025-01-02", "2025-01-13", "2025-01-16", "2025-01-27", "2025-01-30"},
+		},
+		{
+			name:  "count",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: "2025-01-30", from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-01-30", "2025-01-31", "2025-02-01"},
+		},
+		{
+			name:  "count runs from the start, not the window",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: "2025-01-30", from: "2025-01-31", to: "2025-12-31",
+			want: []string{"2025-01-31", "2025-02-01"},
+		},
+		{
+			name:  "until is inclusive",
+			rule:  "FREQ=WEEKLY;UNTIL=20250115T000000Z",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-01-01", "2025-01-08", "2025-01-15"},
+		},
+		{
+			name:  "the 31st skips short months",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-06-30",
+			want: []string{"2025-01-31", "2025-03-31", "2025-05-31"},
+		},
+		{
+			name:  "last day of the month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-04-30",
+			want: []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"},
+		},
+		{
+			name:  "leap day",
+			rule:  "FREQ=YEARLY",
+			start: "2024-02-29", from: "2024-01-01", to: "2028-12-31",
+			want: []string{"2024-02-29", "2028-02-29"},
+		},
+		{
+			name:  "last Friday of the year",
+			rule:  "FREQ=YEARLY;BYDAY=-1FR",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-12-26"},
+		},
+		{
+			name:  "Friday the 13th",
+			rule:  "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-06-13"},
+		},
+		{
+			name:  "start only counts if it matches",
+			rule:  "FREQ=MONTHLY;BYDAY=1MO",
+			start: "2025-01-01", from: "2025-01-01", to: "2025-02-28",
+			want: []string{"2025-01-06", "2025-02-03"},
+		},
+		{
+			name:  "never matches",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			start: "2025-01-01", from: "2025-01-01", to: "2035-12-31",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, days(r.Between(day(tt.start), day(tt.from), day(tt.to))))
+		})
+	}
+}
+
+func TestBetweenMatchesFullExpansion(t *testing.T) {
+	rules := []string{
+		"FREQ=DAILY;INTERVAL=3",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,SA;WKST=SU",
+		"FREQ=MONTHLY;INTERVAL=5;BYDAY=-2WE",
+		"FREQ=YEARLY;INTERVAL=2;BYMONTHDAY=1,-1",
+	}
+	start := day("2024-11-20")
+	for _, s := range rules {
+		r, err := Parse(s)
+		require.NoError(t, err)
+		full := r.Between(start, start, day("2028-12-31"))
+		require.NotEmpty(t, full, s)
+
+		// A window starting later sees the matching part of the full list.
+		for offset := 0; offset < 800; offset += 37 {
+			from := start.AddDate(0, 0, offset)
+			to := from.AddDate(0, 3, 0)
+			var want []string
+			for _, d := range full {
+				if !d.Before(from) && !d.After(to) {
+					want = append(want, d.Format("2006-01-02"))
+				}
+			}
+			assert.Equal(t, want, days(r.Between(start, from, to)), "%s from %s", s, from.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestEachStops(t *testing.T) {
+	r, err := Parse("FREQ=MONTHLY;BYMONTHDAY=15")
+	require.NoError(t, err)
+	var got []time.Time
+	r.Each(day("2025-01-20"), func(d time.Time) bool {
+		got = append(got, d)
+		return len(got) < 2
+	})
+	assert.Equal(t, []string{"2025-02-15", "2025-03-15"}, days(got))
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse(" rrule:freq=monthly;byday=-1fr;interval=1 ")
+	require.NoError(t, err)
+	assert.Equal(t, Monthly, r.Freq)
+	assert.Equal(t, []Day{{N: -1, Weekday: time.Friday}}, r.ByDay)
+	assert.Equal(t, "FREQ=MONTHLY;BYDAY=-1FR", r.String())
+
+	r, err = Parse("FREQ=WEEKLY;INTERVAL=2;UNTIL=20251231;BYMONTH=1,2;BYDAY=MO,FR;WKST=SU")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;UNTIL=20251231;BYMONTH=1,2;BYDAY=MO,FR;WKST=SU", r.String())
+
+	bad := map[string]string{
+		"":                                    "empty rule",
+		"BYDAY=MO":                            "FREQ is required",
+		"FREQ=HOURLY":                         "unsupported FREQ",
+		"FREQ=DAILY;BYHOUR=9":                 "BYHOUR is not supported",
+		"FREQ=DAILY;FOO=1":                    "unknown rule part FOO",
+		"FREQ=DAILY;FREQ=WEEKLY":              "FREQ given twice",
+		"FREQ=MONTHLY;INTERVAL=0":             "invalid INTERVAL",
+		"FREQ=MONTHLY;COUNT=2;UNTIL=20250101": "COUNT or UNTIL",
+		"FREQ=MONTHLY;UNTIL=2025-01-01":       "invalid UNTIL",
+		"FREQ=WEEKLY;BYDAY=2FR":               "numbered BYDAY",
+		"FREQ=MONTHLY;BYDAY=6FR":              "invalid BYDAY position 6",
+		"FREQ=MONTHLY;BYDAY=XX":               "invalid BYDAY",
+		"FREQ=MONTHLY;BYMONTHDAY=32":          "invalid BYMONTHDAY",
+		"FREQ=WEEKLY;BYMONTHDAY=1":            "FREQ=WEEKLY",
+		"FREQ=YEARLY;BYMONTH=-1":              "invalid BYMONTH",
+		"FREQ=MONTHLY;BYSETPOS=1":             "BYSETPOS needs",
+		"FREQ=MONTHLY;WKST=XX":                "invalid WKST",
+		"FREQ=MONTHLY;BYDAY":                  "invalid rule part",
+	}
+	for in, want := range bad {
+		_, err := Parse(in)
+		assert.ErrorContains(t, err, want, "%q", in)
+	}
+}
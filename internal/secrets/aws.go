@@ -0,0 +1,142 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jdelles/currentz/internal/sigv4"
+)
+
+// awsSecret reads the Secrets Manager secret id, a name or ARN, with the
+// credentials in the usual AWS_ environment variables. With a field, the
+// secret must hold a JSON object, and that field's value is returned.
+func awsSecret(ctx context.Context, id, field string) (string, error) {
+	var secret struct {
+		SecretString *string
+	}
+	err := awsCall(ctx, "secretsmanager", "secretsmanager.GetSecretValue", awsRegion(id),
+		map[string]string{"SecretId": id}, &secret)
+	if err != nil {
+		return "", fmt.Errorf("aws-sm: get %s: %w", id, err)
+	}
+	if secret.SecretString == nil {
+		return "", fmt.Errorf("aws-sm: %s is a binary secret; only text secrets are supported", id)
+	}
+	if field == "" {
+		return *secret.SecretString, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(*secret.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("aws-sm: %s doesn't hold a JSON object, so it has no field %q", id, field)
+	}
+	v, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("aws-sm: %s has no field %q", id, field)
+	}
+	return jsonString(v), nil
+}
+
+// awsKMS decrypts blob, base64 ciphertext from `aws kms encrypt` or a
+// data key's CiphertextBlob, with AWS KMS. The plaintext is returned in
+// base64, since a data key is raw bytes.
+func awsKMS(ctx context.Context, blob string) (string, error) {
+	var out struct {
+		Plaintext string
+	}
+	err := awsCall(ctx, "kms", "TrentService.Decrypt", awsRegion(""),
+		map[string]string{"CiphertextBlob": blob}, &out)
+	if err != nil {
+		return "", fmt.Errorf("aws-kms: decrypt: %w", err)
+	}
+	if _, err := base64.StdEncoding.DecodeString(out.Plaintext); err != nil {
+		return "", fmt.Errorf("aws-kms: decrypt: unexpected plaintext: %w", err)
+	}
+	return out.Plaintext, nil
+}
+
+// awsEndpointVars are the variables that point each service elsewhere.
+var awsEndpointVars = map[string]string{
+	"secretsmanager": "AWS_ENDPOINT_URL_SECRETS_MANAGER",
+	"kms":            "AWS_ENDPOINT_URL_KMS",
+}
+
+// awsCall makes a request to one of the AWS JSON APIs, such as Secrets
+// Manager's, signed with the credentials in the usual AWS_ environment
+// variables, and decodes the response into out.
+func awsCall(ctx context.Context, service, target, region string, in, out any) error {
+	signer := sigv4.Signer{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Region:          region,
+		Service:         service,
+	}
+	switch {
+	case signer.AccessKeyID == "" || signer.SecretAccessKey == "":
+		return errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY not set")
+	case signer.Region == "":
+		return errors.New("AWS_REGION not set")
+	}
+	endpoint := os.Getenv(awsEndpointVars[service])
+	if endpoint == "" {
+		endpoint = "https://" + service + "." + region + ".amazonaws.com"
+	}
+
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	sum := sha256.Sum256(payload)
+	signer.Sign(req, hex.EncodeToString(sum[:]))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body, &e)
+		if e.Type == "" {
+			e.Type = resp.Status
+		}
+		return fmt.Errorf("%s %s", e.Type, e.Message)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// awsRegion is the region in id if it's an ARN, and otherwise $AWS_REGION
+// or $AWS_DEFAULT_REGION.
+func awsRegion(id string) string {
+	// arn:aws:secretsmanager:<region>:<account>:secret:<name>
+	if parts := strings.Split(id, ":"); len(parts) > 3 && parts[0] == "arn" && parts[3] != "" {
+		return parts[3]
+	}
+	if r := os.Getenv("AWS_REGION"); r != "" {
+		return r
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
@@ -0,0 +1,78 @@
+// Package secrets reads secrets kept outside the config, given a
+// reference to them:
+//
+//	file:/run/secrets/db_password    a file's contents, less the trailing newline
+//	vault:secret/data/currentz#key   a field of a HashiCorp Vault secret
+//	aws-sm:currentz/prod#key         an AWS Secrets Manager secret, or a field of its JSON
+//	aws-kms:AQICAHh...               ciphertext AWS KMS decrypts, returned in base64
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// client fetches from the secret stores. A store that doesn't answer
+// shouldn't hang startup.
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// IsRef reports whether v is a reference rather than a secret.
+func IsRef(v string) bool {
+	scheme, _, ok := strings.Cut(v, ":")
+	if !ok {
+		return false
+	}
+	switch scheme {
+	case "file", "vault", "aws-sm", "aws-kms":
+		return true
+	}
+	return false
+}
+
+// Resolve returns the secret ref refers to. A value that isn't a reference
+// is returned as it is.
+func Resolve(ctx context.Context, ref string) (string, error) {
+	if !IsRef(ref) {
+		return ref, nil
+	}
+	scheme, rest, _ := strings.Cut(ref, ":")
+	switch scheme {
+	case "file":
+		return readFile(rest)
+	case "vault":
+		path, field, ok := strings.Cut(rest, "#")
+		if !ok || path == "" || field == "" {
+			return "", fmt.Errorf("invalid Vault reference %q (expected vault:<path>#<field>)", ref)
+		}
+		return vault(ctx, path, field)
+	case "aws-kms":
+		if rest == "" {
+			return "", fmt.Errorf("invalid AWS KMS reference %q (expected aws-kms:<base64 ciphertext>)", ref)
+		}
+		return awsKMS(ctx, rest)
+	default:
+		id, field, _ := strings.Cut(rest, "#")
+		if id == "" {
+			return "", fmt.Errorf("invalid AWS Secrets Manager reference %q (expected aws-sm:<secret id>[#<field>])", ref)
+		}
+		return awsSecret(ctx, id, field)
+	}
+}
+
+// readFile reads a secret from a file, such as a mounted Docker or
+// Kubernetes secret, dropping the newline editors leave at the end.
+func readFile(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("invalid file reference (expected file:<path>)")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
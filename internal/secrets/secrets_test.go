@@ -0,0 +1,152 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePlainAndFile(t *testing.T) {
+	v, err := Resolve(context.Background(), "hunter2")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	// Not one of the schemes: a password can have a colon in it.
+	v, err = Resolve(context.Background(), "pass:word")
+	require.NoError(t, err)
+	assert.Equal(t, "pass:word", v)
+
+	path := filepath.Join(t.TempDir(), "db_password")
+	require.NoError(t, os.WriteFile(path, []byte("s3cret \n"), 0o600))
+	v, err = Resolve(context.Background(), "file:"+path)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret ", v, "only the trailing newline goes")
+
+	_, err = Resolve(context.Background(), "file:"+filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/currentz":
+			_, _ = w.Write([]byte(`{"data":{"data":{"db_password":"from-kv2","port":5432},"metadata":{}}}`))
+		case "/v1/kv/currentz":
+			_, _ = w.Write([]byte(`{"data":{"db_password":"from-kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+
+	ctx := context.Background()
+	v, err := Resolve(ctx, "vault:secret/data/currentz#db_password")
+	require.NoError(t, err)
+	assert.Equal(t, "from-kv2", v)
+	v, err = Resolve(ctx, "vault:secret/data/currentz#port")
+	require.NoError(t, err)
+	assert.Equal(t, "5432", v)
+	v, err = Resolve(ctx, "vault:kv/currentz#db_password")
+	require.NoError(t, err)
+	assert.Equal(t, "from-kv1", v)
+
+	_, err = Resolve(ctx, "vault:secret/data/currentz#nope")
+	assert.ErrorContains(t, err, `no field "nope"`)
+	_, err = Resolve(ctx, "vault:secret/data/missing#db_password")
+	assert.ErrorContains(t, err, "404")
+	_, err = Resolve(ctx, "vault:secret/data/currentz")
+	assert.ErrorContains(t, err, "expected vault:<path>#<field>")
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	_, err = Resolve(ctx, "vault:secret/data/currentz#db_password")
+	assert.ErrorContains(t, err, "permission denied")
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		var body struct{ SecretId string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch body.SecretId {
+		case "currentz/prod":
+			_, _ = w.Write([]byte(`{"Name":"currentz/prod","SecretString":"{\"password\":\"from-aws\"}"}`))
+		case "plain":
+			_, _ = w.Write([]byte(`{"Name":"plain","SecretString":"just-text"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	ctx := context.Background()
+	v, err := Resolve(ctx, "aws-sm:currentz/prod#password")
+	require.NoError(t, err)
+	assert.Equal(t, "from-aws", v)
+	assert.Equal(t, "secretsmanager.GetSecretValue", got.Header.Get("X-Amz-Target"))
+	assert.True(t, strings.HasPrefix(got.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+	assert.Contains(t, got.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request")
+
+	v, err = Resolve(ctx, "aws-sm:plain")
+	require.NoError(t, err)
+	assert.Equal(t, "just-text", v)
+	_, err = Resolve(ctx, "aws-sm:plain#password")
+	assert.ErrorContains(t, err, "doesn't hold a JSON object")
+	_, err = Resolve(ctx, "aws-sm:missing")
+	assert.ErrorContains(t, err, "ResourceNotFoundException")
+
+	// An ARN names its own region.
+	_, err = Resolve(ctx, "aws-sm:arn:aws:secretsmanager:us-east-2:123456789012:secret:currentz/prod-AbCdEf#password")
+	assert.Error(t, err)
+	assert.Contains(t, got.Header.Get("Authorization"), "/us-east-2/secretsmanager/aws4_request")
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	_, err = Resolve(ctx, "aws-sm:currentz/prod#password")
+	assert.ErrorContains(t, err, "AWS_ACCESS_KEY_ID")
+}
+
+func TestAWSKMS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ CiphertextBlob string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.Header.Get("X-Amz-Target") != "TrentService.Decrypt" || body.CiphertextBlob != "c2VhbGVk" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"InvalidCiphertextException"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"KeyId":"arn:aws:kms:eu-west-1:123456789012:key/1","Plaintext":"ZGF0YSBrZXk="}`))
+	}))
+	defer srv.Close()
+	t.Setenv("AWS_ENDPOINT_URL_KMS", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	v, err := Resolve(context.Background(), "aws-kms:c2VhbGVk")
+	require.NoError(t, err)
+	assert.Equal(t, "ZGF0YSBrZXk=", v)
+	_, err = Resolve(context.Background(), "aws-kms:b3RoZXI=")
+	assert.ErrorContains(t, err, "InvalidCiphertextException")
+}
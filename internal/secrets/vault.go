@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// vault reads field from the secret at path, an API path without the
+// leading /v1 such as secret/data/currentz. The server is $VAULT_ADDR and
+// the token $VAULT_TOKEN, or the one `vault login` saved in ~/.vault-token.
+func vault(ctx context.Context, path, field string) (string, error) {
+	addr := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return "", errors.New("vault: VAULT_ADDR not set")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(body, &e)
+		return "", fmt.Errorf("vault: read %s: %s %s", path, resp.Status, strings.Join(e.Errors, "; "))
+	}
+
+	// A KV version 2 secret nests its fields one level down, under
+	// data.data; version 1 and most other engines keep them in data.
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("vault: read %s: %w", path, err)
+	}
+	fields := secret.Data
+	if nested, ok := fields["data"]; ok {
+		var kv2 map[string]json.RawMessage
+		if json.Unmarshal(nested, &kv2) == nil {
+			fields = kv2
+		}
+	}
+	v, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("vault: %s has no field %q", path, field)
+	}
+	return jsonString(v), nil
+}
+
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+	return "", errors.New("vault: VAULT_TOKEN not set")
+}
+
+// jsonString is v as text: strings without their quotes, anything else as
+// written.
+func jsonString(v json.RawMessage) string {
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	return string(v)
+}
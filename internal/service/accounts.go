@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// Account types. Checking and savings accounts are part of the cash balance;
+// credit card purchases reach it when the statement is paid.
+const (
+	AccountChecking   = "checking"
+	AccountSavings    = "savings"
+	AccountCreditCard = "credit_card"
+)
+
+// EntityAccount is the audit entity for account changes.
+const EntityAccount = "account"
+
+var (
+	// ErrInvalidAccount is returned for account definitions that do not
+	// validate and for transactions and recurrings naming an account that
+	// does not exist.
+	ErrInvalidAccount = errors.New("invalid account")
+	// ErrAccountNotFound is returned when looking up an unknown account.
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrAccountInUse is returned when deleting an account that still has
+	// transactions or recurrings, including ones in the trash.
+	ErrAccountInUse = errors.New("account has transactions")
+)
+
+type Account = database.Accounts
+
+// AccountInput describes an account to create. StatementDay and DueDay are
+// days of the month and only apply to credit cards; days past the end of a
+// short month fall on its last day.
+type AccountInput struct {
+	Name         string
+	Type         string
+	StatementDay int
+	DueDay       int
+}
+
+// CardStatement is one credit card statement: the purchases made up to
+// ClosesOn, paid from cash on DueOn. Balance is in the base currency.
+type CardStatement struct {
+	AccountID    int32     `json:"account_id"`
+	ClosesOn     time.Time `json:"closes_on"`
+	DueOn        time.Time `json:"due_on"`
+	Balance      float64   `json:"balance"`
+	Transactions int       `json:"transactions"`
+}
+
+func (fs *FinanceService) ListAccounts(ctx context.Context) ([]Account, error) {
+	return fs.db.ListAccounts(ctx)
+}
+
+func (fs *FinanceService) CreateAccount(ctx context.Context, in AccountInput) (Account, error) {
+	params, err := validateAccountInput(in)
+	if err != nil {
+		return Account{}, err
+	}
+	acc, err := fs.db.CreateAccount(ctx, params)
+	if err != nil {
+		return Account{}, err
+	}
+	fs.audit(ctx, AuditCreate, EntityAccount, acc.ID, nil, acc)
+	fs.events.Publish(ctx, EventAccountCreated, acc)
+	return acc, nil
+}
+
+func validateAccountInput(in AccountInput) (database.CreateAccountParams, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return database.CreateAccountParams{}, fmt.Errorf("%w: name is required", ErrInvalidAccount)
+	}
+	if len(name) > 100 {
+		return database.CreateAccountParams{}, fmt.Errorf("%w: name is longer than 100 characters", ErrInvalidAccount)
+	}
+	params := database.CreateAccountParams{Name: name, Type: strings.ToLower(strings.TrimSpace(in.Type))}
+
+	switch params.Type {
+	case AccountChecking, AccountSavings:
+		if in.StatementDay != 0 || in.DueDay != 0 {
+			return database.CreateAccountParams{}, fmt.Errorf("%w: statement_day and due_day only apply to credit cards", ErrInvalidAccount)
+		}
+	case AccountCreditCard:
+		if in.StatementDay < 1 || in.StatementDay > 31 {
+			return database.CreateAccountParams{}, fmt.Errorf("%w: statement_day must be 1-31", ErrInvalidAccount)
+		}
+		if in.DueDay < 1 || in.DueDay > 31 {
+			return database.CreateAccountParams{}, fmt.Errorf("%w: due_day must be 1-31", ErrInvalidAccount)
+		}
+		params.StatementDay = pgtype.Int4{Int32: int32(in.StatementDay), Valid: true}
+		params.DueDay = pgtype.Int4{Int32: int32(in.DueDay), Valid: true}
+	default:
+		return database.CreateAccountParams{}, fmt.Errorf("%w: type must be checking, savings or credit_card", ErrInvalidAccount)
+	}
+	return params, nil
+}
+
+// DeleteAccount removes an account that no transaction or recurring
+// refers to.
+func (fs *FinanceService) DeleteAccount(ctx context.Context, id int32) error {
+	before, err := fs.db.GetAccountByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%w: %d", ErrAccountNotFound, id)
+	}
+	if err := fs.db.DeleteAccount(ctx, id); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return fmt.Errorf("%w: %s", ErrAccountInUse, before.Name)
+		}
+		return err
+	}
+	fs.audit(ctx, AuditDelete, EntityAccount, id, before, nil)
+	fs.events.Publish(ctx, EventAccountDeleted, map[string]int32{"id": id})
+	return nil
+}
+
+// CardStatements lists a credit card's statements that are not yet due,
+// oldest first.
+func (fs *FinanceService) CardStatements(ctx context.Context, id int32) ([]CardStatement, error) {
+	acc, err := fs.db.GetAccountByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %d", ErrAccountNotFound, id)
+	}
+	if acc.Type != AccountCreditCard {
+		return nil, fmt.Errorf("%w: %s is not a credit card", ErrInvalidAccount, acc.Name)
+	}
+	txs, err := fs.db.GetAllTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cardStatements(acc, txs, fs.Today(ctx), conv)
+}
+
+func cardStatements(acc Account, txs []Transaction, today time.Time, conv converter) ([]CardStatement, error) {
+	byClose := make(map[time.Time]*CardStatement)
+	owed := make(map[time.Time]money.Money)
+	for _, tx := range txs {
+		if !tx.AccountID.Valid || tx.AccountID.Int32 != acc.ID {
+			continue
+		}
+		closes, due := statementDates(acc, truncateDay(tx.Date.Time.UTC()))
+		if due.Before(today) {
+			continue
+		}
+		amt, err := NumericToMoney(tx.Amount)
+		if err != nil {
+			continue
+		}
+		if amt, err = conv.toBase(tx.Currency, amt); err != nil {
+			return nil, err
+		}
+		st, ok := byClose[closes]
+		if !ok {
+			st = &CardStatement{AccountID: acc.ID, ClosesOn: closes, DueOn: due}
+			byClose[closes] = st
+		}
+		// Purchases are stored negative; the statement balance is what is owed.
+		owed[closes] = owed[closes].Sub(amt)
+		st.Transactions++
+	}
+
+	out := make([]CardStatement, 0, len(byClose))
+	for closes, st := range byClose {
+		st.Balance = owed[closes].Float64()
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ClosesOn.Before(out[j].ClosesOn) })
+	return out, nil
+}
+
+// statementDates returns the close date of the statement a purchase on day
+// lands on, and the date that statement is due. A purchase on the closing
+// day is on that statement; the due date is the first due day after it.
+func statementDates(acc Account, day time.Time) (closes, due time.Time) {
+	statementDay := int(acc.StatementDay.Int32)
+	closes = dateAtDayOrMonthEnd(day.Year(), day.Month(), statementDay)
+	if closes.Before(day) {
+		next := time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		closes = dateAtDayOrMonthEnd(next.Year(), next.Month(), statementDay)
+	}
+
+	dueDay := int(acc.DueDay.Int32)
+	due = dateAtDayOrMonthEnd(closes.Year(), closes.Month(), dueDay)
+	if !due.After(closes) {
+		next := time.Date(closes.Year(), closes.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		due = dateAtDayOrMonthEnd(next.Year(), next.Month(), dueDay)
+	}
+	return closes, due
+}
+
+// payCardsOnDueDates returns txs with every credit card purchase moved to the
+// due date of its statement, which is when it takes cash out of the balance.
+// txs itself is not modified.
+func payCardsOnDueDates(txs []Transaction, accounts []Account) []Transaction {
+	cards := make(map[int32]Account)
+	for _, a := range accounts {
+		if a.Type == AccountCreditCard {
+			cards[a.ID] = a
+		}
+	}
+	if len(cards) == 0 {
+		return txs
+	}
+
+	out := make([]Transaction, len(txs))
+	for i, tx := range txs {
+		if acc, ok := cards[tx.AccountID.Int32]; ok && tx.AccountID.Valid {
+			_, due := statementDates(acc, truncateDay(tx.Date.Time.UTC()))
+			tx.Date = makePgDate(due)
+		}
+		out[i] = tx
+	}
+	return out
+}
+
+func optInt4(v *int32) pgtype.Int4 {
+	if v == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *v, Valid: true}
+}
+
+// cashDay is the day tx moves cash: its own date, or its statement's due
+// date if it was charged to a credit card.
+func (fs *FinanceService) cashDay(ctx context.Context, tx Transaction) (time.Time, error) {
+	day := truncateDay(tx.Date.Time.UTC())
+	if !tx.AccountID.Valid {
+		return day, nil
+	}
+	acc, err := fs.db.GetAccountByID(ctx, tx.AccountID.Int32)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if acc.Type != AccountCreditCard {
+		return day, nil
+	}
+	_, due := statementDates(acc, day)
+	return due, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func card(id int32, statementDay, dueDay int32) Account {
+	return Account{
+		ID:           id,
+		Name:         "Visa",
+		Type:         AccountCreditCard,
+		StatementDay: pgtype.Int4{Int32: statementDay, Valid: true},
+		DueDay:       pgtype.Int4{Int32: dueDay, Valid: true},
+	}
+}
+
+func TestStatementDates(t *testing.T) {
+	tests := []struct {
+		name                string
+		acc                 Account
+		purchase            string
+		wantCloses, wantDue string
+	}{
+		{"before close, due next month", card(1, 20, 15), "2025-03-05", "2025-03-20", "2025-04-15"},
+		{"on the closing day", card(1, 20, 15), "2025-03-20", "2025-03-20", "2025-04-15"},
+		{"after close rolls to next statement", card(1, 20, 15), "2025-03-21", "2025-04-20", "2025-05-15"},
+		{"due later the same month", card(1, 3, 28), "2025-03-02", "2025-03-03", "2025-03-28"},
+		{"closing day clamps to month end", card(1, 31, 25), "2025-02-10", "2025-02-28", "2025-03-25"},
+		{"year boundary", card(1, 10, 5), "2025-12-15", "2026-01-10", "2026-02-05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closes, due := statementDates(tt.acc, day(tt.purchase))
+			assert.Equal(t, day(tt.wantCloses), closes)
+			assert.Equal(t, day(tt.wantDue), due)
+		})
+	}
+}
+
+func TestPayCardsOnDueDates(t *testing.T) {
+	accounts := []Account{
+		card(1, 20, 15),
+		{ID: 2, Name: "Checking", Type: AccountChecking},
+	}
+	txs := []Transaction{
+		{ID: 1, Date: makePgDate(day("2025-03-05")), Amount: makePgNumeric(-50)},
+		{ID: 2, Date: makePgDate(day("2025-03-05")), Amount: makePgNumeric(-60), AccountID: pgtype.Int4{Int32: 1, Valid: true}},
+		{ID: 3, Date: makePgDate(day("2025-03-05")), Amount: makePgNumeric(-70), AccountID: pgtype.Int4{Int32: 2, Valid: true}},
+	}
+
+	got := payCardsOnDueDates(txs, accounts)
+	require.Len(t, got, 3)
+	assert.Equal(t, day("2025-03-05"), got[0].Date.Time)
+	assert.Equal(t, day("2025-04-15"), got[1].Date.Time)
+	assert.Equal(t, day("2025-03-05"), got[2].Date.Time)
+	assert.Equal(t, day("2025-03-05"), txs[1].Date.Time, "input is left alone")
+}
+
+func TestCardStatements(t *testing.T) {
+	visa := card(1, 20, 15)
+	onCard := pgtype.Int4{Int32: 1, Valid: true}
+	txs := []Transaction{
+		{Date: makePgDate(day("2025-02-10")), Amount: makePgNumeric(-10), AccountID: onCard}, // due Mar 15, already paid
+		{Date: makePgDate(day("2025-03-05")), Amount: makePgNumeric(-50), AccountID: onCard},
+		{Date: makePgDate(day("2025-03-18")), Amount: makePgNumeric(-20), AccountID: onCard, Currency: "EUR"},
+		{Date: makePgDate(day("2025-03-25")), Amount: makePgNumeric(-30), AccountID: onCard},
+		{Date: makePgDate(day("2025-03-06")), Amount: makePgNumeric(-99)}, // cash
+	}
+	conv := converter{base: "USD", rates: map[string]float64{"EUR": 1.5}}
+
+	got, err := cardStatements(visa, txs, day("2025-03-22"), conv)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	assert.Equal(t, day("2025-03-20"), got[0].ClosesOn)
+	assert.Equal(t, day("2025-04-15"), got[0].DueOn)
+	assert.Equal(t, 80.0, got[0].Balance)
+	assert.Equal(t, 2, got[0].Transactions)
+
+	assert.Equal(t, day("2025-04-20"), got[1].ClosesOn)
+	assert.Equal(t, 30.0, got[1].Balance)
+}
+
+func TestValidateAccountInput(t *testing.T) {
+	params, err := validateAccountInput(AccountInput{Name: " Visa ", Type: "Credit_Card", StatementDay: 20, DueDay: 15})
+	require.NoError(t, err)
+	assert.Equal(t, "Visa", params.Name)
+	assert.Equal(t, AccountCreditCard, params.Type)
+	assert.Equal(t, int32(20), params.StatementDay.Int32)
+
+	for _, in := range []AccountInput{
+		{Name: "", Type: AccountChecking},
+		{Name: "Brokerage", Type: "investment"},
+		{Name: "Checking", Type: AccountChecking, DueDay: 5},
+		{Name: "Visa", Type: AccountCreditCard, StatementDay: 0, DueDay: 15},
+		{Name: "Visa", Type: AccountCreditCard, StatementDay: 20, DueDay: 32},
+	} {
+		_, err := validateAccountInput(in)
+		assert.ErrorIs(t, err, ErrInvalidAccount, "%+v", in)
+	}
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// TransactionAggregates summarizes a set of transactions in the base
+// currency. Expenses are stored negative, so TotalExpense is zero or less
+// and Net is their sum with TotalIncome.
+type TransactionAggregates struct {
+	Count        int64   `json:"count"`
+	TotalIncome  float64 `json:"total_income"`
+	TotalExpense float64 `json:"total_expense"`
+	Net          float64 `json:"net"`
+}
+
+// Ways GroupTransactions can bucket a list.
+const (
+	GroupByDay  = "day"
+	GroupByWeek = "week"
+)
+
+// ErrInvalidGrouping is returned for a group_by other than day or week.
+var ErrInvalidGrouping = errors.New("invalid grouping")
+
+// TransactionGroup is the transactions falling on one day, or in the week
+// starting on Start (a Monday).
+type TransactionGroup struct {
+	Start        time.Time             `json:"start"`
+	Transactions []ListedTransaction   `json:"transactions"`
+	Totals       TransactionAggregates `json:"totals"`
+}
+
+// GroupedTransactions is a transaction list split into groups in date order,
+// with totals across all of them.
+type GroupedTransactions struct {
+	GroupBy string                `json:"group_by"`
+	Groups  []TransactionGroup    `json:"groups"`
+	Totals  TransactionAggregates `json:"totals"`
+}
+
+// GroupTransactions buckets txs by day or by ISO week, with totals in the
+// base currency.
+func (fs *FinanceService) GroupTransactions(ctx context.Context, txs []ListedTransaction, by string) (GroupedTransactions, error) {
+	if by != GroupByDay && by != GroupByWeek {
+		return GroupedTransactions{}, fmt.Errorf("%w: %q (expected day or week)", ErrInvalidGrouping, by)
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return GroupedTransactions{}, err
+	}
+	return groupTransactions(txs, by, conv)
+}
+
+// groupTransactions is GroupTransactions once by is known to be valid.
+func groupTransactions(txs []ListedTransaction, by string, conv converter) (GroupedTransactions, error) {
+
+	type sums struct {
+		count           int64
+		income, expense money.Money
+	}
+	groups := []TransactionGroup{}
+	var (
+		totals []sums
+		all    sums
+	)
+	index := make(map[time.Time]int)
+	for _, tx := range txs {
+		start := truncateDay(tx.Date.Time.UTC())
+		if by == GroupByWeek {
+			// Go's weeks start on Sunday; step back to Monday.
+			start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		}
+		i, ok := index[start]
+		if !ok {
+			i = len(groups)
+			index[start] = i
+			groups = append(groups, TransactionGroup{Start: start})
+			totals = append(totals, sums{})
+		}
+		groups[i].Transactions = append(groups[i].Transactions, tx)
+
+		amt, err := conv.toBase(tx.Currency, numericOrZero(tx.Amount))
+		if err != nil {
+			return GroupedTransactions{}, err
+		}
+		for _, s := range []*sums{&totals[i], &all} {
+			s.count++
+			if tx.Type == "income" {
+				s.income = s.income.Add(amt)
+			} else {
+				s.expense = s.expense.Add(amt)
+			}
+		}
+	}
+	for i := range groups {
+		groups[i].Totals = aggregates(totals[i].count, totals[i].income, totals[i].expense)
+	}
+	sort.SliceStable(groups, func(a, b int) bool { return groups[a].Start.Before(groups[b].Start) })
+
+	return GroupedTransactions{
+		GroupBy: by,
+		Groups:  groups,
+		Totals:  aggregates(all.count, all.income, all.expense),
+	}, nil
+}
+
+// AggregateFilter selects the transactions to total. A nil Start or End
+// leaves that side of the range open. IncludeRecurring adds projected
+// recurring occurrences, matching what GetTransactionsWithRecurringsBetween
+// lists, and needs both ends of the range.
+type AggregateFilter struct {
+	Start            *time.Time
+	End              *time.Time
+	IncludeRecurring bool
+	// Status, if set, totals only transactions with that status.
+	// Recurring occurrences have none and are left out when it is set.
+	Status string
+}
+
+// AggregateTransactions totals stored transactions per currency in SQL and
+// converts the totals to the base currency. Recurring occurrences only
+// exist in memory, so when requested they are added on top.
+func (fs *FinanceService) AggregateTransactions(ctx context.Context, filter AggregateFilter) (TransactionAggregates, error) {
+	var params database.GetTransactionAggregatesByCurrencyParams
+	if filter.Start != nil {
+		params.StartDate = makePgDate(*filter.Start)
+	}
+	if filter.End != nil {
+		params.EndDate = makePgDate(*filter.End)
+	}
+	if filter.Status != "" {
+		params.Status = pgtype.Text{String: filter.Status, Valid: true}
+	}
+	if filter.IncludeRecurring && filter.Status == "" && (filter.Start == nil || filter.End == nil) {
+		return TransactionAggregates{}, fmt.Errorf("recurring totals need both start and end dates")
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return TransactionAggregates{}, err
+	}
+	count, income, expense, err := fs.sumByCurrency(ctx, params, conv)
+	if err != nil {
+		return TransactionAggregates{}, err
+	}
+	if !filter.IncludeRecurring || filter.Status != "" {
+		return aggregates(count, income, expense), nil
+	}
+
+	recs, err := fs.ExpandRecurringBetween(ctx, *filter.Start, *filter.End)
+	if err != nil {
+		return TransactionAggregates{}, err
+	}
+	for _, tx := range recs {
+		amt, err := conv.toBase(tx.Currency, numericOrZero(tx.Amount))
+		if err != nil {
+			return TransactionAggregates{}, err
+		}
+		count++
+		if tx.Type == "income" {
+			income = income.Add(amt)
+		} else {
+			expense = expense.Add(amt)
+		}
+	}
+	return aggregates(count, income, expense), nil
+}
+
+// sumByCurrency totals the transactions params selects, converting each
+// currency's totals to the base currency.
+func (fs *FinanceService) sumByCurrency(ctx context.Context, params database.GetTransactionAggregatesByCurrencyParams, conv converter) (count int64, income, expense money.Money, err error) {
+	rows, err := fs.db.GetTransactionAggregatesByCurrency(ctx, params)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	for _, row := range rows {
+		in, err := conv.toBase(row.Currency, numericOrZero(row.TotalIncome))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		out, err := conv.toBase(row.Currency, numericOrZero(row.TotalExpense))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		count += row.Count
+		income = income.Add(in)
+		expense = expense.Add(out)
+	}
+	return count, income, expense, nil
+}
+
+// TransactionSummary is the headline numbers for a date range, in the base
+// currency.
+type TransactionSummary struct {
+	TransactionAggregates
+	// LargestExpense is the expense worth the most in the base currency,
+	// in its own currency. It is nil when there are no expenses in the
+	// range.
+	LargestExpense *ListedTransaction `json:"largest_expense"`
+	// AverageDailySpend is TotalExpense spread over every day of the
+	// range, as a positive amount like the insights' one.
+	AverageDailySpend float64 `json:"average_daily_spend"`
+	Days              int     `json:"days"`
+}
+
+// SummarizeTransactions works out the summary for start to end inclusive,
+// totalled per currency in SQL and converted here. includeRecurring adds
+// projected recurring occurrences, as AggregateFilter.IncludeRecurring
+// does.
+func (fs *FinanceService) SummarizeTransactions(ctx context.Context, start, end time.Time, includeRecurring bool) (TransactionSummary, error) {
+	if end.Before(start) {
+		return TransactionSummary{}, fmt.Errorf("end %s is before start %s", end.Format("2006-01-02"), start.Format("2006-01-02"))
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return TransactionSummary{}, err
+	}
+	count, income, expense, err := fs.sumByCurrency(ctx, database.GetTransactionAggregatesByCurrencyParams{
+		StartDate: makePgDate(start),
+		EndDate:   makePgDate(end),
+	}, conv)
+	if err != nil {
+		return TransactionSummary{}, err
+	}
+
+	// The database finds the biggest expense in each currency; which of
+	// them is biggest depends on the rates. Ties go to the earliest.
+	var (
+		largest     *ListedTransaction
+		largestBase money.Money
+	)
+	consider := func(tx ListedTransaction, amt money.Money) {
+		if largest == nil || amt < largestBase || amt == largestBase && tx.Date.Time.Before(largest.Date.Time) {
+			largest, largestBase = &tx, amt
+		}
+	}
+	biggest, err := fs.db.GetLargestExpenses(ctx, database.GetLargestExpensesParams{
+		StartDate: makePgDate(start),
+		EndDate:   makePgDate(end),
+	})
+	if err != nil {
+		return TransactionSummary{}, err
+	}
+	for _, tx := range OneOffs(biggest) {
+		amt, err := conv.toBase(tx.Currency, numericOrZero(tx.Amount))
+		if err != nil {
+			return TransactionSummary{}, err
+		}
+		consider(tx, amt)
+	}
+
+	if includeRecurring {
+		rs, err := fs.db.ListActiveRecurring(ctx)
+		if err != nil {
+			return TransactionSummary{}, err
+		}
+		for _, occ := range expandListed(rs, start, end) {
+			amt, err := conv.toBase(occ.Currency, numericOrZero(occ.Amount))
+			if err != nil {
+				return TransactionSummary{}, err
+			}
+			count++
+			if occ.Type == "income" {
+				income = income.Add(amt)
+				continue
+			}
+			expense = expense.Add(amt)
+			consider(occ, amt)
+		}
+	}
+
+	days := int(end.Sub(start).Hours()/24) + 1
+	return TransactionSummary{
+		TransactionAggregates: aggregates(count, income, expense),
+		LargestExpense:        largest,
+		AverageDailySpend:     expense.Neg().Mul(1 / float64(days)).Float64(),
+		Days:                  days,
+	}, nil
+}
+
+func aggregates(count int64, income, expense money.Money) TransactionAggregates {
+	return TransactionAggregates{
+		Count:        count,
+		TotalIncome:  income.Float64(),
+		TotalExpense: expense.Float64(),
+		Net:          income.Add(expense).Float64(),
+	}
+}
+
+func numericOrZero(n pgtype.Numeric) money.Money {
+	m, _ := NumericToMoney(n)
+	return m
+}
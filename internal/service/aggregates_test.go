@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupTransactionsByWeek(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	txs := OneOffs([]Transaction{
+		{Type: "expense", Date: makePgDate(day("2025-09-22")), Amount: makePgNumeric(-60)},   // Monday
+		{Type: "income", Date: makePgDate(day("2025-09-21")), Amount: makePgNumeric(2500)},   // Sunday
+		{Type: "expense", Date: makePgDate(day("2025-09-15")), Amount: makePgNumeric(-1200)}, // Monday
+	})
+
+	got, err := fs.GroupTransactions(ctx, txs, GroupByWeek)
+	require.NoError(t, err)
+	require.Len(t, got.Groups, 2)
+	assert.Equal(t, day("2025-09-15"), got.Groups[0].Start)
+	assert.Len(t, got.Groups[0].Transactions, 2)
+	assert.Equal(t, 1300.0, got.Groups[0].Totals.Net)
+	assert.Equal(t, day("2025-09-22"), got.Groups[1].Start)
+	assert.Equal(t, int64(3), got.Totals.Count)
+	assert.Equal(t, 1240.0, got.Totals.Net)
+
+	byDay, err := fs.GroupTransactions(ctx, txs, GroupByDay)
+	require.NoError(t, err)
+	assert.Len(t, byDay.Groups, 3)
+
+	empty, err := fs.GroupTransactions(ctx, nil, GroupByDay)
+	require.NoError(t, err)
+	assert.NotNil(t, empty.Groups)
+
+	_, err = fs.GroupTransactions(ctx, txs, "month")
+	assert.ErrorIs(t, err, ErrInvalidGrouping)
+}
+
+func TestAggregatesConvertCurrencies(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	_, err := fs.SetExchangeRate(ctx, "EUR", 2)
+	require.NoError(t, err)
+	require.NoError(t, fs.AddIncome(ctx, day("2025-09-01"), 1000, "Paycheck", "USD"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-02"), 100, "Train", "EUR"))
+	_, err = fs.CreateRecurringSimple(ctx, RecurringInput{
+		Description: "Gym", Type: "expense", Amount: 10, Currency: "EUR", StartDate: day("2025-09-03"), Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+	start, end := day("2025-09-01"), day("2025-09-30")
+
+	agg, err := fs.AggregateTransactions(ctx, AggregateFilter{Start: &start, End: &end, IncludeRecurring: true})
+	require.NoError(t, err)
+	assert.Equal(t, TransactionAggregates{Count: 3, TotalIncome: 1000, TotalExpense: -220, Net: 780}, agg)
+
+	txs, err := fs.GetTransactionsWithRecurringsBetween(ctx, start, end)
+	require.NoError(t, err)
+	grouped, err := fs.GroupTransactions(ctx, txs, GroupByWeek)
+	require.NoError(t, err)
+	assert.Equal(t, agg, grouped.Totals, "grouping totals match the list aggregates")
+
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-04"), 5, "Coffee", "GBP"))
+	_, err = fs.AggregateTransactions(ctx, AggregateFilter{})
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+	txs, err = fs.GetTransactionsBetween(ctx, start, end)
+	require.NoError(t, err)
+	_, err = fs.GroupTransactions(ctx, txs, GroupByDay)
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+}
+
+func TestSummarizeTransactions(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	start, end := day("2025-09-01"), day("2025-09-30")
+
+	empty, err := fs.SummarizeTransactions(ctx, start, end, true)
+	require.NoError(t, err)
+	assert.Nil(t, empty.LargestExpense)
+	assert.Equal(t, 30, empty.Days)
+
+	require.NoError(t, fs.AddIncome(ctx, day("2025-09-05"), 3000, "Paycheck", "USD"))
+	for _, tx := range []database.CreateTransactionParams{
+		{Date: makePgDate(day("2025-09-10")), Amount: makePgNumeric(-200), Description: "Groceries", Type: "expense"},
+		{Date: makePgDate(day("2025-10-02")), Amount: makePgNumeric(-5000), Description: "Next month", Type: "expense"},
+	} {
+		_, err := fs.createTransaction(ctx, tx)
+		require.NoError(t, err)
+	}
+	rent, err := fs.CreateRecurringSimple(ctx, RecurringInput{
+		Description: "Rent", Type: "expense", Amount: 1000, StartDate: day("2025-01-15"), Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+
+	recorded, err := fs.SummarizeTransactions(ctx, start, end, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), recorded.Count)
+	assert.Equal(t, 2800.0, recorded.Net)
+	require.NotNil(t, recorded.LargestExpense)
+	assert.Equal(t, "Groceries", recorded.LargestExpense.Description)
+	assert.InDelta(t, 6.67, recorded.AverageDailySpend, 0.001)
+
+	all, err := fs.SummarizeTransactions(ctx, start, end, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), all.Count)
+	assert.Equal(t, -1200.0, all.TotalExpense)
+	assert.Equal(t, 40.0, all.AverageDailySpend)
+	require.NotNil(t, all.LargestExpense)
+	assert.Equal(t, SourceRecurring, all.LargestExpense.Source)
+	assert.Equal(t, rent.ID, all.LargestExpense.RecurringID.Int32)
+}
+
+func TestSummarizeTransactionsConvertsCurrencies(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	start, end := day("2025-09-01"), day("2025-09-10")
+
+	_, err := fs.SetExchangeRate(ctx, "EUR", 2)
+	require.NoError(t, err)
+	require.NoError(t, fs.AddIncome(ctx, day("2025-09-01"), 1000, "Paycheck", "USD"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-02"), 150, "Groceries", "USD"))
+	// Fewer euros than dollars, but worth more.
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-03"), 100, "Train", "EUR"))
+
+	summary, err := fs.SummarizeTransactions(ctx, start, end, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), summary.Count)
+	assert.Equal(t, 1000.0, summary.TotalIncome)
+	assert.Equal(t, -350.0, summary.TotalExpense)
+	assert.Equal(t, 650.0, summary.Net)
+	assert.Equal(t, 35.0, summary.AverageDailySpend)
+	require.NotNil(t, summary.LargestExpense)
+	assert.Equal(t, "Train", summary.LargestExpense.Description)
+	assert.Equal(t, "EUR", summary.LargestExpense.Currency)
+
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-04"), 5, "Coffee", "GBP"))
+	_, err = fs.SummarizeTransactions(ctx, start, end, false)
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+}
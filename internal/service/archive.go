@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// MinRetentionYears is the shortest retention period. Anything shorter
+// could archive the income the next tax settlement is worked out from.
+const MinRetentionYears = 2
+
+// ArchivedTransaction is a transaction the archival job moved out of the
+// live ones. It keeps the ID it had.
+type ArchivedTransaction = database.ArchivedTransactions
+
+// RetentionYears is how many years of transactions are kept live. ok is
+// false when none is set, and nothing is archived.
+func (fs *FinanceService) RetentionYears(ctx context.Context) (years int, ok bool) {
+	value, err := fs.db.GetSetting(ctx, SettingRetentionYears)
+	if err != nil {
+		return 0, false
+	}
+	years, err = strconv.Atoi(value)
+	if err != nil || years < MinRetentionYears {
+		return 0, false
+	}
+	return years, true
+}
+
+func (fs *FinanceService) SetRetentionYears(ctx context.Context, years int) error {
+	if years < MinRetentionYears {
+		return fmt.Errorf("%w: retention years %d (expected at least %d)", ErrInvalidSetting, years, MinRetentionYears)
+	}
+	if err := fs.updateSetting(ctx, SettingRetentionYears, strconv.Itoa(years)); err != nil {
+		return err
+	}
+	fs.events.Publish(ctx, EventSettingsChanged, map[string]int{SettingRetentionYears: years})
+	return nil
+}
+
+// ArchiveCutoff is the first day whose transactions the retention period
+// keeps live. ok is false when no retention period is set.
+func (fs *FinanceService) ArchiveCutoff(ctx context.Context) (cutoff time.Time, ok bool) {
+	years, ok := fs.RetentionYears(ctx)
+	if !ok {
+		return time.Time{}, false
+	}
+	return fs.Today(ctx).AddDate(-years, 0, 0), true
+}
+
+// ArchiveTransactions moves the cleared transactions dated before cutoff
+// into the archive and returns how many it moved. Pending transactions and
+// ones with attachments stay. The tax details and self-employment tags of
+// the ones moved are dropped, so tax estimates only cover years still live.
+func (fs *FinanceService) ArchiveTransactions(ctx context.Context, cutoff time.Time) (int64, error) {
+	n, err := fs.db.ArchiveTransactions(ctx, makePgDate(cutoff))
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		fs.events.Publish(ctx, EventTransactionsArchived, map[string]any{
+			"archived": n,
+			"before":   cutoff.Format("2006-01-02"),
+		})
+	}
+	return n, nil
+}
+
+// ArchiveExpired archives what has outlived the retention period, if one
+// is set.
+func (fs *FinanceService) ArchiveExpired(ctx context.Context) (int64, error) {
+	cutoff, ok := fs.ArchiveCutoff(ctx)
+	if !ok {
+		return 0, nil
+	}
+	return fs.ArchiveTransactions(ctx, cutoff)
+}
+
+// ListArchivedTransactions returns the archived transactions dated from
+// start to end, by date. A nil bound leaves that side open.
+func (fs *FinanceService) ListArchivedTransactions(ctx context.Context, start, end *time.Time) ([]ArchivedTransaction, error) {
+	var arg database.ListArchivedTransactionsParams
+	if start != nil {
+		arg.StartDate = makePgDate(*start)
+	}
+	if end != nil {
+		arg.EndDate = makePgDate(*end)
+	}
+	return fs.db.ListArchivedTransactions(ctx, arg)
+}
+
+// StartArchival runs ArchiveExpired on every household every interval
+// until ctx is cancelled. Households without a retention period are left
+// alone.
+func (fs *FinanceService) StartArchival(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			var n int64
+			err := fs.forEachHousehold(ctx, func(ctx context.Context) error {
+				archived, err := fs.ArchiveExpired(ctx)
+				n += archived
+				return err
+			})
+			if err != nil {
+				log.Printf("archival failed: %v", err)
+			} else if n > 0 {
+				log.Printf("archival moved %d transactions", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveExpired(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	today := fs.Today(ctx)
+
+	_, err := fs.createTransaction(ctx, database.CreateTransactionParams{
+		Date: makePgDate(today.AddDate(-4, 0, 0)), Amount: makePgNumeric(-50), Description: "Uncashed check", Type: "expense", Status: StatusPending,
+	})
+	require.NoError(t, err)
+	_, err = fs.createTransaction(ctx, database.CreateTransactionParams{
+		Date: makePgDate(today.AddDate(-1, 0, 0)), Amount: makePgNumeric(100), Description: "Paycheck", Type: "income",
+	})
+	require.NoError(t, err)
+	// Last, so the archive holds the highest ID.
+	old, err := fs.createTransaction(ctx, database.CreateTransactionParams{
+		Date: makePgDate(today.AddDate(-4, 0, 0)), Amount: makePgNumeric(-30), Description: "Old groceries", Type: "expense",
+	})
+	require.NoError(t, err)
+
+	// Nothing is archived until a retention period is set.
+	n, err := fs.ArchiveExpired(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	assert.ErrorIs(t, fs.SetRetentionYears(ctx, 1), ErrInvalidSetting)
+	require.NoError(t, fs.SetRetentionYears(ctx, 3))
+	settings, err := fs.Settings(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, settings.RetentionYears)
+	assert.Equal(t, 3, *settings.RetentionYears)
+
+	n, err = fs.ArchiveExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	live, err := fs.GetAllTransactions(ctx)
+	require.NoError(t, err)
+	var descriptions []string
+	for _, tx := range live {
+		descriptions = append(descriptions, tx.Description)
+	}
+	assert.ElementsMatch(t, []string{"Uncashed check", "Paycheck"}, descriptions)
+
+	archived, err := fs.ListArchivedTransactions(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, old.ID, archived[0].ID)
+	assert.Equal(t, "Old groceries", archived[0].Description)
+
+	start := today.AddDate(-2, 0, 0)
+	archived, err = fs.ListArchivedTransactions(ctx, &start, nil)
+	require.NoError(t, err)
+	assert.Empty(t, archived)
+
+	// The archive goes with a backup, and comes back with it.
+	b, err := fs.Backup(ctx)
+	require.NoError(t, err)
+	require.Len(t, b.Archived, 1)
+	restored := NewFinanceService(memdb.New())
+	summary, err := restored.Restore(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Archived)
+	archived, err = restored.ListArchivedTransactions(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, old.ID, archived[0].ID)
+
+	// New transactions don't take the archived one's ID.
+	tx, err := restored.createTransaction(ctx, database.CreateTransactionParams{
+		Date: makePgDate(today), Amount: makePgNumeric(-5), Description: "Coffee", Type: "expense",
+	})
+	require.NoError(t, err)
+	assert.Greater(t, tx.ID, old.ID)
+}
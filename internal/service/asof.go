@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// ErrInvalidAsOf is returned for as-of dates that cannot be reconstructed.
+var ErrInvalidAsOf = errors.New("invalid as_of date")
+
+// knownState is the data the forecast reads, as it stood at some moment.
+type knownState struct {
+	Transactions    []Transaction
+	Recurring       []Recurring
+	StartingBalance float64
+}
+
+// ForecastAsOf rebuilds the 90-day forecast that would have been shown on
+// asOf, using only the transactions, recurring schedules and starting balance
+// known by the end of that day. State is replayed from the audit log, so edits
+// and deletions made since then are undone. The April tax settlement is left
+// out: it depends on withholding that may only have been recorded later.
+// Interest is left out too, since account balances are not versioned.
+func (fs *FinanceService) ForecastAsOf(ctx context.Context, asOf time.Time) ([]DailyCashFlow, error) {
+	start := truncateDay(asOf.UTC())
+	if start.After(fs.Today(ctx)) {
+		return nil, fmt.Errorf("%w: %s is in the future", ErrInvalidAsOf, start.Format("2006-01-02"))
+	}
+	cutoff := start.AddDate(0, 0, 1)
+	end := start.AddDate(0, 0, 89)
+
+	state, accounts, err := fs.knownAsOf(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rates are not versioned, so amounts are converted at today's rates.
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all := payCardsOnDueDates(append(state.Transactions, expandForForecast(state.Recurring, start, end)...), accounts)
+	daily, err := dailyChanges(all, conv)
+	if err != nil {
+		return nil, err
+	}
+	return accumulateForecast(start, 90, daily, nil, state.StartingBalance), nil
+}
+
+// knownAsOf loads the current data and the audit history and replays it
+// to what was known just before cutoff. Accounts are returned as they are
+// now.
+func (fs *FinanceService) knownAsOf(ctx context.Context, cutoff time.Time) (knownState, []Account, error) {
+	var (
+		txs      []Transaction
+		recs     []Recurring
+		settings []database.Settings
+		accounts []Account
+		history  []database.AuditLog
+	)
+	err := fs.withTx(ctx, func(q database.Querier) error {
+		var err error
+		if txs, err = q.ListTransactionsForBackup(ctx); err != nil {
+			return err
+		}
+		if recs, err = q.ListRecurringForBackup(ctx); err != nil {
+			return err
+		}
+		if settings, err = q.GetAllSettings(ctx); err != nil {
+			return err
+		}
+		if accounts, err = q.ListAccounts(ctx); err != nil {
+			return err
+		}
+		history, err = q.ListAuditHistory(ctx, []string{EntityTransaction, EntityRecurring, EntitySetting})
+		return err
+	})
+	if err != nil {
+		return knownState{}, nil, err
+	}
+
+	state, err := reconstructAsOf(cutoff, txs, recs, settings, history)
+	if err != nil {
+		return knownState{}, nil, err
+	}
+	return state, accounts, nil
+}
+
+// reconstructAsOf works out what was known just before cutoff. For each row
+// the last audit entry before cutoff gives its state; failing that, the first
+// entry after cutoff says what it looked like before the change; rows with no
+// history at all are taken as they are now, filtered by their timestamps.
+func reconstructAsOf(cutoff time.Time, txs []Transaction, recs []Recurring, settings []database.Settings, history []database.AuditLog) (knownState, error) {
+	// history is in id order, so the last write before cutoff wins and the
+	// first write after it is kept.
+	before := make(map[string]json.RawMessage)
+	after := make(map[string]json.RawMessage)
+	for _, e := range history {
+		key := e.Entity + ":" + e.EntityID
+		if e.CreatedAt.Time.Before(cutoff) {
+			before[key] = e.AfterData
+			continue
+		}
+		if _, ok := after[key]; !ok {
+			after[key] = e.BeforeData
+		}
+	}
+	lookup := func(key string) (json.RawMessage, bool) {
+		if raw, ok := before[key]; ok {
+			return raw, true
+		}
+		raw, ok := after[key]
+		return raw, ok
+	}
+	deletedBy := func(ts time.Time, valid bool) bool {
+		return valid && ts.Before(cutoff)
+	}
+
+	var st knownState
+	for _, cur := range txs {
+		key := EntityTransaction + ":" + strconv.Itoa(int(cur.ID))
+		raw, ok := lookup(key)
+		if !ok {
+			if cur.CreatedAt.Time.Before(cutoff) && !deletedBy(cur.DeletedAt.Time, cur.DeletedAt.Valid) {
+				st.Transactions = append(st.Transactions, cur)
+			}
+			continue
+		}
+		var tx Transaction
+		present, err := decodeSnapshot(raw, &tx)
+		if err != nil {
+			return knownState{}, fmt.Errorf("transaction %d: %w", cur.ID, err)
+		}
+		if present {
+			st.Transactions = append(st.Transactions, tx)
+		}
+	}
+
+	for _, cur := range recs {
+		key := EntityRecurring + ":" + strconv.Itoa(int(cur.ID))
+		r := cur
+		if raw, ok := lookup(key); ok {
+			present, err := decodeSnapshot(raw, &r)
+			if err != nil {
+				return knownState{}, fmt.Errorf("recurring %d: %w", cur.ID, err)
+			}
+			if !present {
+				continue
+			}
+		} else if deletedBy(cur.DeletedAt.Time, cur.DeletedAt.Valid) {
+			continue
+		}
+		if r.Active {
+			st.Recurring = append(st.Recurring, r)
+		}
+	}
+
+	balance := ""
+	for _, s := range settings {
+		if s.Key == SettingStartingBalance && s.UpdatedAt.Time.Before(cutoff) {
+			balance = s.Value
+		}
+	}
+	if raw, ok := lookup(EntitySetting + ":starting_balance"); ok {
+		var s struct {
+			Value string `json:"value"`
+		}
+		present, err := decodeSnapshot(raw, &s)
+		if err != nil {
+			return knownState{}, fmt.Errorf("starting balance: %w", err)
+		}
+		balance = ""
+		if present {
+			balance = s.Value
+		}
+	}
+	if balance != "" {
+		f, err := strconv.ParseFloat(balance, 64)
+		if err != nil {
+			return knownState{}, fmt.Errorf("starting balance: %w", err)
+		}
+		st.StartingBalance = f
+	}
+	return st, nil
+}
+
+// decodeSnapshot unpacks an audit before/after image into v. It reports
+// false when the image says the row did not exist: an empty image, or the
+// {"deleted": true} marker written when a row is restored from the trash.
+func decodeSnapshot(raw json.RawMessage, v interface{}) (bool, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return false, nil
+	}
+	var marker struct {
+		Deleted bool `json:"deleted"`
+	}
+	if err := json.Unmarshal(raw, &marker); err != nil {
+		return false, err
+	}
+	if marker.Deleted {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconstructAsOf(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		require.NoError(t, err)
+		return d
+	}
+	ts := func(s string) pgtype.Timestamp { return pgtype.Timestamp{Time: day(s), Valid: true} }
+	tx := func(id int32, amount float64, created string) Transaction {
+		return Transaction{
+			ID:        id,
+			Date:      makePgDate(day("2025-06-10")),
+			Amount:    makePgNumeric(amount),
+			CreatedAt: ts(created),
+		}
+	}
+	js := func(v interface{}) []byte {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+	cutoff := day("2025-06-02")
+
+	// 1: created before cutoff, no history.
+	// 2: created after cutoff, no history.
+	// 3: deleted before cutoff, no history.
+	// 4: trashed and restored before cutoff; the restore's after image is used.
+	// 5: deleted after cutoff; the delete entry's before image is used.
+	old4 := tx(4, -50, "2025-05-01")
+	del3 := tx(3, -10, "2025-05-01")
+	del3.DeletedAt = ts("2025-05-15")
+	txs := []Transaction{
+		tx(1, 100, "2025-05-01"),
+		tx(2, 200, "2025-06-05"),
+		del3,
+		old4,
+	}
+	old5 := tx(5, -75, "2025-05-20")
+	cur5 := old5
+	cur5.DeletedAt = ts("2025-06-20")
+	txs = append(txs, cur5)
+
+	rec := Recurring{ID: 7, Active: true, Interval: "monthly"}
+	paused := rec
+	paused.Active = false
+
+	history := []database.AuditLog{
+		{Entity: EntityTransaction, EntityID: "4", BeforeData: []byte(`{"deleted":true}`), AfterData: js(old4), CreatedAt: ts("2025-05-20")},
+		{Entity: EntityTransaction, EntityID: "5", BeforeData: js(old5), CreatedAt: ts("2025-06-20")},
+		{Entity: EntityRecurring, EntityID: "7", BeforeData: js(rec), AfterData: js(paused), CreatedAt: ts("2025-06-15")},
+		{Entity: EntitySetting, EntityID: "starting_balance", AfterData: js(map[string]string{"key": "starting_balance", "value": "500.00"}), CreatedAt: ts("2025-05-01")},
+		{Entity: EntitySetting, EntityID: "starting_balance", BeforeData: js(map[string]string{"key": "starting_balance", "value": "500.00"}), AfterData: js(map[string]string{"key": "starting_balance", "value": "900.00"}), CreatedAt: ts("2025-06-10")},
+	}
+	settings := []database.Settings{{Key: "starting_balance", Value: "900.00", UpdatedAt: ts("2025-06-10")}}
+
+	st, err := reconstructAsOf(cutoff, txs, []Recurring{paused}, settings, history)
+	require.NoError(t, err)
+
+	var ids []int32
+	for _, tx := range st.Transactions {
+		ids = append(ids, tx.ID)
+	}
+	assert.Equal(t, []int32{1, 4, 5}, ids)
+	require.Len(t, st.Recurring, 1)
+	assert.True(t, st.Recurring[0].Active, "paused after the cutoff, so it was active then")
+	assert.Equal(t, 500.0, st.StartingBalance)
+}
+
+func TestDecodeSnapshot(t *testing.T) {
+	var tx Transaction
+	ok, err := decodeSnapshot(nil, &tx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = decodeSnapshot([]byte(`{"deleted":true}`), &tx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = decodeSnapshot([]byte(`{"id":3,"description":"rent"}`), &tx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "rent", tx.Description)
+}
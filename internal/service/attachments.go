@@ -0,0 +1,277 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/storage/blob"
+)
+
+// EntityAttachment is the audit entity for attachment changes.
+const EntityAttachment = "attachment"
+
+// DefaultMaxAttachmentBytes caps a single attachment unless
+// AttachmentOptions says otherwise. Phone photos of receipts fit easily.
+const DefaultMaxAttachmentBytes = 10 << 20
+
+// AttachmentTypes are the content types attachments may have, detected from
+// the file itself rather than taken from the client.
+var AttachmentTypes = []string{
+	"image/jpeg", "image/png", "image/gif", "image/webp", "image/heic", "application/pdf",
+}
+
+var (
+	// ErrAttachmentNotFound is returned for an attachment ID that doesn't
+	// belong to the transaction.
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrInvalidAttachment is returned for empty files and types not in
+	// AttachmentTypes.
+	ErrInvalidAttachment = errors.New("invalid attachment")
+	// ErrAttachmentTooLarge is returned for files over the size limit.
+	ErrAttachmentTooLarge = errors.New("attachment too large")
+)
+
+type Attachment = database.Attachments
+
+// SetMaxAttachmentBytes changes the size limit on a single attachment.
+// Zero or less restores DefaultMaxAttachmentBytes.
+func (fs *FinanceService) SetMaxAttachmentBytes(n int64) {
+	fs.maxAttachmentBytes = n
+}
+
+// MaxAttachmentBytes is the size limit on a single attachment.
+func (fs *FinanceService) MaxAttachmentBytes() int64 {
+	if fs.maxAttachmentBytes <= 0 {
+		return DefaultMaxAttachmentBytes
+	}
+	return fs.maxAttachmentBytes
+}
+
+// AddAttachment stores the file read from r with transaction id. The type
+// comes from the file's contents; filename is only kept for downloads.
+func (fs *FinanceService) AddAttachment(ctx context.Context, id int32, filename string, r io.Reader) (Attachment, error) {
+	store, err := fs.blobStore(ctx)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if err := fs.liveTransaction(ctx, id); err != nil {
+		return Attachment{}, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return Attachment{}, err
+	}
+	head = head[:n]
+	if n == 0 {
+		return Attachment{}, fmt.Errorf("%w: file is empty", ErrInvalidAttachment)
+	}
+	contentType := detectAttachmentType(head)
+	if contentType == "" {
+		return Attachment{}, fmt.Errorf("%w: unsupported file type %s (expected %s)",
+			ErrInvalidAttachment, http.DetectContentType(head), strings.Join(AttachmentTypes, ", "))
+	}
+
+	key, err := newAttachmentKey()
+	if err != nil {
+		return Attachment{}, err
+	}
+	body := &sizeLimitReader{r: io.MultiReader(bytes.NewReader(head), r), limit: fs.MaxAttachmentBytes()}
+	if err := store.Put(ctx, key, body); err != nil {
+		return Attachment{}, err
+	}
+
+	att, err := fs.db.CreateAttachment(ctx, database.CreateAttachmentParams{
+		TransactionID: id,
+		Filename:      cleanFilename(filename),
+		ContentType:   contentType,
+		Size:          body.n,
+		StorageKey:    key,
+	})
+	if err != nil {
+		fs.deleteBlob(ctx, key)
+		return Attachment{}, err
+	}
+	fs.audit(ctx, AuditCreate, EntityAttachment, att.ID, nil, att)
+	return att, nil
+}
+
+// ListAttachments lists the attachments of transaction id, oldest first.
+func (fs *FinanceService) ListAttachments(ctx context.Context, id int32) ([]Attachment, error) {
+	if err := fs.liveTransaction(ctx, id); err != nil {
+		return nil, err
+	}
+	return fs.db.ListAttachments(ctx, id)
+}
+
+// OpenAttachment returns attachment attID of transaction id with its
+// contents. The caller closes the reader.
+func (fs *FinanceService) OpenAttachment(ctx context.Context, id, attID int32) (Attachment, io.ReadCloser, error) {
+	store, err := fs.blobStore(ctx)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	att, err := fs.getAttachment(ctx, id, attID)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	rc, err := store.Get(ctx, att.StorageKey)
+	if errors.Is(err, blob.ErrNotFound) {
+		return Attachment{}, nil, fmt.Errorf("%w: %d (file missing from storage)", ErrAttachmentNotFound, attID)
+	}
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	return att, rc, nil
+}
+
+// AttachmentURL returns a temporary link that downloads attachment attID
+// of transaction id straight from the blob store, or "" if the store
+// can't make one and the file has to come through OpenAttachment.
+func (fs *FinanceService) AttachmentURL(ctx context.Context, id, attID int32) (string, error) {
+	if _, err := fs.blobStore(ctx); err != nil {
+		return "", err
+	}
+	att, err := fs.getAttachment(ctx, id, attID)
+	if err != nil {
+		return "", err
+	}
+	return fs.downloadURL(ctx, att.StorageKey, blob.DownloadOptions{Filename: att.Filename, ContentType: att.ContentType})
+}
+
+// DeleteAttachment removes attachment attID of transaction id and its file.
+func (fs *FinanceService) DeleteAttachment(ctx context.Context, id, attID int32) error {
+	att, err := fs.getAttachment(ctx, id, attID)
+	if err != nil {
+		return err
+	}
+	n, err := fs.db.DeleteAttachment(ctx, database.DeleteAttachmentParams{ID: attID, TransactionID: id})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrAttachmentNotFound, attID)
+	}
+	fs.deleteBlob(ctx, att.StorageKey)
+	fs.audit(ctx, AuditDelete, EntityAttachment, attID, att, nil)
+	return nil
+}
+
+func (fs *FinanceService) getAttachment(ctx context.Context, id, attID int32) (Attachment, error) {
+	if err := fs.liveTransaction(ctx, id); err != nil {
+		return Attachment{}, err
+	}
+	att, err := fs.db.GetAttachment(ctx, database.GetAttachmentParams{ID: attID, TransactionID: id})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Attachment{}, fmt.Errorf("%w: %d", ErrAttachmentNotFound, attID)
+	}
+	return att, err
+}
+
+// liveTransaction checks that transaction id exists and isn't in the trash.
+func (fs *FinanceService) liveTransaction(ctx context.Context, id int32) error {
+	_, err := fs.db.GetTransactionByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrTransactionNotFound, id)
+	}
+	return err
+}
+
+// purgeableBlobs lists the files of attachments whose transactions are
+// about to be purged from the trash. The rows go with the transactions;
+// the files have to be removed separately.
+func (fs *FinanceService) purgeableBlobs(ctx context.Context, cutoff pgtype.Timestamp) ([]string, error) {
+	if fs.blobs == nil {
+		return nil, nil
+	}
+	atts, err := fs.db.ListPurgeableAttachments(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(atts))
+	for i, a := range atts {
+		keys[i] = a.StorageKey
+	}
+	return keys, nil
+}
+
+func newAttachmentKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "attachments/" + hex.EncodeToString(b), nil
+}
+
+// detectAttachmentType returns the type of a file from its first bytes, or
+// "" if it isn't one of AttachmentTypes.
+func detectAttachmentType(head []byte) string {
+	// HEIC, the iPhone camera's format, is an ISO media file with a HEIF
+	// brand, which http.DetectContentType doesn't know.
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		switch string(head[8:12]) {
+		case "heic", "heix", "heim", "heis", "mif1", "msf1":
+			return "image/heic"
+		}
+	}
+	detected, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	for _, t := range AttachmentTypes {
+		if detected == t {
+			return t
+		}
+	}
+	return ""
+}
+
+// cleanFilename keeps the base name of what the client sent, without
+// control characters, so it is safe to echo in a download header.
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// sizeLimitReader fails with ErrAttachmentTooLarge once more than limit
+// bytes have been read, so the store never keeps an oversized file.
+type sizeLimitReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func (s *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.n += int64(n)
+	if s.n > s.limit {
+		return n, fmt.Errorf("%w (limit is %d bytes)", ErrAttachmentTooLarge, s.limit)
+	}
+	return n, err
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// Audited entity names
+const (
+	EntityTransaction     = "transaction"
+	EntityRecurring       = "recurring"
+	EntitySetting         = "setting"
+	EntityIncomeTaxDetail = "income_tax_detail"
+	EntitySelfEmployment  = "self_employment_income"
+	EntityConfig          = "config"
+)
+
+const defaultAuditLimit = 100
+
+type actorKey struct{}
+
+// WithActor tags ctx with the identity that mutations made under it are
+// attributed to in the audit log.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "system"
+}
+
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows ListAudit results. Zero values mean "any".
+type AuditFilter struct {
+	Entity   string
+	EntityID string
+	Action   string
+	Actor    string
+	Since    *time.Time
+	Until    *time.Time
+	Limit    int
+}
+
+func (fs *FinanceService) ListAudit(ctx context.Context, f AuditFilter) ([]AuditEntry, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	params := database.ListAuditEntriesParams{
+		Entity:   optText(f.Entity),
+		EntityID: optText(f.EntityID),
+		Action:   optText(f.Action),
+		Actor:    optText(f.Actor),
+		RowLimit: int32(limit),
+	}
+	if f.Since != nil {
+		params.Since = pgtype.Timestamp{Time: *f.Since, Valid: true}
+	}
+	if f.Until != nil {
+		params.Until = pgtype.Timestamp{Time: *f.Until, Valid: true}
+	}
+
+	rows, err := fs.db.ListAuditEntries(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, AuditEntry{
+			ID:        row.ID,
+			Actor:     row.Actor,
+			Action:    row.Action,
+			Entity:    row.Entity,
+			EntityID:  row.EntityID,
+			Before:    row.BeforeData,
+			After:     row.AfterData,
+			CreatedAt: row.CreatedAt.Time,
+		})
+	}
+	return out, nil
+}
+
+// audit records a mutation. The change has already been applied, so a
+// failure to write the entry is logged rather than returned.
+func (fs *FinanceService) audit(ctx context.Context, action, entity string, id interface{}, before, after interface{}) {
+	params := database.CreateAuditEntryParams{
+		Actor:    actorFromContext(ctx),
+		Action:   action,
+		Entity:   entity,
+		EntityID: fmt.Sprint(id),
+	}
+	var err error
+	if before != nil {
+		if params.BeforeData, err = json.Marshal(before); err != nil {
+			Logf(ctx, "audit: encode before for %s %v: %v", entity, id, err)
+		}
+	}
+	if after != nil {
+		if params.AfterData, err = json.Marshal(after); err != nil {
+			Logf(ctx, "audit: encode after for %s %v: %v", entity, id, err)
+		}
+	}
+	if err := fs.db.CreateAuditEntry(ctx, params); err != nil {
+		Logf(ctx, "audit: record %s %s %v: %v", action, entity, id, err)
+	}
+}
+
+// RecordConfigChange notes in the audit log that a server setting, named
+// by its config file key, changed from before to after while the server
+// ran.
+func (fs *FinanceService) RecordConfigChange(ctx context.Context, key string, before, after interface{}) {
+	fs.audit(WithActor(ctx, "config"), AuditUpdate, EntityConfig, key, before, after)
+}
+
+func optText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
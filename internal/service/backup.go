@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/rrule"
+)
+
+// BackupVersion is the format version written by Backup. Restore accepts
+// documents up to this version.
+const BackupVersion = 1
+
+// ErrInvalidBackup wraps problems with a backup document itself, as opposed
+// to failures while loading it.
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// Backup is a complete copy of the user's data. Trashed rows are included
+// with their deleted_at set so the trash survives a move. The audit log is
+// history about this database and is not carried over. Documents written
+// before currencies existed have no currency on their rows; those rows are
+// restored in DefaultCurrency.
+type Backup struct {
+	Version      int                              `json:"version"`
+	CreatedAt    time.Time                        `json:"created_at"`
+	Transactions []database.Transactions          `json:"transactions"`
+	Recurring    []database.RecurringTransactions `json:"recurring"`
+	Settings     []database.Settings              `json:"settings"`
+	TaxDetails   []database.IncomeTaxDetails      `json:"income_tax_details"`
+	// SelfEmployment lists the IDs of transactions tagged as
+	// self-employment income.
+	SelfEmployment []int32                  `json:"self_employment_income,omitempty"`
+	ExchangeRates  []database.ExchangeRates `json:"exchange_rates,omitempty"`
+	Accounts       []database.Accounts      `json:"accounts,omitempty"`
+	Envelopes      []database.Envelopes     `json:"envelopes,omitempty"`
+	Scenarios      []database.Scenarios     `json:"scenarios,omitempty"`
+	// NotificationChannels are stored in full, webhook URLs and bot
+	// tokens included, so a restore can send notifications again.
+	NotificationChannels []database.NotificationChannels `json:"notification_channels,omitempty"`
+	Reminders            []database.RecurringReminders   `json:"recurring_reminders,omitempty"`
+	// Archived holds the transactions the archival job moved out of
+	// Transactions.
+	Archived []database.ArchivedTransactions `json:"archived_transactions,omitempty"`
+}
+
+// RestoreSummary counts what a restore loaded.
+type RestoreSummary struct {
+	Transactions   int `json:"transactions"`
+	Recurring      int `json:"recurring"`
+	Settings       int `json:"settings"`
+	TaxDetails     int `json:"income_tax_details"`
+	SelfEmployment int `json:"self_employment_income"`
+	ExchangeRates  int `json:"exchange_rates"`
+	Accounts       int `json:"accounts"`
+	Envelopes      int `json:"envelopes"`
+	Scenarios      int `json:"scenarios"`
+	// NotificationChannels counts the restored notification channels.
+	NotificationChannels int `json:"notification_channels"`
+	Reminders            int `json:"recurring_reminders"`
+	Archived             int `json:"archived_transactions"`
+}
+
+// Backup reads every transaction, recurring, setting, tax detail,
+// self-employment tag, exchange rate, account, envelope, saved scenario,
+// notification channel, recurring reminder and archived transaction.
+func (fs *FinanceService) Backup(ctx context.Context) (Backup, error) {
+	b := Backup{Version: BackupVersion, CreatedAt: time.Now().UTC()}
+	err := fs.withTx(ctx, func(q database.Querier) error {
+		var err error
+		if b.Transactions, err = q.ListTransactionsForBackup(ctx); err != nil {
+			return err
+		}
+		if b.Recurring, err = q.ListRecurringForBackup(ctx); err != nil {
+			return err
+		}
+		if b.Settings, err = q.GetAllSettings(ctx); err != nil {
+			return err
+		}
+		if b.TaxDetails, err = q.ListIncomeTaxDetails(ctx); err != nil {
+			return err
+		}
+		if b.SelfEmployment, err = q.ListSelfEmploymentIncome(ctx); err != nil {
+			return err
+		}
+		if b.ExchangeRates, err = q.ListExchangeRatesForBackup(ctx); err != nil {
+			return err
+		}
+		if b.Accounts, err = q.ListAccountsForBackup(ctx); err != nil {
+			return err
+		}
+		if b.Envelopes, err = q.ListEnvelopesForBackup(ctx); err != nil {
+			return err
+		}
+		if b.Scenarios, err = q.ListScenariosForBackup(ctx); err != nil {
+			return err
+		}
+		if b.NotificationChannels, err = q.ListNotificationChannelsForBackup(ctx); err != nil {
+			return err
+		}
+		if b.Reminders, err = q.ListRecurringReminders(ctx); err != nil {
+			return err
+		}
+		b.Archived, err = q.ListArchivedTransactionsForBackup(ctx)
+		return err
+	})
+	if err != nil {
+		return Backup{}, err
+	}
+	return b, nil
+}
+
+// Restore replaces all data with the contents of b in a single database
+// transaction: either everything is loaded or nothing changes. Row IDs are
+// kept so tax details and audit entries still line up.
+func (fs *FinanceService) Restore(ctx context.Context, b Backup) (RestoreSummary, error) {
+	if err := validateBackup(b); err != nil {
+		return RestoreSummary{}, err
+	}
+
+	err := fs.withTx(ctx, func(q database.Querier) error {
+		// Tax details and self-employment tags go with their transactions
+		// via ON DELETE CASCADE.
+		if err := q.DeleteAllTransactions(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteAllArchivedTransactions(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteAllRecurring(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteAllSettings(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteAllExchangeRates(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteAllEnvelopes(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteAllScenarios(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteAllNotificationChannels(ctx); err != nil {
+			return err
+		}
+		// Accounts go after the transactions that refer to them, and come
+		// back before them.
+		if err := q.DeleteAllAccounts(ctx); err != nil {
+			return err
+		}
+
+		for _, a := range b.Accounts {
+			// Backups taken before interest was tracked have neither field.
+			if !a.Balance.Valid {
+				a.Balance = makePgNumeric(0)
+			}
+			if a.Compounding == "" {
+				a.Compounding = CompoundMonthly
+			}
+			err := q.InsertBackupAccount(ctx, database.InsertBackupAccountParams{
+				ID:           a.ID,
+				Name:         a.Name,
+				Type:         a.Type,
+				StatementDay: a.StatementDay,
+				DueDay:       a.DueDay,
+				CreatedAt:    a.CreatedAt,
+				Balance:      a.Balance,
+				Apy:          a.Apy,
+				Compounding:  a.Compounding,
+			})
+			if err != nil {
+				return fmt.Errorf("account %d: %w", a.ID, err)
+			}
+		}
+
+		for _, t := range b.Transactions {
+			err := q.InsertBackupTransaction(ctx, database.InsertBackupTransactionParams{
+				ID:          t.ID,
+				Date:        t.Date,
+				Amount:      t.Amount,
+				Description: t.Description,
+				Type:        t.Type,
+				CreatedAt:   t.CreatedAt,
+				DeletedAt:   t.DeletedAt,
+				Category:    t.Category,
+				Currency:    currencyOrDefault(t.Currency),
+				AccountID:   t.AccountID,
+				Status:      statusOrDefault(t.Status),
+				Version:     max(t.Version, 1),
+			})
+			if err != nil {
+				return fmt.Errorf("transaction %d: %w", t.ID, err)
+			}
+		}
+		for _, t := range b.Archived {
+			err := q.InsertBackupArchivedTransaction(ctx, database.InsertBackupArchivedTransactionParams{
+				ID:          t.ID,
+				Date:        t.Date,
+				Amount:      t.Amount,
+				Description: t.Description,
+				Type:        t.Type,
+				CreatedAt:   t.CreatedAt,
+				Category:    t.Category,
+				Currency:    currencyOrDefault(t.Currency),
+				AccountID:   t.AccountID,
+				ArchivedAt:  t.ArchivedAt,
+			})
+			if err != nil {
+				return fmt.Errorf("archived transaction %d: %w", t.ID, err)
+			}
+		}
+		for _, r := range b.Recurring {
+			err := q.InsertBackupRecurring(ctx, database.InsertBackupRecurringParams{
+				ID:              r.ID,
+				Description:     r.Description,
+				Type:            r.Type,
+				Amount:          r.Amount,
+				StartDate:       r.StartDate,
+				Interval:        r.Interval,
+				DayOfWeek:       r.DayOfWeek,
+				DayOfMonth:      r.DayOfMonth,
+				EndDate:         r.EndDate,
+				Active:          r.Active,
+				DeletedAt:       r.DeletedAt,
+				Currency:        currencyOrDefault(r.Currency),
+				Prorate:         r.Prorate,
+				PausedFrom:      r.PausedFrom,
+				PausedUntil:     r.PausedUntil,
+				Version:         max(r.Version, 1),
+				OccurrenceCount: r.OccurrenceCount,
+				WeekOfMonth:     r.WeekOfMonth,
+				BusinessDay:     r.BusinessDay,
+				Rrule:           r.Rrule,
+				Category:        r.Category,
+				AccountID:       r.AccountID,
+			})
+			if err != nil {
+				return fmt.Errorf("recurring %d: %w", r.ID, err)
+			}
+		}
+		for _, s := range b.Settings {
+			err := q.InsertBackupSetting(ctx, database.InsertBackupSettingParams{
+				Key:       s.Key,
+				Value:     s.Value,
+				UpdatedAt: s.UpdatedAt,
+			})
+			if err != nil {
+				return fmt.Errorf("setting %q: %w", s.Key, err)
+			}
+		}
+		for _, x := range b.ExchangeRates {
+			err := q.InsertBackupExchangeRate(ctx, database.InsertBackupExchangeRateParams{
+				Currency:  x.Currency,
+				Rate:      x.Rate,
+				UpdatedAt: x.UpdatedAt,
+			})
+			if err != nil {
+				return fmt.Errorf("exchange rate %s: %w", x.Currency, err)
+			}
+		}
+		for _, e := range b.Envelopes {
+			err := q.InsertBackupEnvelope(ctx, database.InsertBackupEnvelopeParams(e))
+			if err != nil {
+				return fmt.Errorf("envelope %d: %w", e.ID, err)
+			}
+		}
+		for _, sc := range b.Scenarios {
+			err := q.InsertBackupScenario(ctx, database.InsertBackupScenarioParams(sc))
+			if err != nil {
+				return fmt.Errorf("scenario %d: %w", sc.ID, err)
+			}
+		}
+		for _, c := range b.NotificationChannels {
+			err := q.InsertBackupNotificationChannel(ctx, database.InsertBackupNotificationChannelParams(c))
+			if err != nil {
+				return fmt.Errorf("notification channel %d: %w", c.ID, err)
+			}
+		}
+		for _, rem := range b.Reminders {
+			if _, err := q.SetRecurringReminder(ctx, database.SetRecurringReminderParams(rem)); err != nil {
+				return fmt.Errorf("reminder for recurring %d: %w", rem.RecurringID, err)
+			}
+		}
+		for _, d := range b.TaxDetails {
+			err := q.UpsertIncomeTaxDetail(ctx, database.UpsertIncomeTaxDetailParams{
+				TransactionID: d.TransactionID,
+				GrossAmount:   d.GrossAmount,
+				TaxWithheld:   d.TaxWithheld,
+			})
+			if err != nil {
+				return fmt.Errorf("tax detail for transaction %d: %w", d.TransactionID, err)
+			}
+		}
+		for _, id := range b.SelfEmployment {
+			if err := q.TagSelfEmploymentIncome(ctx, id); err != nil {
+				return fmt.Errorf("self-employment tag for transaction %d: %w", id, err)
+			}
+		}
+
+		// Restored rows carry explicit IDs, so move the sequences past them.
+		if err := q.ResetTransactionsSequence(ctx); err != nil {
+			return err
+		}
+		if err := q.ResetRecurringSequence(ctx); err != nil {
+			return err
+		}
+		if err := q.ResetAccountsSequence(ctx); err != nil {
+			return err
+		}
+		if err := q.ResetEnvelopesSequence(ctx); err != nil {
+			return err
+		}
+		if err := q.ResetScenariosSequence(ctx); err != nil {
+			return err
+		}
+		return q.ResetNotificationChannelsSequence(ctx)
+	})
+	if err != nil {
+		return RestoreSummary{}, err
+	}
+
+	summary := RestoreSummary{
+		Transactions:   len(b.Transactions),
+		Recurring:      len(b.Recurring),
+		Settings:       len(b.Settings),
+		TaxDetails:     len(b.TaxDetails),
+		SelfEmployment: len(b.SelfEmployment),
+		ExchangeRates:  len(b.ExchangeRates),
+		Accounts:       len(b.Accounts),
+		Envelopes:      len(b.Envelopes),
+		Scenarios:      len(b.Scenarios),
+
+		NotificationChannels: len(b.NotificationChannels),
+		Reminders:            len(b.Reminders),
+		Archived:             len(b.Archived),
+	}
+	fs.events.Publish(ctx, EventDataRestored, summary)
+	return summary, nil
+}
+
+// validateBackup catches documents that would fail part way through or
+// silently lose data, before anything is deleted.
+func validateBackup(b Backup) error {
+	if b.Version < 1 || b.Version > BackupVersion {
+		return fmt.Errorf("%w: unsupported version %d (expected 1 to %d)", ErrInvalidBackup, b.Version, BackupVersion)
+	}
+	txIDs := make(map[int32]bool, len(b.Transactions))
+	for _, t := range b.Transactions {
+		if txIDs[t.ID] {
+			return fmt.Errorf("%w: duplicate transaction id %d", ErrInvalidBackup, t.ID)
+		}
+		txIDs[t.ID] = true
+	}
+	// Archived transactions keep the IDs they had, so they can't share one
+	// with each other or with a live transaction.
+	archivedIDs := make(map[int32]bool, len(b.Archived))
+	for _, t := range b.Archived {
+		if txIDs[t.ID] || archivedIDs[t.ID] {
+			return fmt.Errorf("%w: duplicate transaction id %d", ErrInvalidBackup, t.ID)
+		}
+		archivedIDs[t.ID] = true
+	}
+	recIDs := make(map[int32]bool, len(b.Recurring))
+	for _, r := range b.Recurring {
+		if recIDs[r.ID] {
+			return fmt.Errorf("%w: duplicate recurring id %d", ErrInvalidBackup, r.ID)
+		}
+		if r.PausedFrom.Valid != r.PausedUntil.Valid || (r.PausedFrom.Valid && r.PausedUntil.Time.Before(r.PausedFrom.Time)) {
+			return fmt.Errorf("%w: recurring %d has an invalid pause range", ErrInvalidBackup, r.ID)
+		}
+		if r.Prorate && r.Interval != database.RecurrenceIntervalYearly {
+			return fmt.Errorf("%w: recurring %d is prorated but %s", ErrInvalidBackup, r.ID, r.Interval)
+		}
+		if r.Rrule.Valid != (r.Interval == database.RecurrenceIntervalCustom) {
+			return fmt.Errorf("%w: recurring %d must have an rrule exactly when its interval is custom", ErrInvalidBackup, r.ID)
+		}
+		if _, err := rrule.Parse(r.Rrule.String); r.Rrule.Valid && err != nil {
+			return fmt.Errorf("%w: recurring %d: invalid rrule: %v", ErrInvalidBackup, r.ID, err)
+		}
+		recIDs[r.ID] = true
+	}
+	accountIDs := make(map[int32]bool, len(b.Accounts))
+	for _, a := range b.Accounts {
+		if accountIDs[a.ID] {
+			return fmt.Errorf("%w: duplicate account id %d", ErrInvalidBackup, a.ID)
+		}
+		accountIDs[a.ID] = true
+	}
+	envelopeIDs := make(map[int32]bool, len(b.Envelopes))
+	for _, e := range b.Envelopes {
+		if envelopeIDs[e.ID] {
+			return fmt.Errorf("%w: duplicate envelope id %d", ErrInvalidBackup, e.ID)
+		}
+		envelopeIDs[e.ID] = true
+	}
+	scenarioIDs := make(map[int32]bool, len(b.Scenarios))
+	for _, sc := range b.Scenarios {
+		if scenarioIDs[sc.ID] {
+			return fmt.Errorf("%w: duplicate scenario id %d", ErrInvalidBackup, sc.ID)
+		}
+		scenarioIDs[sc.ID] = true
+	}
+	channelIDs := make(map[int32]bool, len(b.NotificationChannels))
+	for _, c := range b.NotificationChannels {
+		if channelIDs[c.ID] {
+			return fmt.Errorf("%w: duplicate notification channel id %d", ErrInvalidBackup, c.ID)
+		}
+		channelIDs[c.ID] = true
+	}
+	for _, rem := range b.Reminders {
+		if !recIDs[rem.RecurringID] {
+			return fmt.Errorf("%w: reminder refers to missing recurring %d", ErrInvalidBackup, rem.RecurringID)
+		}
+	}
+	for _, t := range b.Transactions {
+		if t.AccountID.Valid && !accountIDs[t.AccountID.Int32] {
+			return fmt.Errorf("%w: transaction %d refers to missing account %d", ErrInvalidBackup, t.ID, t.AccountID.Int32)
+		}
+	}
+	for _, t := range b.Archived {
+		if t.AccountID.Valid && !accountIDs[t.AccountID.Int32] {
+			return fmt.Errorf("%w: archived transaction %d refers to missing account %d", ErrInvalidBackup, t.ID, t.AccountID.Int32)
+		}
+		if _, err := NormalizeCurrency(currencyOrDefault(t.Currency)); err != nil {
+			return fmt.Errorf("%w: archived transaction %d: %v", ErrInvalidBackup, t.ID, err)
+		}
+	}
+	for _, r := range b.Recurring {
+		if r.AccountID.Valid && !accountIDs[r.AccountID.Int32] {
+			return fmt.Errorf("%w: recurring %d refers to missing account %d", ErrInvalidBackup, r.ID, r.AccountID.Int32)
+		}
+	}
+	for _, d := range b.TaxDetails {
+		if !txIDs[d.TransactionID] {
+			return fmt.Errorf("%w: tax detail refers to missing transaction %d", ErrInvalidBackup, d.TransactionID)
+		}
+	}
+	for _, id := range b.SelfEmployment {
+		if !txIDs[id] {
+			return fmt.Errorf("%w: self-employment tag refers to missing transaction %d", ErrInvalidBackup, id)
+		}
+	}
+	for _, t := range b.Transactions {
+		if _, err := NormalizeCurrency(currencyOrDefault(t.Currency)); err != nil {
+			return fmt.Errorf("%w: transaction %d: %v", ErrInvalidBackup, t.ID, err)
+		}
+		if _, err := normalizeStatus(t.Status); err != nil {
+			return fmt.Errorf("%w: transaction %d: %v", ErrInvalidBackup, t.ID, err)
+		}
+	}
+	for _, r := range b.Recurring {
+		if _, err := NormalizeCurrency(currencyOrDefault(r.Currency)); err != nil {
+			return fmt.Errorf("%w: recurring %d: %v", ErrInvalidBackup, r.ID, err)
+		}
+	}
+	return nil
+}
+
+// statusOrDefault fills in the status for transactions from backups made
+// before there was one.
+func statusOrDefault(status string) string {
+	if status == "" {
+		return StatusCleared
+	}
+	return status
+}
+
+func currencyOrDefault(code string) string {
+	if code == "" {
+		return DefaultCurrency
+	}
+	return code
+}
+
+// withTx runs fn against a single database transaction, committing if fn
+// succeeds. Services built on a bare Querier (as in tests) have no pool to
+// begin a transaction on, so fn runs directly against it. Inside DryRun,
+// the transaction is a savepoint in the one DryRun rolls back.
+func (fs *FinanceService) withTx(ctx context.Context, fn func(database.Querier) error) error {
+	if fs.pool == nil {
+		return fn(fs.db)
+	}
+	begin := fs.pool.Begin
+	if tx := dryRunTx(ctx); tx != nil {
+		begin = tx.Begin
+	}
+	tx, err := begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(fs.queries(database.New(tx))); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
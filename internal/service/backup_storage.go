@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/household"
+	"github.com/jdelles/currentz/internal/storage/blob"
+)
+
+// backupPrefix is where the default household's stored backups live in
+// the blob store.
+const backupPrefix = "backups/"
+
+// backupDir is where the stored backups of the household ctx works on live.
+// Other households' are under their own prefix, so none sees another's.
+func backupDir(ctx context.Context) string {
+	if id := household.FromContext(ctx); id != household.Default {
+		return fmt.Sprintf("households/%d/%s", id, backupPrefix)
+	}
+	return backupPrefix
+}
+
+// ErrBackupNotFound is returned for a stored backup name that doesn't exist.
+var ErrBackupNotFound = errors.New("backup not found")
+
+// StoredBackup is a backup document kept in the blob store. Name is what
+// the other stored-backup methods take.
+type StoredBackup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SaveBackup takes a backup and writes it to the blob store, named after
+// the time it was taken.
+func (fs *FinanceService) SaveBackup(ctx context.Context) (StoredBackup, error) {
+	store, err := fs.blobStore(ctx)
+	if err != nil {
+		return StoredBackup{}, err
+	}
+	b, err := fs.Backup(ctx)
+	if err != nil {
+		return StoredBackup{}, err
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return StoredBackup{}, err
+	}
+	name := "currentz-backup-" + b.CreatedAt.Format("20060102T150405Z") + ".json"
+	if err := store.Put(ctx, backupDir(ctx)+name, bytes.NewReader(data)); err != nil {
+		return StoredBackup{}, err
+	}
+	return StoredBackup{Name: name, Size: int64(len(data)), CreatedAt: b.CreatedAt}, nil
+}
+
+// ListStoredBackups lists the stored backups, newest first.
+func (fs *FinanceService) ListStoredBackups(ctx context.Context) ([]StoredBackup, error) {
+	store, err := fs.blobStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dir := backupDir(ctx)
+	infos, err := store.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]StoredBackup, 0, len(infos))
+	for _, info := range infos {
+		name := strings.TrimPrefix(info.Key, dir)
+		if !validBackupName(name) {
+			continue
+		}
+		out = append(out, StoredBackup{Name: name, Size: info.Size, CreatedAt: info.Modified.UTC()})
+	}
+	// Names sort by the time in them.
+	sort.Slice(out, func(i, j int) bool { return out[i].Name > out[j].Name })
+	return out, nil
+}
+
+// OpenStoredBackup reads the stored backup called name. The caller closes
+// the reader.
+func (fs *FinanceService) OpenStoredBackup(ctx context.Context, name string) (io.ReadCloser, error) {
+	store, err := fs.blobStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !validBackupName(name) {
+		return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+	}
+	rc, err := store.Get(ctx, backupDir(ctx)+name)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+	}
+	return rc, err
+}
+
+// StoredBackupURL returns a temporary link that downloads the stored
+// backup called name from the blob store, or "" if the store can't make
+// one. It doesn't check that the backup exists.
+func (fs *FinanceService) StoredBackupURL(ctx context.Context, name string) (string, error) {
+	if _, err := fs.blobStore(ctx); err != nil {
+		return "", err
+	}
+	if !validBackupName(name) {
+		return "", fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+	}
+	return fs.downloadURL(ctx, backupDir(ctx)+name, blob.DownloadOptions{Filename: name, ContentType: "application/json"})
+}
+
+func validBackupName(name string) bool {
+	return strings.HasPrefix(name, "currentz-backup-") && strings.HasSuffix(name, ".json") &&
+		!strings.Contains(name, "/") && blob.ValidKey(name)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBackup(t *testing.T) {
+	ok := Backup{
+		Version:      BackupVersion,
+		Transactions: []Transaction{{ID: 1}, {ID: 2}},
+		Recurring:    []Recurring{{ID: 1}},
+		TaxDetails:   []database.IncomeTaxDetails{{TransactionID: 2}},
+	}
+	assert.NoError(t, validateBackup(ok))
+
+	tests := []struct {
+		want   string
+		mutate func(b *Backup)
+	}{
+		{"unsupported version 2", func(b *Backup) { b.Version = BackupVersion + 1 }},
+		{"unsupported version 0", func(b *Backup) { b.Version = 0 }},
+		{"duplicate transaction id 1", func(b *Backup) { b.Transactions = []Transaction{{ID: 1}, {ID: 1}} }},
+		{"duplicate recurring id 1", func(b *Backup) { b.Recurring = []Recurring{{ID: 1}, {ID: 1}} }},
+		{"missing transaction 9", func(b *Backup) { b.TaxDetails = []database.IncomeTaxDetails{{TransactionID: 9}} }},
+		{"missing recurring 7", func(b *Backup) { b.Reminders = []database.RecurringReminders{{RecurringID: 7, DaysBefore: 3}} }},
+		{"duplicate account id 3", func(b *Backup) { b.Accounts = []database.Accounts{{ID: 3}, {ID: 3}} }},
+		{"transaction 2 refers to missing account 4", func(b *Backup) {
+			b.Transactions = []Transaction{{ID: 1}, {ID: 2, AccountID: pgtype.Int4{Int32: 4, Valid: true}}}
+		}},
+		{"transaction 2: invalid currency", func(b *Backup) { b.Transactions = []Transaction{{ID: 1}, {ID: 2, Currency: "EURO"}} }},
+		{"transaction 2: invalid status", func(b *Backup) { b.Transactions = []Transaction{{ID: 1}, {ID: 2, Status: "bounced"}} }},
+		{"duplicate transaction id 2", func(b *Backup) { b.Archived = []database.ArchivedTransactions{{ID: 2}} }},
+		{"archived transaction 5 refers to missing account 4", func(b *Backup) {
+			b.Archived = []database.ArchivedTransactions{{ID: 5, AccountID: pgtype.Int4{Int32: 4, Valid: true}}}
+		}},
+	}
+	for _, tt := range tests {
+		b := ok
+		tt.mutate(&b)
+		err := validateBackup(b)
+		assert.ErrorIs(t, err, ErrInvalidBackup, tt.want)
+		assert.ErrorContains(t, err, tt.want)
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+const (
+	budgetSettingPrefix = "budget:"
+	lowBalanceSetting   = "low_balance_threshold"
+
+	// maxBudgetCategory keeps budget:<category> within the settings key limit.
+	maxBudgetCategory = 50 - len(budgetSettingPrefix)
+)
+
+// Warning codes returned alongside a newly created expense.
+const (
+	WarningOverBudget = "over_budget"
+	WarningLowBalance = "low_balance"
+)
+
+// Warning is a non-fatal heads-up about a transaction that was saved. Only
+// the fields relevant to Code are set.
+type Warning struct {
+	Code      string     `json:"code"`
+	Message   string     `json:"message"`
+	Category  string     `json:"category,omitempty"`
+	Month     string     `json:"month,omitempty"`
+	Budget    float64    `json:"budget,omitempty"`
+	Spent     float64    `json:"spent,omitempty"`
+	Date      *time.Time `json:"date,omitempty"`
+	Balance   *float64   `json:"balance,omitempty"`
+	Threshold *float64   `json:"threshold,omitempty"`
+}
+
+// ExpenseInput describes an expense to record. Amount is positive.
+type ExpenseInput struct {
+	Date        time.Time
+	Amount      float64
+	Description string
+	Currency    string
+	Category    string
+	// AccountID is the account charged, if not cash. Credit card purchases
+	// reach the forecast on their statement's due date.
+	AccountID *int32
+	// Status is StatusPending for an expense that hasn't cleared the bank,
+	// like a check not yet cashed. It defaults to StatusCleared.
+	Status string
+}
+
+// Budgets returns the monthly spending limit per category, in the base
+// currency.
+func (fs *FinanceService) Budgets(ctx context.Context) (map[string]float64, error) {
+	settings, err := fs.db.GetAllSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64)
+	for _, s := range settings {
+		category, ok := strings.CutPrefix(s.Key, budgetSettingPrefix)
+		if !ok {
+			continue
+		}
+		limit, err := strconv.ParseFloat(s.Value, 64)
+		if err != nil {
+			continue
+		}
+		out[category] = limit
+	}
+	return out, nil
+}
+
+// SetBudget sets the monthly spending limit for a category. Categories are
+// matched case-insensitively.
+func (fs *FinanceService) SetBudget(ctx context.Context, category string, limit float64) error {
+	key, err := budgetKey(category)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 {
+		return fmt.Errorf("budget must be positive")
+	}
+	return fs.updateSetting(ctx, key, money.FromFloat(limit).String())
+}
+
+// DeleteBudget removes a category's limit.
+func (fs *FinanceService) DeleteBudget(ctx context.Context, category string) error {
+	key, err := budgetKey(category)
+	if err != nil {
+		return err
+	}
+	prev, err := fs.db.GetSetting(ctx, key)
+	if err != nil {
+		return fmt.Errorf("no budget for %q", category)
+	}
+	if err := fs.db.DeleteSetting(ctx, key); err != nil {
+		return err
+	}
+	fs.audit(ctx, AuditDelete, EntitySetting, key, map[string]string{"key": key, "value": prev}, nil)
+	return nil
+}
+
+// LowBalanceThreshold is the balance the forecast should stay above. ok is
+// false when neither one nor a default is set, and no low-balance warnings
+// are given.
+func (fs *FinanceService) LowBalanceThreshold(ctx context.Context) (threshold float64, ok bool, err error) {
+	value, err := fs.db.GetSetting(ctx, lowBalanceSetting)
+	if err != nil {
+		if d := fs.settingDefaults().LowBalanceThreshold; d != nil {
+			return *d, true, nil
+		}
+		return 0, false, nil
+	}
+	threshold, err = strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return threshold, true, nil
+}
+
+func (fs *FinanceService) SetLowBalanceThreshold(ctx context.Context, threshold float64) error {
+	return fs.updateSetting(ctx, lowBalanceSetting, money.FromFloat(threshold).String())
+}
+
+// ClearLowBalanceThreshold turns low-balance warnings off, or back to the
+// default threshold if there is one.
+func (fs *FinanceService) ClearLowBalanceThreshold(ctx context.Context) error {
+	return fs.deleteSetting(ctx, lowBalanceSetting)
+}
+
+func budgetKey(category string) (string, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return "", fmt.Errorf("category is required")
+	}
+	if len(category) > maxBudgetCategory {
+		return "", fmt.Errorf("category is longer than %d characters", maxBudgetCategory)
+	}
+	return budgetSettingPrefix + category, nil
+}
+
+// RecordExpense saves an expense and reports whether it pushed its category
+// over budget or the forecast below the low-balance threshold. The expense
+// is kept even if the warnings cannot be worked out.
+func (fs *FinanceService) RecordExpense(ctx context.Context, in ExpenseInput) (Transaction, []Warning, error) {
+	category := strings.TrimSpace(in.Category)
+	tx, err := fs.createTransaction(ctx, database.CreateTransactionParams{
+		Date:        makePgDate(in.Date),
+		Amount:      makePgNumeric(-in.Amount),
+		Description: in.Description,
+		Type:        "expense",
+		Currency:    in.Currency,
+		Category:    pgtype.Text{String: category, Valid: category != ""},
+		AccountID:   optInt4(in.AccountID),
+		Status:      in.Status,
+	})
+	if err != nil {
+		return Transaction{}, nil, err
+	}
+
+	warnings, err := fs.expenseWarnings(ctx, tx)
+	if err != nil {
+		Logf(ctx, "budget warnings for transaction %d: %v", tx.ID, err)
+		return tx, nil, nil
+	}
+	return tx, warnings, nil
+}
+
+func (fs *FinanceService) expenseWarnings(ctx context.Context, tx Transaction) ([]Warning, error) {
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	amt, err := NumericToMoney(tx.Amount)
+	if err != nil {
+		return nil, err
+	}
+	// amt is negative; cost is what this expense took out, in base currency.
+	cost, err := conv.toBase(tx.Currency, amt.Neg())
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []Warning
+	if tx.Category.Valid {
+		w, err := fs.budgetWarning(ctx, tx, cost, conv)
+		if err != nil {
+			return nil, err
+		}
+		if w != nil {
+			warnings = append(warnings, *w)
+		}
+	}
+	w, err := fs.lowBalanceWarning(ctx, tx, cost)
+	if err != nil {
+		return nil, err
+	}
+	if w != nil {
+		warnings = append(warnings, *w)
+	}
+	return warnings, nil
+}
+
+// budgetWarning checks recorded spending in the expense's category for its
+// calendar month. Projected recurrings do not count against the budget.
+func (fs *FinanceService) budgetWarning(ctx context.Context, tx Transaction, cost money.Money, conv converter) (*Warning, error) {
+	key, err := budgetKey(tx.Category.String)
+	if err != nil {
+		return nil, nil
+	}
+	value, err := fs.db.GetSetting(ctx, key)
+	if err != nil {
+		return nil, nil
+	}
+	budget, err := money.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("budget for %q: %w", tx.Category.String, err)
+	}
+
+	d := tx.Date.Time
+	first := time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	txs, err := fs.db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
+		Date:   makePgDate(first),
+		Date_2: makePgDate(last),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var spent money.Money
+	for _, t := range txs {
+		if t.Type != "expense" || !strings.EqualFold(t.Category.String, tx.Category.String) {
+			continue
+		}
+		amt, err := NumericToMoney(t.Amount)
+		if err != nil {
+			continue
+		}
+		if amt, err = conv.toBase(t.Currency, amt.Neg()); err != nil {
+			return nil, err
+		}
+		spent = spent.Add(amt)
+	}
+
+	if spent <= budget || spent.Sub(cost) > budget {
+		return nil, nil
+	}
+	return &Warning{
+		Code:     WarningOverBudget,
+		Message:  fmt.Sprintf("%s spending for %s is %s, over its %s budget", tx.Category.String, first.Format("January 2006"), spent, budget),
+		Category: tx.Category.String,
+		Month:    first.Format("2006-01"),
+		Budget:   budget.Float64(),
+		Spent:    spent.Float64(),
+	}, nil
+}
+
+// lowBalanceWarning reports the first forecast day below the threshold, if
+// this expense is what took it there.
+func (fs *FinanceService) lowBalanceWarning(ctx context.Context, tx Transaction, cost money.Money) (*Warning, error) {
+	threshold, ok, err := fs.LowBalanceThreshold(ctx)
+	if err != nil || !ok {
+		return nil, err
+	}
+	balance, err := fs.GetStartingBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	forecast, err := fs.Calculate90DayForecast(ctx, balance)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := fs.cashDay(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	first := firstDropBelow(forecast, day, cost.Float64(), threshold)
+	if first == nil {
+		return nil, nil
+	}
+	date := first.Date
+	low := money.FromFloat(first.Balance).Float64()
+	return &Warning{
+		Code:      WarningLowBalance,
+		Message:   fmt.Sprintf("forecast balance drops to %.2f on %s, below the %.2f threshold", first.Balance, date.Format("Jan 2, 2006"), threshold),
+		Date:      &date,
+		Balance:   &low,
+		Threshold: &threshold,
+	}, nil
+}
+
+// firstDropBelow returns the first forecast day under threshold, provided
+// the forecast without an expense of cost on day never went under it.
+func firstDropBelow(forecast []DailyCashFlow, day time.Time, cost, threshold float64) *DailyCashFlow {
+	var first *DailyCashFlow
+	for i, f := range forecast {
+		without := f.Balance
+		if !f.Date.Before(day) {
+			without += cost
+		}
+		if without < threshold {
+			return nil
+		}
+		if f.Balance < threshold && first == nil {
+			first = &forecast[i]
+		}
+	}
+	return first
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirstDropBelow(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	forecast := func(balances ...float64) []DailyCashFlow {
+		out := make([]DailyCashFlow, len(balances))
+		for i, b := range balances {
+			out[i] = DailyCashFlow{Date: start.AddDate(0, 0, i), Balance: b}
+		}
+		return out
+	}
+	day2 := start.AddDate(0, 0, 2)
+
+	// A 300 expense on day 2 takes 600 down to 300, under 500.
+	got := firstDropBelow(forecast(1000, 800, 300, 700), day2, 300, 500)
+	require.NotNil(t, got)
+	assert.Equal(t, day2, got.Date)
+	assert.Equal(t, 300.0, got.Balance)
+
+	// Already under the threshold on day 1, so this expense did not cause it.
+	assert.Nil(t, firstDropBelow(forecast(1000, 400, 100, 700), day2, 300, 500))
+
+	// Stays above.
+	assert.Nil(t, firstDropBelow(forecast(1000, 900, 800, 700), day2, 100, 500))
+
+	// A dip after the expense day still counts when the expense caused it.
+	got = firstDropBelow(forecast(1000, 900, 800, 450), day2, 100, 500)
+	require.NotNil(t, got)
+	assert.Equal(t, start.AddDate(0, 0, 3), got.Date)
+}
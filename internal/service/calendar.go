@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// CalendarDay is what falls on one day of a MonthCalendar.
+type CalendarDay struct {
+	Transactions []ListedTransaction   `json:"transactions"`
+	Totals       TransactionAggregates `json:"totals"`
+}
+
+// MonthCalendar is a month of transactions laid out for a calendar grid.
+// Days has an entry for every day of the month, keyed YYYY-MM-DD, so days
+// with nothing on them need no special casing.
+type MonthCalendar struct {
+	Month string `json:"month"`
+	// FirstWeekday is the weekday of the 1st, 0 for Sunday, which is
+	// how many cells a Sunday-first grid leaves blank before it.
+	FirstWeekday int                    `json:"first_weekday"`
+	DaysInMonth  int                    `json:"days_in_month"`
+	Days         map[string]CalendarDay `json:"days"`
+	Totals       TransactionAggregates  `json:"totals"`
+}
+
+// Calendar lists the recorded transactions and recurring occurrences of
+// the month containing month, by day, with each day's totals in the base
+// currency.
+func (fs *FinanceService) Calendar(ctx context.Context, month time.Time) (MonthCalendar, error) {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+
+	txs, err := fs.GetTransactionsWithRecurringsBetween(ctx, first, last)
+	if err != nil {
+		return MonthCalendar{}, err
+	}
+	grouped, err := fs.GroupTransactions(ctx, txs, GroupByDay)
+	if err != nil {
+		return MonthCalendar{}, err
+	}
+
+	cal := MonthCalendar{
+		Month:        first.Format("2006-01"),
+		FirstWeekday: int(first.Weekday()),
+		DaysInMonth:  last.Day(),
+		Days:         make(map[string]CalendarDay, last.Day()),
+		Totals:       grouped.Totals,
+	}
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		cal.Days[d.Format("2006-01-02")] = CalendarDay{Transactions: []ListedTransaction{}}
+	}
+	for _, g := range grouped.Groups {
+		cal.Days[g.Start.Format("2006-01-02")] = CalendarDay{Transactions: g.Transactions, Totals: g.Totals}
+	}
+	return cal, nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+
+	require.NoError(t, fs.AddIncome(ctx, day("2025-09-15"), 3000, "Paycheck", "USD"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-15"), 45, "Groceries", "USD"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-10-01"), 99, "Next month", "USD"))
+	_, err := fs.CreateRecurringSimple(ctx, RecurringInput{
+		Description: "Rent", Type: "expense", Amount: 1000, StartDate: day("2025-01-01"), Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+
+	cal, err := fs.Calendar(ctx, day("2025-09-20"))
+	require.NoError(t, err)
+	assert.Equal(t, "2025-09", cal.Month)
+	assert.Equal(t, 1, cal.FirstWeekday) // Monday
+	assert.Equal(t, 30, cal.DaysInMonth)
+	assert.Len(t, cal.Days, 30)
+
+	rent := cal.Days["2025-09-01"]
+	require.Len(t, rent.Transactions, 1)
+	assert.Equal(t, SourceRecurring, rent.Transactions[0].Source)
+	assert.Equal(t, -1000.0, rent.Totals.Net)
+
+	payday := cal.Days["2025-09-15"]
+	assert.Len(t, payday.Transactions, 2)
+	assert.Equal(t, 2955.0, payday.Totals.Net)
+
+	quiet := cal.Days["2025-09-02"]
+	assert.NotNil(t, quiet.Transactions)
+	assert.Empty(t, quiet.Transactions)
+	assert.Zero(t, quiet.Totals.Net)
+
+	assert.Equal(t, int64(3), cal.Totals.Count)
+	assert.Equal(t, 1955.0, cal.Totals.Net)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// RecurringContribution is how much one recurring adds to or takes from the
+// forecast over a horizon, in the base currency.
+type RecurringContribution struct {
+	ID          int32   `json:"id"`
+	Description string  `json:"description"`
+	Type        string  `json:"type"`
+	Interval    string  `json:"interval"`
+	Occurrences int     `json:"occurrences"`
+	Total       float64 `json:"total"`
+	// Share is this recurring's fraction of all recurring money moved over
+	// the horizon, in either direction.
+	Share float64 `json:"share"`
+}
+
+// RecurringContributions ranks active recurrings by the size of their total
+// effect on the next days of the forecast, largest first.
+func (fs *FinanceService) RecurringContributions(ctx context.Context, days int) ([]RecurringContribution, error) {
+	if days <= 0 {
+		days = 90
+	}
+	start := fs.Today(ctx)
+	end := start.AddDate(0, 0, days-1)
+
+	recs, err := fs.db.ListActiveRecurring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rankContributions(recs, start, end, conv)
+}
+
+func rankContributions(recs []Recurring, start, end time.Time, conv converter) ([]RecurringContribution, error) {
+	out := make([]RecurringContribution, 0, len(recs))
+	var moved money.Money
+	for _, r := range recs {
+		c := RecurringContribution{
+			ID:          r.ID,
+			Description: r.Description,
+			Type:        r.Type,
+			Interval:    string(r.Interval),
+		}
+		var total money.Money
+		for _, tx := range expandOne(r, start, end) {
+			amt, err := NumericToMoney(tx.Amount)
+			if err != nil {
+				continue
+			}
+			if amt, err = conv.toBase(tx.Currency, amt); err != nil {
+				return nil, err
+			}
+			c.Occurrences++
+			total = total.Add(amt)
+		}
+		c.Total = total.Float64()
+		moved = moved.Add(total.Abs())
+		out = append(out, c)
+	}
+
+	for i := range out {
+		if moved > 0 {
+			share := float64(money.FromFloat(out[i].Total).Abs()) / float64(moved)
+			out[i].Share = math.Round(share*10000) / 10000
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return math.Abs(out[i].Total) > math.Abs(out[j].Total)
+	})
+	return out, nil
+}
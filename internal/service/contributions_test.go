@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankContributions(t *testing.T) {
+	recs := []Recurring{
+		{ID: 1, Description: "Gym", Type: "expense", Amount: makePgNumeric(40), Interval: "monthly",
+			StartDate: makePgDate(day("2025-01-10")), Currency: "EUR"},
+		{ID: 2, Description: "Rent", Type: "expense", Amount: makePgNumeric(1200), Interval: "monthly",
+			StartDate: makePgDate(day("2025-01-01")), DayOfMonth: pgtype.Int4{Int32: 1, Valid: true}},
+		{ID: 3, Description: "Salary", Type: "income", Amount: makePgNumeric(2500), Interval: "biweekly",
+			StartDate: makePgDate(day("2025-09-05"))},
+	}
+	conv := converter{base: "USD", rates: map[string]float64{"EUR": 1.1}}
+
+	got, err := rankContributions(recs, day("2025-09-01"), day("2025-11-29"), conv)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+
+	assert.Equal(t, "Salary", got[0].Description)
+	assert.Equal(t, 7, got[0].Occurrences)
+	assert.Equal(t, 17500.0, got[0].Total)
+
+	assert.Equal(t, "Rent", got[1].Description)
+	assert.Equal(t, 3, got[1].Occurrences)
+	assert.Equal(t, -3600.0, got[1].Total)
+
+	assert.Equal(t, "Gym", got[2].Description)
+	assert.InDelta(t, -132.0, got[2].Total, 1e-9)
+
+	assert.InDelta(t, 1.0, got[0].Share+got[1].Share+got[2].Share, 1e-3)
+	assert.InDelta(t, 17500.0/21232.0, got[0].Share, 1e-4)
+}
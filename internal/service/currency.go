@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// DefaultCurrency is the base currency until one is chosen. Rows written
+// before currencies were tracked are in this currency too.
+const DefaultCurrency = "USD"
+
+const baseCurrencyKey = "base_currency"
+
+// EntityExchangeRate is the audit entity for exchange rate changes.
+const EntityExchangeRate = "exchange_rate"
+
+var (
+	// ErrInvalidCurrency is returned for codes that are not three letters.
+	ErrInvalidCurrency = errors.New("invalid currency")
+	// ErrNoExchangeRate is returned when an amount cannot be converted to
+	// the base currency because no rate is stored for its currency.
+	ErrNoExchangeRate = errors.New("no exchange rate")
+)
+
+// ExchangeRate is the value of one unit of Currency in the base currency.
+type ExchangeRate = database.ExchangeRates
+
+// NormalizeCurrency upper-cases an ISO 4217 code and checks its shape. It
+// does not check the code against the list of currencies in use.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("%w %q (expected a three-letter ISO 4217 code)", ErrInvalidCurrency, code)
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("%w %q (expected a three-letter ISO 4217 code)", ErrInvalidCurrency, code)
+		}
+	}
+	return code, nil
+}
+
+// BaseCurrency is the currency forecasts and balances are reported in:
+// the default one, or DefaultCurrency, until chosen.
+func (fs *FinanceService) BaseCurrency(ctx context.Context) (string, error) {
+	value, err := fs.db.GetSetting(ctx, baseCurrencyKey)
+	if err != nil || value == "" {
+		if d := fs.settingDefaults().BaseCurrency; d != "" {
+			return d, nil
+		}
+		return DefaultCurrency, nil
+	}
+	return value, nil
+}
+
+// SetBaseCurrency changes the reporting currency. Stored rates are relative
+// to the base, so they need updating after a change.
+func (fs *FinanceService) SetBaseCurrency(ctx context.Context, code string) error {
+	code, err := NormalizeCurrency(code)
+	if err != nil {
+		return err
+	}
+	if err := fs.updateSetting(ctx, baseCurrencyKey, code); err != nil {
+		return err
+	}
+	fs.events.Publish(ctx, EventCurrencyChanged, map[string]string{"base_currency": code})
+	return nil
+}
+
+func (fs *FinanceService) ListExchangeRates(ctx context.Context) ([]ExchangeRate, error) {
+	return fs.db.ListExchangeRates(ctx)
+}
+
+// SetExchangeRate stores how much one unit of code is worth in the base
+// currency.
+func (fs *FinanceService) SetExchangeRate(ctx context.Context, code string, rate float64) (ExchangeRate, error) {
+	code, err := NormalizeCurrency(code)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	if rate <= 0 {
+		return ExchangeRate{}, fmt.Errorf("rate must be positive")
+	}
+	base, err := fs.BaseCurrency(ctx)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	if code == base {
+		return ExchangeRate{}, fmt.Errorf("%s is the base currency", code)
+	}
+
+	saved, err := fs.db.UpsertExchangeRate(ctx, database.UpsertExchangeRateParams{
+		Currency: code,
+		Rate:     makePgNumeric(rate),
+	})
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	fs.audit(ctx, AuditUpdate, EntityExchangeRate, code, nil, saved)
+	fs.events.Publish(ctx, EventCurrencyChanged, saved)
+	return saved, nil
+}
+
+// DeleteExchangeRate removes the rate for code. Forecasts fail until it is
+// set again if anything still uses that currency.
+func (fs *FinanceService) DeleteExchangeRate(ctx context.Context, code string) error {
+	code, err := NormalizeCurrency(code)
+	if err != nil {
+		return err
+	}
+	n, err := fs.db.DeleteExchangeRate(ctx, code)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w for %s", ErrNoExchangeRate, code)
+	}
+	fs.audit(ctx, AuditDelete, EntityExchangeRate, code, map[string]string{"currency": code}, nil)
+	fs.events.Publish(ctx, EventCurrencyChanged, map[string]string{"deleted": code})
+	return nil
+}
+
+// resolveCurrency normalizes code, defaulting to the base currency.
+func (fs *FinanceService) resolveCurrency(ctx context.Context, code string) (string, error) {
+	if strings.TrimSpace(code) == "" {
+		return fs.BaseCurrency(ctx)
+	}
+	return NormalizeCurrency(code)
+}
+
+// converter turns amounts into the base currency using the stored rates.
+type converter struct {
+	base  string
+	rates map[string]float64
+}
+
+func (fs *FinanceService) converter(ctx context.Context) (converter, error) {
+	base, err := fs.BaseCurrency(ctx)
+	if err != nil {
+		return converter{}, err
+	}
+	rows, err := fs.db.ListExchangeRates(ctx)
+	if err != nil {
+		return converter{}, err
+	}
+	c := converter{base: base, rates: make(map[string]float64, len(rows))}
+	for _, r := range rows {
+		rate, err := NumericToFloat64(r.Rate)
+		if err != nil {
+			return converter{}, fmt.Errorf("rate for %s: %w", r.Currency, err)
+		}
+		c.rates[r.Currency] = rate
+	}
+	return c, nil
+}
+
+// toBase converts amt from code, to the nearest cent. An empty code means
+// the base currency.
+func (c converter) toBase(code string, amt money.Money) (money.Money, error) {
+	if code == "" || code == c.base {
+		return amt, nil
+	}
+	rate, ok := c.rates[code]
+	if !ok {
+		return 0, fmt.Errorf("%w for %s (base currency is %s)", ErrNoExchangeRate, code, c.base)
+	}
+	return amt.Mul(rate), nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jdelles/currentz/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCurrency(t *testing.T) {
+	for in, want := range map[string]string{"usd": "USD", " EUR ": "EUR", "Chf": "CHF"} {
+		got, err := NormalizeCurrency(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got)
+	}
+	for _, in := range []string{"", "US", "EURO", "U$D", "12A"} {
+		_, err := NormalizeCurrency(in)
+		assert.ErrorIs(t, err, ErrInvalidCurrency, in)
+	}
+}
+
+func TestDailyChangesConvertsToBase(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	conv := converter{base: "USD", rates: map[string]float64{"EUR": 1.1}}
+	txs := []Transaction{
+		{Date: makePgDate(day), Amount: makePgNumeric(1000), Currency: "EUR"},
+		{Date: makePgDate(day), Amount: makePgNumeric(-200), Currency: "USD"},
+		{Date: makePgDate(day), Amount: makePgNumeric(-50)},
+	}
+
+	daily, err := dailyChanges(txs, conv)
+	require.NoError(t, err)
+	assert.Equal(t, money.FromCents(85000), daily[day])
+
+	txs = append(txs, Transaction{Date: makePgDate(day), Amount: makePgNumeric(10), Currency: "GBP"})
+	_, err = dailyChanges(txs, conv)
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+	assert.ErrorContains(t, err, "GBP")
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jdelles/currentz/internal/storage/blob"
+)
+
+// ErrDryRunUnsupported is returned by DryRun when the service's store can
+// neither roll back a transaction nor work on a copy of itself.
+var ErrDryRunUnsupported = errors.New("dry run is not supported by this database")
+
+type dryRunKey struct{}
+
+// dryRun is what a DryRun context carries: the transaction every query
+// goes through, if the service has a pool.
+type dryRun struct {
+	tx pgx.Tx
+}
+
+// sandboxer is implemented by stores without a pool that can hand out a
+// throwaway copy of themselves instead, like the demo's in-memory one.
+type sandboxer interface {
+	Sandbox(ctx context.Context) context.Context
+}
+
+// IsDryRun reports whether ctx is inside DryRun, where nothing is kept.
+func IsDryRun(ctx context.Context) bool {
+	_, ok := ctx.Value(dryRunKey{}).(*dryRun)
+	return ok
+}
+
+// DryRun runs fn as if for real, validation, constraints and all, then
+// throws away whatever it wrote. fn's error is returned as is, so callers
+// see exactly what the change would have done. While it runs, events are
+// not published, files are not stored or deleted, notifications are not
+// sent and forecasts are not cached.
+func (fs *FinanceService) DryRun(ctx context.Context, fn func(context.Context) error) error {
+	if IsDryRun(ctx) {
+		return fn(ctx)
+	}
+	if fs.pool == nil {
+		s, ok := fs.db.(sandboxer)
+		if !ok {
+			return ErrDryRunUnsupported
+		}
+		return fn(context.WithValue(s.Sandbox(ctx), dryRunKey{}, &dryRun{}))
+	}
+	tx, err := fs.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+	return fn(context.WithValue(ctx, dryRunKey{}, &dryRun{tx: tx}))
+}
+
+// dryRunTx is the transaction a DryRun context holds, or nil.
+func dryRunTx(ctx context.Context) pgx.Tx {
+	if d, ok := ctx.Value(dryRunKey{}).(*dryRun); ok {
+		return d.tx
+	}
+	return nil
+}
+
+// poolDB sends each query to the pool, or to the DryRun transaction its
+// context holds, so the generated queries need not know about either.
+type poolDB struct {
+	pool *pgxpool.Pool
+}
+
+func (p poolDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if tx := dryRunTx(ctx); tx != nil {
+		return tx.Exec(ctx, sql, args...)
+	}
+	return p.pool.Exec(ctx, sql, args...)
+}
+
+func (p poolDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if tx := dryRunTx(ctx); tx != nil {
+		return tx.Query(ctx, sql, args...)
+	}
+	return p.pool.Query(ctx, sql, args...)
+}
+
+func (p poolDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if tx := dryRunTx(ctx); tx != nil {
+		return tx.QueryRow(ctx, sql, args...)
+	}
+	return p.pool.QueryRow(ctx, sql, args...)
+}
+
+// discardStore stands in for the blob store during a DryRun: reads go to
+// the real one, writes go nowhere.
+type discardStore struct {
+	blob.Store
+}
+
+func (discardStore) Put(ctx context.Context, key string, r io.Reader) error {
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+func (discardStore) Delete(ctx context.Context, key string) error {
+	return nil
+}
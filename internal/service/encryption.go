@@ -0,0 +1,30 @@
+package service
+
+import (
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/fieldcrypt"
+)
+
+// SetEncryptionKey encrypts transaction and recurring descriptions at rest
+// with key, a fieldcrypt.KeySize-byte AES key, from now on. Descriptions
+// already stored stay readable, and are encrypted when next written. It is
+// meant for a service backed by a database and must be called before the
+// service is used.
+func (fs *FinanceService) SetEncryptionKey(key []byte) error {
+	c, err := fieldcrypt.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	fs.cipher = c
+	fs.db = fieldcrypt.Wrap(fs.db, c)
+	return nil
+}
+
+// queries returns q with encryption applied, if it is on, for work done
+// outside fs.db, in a transaction.
+func (fs *FinanceService) queries(q database.Querier) database.Querier {
+	if fs.cipher == nil {
+		return q
+	}
+	return fieldcrypt.Wrap(q, fs.cipher)
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// EntityEnvelope is the audit entity for envelope changes.
+const EntityEnvelope = "envelope"
+
+var (
+	// ErrInvalidEnvelope is returned for envelope definitions that do not
+	// validate.
+	ErrInvalidEnvelope = errors.New("invalid envelope")
+	// ErrEnvelopeNotFound is returned when looking up an unknown envelope.
+	ErrEnvelopeNotFound = errors.New("envelope not found")
+)
+
+// Envelope earmarks part of the balance for a future expense: a sinking
+// fund. See EnvelopeInput for how it fills and empties.
+type Envelope = database.Envelopes
+
+// EnvelopeInput describes an envelope. MonthlyAmount is set aside on
+// StartDate and on the same day of each month after, in the base currency,
+// until the envelope holds Target; a nil Target lets it grow without limit.
+// Expenses in Category, matched case-insensitively, are paid out of the
+// envelope while it has money. StartDate defaults to today.
+type EnvelopeInput struct {
+	Name          string
+	MonthlyAmount float64
+	Target        *float64
+	StartDate     time.Time
+	Category      string
+}
+
+// EnvelopeBalance is an envelope with what it holds today.
+type EnvelopeBalance struct {
+	Envelope
+	Balance float64 `json:"balance"`
+}
+
+// ListEnvelopes returns every envelope with its balance as of today.
+func (fs *FinanceService) ListEnvelopes(ctx context.Context) ([]EnvelopeBalance, error) {
+	envs, err := fs.db.ListEnvelopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today := fs.Today(ctx)
+	held, err := fs.envelopeSchedules(ctx, envs, today, 1)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]EnvelopeBalance, len(envs))
+	for i, e := range envs {
+		out[i] = EnvelopeBalance{Envelope: e, Balance: held[i][0].Float64()}
+	}
+	return out, nil
+}
+
+// GetEnvelope returns one envelope with its balance as of today.
+func (fs *FinanceService) GetEnvelope(ctx context.Context, id int32) (EnvelopeBalance, error) {
+	e, err := fs.db.GetEnvelope(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return EnvelopeBalance{}, fmt.Errorf("%w: %d", ErrEnvelopeNotFound, id)
+	}
+	if err != nil {
+		return EnvelopeBalance{}, err
+	}
+	held, err := fs.envelopeSchedules(ctx, []Envelope{e}, fs.Today(ctx), 1)
+	if err != nil {
+		return EnvelopeBalance{}, err
+	}
+	return EnvelopeBalance{Envelope: e, Balance: held[0][0].Float64()}, nil
+}
+
+func (fs *FinanceService) CreateEnvelope(ctx context.Context, in EnvelopeInput) (Envelope, error) {
+	params, err := fs.validateEnvelopeInput(ctx, in)
+	if err != nil {
+		return Envelope{}, err
+	}
+	e, err := fs.db.CreateEnvelope(ctx, params)
+	if err != nil {
+		return Envelope{}, envelopeError(params.Name, err)
+	}
+	fs.audit(ctx, AuditCreate, EntityEnvelope, e.ID, nil, e)
+	fs.events.Publish(ctx, EventEnvelopeCreated, e)
+	return e, nil
+}
+
+// UpdateEnvelope replaces an envelope's definition. Its balance is worked
+// out again from the new one, as if it had always been that way.
+func (fs *FinanceService) UpdateEnvelope(ctx context.Context, id int32, in EnvelopeInput) (Envelope, error) {
+	before, err := fs.db.GetEnvelope(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Envelope{}, fmt.Errorf("%w: %d", ErrEnvelopeNotFound, id)
+	}
+	if err != nil {
+		return Envelope{}, err
+	}
+	params, err := fs.validateEnvelopeInput(ctx, in)
+	if err != nil {
+		return Envelope{}, err
+	}
+	e, err := fs.db.UpdateEnvelope(ctx, database.UpdateEnvelopeParams{
+		Name:          params.Name,
+		MonthlyAmount: params.MonthlyAmount,
+		Target:        params.Target,
+		StartDate:     params.StartDate,
+		Category:      params.Category,
+		ID:            id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Envelope{}, fmt.Errorf("%w: %d", ErrEnvelopeNotFound, id)
+	}
+	if err != nil {
+		return Envelope{}, envelopeError(params.Name, err)
+	}
+	fs.audit(ctx, AuditUpdate, EntityEnvelope, id, before, e)
+	fs.events.Publish(ctx, EventEnvelopeUpdated, e)
+	return e, nil
+}
+
+// DeleteEnvelope removes an envelope, releasing what it held back into the
+// available balance.
+func (fs *FinanceService) DeleteEnvelope(ctx context.Context, id int32) error {
+	before, err := fs.db.GetEnvelope(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrEnvelopeNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+	n, err := fs.db.DeleteEnvelope(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrEnvelopeNotFound, id)
+	}
+	fs.audit(ctx, AuditDelete, EntityEnvelope, id, before, nil)
+	fs.events.Publish(ctx, EventEnvelopeDeleted, map[string]int32{"id": id})
+	return nil
+}
+
+func (fs *FinanceService) validateEnvelopeInput(ctx context.Context, in EnvelopeInput) (database.CreateEnvelopeParams, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return database.CreateEnvelopeParams{}, fmt.Errorf("%w: name is required", ErrInvalidEnvelope)
+	}
+	if len(name) > 100 {
+		return database.CreateEnvelopeParams{}, fmt.Errorf("%w: name is longer than 100 characters", ErrInvalidEnvelope)
+	}
+	monthly := money.FromFloat(in.MonthlyAmount)
+	if monthly <= 0 {
+		return database.CreateEnvelopeParams{}, fmt.Errorf("%w: monthly_amount must be positive", ErrInvalidEnvelope)
+	}
+	params := database.CreateEnvelopeParams{
+		Name:          name,
+		MonthlyAmount: makePgNumeric(monthly.Float64()),
+		StartDate:     makePgDate(in.StartDate),
+	}
+	if in.Target != nil {
+		target := money.FromFloat(*in.Target)
+		if target <= 0 {
+			return database.CreateEnvelopeParams{}, fmt.Errorf("%w: target must be positive", ErrInvalidEnvelope)
+		}
+		params.Target = makePgNumeric(target.Float64())
+	}
+	if in.StartDate.IsZero() {
+		params.StartDate = makePgDate(fs.Today(ctx))
+	}
+	if category := strings.TrimSpace(in.Category); category != "" {
+		params.Category = pgtype.Text{String: category, Valid: true}
+	}
+	return params, nil
+}
+
+// envelopeError turns a clash on the unique name into ErrInvalidEnvelope.
+func envelopeError(name string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("%w: an envelope named %q already exists", ErrInvalidEnvelope, name)
+	}
+	return err
+}
+
+// envelopeSchedules returns what each envelope holds at the end of each of
+// the days days from start, in the base currency.
+func (fs *FinanceService) envelopeSchedules(ctx context.Context, envs []Envelope, start time.Time, days int) ([][]money.Money, error) {
+	end := start.AddDate(0, 0, days-1)
+	var spending []Transaction
+	if first, ok := earliestEnvelope(envs); ok && !first.After(end) {
+		var err error
+		spending, err = fs.db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
+			Date:   makePgDate(first),
+			Date_2: makePgDate(end),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]money.Money, len(envs))
+	for i, e := range envs {
+		if out[i], err = envelopeSchedule(e, spending, start, days, conv); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// earliestEnvelope returns the first start date among envs.
+func earliestEnvelope(envs []Envelope) (time.Time, bool) {
+	var first time.Time
+	for i, e := range envs {
+		if d := truncateDay(e.StartDate.Time); i == 0 || d.Before(first) {
+			first = d
+		}
+	}
+	return first, len(envs) > 0
+}
+
+// envelopeSchedule runs one envelope forward from its start date, filling
+// it each month and paying its category's expenses from it, and returns
+// what it holds at the end of each of the days days from start. On a day
+// with both, the deposit comes first. The envelope never goes below zero:
+// spending beyond what it holds comes out of the available balance.
+func envelopeSchedule(e Envelope, txs []Transaction, start time.Time, days int, conv converter) ([]money.Money, error) {
+	out := make([]money.Money, days)
+	anchor := truncateDay(e.StartDate.Time)
+	end := start.AddDate(0, 0, days-1)
+	if anchor.After(end) {
+		return out, nil
+	}
+
+	spent := make(map[time.Time]money.Money)
+	if e.Category.Valid {
+		for _, tx := range txs {
+			if tx.Type != "expense" || !strings.EqualFold(strings.TrimSpace(tx.Category.String), e.Category.String) {
+				continue
+			}
+			amt, err := NumericToMoney(tx.Amount)
+			if err != nil {
+				continue
+			}
+			if amt, err = conv.toBase(tx.Currency, amt.Neg()); err != nil {
+				return nil, err
+			}
+			d := truncateDay(tx.Date.Time.UTC())
+			spent[d] = spent[d].Add(amt)
+		}
+	}
+
+	monthly := numericOrZero(e.MonthlyAmount)
+	target := numericOrZero(e.Target)
+	var held money.Money
+	next, months := anchor, 0
+	for d := anchor; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.Equal(next) {
+			held = held.Add(monthly)
+			if e.Target.Valid && held > target {
+				held = target
+			}
+			months++
+			y, m, _ := anchor.AddDate(0, months, 1-anchor.Day()).Date()
+			next = dateAtDayOrMonthEnd(y, m, anchor.Day())
+		}
+		if held = held.Sub(spent[d]); held < 0 {
+			held = 0
+		}
+		if i := int(d.Sub(start).Hours() / 24); i >= 0 {
+			out[i] = held
+		}
+	}
+	// Days before the envelope started hold nothing, which out already says.
+	return out, nil
+}
+
+// setAvailable fills in Available on each forecast day: the balance less
+// what the envelopes hold that day.
+func (fs *FinanceService) setAvailable(ctx context.Context, forecast []DailyCashFlow, start time.Time) error {
+	envs, err := fs.db.ListEnvelopes(ctx)
+	if err != nil {
+		return err
+	}
+	held, err := fs.envelopeSchedules(ctx, envs, start, len(forecast))
+	if err != nil {
+		return err
+	}
+	for i := range forecast {
+		available := money.FromFloat(forecast[i].Balance)
+		for _, h := range held {
+			available = available.Sub(h[i])
+		}
+		f := available.Float64()
+		forecast[i].Available = &f
+	}
+	return nil
+}
+
+// LowestAvailable returns the day with the lowest available balance and
+// its index, or -1 if the forecast has none. Forecasts only carry
+// available balances when asked for with ForecastOptions.Envelopes.
+func LowestAvailable(forecast []DailyCashFlow) (DailyCashFlow, int) {
+	index := -1
+	for i, day := range forecast {
+		if day.Available != nil && (index < 0 || *day.Available < *forecast[index].Available) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return DailyCashFlow{}, -1
+	}
+	return forecast[index], index
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelopeSchedule(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		require.NoError(t, err)
+		return d
+	}
+	env := Envelope{
+		MonthlyAmount: makePgNumeric(100),
+		Target:        makePgNumeric(250),
+		StartDate:     makePgDate(day("2025-01-31")),
+		Category:      pgtype.Text{String: "Car repairs", Valid: true},
+	}
+	expense := func(date string, amount float64, category, currency string) Transaction {
+		return Transaction{
+			Date:     makePgDate(day(date)),
+			Amount:   makePgNumeric(-amount),
+			Type:     "expense",
+			Category: pgtype.Text{String: category, Valid: true},
+			Currency: currency,
+		}
+	}
+	txs := []Transaction{
+		expense("2025-02-10", 30, "car repairs", ""),
+		expense("2025-02-10", 500, "Groceries", ""),
+		expense("2025-04-10", 200, "Car repairs", "EUR"),
+	}
+	conv := converter{base: "USD", rates: map[string]float64{"EUR": 1.5}}
+
+	start := day("2025-01-30")
+	held, err := envelopeSchedule(env, txs, start, 100, conv)
+	require.NoError(t, err)
+	at := func(s string) money.Money { return held[int(day(s).Sub(start).Hours()/24)] }
+
+	assert.Equal(t, money.Money(0), at("2025-01-30"), "before it starts")
+	assert.Equal(t, money.FromFloat(100), at("2025-01-31"))
+	assert.Equal(t, money.FromFloat(70), at("2025-02-10"), "spent from")
+	assert.Equal(t, money.FromFloat(170), at("2025-02-28"), "short month")
+	assert.Equal(t, money.FromFloat(250), at("2025-03-31"), "capped at target")
+	assert.Equal(t, money.Money(0), at("2025-04-10"), "overspent; never negative")
+	assert.Equal(t, money.FromFloat(100), at("2025-04-30"))
+
+	// Starting the window part way through still counts earlier months.
+	held, err = envelopeSchedule(env, txs, day("2025-03-31"), 1, conv)
+	require.NoError(t, err)
+	assert.Equal(t, []money.Money{money.FromFloat(250)}, held)
+
+	_, err = envelopeSchedule(env, []Transaction{expense("2025-02-01", 5, "Car repairs", "JPY")}, start, 10, conv)
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+}
+
+func TestLowestAvailable(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	forecast := []DailyCashFlow{
+		{Balance: 100, Available: f(40)},
+		{Balance: 80, Available: f(-10)},
+		{Balance: 50, Available: f(0)},
+	}
+	low, i := LowestAvailable(forecast)
+	assert.Equal(t, 1, i)
+	assert.Equal(t, 80.0, low.Balance)
+
+	_, i = LowestAvailable([]DailyCashFlow{{Balance: 10}})
+	assert.Equal(t, -1, i, "no available balances")
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jdelles/currentz/internal/household"
+)
+
+// Event types published on the EventBus after successful mutations.
+const (
+	EventTransactionCreated = "transaction.created"
+	EventTransactionUpdated = "transaction.updated"
+	EventTransactionDeleted = "transaction.deleted"
+	EventRecurringCreated   = "recurring.created"
+	EventRecurringUpdated   = "recurring.updated"
+	EventRecurringDeleted   = "recurring.deleted"
+	EventBalanceChanged     = "balance.changed"
+	EventDataRestored       = "data.restored"
+	EventCurrencyChanged    = "currency.changed"
+	EventAccountCreated     = "account.created"
+	EventAccountUpdated     = "account.updated"
+	EventAccountDeleted     = "account.deleted"
+	EventSettingsChanged    = "settings.changed"
+	EventEnvelopeCreated    = "envelope.created"
+	EventEnvelopeUpdated    = "envelope.updated"
+	EventEnvelopeDeleted    = "envelope.deleted"
+	EventScenarioCreated    = "scenario.created"
+	EventScenarioUpdated    = "scenario.updated"
+	EventScenarioDeleted    = "scenario.deleted"
+
+	EventNotificationChannelCreated = "notification_channel.created"
+	EventNotificationChannelUpdated = "notification_channel.updated"
+	EventNotificationChannelDeleted = "notification_channel.deleted"
+
+	// EventTransactionsArchived is published when the archival job moves
+	// old transactions out of the live ones.
+	EventTransactionsArchived = "transactions.archived"
+)
+
+// Event is a notification that some piece of data changed.
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+	Time time.Time   `json:"time"`
+	// Household is the household whose data changed.
+	Household int32 `json:"household"`
+}
+
+// EventBus fans out events to any number of subscribers. Slow subscribers
+// drop events rather than blocking publishers.
+type EventBus struct {
+	mu sync.RWMutex
+	// subs maps each subscription to the household it follows.
+	subs  map[chan Event]int32
+	hooks []func(Event)
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[chan Event]int32)}
+}
+
+// Subscribe returns a channel of the events in one household and a
+// function that must be called to release the subscription.
+func (b *EventBus) Subscribe(household int32) (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+	b.mu.Lock()
+	b.subs[ch] = household
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// OnPublish runs fn for every event, before Publish returns. Unlike a
+// subscription it never misses one, so it suits invalidating caches. fn
+// must be quick and must not publish.
+func (b *EventBus) OnPublish(fn func(Event)) {
+	b.mu.Lock()
+	b.hooks = append(b.hooks, fn)
+	b.mu.Unlock()
+}
+
+// Publish sends an event about the household ctx works on. Nothing
+// happened inside DryRun, so there it sends nothing.
+func (b *EventBus) Publish(ctx context.Context, eventType string, data interface{}) {
+	if IsDryRun(ctx) {
+		return
+	}
+	ev := Event{
+		Type:      eventType,
+		Data:      data,
+		Time:      time.Now().UTC(),
+		Household: household.FromContext(ctx),
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, fn := range b.hooks {
+		fn(ev)
+	}
+	for ch, h := range b.subs {
+		if h != ev.Household {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/exporter"
+)
+
+// ErrInvalidExport is returned for an export request the service cannot
+// satisfy, such as an unknown format.
+var ErrInvalidExport = errors.New("invalid export request")
+
+// ExportTransactions writes stored transactions as CSV in another tool's
+// layout ("ynab" or "mint"). Projected recurring occurrences are left out
+// since they have not happened yet. A nil start or end exports from the
+// first or through the last transaction.
+func (fs *FinanceService) ExportTransactions(ctx context.Context, format string, w io.Writer, start, end *time.Time) error {
+	if !exporter.Supports(format) {
+		return fmt.Errorf("%w: unsupported format %q (expected %s)", ErrInvalidExport, format, strings.Join(exporter.Formats, " or "))
+	}
+
+	var (
+		txs []Transaction
+		err error
+	)
+	if start == nil && end == nil {
+		txs, err = fs.db.GetAllTransactions(ctx)
+	} else {
+		from, to := time.Time{}, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+		if start != nil {
+			from = *start
+		}
+		if end != nil {
+			to = *end
+		}
+		txs, err = fs.db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
+			Date:   makePgDate(from),
+			Date_2: makePgDate(to),
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return exporter.WriteCSV(w, format, txs)
+}
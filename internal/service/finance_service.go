@@ -2,43 +2,110 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
-	"sort"
+	"slices"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jdelles/currentz/internal/auth"
 	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/fieldcrypt"
+	"github.com/jdelles/currentz/internal/household"
+	"github.com/jdelles/currentz/internal/storage/blob"
+	"github.com/jdelles/currentz/pkg/money"
 )
 
 type Transaction = database.Transactions
 
+var (
+	// ErrTransactionNotFound is returned for IDs with no live transaction,
+	// including ones that are already in the trash.
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrRecurringNotFound is the same for recurring transactions.
+	ErrRecurringNotFound = errors.New("recurring transaction not found")
+)
+
 type DailyCashFlow struct {
 	Date    time.Time `json:"date"`
 	Balance float64   `json:"balance"`
 	Change  float64   `json:"change"`
+	// Interest is the part of Change earned by interest-bearing accounts.
+	Interest float64 `json:"interest,omitempty"`
+	// Available is Balance less what envelopes hold that day. It is only
+	// set when asked for with ForecastOptions.Envelopes.
+	Available *float64 `json:"available,omitempty"`
+	// TaxReserve is the estimated tax owed on self-employment income
+	// received but not yet due, which Balance still includes. It is only
+	// set when a self-employment tax rate is.
+	TaxReserve *float64 `json:"tax_reserve,omitempty"`
 }
 
 type FinanceService struct {
-	db   database.Querier
-	pool *pgxpool.Pool
+	db        database.Querier
+	pool      *pgxpool.Pool
+	events    *EventBus
+	forecasts *forecastCache
+	// blobs holds attachments and stored backups; nil until SetBlobStore.
+	blobs              blob.Store
+	maxAttachmentBytes int64
+	// defaults stand in for settings a household hasn't set. They can be
+	// replaced while the server runs, when the config file changes.
+	defaults atomic.Pointer[SettingDefaults]
+	// authSecret signs access tokens. It is random until SetAuthSecret,
+	// so sessions don't survive a restart.
+	authSecret []byte
+	// cipher encrypts descriptions at rest; nil until SetEncryptionKey.
+	cipher *fieldcrypt.Cipher
 }
 
 func NewFinanceService(db database.Querier) *FinanceService {
-	return &FinanceService{db: db}
+	return newFinanceService(db, nil)
+}
+
+func newFinanceService(db database.Querier, pool *pgxpool.Pool) *FinanceService {
+	fs := &FinanceService{
+		db:         db,
+		pool:       pool,
+		events:     NewEventBus(),
+		forecasts:  newForecastCache(forecastCacheTTL),
+		authSecret: auth.NewSecret(),
+	}
+	fs.events.OnPublish(func(Event) { fs.forecasts.invalidate() })
+	return fs
 }
 
 func NewFinanceServiceFromURL(ctx context.Context, dbURL string) (*FinanceService, error) {
-	pool, err := pgxpool.New(ctx, dbURL)
+	cfg, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+	cfg.BeforeAcquire = setHousehold
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pgx pool: %w", err)
 	}
-	return &FinanceService{
-		db:   database.New(pool),
-		pool: pool,
-	}, nil
+	return newFinanceService(database.New(poolDB{pool}), pool), nil
+}
+
+// SubscribeEvents registers a listener for data-change events in the
+// household ctx works on. The returned function must be called to release
+// the subscription.
+func (fs *FinanceService) SubscribeEvents(ctx context.Context) (<-chan Event, func()) {
+	return fs.events.Subscribe(household.FromContext(ctx))
+}
+
+// OnEvent runs fn for every event in any household, as it is published.
+// fn must be quick and must not publish.
+func (fs *FinanceService) OnEvent(fn func(Event)) {
+	fs.events.OnPublish(fn)
 }
 
 func (fs *FinanceService) Close() error {
@@ -49,7 +116,7 @@ func (fs *FinanceService) Close() error {
 }
 
 func (fs *FinanceService) GetStartingBalance(ctx context.Context) (float64, error) {
-	value, err := fs.db.GetSetting(ctx, "starting_balance")
+	value, err := fs.db.GetSetting(ctx, SettingStartingBalance)
 	if err != nil {
 		return 0, nil
 	}
@@ -57,80 +124,294 @@ func (fs *FinanceService) GetStartingBalance(ctx context.Context) (float64, erro
 }
 
 func (fs *FinanceService) SetStartingBalance(ctx context.Context, balance float64) error {
-	return fs.db.UpdateSetting(ctx, database.UpdateSettingParams{
-		Key:   "starting_balance",
-		Value: fmt.Sprintf("%.2f", balance),
-	})
+	if err := fs.updateSetting(ctx, SettingStartingBalance, money.FromFloat(balance).String()); err != nil {
+		return err
+	}
+	fs.events.Publish(ctx, EventBalanceChanged, map[string]float64{"balance": balance})
+	return nil
 }
 
-func (fs *FinanceService) AddIncome(ctx context.Context, date time.Time, amount float64, description string) error {
-	return fs.db.CreateTransaction(ctx, database.CreateTransactionParams{
+// updateSetting writes a setting and records the change in the audit log.
+func (fs *FinanceService) updateSetting(ctx context.Context, key, value string) error {
+	action := AuditUpdate
+	var before interface{}
+	if prev, err := fs.db.GetSetting(ctx, key); err == nil {
+		before = map[string]string{"key": key, "value": prev}
+	} else {
+		action = AuditCreate
+	}
+	err := fs.db.UpdateSetting(ctx, database.UpdateSettingParams{Key: key, Value: value})
+	if err != nil {
+		return err
+	}
+	fs.audit(ctx, action, EntitySetting, key, before, map[string]string{"key": key, "value": value})
+	return nil
+}
+
+// AddIncome records income in currency, or the base currency if empty.
+func (fs *FinanceService) AddIncome(ctx context.Context, date time.Time, amount float64, description, currency string) error {
+	_, err := fs.createTransaction(ctx, database.CreateTransactionParams{
 		Date:        makePgDate(date),
 		Amount:      makePgNumeric(amount),
 		Description: description,
 		Type:        "income",
+		Currency:    currency,
 	})
+	return err
 }
 
-func (fs *FinanceService) AddExpense(ctx context.Context, date time.Time, amount float64, description string) error {
-	return fs.db.CreateTransaction(ctx, database.CreateTransactionParams{
+// AddExpense records an expense in currency, or the base currency if empty.
+func (fs *FinanceService) AddExpense(ctx context.Context, date time.Time, amount float64, description, currency string) error {
+	_, err := fs.createTransaction(ctx, database.CreateTransactionParams{
 		Date:        makePgDate(date),
 		Amount:      makePgNumeric(-amount),
 		Description: description,
 		Type:        "expense",
+		Currency:    currency,
 	})
+	return err
+}
+
+func (fs *FinanceService) createTransaction(ctx context.Context, params database.CreateTransactionParams) (Transaction, error) {
+	currency, err := fs.resolveCurrency(ctx, params.Currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+	params.Currency = currency
+	if params.Status, err = normalizeStatus(params.Status); err != nil {
+		return Transaction{}, err
+	}
+	if params.AccountID.Valid {
+		if _, err := fs.db.GetAccountByID(ctx, params.AccountID.Int32); err != nil {
+			return Transaction{}, fmt.Errorf("%w: no account %d", ErrInvalidAccount, params.AccountID.Int32)
+		}
+	}
+	tx, err := fs.db.CreateTransaction(ctx, params)
+	if err != nil {
+		return Transaction{}, err
+	}
+	fs.audit(ctx, AuditCreate, EntityTransaction, tx.ID, nil, tx)
+	fs.events.Publish(ctx, EventTransactionCreated, tx)
+	return tx, nil
 }
 
 func (fs *FinanceService) GetAllTransactions(ctx context.Context) ([]Transaction, error) {
 	return fs.db.GetAllTransactions(ctx)
 }
 
+// DeleteTransaction moves a transaction to the trash. It returns
+// ErrTransactionNotFound if there was nothing to delete.
 func (fs *FinanceService) DeleteTransaction(ctx context.Context, id int32) error {
-	return fs.db.DeleteTransaction(ctx, id)
+	before, err := fs.db.GetTransactionByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrTransactionNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+	n, err := fs.db.DeleteTransaction(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		// Deleted by someone else since the lookup.
+		return fmt.Errorf("%w: %d", ErrTransactionNotFound, id)
+	}
+	fs.audit(ctx, AuditDelete, EntityTransaction, id, before, nil)
+	fs.events.Publish(ctx, EventTransactionDeleted, map[string]int32{"id": id})
+	return nil
 }
 
 func (fs *FinanceService) Calculate90DayForecast(ctx context.Context, startingBalance float64) ([]DailyCashFlow, error) {
-	// 1) window (UTC midnight to avoid time drift)
-	start := time.Now().UTC().Truncate(24 * time.Hour)
-	end := start.AddDate(0, 0, 89)
+	return fs.Forecast(ctx, startingBalance, 90)
+}
+
+// Forecast projects the balance for days days, today included. Non-positive
+// days fall back to 90. Forecasts are cached until the next change to the
+// data they are built from.
+func (fs *FinanceService) Forecast(ctx context.Context, startingBalance float64, days int) ([]DailyCashFlow, error) {
+	return fs.ForecastWithOptions(ctx, startingBalance, days, ForecastOptions{})
+}
+
+// ForecastOptions changes what a forecast counts. The zero value is what
+// Forecast uses.
+type ForecastOptions struct {
+	// ExcludePending leaves out pending transactions, projecting the
+	// balance as if they never clear.
+	ExcludePending bool
+	// Envelopes sets each day's Available balance, net of what envelopes
+	// have set aside.
+	Envelopes bool
+}
+
+// ForecastWithOptions is Forecast with opts applied.
+func (fs *FinanceService) ForecastWithOptions(ctx context.Context, startingBalance float64, days int, opts ForecastOptions) ([]DailyCashFlow, error) {
+	if days <= 0 {
+		days = 90
+	}
+
+	// window, starting today in the user's timezone
+	key := forecastKey{
+		household: household.FromContext(ctx),
+		start:     fs.Today(ctx),
+		days:      days,
+		balance:   startingBalance,
+		opts:      opts,
+	}
+	// Inside DryRun the data differs from what's cached, and won't last.
+	if IsDryRun(ctx) {
+		return fs.forecast(ctx, key.start, startingBalance, days, opts, nil)
+	}
+	forecast, gen, ok := fs.forecasts.get(key)
+	if ok {
+		return forecast, nil
+	}
+	forecast, err := fs.forecast(ctx, key.start, startingBalance, days, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	fs.forecasts.put(key, gen, forecast)
+	return forecast, nil
+}
+
+// forecast projects the balance from start. extra holds hypothetical
+// transactions from a Scenario, counted along with the real ones.
+func (fs *FinanceService) forecast(ctx context.Context, start time.Time, startingBalance float64, days int, opts ForecastOptions, extra []Transaction) ([]DailyCashFlow, error) {
+	// 1) window
+	end := start.AddDate(0, 0, days-1)
+
+	// 2) one-offs summed per day by the database, with card purchases moved
+	// to their due dates and uncleared ones from before today brought in
+	oneOffs, err := fs.dailySums(ctx, start.AddDate(0, 0, -maxCardLagDays), end, !opts.ExcludePending)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := fs.db.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	oneOffs = payCardsOnDueDates(oneOffs, accounts)
+	if !opts.ExcludePending {
+		pending, err := fs.pendingBefore(ctx, start, accounts)
+		if err != nil {
+			return nil, err
+		}
+		oneOffs = append(oneOffs, pending...)
+	}
+
+	// 3) expanded recurrings, with card charges moved to their due dates
+	rs, err := fs.db.ListActiveRecurring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recs := payCardsOnDueDates(expandForForecast(rs, start, end), accounts)
 
-	// 2) one-offs from DB
-	oneOffs, err := fs.db.GetAllTransactions(ctx)
+	// 4) sum daily deltas in the base currency
+	conv, err := fs.converter(ctx)
 	if err != nil {
 		return nil, err
 	}
+	all := append(append(oneOffs, recs...), extra...)
+	daily, err := dailyChanges(all, conv)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.applyTaxSettlements(ctx, daily, start, end); err != nil {
+		return nil, err
+	}
 
-	// 3) expanded recurrings inside the window
-	recs, err := fs.ExpandRecurringBetween(ctx, start, end)
+	// 5) interest earned by savings and checking accounts
+	interest, err := accountInterest(accounts, all, start, end, conv)
 	if err != nil {
 		return nil, err
 	}
 
-	// 4) sum daily deltas
-	daily := make(map[time.Time]float64, 100)
-	for _, tx := range append(oneOffs, recs...) {
+	// 6) accumulate into balances
+	fc := accumulateForecast(start, days, daily, interest, startingBalance)
+
+	// 7) money set aside in envelopes
+	if opts.Envelopes {
+		if err := fs.setAvailable(ctx, fc, start); err != nil {
+			return nil, err
+		}
+	}
+
+	// 8) tax owed on self-employment income
+	if err := fs.setTaxReserve(ctx, fc, start); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+// maxCardLagDays is the longest a card purchase can wait to be paid: up to
+// a month until its statement closes, and up to a month more until it is
+// due.
+const maxCardLagDays = 63
+
+// dailySums returns one transaction per day, currency and account between
+// start and end, holding the day's total. Days before the forecast starts
+// matter only for card purchases that come due inside it.
+func (fs *FinanceService) dailySums(ctx context.Context, start, end time.Time, includePending bool) ([]Transaction, error) {
+	rows, err := fs.db.GetDailyTransactionSums(ctx, database.GetDailyTransactionSumsParams{
+		StartDate:      makePgDate(start),
+		EndDate:        makePgDate(end),
+		IncludePending: includePending,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Transaction, len(rows))
+	for i, row := range rows {
+		out[i] = Transaction{Date: row.Date, Amount: row.Total, Currency: row.Currency, AccountID: row.AccountID}
+	}
+	return out, nil
+}
+
+// dailyChanges sums transaction amounts per calendar day, converted to the
+// base currency. Dates are zone-less days stored as midnight UTC.
+func dailyChanges(txs []Transaction, conv converter) (map[time.Time]money.Money, error) {
+	daily := make(map[time.Time]money.Money, 100)
+	for _, tx := range txs {
 		// normalize to UTC day key
 		day := tx.Date.Time.In(time.UTC).Truncate(24 * time.Hour)
-		amt, err := NumericToFloat64(tx.Amount)
+		amt, err := NumericToMoney(tx.Amount)
 		if err != nil {
 			continue
 		}
-		daily[day] += amt
+		if amt, err = conv.toBase(tx.Currency, amt); err != nil {
+			return nil, err
+		}
+		daily[day] = daily[day].Add(amt)
 	}
+	return daily, nil
+}
 
-	// 5) accumulate into balances
-	fc := make([]DailyCashFlow, 90)
-	bal := startingBalance
-	for i := 0; i < 90; i++ {
+// accumulateForecast runs balance forward over the days days from start,
+// adding interest on top of each day's transactions.
+func accumulateForecast(start time.Time, days int, daily, interest map[time.Time]money.Money, startingBalance float64) []DailyCashFlow {
+	fc := make([]DailyCashFlow, days)
+	balance := money.FromFloat(startingBalance)
+	for i := 0; i < days; i++ {
 		day := start.AddDate(0, 0, i)
-		change := daily[day]
-		bal += change
-		fc[i] = DailyCashFlow{Date: day, Balance: bal, Change: change}
+		change := daily[day].Add(interest[day])
+		balance = balance.Add(change)
+		fc[i] = DailyCashFlow{
+			Date:     day,
+			Balance:  balance.Float64(),
+			Change:   change.Float64(),
+			Interest: interest[day].Float64(),
+		}
 	}
-	return fc, nil
+	return fc
 }
 
 func (fs *FinanceService) FindLowestPoint(forecast []DailyCashFlow) (DailyCashFlow, int) {
+	return LowestPoint(forecast)
+}
+
+// LowestPoint returns the day with the lowest balance and its index, or -1
+// for an empty forecast.
+func LowestPoint(forecast []DailyCashFlow) (DailyCashFlow, int) {
 	if len(forecast) == 0 {
 		return DailyCashFlow{}, -1
 	}
@@ -145,28 +426,110 @@ func (fs *FinanceService) FindLowestPoint(forecast []DailyCashFlow) (DailyCashFl
 	return lowest, lowestIndex
 }
 
-func (fs *FinanceService) GetUpcomingTransactions(ctx context.Context, days int) ([]Transaction, error) {
-	start := time.Now().Truncate(24 * time.Hour)
-	end := start.AddDate(0, 0, days)
+func (fs *FinanceService) GetUpcomingTransactions(ctx context.Context, days int) ([]ListedTransaction, error) {
+	start, end := fs.UpcomingWindow(ctx, days)
 	return fs.GetTransactionsWithRecurringsBetween(ctx, start, end)
 }
 
+// UpcomingWindow is the date range GetUpcomingTransactions covers.
+func (fs *FinanceService) UpcomingWindow(ctx context.Context, days int) (time.Time, time.Time) {
+	start := fs.Today(ctx)
+	return start, start.AddDate(0, 0, days)
+}
+
 func makePgDate(t time.Time) pgtype.Date {
 	var d pgtype.Date
 	_ = d.Scan(t)
 	return d
 }
 
+// makePgNumeric rounds f to cents, giving the same value as scanning
+// "12.34" would without formatting and parsing a string.
 func makePgNumeric(f float64) pgtype.Numeric {
-	var n pgtype.Numeric
-	_ = n.Scan(fmt.Sprintf("%.2f", f))
-	return n
+	return pgtype.Numeric{Int: big.NewInt(money.FromFloat(f).Cents()), Exp: -2, Valid: true}
+}
+
+// pow10 holds the powers of ten an int64 can hold, for converting amounts
+// without big arithmetic.
+var pow10 = [...]int64{1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18}
+
+// pow10f holds the powers of ten a float64 represents exactly.
+var pow10f = [...]float64{1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19, 1e20, 1e21, 1e22}
+
+// NumericToMoney converts a NUMERIC amount to cents, rounding anything past
+// the second decimal place half away from zero.
+func NumericToMoney(n pgtype.Numeric) (money.Money, error) {
+	if n.Int == nil {
+		return 0, nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return 0, fmt.Errorf("amount is not a finite number")
+	}
+	// Amounts nearly always fit an int64 with a couple of decimal places,
+	// which needs no allocation.
+	if n.Int.IsInt64() {
+		v := n.Int.Int64()
+		switch exp := int(n.Exp) + 2; {
+		case exp >= 0 && exp < len(pow10):
+			if p := pow10[exp]; v <= math.MaxInt64/p && v >= -math.MaxInt64/p {
+				return money.Money(v * p), nil
+			}
+		case exp < 0 && -exp < len(pow10):
+			p := pow10[-exp]
+			cents, rem := v/p, v%p
+			if rem < 0 {
+				rem = -rem
+			}
+			if 2*rem >= p {
+				if v < 0 {
+					cents--
+				} else {
+					cents++
+				}
+			}
+			return money.Money(cents), nil
+		}
+	}
+	cents := new(big.Int).Set(n.Int)
+	switch exp := int64(n.Exp) + 2; {
+	case exp > 0:
+		cents.Mul(cents, new(big.Int).Exp(big.NewInt(10), big.NewInt(exp), nil))
+	case exp < 0:
+		divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(-exp), nil)
+		var rem big.Int
+		cents.QuoRem(cents, divisor, &rem)
+		// Round half away from zero: |2*rem| >= divisor.
+		if rem.Abs(&rem).Lsh(&rem, 1).Cmp(divisor) >= 0 {
+			if n.Int.Sign() < 0 {
+				cents.Sub(cents, big.NewInt(1))
+			} else {
+				cents.Add(cents, big.NewInt(1))
+			}
+		}
+	}
+	if !cents.IsInt64() {
+		return 0, fmt.Errorf("amount out of range")
+	}
+	return money.FromCents(cents.Int64()), nil
 }
 
 func NumericToFloat64(n pgtype.Numeric) (float64, error) {
 	if n.Int == nil {
 		return 0, nil
 	}
+	// A float64 holds integers up to 2^53 and the powers of ten up to 1e22
+	// exactly, so one multiplication or division rounds the same as the
+	// exact conversion below.
+	if n.Int.IsInt64() {
+		if v := n.Int.Int64(); v >= -1<<53 && v <= 1<<53 {
+			switch exp := int(n.Exp); {
+			case exp >= 0 && exp < len(pow10f):
+				return float64(v) * pow10f[exp], nil
+			case exp < 0 && -exp < len(pow10f):
+				return float64(v) / pow10f[-exp], nil
+			}
+		}
+	}
 	r := new(big.Rat).SetInt(n.Int)
 	if n.Exp > 0 {
 		factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n.Exp)), nil)
@@ -179,7 +542,35 @@ func NumericToFloat64(n pgtype.Numeric) (float64, error) {
 	return f, nil
 }
 
-func (fs *FinanceService) GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]Transaction, error) {
+// Where a listed transaction comes from.
+const (
+	SourceOneOff    = "one_off"
+	SourceRecurring = "recurring"
+)
+
+// ListedTransaction is a transaction in a list that can mix recorded
+// transactions with projected recurring occurrences. An occurrence has no
+// ID of its own, so it is 0 and RecurringID and OccurrenceDate name the
+// occurrence instead; both are null for recorded ones. OccurrenceDate is
+// the day the schedule put the occurrence on, which is after Date when a
+// business day moved it off a weekend.
+type ListedTransaction struct {
+	Transaction
+	Source         string      `json:"source"`
+	RecurringID    pgtype.Int4 `json:"recurring_id"`
+	OccurrenceDate pgtype.Date `json:"occurrence_date"`
+}
+
+// OneOffs lists recorded transactions as ListedTransactions.
+func OneOffs(txs []Transaction) []ListedTransaction {
+	out := make([]ListedTransaction, len(txs))
+	for i, tx := range txs {
+		out[i] = ListedTransaction{Transaction: tx, Source: SourceOneOff}
+	}
+	return out
+}
+
+func (fs *FinanceService) GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]ListedTransaction, error) {
 	oneOffs, err := fs.db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
 		Date:   makePgDate(start),
 		Date_2: makePgDate(end),
@@ -187,19 +578,40 @@ func (fs *FinanceService) GetTransactionsWithRecurringsBetween(ctx context.Conte
 	if err != nil {
 		return nil, err
 	}
-	recs, err := fs.ExpandRecurringBetween(ctx, start, end)
+	rs, err := fs.db.ListActiveRecurring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recs := expandListed(rs, start, end)
+
+	all := make([]ListedTransaction, 0, len(oneOffs)+len(recs))
+	all = append(append(all, OneOffs(oneOffs)...), recs...)
+	sortListed(all)
+	return all, nil
+}
+
+// GetTransactionsBetween is GetTransactionsWithRecurringsBetween without
+// the recurring occurrences: only what is recorded.
+func (fs *FinanceService) GetTransactionsBetween(ctx context.Context, start, end time.Time) ([]ListedTransaction, error) {
+	txs, err := fs.db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
+		Date:   makePgDate(start),
+		Date_2: makePgDate(end),
+	})
 	if err != nil {
 		return nil, err
 	}
+	listed := OneOffs(txs)
+	sortListed(listed)
+	return listed, nil
+}
 
-	all := append(oneOffs, recs...)
-	sort.SliceStable(all, func(i, j int) bool {
-		ti := all[i].Date.Time
-		tj := all[j].Date.Time
-		if ti.Equal(tj) {
-			return all[i].Description < all[j].Description
+// sortListed puts a transaction list in date order, and by description
+// within a day.
+func sortListed(txs []ListedTransaction) {
+	slices.SortStableFunc(txs, func(a, b ListedTransaction) int {
+		if c := a.Date.Time.Compare(b.Date.Time); c != 0 {
+			return c
 		}
-		return ti.Before(tj)
+		return strings.Compare(a.Description, b.Description)
 	})
-	return all, nil
 }
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumericToMoney(t *testing.T) {
+	tests := map[string]money.Money{
+		"1234.56":                 123456,
+		"-0.07":                   -7,
+		"1200":                    120000,
+		"0.125":                   13,
+		"-0.125":                  -13,
+		"19.994":                  1999,
+		"-19.995":                 -2000,
+		"0.000000000000000000005": 0,
+	}
+	for in, want := range tests {
+		var n pgtype.Numeric
+		require.NoError(t, n.Scan(in))
+		got, err := NumericToMoney(n)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	got, err := NumericToMoney(pgtype.Numeric{Int: big.NewInt(2), Exp: 3, Valid: true})
+	require.NoError(t, err)
+	assert.Equal(t, money.Money(200000), got)
+
+	got, err = NumericToMoney(pgtype.Numeric{})
+	require.NoError(t, err)
+	assert.Equal(t, money.Money(0), got)
+}
+
+func TestNumericToFloat64(t *testing.T) {
+	for _, in := range []string{"1234.56", "-0.07", "1200", "0.1", "-19.995", "0.000000000000000000005", "9007199254740993", "123456789012345678901234.5"} {
+		var n pgtype.Numeric
+		require.NoError(t, n.Scan(in))
+		want, err := strconv.ParseFloat(in, 64)
+		require.NoError(t, err)
+		got, err := NumericToFloat64(n)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+}
+
+func TestAccumulateForecastIsExact(t *testing.T) {
+	start := day("2025-06-01")
+	daily := map[time.Time]money.Money{}
+	for i := 0; i < 10; i++ {
+		daily[start.AddDate(0, 0, i)] = money.FromFloat(0.1)
+	}
+	fc := accumulateForecast(start, 90, daily, nil, 0.2)
+	assert.Equal(t, 1.2, fc[9].Balance)
+	assert.Equal(t, 0.1, fc[9].Change)
+}
+
+func TestAccumulateForecastCoversDays(t *testing.T) {
+	start := day("2025-06-01")
+	daily := map[time.Time]money.Money{day("2025-06-14"): money.FromFloat(-50)}
+	fc := accumulateForecast(start, 14, daily, nil, 100)
+	require.Len(t, fc, 14)
+	assert.Equal(t, day("2025-06-14"), fc[13].Date)
+	assert.Equal(t, 50.0, fc[13].Balance)
+}
+
+// sumsDB answers GetDailyTransactionSums; any other query panics.
+type sumsDB struct {
+	database.Querier
+	args database.GetDailyTransactionSumsParams
+	rows []database.GetDailyTransactionSumsRow
+}
+
+func (db *sumsDB) GetDailyTransactionSums(_ context.Context, arg database.GetDailyTransactionSumsParams) ([]database.GetDailyTransactionSumsRow, error) {
+	db.args = arg
+	return db.rows, nil
+}
+
+func TestDailySums(t *testing.T) {
+	db := &sumsDB{rows: []database.GetDailyTransactionSumsRow{
+		{Date: makePgDate(day("2025-06-01")), Currency: "USD", Total: makePgNumeric(-42.5)},
+		{Date: makePgDate(day("2025-06-01")), Currency: "EUR", Total: makePgNumeric(10), AccountID: pgtype.Int4{Int32: 3, Valid: true}},
+	}}
+	fs := NewFinanceService(db)
+
+	txs, err := fs.dailySums(context.Background(), day("2025-06-01"), day("2025-06-30"), true)
+	require.NoError(t, err)
+	assert.Equal(t, day("2025-06-01"), db.args.StartDate.Time)
+	assert.Equal(t, day("2025-06-30"), db.args.EndDate.Time)
+
+	require.Len(t, txs, 2)
+	assert.Equal(t, "EUR", txs[1].Currency)
+	assert.Equal(t, int32(3), txs[1].AccountID.Int32)
+	daily, err := dailyChanges(txs[:1], converter{base: "USD"})
+	require.NoError(t, err)
+	assert.Equal(t, money.FromFloat(-42.5), daily[day("2025-06-01")])
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// maxForecastWorkers bounds how many account forecasts ForecastAll computes
+// at once.
+const maxForecastWorkers = 8
+
+// AccountForecast is one account's projected balance.
+type AccountForecast struct {
+	Account  Account         `json:"account"`
+	Forecast []DailyCashFlow `json:"forecast"`
+}
+
+// AllForecasts is a forecast per account next to the usual cash forecast,
+// Total, which counts every transaction whether or not it names an account.
+type AllForecasts struct {
+	Accounts map[int32]AccountForecast `json:"accounts"`
+	Total    []DailyCashFlow           `json:"total"`
+}
+
+// ForecastAll projects every account's balance for days days, along with
+// the total from startingBalance. Checking and savings accounts start from
+// their stored balance and move with the transactions charged to them,
+// plus interest. A credit card's balance is what it owes: purchases lower
+// it and paying each statement on its due date brings it back up.
+//
+// The data is loaded once and the accounts are then worked out
+// concurrently. opts applies to all of them, though only Total has
+// envelopes and a tax reserve.
+func (fs *FinanceService) ForecastAll(ctx context.Context, startingBalance float64, days int, opts ForecastOptions) (AllForecasts, error) {
+	if days <= 0 {
+		days = 90
+	}
+	start := fs.Today(ctx)
+	end := start.AddDate(0, 0, days-1)
+
+	accounts, err := fs.db.ListAccounts(ctx)
+	if err != nil {
+		return AllForecasts{}, err
+	}
+	txs, err := fs.dailySums(ctx, start.AddDate(0, 0, -maxCardLagDays), end, !opts.ExcludePending)
+	if err != nil {
+		return AllForecasts{}, err
+	}
+	if !opts.ExcludePending {
+		pending, err := fs.pendingBefore(ctx, start, accounts)
+		if err != nil {
+			return AllForecasts{}, err
+		}
+		txs = append(txs, pending...)
+	}
+	rs, err := fs.db.ListActiveRecurring(ctx)
+	if err != nil {
+		return AllForecasts{}, err
+	}
+	txs = append(txs, expandForForecast(rs, start, end)...)
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return AllForecasts{}, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		total    []DailyCashFlow
+		totalErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		total, totalErr = fs.ForecastWithOptions(ctx, startingBalance, days, opts)
+	}()
+
+	results := make([][]DailyCashFlow, len(accounts))
+	errs := make([]error, len(accounts))
+	sem := make(chan struct{}, maxForecastWorkers)
+	for i, acc := range accounts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = accountForecast(acc, txs, start, days, conv)
+		}()
+	}
+	wg.Wait()
+
+	if totalErr != nil {
+		return AllForecasts{}, totalErr
+	}
+	all := AllForecasts{Accounts: make(map[int32]AccountForecast, len(accounts)), Total: total}
+	for i, acc := range accounts {
+		if errs[i] != nil {
+			return AllForecasts{}, fmt.Errorf("forecast for %s: %w", acc.Name, errs[i])
+		}
+		all.Accounts[acc.ID] = AccountForecast{Account: acc, Forecast: results[i]}
+	}
+	return all, nil
+}
+
+// accountForecast projects acc's balance for days days from start, from
+// the transactions in txs charged to it. txs is only read, so calls can
+// share it.
+func accountForecast(acc Account, txs []Transaction, start time.Time, days int, conv converter) ([]DailyCashFlow, error) {
+	end := start.AddDate(0, 0, days-1)
+	inWindow := func(day time.Time) bool { return !day.Before(start) && !day.After(end) }
+
+	var (
+		balance money.Money
+		own     []Transaction
+		daily   = make(map[time.Time]money.Money)
+	)
+	if acc.Type != AccountCreditCard && acc.Balance.Valid {
+		b, err := NumericToMoney(acc.Balance)
+		if err != nil {
+			return nil, err
+		}
+		balance = b
+	}
+	for _, tx := range txs {
+		if !tx.AccountID.Valid || tx.AccountID.Int32 != acc.ID {
+			continue
+		}
+		amt, err := NumericToMoney(tx.Amount)
+		if err != nil {
+			continue
+		}
+		if amt, err = conv.toBase(tx.Currency, amt); err != nil {
+			return nil, err
+		}
+		day := truncateDay(tx.Date.Time.UTC())
+		if acc.Type != AccountCreditCard {
+			if inWindow(day) {
+				daily[day] = daily[day].Add(amt)
+				own = append(own, tx)
+			}
+			continue
+		}
+		// A purchase is owed from the day it is made until its statement
+		// is paid. Ones made before start and still unpaid are owed
+		// already.
+		_, due := statementDates(acc, day)
+		switch {
+		case inWindow(day):
+			daily[day] = daily[day].Add(amt)
+		case day.Before(start) && !due.Before(start):
+			balance = balance.Add(amt)
+		}
+		if inWindow(due) {
+			daily[due] = daily[due].Sub(amt)
+		}
+	}
+
+	interest, err := accountInterest([]Account{acc}, own, start, end, conv)
+	if err != nil {
+		return nil, err
+	}
+	return accumulateForecast(start, days, daily, interest, balance.Float64()), nil
+}
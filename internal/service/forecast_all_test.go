@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountForecastChecking(t *testing.T) {
+	checking := Account{ID: 2, Name: "Checking", Type: AccountChecking, Balance: makePgNumeric(500)}
+	onChecking := pgtype.Int4{Int32: 2, Valid: true}
+	txs := []Transaction{
+		{Date: makePgDate(day("2025-03-01")), Amount: makePgNumeric(-40), AccountID: onChecking}, // before the window
+		{Date: makePgDate(day("2025-03-11")), Amount: makePgNumeric(-100), AccountID: onChecking},
+		{Date: makePgDate(day("2025-03-12")), Amount: makePgNumeric(20), AccountID: onChecking, Currency: "EUR"},
+		{Date: makePgDate(day("2025-03-12")), Amount: makePgNumeric(-999)}, // no account
+	}
+	conv := converter{base: "USD", rates: map[string]float64{"EUR": 1.5}}
+
+	fc, err := accountForecast(checking, txs, day("2025-03-10"), 4, conv)
+	require.NoError(t, err)
+	require.Len(t, fc, 4)
+	assert.Equal(t, 500.0, fc[0].Balance)
+	assert.Equal(t, 400.0, fc[1].Balance)
+	assert.Equal(t, 430.0, fc[2].Balance)
+	assert.Equal(t, 430.0, fc[3].Balance)
+}
+
+func TestAccountForecastCard(t *testing.T) {
+	visa := card(1, 20, 15)
+	onCard := pgtype.Int4{Int32: 1, Valid: true}
+	txs := []Transaction{
+		{Date: makePgDate(day("2025-02-10")), Amount: makePgNumeric(-10), AccountID: onCard}, // paid Mar 15
+		{Date: makePgDate(day("2025-03-05")), Amount: makePgNumeric(-50), AccountID: onCard}, // due Apr 15
+		{Date: makePgDate(day("2025-03-18")), Amount: makePgNumeric(-20), AccountID: onCard}, // due Apr 15
+		{Date: makePgDate(day("2025-03-25")), Amount: makePgNumeric(-30), AccountID: onCard}, // due May 15
+	}
+
+	fc, err := accountForecast(visa, txs, day("2025-03-10"), 40, converter{base: "USD"})
+	require.NoError(t, err)
+	require.Len(t, fc, 40)
+	assert.Equal(t, -60.0, fc[0].Balance, "owes the February statement and March so far")
+	assert.Equal(t, -50.0, fc[5].Balance, "Mar 15, February statement paid")
+	assert.Equal(t, -70.0, fc[8].Balance, "Mar 18")
+	assert.Equal(t, -100.0, fc[15].Balance, "Mar 25")
+	assert.Equal(t, -30.0, fc[36].Balance, "Apr 15, March statement paid")
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/memdb"
+)
+
+// benchScales are the transaction counts the forecast benchmarks run at.
+var benchScales = []int{10_000, 100_000}
+
+// benchService returns a service on an in-memory store holding n
+// transactions spread over the two years around today, a quarter of them
+// on one of three accounts, and 50 recurrings.
+func benchService(b *testing.B, n int) (*FinanceService, context.Context) {
+	b.Helper()
+	ctx := context.Background()
+	db := memdb.New()
+	fs := NewFinanceService(db)
+	today := fs.Today(ctx)
+
+	var accounts []int32
+	for _, in := range []AccountInput{
+		{Name: "Checking", Type: AccountChecking},
+		{Name: "Savings", Type: AccountSavings},
+		{Name: "Visa", Type: AccountCreditCard, StatementDay: 20, DueDay: 15},
+	} {
+		acc, err := fs.CreateAccount(ctx, in)
+		if err != nil {
+			b.Fatal(err)
+		}
+		accounts = append(accounts, acc.ID)
+	}
+
+	for i := 0; i < n; i++ {
+		params := database.CreateTransactionParams{
+			Date:        makePgDate(today.AddDate(0, 0, i%730-365)),
+			Amount:      makePgNumeric(-float64(i%20000) / 100),
+			Description: fmt.Sprintf("Purchase %d", i%500),
+			Type:        "expense",
+			Currency:    "USD",
+			Status:      StatusCleared,
+		}
+		if i%7 == 0 {
+			params.Amount = makePgNumeric(float64(i%300000) / 100)
+			params.Type = "income"
+		}
+		if i%4 == 0 {
+			params.AccountID = pgtype.Int4{Int32: accounts[i%len(accounts)], Valid: true}
+		}
+		if i%50 == 0 {
+			params.Status = StatusPending
+		}
+		if _, err := db.CreateTransaction(ctx, params); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for _, r := range manyRecurrings(50) {
+		if _, err := db.CreateRecurring(ctx, database.CreateRecurringParams{
+			Description: fmt.Sprintf("Bill %d", r.ID),
+			Type:        r.Type,
+			Amount:      r.Amount,
+			StartDate:   r.StartDate,
+			Interval:    r.Interval,
+			EndDate:     r.EndDate,
+			Active:      true,
+			Currency:    "USD",
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return fs, ctx
+}
+
+func BenchmarkCalculate90DayForecast(b *testing.B) {
+	for _, n := range benchScales {
+		b.Run(fmt.Sprintf("%dk", n/1000), func(b *testing.B) {
+			fs, ctx := benchService(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Measure the forecast, not the cache in front of it.
+				fs.forecasts.invalidate()
+				if _, err := fs.Calculate90DayForecast(ctx, 1000); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetTransactionsWithRecurringsBetween(b *testing.B) {
+	for _, n := range benchScales {
+		b.Run(fmt.Sprintf("%dk", n/1000), func(b *testing.B) {
+			fs, ctx := benchService(b, n)
+			start := fs.Today(ctx).AddDate(0, 0, -90)
+			end := start.AddDate(0, 0, 180)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := fs.GetTransactionsWithRecurringsBetween(ctx, start, end); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
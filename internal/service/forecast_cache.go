@@ -0,0 +1,79 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// forecastCacheTTL bounds how long a forecast is reused. Writes through
+	// the service clear the cache at once; this covers writes another
+	// process makes to the same database.
+	forecastCacheTTL = time.Minute
+	// maxCachedForecasts keeps a client asking for every horizon in turn
+	// from growing the cache without end.
+	maxCachedForecasts = 32
+)
+
+// forecastKey is everything a forecast depends on besides the data.
+type forecastKey struct {
+	household int32
+	start     time.Time
+	days      int
+	balance   float64
+	opts      ForecastOptions
+}
+
+type cachedForecast struct {
+	forecast []DailyCashFlow
+	at       time.Time
+}
+
+// forecastCache holds recent forecasts by horizon. Any event on the bus
+// clears it, since transactions, recurrings, accounts, rates and settings
+// all feed into a forecast.
+type forecastCache struct {
+	mu      sync.Mutex
+	entries map[forecastKey]cachedForecast
+	// gen counts invalidations, so a forecast computed from data that
+	// changed halfway through isn't stored.
+	gen uint64
+	ttl time.Duration
+	now func() time.Time
+}
+
+func newForecastCache(ttl time.Duration) *forecastCache {
+	return &forecastCache{entries: map[forecastKey]cachedForecast{}, ttl: ttl, now: time.Now}
+}
+
+// get returns a copy of the cached forecast for key, if there is a fresh
+// one. gen is passed back to put along with the forecast computed on a miss.
+func (c *forecastCache) get(key forecastKey) (forecast []DailyCashFlow, gen uint64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || c.now().Sub(e.at) >= c.ttl {
+		return nil, c.gen, false
+	}
+	return append([]DailyCashFlow(nil), e.forecast...), c.gen, true
+}
+
+// put stores forecast unless the cache was invalidated since gen.
+func (c *forecastCache) put(key forecastKey, gen uint64, forecast []DailyCashFlow) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if len(c.entries) >= maxCachedForecasts {
+		clear(c.entries)
+	}
+	c.entries[key] = cachedForecast{forecast: append([]DailyCashFlow(nil), forecast...), at: c.now()}
+}
+
+func (c *forecastCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	clear(c.entries)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecastCache(t *testing.T) {
+	now := day("2025-06-01")
+	c := newForecastCache(time.Minute)
+	c.now = func() time.Time { return now }
+	key := forecastKey{start: day("2025-06-01"), days: 90, balance: 100}
+	fc := []DailyCashFlow{{Date: key.start, Balance: 100}}
+
+	_, gen, ok := c.get(key)
+	require.False(t, ok)
+	c.put(key, gen, fc)
+
+	got, _, ok := c.get(key)
+	require.True(t, ok)
+	assert.Equal(t, fc, got)
+	got[0].Balance = -1
+	got, _, _ = c.get(key)
+	assert.Equal(t, 100.0, got[0].Balance, "callers get their own copy")
+
+	_, _, ok = c.get(forecastKey{start: key.start, days: 30, balance: 100})
+	assert.False(t, ok, "keyed by horizon")
+
+	now = now.Add(time.Minute)
+	_, _, ok = c.get(key)
+	assert.False(t, ok, "expired")
+}
+
+func TestForecastCacheInvalidation(t *testing.T) {
+	c := newForecastCache(time.Minute)
+	key := forecastKey{start: day("2025-06-01"), days: 90}
+	fc := []DailyCashFlow{{Date: key.start}}
+
+	_, gen, _ := c.get(key)
+	c.put(key, gen, fc)
+	c.invalidate()
+	_, gen, ok := c.get(key)
+	assert.False(t, ok)
+
+	// A write lands while the forecast is being computed.
+	c.invalidate()
+	c.put(key, gen, fc)
+	_, _, ok = c.get(key)
+	assert.False(t, ok, "computed from data that has since changed")
+}
+
+func TestEventsInvalidateForecasts(t *testing.T) {
+	fs := NewFinanceService(nil)
+	key := forecastKey{start: day("2025-06-01"), days: 90}
+	_, gen, _ := fs.forecasts.get(key)
+	fs.forecasts.put(key, gen, []DailyCashFlow{{Date: key.start}})
+
+	fs.events.Publish(context.Background(), EventTransactionCreated, nil)
+	_, _, ok := fs.forecasts.get(key)
+	assert.False(t, ok)
+}
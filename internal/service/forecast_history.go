@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// ErrSnapshotNotFound is returned for days with no forecast snapshot.
+var ErrSnapshotNotFound = errors.New("forecast snapshot not found")
+
+// ForecastSnapshot is the forecast as it was computed on Date, from the
+// starting balance of the time.
+type ForecastSnapshot struct {
+	Date            time.Time       `json:"date"`
+	StartingBalance float64         `json:"starting_balance"`
+	Forecast        []DailyCashFlow `json:"forecast"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// SnapshotForecast stores today's forecast over the configured horizon,
+// replacing any taken earlier today, so each day keeps the last one.
+func (fs *FinanceService) SnapshotForecast(ctx context.Context) error {
+	balance, err := fs.GetStartingBalance(ctx)
+	if err != nil {
+		return err
+	}
+	forecast, err := fs.Forecast(ctx, balance, fs.ForecastDays(ctx))
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(forecast)
+	if err != nil {
+		return err
+	}
+	return fs.db.UpsertForecastSnapshot(ctx, database.UpsertForecastSnapshotParams{
+		SnapshotDate:    makePgDate(fs.Today(ctx)),
+		StartingBalance: makePgNumeric(balance),
+		Forecast:        data,
+	})
+}
+
+// ForecastHistory returns the snapshot taken on day. Days from before
+// snapshots were taken, or when the server wasn't running, have none;
+// ForecastAsOf can rebuild those.
+func (fs *FinanceService) ForecastHistory(ctx context.Context, day time.Time) (ForecastSnapshot, error) {
+	day = truncateDay(day)
+	row, err := fs.db.GetForecastSnapshot(ctx, makePgDate(day))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ForecastSnapshot{}, fmt.Errorf("%w: %s", ErrSnapshotNotFound, day.Format("2006-01-02"))
+	}
+	if err != nil {
+		return ForecastSnapshot{}, err
+	}
+	s := ForecastSnapshot{
+		Date:            row.SnapshotDate.Time,
+		StartingBalance: numericOrZero(row.StartingBalance).Float64(),
+		CreatedAt:       row.CreatedAt.Time,
+	}
+	if err := json.Unmarshal(row.Forecast, &s.Forecast); err != nil {
+		return ForecastSnapshot{}, fmt.Errorf("forecast snapshot %s: %w", day.Format("2006-01-02"), err)
+	}
+	return s, nil
+}
+
+// ForecastSnapshotDates lists the days that have a snapshot, oldest first.
+func (fs *FinanceService) ForecastSnapshotDates(ctx context.Context) ([]time.Time, error) {
+	rows, err := fs.db.ListForecastSnapshotDates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]time.Time, len(rows))
+	for i, d := range rows {
+		out[i] = d.Time
+	}
+	return out, nil
+}
+
+// StartForecastSnapshots runs SnapshotForecast on every household every
+// interval until ctx is cancelled.
+func (fs *FinanceService) StartForecastSnapshots(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := fs.forEachHousehold(ctx, fs.SnapshotForecast); err != nil && ctx.Err() == nil {
+				log.Printf("forecast snapshot failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SchemaVersion is the goose migration this code expects the database to be
+// at: the number of the newest file in sql/migrations.
+const SchemaVersion = 32
+
+// ErrNoPool is returned by the health checks of a service that wasn't
+// opened from a database URL.
+var ErrNoPool = errors.New("no database connection pool")
+
+// pinger is implemented by stores that have no pool but can still report
+// their health, like the demo's in-memory one. They need no migrations.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SchemaStatus compares the migrations applied to the database with the
+// ones this build was written against.
+type SchemaStatus struct {
+	Current  int64 `json:"current"`
+	Expected int64 `json:"expected"`
+	// Pending counts migrations not yet applied. A negative count means the
+	// database is ahead, as it is mid-way through a rolling deploy.
+	Pending int64 `json:"pending"`
+}
+
+// Ping checks the database can be reached.
+func (fs *FinanceService) Ping(ctx context.Context) error {
+	if fs.pool == nil {
+		if p, ok := fs.db.(pinger); ok {
+			return p.Ping(ctx)
+		}
+		return ErrNoPool
+	}
+	return fs.pool.Ping(ctx)
+}
+
+// SchemaStatus reads the newest applied migration from goose's bookkeeping
+// table. A database goose has never touched is at version 0.
+func (fs *FinanceService) SchemaStatus(ctx context.Context) (SchemaStatus, error) {
+	if fs.pool == nil {
+		if _, ok := fs.db.(pinger); ok {
+			return SchemaStatus{Current: SchemaVersion, Expected: SchemaVersion}, nil
+		}
+		return SchemaStatus{}, ErrNoPool
+	}
+	var current int64
+	err := fs.pool.QueryRow(ctx,
+		`SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&current)
+	var pgErr *pgconn.PgError
+	// 42P01 is undefined_table.
+	if err != nil && !(errors.As(err, &pgErr) && pgErr.Code == "42P01") {
+		return SchemaStatus{}, err
+	}
+	return SchemaStatus{Current: current, Expected: SchemaVersion, Pending: SchemaVersion - current}, nil
+}
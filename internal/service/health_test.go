@@ -0,0 +1,27 @@
+package service
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaVersionMatchesMigrations(t *testing.T) {
+	entries, err := os.ReadDir("../../sql/migrations")
+	require.NoError(t, err)
+	var newest int64
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		n, err := strconv.ParseInt(prefix, 10, 64)
+		require.NoError(t, err, e.Name())
+		newest = max(newest, n)
+	}
+	assert.Equal(t, newest, int64(SchemaVersion), "bump SchemaVersion along with new migrations")
+}
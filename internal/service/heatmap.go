@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// HeatmapTotal is what was spent over some days of a SpendingHeatmap, as a
+// positive amount.
+type HeatmapTotal struct {
+	Count int64   `json:"count"`
+	Spent float64 `json:"spent"`
+}
+
+// HeatmapDay is one cell of the heatmap: a day, placed by its ISO week and
+// weekday (1 for Monday through 7 for Sunday).
+type HeatmapDay struct {
+	Date    time.Time `json:"date"`
+	Year    int       `json:"year"`
+	Week    int       `json:"week"`
+	Weekday int       `json:"weekday"`
+	HeatmapTotal
+}
+
+// HeatmapWeekday is the spending on one weekday across the whole range.
+type HeatmapWeekday struct {
+	Weekday int `json:"weekday"`
+	HeatmapTotal
+}
+
+// HeatmapWeek is the spending in the ISO week starting on Start (a Monday).
+type HeatmapWeek struct {
+	Year  int       `json:"year"`
+	Week  int       `json:"week"`
+	Start time.Time `json:"start"`
+	HeatmapTotal
+}
+
+// SpendingHeatmap is recorded spending from Start to End, in the base
+// currency, laid out for a week-by-weekday heatmap. Days only lists days with spending. Weekdays
+// always has seven entries, Monday first, and Weeks has every week the
+// range touches, so the grid's rows and columns come straight from them.
+type SpendingHeatmap struct {
+	Start    time.Time        `json:"start"`
+	End      time.Time        `json:"end"`
+	Days     []HeatmapDay     `json:"days"`
+	Weekdays []HeatmapWeekday `json:"weekdays"`
+	Weeks    []HeatmapWeek    `json:"weeks"`
+	// MaxDaySpent is the most spent on any one day, to scale colors by.
+	MaxDaySpent float64      `json:"max_day_spent"`
+	Totals      HeatmapTotal `json:"totals"`
+}
+
+// SpendingHeatmap totals the expenses recorded from start to end inclusive
+// per day and currency in SQL, converts them to the base currency and adds
+// the days up per weekday and per week.
+func (fs *FinanceService) SpendingHeatmap(ctx context.Context, start, end time.Time) (SpendingHeatmap, error) {
+	start, end = truncateDay(start.UTC()), truncateDay(end.UTC())
+	if end.Before(start) {
+		return SpendingHeatmap{}, fmt.Errorf("end %s is before start %s", end.Format("2006-01-02"), start.Format("2006-01-02"))
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return SpendingHeatmap{}, err
+	}
+	rows, err := fs.db.GetSpendingHeatmap(ctx, database.GetSpendingHeatmapParams{
+		StartDate: makePgDate(start),
+		EndDate:   makePgDate(end),
+	})
+	if err != nil {
+		return SpendingHeatmap{}, err
+	}
+
+	type sums struct {
+		count int64
+		spent money.Money
+	}
+	var (
+		weekdays [7]sums
+		all      sums
+		most     money.Money
+		daySums  []sums
+	)
+	weeks := make(map[time.Time]*sums)
+	days := make([]HeatmapDay, 0, len(rows))
+	for _, row := range rows {
+		spent, err := conv.toBase(row.Currency, numericOrZero(row.Spent))
+		if err != nil {
+			return SpendingHeatmap{}, err
+		}
+		d := truncateDay(row.Date.Time.UTC())
+		// Rows are in date order, so a day's currencies are together.
+		if n := len(days); n == 0 || !days[n-1].Date.Equal(d) {
+			days = append(days, HeatmapDay{Date: d, Year: int(row.IsoYear), Week: int(row.Week), Weekday: int(row.Weekday)})
+			daySums = append(daySums, sums{})
+		}
+		monday := d.AddDate(0, 0, 1-int(row.Weekday))
+		if weeks[monday] == nil {
+			weeks[monday] = &sums{}
+		}
+		for _, s := range []*sums{&daySums[len(daySums)-1], &weekdays[row.Weekday-1], weeks[monday], &all} {
+			s.count += row.Count
+			s.spent = s.spent.Add(spent)
+		}
+	}
+	for i, s := range daySums {
+		days[i].HeatmapTotal = HeatmapTotal{Count: s.count, Spent: s.spent.Float64()}
+		if s.spent > most {
+			most = s.spent
+		}
+	}
+
+	heatmap := SpendingHeatmap{
+		Start:       start,
+		End:         end,
+		Days:        days,
+		Weekdays:    make([]HeatmapWeekday, 7),
+		Weeks:       []HeatmapWeek{},
+		MaxDaySpent: most.Float64(),
+		Totals:      HeatmapTotal{Count: all.count, Spent: all.spent.Float64()},
+	}
+	for i, s := range weekdays {
+		heatmap.Weekdays[i] = HeatmapWeekday{Weekday: i + 1, HeatmapTotal: HeatmapTotal{Count: s.count, Spent: s.spent.Float64()}}
+	}
+	// Go's weeks start on Sunday; step back to Monday.
+	for monday := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7)); !monday.After(end); monday = monday.AddDate(0, 0, 7) {
+		year, week := monday.ISOWeek()
+		w := HeatmapWeek{Year: year, Week: week, Start: monday}
+		if s := weeks[monday]; s != nil {
+			w.HeatmapTotal = HeatmapTotal{Count: s.count, Spent: s.spent.Float64()}
+		}
+		heatmap.Weeks = append(heatmap.Weeks, w)
+	}
+	return heatmap, nil
+}
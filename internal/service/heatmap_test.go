@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpendingHeatmap(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-01"), 40, "Groceries", "USD")) // Monday
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-01"), 10, "Coffee", "USD"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-08"), 25, "Groceries", "USD")) // Monday
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-14"), 100, "Dinner", "USD"))   // Sunday
+	require.NoError(t, fs.AddIncome(ctx, day("2025-09-05"), 3000, "Paycheck", "USD"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-20"), 999, "Outside", "USD"))
+
+	heatmap, err := fs.SpendingHeatmap(ctx, day("2025-09-01"), day("2025-09-17"))
+	require.NoError(t, err)
+
+	require.Len(t, heatmap.Days, 3)
+	assert.Equal(t, HeatmapDay{
+		Date: day("2025-09-01"), Year: 2025, Week: 36, Weekday: 1,
+		HeatmapTotal: HeatmapTotal{Count: 2, Spent: 50},
+	}, heatmap.Days[0])
+	assert.Equal(t, 7, heatmap.Days[2].Weekday)
+	assert.Equal(t, 100.0, heatmap.MaxDaySpent)
+
+	require.Len(t, heatmap.Weekdays, 7)
+	assert.Equal(t, HeatmapTotal{Count: 3, Spent: 75}, heatmap.Weekdays[0].HeatmapTotal)
+	assert.Equal(t, 7, heatmap.Weekdays[6].Weekday)
+	assert.Equal(t, 100.0, heatmap.Weekdays[6].Spent)
+	assert.Zero(t, heatmap.Weekdays[2].Count)
+
+	// The range ends on a Wednesday, so its week is there with nothing spent.
+	require.Len(t, heatmap.Weeks, 3)
+	assert.Equal(t, day("2025-09-08"), heatmap.Weeks[1].Start)
+	assert.Equal(t, 37, heatmap.Weeks[1].Week)
+	assert.Equal(t, 125.0, heatmap.Weeks[1].Spent)
+	assert.Zero(t, heatmap.Weeks[2].Spent)
+
+	assert.Equal(t, HeatmapTotal{Count: 4, Spent: 175}, heatmap.Totals)
+
+	_, err = fs.SpendingHeatmap(ctx, day("2025-09-17"), day("2025-09-01"))
+	assert.Error(t, err)
+}
+
+func TestSpendingHeatmapConvertsCurrencies(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+
+	_, err := fs.SetExchangeRate(ctx, "EUR", 2)
+	require.NoError(t, err)
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-01"), 30, "Groceries", "USD"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-01"), 20, "Museum", "EUR"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-02"), 60, "Dinner", "USD"))
+
+	heatmap, err := fs.SpendingHeatmap(ctx, day("2025-09-01"), day("2025-09-07"))
+	require.NoError(t, err)
+	require.Len(t, heatmap.Days, 2, "one cell a day, whatever the currencies")
+	assert.Equal(t, HeatmapTotal{Count: 2, Spent: 70}, heatmap.Days[0].HeatmapTotal)
+	assert.Equal(t, 70.0, heatmap.MaxDaySpent)
+	assert.Equal(t, HeatmapTotal{Count: 2, Spent: 70}, heatmap.Weekdays[0].HeatmapTotal)
+	assert.Equal(t, HeatmapTotal{Count: 3, Spent: 130}, heatmap.Totals)
+
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-03"), 5, "Coffee", "GBP"))
+	_, err = fs.SpendingHeatmap(ctx, day("2025-09-01"), day("2025-09-07"))
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+}
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/auth"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/household"
+)
+
+// Audit entities for households and who belongs to them.
+const (
+	EntityHousehold       = "household"
+	EntityHouseholdMember = "household_member"
+	EntityInvitation      = "household_invitation"
+)
+
+// InvitationTTL is how long an invitation to a household can be accepted
+// for.
+const InvitationTTL = 7 * 24 * time.Hour
+
+const maxHouseholdNameLen = 100
+
+var (
+	// ErrHouseholdNotFound is returned for IDs with no household, and for
+	// members a household doesn't have.
+	ErrHouseholdNotFound = errors.New("household not found")
+	// ErrInvalidHousehold is returned for a bad name or role, for adding
+	// someone twice, for removing a household's last owner and for deleting
+	// the default household.
+	ErrInvalidHousehold = errors.New("invalid household")
+	// ErrInvitationNotFound is returned for IDs with no invitation.
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvalidInvitation is returned for accepting an invitation token
+	// that is unknown, expired or already used.
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+)
+
+// Household is a set of accounts, transactions and everything else kept
+// apart from other households' and shared by its members.
+type Household struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	// Role is the user's role in the household, when listing theirs.
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HouseholdMember is a user who belongs to a household, and what they may
+// do in it.
+type HouseholdMember struct {
+	UserID   int32     `json:"user_id"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Invitation lets whoever holds its token join a household with a role.
+type Invitation struct {
+	ID          int32  `json:"id"`
+	HouseholdID int32  `json:"household_id"`
+	Role        string `json:"role"`
+	// Token is what the invitation is accepted with. Only its hash is kept,
+	// so it is only there when the invitation is created.
+	Token      string     `json:"token,omitempty"`
+	InvitedBy  *int32     `json:"invited_by,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedBy *int32     `json:"accepted_by,omitempty"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// WithHousehold returns a copy of ctx working on household id. Every data
+// method works on the household its context names, the default one if it
+// names none.
+func WithHousehold(ctx context.Context, id int32) context.Context {
+	return household.WithID(ctx, id)
+}
+
+// HouseholdFromContext is the household ctx works on.
+func HouseholdFromContext(ctx context.Context) int32 {
+	return household.FromContext(ctx)
+}
+
+// ListHouseholds returns every household.
+func (fs *FinanceService) ListHouseholds(ctx context.Context) ([]Household, error) {
+	rows, err := fs.db.ListHouseholds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Household, len(rows))
+	for i, row := range rows {
+		out[i] = householdFromRow(row)
+	}
+	return out, nil
+}
+
+// UserHouseholds returns the households a user belongs to, with their role
+// in each, oldest first.
+func (fs *FinanceService) UserHouseholds(ctx context.Context, userID int32) ([]Household, error) {
+	rows, err := fs.db.ListUserHouseholds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Household, len(rows))
+	for i, row := range rows {
+		out[i] = Household{ID: row.ID, Name: row.Name, Role: row.Role, CreatedAt: row.CreatedAt.Time}
+	}
+	return out, nil
+}
+
+func (fs *FinanceService) GetHousehold(ctx context.Context, id int32) (Household, error) {
+	row, err := fs.db.GetHousehold(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Household{}, fmt.Errorf("%w: %d", ErrHouseholdNotFound, id)
+	} else if err != nil {
+		return Household{}, err
+	}
+	return householdFromRow(row), nil
+}
+
+// CreateHousehold starts an empty household with owner as its owner. An
+// owner of 0 leaves it without members, for the API key's holder to
+// invite some.
+func (fs *FinanceService) CreateHousehold(ctx context.Context, name string, owner int32) (Household, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxHouseholdNameLen {
+		return Household{}, fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidHousehold, maxHouseholdNameLen)
+	}
+	row, err := fs.db.CreateHousehold(ctx, name)
+	if err != nil {
+		return Household{}, err
+	}
+	h := householdFromRow(row)
+	ctx = household.WithID(ctx, h.ID)
+	if owner != 0 {
+		if _, err := fs.AddHouseholdMember(ctx, h.ID, owner, RoleOwner); err != nil {
+			return Household{}, err
+		}
+		h.Role = RoleOwner
+	}
+	fs.audit(ctx, AuditCreate, EntityHousehold, h.ID, nil, h)
+	return h, nil
+}
+
+// DeleteHousehold removes a household and all of its data. The default
+// household stays.
+func (fs *FinanceService) DeleteHousehold(ctx context.Context, id int32) error {
+	if id == household.Default {
+		return fmt.Errorf("%w: the default household can't be deleted", ErrInvalidHousehold)
+	}
+	n, err := fs.db.DeleteHousehold(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrHouseholdNotFound, id)
+	}
+	return nil
+}
+
+// MemberRole is a user's role in a household, or "" if they don't belong
+// to it.
+func (fs *FinanceService) MemberRole(ctx context.Context, householdID, userID int32) (string, error) {
+	m, err := fs.db.GetHouseholdMember(ctx, database.GetHouseholdMemberParams{HouseholdID: householdID, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return m.Role, nil
+}
+
+func (fs *FinanceService) ListHouseholdMembers(ctx context.Context, householdID int32) ([]HouseholdMember, error) {
+	if _, err := fs.GetHousehold(ctx, householdID); err != nil {
+		return nil, err
+	}
+	rows, err := fs.db.ListHouseholdMembers(ctx, householdID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]HouseholdMember, len(rows))
+	for i, row := range rows {
+		out[i] = HouseholdMember{UserID: row.UserID, Username: row.Username, Role: row.Role, JoinedAt: row.CreatedAt.Time}
+	}
+	return out, nil
+}
+
+// AddHouseholdMember lets a user into a household with role.
+func (fs *FinanceService) AddHouseholdMember(ctx context.Context, householdID, userID int32, role string) (HouseholdMember, error) {
+	if err := validateMemberRole(role); err != nil {
+		return HouseholdMember{}, err
+	}
+	u, err := fs.GetUser(ctx, userID)
+	if err != nil {
+		return HouseholdMember{}, err
+	}
+	row, err := fs.db.AddHouseholdMember(ctx, database.AddHouseholdMemberParams{HouseholdID: householdID, UserID: userID, Role: role})
+	if err != nil {
+		return HouseholdMember{}, memberError(householdID, u.Username, err)
+	}
+	m := HouseholdMember{UserID: u.ID, Username: u.Username, Role: row.Role, JoinedAt: row.CreatedAt.Time}
+	fs.audit(household.WithID(ctx, householdID), AuditCreate, EntityHouseholdMember, userID, nil, m)
+	return m, nil
+}
+
+// SetHouseholdMemberRole changes what a member may do in a household. It
+// takes effect on their next request. There is always at least one owner
+// left.
+func (fs *FinanceService) SetHouseholdMemberRole(ctx context.Context, householdID, userID int32, role string) (HouseholdMember, error) {
+	if err := validateMemberRole(role); err != nil {
+		return HouseholdMember{}, err
+	}
+	before, err := fs.householdMember(ctx, householdID, userID)
+	if err != nil {
+		return HouseholdMember{}, err
+	}
+	if before.Role == role {
+		return before, nil
+	}
+	if err := fs.keepAHouseholdOwner(ctx, householdID, before); err != nil {
+		return HouseholdMember{}, err
+	}
+	if _, err := fs.db.SetHouseholdMemberRole(ctx, database.SetHouseholdMemberRoleParams{
+		HouseholdID: householdID, UserID: userID, Role: role,
+	}); err != nil {
+		return HouseholdMember{}, err
+	}
+	after := before
+	after.Role = role
+	fs.audit(household.WithID(ctx, householdID), AuditUpdate, EntityHouseholdMember, userID, before, after)
+	return after, nil
+}
+
+// RemoveHouseholdMember takes a user out of a household, whose data they
+// can no longer reach. The last owner can't be removed.
+func (fs *FinanceService) RemoveHouseholdMember(ctx context.Context, householdID, userID int32) error {
+	m, err := fs.householdMember(ctx, householdID, userID)
+	if err != nil {
+		return err
+	}
+	if err := fs.keepAHouseholdOwner(ctx, householdID, m); err != nil {
+		return err
+	}
+	if _, err := fs.db.RemoveHouseholdMember(ctx, database.RemoveHouseholdMemberParams{
+		HouseholdID: householdID, UserID: userID,
+	}); err != nil {
+		return err
+	}
+	fs.audit(household.WithID(ctx, householdID), AuditDelete, EntityHouseholdMember, userID, m, nil)
+	return nil
+}
+
+// CreateInvitation invites whoever is handed the returned token to join a
+// household with role. It can be accepted once, within InvitationTTL.
+func (fs *FinanceService) CreateInvitation(ctx context.Context, householdID int32, role string, invitedBy int32) (Invitation, error) {
+	if err := validateMemberRole(role); err != nil {
+		return Invitation{}, err
+	}
+	if _, err := fs.GetHousehold(ctx, householdID); err != nil {
+		return Invitation{}, err
+	}
+	token, hash := auth.NewRefreshToken()
+	row, err := fs.db.CreateHouseholdInvitation(ctx, database.CreateHouseholdInvitationParams{
+		HouseholdID: householdID,
+		Role:        role,
+		TokenHash:   hash,
+		InvitedBy:   pgtype.Int4{Int32: invitedBy, Valid: invitedBy != 0},
+		ExpiresAt:   timestamp(time.Now().Add(InvitationTTL)),
+	})
+	if err != nil {
+		return Invitation{}, err
+	}
+	inv := invitationFromRow(row)
+	fs.audit(household.WithID(ctx, householdID), AuditCreate, EntityInvitation, inv.ID, nil, inv)
+	inv.Token = token
+	return inv, nil
+}
+
+// ListInvitations returns a household's invitations, used or not, oldest
+// first.
+func (fs *FinanceService) ListInvitations(ctx context.Context, householdID int32) ([]Invitation, error) {
+	if _, err := fs.GetHousehold(ctx, householdID); err != nil {
+		return nil, err
+	}
+	rows, err := fs.db.ListHouseholdInvitations(ctx, householdID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Invitation, len(rows))
+	for i, row := range rows {
+		out[i] = invitationFromRow(row)
+	}
+	return out, nil
+}
+
+// RevokeInvitation deletes an invitation so its token stops working.
+func (fs *FinanceService) RevokeInvitation(ctx context.Context, householdID, id int32) error {
+	n, err := fs.db.DeleteHouseholdInvitation(ctx, database.DeleteHouseholdInvitationParams{HouseholdID: householdID, ID: id})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrInvitationNotFound, id)
+	}
+	fs.audit(household.WithID(ctx, householdID), AuditDelete, EntityInvitation, id, nil, nil)
+	return nil
+}
+
+// AcceptInvitation adds a user to the household an invitation is for, with
+// the role it offers, and uses the invitation up.
+func (fs *FinanceService) AcceptInvitation(ctx context.Context, token string, userID int32) (Household, error) {
+	row, err := fs.db.GetHouseholdInvitationByToken(ctx, auth.HashToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Household{}, ErrInvalidInvitation
+	} else if err != nil {
+		return Household{}, err
+	}
+	if row.AcceptedAt.Valid || time.Now().After(row.ExpiresAt.Time) {
+		return Household{}, ErrInvalidInvitation
+	}
+	role, err := fs.MemberRole(ctx, row.HouseholdID, userID)
+	if err != nil {
+		return Household{}, err
+	}
+	if role != "" {
+		return Household{}, fmt.Errorf("%w: already a member of household %d", ErrInvalidHousehold, row.HouseholdID)
+	}
+	// Two accepts racing over one invitation: only one gets it.
+	n, err := fs.db.AcceptHouseholdInvitation(ctx, database.AcceptHouseholdInvitationParams{
+		ID:         row.ID,
+		AcceptedBy: pgtype.Int4{Int32: userID, Valid: true},
+	})
+	if err != nil {
+		return Household{}, err
+	}
+	if n == 0 {
+		return Household{}, ErrInvalidInvitation
+	}
+	if _, err := fs.AddHouseholdMember(ctx, row.HouseholdID, userID, row.Role); err != nil {
+		return Household{}, err
+	}
+	h, err := fs.GetHousehold(ctx, row.HouseholdID)
+	if err != nil {
+		return Household{}, err
+	}
+	h.Role = row.Role
+	return h, nil
+}
+
+// forEachHousehold runs fn on every household in turn, for the background
+// jobs. It goes on past failures and returns them all.
+func (fs *FinanceService) forEachHousehold(ctx context.Context, fn func(context.Context) error) error {
+	households, err := fs.db.ListHouseholds(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, h := range households {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := fn(household.WithID(ctx, h.ID)); err != nil {
+			errs = append(errs, fmt.Errorf("household %d: %w", h.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (fs *FinanceService) householdMember(ctx context.Context, householdID, userID int32) (HouseholdMember, error) {
+	members, err := fs.ListHouseholdMembers(ctx, householdID)
+	if err != nil {
+		return HouseholdMember{}, err
+	}
+	for _, m := range members {
+		if m.UserID == userID {
+			return m, nil
+		}
+	}
+	return HouseholdMember{}, fmt.Errorf("%w: user %d is not a member of household %d", ErrHouseholdNotFound, userID, householdID)
+}
+
+// keepAHouseholdOwner refuses to take m's owner role away if nobody else in
+// the household has it, which would leave no one able to manage it.
+func (fs *FinanceService) keepAHouseholdOwner(ctx context.Context, householdID int32, m HouseholdMember) error {
+	if m.Role != RoleOwner {
+		return nil
+	}
+	owners, err := fs.db.CountHouseholdOwners(ctx, householdID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return fmt.Errorf("%w: %s is the last owner of household %d", ErrInvalidHousehold, m.Username, householdID)
+	}
+	return nil
+}
+
+func householdFromRow(row database.Households) Household {
+	return Household{ID: row.ID, Name: row.Name, CreatedAt: row.CreatedAt.Time}
+}
+
+func invitationFromRow(row database.HouseholdInvitations) Invitation {
+	inv := Invitation{
+		ID:          row.ID,
+		HouseholdID: row.HouseholdID,
+		Role:        row.Role,
+		ExpiresAt:   row.ExpiresAt.Time,
+		CreatedAt:   row.CreatedAt.Time,
+	}
+	if row.InvitedBy.Valid {
+		inv.InvitedBy = &row.InvitedBy.Int32
+	}
+	if row.AcceptedBy.Valid {
+		inv.AcceptedBy = &row.AcceptedBy.Int32
+	}
+	if row.AcceptedAt.Valid {
+		inv.AcceptedAt = &row.AcceptedAt.Time
+	}
+	return inv
+}
+
+func validateMemberRole(role string) error {
+	if roleRank[role] == 0 {
+		return fmt.Errorf("%w: role %q (expected %s)", ErrInvalidHousehold, role, strings.Join(Roles, ", "))
+	}
+	return nil
+}
+
+// memberError turns a clash on the membership's key into
+// ErrInvalidHousehold, and a household that doesn't exist into
+// ErrHouseholdNotFound.
+func memberError(householdID int32, username string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return fmt.Errorf("%w: %s is already a member of household %d", ErrInvalidHousehold, username, householdID)
+		case "23503":
+			return fmt.Errorf("%w: %d", ErrHouseholdNotFound, householdID)
+		}
+	}
+	return err
+}
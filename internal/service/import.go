@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/importer"
+)
+
+// ErrInvalidImport wraps errors caused by the import file itself, as opposed
+// to failures while saving its transactions.
+var ErrInvalidImport = errors.New("invalid import file")
+
+// ImportResult describes the transactions an import created, or with
+// DryRun set, the transactions it would create.
+type ImportResult struct {
+	DryRun       bool          `json:"dry_run"`
+	Format       string        `json:"format"`
+	Entries      int           `json:"entries"`
+	Transactions []Transaction `json:"transactions"`
+}
+
+// ImportTransactions parses an export file and, unless dryRun is set,
+// creates a transaction for each entry. Split entries become one
+// transaction per split so each keeps its own category.
+func (fs *FinanceService) ImportTransactions(ctx context.Context, format string, r io.Reader, dryRun bool) (ImportResult, error) {
+	var (
+		entries []importer.Entry
+		err     error
+	)
+	switch strings.ToLower(format) {
+	case "qif":
+		entries, err = importer.ParseQIF(r)
+	case "ynab":
+		entries, err = importer.ParseYNABCSV(r)
+	case "mint":
+		entries, err = importer.ParseMintCSV(r)
+	default:
+		return ImportResult{}, fmt.Errorf("%w: unsupported format %q (expected qif, ynab or mint)", ErrInvalidImport, format)
+	}
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+
+	params := entriesToTransactions(entries)
+	result := ImportResult{
+		DryRun:       dryRun,
+		Format:       strings.ToLower(format),
+		Entries:      len(entries),
+		Transactions: make([]Transaction, 0, len(params)),
+	}
+	for _, p := range params {
+		if dryRun {
+			result.Transactions = append(result.Transactions, Transaction{
+				Date:        p.Date,
+				Amount:      p.Amount,
+				Description: p.Description,
+				Type:        p.Type,
+				Category:    p.Category,
+			})
+			continue
+		}
+		tx, err := fs.createTransaction(ctx, p)
+		if err != nil {
+			return result, fmt.Errorf("import stopped after %d transactions: %w", len(result.Transactions), err)
+		}
+		result.Transactions = append(result.Transactions, tx)
+	}
+	return result, nil
+}
+
+func entriesToTransactions(entries []importer.Entry) []database.CreateTransactionParams {
+	var out []database.CreateTransactionParams
+	for _, e := range entries {
+		if len(e.Splits) == 0 {
+			out = append(out, entryTransaction(e.Date, e.Amount, describeEntry(e.Payee, e.Memo), e.Category))
+			continue
+		}
+		for _, s := range e.Splits {
+			memo := s.Memo
+			if memo == "" {
+				memo = e.Memo
+			}
+			out = append(out, entryTransaction(e.Date, s.Amount, describeEntry(e.Payee, memo), s.Category))
+		}
+	}
+	return out
+}
+
+func entryTransaction(date time.Time, amount float64, description, category string) database.CreateTransactionParams {
+	typ := "income"
+	if amount < 0 {
+		typ = "expense"
+	}
+	return database.CreateTransactionParams{
+		Date:        makePgDate(date),
+		Amount:      makePgNumeric(amount),
+		Description: description,
+		Type:        typ,
+		Category:    pgtype.Text{String: category, Valid: category != ""},
+	}
+}
+
+func describeEntry(payee, memo string) string {
+	switch {
+	case payee == "":
+		return memo
+	case memo == "" || strings.EqualFold(payee, memo):
+		return payee
+	default:
+		return payee + " - " + memo
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jdelles/currentz/internal/analytics"
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// SpendingInsights is the month summary Insights returns.
+type SpendingInsights = analytics.Insights
+
+// Insights summarizes spending trends for the month containing month
+// against the month before, in the base currency: see analytics.Insights.
+// A month still under way is counted through today and compared with the
+// same days of the month before. Amounts are converted at today's rates.
+func (fs *FinanceService) Insights(ctx context.Context, month time.Time) (SpendingInsights, error) {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	today := fs.Today(ctx)
+	if first.After(today) {
+		return SpendingInsights{}, fmt.Errorf("%w: %s hasn't started", ErrInvalidMonth, first.Format("2006-01"))
+	}
+	through := last
+	if today.Before(last) {
+		through = today
+	}
+
+	txs, err := fs.db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
+		Date:   makePgDate(first.AddDate(0, -1, 0)),
+		Date_2: makePgDate(through),
+	})
+	if err != nil {
+		return SpendingInsights{}, err
+	}
+	recurring, err := fs.ExpandRecurringBetween(ctx, first, through)
+	if err != nil {
+		return SpendingInsights{}, err
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return SpendingInsights{}, err
+	}
+
+	in := analytics.Input{Month: first, Through: through}
+	if in.Transactions, err = analyticsTransactions(txs, conv); err != nil {
+		return SpendingInsights{}, err
+	}
+	if in.Recurring, err = analyticsTransactions(recurring, conv); err != nil {
+		return SpendingInsights{}, err
+	}
+	return analytics.Summarize(in), nil
+}
+
+// analyticsTransactions converts txs to the base currency for the
+// analytics package.
+func analyticsTransactions(txs []Transaction, conv converter) ([]analytics.Transaction, error) {
+	out := make([]analytics.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		amt, err := NumericToMoney(tx.Amount)
+		if err != nil {
+			continue
+		}
+		if amt, err = conv.toBase(tx.Currency, amt); err != nil {
+			return nil, err
+		}
+		out = append(out, analytics.Transaction{
+			Date:        tx.Date.Time,
+			Amount:      amt,
+			Category:    tx.Category.String,
+			Description: tx.Description,
+		})
+	}
+	return out, nil
+}
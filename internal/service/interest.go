@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// How often interest is credited to an account.
+const (
+	CompoundDaily   = "daily"
+	CompoundMonthly = "monthly"
+)
+
+// InterestInput sets what an account earns. Balance is the account's
+// current balance, which is already part of the starting balance; it is only
+// used to work out interest. APY is a fraction, 0.045 for 4.5%, and nil
+// turns interest off. Compounding defaults to monthly.
+type InterestInput struct {
+	Balance     float64
+	APY         *float64
+	Compounding string
+}
+
+// SetAccountInterest updates the balance and interest terms of a checking or
+// savings account.
+func (fs *FinanceService) SetAccountInterest(ctx context.Context, id int32, in InterestInput) (Account, error) {
+	before, err := fs.db.GetAccountByID(ctx, id)
+	if err != nil {
+		return Account{}, fmt.Errorf("%w: %d", ErrAccountNotFound, id)
+	}
+	if before.Type == AccountCreditCard {
+		return Account{}, fmt.Errorf("%w: interest is only projected for checking and savings accounts", ErrInvalidAccount)
+	}
+
+	params := database.SetAccountInterestParams{
+		ID:          id,
+		Balance:     makePgNumeric(in.Balance),
+		Compounding: strings.ToLower(strings.TrimSpace(in.Compounding)),
+	}
+	if params.Compounding == "" {
+		params.Compounding = CompoundMonthly
+	}
+	if params.Compounding != CompoundDaily && params.Compounding != CompoundMonthly {
+		return Account{}, fmt.Errorf("%w: compounding must be daily or monthly", ErrInvalidAccount)
+	}
+	if in.APY != nil {
+		if *in.APY < 0 || *in.APY >= 1 {
+			return Account{}, fmt.Errorf("%w: apy %.4f out of range (expected 0 <= apy < 1)", ErrInvalidAccount, *in.APY)
+		}
+		_ = params.Apy.Scan(fmt.Sprintf("%.6f", *in.APY))
+	}
+
+	acc, err := fs.db.SetAccountInterest(ctx, params)
+	if err != nil {
+		return Account{}, err
+	}
+	fs.audit(ctx, AuditUpdate, EntityAccount, id, before, acc)
+	fs.events.Publish(ctx, EventAccountUpdated, acc)
+	return acc, nil
+}
+
+// accountInterest projects the interest each account earns per day between
+// start and end. An account's balance starts at its stored balance and
+// moves with the transactions charged to it inside the window. Daily
+// compounding credits interest every day; monthly compounding credits a
+// month's worth on the 1st, on the balance at that point.
+func accountInterest(accounts []Account, txs []Transaction, start, end time.Time, conv converter) (map[time.Time]money.Money, error) {
+	out := make(map[time.Time]money.Money)
+	for _, acc := range accounts {
+		if acc.Type == AccountCreditCard || !acc.Apy.Valid {
+			continue
+		}
+		apy, err := NumericToFloat64(acc.Apy)
+		if err != nil || apy <= 0 {
+			continue
+		}
+		balance, err := NumericToMoney(acc.Balance)
+		if err != nil {
+			return nil, fmt.Errorf("balance of %s: %w", acc.Name, err)
+		}
+
+		flows := make(map[time.Time]money.Money)
+		for _, tx := range txs {
+			if !tx.AccountID.Valid || tx.AccountID.Int32 != acc.ID {
+				continue
+			}
+			day := truncateDay(tx.Date.Time.UTC())
+			if day.Before(start) || day.After(end) {
+				continue
+			}
+			amt, err := NumericToMoney(tx.Amount)
+			if err != nil {
+				continue
+			}
+			if amt, err = conv.toBase(tx.Currency, amt); err != nil {
+				return nil, err
+			}
+			flows[day] = flows[day].Add(amt)
+		}
+
+		dailyRate := math.Pow(1+apy, 1.0/365) - 1
+		monthlyRate := math.Pow(1+apy, 1.0/12) - 1
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			if acc.Compounding == CompoundMonthly && day.Day() == 1 {
+				earned := balance.Mul(monthlyRate)
+				balance = balance.Add(earned)
+				out[day] = out[day].Add(earned)
+			}
+			balance = balance.Add(flows[day])
+			if acc.Compounding == CompoundDaily {
+				earned := balance.Mul(dailyRate)
+				balance = balance.Add(earned)
+				out[day] = out[day].Add(earned)
+			}
+		}
+	}
+	return out, nil
+}
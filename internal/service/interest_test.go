@@ -0,0 +1,77 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+func savings(id int32, balance float64, apy, compounding string) Account {
+	acc := Account{ID: id, Name: "Savings", Type: AccountSavings, Balance: makePgNumeric(balance), Compounding: compounding}
+	_ = acc.Apy.Scan(apy)
+	return acc
+}
+
+func TestAccountInterestMonthly(t *testing.T) {
+	accounts := []Account{savings(2, 10000, "0.05", CompoundMonthly)}
+	conv := converter{base: "USD"}
+
+	got, err := accountInterest(accounts, nil, day("2025-06-15"), day("2025-08-14"), conv)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, money.FromCents(4074), got[day("2025-07-01")])
+	assert.Equal(t, money.FromCents(4091), got[day("2025-08-01")], "compounds on July's interest")
+
+	// A deposit into the account before the 1st earns from then on.
+	txs := []Transaction{
+		{Date: makePgDate(day("2025-06-20")), Amount: makePgNumeric(1000), AccountID: pgtype.Int4{Int32: 2, Valid: true}},
+		{Date: makePgDate(day("2025-06-20")), Amount: makePgNumeric(5000)},
+	}
+	got, err = accountInterest(accounts, txs, day("2025-06-15"), day("2025-07-14"), conv)
+	require.NoError(t, err)
+	assert.Equal(t, money.FromCents(4482), got[day("2025-07-01")])
+}
+
+func TestAccountInterestDaily(t *testing.T) {
+	accounts := []Account{savings(2, 10000, "0.05", CompoundDaily)}
+	start := day("2025-01-01")
+
+	got, err := accountInterest(accounts, nil, start, start.AddDate(0, 0, 364), converter{base: "USD"})
+	require.NoError(t, err)
+	assert.Len(t, got, 365)
+	assert.Equal(t, money.FromCents(134), got[start])
+
+	var total money.Money
+	for _, earned := range got {
+		total = total.Add(earned)
+	}
+	assert.InDelta(t, 500.0, total.Float64(), 1, "a year of daily compounding matches the APY")
+}
+
+func TestAccountInterestSkipsCardsAndUnsetAPY(t *testing.T) {
+	visa := card(1, 20, 15)
+	_ = visa.Apy.Scan("0.2")
+	checking := Account{ID: 3, Name: "Checking", Type: AccountChecking, Balance: makePgNumeric(5000), Compounding: CompoundDaily}
+
+	got, err := accountInterest([]Account{visa, checking}, nil, day("2025-06-01"), day("2025-08-29"), converter{base: "USD"})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestAccumulateForecastAddsInterest(t *testing.T) {
+	start := day("2025-06-30")
+	daily := map[time.Time]money.Money{start: money.FromFloat(-100)}
+	interest := map[time.Time]money.Money{start.AddDate(0, 0, 1): money.FromCents(4074)}
+
+	fc := accumulateForecast(start, 90, daily, interest, 1000)
+	assert.Equal(t, 900.0, fc[0].Balance)
+	assert.Zero(t, fc[0].Interest)
+	assert.Equal(t, 940.74, fc[1].Balance)
+	assert.Equal(t, 40.74, fc[1].Change)
+	assert.Equal(t, 40.74, fc[1].Interest)
+}
@@ -0,0 +1,552 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/notify"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// EntityNotificationChannel is the audit entity for notification channel
+// changes.
+const EntityNotificationChannel = "notification_channel"
+
+// Kinds of notification SendNotifications delivers.
+const (
+	NotifyLowBalance   = "low_balance"
+	NotifyLargeBill    = "large_bill"
+	NotifyWeeklyDigest = "weekly_digest"
+)
+
+// largeBillDays is how far ahead SendNotifications looks for large bills,
+// today included.
+const largeBillDays = 7
+
+var (
+	// ErrInvalidNotificationChannel is returned for channel definitions
+	// that do not validate.
+	ErrInvalidNotificationChannel = errors.New("invalid notification channel")
+	// ErrNotificationChannelNotFound is returned when looking up an
+	// unknown channel.
+	ErrNotificationChannelNotFound = errors.New("notification channel not found")
+	// ErrNotificationFailed is returned when a channel couldn't deliver.
+	ErrNotificationFailed = errors.New("notification failed")
+)
+
+// NotificationChannel is somewhere notifications go. Config has its
+// secrets redacted; see notify.Config.Redacted.
+type NotificationChannel struct {
+	ID        int32         `json:"id"`
+	Name      string        `json:"name"`
+	Type      string        `json:"type"`
+	Config    notify.Config `json:"config"`
+	Enabled   bool          `json:"enabled"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// NotificationChannelInput describes a channel. Config.Type picks the
+// kind of channel and which of its fields are needed.
+type NotificationChannelInput struct {
+	Name    string
+	Config  notify.Config
+	Enabled bool
+}
+
+// ListNotificationChannels returns every channel, secrets redacted.
+func (fs *FinanceService) ListNotificationChannels(ctx context.Context) ([]NotificationChannel, error) {
+	rows, err := fs.db.ListNotificationChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]NotificationChannel, len(rows))
+	for i, row := range rows {
+		c, _, err := notificationChannel(row)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = c
+	}
+	return out, nil
+}
+
+func (fs *FinanceService) GetNotificationChannel(ctx context.Context, id int32) (NotificationChannel, error) {
+	c, _, err := fs.notificationChannel(ctx, id)
+	return c, err
+}
+
+func (fs *FinanceService) CreateNotificationChannel(ctx context.Context, in NotificationChannelInput) (NotificationChannel, error) {
+	params, err := notificationChannelParams(in)
+	if err != nil {
+		return NotificationChannel{}, err
+	}
+	row, err := fs.db.CreateNotificationChannel(ctx, params)
+	if err != nil {
+		return NotificationChannel{}, notificationChannelError(params.Name, err)
+	}
+	c, _, err := notificationChannel(row)
+	if err != nil {
+		return NotificationChannel{}, err
+	}
+	fs.audit(ctx, AuditCreate, EntityNotificationChannel, c.ID, nil, c)
+	fs.events.Publish(ctx, EventNotificationChannelCreated, c)
+	return c, nil
+}
+
+// UpdateNotificationChannel replaces a channel's definition, secrets
+// included.
+func (fs *FinanceService) UpdateNotificationChannel(ctx context.Context, id int32, in NotificationChannelInput) (NotificationChannel, error) {
+	before, _, err := fs.notificationChannel(ctx, id)
+	if err != nil {
+		return NotificationChannel{}, err
+	}
+	params, err := notificationChannelParams(in)
+	if err != nil {
+		return NotificationChannel{}, err
+	}
+	row, err := fs.db.UpdateNotificationChannel(ctx, database.UpdateNotificationChannelParams{
+		Name:    params.Name,
+		Type:    params.Type,
+		Config:  params.Config,
+		Enabled: params.Enabled,
+		ID:      id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NotificationChannel{}, fmt.Errorf("%w: %d", ErrNotificationChannelNotFound, id)
+	}
+	if err != nil {
+		return NotificationChannel{}, notificationChannelError(params.Name, err)
+	}
+	c, _, err := notificationChannel(row)
+	if err != nil {
+		return NotificationChannel{}, err
+	}
+	fs.audit(ctx, AuditUpdate, EntityNotificationChannel, id, before, c)
+	fs.events.Publish(ctx, EventNotificationChannelUpdated, c)
+	return c, nil
+}
+
+func (fs *FinanceService) DeleteNotificationChannel(ctx context.Context, id int32) error {
+	before, _, err := fs.notificationChannel(ctx, id)
+	if err != nil {
+		return err
+	}
+	n, err := fs.db.DeleteNotificationChannel(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrNotificationChannelNotFound, id)
+	}
+	fs.audit(ctx, AuditDelete, EntityNotificationChannel, id, before, nil)
+	fs.events.Publish(ctx, EventNotificationChannelDeleted, map[string]int32{"id": id})
+	return nil
+}
+
+// TestNotificationChannel sends a test message through one channel,
+// enabled or not. Inside DryRun it only checks the channel can be opened.
+func (fs *FinanceService) TestNotificationChannel(ctx context.Context, id int32) error {
+	c, cfg, err := fs.notificationChannel(ctx, id)
+	if err != nil {
+		return err
+	}
+	ch, err := notify.Open(cfg, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidNotificationChannel, err)
+	}
+	if IsDryRun(ctx) {
+		return nil
+	}
+	msg := notify.Message{Title: "currentz", Text: fmt.Sprintf("Notifications to %q are working.", c.Name)}
+	if err := ch.Send(ctx, msg); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotificationFailed, err)
+	}
+	return nil
+}
+
+// notificationChannel looks up a channel, returning it redacted and its
+// full configuration.
+func (fs *FinanceService) notificationChannel(ctx context.Context, id int32) (NotificationChannel, notify.Config, error) {
+	row, err := fs.db.GetNotificationChannel(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NotificationChannel{}, notify.Config{}, fmt.Errorf("%w: %d", ErrNotificationChannelNotFound, id)
+	}
+	if err != nil {
+		return NotificationChannel{}, notify.Config{}, err
+	}
+	return notificationChannel(row)
+}
+
+// notificationChannel decodes a notification_channels row.
+func notificationChannel(row database.NotificationChannels) (NotificationChannel, notify.Config, error) {
+	var cfg notify.Config
+	if err := json.Unmarshal(row.Config, &cfg); err != nil {
+		return NotificationChannel{}, notify.Config{}, fmt.Errorf("notification channel %d: %w", row.ID, err)
+	}
+	cfg.Type = row.Type
+	return NotificationChannel{
+		ID:        row.ID,
+		Name:      row.Name,
+		Type:      row.Type,
+		Config:    cfg.Redacted(),
+		Enabled:   row.Enabled,
+		CreatedAt: row.CreatedAt.Time,
+	}, cfg, nil
+}
+
+func notificationChannelParams(in NotificationChannelInput) (database.CreateNotificationChannelParams, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return database.CreateNotificationChannelParams{}, fmt.Errorf("%w: name is required", ErrInvalidNotificationChannel)
+	}
+	if len(name) > 100 {
+		return database.CreateNotificationChannelParams{}, fmt.Errorf("%w: name is longer than 100 characters", ErrInvalidNotificationChannel)
+	}
+	cfg := in.Config
+	cfg.Type = strings.ToLower(strings.TrimSpace(cfg.Type))
+	if err := cfg.Validate(); err != nil {
+		return database.CreateNotificationChannelParams{}, fmt.Errorf("%w: %w", ErrInvalidNotificationChannel, err)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return database.CreateNotificationChannelParams{}, err
+	}
+	return database.CreateNotificationChannelParams{
+		Name:    name,
+		Type:    cfg.Type,
+		Config:  data,
+		Enabled: in.Enabled,
+	}, nil
+}
+
+// notificationChannelError turns a clash on the unique name into
+// ErrInvalidNotificationChannel.
+func notificationChannelError(name string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("%w: a channel named %q already exists", ErrInvalidNotificationChannel, name)
+	}
+	return err
+}
+
+// LargeBillThreshold is the size, in the base currency, from which an
+// upcoming recurring expense is notified. ok is false when neither it nor a
+// default is set.
+func (fs *FinanceService) LargeBillThreshold(ctx context.Context) (threshold float64, ok bool) {
+	value, err := fs.db.GetSetting(ctx, SettingLargeBillThreshold)
+	if err != nil {
+		if d := fs.settingDefaults().LargeBillThreshold; d != nil {
+			return *d, true
+		}
+		return 0, false
+	}
+	threshold, err = strconv.ParseFloat(value, 64)
+	if err != nil || threshold <= 0 {
+		return 0, false
+	}
+	return threshold, true
+}
+
+func (fs *FinanceService) SetLargeBillThreshold(ctx context.Context, threshold float64) error {
+	if threshold <= 0 {
+		return fmt.Errorf("%w: large bill threshold must be positive", ErrInvalidSetting)
+	}
+	if err := fs.updateSetting(ctx, SettingLargeBillThreshold, money.FromFloat(threshold).String()); err != nil {
+		return err
+	}
+	fs.events.Publish(ctx, EventSettingsChanged, map[string]float64{SettingLargeBillThreshold: threshold})
+	return nil
+}
+
+// NotificationMinChange is how far, in the base currency, the figure a
+// low-balance alert or weekly digest reports must move from the one the
+// last of its kind reported before another goes out. ok is false when
+// neither it nor a default is set, and each is sent regardless.
+func (fs *FinanceService) NotificationMinChange(ctx context.Context) (change float64, ok bool) {
+	value, err := fs.db.GetSetting(ctx, SettingNotificationMinChange)
+	if err != nil {
+		if d := fs.settingDefaults().NotificationMinChange; d != nil {
+			return *d, true
+		}
+		return 0, false
+	}
+	change, err = strconv.ParseFloat(value, 64)
+	if err != nil || change <= 0 {
+		return 0, false
+	}
+	return change, true
+}
+
+func (fs *FinanceService) SetNotificationMinChange(ctx context.Context, change float64) error {
+	if change <= 0 {
+		return fmt.Errorf("%w: notification min change must be positive", ErrInvalidSetting)
+	}
+	if err := fs.updateSetting(ctx, SettingNotificationMinChange, money.FromFloat(change).String()); err != nil {
+		return err
+	}
+	fs.events.Publish(ctx, EventSettingsChanged, map[string]float64{SettingNotificationMinChange: change})
+	return nil
+}
+
+// SendNotifications works out what is worth telling the user about now and
+// sends each item once to every enabled channel:
+//
+//   - a low-balance alert, at most daily, while the forecast dips below
+//     the low-balance threshold;
+//   - each recurring expense of at least the large-bill threshold due in
+//     the next week;
+//   - on Mondays, a digest of the week before and the month ahead.
+//
+// With a notification min change set, the low-balance alert and the digest
+// only go out once the lowest and the closing balance they report have
+// moved by more than that since the last one. Nothing is worked out while
+// there are no enabled channels.
+func (fs *FinanceService) SendNotifications(ctx context.Context) error {
+	channels, err := fs.enabledChannels(ctx)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+	today := fs.Today(ctx)
+	balance, err := fs.GetStartingBalance(ctx)
+	if err != nil {
+		return err
+	}
+	forecast, err := fs.Forecast(ctx, balance, fs.ForecastDays(ctx))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if threshold, ok, err := fs.LowBalanceThreshold(ctx); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		low, i := LowestPoint(forecast)
+		if i >= 0 && low.Balance < threshold {
+			errs = append(errs, fs.notifyOnChange(ctx, channels, NotifyLowBalance, today.Format("2006-01-02"), money.FromFloat(low.Balance), notify.Message{
+				Title: "Low balance ahead",
+				Text:  fmt.Sprintf("The forecast balance drops to %.2f on %s, below the %.2f threshold.", low.Balance, low.Date.Format("Jan 2, 2006"), threshold),
+			}))
+		}
+	}
+
+	if threshold, ok := fs.LargeBillThreshold(ctx); ok {
+		errs = append(errs, fs.notifyLargeBills(ctx, channels, today, money.FromFloat(threshold)))
+	}
+
+	if today.Weekday() == time.Monday {
+		year, week := today.ISOWeek()
+		msg, err := fs.weeklyDigest(ctx, today, forecast)
+		key := fmt.Sprintf("%d-W%02d", year, week)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case len(forecast) == 0:
+			errs = append(errs, fs.notify(ctx, channels, NotifyWeeklyDigest, key, msg))
+		default:
+			closing := forecast[min(len(forecast), 30)-1].Balance
+			errs = append(errs, fs.notifyOnChange(ctx, channels, NotifyWeeklyDigest, key, money.FromFloat(closing), msg))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StartNotifications runs SendNotifications on every household every
+// interval until ctx is cancelled.
+func (fs *FinanceService) StartNotifications(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := fs.forEachHousehold(ctx, fs.SendNotifications); err != nil && ctx.Err() == nil {
+				log.Printf("notifications failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (fs *FinanceService) notifyLargeBills(ctx context.Context, channels []notify.Channel, today time.Time, threshold money.Money) error {
+	bills, err := fs.ExpandRecurringBetween(ctx, today, today.AddDate(0, 0, largeBillDays-1))
+	if err != nil {
+		return err
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, bill := range bills {
+		if bill.Type != "expense" {
+			continue
+		}
+		amt, err := NumericToMoney(bill.Amount)
+		if err != nil {
+			continue
+		}
+		if amt, err = conv.toBase(bill.Currency, amt.Neg()); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if amt < threshold {
+			continue
+		}
+		date := bill.Date.Time
+		key := date.Format("2006-01-02") + ":" + strings.ToLower(strings.TrimSpace(bill.Description))
+		errs = append(errs, fs.notify(ctx, channels, NotifyLargeBill, truncate(key, 100), notify.Message{
+			Title: "Large bill coming up",
+			Text:  fmt.Sprintf("%s for %.2f is due on %s.", bill.Description, amt.Float64(), date.Format("Mon Jan 2")),
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// weeklyDigest sums up the seven days before today and the month ahead.
+func (fs *FinanceService) weeklyDigest(ctx context.Context, today time.Time, forecast []DailyCashFlow) (notify.Message, error) {
+	start := today.AddDate(0, 0, -7)
+	txs, err := fs.db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
+		Date:   makePgDate(start),
+		Date_2: makePgDate(today.AddDate(0, 0, -1)),
+	})
+	if err != nil {
+		return notify.Message{}, err
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return notify.Message{}, err
+	}
+	var income, spent money.Money
+	for _, tx := range txs {
+		amt, err := NumericToMoney(tx.Amount)
+		if err != nil {
+			continue
+		}
+		if amt, err = conv.toBase(tx.Currency, amt); err != nil {
+			return notify.Message{}, err
+		}
+		if amt >= 0 {
+			income = income.Add(amt)
+		} else {
+			spent = spent.Sub(amt)
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Last week: %.2f in, %.2f out.", income.Float64(), spent.Float64()),
+	}
+	if len(forecast) > 0 {
+		lines = append(lines, fmt.Sprintf("Balance today: %.2f.", forecast[0].Balance))
+		month := forecast[:min(len(forecast), 30)]
+		low, _ := LowestPoint(month)
+		lines = append(lines,
+			fmt.Sprintf("Lowest in the next 30 days: %.2f on %s.", low.Balance, low.Date.Format("Jan 2")),
+			fmt.Sprintf("In 30 days: %.2f.", month[len(month)-1].Balance),
+		)
+	}
+	return notify.Message{
+		Title: "Week of " + today.Format("Jan 2"),
+		Text:  strings.Join(lines, "\n"),
+	}, nil
+}
+
+// enabledChannels opens every enabled channel. One that can't be opened
+// is logged and skipped rather than holding up the rest.
+func (fs *FinanceService) enabledChannels(ctx context.Context) ([]notify.Channel, error) {
+	rows, err := fs.db.ListNotificationChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []notify.Channel
+	for _, row := range rows {
+		if !row.Enabled {
+			continue
+		}
+		_, cfg, err := notificationChannel(row)
+		if err == nil {
+			var ch notify.Channel
+			if ch, err = notify.Open(cfg, nil); err == nil {
+				out = append(out, ch)
+				continue
+			}
+		}
+		log.Printf("notification channel %d: %v", row.ID, err)
+	}
+	return out, nil
+}
+
+// notify sends msg to channels unless a notification of kind with this key
+// has gone out before. It counts as sent once any channel has taken it, so
+// a channel that is down misses it rather than the others getting it
+// twice. Inside DryRun nothing goes out.
+func (fs *FinanceService) notify(ctx context.Context, channels []notify.Channel, kind, key string, msg notify.Message) error {
+	return fs.send(ctx, channels, kind, key, pgtype.Numeric{}, msg)
+}
+
+// notifyOnChange is notify for a message that reports metric, which is kept
+// with it. While a notification min change is set, msg is held back, and
+// not counted as sent, until metric has moved by more than that from the
+// figure the last notification of kind reported.
+func (fs *FinanceService) notifyOnChange(ctx context.Context, channels []notify.Channel, kind, key string, metric money.Money, msg notify.Message) error {
+	if change, ok := fs.NotificationMinChange(ctx); ok {
+		last, err := fs.db.LastNotificationMetric(ctx, kind)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if err == nil {
+			prev, err := NumericToMoney(last)
+			if err != nil {
+				return err
+			}
+			if metric.Sub(prev).Abs() <= money.FromFloat(change) {
+				return nil
+			}
+		}
+	}
+	return fs.send(ctx, channels, kind, key, makePgNumeric(metric.Float64()), msg)
+}
+
+// send is notify, keeping metric with the record of what was sent.
+func (fs *FinanceService) send(ctx context.Context, channels []notify.Channel, kind, key string, metric pgtype.Numeric, msg notify.Message) error {
+	sent, err := fs.db.NotificationSent(ctx, database.NotificationSentParams{Kind: kind, Key: key})
+	if err != nil || sent || IsDryRun(ctx) {
+		return err
+	}
+	var (
+		errs      []error
+		delivered bool
+	)
+	for _, ch := range channels {
+		if err := ch.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrNotificationFailed, kind, err))
+			continue
+		}
+		delivered = true
+	}
+	if delivered {
+		if err := fs.db.RecordNotification(ctx, database.RecordNotificationParams{Kind: kind, Key: key, Metric: metric}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// truncate cuts s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/jdelles/currentz/internal/notify"
+	"github.com/jdelles/currentz/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingChannel struct{ sent []notify.Message }
+
+func (c *recordingChannel) Send(ctx context.Context, msg notify.Message) error {
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func TestNotifyOnChange(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	ch := &recordingChannel{}
+	channels := []notify.Channel{ch}
+	send := func(key string, metric float64) {
+		t.Helper()
+		require.NoError(t, fs.notifyOnChange(ctx, channels, NotifyLowBalance, key, money.FromFloat(metric), notify.Message{Title: key}))
+	}
+	sent := func(key string) bool {
+		t.Helper()
+		ok, err := fs.db.NotificationSent(ctx, database.NotificationSentParams{Kind: NotifyLowBalance, Key: key})
+		require.NoError(t, err)
+		return ok
+	}
+
+	// Without a min change every one goes out.
+	send("2025-06-01", 200)
+	send("2025-06-02", 205)
+	assert.Len(t, ch.sent, 2)
+
+	assert.ErrorIs(t, fs.SetNotificationMinChange(ctx, 0), ErrInvalidSetting)
+	require.NoError(t, fs.SetNotificationMinChange(ctx, 50))
+
+	// Compared with the last one sent, not the last one worked out, so
+	// small drifts can't add up unnoticed.
+	send("2025-06-03", 250)
+	send("2025-06-04", 160)
+	assert.Len(t, ch.sent, 2)
+	assert.False(t, sent("2025-06-03"), "held back, so it can go later in the day")
+	send("2025-06-05", 150)
+	require.Len(t, ch.sent, 3)
+	assert.Equal(t, "2025-06-05", ch.sent[2].Title)
+	send("2025-06-06", 120)
+	assert.Len(t, ch.sent, 3)
+
+	// Other kinds keep their own figures.
+	require.NoError(t, fs.notifyOnChange(ctx, channels, NotifyWeeklyDigest, "2025-W23", money.FromFloat(150), notify.Message{}))
+	assert.Len(t, ch.sent, 4)
+}
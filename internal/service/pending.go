@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// Transaction statuses. A pending transaction has been made but hasn't
+// cleared the bank yet, like a check that hasn't been cashed, so the bank
+// balance the starting balance is taken from doesn't include it.
+const (
+	StatusPending = "pending"
+	StatusCleared = "cleared"
+)
+
+// ErrInvalidStatus is returned for a status other than pending or cleared.
+var ErrInvalidStatus = errors.New("invalid status")
+
+// normalizeStatus checks status, defaulting an empty one to cleared.
+func normalizeStatus(status string) (string, error) {
+	switch s := strings.ToLower(strings.TrimSpace(status)); s {
+	case "":
+		return StatusCleared, nil
+	case StatusPending, StatusCleared:
+		return s, nil
+	}
+	return "", fmt.Errorf("%w %q (expected pending or cleared)", ErrInvalidStatus, status)
+}
+
+// GetTransactionsByStatus lists the transactions with status, by date.
+func (fs *FinanceService) GetTransactionsByStatus(ctx context.Context, status string) ([]Transaction, error) {
+	status, err := normalizeStatus(status)
+	if err != nil {
+		return nil, err
+	}
+	return fs.db.GetTransactionsByStatus(ctx, status)
+}
+
+// SetTransactionStatus marks a transaction pending or cleared, typically
+// clearing a check once it shows up on the bank statement.
+func (fs *FinanceService) SetTransactionStatus(ctx context.Context, id int32, status string) (Transaction, error) {
+	status, err := normalizeStatus(status)
+	if err != nil {
+		return Transaction{}, err
+	}
+	before, err := fs.db.GetTransactionByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Transaction{}, fmt.Errorf("%w: %d", ErrTransactionNotFound, id)
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	if err := checkVersion(ctx, EntityTransaction, id, before.Version); err != nil {
+		return Transaction{}, err
+	}
+	tx, err := fs.db.SetTransactionStatus(ctx, database.SetTransactionStatusParams{ID: id, Status: status, Version: before.Version})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Transaction{}, lostRace(EntityTransaction, id)
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	fs.audit(ctx, AuditUpdate, EntityTransaction, id, before, tx)
+	fs.events.Publish(ctx, EventTransactionUpdated, tx)
+	return tx, nil
+}
+
+// pendingBefore returns the pending transactions dated before start, moved
+// to start: they already happened, but the bank balance doesn't show them
+// yet, so they take effect on the first forecast day. Card purchases are
+// left where payCardsOnDueDates put them, since paying the card is what
+// moves cash.
+func (fs *FinanceService) pendingBefore(ctx context.Context, start time.Time, accounts []Account) ([]Transaction, error) {
+	rows, err := fs.db.GetPendingTransactionsBefore(ctx, makePgDate(start))
+	if err != nil {
+		return nil, err
+	}
+	cards := make(map[int32]bool)
+	for _, a := range accounts {
+		cards[a.ID] = a.Type == AccountCreditCard
+	}
+	var out []Transaction
+	for _, tx := range rows {
+		if tx.AccountID.Valid && cards[tx.AccountID.Int32] {
+			continue
+		}
+		tx.Date = makePgDate(start)
+		out = append(out, tx)
+	}
+	return out, nil
+}
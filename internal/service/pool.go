@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/household"
+)
+
+// Backoff between connection attempts at startup.
+const (
+	initialConnectBackoff = 250 * time.Millisecond
+	maxConnectBackoff     = 5 * time.Second
+)
+
+// PoolOptions tunes the database connection pool. Zero fields keep the
+// pool's defaults, or whatever the URL sets with pool_max_conns and the
+// like.
+type PoolOptions struct {
+	MaxConns          int32
+	MinConns          int32
+	HealthCheckPeriod time.Duration
+	// ConnectTimeout is how long to keep retrying a database that isn't up
+	// yet, as when it starts alongside the server. Zero tries once.
+	ConnectTimeout time.Duration
+}
+
+// OpenFinanceService connects to dbURL, retrying with exponential backoff
+// for up to opts.ConnectTimeout, and returns a service backed by the pool.
+func OpenFinanceService(ctx context.Context, dbURL string, opts PoolOptions) (*FinanceService, error) {
+	cfg, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+	if opts.MaxConns > 0 {
+		cfg.MaxConns = opts.MaxConns
+	}
+	if opts.MinConns > 0 {
+		cfg.MinConns = opts.MinConns
+	}
+	if cfg.MinConns > cfg.MaxConns {
+		return nil, fmt.Errorf("min conns %d is above max conns %d", cfg.MinConns, cfg.MaxConns)
+	}
+	if opts.HealthCheckPeriod > 0 {
+		cfg.HealthCheckPeriod = opts.HealthCheckPeriod
+	}
+	cfg.BeforeAcquire = setHousehold
+	cfg.ConnConfig.Tracer = queryTracer{}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pgx pool: %w", err)
+	}
+	if err := connectWithRetry(ctx, opts.ConnectTimeout, pool.Ping); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("database unavailable: %w", err)
+	}
+	warnIfBypassingRowSecurity(ctx, pool)
+	return newFinanceService(database.New(poolDB{pool}), pool), nil
+}
+
+// setHousehold points a connection at the household the work it's acquired
+// for is on, for the row security policies to keep it to. A connection that
+// can't be set is destroyed and another one tried.
+func setHousehold(ctx context.Context, conn *pgx.Conn) bool {
+	id := strconv.Itoa(int(household.FromContext(ctx)))
+	_, err := conn.Exec(ctx, "SELECT set_config('currentz.household', $1, false)", id)
+	return err == nil
+}
+
+// warnIfBypassingRowSecurity logs when the server connects as a role that
+// row security doesn't apply to, such as a superuser: every household would
+// see every other's data.
+func warnIfBypassingRowSecurity(ctx context.Context, pool *pgxpool.Pool) {
+	var bypass bool
+	err := pool.QueryRow(ctx,
+		"SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypass)
+	if err == nil && bypass {
+		log.Println("warning: the database role bypasses row security, so households are not kept apart; connect as an ordinary role")
+	}
+}
+
+// connectWithRetry calls ping until it succeeds, waiting twice as long after
+// each failure, and gives up once timeout has passed.
+func connectWithRetry(ctx context.Context, timeout time.Duration, ping func(context.Context) error) error {
+	if timeout <= 0 {
+		return ping(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := initialConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+		deadline, _ := ctx.Deadline()
+		if time.Until(deadline) < backoff {
+			return err
+		}
+		log.Printf("database not ready (attempt %d): %v; retrying in %s", attempt, err, backoff)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
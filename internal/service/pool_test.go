@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectWithRetry(t *testing.T) {
+	down := errors.New("connection refused")
+	failing := func(n int) (func(context.Context) error, *int) {
+		calls := 0
+		return func(context.Context) error {
+			calls++
+			if calls <= n {
+				return down
+			}
+			return nil
+		}, &calls
+	}
+
+	ping, calls := failing(1)
+	assert.NoError(t, connectWithRetry(context.Background(), time.Second, ping))
+	assert.Equal(t, 2, *calls, "retried once the database came up")
+
+	ping, calls = failing(1)
+	assert.ErrorIs(t, connectWithRetry(context.Background(), 0, ping), down)
+	assert.Equal(t, 1, *calls, "no timeout means a single try")
+
+	ping, calls = failing(100)
+	start := time.Now()
+	assert.ErrorIs(t, connectWithRetry(context.Background(), 300*time.Millisecond, ping), down)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 2, *calls, "gives up rather than sleep past the deadline")
+}
+
+func TestOpenFinanceServiceRejectsBadOptions(t *testing.T) {
+	_, err := OpenFinanceService(context.Background(), "postgres://localhost/x", PoolOptions{MaxConns: 2, MinConns: 5})
+	assert.Error(t, err)
+	_, err = OpenFinanceService(context.Background(), "::not a url", PoolOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// MonthOutlook totals one calendar month in the base currency. Income,
+// Expense and Net are what actually lands in the month. The Accrued fields
+// spread each prorated yearly recurring over the twelve months up to and
+// including its due month, so a 1,200 insurance bill due in March counts
+// 100 a month from the previous April instead of 1,200 in March.
+type MonthOutlook struct {
+	Month          string  `json:"month"`
+	Income         float64 `json:"income"`
+	Expense        float64 `json:"expense"`
+	Net            float64 `json:"net"`
+	AccruedIncome  float64 `json:"accrued_income"`
+	AccruedExpense float64 `json:"accrued_expense"`
+	AccruedNet     float64 `json:"accrued_net"`
+}
+
+// MonthlyOutlook returns months calendar months starting with the current
+// one. Recorded transactions count on their own date and recurrings on each
+// occurrence.
+func (fs *FinanceService) MonthlyOutlook(ctx context.Context, months int) ([]MonthOutlook, error) {
+	if months <= 0 {
+		months = 12
+	}
+	today := fs.Today(ctx)
+	start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, months, -1)
+
+	txs, err := fs.db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
+		Date:   makePgDate(start),
+		Date_2: makePgDate(end),
+	})
+	if err != nil {
+		return nil, err
+	}
+	recs, err := fs.db.ListActiveRecurring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return monthlyOutlook(txs, recs, start, months, conv)
+}
+
+type monthSums struct {
+	income, expense, accruedIncome, accruedExpense money.Money
+}
+
+func (s *monthSums) add(typ string, amt money.Money, accrued bool) {
+	switch {
+	case typ == "income" && accrued:
+		s.accruedIncome = s.accruedIncome.Add(amt)
+	case typ == "income":
+		s.income = s.income.Add(amt)
+	case accrued:
+		s.accruedExpense = s.accruedExpense.Add(amt)
+	default:
+		s.expense = s.expense.Add(amt)
+	}
+}
+
+// monthlyOutlook totals months calendar months from start, which must be the
+// first of a month.
+func monthlyOutlook(txs []Transaction, recs []Recurring, start time.Time, months int, conv converter) ([]MonthOutlook, error) {
+	sums := make([]monthSums, months)
+	monthIndex := func(d time.Time) int {
+		return (d.Year()-start.Year())*12 + int(d.Month()) - int(start.Month())
+	}
+	// book adds amt to month i's landed and/or accrued totals, dropping
+	// months outside the window.
+	book := func(i int, typ string, amt money.Money, landed, accrued bool) {
+		if i < 0 || i >= months {
+			return
+		}
+		if landed {
+			sums[i].add(typ, amt, false)
+		}
+		if accrued {
+			sums[i].add(typ, amt, true)
+		}
+	}
+	toBase := func(tx Transaction) (money.Money, error) {
+		amt, err := NumericToMoney(tx.Amount)
+		if err != nil {
+			return 0, nil
+		}
+		return conv.toBase(tx.Currency, amt)
+	}
+
+	end := start.AddDate(0, months, -1)
+	for _, tx := range txs {
+		amt, err := toBase(tx)
+		if err != nil {
+			return nil, err
+		}
+		book(monthIndex(tx.Date.Time.UTC()), tx.Type, amt, true, true)
+	}
+	for _, r := range recs {
+		if !r.Prorate {
+			for _, tx := range expandOne(r, start, end) {
+				amt, err := toBase(tx)
+				if err != nil {
+					return nil, err
+				}
+				book(monthIndex(tx.Date.Time), tx.Type, amt, true, true)
+			}
+			continue
+		}
+
+		// Bills due up to eleven months after the window still accrue in it.
+		for _, tx := range expandOne(r, start, start.AddDate(0, months+11, -1)) {
+			amt, err := toBase(tx)
+			if err != nil {
+				return nil, err
+			}
+			due := monthIndex(tx.Date.Time)
+			book(due, tx.Type, amt, true, false)
+			for k, part := range amt.Allocate(1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1) {
+				book(due-11+k, tx.Type, part, false, true)
+			}
+		}
+	}
+
+	out := make([]MonthOutlook, months)
+	for i, s := range sums {
+		out[i] = MonthOutlook{
+			Month:          start.AddDate(0, i, 0).Format("2006-01"),
+			Income:         s.income.Float64(),
+			Expense:        s.expense.Float64(),
+			Net:            s.income.Add(s.expense).Float64(),
+			AccruedIncome:  s.accruedIncome.Float64(),
+			AccruedExpense: s.accruedExpense.Float64(),
+			AccruedNet:     s.accruedIncome.Add(s.accruedExpense).Float64(),
+		}
+	}
+	return out, nil
+}
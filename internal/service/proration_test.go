@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+func TestMonthlyOutlookProratesYearlyBills(t *testing.T) {
+	insurance := Recurring{
+		ID:          1,
+		Description: "Car insurance",
+		Type:        "expense",
+		Amount:      makePgNumeric(1200.05),
+		StartDate:   makePgDate(day("2025-03-10")),
+		Interval:    database.RecurrenceIntervalYearly,
+		DayOfMonth:  pgtype.Int4{Int32: 10, Valid: true},
+		Active:      true,
+		Currency:    "USD",
+		Prorate:     true,
+	}
+	rent := Recurring{
+		ID:          2,
+		Description: "Rent",
+		Type:        "expense",
+		Amount:      makePgNumeric(1000),
+		StartDate:   makePgDate(day("2025-01-01")),
+		Interval:    database.RecurrenceIntervalMonthly,
+		DayOfMonth:  pgtype.Int4{Int32: 1, Valid: true},
+		Active:      true,
+		Currency:    "USD",
+	}
+	txs := []Transaction{
+		{Type: "income", Date: makePgDate(day("2025-02-14")), Amount: makePgNumeric(3000), Currency: "USD"},
+	}
+
+	got, err := monthlyOutlook(txs, []Recurring{insurance, rent}, day("2025-01-01"), 12, converter{base: "USD"})
+	require.NoError(t, err)
+	require.Len(t, got, 12)
+
+	jan, feb, mar, apr := got[0], got[1], got[2], got[3]
+	assert.Equal(t, "2025-01", jan.Month)
+	assert.Equal(t, -1000.0, jan.Expense)
+	assert.Equal(t, -1100.0, jan.AccruedExpense, "accrues toward the March payment")
+
+	assert.Equal(t, 3000.0, feb.Income)
+	assert.Equal(t, 3000.0, feb.AccruedIncome)
+	assert.Equal(t, 1900.0, feb.AccruedNet)
+
+	assert.Equal(t, -2200.05, mar.Expense, "the real payment lands in March")
+	assert.Equal(t, -1100.0, mar.AccruedExpense)
+
+	assert.Equal(t, -1000.0, apr.Expense)
+	// Leftover cents from splitting 1,200.05 go to the earliest months.
+	assert.Equal(t, -1100.01, apr.AccruedExpense, "already saving for next March")
+
+	var landed, accrued float64
+	for _, m := range got[3:] {
+		landed += m.Expense
+		accrued += m.AccruedExpense
+	}
+	assert.InDelta(t, -9000.0, landed, 0.001)
+	assert.InDelta(t, -9000.0-900.05, accrued, 0.001, "nine of next year's twelve parts")
+}
@@ -2,33 +2,78 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/rrule"
 )
 
 type Recurring = database.RecurringTransactions
 
 type RecurringInput struct {
-	Description string
-	Type        string
-	Amount      float64
-	StartDate   time.Time
-	Interval    string
-	DayOfWeek   *int
-	DayOfMonth  *int
-	EndDate     *time.Time
-	Active      bool
+	Description string     `json:"description"`
+	Type        string     `json:"type"`
+	Amount      float64    `json:"amount"`
+	StartDate   time.Time  `json:"start_date"`
+	Interval    string     `json:"interval"`
+	DayOfWeek   *int       `json:"day_of_week,omitempty"`
+	DayOfMonth  *int       `json:"day_of_month,omitempty"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+	Active      bool       `json:"active"`
+	// Currency defaults to the base currency when empty.
+	Currency string `json:"currency,omitempty"`
+	// Prorate spreads a yearly recurring over the months before each due
+	// date in MonthlyOutlook. Only yearly recurrings can be prorated.
+	Prorate bool `json:"prorate,omitempty"`
+	// OccurrenceCount ends the recurring after that many occurrences, as
+	// for an installment plan. Paused occurrences don't count. With an
+	// EndDate too, whichever comes first ends it.
+	OccurrenceCount *int `json:"occurrence_count,omitempty"`
+	// WeekOfMonth puts a monthly recurring on the nth DayOfWeek of the
+	// month instead of a day of the month: 1-4, or -1 for the last, as in
+	// "the 2nd Friday".
+	WeekOfMonth *int `json:"week_of_month,omitempty"`
+	// BusinessDay moves a monthly occurrence that lands on a weekend back
+	// to the Friday before. With DayOfMonth 31 it is the last business day
+	// of the month.
+	BusinessDay bool `json:"business_day,omitempty"`
+	// RRule is an iCalendar recurrence rule, such as
+	// "FREQ=MONTHLY;BYDAY=2TU", followed from StartDate for schedules the
+	// intervals can't express. Interval is then "custom" or empty, and the
+	// day fields don't apply: the rule's BYDAY and BYMONTHDAY do their job.
+	RRule string `json:"rrule,omitempty"`
+	// Category and AccountID are passed on to every occurrence, so they
+	// show up in category reports and in the forecast of that account, as
+	// a one-off's would.
+	Category  string `json:"category,omitempty"`
+	AccountID *int32 `json:"account_id,omitempty"`
 }
 
 func (fs *FinanceService) CreateRecurringSimple(ctx context.Context, in RecurringInput) (Recurring, error) {
-	ival, err := parseIntervalEnum(in.Interval)
+	params, err := recurringParams(in)
 	if err != nil {
 		return Recurring{}, err
 	}
+	return fs.CreateRecurring(ctx, params)
+}
+
+// recurringParams validates in and converts it to the row to create.
+func recurringParams(in RecurringInput) (database.CreateRecurringParams, error) {
+	if err := validateRecurringInput(in); err != nil {
+		return database.CreateRecurringParams{}, err
+	}
+	ival, rule, err := recurrenceOf(in)
+	if err != nil {
+		return database.CreateRecurringParams{}, err
+	}
 
 	var dow, dom pgtype.Int4
 	if in.DayOfWeek != nil {
@@ -41,121 +86,737 @@ func (fs *FinanceService) CreateRecurringSimple(ctx context.Context, in Recurrin
 	if in.EndDate != nil {
 		end = makePgDate(*in.EndDate)
 	}
+	var count pgtype.Int4
+	if in.OccurrenceCount != nil {
+		count = pgtype.Int4{Int32: int32(*in.OccurrenceCount), Valid: true}
+	}
+	var week pgtype.Int4
+	if in.WeekOfMonth != nil {
+		week = pgtype.Int4{Int32: int32(*in.WeekOfMonth), Valid: true}
+	}
+	category := strings.TrimSpace(in.Category)
+
+	return database.CreateRecurringParams{
+		Description:     in.Description,
+		Type:            in.Type,
+		Amount:          makePgNumeric(in.Amount),
+		StartDate:       makePgDate(in.StartDate),
+		Interval:        ival,
+		DayOfWeek:       dow,
+		DayOfMonth:      dom,
+		EndDate:         end,
+		Active:          in.Active,
+		Currency:        in.Currency,
+		Prorate:         in.Prorate,
+		OccurrenceCount: count,
+		WeekOfMonth:     week,
+		BusinessDay:     in.BusinessDay,
+		Rrule:           rule,
+		Category:        pgtype.Text{String: category, Valid: category != ""},
+		AccountID:       optInt4(in.AccountID),
+	}, nil
+}
+
+// recurrenceOf returns the interval in asks for and, for a custom one, the
+// rule to store in its canonical form.
+func recurrenceOf(in RecurringInput) (database.RecurrenceInterval, pgtype.Text, error) {
+	if in.RRule == "" {
+		ival, err := parseIntervalEnum(in.Interval)
+		return ival, pgtype.Text{}, err
+	}
+	rule, err := rrule.Parse(in.RRule)
+	if err != nil {
+		return "", pgtype.Text{}, fmt.Errorf("invalid rrule: %w", err)
+	}
+	return database.RecurrenceIntervalCustom, pgtype.Text{String: rule.String(), Valid: true}, nil
+}
+
+func validateRecurringInput(in RecurringInput) error {
+	if strings.TrimSpace(in.Description) == "" {
+		return fmt.Errorf("description is required")
+	}
+	if in.Type != "income" && in.Type != "expense" {
+		return fmt.Errorf("invalid type %q (expected income|expense)", in.Type)
+	}
+	if in.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if in.RRule != "" {
+		if err := validateRRule(in); err != nil {
+			return err
+		}
+	} else if in.Interval == "" {
+		return fmt.Errorf("interval or rrule is required")
+	} else if _, err := parseIntervalEnum(in.Interval); err != nil {
+		return err
+	}
+	if in.DayOfWeek != nil && (*in.DayOfWeek < 0 || *in.DayOfWeek > 6) {
+		return fmt.Errorf("invalid day_of_week %d (expected 0-6)", *in.DayOfWeek)
+	}
+	if in.DayOfMonth != nil && (*in.DayOfMonth < 1 || *in.DayOfMonth > 31) {
+		return fmt.Errorf("invalid day_of_month %d (expected 1-31)", *in.DayOfMonth)
+	}
+	if in.EndDate != nil && in.EndDate.Before(in.StartDate) {
+		return fmt.Errorf("end_date is before start_date")
+	}
+	if in.Prorate && in.Interval != "yearly" {
+		return fmt.Errorf("only yearly recurrings can be prorated")
+	}
+	if in.OccurrenceCount != nil && (*in.OccurrenceCount < 1 || *in.OccurrenceCount > math.MaxInt32) {
+		return fmt.Errorf("invalid occurrence_count %d (expected at least 1)", *in.OccurrenceCount)
+	}
+	if in.WeekOfMonth != nil {
+		if w := *in.WeekOfMonth; (w < 1 || w > 4) && w != -1 {
+			return fmt.Errorf("invalid week_of_month %d (expected 1-4, or -1 for the last)", w)
+		}
+		if in.Interval != "monthly" {
+			return fmt.Errorf("only monthly recurrings can have a week_of_month")
+		}
+		if in.DayOfWeek == nil {
+			return fmt.Errorf("week_of_month needs a day_of_week")
+		}
+		if in.DayOfMonth != nil {
+			return fmt.Errorf("set day_of_month or week_of_month, not both")
+		}
+	}
+	if in.BusinessDay && in.Interval != "monthly" {
+		return fmt.Errorf("only monthly recurrings can be moved to business days")
+	}
+	return nil
+}
 
-	params := database.CreateRecurringParams{
-		Description: in.Description,
-		Type:        in.Type,
-		Amount:      makePgNumeric(in.Amount),
-		StartDate:   makePgDate(in.StartDate),
-		Interval:    ival,
-		DayOfWeek:   dow,
-		DayOfMonth:  dom,
-		EndDate:     end,
-		Active:      in.Active,
+// validateRRule checks a recurring that follows a rule: the rule must parse
+// and have at least one occurrence, and nothing else may set the schedule.
+func validateRRule(in RecurringInput) error {
+	rule, err := rrule.Parse(in.RRule)
+	if err != nil {
+		return fmt.Errorf("invalid rrule: %w", err)
+	}
+	if in.Interval != "" && in.Interval != string(database.RecurrenceIntervalCustom) {
+		return fmt.Errorf("an rrule sets the schedule, so interval must be custom or left out (not %q)", in.Interval)
 	}
-	return fs.db.CreateRecurring(ctx, params)
+	if in.DayOfWeek != nil || in.DayOfMonth != nil {
+		return fmt.Errorf("day_of_week and day_of_month don't apply with an rrule; use its BYDAY or BYMONTHDAY")
+	}
+	found := false
+	rule.Each(in.StartDate, func(d time.Time) bool {
+		found = in.EndDate == nil || !d.After(*in.EndDate)
+		return false
+	})
+	if !found {
+		return fmt.Errorf("rrule %s has no occurrences after start_date", rule)
+	}
+	return nil
 }
 
 func (fs *FinanceService) CreateRecurring(ctx context.Context, r database.CreateRecurringParams) (Recurring, error) {
-	return fs.db.CreateRecurring(ctx, r)
+	if err := fs.resolveRecurringRefs(ctx, &r); err != nil {
+		return Recurring{}, err
+	}
+	rec, err := fs.db.CreateRecurring(ctx, r)
+	if err != nil {
+		return Recurring{}, err
+	}
+	fs.audit(ctx, AuditCreate, EntityRecurring, rec.ID, nil, rec)
+	fs.events.Publish(ctx, EventRecurringCreated, rec)
+	return rec, nil
 }
+
+// resolveRecurringRefs fills in r's currency and checks that its account
+// exists.
+func (fs *FinanceService) resolveRecurringRefs(ctx context.Context, r *database.CreateRecurringParams) error {
+	currency, err := fs.resolveCurrency(ctx, r.Currency)
+	if err != nil {
+		return err
+	}
+	r.Currency = currency
+	if r.AccountID.Valid {
+		if _, err := fs.db.GetAccountByID(ctx, r.AccountID.Int32); err != nil {
+			return fmt.Errorf("%w: no account %d", ErrInvalidAccount, r.AccountID.Int32)
+		}
+	}
+	return nil
+}
+
 func (fs *FinanceService) ListRecurring(ctx context.Context) ([]Recurring, error) {
 	return fs.db.ListRecurring(ctx)
 }
+
+// DeleteRecurring moves a recurring transaction to the trash. It returns
+// ErrRecurringNotFound if there was nothing to delete.
 func (fs *FinanceService) DeleteRecurring(ctx context.Context, id int32) error {
-	return fs.db.DeleteRecurring(ctx, id)
+	before, err := fs.db.GetRecurringByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrRecurringNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+	n, err := fs.db.DeleteRecurring(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrRecurringNotFound, id)
+	}
+	fs.audit(ctx, AuditDelete, EntityRecurring, id, before, nil)
+	fs.events.Publish(ctx, EventRecurringDeleted, map[string]int32{"id": id})
+	return nil
 }
 func (fs *FinanceService) SetRecurringActive(ctx context.Context, id int32, active bool) error {
-	return fs.db.SetRecurringActive(ctx, database.SetRecurringActiveParams{ID: id, Active: active})
+	before, err := fs.db.GetRecurringByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrRecurringNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(ctx, EntityRecurring, id, before.Version); err != nil {
+		return err
+	}
+	after, err := fs.db.SetRecurringActive(ctx, database.SetRecurringActiveParams{ID: id, Active: active, Version: before.Version})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return lostRace(EntityRecurring, id)
+	}
+	if err != nil {
+		return err
+	}
+	fs.audit(ctx, AuditUpdate, EntityRecurring, id, before, after)
+	fs.events.Publish(ctx, EventRecurringUpdated, map[string]interface{}{"id": id, "active": active})
+	return nil
+}
+
+// ReanchorInput moves a weekly or biweekly schedule. Set exactly one:
+// AnchorDate to choose the new first occurrence directly, or ShiftWeeks to
+// move the existing anchor by that many weeks (negative moves earlier).
+type ReanchorInput struct {
+	AnchorDate *time.Time
+	ShiftWeeks int
 }
 
+// ReanchorRecurring changes which week a weekly or biweekly recurring falls
+// on by moving its start_date, keeping its ID and every other field so audit
+// history and anything referring to it still line up. A pinned day_of_week
+// is kept and still applies to the new anchor.
+func (fs *FinanceService) ReanchorRecurring(ctx context.Context, id int32, in ReanchorInput) (Recurring, error) {
+	if (in.AnchorDate == nil) == (in.ShiftWeeks == 0) {
+		return Recurring{}, fmt.Errorf("set exactly one of anchor_date or shift_weeks")
+	}
+	before, err := fs.db.GetRecurringByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Recurring{}, fmt.Errorf("%w: %d", ErrRecurringNotFound, id)
+	}
+	if err != nil {
+		return Recurring{}, err
+	}
+	if err := checkVersion(ctx, EntityRecurring, id, before.Version); err != nil {
+		return Recurring{}, err
+	}
+	if before.Interval != database.RecurrenceIntervalWeekly && before.Interval != database.RecurrenceIntervalBiweekly {
+		return Recurring{}, fmt.Errorf("only weekly and biweekly recurrings can be re-anchored (this one is %s)", before.Interval)
+	}
+
+	anchor := before.StartDate.Time.AddDate(0, 0, 7*in.ShiftWeeks)
+	if in.AnchorDate != nil {
+		anchor = truncateDay(*in.AnchorDate)
+	}
+	if before.EndDate.Valid && anchor.After(before.EndDate.Time) {
+		return Recurring{}, fmt.Errorf("new anchor %s is after end_date %s",
+			anchor.Format("2006-01-02"), before.EndDate.Time.Format("2006-01-02"))
+	}
+
+	after, err := fs.db.UpdateRecurring(ctx, database.UpdateRecurringParams{
+		ID:              id,
+		Description:     before.Description,
+		Type:            before.Type,
+		Amount:          before.Amount,
+		StartDate:       makePgDate(anchor),
+		Interval:        before.Interval,
+		DayOfWeek:       before.DayOfWeek,
+		DayOfMonth:      before.DayOfMonth,
+		EndDate:         before.EndDate,
+		Active:          before.Active,
+		Currency:        before.Currency,
+		Prorate:         before.Prorate,
+		OccurrenceCount: before.OccurrenceCount,
+		WeekOfMonth:     before.WeekOfMonth,
+		BusinessDay:     before.BusinessDay,
+		Rrule:           before.Rrule,
+		Category:        before.Category,
+		AccountID:       before.AccountID,
+		Version:         before.Version,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Recurring{}, lostRace(EntityRecurring, id)
+	}
+	if err != nil {
+		return Recurring{}, err
+	}
+	fs.audit(ctx, AuditUpdate, EntityRecurring, id, before, after)
+	fs.events.Publish(ctx, EventRecurringUpdated, after)
+	return after, nil
+}
+
+// PauseRecurring suspends a recurring from from through until, inclusive.
+// Occurrences in that range are skipped and the schedule carries on as
+// before afterwards. Passing nil for both clears the pause.
+func (fs *FinanceService) PauseRecurring(ctx context.Context, id int32, from, until *time.Time) (Recurring, error) {
+	if (from == nil) != (until == nil) {
+		return Recurring{}, fmt.Errorf("set both paused_from and paused_until, or neither")
+	}
+	var params database.SetRecurringPauseParams
+	params.ID = id
+	if from != nil {
+		if until.Before(*from) {
+			return Recurring{}, fmt.Errorf("paused_until is before paused_from")
+		}
+		params.PausedFrom = makePgDate(truncateDay(*from))
+		params.PausedUntil = makePgDate(truncateDay(*until))
+	}
+
+	before, err := fs.db.GetRecurringByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Recurring{}, fmt.Errorf("%w: %d", ErrRecurringNotFound, id)
+	}
+	if err != nil {
+		return Recurring{}, err
+	}
+	if err := checkVersion(ctx, EntityRecurring, id, before.Version); err != nil {
+		return Recurring{}, err
+	}
+	params.Version = before.Version
+	after, err := fs.db.SetRecurringPause(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Recurring{}, lostRace(EntityRecurring, id)
+	}
+	if err != nil {
+		return Recurring{}, err
+	}
+	fs.audit(ctx, AuditUpdate, EntityRecurring, id, before, after)
+	fs.events.Publish(ctx, EventRecurringUpdated, after)
+	return after, nil
+}
+
+// RecurringOccurrences returns a single recurring and its occurrences
+// between start and end. Inactive recurrings have no occurrences.
+func (fs *FinanceService) RecurringOccurrences(ctx context.Context, id int32, start, end time.Time) (Recurring, []Transaction, error) {
+	r, err := fs.db.GetRecurringByID(ctx, id)
+	if err != nil {
+		return Recurring{}, nil, err
+	}
+	if !r.Active {
+		return r, nil, nil
+	}
+	return r, expandOne(r, start, end), nil
+}
+
+// ExpandRecurringBetween returns the occurrences of every active recurring
+// from start to end inclusive, grouped by recurring in the order they are
+// listed.
 func (fs *FinanceService) ExpandRecurringBetween(ctx context.Context, start, end time.Time) ([]Transaction, error) {
 	rs, err := fs.db.ListActiveRecurring(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return expandAll(rs, start, end), nil
+}
+
+// expandForForecast expands rs for a forecast from start to end. A charge
+// to a credit card takes cash on its statement's due date, up to
+// maxCardLagDays later, so expansion starts that much earlier; the
+// forecast drops whatever still falls outside the window.
+func expandForForecast(rs []Recurring, start, end time.Time) []Transaction {
+	return expandAll(rs, start.AddDate(0, 0, -maxCardLagDays), end)
+}
+
+// minRecurringsPerWorker is the fewest recurrings worth handing a worker of
+// their own: below it, starting goroutines costs more than it saves.
+const minRecurringsPerWorker = 64
+
+// expandAll expands each of rs between start and end and returns the
+// occurrences in the order of rs, the same as expanding them one after
+// another. Large sets are split into contiguous runs expanded in parallel,
+// at most one per CPU.
+func expandAll(rs []Recurring, start, end time.Time) []Transaction {
+	return expandWithWorkers(rs, start, end, expandWorkers(len(rs)))
+}
+
+// expandWorkers is how many workers expandAll uses for n recurrings.
+func expandWorkers(n int) int {
+	return min(runtime.GOMAXPROCS(0), n/minRecurringsPerWorker)
+}
+
+// expandWithWorkers is expandAll on a set number of workers.
+func expandWithWorkers(rs []Recurring, start, end time.Time, workers int) []Transaction {
+	parts := expandParts(rs, start, end, workers)
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	if n == 0 {
+		return nil
+	}
+	out := make([]Transaction, 0, n)
+	for _, p := range parts {
+		for _, occ := range p {
+			out = append(out, occ.tx)
+		}
+	}
+	return out
+}
 
-	var out []Transaction
-	for _, r := range rs {
-		occ := expandOne(r, start, end)
-		out = append(out, occ...)
+// expandListed is expandAll with each occurrence marked as coming from
+// its recurring and dated the day it was scheduled for.
+func expandListed(rs []Recurring, start, end time.Time) []ListedTransaction {
+	var out []ListedTransaction
+	for i, p := range expandParts(rs, start, end, expandWorkers(len(rs))) {
+		for _, occ := range p {
+			out = append(out, ListedTransaction{
+				Transaction:    occ.tx,
+				Source:         SourceRecurring,
+				RecurringID:    pgtype.Int4{Int32: rs[i].ID, Valid: true},
+				OccurrenceDate: makePgDate(occ.scheduled),
+			})
+		}
 	}
-	return out, nil
+	return out
 }
 
+// expandParts expands each of rs on up to workers goroutines, returning
+// the occurrences of rs[i] at index i.
+func expandParts(rs []Recurring, start, end time.Time, workers int) [][]occurrence {
+	parts := make([][]occurrence, len(rs))
+	if workers <= 1 {
+		for i, r := range rs {
+			parts[i] = expandOccurrences(r, start, end)
+		}
+		return parts
+	}
+
+	chunk := (len(rs) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < len(rs); lo += chunk {
+		hi := min(lo+chunk, len(rs))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := lo; i < hi; i++ {
+				parts[i] = expandOccurrences(rs[i], start, end)
+			}
+		}()
+	}
+	wg.Wait()
+	return parts
+}
+
+// occurrence is one occurrence of a recurring: the transaction, dated the
+// day it moves money, and the day its schedule put it on. They differ when
+// a business day moves it off a weekend.
+type occurrence struct {
+	tx        Transaction
+	scheduled time.Time
+}
+
+func newOccurrence(r Recurring, scheduled, d time.Time) occurrence {
+	return occurrence{tx: toTxFromRecurring(r, d), scheduled: scheduled}
+}
+
+// expandOne returns r's occurrences from start to end inclusive.
 func expandOne(r Recurring, start, end time.Time) []Transaction {
-	if r.StartDate.Time.After(end) {
+	occs := expandOccurrences(r, start, end)
+	if occs == nil {
+		return nil
+	}
+	out := make([]Transaction, len(occs))
+	for i, occ := range occs {
+		out[i] = occ.tx
+	}
+	return out
+}
+
+// expandOccurrences is expandOne keeping the scheduled dates.
+func expandOccurrences(r Recurring, start, end time.Time) []occurrence {
+	earliest := earliestOccurrence(r)
+	if earliest.After(end) {
 		return nil
 	}
 	if r.EndDate.Valid && r.EndDate.Time.Before(start) {
 		return nil
 	}
 
-	winStart := maxDate(start, r.StartDate.Time)
+	winStart := maxDate(start, earliest)
 	winEnd := end
 	if r.EndDate.Valid && r.EndDate.Time.Before(end) {
 		winEnd = r.EndDate.Time
 	}
+	if r.OccurrenceCount.Valid {
+		if last, ok := countEnd(r); ok && last.Before(winEnd) {
+			winEnd = last
+		}
+	}
+	if winStart.After(winEnd) {
+		return nil
+	}
+	return skipPaused(r, expandSchedule(r, winStart, winEnd))
+}
 
-	var instances []Transaction
+// expandSchedule returns r's occurrences in [start, end] by its interval
+// alone, ignoring its end date, count and pause.
+func expandSchedule(r Recurring, start, end time.Time) []occurrence {
 	switch r.Interval {
 	case "weekly", "biweekly":
-		instances = expandWeeklyLike(r, winStart, winEnd)
+		return expandWeeklyLike(r, start, end)
 	case "monthly":
-		instances = expandMonthly(r, winStart, winEnd)
+		return expandMonthly(r, start, end)
 	case "yearly":
-		instances = expandYearly(r, winStart, winEnd)
+		return expandYearly(r, start, end)
+	case "custom":
+		return expandRule(r, start, end)
 	}
-	return instances
+	return nil
 }
 
-func expandWeeklyLike(r Recurring, start, end time.Time) []Transaction {
-	var out []Transaction
-	step := 7
-	if r.Interval == "biweekly" {
-		step = 14
+// earliestOccurrence is the first day an occurrence of r can fall on: its
+// start date, or up to two days before for a business-day occurrence
+// scheduled on a weekend start date. The expansions check the schedule
+// against the start date themselves.
+func earliestOccurrence(r Recurring) time.Time {
+	d := truncateDay(r.StartDate.Time)
+	if r.BusinessDay {
+		d = d.AddDate(0, 0, -2)
 	}
-	anchor := truncateDay(r.StartDate.Time)
+	return d
+}
 
-	wantDOW := int(anchor.Weekday())
-	if r.DayOfWeek.Valid {
-		wantDOW = int(r.DayOfWeek.Int32)
+// countEnd returns the day of the last occurrence r's occurrence count
+// allows: the count-th one that isn't paused. ok is false when the end date
+// comes first, or there is no count.
+func countEnd(r Recurring) (last time.Time, ok bool) {
+	if !r.OccurrenceCount.Valid {
+		return time.Time{}, false
+	}
+	n := int(r.OccurrenceCount.Int32)
+	if r.Interval == database.RecurrenceIntervalCustom {
+		return ruleCountEnd(r, n)
 	}
-	first := alignToNextOnPhase(anchor, start, step, wantDOW)
+	// Occurrences are at most one interval apart, the first within one of
+	// the start, so n+1 intervals past it and the pause cover the nth.
+	from := earliestOccurrence(r)
+	to := truncateDay(r.StartDate.Time).AddDate(0, 0, (n+1)*maxIntervalDays(r.Interval))
+	if r.PausedFrom.Valid && r.PausedUntil.Valid {
+		to = to.Add(r.PausedUntil.Time.Sub(r.PausedFrom.Time) + 24*time.Hour)
+	}
+	if r.EndDate.Valid && r.EndDate.Time.Before(to) {
+		to = r.EndDate.Time
+	}
+	instances := skipPaused(r, expandSchedule(r, from, to))
+	if len(instances) < n {
+		return time.Time{}, false
+	}
+	return instances[n-1].tx.Date.Time, true
+}
 
-	for d := first; !d.After(end); d = d.AddDate(0, 0, step) {
-		if int(d.Weekday()) != wantDOW {
-			d = snapToWeekday(d, time.Weekday(wantDOW))
+// ruleCountEnd is countEnd for a recurring that follows a rule, whose
+// occurrences can be any distance apart.
+func ruleCountEnd(r Recurring, n int) (last time.Time, ok bool) {
+	rule, err := rrule.Parse(r.Rrule.String)
+	if err != nil {
+		return time.Time{}, false
+	}
+	rule.Each(r.StartDate.Time, func(d time.Time) bool {
+		if r.EndDate.Valid && d.After(r.EndDate.Time) {
+			return false
+		}
+		if isPaused(r, d) {
+			return true
 		}
-		out = append(out, toTxFromRecurring(r, d))
+		if n--; n == 0 {
+			last, ok = d, true
+			return false
+		}
+		return true
+	})
+	return last, ok
+}
+
+// maxIntervalDays is the most days apart two occurrences on interval can
+// be.
+func maxIntervalDays(interval database.RecurrenceInterval) int {
+	switch interval {
+	case database.RecurrenceIntervalWeekly:
+		return 7
+	case database.RecurrenceIntervalBiweekly:
+		return 14
+	case database.RecurrenceIntervalMonthly:
+		return 31
+	}
+	return 366
+}
+
+// skipPaused drops the occurrences that fall inside r's pause window.
+func skipPaused(r Recurring, instances []occurrence) []occurrence {
+	if !r.PausedFrom.Valid || !r.PausedUntil.Valid {
+		return instances
+	}
+	kept := instances[:0]
+	for _, occ := range instances {
+		if !isPaused(r, occ.tx.Date.Time) {
+			kept = append(kept, occ)
+		}
+	}
+	return kept
+}
+
+// isPaused reports whether d falls inside r's pause window.
+func isPaused(r Recurring, d time.Time) bool {
+	return r.PausedFrom.Valid && r.PausedUntil.Valid &&
+		!d.Before(r.PausedFrom.Time) && !d.After(r.PausedUntil.Time)
+}
+
+// expandRule expands a recurring that follows an RRULE. A stored rule that
+// no longer parses has no occurrences.
+func expandRule(r Recurring, start, end time.Time) []occurrence {
+	rule, err := rrule.Parse(r.Rrule.String)
+	if err != nil {
+		return nil
+	}
+	var out []occurrence
+	for _, d := range rule.Between(r.StartDate.Time, start, end) {
+		out = append(out, newOccurrence(r, d, d))
 	}
 	return out
 }
 
-func expandMonthly(r Recurring, start, end time.Time) []Transaction {
-	var out []Transaction
-	anchor := truncateDay(r.StartDate.Time)
-	day := anchor.Day()
-	if r.DayOfMonth.Valid {
-		day = int(r.DayOfMonth.Int32)
+func expandWeeklyLike(r Recurring, start, end time.Time) []occurrence {
+	opts := WeeklyPhaseOptions{Anchor: r.StartDate.Time, Step: 7}
+	if r.Interval == "biweekly" {
+		opts.Step = 14
+	}
+	if r.DayOfWeek.Valid {
+		wd := time.Weekday(r.DayOfWeek.Int32)
+		opts.Weekday = &wd
 	}
-	y, m := start.Year(), start.Month()
-	for d := dateAtDayOrMonthEnd(y, m, day); !d.After(end); {
-		if !d.Before(start) && !d.Before(anchor) {
-			out = append(out, toTxFromRecurring(r, d))
+
+	var out []occurrence
+	for _, d := range WeeklyOccurrences(opts, start, end) {
+		out = append(out, newOccurrence(r, d, d))
+	}
+	return out
+}
+
+// WeeklyPhaseOptions describes a weekly or biweekly schedule.
+type WeeklyPhaseOptions struct {
+	// Anchor fixes the phase of the schedule; for recurrings it is the
+	// start_date. Only the calendar day is used.
+	Anchor time.Time
+	// Weekday pins occurrences to a day of the week. When nil the anchor's
+	// own weekday is used.
+	Weekday *time.Weekday
+	// Step is the number of days between occurrences: 7 for weekly, 14 for
+	// biweekly. Zero means 7.
+	Step int
+}
+
+// FirstWeeklyOccurrence returns the first date the schedule lands on: the
+// wanted weekday on or after the anchor.
+func FirstWeeklyOccurrence(opts WeeklyPhaseOptions) time.Time {
+	first := truncateDay(opts.Anchor)
+	if opts.Weekday != nil {
+		first = snapToWeekday(first, *opts.Weekday)
+	}
+	return first
+}
+
+// WeeklyOccurrences returns, in order, every date within [start, end] on
+// which the schedule lands. Occurrences are FirstWeeklyOccurrence(opts)
+// plus whole multiples of Step days, so a biweekly paycheck anchored on a
+// given Friday always lands on the Fridays an even number of weeks away,
+// regardless of which window is asked for. Nothing is returned before the
+// anchor.
+func WeeklyOccurrences(opts WeeklyPhaseOptions, start, end time.Time) []time.Time {
+	step := opts.Step
+	if step <= 0 {
+		step = 7
+	}
+	start, end = truncateDay(start), truncateDay(end)
+
+	d := FirstWeeklyOccurrence(opts)
+	if d.Before(start) {
+		skip := int(start.Sub(d).Hours()/24) / step
+		d = d.AddDate(0, 0, skip*step)
+		if d.Before(start) {
+			d = d.AddDate(0, 0, step)
 		}
-		if m == 12 {
-			y, m = y+1, 1
-		} else {
-			m++
+	}
+
+	var out []time.Time
+	for ; !d.After(end); d = d.AddDate(0, 0, step) {
+		out = append(out, d)
+	}
+	return out
+}
+
+func expandMonthly(r Recurring, start, end time.Time) []occurrence {
+	var out []occurrence
+	anchor := truncateDay(r.StartDate.Time)
+	// A business day is at most two days before the date it replaces, so
+	// a month starting just after end can still have an occurrence in it.
+	first := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for ; !first.After(end.AddDate(0, 0, 2)); first = first.AddDate(0, 1, 0) {
+		// The schedule starts at the anchor, even where a business day
+		// moves its first occurrence before it.
+		scheduled, d := monthlyOccurrence(r, first.Year(), first.Month())
+		if !d.Before(start) && !d.After(end) && !scheduled.Before(anchor) {
+			out = append(out, newOccurrence(r, scheduled, d))
 		}
-		d = dateAtDayOrMonthEnd(y, m, day)
 	}
 	return out
 }
 
-func expandYearly(r Recurring, start, end time.Time) []Transaction {
-	var out []Transaction
+// monthlyOccurrence returns the day a monthly r is scheduled for in month
+// m of year y, and the day it falls on once moved off a weekend.
+func monthlyOccurrence(r Recurring, y int, m time.Month) (scheduled, d time.Time) {
+	if r.WeekOfMonth.Valid && r.DayOfWeek.Valid {
+		scheduled = nthWeekday(y, m, time.Weekday(r.DayOfWeek.Int32), int(r.WeekOfMonth.Int32))
+	} else {
+		day := truncateDay(r.StartDate.Time).Day()
+		if r.DayOfMonth.Valid {
+			day = int(r.DayOfMonth.Int32)
+		}
+		scheduled = dateAtDayOrMonthEnd(y, m, day)
+	}
+	d = scheduled
+	if r.BusinessDay {
+		d = businessDayOnOrBefore(d)
+	}
+	return scheduled, d
+}
+
+// nthWeekday returns the nth wd of month m, or the last one when n is
+// negative.
+func nthWeekday(y int, m time.Month, wd time.Weekday, n int) time.Time {
+	if n < 0 {
+		last := dateAtDayOrMonthEnd(y, m, 31)
+		return last.AddDate(0, 0, -((int(last.Weekday()) - int(wd) + 7) % 7))
+	}
+	first := snapToWeekday(time.Date(y, m, 1, 0, 0, 0, 0, time.UTC), wd)
+	return first.AddDate(0, 0, 7*(n-1))
+}
+
+// businessDayOnOrBefore moves a Saturday or Sunday back to the Friday
+// before. Holidays aren't known, so every weekday counts.
+func businessDayOnOrBefore(d time.Time) time.Time {
+	switch d.Weekday() {
+	case time.Saturday:
+		return d.AddDate(0, 0, -1)
+	case time.Sunday:
+		return d.AddDate(0, 0, -2)
+	}
+	return d
+}
+
+func expandYearly(r Recurring, start, end time.Time) []occurrence {
+	var out []occurrence
 	anchor := truncateDay(r.StartDate.Time)
 	day := anchor.Day()
 	if r.DayOfMonth.Valid {
@@ -170,7 +831,7 @@ func expandYearly(r Recurring, start, end time.Time) []Transaction {
 	}
 	for !cand.After(end) {
 		if !cand.Before(anchor) {
-			out = append(out, toTxFromRecurring(r, cand))
+			out = append(out, newOccurrence(r, cand, cand))
 		}
 		y++
 		cand = dateAtDayOrMonthEnd(y, month, day)
@@ -189,6 +850,9 @@ func toTxFromRecurring(r Recurring, d time.Time) Transaction {
 		Amount:      amt,
 		Description: r.Description,
 		Type:        r.Type,
+		Currency:    r.Currency,
+		Category:    r.Category,
+		AccountID:   r.AccountID,
 	}
 }
 
@@ -209,17 +873,6 @@ func snapToWeekday(d time.Time, w time.Weekday) time.Time {
 	return d.AddDate(0, 0, diff)
 }
 
-func alignToNextOnPhase(anchor, start time.Time, stepDays int, wantDOW int) time.Time {
-	d := anchor
-	for d.Before(start) {
-		d = d.AddDate(0, 0, stepDays)
-	}
-	if wantDOW >= 0 {
-		d = snapToWeekday(d, time.Weekday(wantDOW))
-	}
-	return d
-}
-
 func dateAtDayOrMonthEnd(y int, m time.Month, day int) time.Time {
 	firstNext := time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
 	last := firstNext.AddDate(0, 0, -1).Day()
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/amount"
+	"github.com/jdelles/currentz/internal/dates"
+)
+
+// ImportRowError reports a problem with one row of an import file. Rows are
+// numbered from 1, not counting the CSV header.
+type ImportRowError struct {
+	Row int
+	Err error
+}
+
+func (e ImportRowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+// ParseRecurringCSV reads recurring definitions from CSV with a header row.
+// Recognized columns (case-insensitive, any order): description, type,
+// amount, interval, rrule, start_date, day, day_of_week, day_of_month,
+// week_of_month, business_day, end_date, occurrence_count, active,
+// currency, category, account_id. "day" is a weekday (0-6 or sun..sat)
+// for weekly/biweekly and with week_of_month (1-4 or "last"), and a day
+// of month otherwise.
+// start_date defaults to today, active to true and currency to the base
+// currency.
+func ParseRecurringCSV(r io.Reader) ([]RecurringInput, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	for i, h := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(h))
+	}
+
+	var rows []map[string]string
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, h := range header {
+			if i < len(rec) {
+				row[h] = strings.TrimSpace(rec[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return parseRecurringRows(rows)
+}
+
+// ParseRecurringJSON reads a JSON array of objects using the same field
+// names as ParseRecurringCSV.
+func ParseRecurringJSON(r io.Reader) ([]RecurringInput, error) {
+	var raw []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode JSON: %w", err)
+	}
+	rows := make([]map[string]string, 0, len(raw))
+	for _, obj := range raw {
+		row := make(map[string]string, len(obj))
+		for k, v := range obj {
+			if v == nil {
+				continue
+			}
+			row[strings.ToLower(k)] = strings.TrimSpace(fmt.Sprint(v))
+		}
+		rows = append(rows, row)
+	}
+	return parseRecurringRows(rows)
+}
+
+func parseRecurringRows(rows []map[string]string) ([]RecurringInput, error) {
+	var errs []error
+	out := make([]RecurringInput, 0, len(rows))
+	for i, row := range rows {
+		in, err := ParseRecurringRow(row)
+		if err != nil {
+			errs = append(errs, ImportRowError{Row: i + 1, Err: err})
+			continue
+		}
+		out = append(out, in)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+func utc() *time.Location { return time.UTC }
+
+// ParseRecurringRow reads one recurring from named fields, using the same
+// columns and rules as a CSV import row.
+func ParseRecurringRow(row map[string]string) (RecurringInput, error) {
+	today := func() time.Time { return time.Now().UTC().Truncate(24 * time.Hour) }
+	in := RecurringInput{
+		Description: row["description"],
+		Type:        strings.ToLower(row["type"]),
+		Interval:    strings.ToLower(row["interval"]),
+		RRule:       row["rrule"],
+		Category:    row["category"],
+		StartDate:   today(),
+		Active:      true,
+	}
+
+	amt, err := amount.Parse(row["amount"])
+	if err != nil {
+		return in, err
+	}
+	in.Amount = amt
+
+	if s := row["start_date"]; s != "" {
+		if in.StartDate, err = dates.ParseDay(s, today, utc); err != nil {
+			return in, fmt.Errorf("invalid start_date %q", s)
+		}
+	}
+	if s := row["end_date"]; s != "" {
+		end, err := dates.ParseDay(s, today, utc)
+		if err != nil {
+			return in, fmt.Errorf("invalid end_date %q", s)
+		}
+		in.EndDate = &end
+	}
+	if s := row["occurrence_count"]; s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return in, fmt.Errorf("invalid occurrence_count %q", s)
+		}
+		in.OccurrenceCount = &n
+	}
+	if s := row["account_id"]; s != "" {
+		id, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return in, fmt.Errorf("invalid account_id %q", s)
+		}
+		account := int32(id)
+		in.AccountID = &account
+	}
+	if s := row["active"]; s != "" {
+		if in.Active, err = strconv.ParseBool(s); err != nil {
+			return in, fmt.Errorf("invalid active %q", s)
+		}
+	}
+	if s := row["business_day"]; s != "" {
+		if in.BusinessDay, err = strconv.ParseBool(s); err != nil {
+			return in, fmt.Errorf("invalid business_day %q", s)
+		}
+	}
+	if s := row["week_of_month"]; s != "" {
+		n := -1
+		if !strings.EqualFold(s, "last") {
+			if n, err = strconv.Atoi(s); err != nil {
+				return in, fmt.Errorf("invalid week_of_month %q", s)
+			}
+		}
+		in.WeekOfMonth = &n
+	}
+	if s := row["prorate"]; s != "" {
+		if in.Prorate, err = strconv.ParseBool(s); err != nil {
+			return in, fmt.Errorf("invalid prorate %q", s)
+		}
+	}
+	if s := row["currency"]; s != "" {
+		if in.Currency, err = NormalizeCurrency(s); err != nil {
+			return in, err
+		}
+	}
+
+	weekly := in.Interval == "weekly" || in.Interval == "biweekly" || in.WeekOfMonth != nil
+	dow, dom := row["day_of_week"], row["day_of_month"]
+	if day := row["day"]; day != "" {
+		if weekly {
+			dow = day
+		} else {
+			dom = day
+		}
+	}
+	if dow != "" {
+		v, err := parseWeekday(dow)
+		if err != nil {
+			return in, err
+		}
+		in.DayOfWeek = &v
+	}
+	if dom != "" {
+		v, err := strconv.Atoi(dom)
+		if err != nil {
+			return in, fmt.Errorf("invalid day_of_month %q", dom)
+		}
+		in.DayOfMonth = &v
+	}
+
+	return in, validateRecurringInput(in)
+}
+
+func parseWeekday(s string) (int, error) {
+	if v, err := strconv.Atoi(s); err == nil {
+		return v, nil
+	}
+	names := []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	lower := strings.ToLower(s)
+	for i, n := range names {
+		if strings.HasPrefix(lower, n) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid day_of_week %q", s)
+}
+
+// ImportRecurring creates every definition in order and returns the created
+// rows. Inputs are validated up front so a bad row creates nothing.
+func (fs *FinanceService) ImportRecurring(ctx context.Context, inputs []RecurringInput) ([]Recurring, error) {
+	for i, in := range inputs {
+		if err := validateRecurringInput(in); err != nil {
+			return nil, ImportRowError{Row: i + 1, Err: err}
+		}
+	}
+	created := make([]Recurring, 0, len(inputs))
+	for i, in := range inputs {
+		rec, err := fs.CreateRecurringSimple(ctx, in)
+		if err != nil {
+			return created, ImportRowError{Row: i + 1, Err: err}
+		}
+		created = append(created, rec)
+	}
+	return created, nil
+}
+
+// RecurringImportResult describes the recurrings an import created, or with
+// DryRun set, the ones it would create.
+type RecurringImportResult struct {
+	DryRun    bool        `json:"dry_run"`
+	Rows      int         `json:"rows"`
+	Recurring []Recurring `json:"recurring"`
+}
+
+// ImportRecurringCSV reads recurring definitions from CSV, as
+// ParseRecurringCSV does, and unless dryRun is set creates them. Every row
+// is checked before anything is saved, including that its currency and
+// account exist, and the problems with all rows are reported together,
+// wrapped in ErrInvalidImport.
+func (fs *FinanceService) ImportRecurringCSV(ctx context.Context, r io.Reader, dryRun bool) (RecurringImportResult, error) {
+	inputs, err := ParseRecurringCSV(r)
+	if err != nil {
+		return RecurringImportResult{}, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+
+	result := RecurringImportResult{DryRun: dryRun, Rows: len(inputs)}
+	preview := make([]Recurring, 0, len(inputs))
+	var errs []error
+	for i, in := range inputs {
+		params, err := recurringParams(in)
+		if err == nil {
+			err = fs.resolveRecurringRefs(ctx, &params)
+		}
+		if err != nil {
+			errs = append(errs, ImportRowError{Row: i + 1, Err: err})
+			continue
+		}
+		preview = append(preview, Recurring{
+			Description:     params.Description,
+			Type:            params.Type,
+			Amount:          params.Amount,
+			StartDate:       params.StartDate,
+			Interval:        params.Interval,
+			DayOfWeek:       params.DayOfWeek,
+			DayOfMonth:      params.DayOfMonth,
+			EndDate:         params.EndDate,
+			Active:          params.Active,
+			Currency:        params.Currency,
+			Prorate:         params.Prorate,
+			OccurrenceCount: params.OccurrenceCount,
+			WeekOfMonth:     params.WeekOfMonth,
+			BusinessDay:     params.BusinessDay,
+			Rrule:           params.Rrule,
+			Category:        params.Category,
+			AccountID:       params.AccountID,
+		})
+	}
+	if len(errs) > 0 {
+		return result, fmt.Errorf("%w: %w", ErrInvalidImport, errors.Join(errs...))
+	}
+	if dryRun {
+		result.Recurring = preview
+		return result, nil
+	}
+
+	result.Recurring, err = fs.ImportRecurring(ctx, inputs)
+	if err != nil {
+		return result, fmt.Errorf("import stopped after %d recurrings: %w", len(result.Recurring), err)
+	}
+	return result, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/memdb"
+)
+
+func TestParseRecurringCSV(t *testing.T) {
+	csv := `Description,Type,Amount,Interval,Start_Date,Day,End_Date
+Rent,expense,1200.00,monthly,2025-01-01,1,
+Paycheck,income,$2500,biweekly,2025-01-03,fri,12/31/2025
+Gym,expense,40,monthly,2025-01-15,,
+`
+	inputs, err := ParseRecurringCSV(strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, inputs, 3)
+
+	assert.Equal(t, "Rent", inputs[0].Description)
+	assert.Equal(t, 1200.00, inputs[0].Amount)
+	require.NotNil(t, inputs[0].DayOfMonth)
+	assert.Equal(t, 1, *inputs[0].DayOfMonth)
+	assert.Nil(t, inputs[0].DayOfWeek)
+	assert.Nil(t, inputs[0].EndDate)
+	assert.True(t, inputs[0].Active)
+
+	assert.Equal(t, 2500.00, inputs[1].Amount)
+	require.NotNil(t, inputs[1].DayOfWeek)
+	assert.Equal(t, 5, *inputs[1].DayOfWeek)
+	require.NotNil(t, inputs[1].EndDate)
+	assert.Equal(t, day("2025-12-31"), *inputs[1].EndDate)
+
+	assert.Nil(t, inputs[2].DayOfMonth)
+}
+
+func TestParseRecurringCSVMonthlyRules(t *testing.T) {
+	csv := `description,type,amount,interval,day,week_of_month,business_day
+Salary,income,4200,monthly,31,,true
+Book club,expense,15,monthly,fri,last,
+`
+	inputs, err := ParseRecurringCSV(strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, inputs, 2)
+
+	assert.True(t, inputs[0].BusinessDay)
+	require.NotNil(t, inputs[0].DayOfMonth)
+	assert.Equal(t, 31, *inputs[0].DayOfMonth)
+
+	assert.False(t, inputs[1].BusinessDay)
+	require.NotNil(t, inputs[1].WeekOfMonth)
+	assert.Equal(t, -1, *inputs[1].WeekOfMonth)
+	require.NotNil(t, inputs[1].DayOfWeek, "day is a weekday with week_of_month")
+	assert.Equal(t, 5, *inputs[1].DayOfWeek)
+	assert.Nil(t, inputs[1].DayOfMonth)
+}
+
+func TestParseRecurringCSVReportsEveryBadRow(t *testing.T) {
+	csv := `description,type,amount,interval
+Rent,expense,1200,monthly
+Oops,transfer,10,monthly
+Bad,expense,abc,weekly
+Worse,income,5,daily
+`
+	_, err := ParseRecurringCSV(strings.NewReader(csv))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "row 2: invalid type")
+	assert.Contains(t, err.Error(), "row 3: invalid amount")
+	assert.Contains(t, err.Error(), "row 4: invalid interval")
+	assert.NotContains(t, err.Error(), "row 1")
+}
+
+func TestParseRecurringJSON(t *testing.T) {
+	js := `[
+		{"description": "Insurance", "type": "expense", "amount": 900, "interval": "yearly", "start_date": "2025-03-10", "prorate": true},
+		{"description": "Allowance", "type": "expense", "amount": 20, "interval": "weekly", "start_date": "2025-01-01", "day": 6, "active": false, "occurrence_count": 12}
+	]`
+	inputs, err := ParseRecurringJSON(strings.NewReader(js))
+	require.NoError(t, err)
+	require.Len(t, inputs, 2)
+
+	assert.Equal(t, 900.00, inputs[0].Amount)
+	assert.Equal(t, day("2025-03-10"), inputs[0].StartDate)
+	assert.True(t, inputs[0].Prorate)
+	require.NotNil(t, inputs[1].DayOfWeek)
+	assert.Equal(t, 6, *inputs[1].DayOfWeek)
+	assert.False(t, inputs[1].Active)
+	require.NotNil(t, inputs[1].OccurrenceCount)
+	assert.Equal(t, 12, *inputs[1].OccurrenceCount)
+	assert.Nil(t, inputs[0].OccurrenceCount)
+
+	_, err = ParseRecurringJSON(strings.NewReader(`[{"description": "X", "type": "expense", "amount": 1, "interval": "weekly", "occurrence_count": 0}]`))
+	assert.ErrorContains(t, err, "invalid occurrence_count 0")
+}
+
+func TestImportRecurringCSV(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	csv := `description,type,amount,interval,start_date,day,account_id
+Rent,expense,1200,monthly,2025-01-01,1,
+Paycheck,income,2500,biweekly,2025-01-03,fri,
+`
+
+	preview, err := fs.ImportRecurringCSV(ctx, strings.NewReader(csv), true)
+	require.NoError(t, err)
+	assert.True(t, preview.DryRun)
+	assert.Equal(t, 2, preview.Rows)
+	require.Len(t, preview.Recurring, 2)
+	assert.Equal(t, "Rent", preview.Recurring[0].Description)
+	assert.Equal(t, "USD", preview.Recurring[0].Currency)
+	saved, err := fs.ListRecurring(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, saved, "a dry run saves nothing")
+
+	// One unknown account stops the whole file.
+	_, err = fs.ImportRecurringCSV(ctx, strings.NewReader(csv+"Phone,expense,60,monthly,2025-01-05,5,9\n"), false)
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	assert.ErrorContains(t, err, "row 3")
+	saved, err = fs.ListRecurring(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, saved)
+
+	result, err := fs.ImportRecurringCSV(ctx, strings.NewReader(csv), false)
+	require.NoError(t, err)
+	assert.False(t, result.DryRun)
+	require.Len(t, result.Recurring, 2)
+	assert.NotZero(t, result.Recurring[1].ID)
+	saved, err = fs.ListRecurring(ctx)
+	require.NoError(t, err)
+	assert.Len(t, saved, 2)
+}
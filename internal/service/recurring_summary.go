@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// RecurringSummary is a recurring together with where its schedule stands
+// today, as worked out by the expansion engine. Pauses, end dates and
+// inactive recurrings are taken into account, so an inactive recurring has
+// no next occurrence and an annualized amount of zero. Amounts are in the
+// recurring's own currency, with expenses negative.
+type RecurringSummary struct {
+	Recurring
+	NextOccurrence *time.Time `json:"next_occurrence"`
+	LastOccurrence *time.Time `json:"last_occurrence"`
+	// UpcomingCount is the number of occurrences in the next 30 days,
+	// today included.
+	UpcomingCount int `json:"occurrences_next_30_days"`
+	// Annualized is the total over the next 365 days.
+	Annualized float64 `json:"annualized_amount"`
+	// RemainingOccurrences is how many occurrences an occurrence count
+	// leaves from today on, today included, whether or not the recurring
+	// is active. It is nil without a count.
+	RemainingOccurrences *int `json:"remaining_occurrences,omitempty"`
+}
+
+// recurringLookahead bounds the search for a next occurrence. Two years
+// covers a yearly schedule paused for a whole cycle.
+const recurringLookahead = 2 * 366
+
+// RecurringSummaries lists recurrings with their schedule metadata.
+func (fs *FinanceService) RecurringSummaries(ctx context.Context) ([]RecurringSummary, error) {
+	rs, err := fs.db.ListRecurring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return summarizeRecurrings(rs, fs.Today(ctx)), nil
+}
+
+func summarizeRecurrings(rs []Recurring, today time.Time) []RecurringSummary {
+	out := make([]RecurringSummary, len(rs))
+	for i, r := range rs {
+		out[i] = summarizeRecurring(r, today)
+	}
+	return out
+}
+
+func summarizeRecurring(r Recurring, today time.Time) RecurringSummary {
+	s := RecurringSummary{Recurring: r}
+	if r.OccurrenceCount.Valid {
+		last, ok := countEnd(r)
+		if !ok {
+			// The end date comes first.
+			last = r.EndDate.Time
+		}
+		remaining := len(expandOne(r, today, last))
+		s.RemainingOccurrences = &remaining
+	}
+	if !r.Active {
+		return s
+	}
+
+	if past := expandOne(r, earliestOccurrence(r), today.AddDate(0, 0, -1)); len(past) > 0 {
+		last := past[len(past)-1].Date.Time
+		s.LastOccurrence = &last
+	}
+	ahead := expandOne(r, today, today.AddDate(0, 0, recurringLookahead))
+	if len(ahead) > 0 {
+		next := ahead[0].Date.Time
+		s.NextOccurrence = &next
+	}
+
+	monthEnd := today.AddDate(0, 0, 29)
+	yearEnd := today.AddDate(0, 0, 364)
+	var annual money.Money
+	for _, tx := range ahead {
+		d := tx.Date.Time
+		if d.After(yearEnd) {
+			break
+		}
+		if !d.After(monthEnd) {
+			s.UpcomingCount++
+		}
+		annual = annual.Add(numericOrZero(tx.Amount))
+	}
+	s.Annualized = annual.Float64()
+	return s
+}
@@ -0,0 +1,559 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func weekdayPtr(w time.Weekday) *time.Weekday {
+	return &w
+}
+
+func TestWeeklyOccurrences(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       WeeklyPhaseOptions
+		start, end string
+		want       []string
+	}{
+		{
+			name:  "weekly on anchor weekday",
+			opts:  WeeklyPhaseOptions{Anchor: day("2025-09-05"), Step: 7},
+			start: "2025-09-01", end: "2025-09-30",
+			want: []string{"2025-09-05", "2025-09-12", "2025-09-19", "2025-09-26"},
+		},
+		{
+			name:  "zero step defaults to weekly",
+			opts:  WeeklyPhaseOptions{Anchor: day("2025-09-05")},
+			start: "2025-09-05", end: "2025-09-12",
+			want: []string{"2025-09-05", "2025-09-12"},
+		},
+		{
+			name:  "biweekly keeps phase from anchor",
+			opts:  WeeklyPhaseOptions{Anchor: day("2025-01-03"), Step: 14},
+			start: "2025-09-01", end: "2025-09-30",
+			want: []string{"2025-09-12", "2025-09-26"},
+		},
+		{
+			name:  "biweekly off-phase window start",
+			opts:  WeeklyPhaseOptions{Anchor: day("2025-01-03"), Step: 14},
+			start: "2025-09-13", end: "2025-10-10",
+			want: []string{"2025-09-26", "2025-10-10"},
+		},
+		{
+			name:  "pinned weekday snaps forward from anchor",
+			opts:  WeeklyPhaseOptions{Anchor: day("2025-09-01"), Weekday: weekdayPtr(time.Friday), Step: 14},
+			start: "2025-09-01", end: "2025-09-30",
+			want: []string{"2025-09-05", "2025-09-19"},
+		},
+		{
+			name:  "pinned weekday with window starting mid-phase",
+			opts:  WeeklyPhaseOptions{Anchor: day("2025-09-01"), Weekday: weekdayPtr(time.Friday), Step: 14},
+			start: "2025-09-04", end: "2025-09-30",
+			want: []string{"2025-09-05", "2025-09-19"},
+		},
+		{
+			name:  "nothing before anchor",
+			opts:  WeeklyPhaseOptions{Anchor: day("2025-09-12"), Step: 7},
+			start: "2025-09-01", end: "2025-09-20",
+			want: []string{"2025-09-12", "2025-09-19"},
+		},
+		{
+			name:  "window before anchor",
+			opts:  WeeklyPhaseOptions{Anchor: day("2025-09-12"), Step: 7},
+			start: "2025-08-01", end: "2025-08-31",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []time.Time
+			for _, s := range tt.want {
+				want = append(want, day(s))
+			}
+			assert.Equal(t, want, WeeklyOccurrences(tt.opts, day(tt.start), day(tt.end)))
+		})
+	}
+}
+
+func TestWeeklyOccurrencesInvariants(t *testing.T) {
+	opts := WeeklyPhaseOptions{Anchor: day("2025-01-06"), Weekday: weekdayPtr(time.Friday), Step: 14}
+	first := FirstWeeklyOccurrence(opts)
+	assert.Equal(t, day("2025-01-10"), first)
+
+	full := WeeklyOccurrences(opts, day("2025-01-01"), day("2025-12-31"))
+	for i, d := range full {
+		assert.Equal(t, time.Friday, d.Weekday())
+		assert.Zero(t, int(d.Sub(first).Hours()/24)%14, "off phase: %s", d)
+		if i > 0 {
+			assert.Equal(t, 14, int(d.Sub(full[i-1]).Hours()/24))
+		}
+	}
+
+	// Any sub-window sees exactly the matching slice of the full year.
+	for offset := 0; offset < 30; offset++ {
+		start := day("2025-03-01").AddDate(0, 0, offset)
+		end := start.AddDate(0, 0, 60)
+		var want []time.Time
+		for _, d := range full {
+			if !d.Before(start) && !d.After(end) {
+				want = append(want, d)
+			}
+		}
+		assert.Equal(t, want, WeeklyOccurrences(opts, start, end), "window starting %s", start.Format("2006-01-02"))
+	}
+}
+
+func TestExpandOneSkipsPause(t *testing.T) {
+	gym := Recurring{
+		Description: "Gym",
+		Type:        "expense",
+		Amount:      makePgNumeric(40),
+		StartDate:   makePgDate(day("2025-01-15")),
+		Interval:    "monthly",
+		Active:      true,
+		PausedFrom:  makePgDate(day("2025-06-15")),
+		PausedUntil: makePgDate(day("2025-08-14")),
+	}
+
+	var dates []string
+	for _, tx := range expandOne(gym, day("2025-05-01"), day("2025-09-30")) {
+		dates = append(dates, tx.Date.Time.Format("2006-01-02"))
+	}
+	assert.Equal(t, []string{"2025-05-15", "2025-08-15", "2025-09-15"}, dates, "pause bounds are inclusive")
+}
+
+func TestExpandOneOccurrenceCount(t *testing.T) {
+	laptop := Recurring{
+		Description:     "Laptop",
+		Type:            "expense",
+		Amount:          makePgNumeric(150),
+		StartDate:       makePgDate(day("2025-01-31")),
+		Interval:        "monthly",
+		Active:          true,
+		OccurrenceCount: pgtype.Int4{Int32: 4, Valid: true},
+	}
+	dates := func(r Recurring, start, end string) []string {
+		var out []string
+		for _, tx := range expandOne(r, day(start), day(end)) {
+			out = append(out, tx.Date.Time.Format("2006-01-02"))
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"},
+		dates(laptop, "2025-01-01", "2026-12-31"))
+	assert.Equal(t, []string{"2025-03-31", "2025-04-30"}, dates(laptop, "2025-03-01", "2025-12-31"),
+		"the count runs from the start date, not the window")
+	assert.Empty(t, dates(laptop, "2025-05-01", "2025-12-31"))
+
+	// A paused payment isn't one of the four.
+	paused := laptop
+	paused.PausedFrom = makePgDate(day("2025-02-01"))
+	paused.PausedUntil = makePgDate(day("2025-03-31"))
+	assert.Equal(t, []string{"2025-01-31", "2025-04-30", "2025-05-31", "2025-06-30"},
+		dates(paused, "2025-01-01", "2026-12-31"))
+
+	// Whichever of the end date and the count comes first wins.
+	ended := laptop
+	ended.EndDate = makePgDate(day("2025-03-15"))
+	assert.Equal(t, []string{"2025-01-31", "2025-02-28"}, dates(ended, "2025-01-01", "2026-12-31"))
+
+	weekly := laptop
+	weekly.Interval = "biweekly"
+	weekly.DayOfWeek = pgtype.Int4{Int32: int32(time.Friday), Valid: true}
+	weekly.OccurrenceCount.Int32 = 3
+	assert.Equal(t, []string{"2025-01-31", "2025-02-14", "2025-02-28"}, dates(weekly, "2025-01-01", "2025-12-31"))
+}
+
+func TestExpandMonthlyRules(t *testing.T) {
+	dates := func(r Recurring, start, end string) []string {
+		var out []string
+		for _, tx := range expandOne(r, day(start), day(end)) {
+			out = append(out, tx.Date.Time.Format("2006-01-02"))
+		}
+		return out
+	}
+	friday := pgtype.Int4{Int32: int32(time.Friday), Valid: true}
+
+	salary := Recurring{
+		Description: "Salary",
+		Type:        "income",
+		Amount:      makePgNumeric(4200),
+		StartDate:   makePgDate(day("2025-04-01")),
+		Interval:    "monthly",
+		DayOfMonth:  pgtype.Int4{Int32: 31, Valid: true},
+		BusinessDay: true,
+		Active:      true,
+	}
+	assert.Equal(t, []string{"2025-04-30", "2025-05-30", "2025-06-30", "2025-07-31", "2025-08-29", "2025-09-30"},
+		dates(salary, "2025-01-01", "2025-09-30"), "May 31 is a Saturday and Aug 31 a Sunday")
+
+	// Nov 1 is a Saturday, so its occurrence is in October's window.
+	first := salary
+	first.DayOfMonth.Int32 = 1
+	assert.Equal(t, []string{"2025-10-01", "2025-10-31"}, dates(first, "2025-10-01", "2025-10-31"))
+	assert.Empty(t, dates(first, "2025-11-01", "2025-11-30"), "and so isn't in November's")
+
+	// Starting on Saturday May 31, the first payment is moved back to the
+	// Friday before the start date and still counts.
+	weekendStart := salary
+	weekendStart.StartDate = makePgDate(day("2025-05-31"))
+	assert.Equal(t, []string{"2025-05-30", "2025-06-30", "2025-07-31"}, dates(weekendStart, "2025-05-01", "2025-07-31"))
+	assert.Equal(t, []string{"2025-05-30"}, dates(weekendStart, "2025-05-30", "2025-05-30"))
+	weekendStart.OccurrenceCount = pgtype.Int4{Int32: 2, Valid: true}
+	assert.Equal(t, []string{"2025-05-30", "2025-06-30"}, dates(weekendStart, "2025-01-01", "2025-12-31"))
+
+	club := Recurring{
+		Description: "Book club",
+		Type:        "expense",
+		Amount:      makePgNumeric(15),
+		StartDate:   makePgDate(day("2025-01-01")),
+		Interval:    "monthly",
+		DayOfWeek:   friday,
+		WeekOfMonth: pgtype.Int4{Int32: 2, Valid: true},
+		Active:      true,
+	}
+	assert.Equal(t, []string{"2025-01-10", "2025-02-14", "2025-03-14"}, dates(club, "2025-01-01", "2025-03-31"))
+
+	last := club
+	last.WeekOfMonth.Int32 = -1
+	assert.Equal(t, []string{"2025-01-31", "2025-02-28", "2025-03-28"}, dates(last, "2025-01-01", "2025-03-31"))
+
+	last.OccurrenceCount = pgtype.Int4{Int32: 2, Valid: true}
+	assert.Equal(t, []string{"2025-01-31", "2025-02-28"}, dates(last, "2025-01-01", "2025-12-31"))
+}
+
+func TestValidateMonthlyRules(t *testing.T) {
+	base := RecurringInput{
+		Description: "Book club",
+		Type:        "expense",
+		Amount:      15,
+		StartDate:   day("2025-01-01"),
+		Interval:    "monthly",
+		DayOfWeek:   ptr(int(time.Friday)),
+		WeekOfMonth: ptr(2),
+	}
+	require.NoError(t, validateRecurringInput(base))
+
+	cases := map[string]func(in *RecurringInput){
+		"week out of range":  func(in *RecurringInput) { in.WeekOfMonth = ptr(5) },
+		"no weekday":         func(in *RecurringInput) { in.DayOfWeek = nil },
+		"also day of month":  func(in *RecurringInput) { in.DayOfMonth = ptr(10) },
+		"weekly with a week": func(in *RecurringInput) { in.Interval = "weekly" },
+		"yearly business day": func(in *RecurringInput) {
+			in.Interval, in.WeekOfMonth, in.BusinessDay = "yearly", nil, true
+		},
+	}
+	for name, mutate := range cases {
+		in := base
+		mutate(&in)
+		assert.Error(t, validateRecurringInput(in), name)
+	}
+}
+
+func TestRecurringRRule(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+
+	salary, err := fs.CreateRecurringSimple(ctx, RecurringInput{
+		Description: "Salary",
+		Type:        "income",
+		Amount:      4200,
+		StartDate:   day("2025-01-01"),
+		RRule:       "rrule:freq=monthly;byday=mo,tu,we,th,fr;bysetpos=-1",
+		Active:      true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, database.RecurrenceIntervalCustom, salary.Interval)
+	assert.Equal(t, "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", salary.Rrule.String, "stored in canonical form")
+
+	_, occurrences, err := fs.RecurringOccurrences(ctx, salary.ID, day("2025-04-01"), day("2025-06-30"))
+	require.NoError(t, err)
+	var dates []string
+	for _, tx := range occurrences {
+		dates = append(dates, tx.Date.Time.Format("2006-01-02"))
+	}
+	assert.Equal(t, []string{"2025-04-30", "2025-05-30", "2025-06-30"}, dates)
+
+	// The count and pause apply to a rule as to any other schedule.
+	salary.OccurrenceCount = pgtype.Int4{Int32: 3, Valid: true}
+	salary.PausedFrom = makePgDate(day("2025-02-01"))
+	salary.PausedUntil = makePgDate(day("2025-02-28"))
+	dates = nil
+	for _, tx := range expandOne(salary, day("2025-01-01"), day("2025-12-31")) {
+		dates = append(dates, tx.Date.Time.Format("2006-01-02"))
+	}
+	assert.Equal(t, []string{"2025-01-31", "2025-03-31", "2025-04-30"}, dates)
+}
+
+func TestTransactionsBetweenWithAndWithoutRecurrings(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+
+	rent, err := fs.CreateRecurringSimple(ctx, RecurringInput{
+		Description: "Rent", Type: "expense", Amount: 1200, StartDate: day("2025-01-01"), Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+	lunch, err := fs.createTransaction(ctx, database.CreateTransactionParams{
+		Date: makePgDate(day("2025-03-01")), Amount: makePgNumeric(-12), Description: "Lunch", Type: "expense",
+	})
+	require.NoError(t, err)
+
+	all, err := fs.GetTransactionsWithRecurringsBetween(ctx, day("2025-03-01"), day("2025-03-31"))
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, SourceOneOff, all[0].Source)
+	assert.Equal(t, lunch.ID, all[0].ID)
+	assert.False(t, all[0].RecurringID.Valid)
+	assert.Equal(t, SourceRecurring, all[1].Source)
+	assert.Zero(t, all[1].ID)
+	assert.Equal(t, rent.ID, all[1].RecurringID.Int32)
+	assert.Equal(t, day("2025-03-01"), all[1].OccurrenceDate.Time)
+
+	recorded, err := fs.GetTransactionsBetween(ctx, day("2025-03-01"), day("2025-03-31"))
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, all[0], recorded[0])
+}
+
+func TestValidateRRule(t *testing.T) {
+	base := RecurringInput{
+		Description: "Estimated tax",
+		Type:        "expense",
+		Amount:      900,
+		StartDate:   day("2025-01-01"),
+		RRule:       "FREQ=YEARLY;BYMONTH=1,4,6,9;BYMONTHDAY=15",
+	}
+	require.NoError(t, validateRecurringInput(base))
+	custom := base
+	custom.Interval = "custom"
+	require.NoError(t, validateRecurringInput(custom))
+
+	cases := map[string]struct {
+		mutate func(in *RecurringInput)
+		want   string
+	}{
+		"bad rule":       {func(in *RecurringInput) { in.RRule = "FREQ=HOURLY" }, "invalid rrule"},
+		"interval too":   {func(in *RecurringInput) { in.Interval = "monthly" }, "interval must be custom"},
+		"day of month":   {func(in *RecurringInput) { in.DayOfMonth = ptr(15) }, "BYMONTHDAY"},
+		"never":          {func(in *RecurringInput) { in.RRule = "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30" }, "no occurrences"},
+		"ends first":     {func(in *RecurringInput) { in.EndDate = ptr(day("2025-01-10")) }, "no occurrences"},
+		"neither":        {func(in *RecurringInput) { in.RRule = "" }, "interval or rrule is required"},
+		"custom no rule": {func(in *RecurringInput) { in.RRule, in.Interval = "", "custom" }, "invalid interval"},
+	}
+	for name, tc := range cases {
+		in := base
+		tc.mutate(&in)
+		assert.ErrorContains(t, validateRecurringInput(in), tc.want, name)
+	}
+}
+
+// manyRecurrings is n recurrings spread over every interval, with a few
+// ended, paused or not started yet.
+func manyRecurrings(n int) []Recurring {
+	intervals := []database.RecurrenceInterval{"weekly", "biweekly", "monthly", "yearly"}
+	rs := make([]Recurring, n)
+	for i := range rs {
+		rs[i] = Recurring{
+			ID:        int32(i + 1),
+			Type:      "expense",
+			Amount:    makePgNumeric(float64(10 + i%90)),
+			StartDate: makePgDate(day("2025-01-01").AddDate(0, 0, i%400)),
+			Interval:  intervals[i%len(intervals)],
+			Active:    true,
+		}
+		switch i % 10 {
+		case 3:
+			rs[i].EndDate = makePgDate(day("2025-06-30"))
+		case 7:
+			rs[i].PausedFrom = makePgDate(day("2025-09-01"))
+			rs[i].PausedUntil = makePgDate(day("2025-10-31"))
+		}
+	}
+	return rs
+}
+
+func TestExpandAllKeepsOrder(t *testing.T) {
+	rs := manyRecurrings(1000)
+	start, end := day("2025-03-01"), day("2026-02-28")
+
+	var want []Transaction
+	for _, r := range rs {
+		want = append(want, expandOne(r, start, end)...)
+	}
+	for _, workers := range []int{1, 3, 8} {
+		got := expandWithWorkers(rs, start, end, workers)
+		require.Len(t, got, len(want), "%d workers", workers)
+		assert.Equal(t, want, got, "%d workers", workers)
+		assert.Nil(t, expandWithWorkers(rs, day("2020-01-01"), day("2020-12-31"), workers))
+	}
+	assert.Equal(t, want, expandAll(rs, start, end))
+}
+
+func TestExpandListedNamesRecurring(t *testing.T) {
+	rs := manyRecurrings(300)
+	start, end := day("2025-03-01"), day("2025-08-31")
+
+	var want []ListedTransaction
+	for _, r := range rs {
+		for _, occ := range expandOccurrences(r, start, end) {
+			want = append(want, ListedTransaction{
+				Transaction:    occ.tx,
+				Source:         SourceRecurring,
+				RecurringID:    pgtype.Int4{Int32: r.ID, Valid: true},
+				OccurrenceDate: makePgDate(occ.scheduled),
+			})
+		}
+	}
+	require.NotEmpty(t, want)
+	assert.Equal(t, want, expandListed(rs, start, end))
+}
+
+func TestExpandListedKeepsScheduledDate(t *testing.T) {
+	salary := Recurring{
+		ID:          4,
+		Description: "Salary",
+		Type:        "income",
+		Amount:      makePgNumeric(4200),
+		StartDate:   makePgDate(day("2025-04-01")),
+		Interval:    "monthly",
+		DayOfMonth:  pgtype.Int4{Int32: 31, Valid: true},
+		BusinessDay: true,
+		Active:      true,
+	}
+
+	got := expandListed([]Recurring{salary}, day("2025-05-01"), day("2025-06-30"))
+	require.Len(t, got, 2)
+	// May 31 is a Saturday, so the salary is paid on the Friday before.
+	assert.Equal(t, day("2025-05-30"), got[0].Date.Time)
+	assert.Equal(t, day("2025-05-31"), got[0].OccurrenceDate.Time)
+	assert.Equal(t, day("2025-06-30"), got[1].Date.Time)
+	assert.Equal(t, day("2025-06-30"), got[1].OccurrenceDate.Time)
+}
+
+// BenchmarkExpandRecurring compares expanding a year of recurrings one
+// after another with the worker pool. Run it with -cpu 1,4 to see the pool
+// scale.
+func BenchmarkExpandRecurring(b *testing.B) {
+	start, end := day("2025-03-01"), day("2026-02-28")
+	for _, n := range []int{100, 500, 2000} {
+		rs := manyRecurrings(n)
+		b.Run(fmt.Sprintf("serial/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var out []Transaction
+				for _, r := range rs {
+					out = append(out, expandOne(r, start, end)...)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("pool/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				expandAll(rs, start, end)
+			}
+		})
+	}
+}
+
+func TestSummarizeRecurring(t *testing.T) {
+	rent := Recurring{
+		Description: "Rent",
+		Type:        "expense",
+		Amount:      makePgNumeric(1200),
+		StartDate:   makePgDate(day("2025-01-01")),
+		Interval:    "monthly",
+		Active:      true,
+	}
+	s := summarizeRecurring(rent, day("2025-06-10"))
+	require.NotNil(t, s.LastOccurrence)
+	assert.Equal(t, day("2025-06-01"), *s.LastOccurrence)
+	require.NotNil(t, s.NextOccurrence)
+	assert.Equal(t, day("2025-07-01"), *s.NextOccurrence)
+	assert.Equal(t, 1, s.UpcomingCount)
+	assert.Equal(t, -14400.0, s.Annualized)
+
+	// Ending in September leaves three more payments.
+	rent.EndDate = makePgDate(day("2025-09-30"))
+	s = summarizeRecurring(rent, day("2025-06-10"))
+	assert.Equal(t, -3600.0, s.Annualized)
+
+	assert.Nil(t, s.RemainingOccurrences, "no count")
+
+	// Ten payments from January end with October, after the end date.
+	rent.OccurrenceCount = pgtype.Int4{Int32: 10, Valid: true}
+	s = summarizeRecurring(rent, day("2025-06-10"))
+	require.NotNil(t, s.RemainingOccurrences)
+	assert.Equal(t, 3, *s.RemainingOccurrences)
+	rent.EndDate = pgtype.Date{}
+	s = summarizeRecurring(rent, day("2025-06-01"))
+	assert.Equal(t, 5, *s.RemainingOccurrences, "today counts")
+	assert.Equal(t, -6000.0, s.Annualized)
+
+	rent.Active = false
+	s = summarizeRecurring(rent, day("2025-06-10"))
+	assert.Nil(t, s.NextOccurrence)
+	assert.Nil(t, s.LastOccurrence)
+	assert.Zero(t, s.Annualized)
+	assert.Equal(t, 4, *s.RemainingOccurrences)
+}
+
+func TestRecurringCategoryAndAccount(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	visa, err := fs.CreateAccount(ctx, AccountInput{Name: "Visa", Type: AccountCreditCard, StatementDay: 20, DueDay: 15})
+	require.NoError(t, err)
+
+	in := RecurringInput{
+		Description: "Streaming",
+		Type:        "expense",
+		Amount:      15,
+		StartDate:   day("2025-01-12"),
+		Interval:    "monthly",
+		Active:      true,
+		Category:    " Subscriptions ",
+		AccountID:   ptr(visa.ID + 1),
+	}
+	_, err = fs.CreateRecurringSimple(ctx, in)
+	assert.ErrorIs(t, err, ErrInvalidAccount)
+
+	in.AccountID = &visa.ID
+	rec, err := fs.CreateRecurringSimple(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, "Subscriptions", rec.Category.String)
+
+	_, occurrences, err := fs.RecurringOccurrences(ctx, rec.ID, day("2025-01-01"), day("2025-01-31"))
+	require.NoError(t, err)
+	require.Len(t, occurrences, 1)
+	assert.Equal(t, rec.Category, occurrences[0].Category)
+	assert.Equal(t, rec.AccountID, occurrences[0].AccountID)
+
+	// Charges are paid on their statement's due date, so the forecast from
+	// Mar 10 pays February's charge on Mar 15 and March's on Apr 15.
+	start, end := day("2025-03-10"), day("2025-04-30")
+	var paid []string
+	for _, tx := range payCardsOnDueDates(expandForForecast([]Recurring{rec}, start, end), []Account{visa}) {
+		if d := tx.Date.Time; !d.Before(start) && !d.After(end) {
+			paid = append(paid, d.Format("2006-01-02"))
+		}
+	}
+	assert.Equal(t, []string{"2025-03-15", "2025-04-15"}, paid)
+
+	assert.ErrorIs(t, fs.DeleteAccount(ctx, visa.ID), ErrAccountInUse)
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/notify"
+)
+
+// EntityReminder is the audit entity for recurring reminder settings.
+const EntityReminder = "recurring_reminder"
+
+// NotifyReminder is the kind of notification SendReminders delivers.
+const NotifyReminder = "reminder"
+
+// MaxReminderDays is the furthest ahead of an occurrence a reminder can
+// be set.
+const MaxReminderDays = 60
+
+// ErrInvalidReminder is returned for a reminder set outside 0 to
+// MaxReminderDays days before.
+var ErrInvalidReminder = errors.New("invalid reminder")
+
+// RecurringReminder is how many days before each of a recurring's
+// occurrences to remind the user of it.
+type RecurringReminder = database.RecurringReminders
+
+// Reminder is a reminder of one occurrence of a recurring. Amount is
+// negative for expenses, in the recurring's own currency.
+type Reminder struct {
+	RecurringID int32     `json:"recurring_id"`
+	Description string    `json:"description"`
+	Type        string    `json:"type"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency"`
+	DueDate     time.Time `json:"due_date"`
+	RemindOn    time.Time `json:"remind_on"`
+	DaysBefore  int32     `json:"days_before"`
+	// Sent is true once the reminder has gone to a notification channel.
+	Sent bool `json:"sent"`
+}
+
+// SetRecurringReminder asks for a reminder daysBefore days ahead of each
+// of a recurring's occurrences, replacing any it had. 0 reminds on the day.
+func (fs *FinanceService) SetRecurringReminder(ctx context.Context, id int32, daysBefore int) (RecurringReminder, error) {
+	if daysBefore < 0 || daysBefore > MaxReminderDays {
+		return RecurringReminder{}, fmt.Errorf("%w: days_before %d (expected 0-%d)", ErrInvalidReminder, daysBefore, MaxReminderDays)
+	}
+	if _, err := fs.db.GetRecurringByID(ctx, id); errors.Is(err, pgx.ErrNoRows) {
+		return RecurringReminder{}, fmt.Errorf("%w: %d", ErrRecurringNotFound, id)
+	} else if err != nil {
+		return RecurringReminder{}, err
+	}
+	before, err := fs.recurringReminder(ctx, id)
+	if err != nil {
+		return RecurringReminder{}, err
+	}
+	after, err := fs.db.SetRecurringReminder(ctx, database.SetRecurringReminderParams{
+		RecurringID: id,
+		DaysBefore:  int32(daysBefore),
+	})
+	if err != nil {
+		return RecurringReminder{}, err
+	}
+	if before == nil {
+		fs.audit(ctx, AuditCreate, EntityReminder, id, nil, after)
+	} else {
+		fs.audit(ctx, AuditUpdate, EntityReminder, id, *before, after)
+	}
+	fs.events.Publish(ctx, EventRecurringUpdated, map[string]int32{"id": id})
+	return after, nil
+}
+
+// ClearRecurringReminder stops reminders for a recurring. One without a
+// reminder is left alone.
+func (fs *FinanceService) ClearRecurringReminder(ctx context.Context, id int32) error {
+	if _, err := fs.db.GetRecurringByID(ctx, id); errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrRecurringNotFound, id)
+	} else if err != nil {
+		return err
+	}
+	before, err := fs.recurringReminder(ctx, id)
+	if err != nil || before == nil {
+		return err
+	}
+	if _, err := fs.db.DeleteRecurringReminder(ctx, id); err != nil {
+		return err
+	}
+	fs.audit(ctx, AuditDelete, EntityReminder, id, *before, nil)
+	fs.events.Publish(ctx, EventRecurringUpdated, map[string]int32{"id": id})
+	return nil
+}
+
+// Reminders lists the reminders that fall due in the next days days,
+// today included, soonest first. Reminders already due for an occurrence
+// still to come are listed too, whether or not they have been sent.
+func (fs *FinanceService) Reminders(ctx context.Context, days int) ([]Reminder, error) {
+	today := fs.Today(ctx)
+	return fs.reminders(ctx, today, today.AddDate(0, 0, days-1))
+}
+
+// SendReminders sends each reminder that is due to every enabled
+// notification channel, once.
+func (fs *FinanceService) SendReminders(ctx context.Context) error {
+	channels, err := fs.enabledChannels(ctx)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+	today := fs.Today(ctx)
+	due, err := fs.reminders(ctx, today, today)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, r := range due {
+		if r.Sent {
+			continue
+		}
+		errs = append(errs, fs.notify(ctx, channels, NotifyReminder, reminderKey(r.RecurringID, r.DueDate), notify.Message{
+			Title: "Bill reminder",
+			Text: fmt.Sprintf("%s for %.2f %s is due %s, on %s.", r.Description, math.Abs(r.Amount), r.Currency,
+				dueIn(int(r.DueDate.Sub(today).Hours()/24)), r.DueDate.Format("Mon Jan 2")),
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// StartReminders runs SendReminders on every household every interval
+// until ctx is cancelled.
+func (fs *FinanceService) StartReminders(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := fs.forEachHousehold(ctx, fs.SendReminders); err != nil && ctx.Err() == nil {
+				log.Printf("reminders failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// reminders finds the reminders whose day falls between start and end, or
+// before start for an occurrence on or after it.
+func (fs *FinanceService) reminders(ctx context.Context, start, end time.Time) ([]Reminder, error) {
+	settings, err := fs.db.ListRecurringReminders(ctx)
+	if err != nil || len(settings) == 0 {
+		return []Reminder{}, err
+	}
+	rs, err := fs.db.ListActiveRecurring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[int32]Recurring, len(rs))
+	for _, r := range rs {
+		active[r.ID] = r
+	}
+
+	out := []Reminder{}
+	for _, s := range settings {
+		r, ok := active[s.RecurringID]
+		if !ok {
+			continue
+		}
+		for _, occ := range expandOne(r, start, end.AddDate(0, 0, int(s.DaysBefore))) {
+			amt, err := NumericToMoney(occ.Amount)
+			if err != nil {
+				return nil, err
+			}
+			due := occ.Date.Time
+			sent, err := fs.db.NotificationSent(ctx, database.NotificationSentParams{
+				Kind: NotifyReminder,
+				Key:  reminderKey(r.ID, due),
+			})
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, Reminder{
+				RecurringID: r.ID,
+				Description: r.Description,
+				Type:        r.Type,
+				Amount:      amt.Float64(),
+				Currency:    r.Currency,
+				DueDate:     due,
+				RemindOn:    due.AddDate(0, 0, -int(s.DaysBefore)),
+				DaysBefore:  s.DaysBefore,
+				Sent:        sent,
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].RemindOn.Equal(out[j].RemindOn) {
+			return out[i].RemindOn.Before(out[j].RemindOn)
+		}
+		if !out[i].DueDate.Equal(out[j].DueDate) {
+			return out[i].DueDate.Before(out[j].DueDate)
+		}
+		return out[i].RecurringID < out[j].RecurringID
+	})
+	return out, nil
+}
+
+// recurringReminder is a recurring's reminder, nil when it has none.
+func (fs *FinanceService) recurringReminder(ctx context.Context, id int32) (*RecurringReminder, error) {
+	all, err := fs.db.ListRecurringReminders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range all {
+		if r.RecurringID == id {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func reminderKey(id int32, due time.Time) string {
+	return fmt.Sprintf("%d:%s", id, due.Format("2006-01-02"))
+}
+
+// dueIn words how far off a due date is.
+func dueIn(days int) string {
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	default:
+		return fmt.Sprintf("in %d days", days)
+	}
+}
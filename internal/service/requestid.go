@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID tags ctx with the ID of the API request the work under it
+// is for, so its log lines, and the queries it runs, can be matched up
+// with the request.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID ctx was tagged with, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Logf logs like log.Printf, prefixed with ctx's request ID when it has
+// one: "request 4bf92f35: ...".
+func Logf(ctx context.Context, format string, args ...any) {
+	if id := RequestIDFromContext(ctx); id != "" {
+		format = "request " + id + ": " + format
+	}
+	log.Output(2, fmt.Sprintf(format, args...))
+}
+
+// queryTracer logs the queries that fail, with the request they ran for,
+// so a failed API call can be traced to its SQL.
+type queryTracer struct{}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql string
+	at  time.Time
+}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, at: time.Now()})
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if data.Err == nil || !ok {
+		return
+	}
+	Logf(ctx, "query failed after %s: %v\n%s", time.Since(start.at).Round(time.Microsecond), data.Err, start.sql)
+}
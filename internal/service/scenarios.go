@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// ErrInvalidScenario is returned for what-if scenarios that do not
+// validate.
+var ErrInvalidScenario = errors.New("invalid scenario")
+
+// Scenario is a what-if: hypothetical transactions and recurrings laid over
+// the real data, which is left as it is. The zero Scenario is the real data
+// alone.
+type Scenario struct {
+	Transactions []ScenarioTransaction `json:"transactions,omitempty"`
+	// Recurring are expanded as if active; their Active and Prorate are
+	// ignored.
+	Recurring []RecurringInput `json:"recurring,omitempty"`
+}
+
+// ScenarioTransaction is a hypothetical one-off income or expense. Amount
+// is positive for both. Currency defaults to the base currency.
+type ScenarioTransaction struct {
+	Date        time.Time `json:"date"`
+	Type        string    `json:"type"`
+	Amount      float64   `json:"amount"`
+	Description string    `json:"description,omitempty"`
+	Currency    string    `json:"currency,omitempty"`
+}
+
+// ForecastDelta is one day of a ForecastComparison. Delta is Scenario
+// minus Baseline, so it is positive when the scenario leaves more money.
+type ForecastDelta struct {
+	Date     time.Time `json:"date"`
+	Baseline float64   `json:"baseline"`
+	Scenario float64   `json:"scenario"`
+	Delta    float64   `json:"delta"`
+}
+
+// LowestPointShift compares the lowest days of two forecasts.
+type LowestPointShift struct {
+	Baseline DailyCashFlow `json:"baseline"`
+	Scenario DailyCashFlow `json:"scenario"`
+	// Delta is how much higher the scenario's lowest balance is.
+	Delta float64 `json:"delta"`
+	// DaysShift is how many days later the scenario's lowest day comes;
+	// negative when it comes sooner.
+	DaysShift int `json:"days_shift"`
+}
+
+// ForecastComparison sets two forecasts of the same window side by side.
+type ForecastComparison struct {
+	Days          []ForecastDelta  `json:"days"`
+	EndingBalance ForecastDelta    `json:"ending_balance"`
+	LowestPoint   LowestPointShift `json:"lowest_point"`
+}
+
+// ForecastScenario projects the balance the way Forecast does, with sc's
+// transactions and recurrings added. Only the zero Scenario is cached.
+func (fs *FinanceService) ForecastScenario(ctx context.Context, startingBalance float64, days int, sc Scenario) ([]DailyCashFlow, error) {
+	if len(sc.Transactions) == 0 && len(sc.Recurring) == 0 {
+		return fs.Forecast(ctx, startingBalance, days)
+	}
+	if days <= 0 {
+		days = 90
+	}
+	start := fs.Today(ctx)
+	extra, err := scenarioTransactions(sc, start, start.AddDate(0, 0, days-1))
+	if err != nil {
+		return nil, err
+	}
+	return fs.forecast(ctx, start, startingBalance, days, ForecastOptions{}, extra)
+}
+
+// CompareForecasts forecasts baseline and scenario from the same starting
+// balance over the same days and compares them day by day. Pass the zero
+// Scenario as baseline to compare against the real forecast.
+func (fs *FinanceService) CompareForecasts(ctx context.Context, startingBalance float64, days int, baseline, scenario Scenario) (ForecastComparison, error) {
+	a, err := fs.ForecastScenario(ctx, startingBalance, days, baseline)
+	if err != nil {
+		return ForecastComparison{}, fmt.Errorf("baseline: %w", err)
+	}
+	b, err := fs.ForecastScenario(ctx, startingBalance, days, scenario)
+	if err != nil {
+		return ForecastComparison{}, fmt.Errorf("scenario: %w", err)
+	}
+	return compareForecasts(a, b), nil
+}
+
+// compareForecasts compares two forecasts of the same window.
+func compareForecasts(baseline, scenario []DailyCashFlow) ForecastComparison {
+	delta := func(a, b DailyCashFlow) ForecastDelta {
+		return ForecastDelta{
+			Date:     a.Date,
+			Baseline: a.Balance,
+			Scenario: b.Balance,
+			Delta:    money.FromFloat(b.Balance).Sub(money.FromFloat(a.Balance)).Float64(),
+		}
+	}
+	n := min(len(baseline), len(scenario))
+	cmp := ForecastComparison{Days: make([]ForecastDelta, n)}
+	for i := range n {
+		cmp.Days[i] = delta(baseline[i], scenario[i])
+	}
+	if n == 0 {
+		return cmp
+	}
+	cmp.EndingBalance = cmp.Days[n-1]
+
+	lowA, i := LowestPoint(baseline[:n])
+	lowB, j := LowestPoint(scenario[:n])
+	cmp.LowestPoint = LowestPointShift{
+		Baseline:  lowA,
+		Scenario:  lowB,
+		Delta:     delta(lowA, lowB).Delta,
+		DaysShift: j - i,
+	}
+	return cmp
+}
+
+// validateScenario checks every row of sc.
+func validateScenario(sc Scenario) error {
+	for i, st := range sc.Transactions {
+		if st.Type != "income" && st.Type != "expense" {
+			return fmt.Errorf("%w: transaction %d: invalid type %q (expected income|expense)", ErrInvalidScenario, i+1, st.Type)
+		}
+		if st.Amount <= 0 {
+			return fmt.Errorf("%w: transaction %d: amount must be positive", ErrInvalidScenario, i+1)
+		}
+		if st.Date.IsZero() {
+			return fmt.Errorf("%w: transaction %d: date is required", ErrInvalidScenario, i+1)
+		}
+		if _, err := scenarioCurrency(st.Currency); err != nil {
+			return fmt.Errorf("%w: transaction %d: %w", ErrInvalidScenario, i+1, err)
+		}
+	}
+	for i, in := range sc.Recurring {
+		in.Prorate = false
+		if err := validateRecurringInput(in); err != nil {
+			return fmt.Errorf("%w: recurring %d: %w", ErrInvalidScenario, i+1, err)
+		}
+		if _, err := scenarioCurrency(in.Currency); err != nil {
+			return fmt.Errorf("%w: recurring %d: %w", ErrInvalidScenario, i+1, err)
+		}
+	}
+	return nil
+}
+
+// scenarioTransactions validates sc and returns its transactions between
+// start and end, recurrings expanded.
+func scenarioTransactions(sc Scenario, start, end time.Time) ([]Transaction, error) {
+	if err := validateScenario(sc); err != nil {
+		return nil, err
+	}
+	var out []Transaction
+	for _, st := range sc.Transactions {
+		currency, _ := scenarioCurrency(st.Currency)
+		d := truncateDay(st.Date)
+		if d.Before(start) || d.After(end) {
+			continue
+		}
+		amount := st.Amount
+		if st.Type == "expense" {
+			amount = -amount
+		}
+		out = append(out, Transaction{
+			Date:        makePgDate(d),
+			Amount:      makePgNumeric(amount),
+			Description: st.Description,
+			Type:        st.Type,
+			Currency:    currency,
+		})
+	}
+	for _, in := range sc.Recurring {
+		currency, _ := scenarioCurrency(in.Currency)
+		interval, rule, _ := recurrenceOf(in)
+		r := Recurring{
+			Description: in.Description,
+			Type:        in.Type,
+			Amount:      makePgNumeric(in.Amount),
+			StartDate:   makePgDate(in.StartDate),
+			Interval:    interval,
+			Active:      true,
+			Currency:    currency,
+			BusinessDay: in.BusinessDay,
+			Rrule:       rule,
+		}
+		if in.DayOfWeek != nil {
+			r.DayOfWeek = pgtype.Int4{Int32: int32(*in.DayOfWeek), Valid: true}
+		}
+		if in.DayOfMonth != nil {
+			r.DayOfMonth = pgtype.Int4{Int32: int32(*in.DayOfMonth), Valid: true}
+		}
+		if in.EndDate != nil {
+			r.EndDate = makePgDate(*in.EndDate)
+		}
+		if in.OccurrenceCount != nil {
+			r.OccurrenceCount = pgtype.Int4{Int32: int32(*in.OccurrenceCount), Valid: true}
+		}
+		if in.WeekOfMonth != nil {
+			r.WeekOfMonth = pgtype.Int4{Int32: int32(*in.WeekOfMonth), Valid: true}
+		}
+		out = append(out, expandOne(r, start, end)...)
+	}
+	return out, nil
+}
+
+// scenarioCurrency normalizes a scenario row's currency, leaving it empty
+// (the base currency) when not given.
+func scenarioCurrency(code string) (string, error) {
+	if strings.TrimSpace(code) == "" {
+		return "", nil
+	}
+	return NormalizeCurrency(code)
+}
+
+// EntityScenario is the audit entity for saved scenario changes.
+const EntityScenario = "scenario"
+
+// ErrScenarioNotFound is returned when looking up an unknown saved
+// scenario.
+var ErrScenarioNotFound = errors.New("scenario not found")
+
+// SavedScenario is a named Scenario kept in the database so it can be
+// forecast again against the data as it is then.
+type SavedScenario struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	Scenario  Scenario  `json:"scenario"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListScenarios returns every saved scenario.
+func (fs *FinanceService) ListScenarios(ctx context.Context) ([]SavedScenario, error) {
+	rows, err := fs.db.ListScenarios(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SavedScenario, len(rows))
+	for i, row := range rows {
+		if out[i], err = savedScenario(row); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (fs *FinanceService) GetScenario(ctx context.Context, id int32) (SavedScenario, error) {
+	row, err := fs.db.GetScenario(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SavedScenario{}, fmt.Errorf("%w: %d", ErrScenarioNotFound, id)
+	}
+	if err != nil {
+		return SavedScenario{}, err
+	}
+	return savedScenario(row)
+}
+
+func (fs *FinanceService) CreateScenario(ctx context.Context, name string, sc Scenario) (SavedScenario, error) {
+	params, err := scenarioParams(name, sc)
+	if err != nil {
+		return SavedScenario{}, err
+	}
+	row, err := fs.db.CreateScenario(ctx, params)
+	if err != nil {
+		return SavedScenario{}, scenarioError(params.Name, err)
+	}
+	saved, err := savedScenario(row)
+	if err != nil {
+		return SavedScenario{}, err
+	}
+	fs.audit(ctx, AuditCreate, EntityScenario, saved.ID, nil, saved)
+	fs.events.Publish(ctx, EventScenarioCreated, saved)
+	return saved, nil
+}
+
+// UpdateScenario renames a saved scenario and replaces what it holds.
+func (fs *FinanceService) UpdateScenario(ctx context.Context, id int32, name string, sc Scenario) (SavedScenario, error) {
+	before, err := fs.GetScenario(ctx, id)
+	if err != nil {
+		return SavedScenario{}, err
+	}
+	params, err := scenarioParams(name, sc)
+	if err != nil {
+		return SavedScenario{}, err
+	}
+	row, err := fs.db.UpdateScenario(ctx, database.UpdateScenarioParams{
+		Name:       params.Name,
+		Definition: params.Definition,
+		ID:         id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SavedScenario{}, fmt.Errorf("%w: %d", ErrScenarioNotFound, id)
+	}
+	if err != nil {
+		return SavedScenario{}, scenarioError(params.Name, err)
+	}
+	saved, err := savedScenario(row)
+	if err != nil {
+		return SavedScenario{}, err
+	}
+	fs.audit(ctx, AuditUpdate, EntityScenario, id, before, saved)
+	fs.events.Publish(ctx, EventScenarioUpdated, saved)
+	return saved, nil
+}
+
+func (fs *FinanceService) DeleteScenario(ctx context.Context, id int32) error {
+	before, err := fs.GetScenario(ctx, id)
+	if err != nil {
+		return err
+	}
+	n, err := fs.db.DeleteScenario(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrScenarioNotFound, id)
+	}
+	fs.audit(ctx, AuditDelete, EntityScenario, id, before, nil)
+	fs.events.Publish(ctx, EventScenarioDeleted, map[string]int32{"id": id})
+	return nil
+}
+
+// ScenarioForecast compares a saved scenario with the real forecast, both
+// worked out from today's data.
+func (fs *FinanceService) ScenarioForecast(ctx context.Context, id int32, startingBalance float64, days int) (ForecastComparison, error) {
+	saved, err := fs.GetScenario(ctx, id)
+	if err != nil {
+		return ForecastComparison{}, err
+	}
+	return fs.CompareForecasts(ctx, startingBalance, days, Scenario{}, saved.Scenario)
+}
+
+// scenarioParams validates a scenario for saving and encodes it.
+func scenarioParams(name string, sc Scenario) (database.CreateScenarioParams, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return database.CreateScenarioParams{}, fmt.Errorf("%w: name is required", ErrInvalidScenario)
+	}
+	if len(name) > 100 {
+		return database.CreateScenarioParams{}, fmt.Errorf("%w: name is longer than 100 characters", ErrInvalidScenario)
+	}
+	if err := validateScenario(sc); err != nil {
+		return database.CreateScenarioParams{}, err
+	}
+	def, err := json.Marshal(sc)
+	if err != nil {
+		return database.CreateScenarioParams{}, err
+	}
+	return database.CreateScenarioParams{Name: name, Definition: def}, nil
+}
+
+// savedScenario decodes a scenarios row.
+func savedScenario(row database.Scenarios) (SavedScenario, error) {
+	saved := SavedScenario{ID: row.ID, Name: row.Name, CreatedAt: row.CreatedAt.Time}
+	if err := json.Unmarshal(row.Definition, &saved.Scenario); err != nil {
+		return SavedScenario{}, fmt.Errorf("scenario %d: %w", row.ID, err)
+	}
+	return saved, nil
+}
+
+// scenarioError turns a clash on the unique name into ErrInvalidScenario.
+func scenarioError(name string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("%w: a scenario named %q already exists", ErrInvalidScenario, name)
+	}
+	return err
+}
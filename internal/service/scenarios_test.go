@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenarioTransactions(t *testing.T) {
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 29)
+	dom := 15
+	sc := Scenario{
+		Transactions: []ScenarioTransaction{
+			{Date: start.AddDate(0, 0, 4), Type: "expense", Amount: 1200, Description: "New laptop"},
+			{Date: start.AddDate(0, 0, 40), Type: "income", Amount: 50, Description: "Outside window"},
+			{Date: start, Type: "income", Amount: 80, Description: "Sold bike", Currency: "eur"},
+		},
+		Recurring: []RecurringInput{
+			{Description: "Gym", Type: "expense", Amount: 40, StartDate: start, Interval: "monthly", DayOfMonth: &dom},
+		},
+	}
+	txs, err := scenarioTransactions(sc, start, end)
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+	assert.Equal(t, -1200.0, toFloat(txs[0].Amount))
+	assert.Equal(t, "EUR", txs[1].Currency)
+	assert.Equal(t, "Gym", txs[2].Description)
+	assert.Equal(t, start.AddDate(0, 0, 14), txs[2].Date.Time)
+	assert.Equal(t, -40.0, toFloat(txs[2].Amount))
+
+	for name, bad := range map[string]Scenario{
+		"type":     {Transactions: []ScenarioTransaction{{Date: start, Type: "transfer", Amount: 1}}},
+		"amount":   {Transactions: []ScenarioTransaction{{Date: start, Type: "income"}}},
+		"date":     {Transactions: []ScenarioTransaction{{Type: "income", Amount: 1}}},
+		"currency": {Transactions: []ScenarioTransaction{{Date: start, Type: "income", Amount: 1, Currency: "dollars"}}},
+		"interval": {Recurring: []RecurringInput{{Description: "x", Type: "expense", Amount: 1, StartDate: start, Interval: "daily"}}},
+	} {
+		_, err := scenarioTransactions(bad, start, end)
+		assert.ErrorIs(t, err, ErrInvalidScenario, name)
+	}
+}
+
+func TestCompareForecasts(t *testing.T) {
+	day := func(i int, balance float64) DailyCashFlow {
+		return DailyCashFlow{Date: time.Date(2025, 9, 1+i, 0, 0, 0, 0, time.UTC), Balance: balance}
+	}
+	baseline := []DailyCashFlow{day(0, 500), day(1, 100), day(2, 300), day(3, 400)}
+	scenario := []DailyCashFlow{day(0, 500), day(1, 400), day(2, 50.1), day(3, 150)}
+
+	cmp := compareForecasts(baseline, scenario)
+	require.Len(t, cmp.Days, 4)
+	assert.Equal(t, 300.0, cmp.Days[1].Delta)
+	assert.Equal(t, -249.9, cmp.Days[2].Delta)
+	assert.Equal(t, ForecastDelta{Date: baseline[3].Date, Baseline: 400, Scenario: 150, Delta: -250}, cmp.EndingBalance)
+	assert.Equal(t, 100.0, cmp.LowestPoint.Baseline.Balance)
+	assert.Equal(t, 50.1, cmp.LowestPoint.Scenario.Balance)
+	assert.Equal(t, -49.9, cmp.LowestPoint.Delta)
+	assert.Equal(t, 1, cmp.LowestPoint.DaysShift)
+
+	assert.Empty(t, compareForecasts(nil, scenario).Days)
+}
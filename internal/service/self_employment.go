@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+const selfEmploymentRateSetting = "self_employment_tax_rate"
+
+var (
+	// ErrNotIncome is returned when tagging an expense as self-employment
+	// income.
+	ErrNotIncome = errors.New("not an income transaction")
+	// ErrSelfEmploymentRateNotSet is returned by the self-employment tax
+	// estimate until a rate is configured.
+	ErrSelfEmploymentRateNotSet = errors.New("self-employment tax rate not configured")
+	// ErrNoSelfEmploymentIncome is returned when there is nothing to base
+	// estimated payments on.
+	ErrNoSelfEmploymentIncome = errors.New("no self-employment income")
+)
+
+// taxQuarterStarts are the months the IRS estimated tax periods begin in.
+// They are uneven: the second covers only April and May.
+var taxQuarterStarts = [4]time.Month{time.January, time.April, time.June, time.September}
+
+// EstimatedTaxQuarter is one estimated tax period and the self-employment
+// income received in it so far.
+type EstimatedTaxQuarter struct {
+	Quarter int       `json:"quarter"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	DueDate time.Time `json:"due_date"`
+	Income  float64   `json:"income"`
+	Tax     float64   `json:"tax"`
+}
+
+// SelfEmploymentTaxEstimate projects the estimated tax owed for a year on
+// income tagged as self-employment, in the base currency.
+type SelfEmploymentTaxEstimate struct {
+	Year            int       `json:"year"`
+	AsOf            time.Time `json:"as_of"`
+	Rate            float64   `json:"rate"`
+	IncomeToDate    float64   `json:"income_to_date"`
+	TaxToDate       float64   `json:"tax_to_date"`
+	ProjectedIncome float64   `json:"projected_income"`
+	ProjectedTax    float64   `json:"projected_tax"`
+	// QuarterlyPayment is ProjectedTax split evenly over the four due
+	// dates.
+	QuarterlyPayment float64               `json:"quarterly_payment"`
+	Quarters         []EstimatedTaxQuarter `json:"quarters"`
+}
+
+// TagSelfEmployment marks an income transaction as earned from
+// self-employment. Tagging it again does nothing.
+func (fs *FinanceService) TagSelfEmployment(ctx context.Context, id int32) error {
+	tx, err := fs.db.GetTransactionByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrTransactionNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+	if tx.Type != "income" {
+		return fmt.Errorf("%w: %d", ErrNotIncome, id)
+	}
+	if err := fs.db.TagSelfEmploymentIncome(ctx, id); err != nil {
+		return err
+	}
+	fs.audit(ctx, AuditCreate, EntitySelfEmployment, id, nil, database.SelfEmploymentIncome{TransactionID: id})
+	fs.events.Publish(ctx, EventTransactionUpdated, map[string]int32{"id": id})
+	return nil
+}
+
+// UntagSelfEmployment removes the self-employment tag from a transaction.
+// An untagged transaction is left alone.
+func (fs *FinanceService) UntagSelfEmployment(ctx context.Context, id int32) error {
+	if _, err := fs.db.GetTransactionByID(ctx, id); errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrTransactionNotFound, id)
+	} else if err != nil {
+		return err
+	}
+	n, err := fs.db.UntagSelfEmploymentIncome(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		fs.audit(ctx, AuditDelete, EntitySelfEmployment, id, database.SelfEmploymentIncome{TransactionID: id}, nil)
+		fs.events.Publish(ctx, EventTransactionUpdated, map[string]int32{"id": id})
+	}
+	return nil
+}
+
+// SetSelfEmploymentTaxRate stores the effective rate owed on
+// self-employment income (0.3 = 30%), covering both income and
+// self-employment tax.
+func (fs *FinanceService) SetSelfEmploymentTaxRate(ctx context.Context, rate float64) error {
+	if rate < 0 || rate >= 1 {
+		return fmt.Errorf("invalid self-employment tax rate %.4f (expected 0 <= rate < 1)", rate)
+	}
+	if err := fs.updateSetting(ctx, selfEmploymentRateSetting, strconv.FormatFloat(rate, 'f', -1, 64)); err != nil {
+		return err
+	}
+	fs.events.Publish(ctx, EventSettingsChanged, map[string]float64{selfEmploymentRateSetting: rate})
+	return nil
+}
+
+func (fs *FinanceService) selfEmploymentRate(ctx context.Context) (float64, bool) {
+	value, err := fs.db.GetSetting(ctx, selfEmploymentRateSetting)
+	if err != nil {
+		return 0, false
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate <= 0 {
+		return 0, false
+	}
+	return rate, true
+}
+
+// EstimateSelfEmploymentTax projects the year's estimated tax by
+// annualizing the self-employment income tagged so far, the way
+// EstimateTaxes does for paychecks.
+func (fs *FinanceService) EstimateSelfEmploymentTax(ctx context.Context, year int) (SelfEmploymentTaxEstimate, error) {
+	rate, ok := fs.selfEmploymentRate(ctx)
+	if !ok {
+		return SelfEmploymentTaxEstimate{}, ErrSelfEmploymentRateNotSet
+	}
+	yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+	asOf := truncateDay(fs.Today(ctx))
+	if asOf.After(yearEnd) {
+		asOf = yearEnd
+	}
+	var income []database.GetSelfEmploymentIncomeBetweenRow
+	if !asOf.Before(yearStart) {
+		var err error
+		income, err = fs.db.GetSelfEmploymentIncomeBetween(ctx, database.GetSelfEmploymentIncomeBetweenParams{
+			StartDate: makePgDate(yearStart),
+			EndDate:   makePgDate(asOf),
+		})
+		if err != nil {
+			return SelfEmploymentTaxEstimate{}, err
+		}
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return SelfEmploymentTaxEstimate{}, err
+	}
+	return estimateSelfEmploymentTax(year, rate, asOf, income, conv)
+}
+
+func estimateSelfEmploymentTax(year int, rate float64, asOf time.Time, income []database.GetSelfEmploymentIncomeBetweenRow, conv converter) (SelfEmploymentTaxEstimate, error) {
+	est := SelfEmploymentTaxEstimate{Year: year, AsOf: asOf, Rate: rate}
+	var perQuarter [4]money.Money
+	var total money.Money
+	for _, row := range income {
+		amt, err := NumericToMoney(row.Amount)
+		if err != nil {
+			continue
+		}
+		if amt, err = conv.toBase(row.Currency, amt); err != nil {
+			return SelfEmploymentTaxEstimate{}, err
+		}
+		q := taxQuarter(row.Date.Time)
+		perQuarter[q-1] = perQuarter[q-1].Add(amt)
+		total = total.Add(amt)
+	}
+	for q := 1; q <= 4; q++ {
+		start, end, due := taxQuarterDates(year, q)
+		est.Quarters = append(est.Quarters, EstimatedTaxQuarter{
+			Quarter: q,
+			Start:   start,
+			End:     end,
+			DueDate: due,
+			Income:  perQuarter[q-1].Float64(),
+			Tax:     perQuarter[q-1].Mul(rate).Float64(),
+		})
+	}
+
+	yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	if asOf.Before(yearStart) {
+		return est, nil
+	}
+	elapsed := asOf.Sub(yearStart).Hours()/24 + 1
+	days := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC).Sub(yearStart).Hours()/24 + 1
+	projected := total.Mul(days / elapsed)
+	projectedTax := projected.Mul(rate)
+
+	est.IncomeToDate = total.Float64()
+	est.TaxToDate = total.Mul(rate).Float64()
+	est.ProjectedIncome = projected.Float64()
+	est.ProjectedTax = projectedTax.Float64()
+	est.QuarterlyPayment = projectedTax.Mul(0.25).Float64()
+	return est, nil
+}
+
+// GenerateEstimatedPayments sets up a yearly recurring expense for each
+// estimated tax due date of year, paying QuarterlyPayment from
+// EstimateSelfEmploymentTax. They are named "Estimated tax Q1" to "Q4";
+// running it again updates those recurrings rather than adding more.
+func (fs *FinanceService) GenerateEstimatedPayments(ctx context.Context, year int) ([]Recurring, error) {
+	est, err := fs.EstimateSelfEmploymentTax(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+	if est.QuarterlyPayment <= 0 {
+		return nil, fmt.Errorf("%w in %d", ErrNoSelfEmploymentIncome, year)
+	}
+	currency, err := fs.resolveCurrency(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	existing, err := fs.db.ListRecurring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byDescription := make(map[string]Recurring, len(existing))
+	for _, r := range existing {
+		byDescription[r.Description] = r
+	}
+
+	out := make([]Recurring, 0, 4)
+	for q := 1; q <= 4; q++ {
+		_, _, due := taxQuarterDates(year, q)
+		params := database.CreateRecurringParams{
+			Description: fmt.Sprintf("Estimated tax Q%d", q),
+			Type:        "expense",
+			Amount:      makePgNumeric(est.QuarterlyPayment),
+			StartDate:   makePgDate(due),
+			Interval:    database.RecurrenceIntervalYearly,
+			DayOfMonth:  pgtype.Int4{Int32: int32(due.Day()), Valid: true},
+			Active:      true,
+			Currency:    currency,
+		}
+		before, ok := byDescription[params.Description]
+		if !ok {
+			rec, err := fs.CreateRecurring(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, rec)
+			continue
+		}
+		rec, err := fs.db.UpdateRecurring(ctx, database.UpdateRecurringParams{
+			Description: params.Description,
+			Type:        params.Type,
+			Amount:      params.Amount,
+			StartDate:   params.StartDate,
+			Interval:    params.Interval,
+			DayOfMonth:  params.DayOfMonth,
+			Active:      params.Active,
+			Currency:    params.Currency,
+			ID:          before.ID,
+			Version:     before.Version,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, lostRace(EntityRecurring, before.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fs.audit(ctx, AuditUpdate, EntityRecurring, rec.ID, before, rec)
+		fs.events.Publish(ctx, EventRecurringUpdated, rec)
+		out = append(out, rec)
+	}
+	return out, nil
+}
+
+// taxQuarter returns the estimated tax period, 1 to 4, that d falls in.
+func taxQuarter(d time.Time) int {
+	q := 1
+	for i, m := range taxQuarterStarts {
+		if d.Month() >= m {
+			q = i + 1
+		}
+	}
+	return q
+}
+
+// taxQuarterDates returns the first and last days of an estimated tax
+// period and the day its payment is due: the 15th of the month after it
+// ends, which for the fourth is January of the next year.
+func taxQuarterDates(year, q int) (start, end, due time.Time) {
+	start = time.Date(year, taxQuarterStarts[q-1], 1, 0, 0, 0, 0, time.UTC)
+	if q == 4 {
+		end = time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	} else {
+		end = time.Date(year, taxQuarterStarts[q], 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	}
+	due = time.Date(year, end.Month()+1, 15, 0, 0, 0, 0, time.UTC)
+	return start, end, due
+}
+
+// setTaxReserve fills in TaxReserve on each forecast day when a
+// self-employment tax rate is set.
+func (fs *FinanceService) setTaxReserve(ctx context.Context, forecast []DailyCashFlow, start time.Time) error {
+	rate, ok := fs.selfEmploymentRate(ctx)
+	if !ok {
+		return nil
+	}
+	// Income waits at most from September 1 until January 15 for its
+	// payment to come due.
+	income, err := fs.db.GetSelfEmploymentIncomeBetween(ctx, database.GetSelfEmploymentIncomeBetweenParams{
+		StartDate: makePgDate(start.AddDate(0, -5, 0)),
+		EndDate:   makePgDate(start.AddDate(0, 0, len(forecast)-1)),
+	})
+	if err != nil {
+		return err
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return err
+	}
+	reserve, err := taxReserve(income, rate, start, len(forecast), conv)
+	if err != nil {
+		return err
+	}
+	for i := range forecast {
+		r := reserve[i].Float64()
+		forecast[i].TaxReserve = &r
+	}
+	return nil
+}
+
+// taxReserve returns, for each of the days days from start, the tax owed
+// on self-employment income received by then whose estimated payment isn't
+// due yet. On the due date the payment goes out and the reserve with it.
+func taxReserve(income []database.GetSelfEmploymentIncomeBetweenRow, rate float64, start time.Time, days int, conv converter) ([]money.Money, error) {
+	out := make([]money.Money, days)
+	for _, row := range income {
+		amt, err := NumericToMoney(row.Amount)
+		if err != nil {
+			continue
+		}
+		if amt, err = conv.toBase(row.Currency, amt); err != nil {
+			return nil, err
+		}
+		tax := amt.Mul(rate)
+		received := truncateDay(row.Date.Time.UTC())
+		_, _, due := taxQuarterDates(received.Year(), taxQuarter(received))
+		for i := range out {
+			if d := start.AddDate(0, 0, i); !d.Before(received) && d.Before(due) {
+				out[i] = out[i].Add(tax)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaxQuarters(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		require.NoError(t, err)
+		return d
+	}
+	for _, tt := range []struct {
+		date            string
+		quarter         int
+		start, end, due string
+	}{
+		{"2025-03-31", 1, "2025-01-01", "2025-03-31", "2025-04-15"},
+		{"2025-04-01", 2, "2025-04-01", "2025-05-31", "2025-06-15"},
+		{"2025-08-31", 3, "2025-06-01", "2025-08-31", "2025-09-15"},
+		{"2025-12-31", 4, "2025-09-01", "2025-12-31", "2026-01-15"},
+	} {
+		q := taxQuarter(day(tt.date))
+		assert.Equal(t, tt.quarter, q, tt.date)
+		start, end, due := taxQuarterDates(2025, q)
+		assert.Equal(t, day(tt.start), start)
+		assert.Equal(t, day(tt.end), end)
+		assert.Equal(t, day(tt.due), due)
+	}
+}
+
+func TestTaxReserve(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		require.NoError(t, err)
+		return d
+	}
+	income := func(date string, amount float64, currency string) database.GetSelfEmploymentIncomeBetweenRow {
+		return database.GetSelfEmploymentIncomeBetweenRow{Date: makePgDate(day(date)), Amount: makePgNumeric(amount), Currency: currency}
+	}
+	conv := converter{base: "USD", rates: map[string]float64{"EUR": 2}}
+	rows := []database.GetSelfEmploymentIncomeBetweenRow{
+		income("2025-03-20", 1000, ""),
+		income("2025-04-10", 100, "EUR"),
+	}
+
+	start := day("2025-03-19")
+	reserve, err := taxReserve(rows, 0.25, start, 100, conv)
+	require.NoError(t, err)
+	at := func(s string) money.Money { return reserve[int(day(s).Sub(start).Hours()/24)] }
+
+	assert.Equal(t, money.Money(0), at("2025-03-19"))
+	assert.Equal(t, money.FromFloat(250), at("2025-03-20"))
+	assert.Equal(t, money.FromFloat(300), at("2025-04-14"))
+	assert.Equal(t, money.FromFloat(50), at("2025-04-15"), "first quarter paid")
+	assert.Equal(t, money.FromFloat(50), at("2025-06-14"))
+	assert.Equal(t, money.Money(0), at("2025-06-15"))
+
+	_, err = taxReserve([]database.GetSelfEmploymentIncomeBetweenRow{income("2025-03-20", 5, "JPY")}, 0.25, start, 1, conv)
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+}
+
+func TestEstimateSelfEmploymentTax(t *testing.T) {
+	conv := converter{base: "USD"}
+	rows := []database.GetSelfEmploymentIncomeBetweenRow{
+		{Date: makePgDate(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)), Amount: makePgNumeric(3000)},
+		{Date: makePgDate(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)), Amount: makePgNumeric(1000)},
+	}
+	asOf := time.Date(2025, 7, 2, 0, 0, 0, 0, time.UTC) // day 183 of 365
+	est, err := estimateSelfEmploymentTax(2025, 0.3, asOf, rows, conv)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4000.0, est.IncomeToDate)
+	assert.Equal(t, 1200.0, est.TaxToDate)
+	assert.InDelta(t, 4000*365/183.0, est.ProjectedIncome, 0.01)
+	assert.InDelta(t, est.ProjectedTax/4, est.QuarterlyPayment, 0.01)
+	assert.Equal(t, 900.0, est.Quarters[0].Tax)
+	assert.Equal(t, 1000.0, est.Quarters[1].Income)
+	assert.Equal(t, 0.0, est.Quarters[2].Income)
+
+	before, err := estimateSelfEmploymentTax(2026, 0.3, asOf, nil, conv)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, before.ProjectedTax, "year hasn't started")
+	assert.Len(t, before.Quarters, 4)
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of the settings the settings API reads and writes. Budgets have
+// their own endpoints and a key per category, so they aren't among them.
+const (
+	SettingStartingBalance       = "starting_balance"
+	SettingLowBalanceThreshold   = lowBalanceSetting
+	SettingTimezone              = timezoneSetting
+	SettingBaseCurrency          = baseCurrencyKey
+	SettingTaxRate               = taxRateSetting
+	SettingSelfEmploymentRate    = selfEmploymentRateSetting
+	SettingForecastDays          = "forecast_days"
+	SettingLargeBillThreshold    = "large_bill_threshold"
+	SettingRetentionYears        = "retention_years"
+	SettingNotificationMinChange = "notification_min_change"
+)
+
+// SettingKeys lists the keys in Settings, in the order they appear there.
+var SettingKeys = []string{
+	SettingStartingBalance, SettingLowBalanceThreshold, SettingTimezone,
+	SettingBaseCurrency, SettingTaxRate, SettingSelfEmploymentRate,
+	SettingForecastDays, SettingLargeBillThreshold, SettingRetentionYears,
+	SettingNotificationMinChange,
+}
+
+const (
+	// DefaultForecastDays is the forecast horizon until one is set.
+	DefaultForecastDays = 90
+	// MaxForecastDays bounds the horizon of a single forecast.
+	MaxForecastDays = 730
+)
+
+var (
+	ErrUnknownSetting = errors.New("unknown setting")
+	ErrInvalidSetting = errors.New("invalid setting")
+)
+
+// Settings is every user preference with its current value, defaults
+// filled in. The pointers are nil when the feature they enable is off.
+type Settings struct {
+	StartingBalance       float64  `json:"starting_balance"`
+	LowBalanceThreshold   *float64 `json:"low_balance_threshold"`
+	Timezone              string   `json:"timezone"`
+	BaseCurrency          string   `json:"base_currency"`
+	TaxRate               *float64 `json:"tax_rate"`
+	SelfEmploymentRate    *float64 `json:"self_employment_tax_rate"`
+	ForecastDays          int      `json:"forecast_days"`
+	LargeBillThreshold    *float64 `json:"large_bill_threshold"`
+	RetentionYears        *int     `json:"retention_years"`
+	NotificationMinChange *float64 `json:"notification_min_change"`
+}
+
+// SettingsUpdate changes the settings that are non-nil and leaves the rest.
+// Use ResetSetting to turn a setting back to its default.
+type SettingsUpdate struct {
+	StartingBalance       *float64 `json:"starting_balance,omitempty"`
+	LowBalanceThreshold   *float64 `json:"low_balance_threshold,omitempty"`
+	Timezone              *string  `json:"timezone,omitempty"`
+	BaseCurrency          *string  `json:"base_currency,omitempty"`
+	TaxRate               *float64 `json:"tax_rate,omitempty"`
+	SelfEmploymentRate    *float64 `json:"self_employment_tax_rate,omitempty"`
+	ForecastDays          *int     `json:"forecast_days,omitempty"`
+	LargeBillThreshold    *float64 `json:"large_bill_threshold,omitempty"`
+	RetentionYears        *int     `json:"retention_years,omitempty"`
+	NotificationMinChange *float64 `json:"notification_min_change,omitempty"`
+}
+
+// Settings reads every setting at once.
+func (fs *FinanceService) Settings(ctx context.Context) (Settings, error) {
+	var s Settings
+	var err error
+	if s.StartingBalance, err = fs.GetStartingBalance(ctx); err != nil {
+		return Settings{}, err
+	}
+	threshold, ok, err := fs.LowBalanceThreshold(ctx)
+	if err != nil {
+		return Settings{}, err
+	}
+	if ok {
+		s.LowBalanceThreshold = &threshold
+	}
+	if s.Timezone, err = fs.Timezone(ctx); err != nil {
+		return Settings{}, err
+	}
+	if s.BaseCurrency, err = fs.BaseCurrency(ctx); err != nil {
+		return Settings{}, err
+	}
+	if rate, ok := fs.taxRate(ctx); ok {
+		s.TaxRate = &rate
+	}
+	if rate, ok := fs.selfEmploymentRate(ctx); ok {
+		s.SelfEmploymentRate = &rate
+	}
+	s.ForecastDays = fs.ForecastDays(ctx)
+	if threshold, ok := fs.LargeBillThreshold(ctx); ok {
+		s.LargeBillThreshold = &threshold
+	}
+	if years, ok := fs.RetentionYears(ctx); ok {
+		s.RetentionYears = &years
+	}
+	if change, ok := fs.NotificationMinChange(ctx); ok {
+		s.NotificationMinChange = &change
+	}
+	return s, nil
+}
+
+// UpdateSettings applies in and returns the settings after the change.
+// Every value is checked before any is written, so a bad one leaves all
+// the settings as they were.
+func (fs *FinanceService) UpdateSettings(ctx context.Context, in SettingsUpdate) (Settings, error) {
+	if err := in.validate(); err != nil {
+		return Settings{}, err
+	}
+	if in.StartingBalance != nil {
+		if err := fs.SetStartingBalance(ctx, *in.StartingBalance); err != nil {
+			return Settings{}, err
+		}
+	}
+	if in.LowBalanceThreshold != nil {
+		if err := fs.SetLowBalanceThreshold(ctx, *in.LowBalanceThreshold); err != nil {
+			return Settings{}, err
+		}
+	}
+	if in.Timezone != nil {
+		if err := fs.SetTimezone(ctx, *in.Timezone); err != nil {
+			return Settings{}, err
+		}
+	}
+	if in.BaseCurrency != nil {
+		if err := fs.SetBaseCurrency(ctx, *in.BaseCurrency); err != nil {
+			return Settings{}, err
+		}
+	}
+	if in.TaxRate != nil {
+		if err := fs.SetTaxRate(ctx, *in.TaxRate); err != nil {
+			return Settings{}, err
+		}
+	}
+	if in.SelfEmploymentRate != nil {
+		if err := fs.SetSelfEmploymentTaxRate(ctx, *in.SelfEmploymentRate); err != nil {
+			return Settings{}, err
+		}
+	}
+	if in.ForecastDays != nil {
+		if err := fs.SetForecastDays(ctx, *in.ForecastDays); err != nil {
+			return Settings{}, err
+		}
+	}
+	if in.LargeBillThreshold != nil {
+		if err := fs.SetLargeBillThreshold(ctx, *in.LargeBillThreshold); err != nil {
+			return Settings{}, err
+		}
+	}
+	if in.RetentionYears != nil {
+		if err := fs.SetRetentionYears(ctx, *in.RetentionYears); err != nil {
+			return Settings{}, err
+		}
+	}
+	if in.NotificationMinChange != nil {
+		if err := fs.SetNotificationMinChange(ctx, *in.NotificationMinChange); err != nil {
+			return Settings{}, err
+		}
+	}
+	return fs.Settings(ctx)
+}
+
+func (in SettingsUpdate) validate() error {
+	if in.Timezone != nil {
+		name := strings.TrimSpace(*in.Timezone)
+		if _, err := time.LoadLocation(name); err != nil || name == "" {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSetting, *in.Timezone)
+		}
+	}
+	if in.BaseCurrency != nil {
+		if _, err := NormalizeCurrency(*in.BaseCurrency); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSetting, err)
+		}
+	}
+	if in.TaxRate != nil && (*in.TaxRate < 0 || *in.TaxRate >= 1) {
+		return fmt.Errorf("%w: tax rate %.4f (expected 0 <= rate < 1)", ErrInvalidSetting, *in.TaxRate)
+	}
+	if in.SelfEmploymentRate != nil && (*in.SelfEmploymentRate < 0 || *in.SelfEmploymentRate >= 1) {
+		return fmt.Errorf("%w: self-employment tax rate %.4f (expected 0 <= rate < 1)", ErrInvalidSetting, *in.SelfEmploymentRate)
+	}
+	if in.ForecastDays != nil && (*in.ForecastDays < 1 || *in.ForecastDays > MaxForecastDays) {
+		return fmt.Errorf("%w: forecast days %d (expected 1-%d)", ErrInvalidSetting, *in.ForecastDays, MaxForecastDays)
+	}
+	if in.LargeBillThreshold != nil && *in.LargeBillThreshold <= 0 {
+		return fmt.Errorf("%w: large bill threshold must be positive", ErrInvalidSetting)
+	}
+	if in.RetentionYears != nil && *in.RetentionYears < MinRetentionYears {
+		return fmt.Errorf("%w: retention years %d (expected at least %d)", ErrInvalidSetting, *in.RetentionYears, MinRetentionYears)
+	}
+	if in.NotificationMinChange != nil && *in.NotificationMinChange <= 0 {
+		return fmt.Errorf("%w: notification min change must be positive", ErrInvalidSetting)
+	}
+	return nil
+}
+
+// SettingDefaults are the settings a household has until it sets its own,
+// in place of the built-in ones, as from a config file. Zero fields keep the
+// built-in default.
+type SettingDefaults struct {
+	Timezone              string   `json:"timezone"`
+	BaseCurrency          string   `json:"base_currency"`
+	LowBalanceThreshold   *float64 `json:"low_balance_threshold"`
+	LargeBillThreshold    *float64 `json:"large_bill_threshold"`
+	ForecastDays          int      `json:"forecast_days"`
+	NotificationMinChange *float64 `json:"notification_min_change"`
+}
+
+// SetSettingDefaults replaces the built-in defaults with d, after checking
+// each value as UpdateSettings would. It is safe to call while requests
+// are served.
+func (fs *FinanceService) SetSettingDefaults(d SettingDefaults) error {
+	in := SettingsUpdate{
+		LowBalanceThreshold:   d.LowBalanceThreshold,
+		LargeBillThreshold:    d.LargeBillThreshold,
+		NotificationMinChange: d.NotificationMinChange,
+	}
+	if d.Timezone != "" {
+		in.Timezone = &d.Timezone
+	}
+	if d.BaseCurrency != "" {
+		in.BaseCurrency = &d.BaseCurrency
+	}
+	if d.ForecastDays != 0 {
+		in.ForecastDays = &d.ForecastDays
+	}
+	if err := in.validate(); err != nil {
+		return err
+	}
+	if d.BaseCurrency != "" {
+		d.BaseCurrency, _ = NormalizeCurrency(d.BaseCurrency)
+	}
+	d.Timezone = strings.TrimSpace(d.Timezone)
+	fs.defaults.Store(&d)
+	fs.forecasts.invalidate()
+	return nil
+}
+
+// settingDefaults returns the defaults SetSettingDefaults last set.
+func (fs *FinanceService) settingDefaults() SettingDefaults {
+	if d := fs.defaults.Load(); d != nil {
+		return *d
+	}
+	return SettingDefaults{}
+}
+
+// ResetSetting turns key back to its default, as if it had never been set.
+func (fs *FinanceService) ResetSetting(ctx context.Context, key string) error {
+	switch key {
+	case SettingStartingBalance:
+		if err := fs.deleteSetting(ctx, key); err != nil {
+			return err
+		}
+		fs.events.Publish(ctx, EventBalanceChanged, map[string]float64{"balance": 0})
+	case SettingLowBalanceThreshold:
+		return fs.ClearLowBalanceThreshold(ctx)
+	case SettingBaseCurrency:
+		if err := fs.deleteSetting(ctx, key); err != nil {
+			return err
+		}
+		base, err := fs.BaseCurrency(ctx)
+		if err != nil {
+			return err
+		}
+		fs.events.Publish(ctx, EventCurrencyChanged, map[string]string{"base_currency": base})
+	case SettingTimezone, SettingTaxRate, SettingSelfEmploymentRate, SettingForecastDays, SettingLargeBillThreshold, SettingRetentionYears, SettingNotificationMinChange:
+		if err := fs.deleteSetting(ctx, key); err != nil {
+			return err
+		}
+		fs.events.Publish(ctx, EventSettingsChanged, map[string]any{key: nil})
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownSetting, key)
+	}
+	return nil
+}
+
+// ForecastDays is how many days a forecast covers when the caller doesn't
+// say.
+func (fs *FinanceService) ForecastDays(ctx context.Context) int {
+	def := DefaultForecastDays
+	if d := fs.settingDefaults().ForecastDays; d != 0 {
+		def = d
+	}
+	value, err := fs.db.GetSetting(ctx, SettingForecastDays)
+	if err != nil {
+		return def
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 || days > MaxForecastDays {
+		return def
+	}
+	return days
+}
+
+func (fs *FinanceService) SetForecastDays(ctx context.Context, days int) error {
+	if days < 1 || days > MaxForecastDays {
+		return fmt.Errorf("invalid forecast days %d (expected 1-%d)", days, MaxForecastDays)
+	}
+	if err := fs.updateSetting(ctx, SettingForecastDays, strconv.Itoa(days)); err != nil {
+		return err
+	}
+	fs.events.Publish(ctx, EventSettingsChanged, map[string]int{SettingForecastDays: days})
+	return nil
+}
+
+// deleteSetting removes key, recording the old value in the audit log. A
+// key that isn't set is left alone.
+func (fs *FinanceService) deleteSetting(ctx context.Context, key string) error {
+	prev, err := fs.db.GetSetting(ctx, key)
+	if err != nil {
+		return nil
+	}
+	if err := fs.db.DeleteSetting(ctx, key); err != nil {
+		return err
+	}
+	fs.audit(ctx, AuditDelete, EntitySetting, key, map[string]string{"key": key, "value": prev}, nil)
+	return nil
+}
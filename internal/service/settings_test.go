@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/memdb"
+)
+
+// settingsDB keeps settings in a map and counts audit entries; any other
+// query panics.
+type settingsDB struct {
+	database.Querier
+	values map[string]string
+	audits int
+}
+
+func (db *settingsDB) GetSetting(_ context.Context, key string) (string, error) {
+	v, ok := db.values[key]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return v, nil
+}
+
+func (db *settingsDB) UpdateSetting(_ context.Context, arg database.UpdateSettingParams) error {
+	db.values[arg.Key] = arg.Value
+	return nil
+}
+
+func (db *settingsDB) DeleteSetting(_ context.Context, key string) error {
+	delete(db.values, key)
+	return nil
+}
+
+func (db *settingsDB) CreateAuditEntry(context.Context, database.CreateAuditEntryParams) error {
+	db.audits++
+	return nil
+}
+
+func TestSettings(t *testing.T) {
+	ctx := context.Background()
+	db := &settingsDB{values: map[string]string{}}
+	fs := NewFinanceService(db)
+
+	s, err := fs.Settings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Settings{Timezone: "UTC", BaseCurrency: DefaultCurrency, ForecastDays: DefaultForecastDays}, s)
+
+	balance, threshold, rate, days, zone, cur := 1500.0, 200.0, 0.24, 45, "America/Chicago", "eur"
+	s, err = fs.UpdateSettings(ctx, SettingsUpdate{
+		StartingBalance: &balance, LowBalanceThreshold: &threshold, TaxRate: &rate,
+		ForecastDays: &days, Timezone: &zone, BaseCurrency: &cur,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1500.0, s.StartingBalance)
+	assert.Equal(t, &threshold, s.LowBalanceThreshold)
+	assert.Equal(t, &rate, s.TaxRate)
+	assert.Equal(t, 45, s.ForecastDays)
+	assert.Equal(t, "America/Chicago", s.Timezone)
+	assert.Equal(t, "EUR", s.BaseCurrency)
+	assert.Equal(t, 6, db.audits)
+
+	for key := range db.values {
+		require.NoError(t, fs.ResetSetting(ctx, key))
+	}
+	s, err = fs.Settings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Settings{Timezone: "UTC", BaseCurrency: DefaultCurrency, ForecastDays: DefaultForecastDays}, s)
+	assert.ErrorIs(t, fs.ResetSetting(ctx, "theme"), ErrUnknownSetting)
+}
+
+func TestUpdateSettingsValidatesFirst(t *testing.T) {
+	ctx := context.Background()
+	db := &settingsDB{values: map[string]string{}}
+	fs := NewFinanceService(db)
+
+	balance, days := 900.0, 0
+	_, err := fs.UpdateSettings(ctx, SettingsUpdate{StartingBalance: &balance, ForecastDays: &days})
+	assert.ErrorIs(t, err, ErrInvalidSetting)
+	assert.Empty(t, db.values, "nothing written when one value is bad")
+
+	for _, in := range []SettingsUpdate{
+		{Timezone: ptr("Mars/Olympus")},
+		{Timezone: ptr(" ")},
+		{BaseCurrency: ptr("dollars")},
+		{TaxRate: ptr(1.0)},
+		{ForecastDays: ptr(MaxForecastDays + 1)},
+	} {
+		_, err := fs.UpdateSettings(ctx, in)
+		assert.ErrorIs(t, err, ErrInvalidSetting)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestSettingDefaults(t *testing.T) {
+	ctx := context.Background()
+	db := &settingsDB{values: map[string]string{}}
+	fs := NewFinanceService(db)
+
+	assert.ErrorIs(t, fs.SetSettingDefaults(SettingDefaults{Timezone: "Mars/Olympus"}), ErrInvalidSetting)
+	assert.ErrorIs(t, fs.SetSettingDefaults(SettingDefaults{ForecastDays: -1}), ErrInvalidSetting)
+
+	threshold := 250.0
+	require.NoError(t, fs.SetSettingDefaults(SettingDefaults{
+		Timezone: "Europe/Berlin", BaseCurrency: "eur", LowBalanceThreshold: &threshold, ForecastDays: 60,
+	}))
+	s, err := fs.Settings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", s.Timezone)
+	assert.Equal(t, "EUR", s.BaseCurrency)
+	assert.Equal(t, &threshold, s.LowBalanceThreshold)
+	assert.Equal(t, 60, s.ForecastDays)
+	assert.Nil(t, s.LargeBillThreshold)
+
+	// A household's own setting wins, and resetting it brings the default
+	// back.
+	zone := "America/Chicago"
+	s, err = fs.UpdateSettings(ctx, SettingsUpdate{Timezone: &zone})
+	require.NoError(t, err)
+	assert.Equal(t, zone, s.Timezone)
+	require.NoError(t, fs.ResetSetting(ctx, SettingTimezone))
+	tz, err := fs.Timezone(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", tz)
+}
+
+// TestReloadSettingDefaults swaps the defaults as a config file reload
+// does, and records the change.
+func TestReloadSettingDefaults(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	require.NoError(t, fs.SetSettingDefaults(SettingDefaults{ForecastDays: 60}))
+	assert.Equal(t, 60, fs.ForecastDays(ctx))
+
+	threshold := 500.0
+	next := SettingDefaults{ForecastDays: 30, LargeBillThreshold: &threshold}
+	require.NoError(t, fs.SetSettingDefaults(next))
+	fs.RecordConfigChange(ctx, "defaults", SettingDefaults{ForecastDays: 60}, next)
+	assert.Equal(t, 30, fs.ForecastDays(ctx))
+	got, ok := fs.LargeBillThreshold(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, threshold, got)
+
+	entries, err := fs.ListAudit(ctx, AuditFilter{Entity: EntityConfig})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "config", entries[0].Actor)
+	assert.Equal(t, AuditUpdate, entries[0].Action)
+	assert.Equal(t, "defaults", entries[0].EntityID)
+	assert.JSONEq(t, `{"timezone":"","base_currency":"","low_balance_threshold":null,"large_bill_threshold":null,"forecast_days":60,"notification_min_change":null}`, string(entries[0].Before))
+}
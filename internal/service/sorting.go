@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/database"
+)
+
+// Keys a list can be sorted on.
+const (
+	SortDate        = "date"
+	SortAmount      = "amount"
+	SortDescription = "description"
+)
+
+// ErrInvalidSort is returned for a sort other than date, amount or
+// description, or an order other than asc or desc.
+var ErrInvalidSort = errors.New("invalid sort")
+
+// MaxPageLimit is the most rows one page of a sorted list can ask for.
+const MaxPageLimit = 500
+
+// ErrInvalidPage is returned for a cursor that doesn't belong to the list
+// and order asked for, or for paging in an order that can't be paged.
+var ErrInvalidPage = errors.New("invalid page")
+
+// Page asks for one page of a sorted list. The zero value asks for all of
+// it.
+type Page struct {
+	// Limit is the most rows to return; zero returns them all.
+	Limit int
+	// After is the cursor the page before returned, or empty for the first
+	// page.
+	After string
+}
+
+// ListOrder is the order a list comes back in. Rows with the same key are
+// ordered by ID, in the same direction. The zero value is each list's
+// usual order: by date for transactions and by ID for recurrings.
+type ListOrder struct {
+	Sort string
+	Desc bool
+}
+
+// ParseListOrder reads the sort and order parameters of a list endpoint.
+// Either may be empty; an order on its own reverses the usual order.
+func ParseListOrder(sort, order string) (ListOrder, error) {
+	var o ListOrder
+	switch sort {
+	case "", SortDate, SortAmount, SortDescription:
+		o.Sort = sort
+	default:
+		return ListOrder{}, fmt.Errorf("%w: sort %q (expected date, amount or description)", ErrInvalidSort, sort)
+	}
+	switch order {
+	case "", "asc":
+	case "desc":
+		o.Desc = true
+	default:
+		return ListOrder{}, fmt.Errorf("%w: order %q (expected asc or desc)", ErrInvalidSort, order)
+	}
+	return o, nil
+}
+
+// ListTransactions lists the live transactions in order, only those with
+// status unless it is empty. Amounts sort with their signs, so the biggest
+// expenses come first in ascending order. next is the cursor for the page
+// after this one, or empty when this is the last.
+func (fs *FinanceService) ListTransactions(ctx context.Context, status string, order ListOrder, page Page) (txs []Transaction, next string, err error) {
+	if order.Sort == "" {
+		order.Sort = SortDate
+	}
+	keys, err := fs.pageKeys(order, page)
+	if err != nil {
+		return nil, "", err
+	}
+	arg := database.ListTransactionsSortedParams{
+		SortKey:          order.Sort,
+		Descending:       order.Desc,
+		AfterDate:        keys.AfterDate,
+		AfterID:          keys.AfterID,
+		AfterAmount:      keys.AfterAmount,
+		AfterDescription: keys.AfterDescription,
+		PageSize:         keys.PageSize,
+	}
+	if status != "" {
+		status, err := normalizeStatus(status)
+		if err != nil {
+			return nil, "", err
+		}
+		arg.Status = pgtype.Text{String: status, Valid: true}
+	}
+	txs, err = fs.db.ListTransactionsSorted(ctx, arg)
+	if err != nil {
+		return nil, "", err
+	}
+	if page.Limit <= 0 || len(txs) <= page.Limit {
+		return txs, "", nil
+	}
+	txs = txs[:page.Limit]
+	last := txs[len(txs)-1]
+	return txs, encodeCursor(order, last.Date, last.Amount, last.Description, last.ID), nil
+}
+
+// ListRecurringSummaries is RecurringSummaries in order, a page at a time
+// as ListTransactions is. Sorting on date sorts on the start date, and
+// amounts sort without signs.
+func (fs *FinanceService) ListRecurringSummaries(ctx context.Context, order ListOrder, page Page) (summaries []RecurringSummary, next string, err error) {
+	keys, err := fs.pageKeys(order, page)
+	if err != nil {
+		return nil, "", err
+	}
+	rs, err := fs.db.ListRecurringSorted(ctx, database.ListRecurringSortedParams{
+		SortKey:          order.Sort,
+		Descending:       order.Desc,
+		AfterDate:        keys.AfterDate,
+		AfterID:          keys.AfterID,
+		AfterAmount:      keys.AfterAmount,
+		AfterDescription: keys.AfterDescription,
+		PageSize:         keys.PageSize,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if page.Limit > 0 && len(rs) > page.Limit {
+		rs = rs[:page.Limit]
+		last := rs[len(rs)-1]
+		next = encodeCursor(order, last.StartDate, last.Amount, last.Description, last.ID)
+	}
+	return summarizeRecurrings(rs, fs.Today(ctx)), next, nil
+}
+
+// listCursor is what a page cursor holds: the order it was made for and
+// the sort key and ID of the last row on its page.
+type listCursor struct {
+	Sort string          `json:"sort"`
+	Desc bool            `json:"desc,omitempty"`
+	Key  json.RawMessage `json:"key,omitempty"`
+	ID   int32           `json:"id"`
+}
+
+// pageArgs are the keyset arguments of the sorted list queries.
+type pageArgs struct {
+	AfterDate        pgtype.Date
+	AfterID          pgtype.Int4
+	AfterAmount      pgtype.Numeric
+	AfterDescription pgtype.Text
+	PageSize         pgtype.Int4
+}
+
+// pageKeys turns page into the query arguments for a list in order. One
+// more row than the limit is asked for, to tell whether another page
+// follows.
+func (fs *FinanceService) pageKeys(order ListOrder, page Page) (pageArgs, error) {
+	var args pageArgs
+	if page.Limit < 0 || page.Limit > MaxPageLimit {
+		return args, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidPage, MaxPageLimit)
+	}
+	paged := page.Limit > 0 || page.After != ""
+	// The database orders encrypted descriptions by their ciphertext, so
+	// they are sorted again once decrypted; that only works on the whole
+	// list.
+	if paged && order.Sort == SortDescription && fs.cipher != nil {
+		return args, fmt.Errorf("%w: descriptions are encrypted, so lists sorted on description can't be paged", ErrInvalidPage)
+	}
+	if page.Limit > 0 {
+		args.PageSize = pgtype.Int4{Int32: int32(page.Limit) + 1, Valid: true}
+	}
+	if page.After == "" {
+		return args, nil
+	}
+
+	invalid := fmt.Errorf("%w: cursor %q", ErrInvalidPage, page.After)
+	raw, err := base64.RawURLEncoding.DecodeString(page.After)
+	if err != nil {
+		return args, invalid
+	}
+	var c listCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return args, invalid
+	}
+	if c.Sort != order.Sort || c.Desc != order.Desc {
+		return args, fmt.Errorf("%w: cursor is for a different sort or order", ErrInvalidPage)
+	}
+	args.AfterID = pgtype.Int4{Int32: c.ID, Valid: true}
+	switch c.Sort {
+	case SortDate:
+		var d string
+		if err := json.Unmarshal(c.Key, &d); err != nil {
+			return args, invalid
+		}
+		if err := args.AfterDate.Scan(d); err != nil {
+			return args, invalid
+		}
+	case SortAmount:
+		if err := args.AfterAmount.UnmarshalJSON(c.Key); err != nil || !args.AfterAmount.Valid {
+			return args, invalid
+		}
+	case SortDescription:
+		if err := json.Unmarshal(c.Key, &args.AfterDescription.String); err != nil {
+			return args, invalid
+		}
+		args.AfterDescription.Valid = true
+	}
+	return args, nil
+}
+
+// encodeCursor makes the cursor for the page after the row with the given
+// sort keys and id.
+func encodeCursor(order ListOrder, date pgtype.Date, amount pgtype.Numeric, description string, id int32) string {
+	c := listCursor{Sort: order.Sort, Desc: order.Desc, ID: id}
+	switch order.Sort {
+	case SortDate:
+		c.Key, _ = json.Marshal(date.Time.Format("2006-01-02"))
+	case SortAmount:
+		c.Key, _ = amount.MarshalJSON()
+	case SortDescription:
+		c.Key, _ = json.Marshal(description)
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
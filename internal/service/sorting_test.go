@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jdelles/currentz/internal/fieldcrypt"
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListOrder(t *testing.T) {
+	order, err := ParseListOrder("", "")
+	require.NoError(t, err)
+	assert.Equal(t, ListOrder{}, order)
+
+	order, err = ParseListOrder(SortAmount, "desc")
+	require.NoError(t, err)
+	assert.Equal(t, ListOrder{Sort: SortAmount, Desc: true}, order)
+
+	_, err = ParseListOrder("category", "")
+	assert.ErrorIs(t, err, ErrInvalidSort)
+	_, err = ParseListOrder(SortDate, "DESC")
+	assert.ErrorIs(t, err, ErrInvalidSort)
+}
+
+func TestListSorted(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+
+	for _, in := range []RecurringInput{
+		{Description: "Rent", Type: "expense", Amount: 1200, StartDate: day("2025-01-01"), Interval: "monthly", Active: true},
+		{Description: "Gym", Type: "expense", Amount: 40, StartDate: day("2025-03-01"), Interval: "monthly", Active: true},
+		{Description: "Salary", Type: "income", Amount: 4200, StartDate: day("2025-02-01"), Interval: "monthly", Active: true},
+	} {
+		_, err := fs.CreateRecurringSimple(ctx, in)
+		require.NoError(t, err)
+	}
+	descriptions := func(order ListOrder) []string {
+		rs, _, err := fs.ListRecurringSummaries(ctx, order, Page{})
+		require.NoError(t, err)
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.Description)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"Rent", "Gym", "Salary"}, descriptions(ListOrder{}))
+	assert.Equal(t, []string{"Gym", "Salary", "Rent"}, descriptions(ListOrder{Sort: SortDate, Desc: true}))
+	assert.Equal(t, []string{"Gym", "Rent", "Salary"}, descriptions(ListOrder{Sort: SortAmount}))
+
+	require.NoError(t, fs.AddIncome(ctx, day("2025-03-01"), 100, "Refund", "USD"))
+	require.NoError(t, fs.AddIncome(ctx, day("2025-02-01"), 50, "Bonus", "USD"))
+	txs, _, err := fs.ListTransactions(ctx, "", ListOrder{Desc: true}, Page{})
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, "Refund", txs[0].Description, "latest first")
+	txs, _, err = fs.ListTransactions(ctx, StatusPending, ListOrder{}, Page{})
+	require.NoError(t, err)
+	assert.Empty(t, txs)
+	_, _, err = fs.ListTransactions(ctx, "bounced", ListOrder{}, Page{})
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+}
+
+func TestListPaged(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+
+	// Equal amounts and dates, so pages have to break ties on ID.
+	for _, desc := range []string{"Coffee", "Lunch", "Taxi", "Books", "Parking"} {
+		require.NoError(t, fs.AddExpense(ctx, day("2025-03-01"), 12, desc, "USD"))
+	}
+	require.NoError(t, fs.AddExpense(ctx, day("2025-02-01"), 80, "Shoes", "USD"))
+
+	for _, order := range []ListOrder{
+		{}, {Sort: SortAmount, Desc: true}, {Sort: SortDescription}, {Sort: SortDate, Desc: true},
+	} {
+		all, _, err := fs.ListTransactions(ctx, "", order, Page{})
+		require.NoError(t, err)
+
+		var paged []Transaction
+		page := Page{Limit: 4}
+		for {
+			txs, next, err := fs.ListTransactions(ctx, "", order, page)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(txs), page.Limit)
+			paged = append(paged, txs...)
+			if next == "" {
+				break
+			}
+			page.After = next
+		}
+		assert.Equal(t, all, paged, "%+v", order)
+	}
+
+	_, next, err := fs.ListTransactions(ctx, "", ListOrder{}, Page{Limit: 6})
+	require.NoError(t, err)
+	assert.Empty(t, next, "no cursor when the page holds the rest")
+
+	_, next, err = fs.ListTransactions(ctx, "", ListOrder{Sort: SortAmount}, Page{Limit: 1})
+	require.NoError(t, err)
+	_, _, err = fs.ListTransactions(ctx, "", ListOrder{Sort: SortAmount, Desc: true}, Page{Limit: 1, After: next})
+	assert.ErrorIs(t, err, ErrInvalidPage, "cursor from another order")
+	_, _, err = fs.ListTransactions(ctx, "", ListOrder{}, Page{After: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidPage)
+	_, _, err = fs.ListTransactions(ctx, "", ListOrder{}, Page{Limit: MaxPageLimit + 1})
+	assert.ErrorIs(t, err, ErrInvalidPage)
+
+	for _, desc := range []string{"Rent", "Gym", "Phone"} {
+		_, err := fs.CreateRecurringSimple(ctx, RecurringInput{
+			Description: desc, Type: "expense", Amount: 40, StartDate: day("2025-01-01"), Interval: "monthly", Active: true,
+		})
+		require.NoError(t, err)
+	}
+	order := ListOrder{Sort: SortAmount}
+	rs, next, err := fs.ListRecurringSummaries(ctx, order, Page{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, rs, 2)
+	require.NotEmpty(t, next)
+	rest, next, err := fs.ListRecurringSummaries(ctx, order, Page{Limit: 2, After: next})
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Empty(t, next)
+	assert.Equal(t, []string{"Rent", "Gym", "Phone"}, []string{rs[0].Description, rs[1].Description, rest[0].Description})
+}
+
+func TestListPagedEncryptedDescriptions(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	require.NoError(t, fs.SetEncryptionKey(bytes.Repeat([]byte{7}, fieldcrypt.KeySize)))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-03-01"), 12, "Coffee", "USD"))
+
+	_, _, err := fs.ListTransactions(ctx, "", ListOrder{Sort: SortDescription}, Page{Limit: 10})
+	assert.ErrorIs(t, err, ErrInvalidPage)
+	_, _, err = fs.ListRecurringSummaries(ctx, ListOrder{Sort: SortDescription}, Page{Limit: 10})
+	assert.ErrorIs(t, err, ErrInvalidPage)
+
+	txs, _, err := fs.ListTransactions(ctx, "", ListOrder{Sort: SortDescription}, Page{})
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "Coffee", txs[0].Description)
+	_, _, err = fs.ListTransactions(ctx, "", ListOrder{Sort: SortDate}, Page{Limit: 10})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jdelles/currentz/internal/storage/blob"
+)
+
+// DownloadURLExpiry is how long a presigned download link works.
+const DownloadURLExpiry = 15 * time.Minute
+
+// ErrStorageDisabled is returned by attachment and stored-backup methods
+// when no blob store is configured.
+var ErrStorageDisabled = errors.New("file storage is not configured")
+
+// SetBlobStore gives the service somewhere to keep files: attachments and
+// stored backups. Until it is called, those methods return
+// ErrStorageDisabled.
+func (fs *FinanceService) SetBlobStore(store blob.Store) {
+	fs.blobs = store
+}
+
+// blobStore is the store to keep files in, one that keeps nothing inside
+// DryRun.
+func (fs *FinanceService) blobStore(ctx context.Context) (blob.Store, error) {
+	if fs.blobs == nil {
+		return nil, ErrStorageDisabled
+	}
+	if IsDryRun(ctx) {
+		return discardStore{fs.blobs}, nil
+	}
+	return fs.blobs, nil
+}
+
+// downloadURL presigns a link to key if the store supports it, and
+// returns "" if it doesn't.
+func (fs *FinanceService) downloadURL(ctx context.Context, key string, opts blob.DownloadOptions) (string, error) {
+	p, ok := fs.blobs.(blob.Presigner)
+	if !ok {
+		return "", nil
+	}
+	return p.PresignGet(ctx, key, DownloadURLExpiry, opts)
+}
+
+// deleteBlob removes a file nothing refers to any more. A failure only
+// leaves an orphaned file behind, so it is logged rather than returned.
+func (fs *FinanceService) deleteBlob(ctx context.Context, key string) {
+	if fs.blobs == nil || IsDryRun(ctx) {
+		return
+	}
+	if err := fs.blobs.Delete(context.Background(), key); err != nil {
+		Logf(ctx, "delete blob %s: %v", key, err)
+	}
+}
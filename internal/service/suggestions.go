@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// ErrSuggestionNotFound is returned when accepting a suggestion that is no
+// longer offered, for example because it was already accepted.
+var ErrSuggestionNotFound = errors.New("recurring suggestion not found")
+
+// minPatternOccurrences is how many matching transactions it takes to
+// suggest a recurring.
+const minPatternOccurrences = 3
+
+// RecurringSuggestion is a recurring the transaction history looks like it
+// already follows. StartDate is the next expected occurrence, so accepting
+// it doesn't project copies of payments already recorded.
+type RecurringSuggestion struct {
+	ID             string    `json:"id"`
+	Description    string    `json:"description"`
+	Type           string    `json:"type"`
+	Amount         float64   `json:"amount"`
+	Currency       string    `json:"currency"`
+	Interval       string    `json:"interval"`
+	DayOfWeek      *int      `json:"day_of_week,omitempty"`
+	DayOfMonth     *int      `json:"day_of_month,omitempty"`
+	StartDate      time.Time `json:"start_date"`
+	Occurrences    int       `json:"occurrences"`
+	LastSeen       time.Time `json:"last_seen"`
+	TransactionIDs []int32   `json:"transaction_ids"`
+}
+
+// Input turns the suggestion into an active recurring.
+func (s RecurringSuggestion) Input() RecurringInput {
+	return RecurringInput{
+		Description: s.Description,
+		Type:        s.Type,
+		Amount:      s.Amount,
+		StartDate:   s.StartDate,
+		Interval:    s.Interval,
+		DayOfWeek:   s.DayOfWeek,
+		DayOfMonth:  s.DayOfMonth,
+		Active:      true,
+		Currency:    s.Currency,
+	}
+}
+
+// SuggestRecurring scans recorded transactions for ones that repeat with the
+// same description, a steady amount and a regular gap, and suggests a
+// recurring for each. Descriptions already covered by a recurring are left
+// out.
+func (fs *FinanceService) SuggestRecurring(ctx context.Context) ([]RecurringSuggestion, error) {
+	txs, err := fs.db.GetAllTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recs, err := fs.db.ListRecurring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return detectRecurring(txs, recs, fs.Today(ctx)), nil
+}
+
+// AcceptRecurringSuggestion creates the recurring a suggestion describes.
+func (fs *FinanceService) AcceptRecurringSuggestion(ctx context.Context, id string) (Recurring, error) {
+	suggestions, err := fs.SuggestRecurring(ctx)
+	if err != nil {
+		return Recurring{}, err
+	}
+	for _, s := range suggestions {
+		if s.ID == id {
+			return fs.CreateRecurringSimple(ctx, s.Input())
+		}
+	}
+	return Recurring{}, fmt.Errorf("%w: %s", ErrSuggestionNotFound, id)
+}
+
+// patternInterval is a schedule the detector can recognise, with the range
+// of day gaps between payments it accepts. step is the exact gap for
+// weekly schedules.
+type patternInterval struct {
+	name           string
+	min, max, step int
+}
+
+var patternIntervals = []patternInterval{
+	{"weekly", 6, 8, 7},
+	{"biweekly", 12, 16, 14},
+	{"monthly", 25, 35, 0},
+	{"yearly", 358, 372, 0},
+}
+
+func detectRecurring(txs []Transaction, existing []Recurring, today time.Time) []RecurringSuggestion {
+	covered := make(map[string]bool, len(existing))
+	for _, r := range existing {
+		covered[r.Type+"|"+patternKey(r.Description)] = true
+	}
+
+	groups := make(map[string][]Transaction)
+	for _, tx := range txs {
+		desc := patternKey(tx.Description)
+		if desc == "" || covered[tx.Type+"|"+desc] {
+			continue
+		}
+		key := tx.Type + "|" + desc + "|" + currencyOrDefault(tx.Currency)
+		groups[key] = append(groups[key], tx)
+	}
+
+	var out []RecurringSuggestion
+	for key, group := range groups {
+		if s, ok := suggestFromGroup(group, today); ok {
+			sum := sha1.Sum([]byte(key))
+			s.ID = hex.EncodeToString(sum[:6])
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Occurrences != out[j].Occurrences {
+			return out[i].Occurrences > out[j].Occurrences
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// suggestFromGroup checks whether transactions sharing a description follow
+// one schedule: every gap fits the same interval and every amount is within
+// 10% of the median.
+func suggestFromGroup(group []Transaction, today time.Time) (RecurringSuggestion, bool) {
+	if len(group) < minPatternOccurrences {
+		return RecurringSuggestion{}, false
+	}
+	sort.Slice(group, func(i, j int) bool { return group[i].Date.Time.Before(group[j].Date.Time) })
+
+	gaps := make([]int, len(group)-1)
+	for i := 1; i < len(group); i++ {
+		gaps[i-1] = int(group[i].Date.Time.Sub(group[i-1].Date.Time).Hours() / 24)
+	}
+	var ival patternInterval
+	found := false
+	for _, p := range patternIntervals {
+		fits := true
+		for _, g := range gaps {
+			if g < p.min || g > p.max {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			ival, found = p, true
+			break
+		}
+	}
+	if !found {
+		return RecurringSuggestion{}, false
+	}
+
+	amounts := make([]money.Money, len(group))
+	for i, tx := range group {
+		amounts[i] = numericOrZero(tx.Amount).Abs()
+	}
+	sorted := append([]money.Money(nil), amounts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+	if median == 0 {
+		return RecurringSuggestion{}, false
+	}
+	for _, a := range amounts {
+		if a.Sub(median).Abs() > median.Mul(0.1) {
+			return RecurringSuggestion{}, false
+		}
+	}
+
+	last := group[len(group)-1]
+	lastDay := truncateDay(last.Date.Time.UTC())
+	s := RecurringSuggestion{
+		Description: strings.TrimSpace(last.Description),
+		Type:        last.Type,
+		Amount:      median.Float64(),
+		Currency:    currencyOrDefault(last.Currency),
+		Interval:    ival.name,
+		Occurrences: len(group),
+		LastSeen:    lastDay,
+	}
+	for _, tx := range group {
+		s.TransactionIDs = append(s.TransactionIDs, tx.ID)
+	}
+
+	switch ival.name {
+	case "weekly", "biweekly":
+		dow := int(lastDay.Weekday())
+		s.DayOfWeek = &dow
+		s.StartDate = lastDay.AddDate(0, 0, ival.step)
+	case "monthly":
+		// Payments shifted off a weekend shouldn't move the schedule, so
+		// use the day of month seen most often.
+		seen := make(map[int]int)
+		dom := lastDay.Day()
+		for _, tx := range group {
+			d := tx.Date.Time.Day()
+			seen[d]++
+			if seen[d] > seen[dom] {
+				dom = d
+			}
+		}
+		s.DayOfMonth = &dom
+		next := time.Date(lastDay.Year(), lastDay.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		s.StartDate = dateAtDayOrMonthEnd(next.Year(), next.Month(), dom)
+	case "yearly":
+		dom := lastDay.Day()
+		s.DayOfMonth = &dom
+		s.StartDate = dateAtDayOrMonthEnd(lastDay.Year()+1, lastDay.Month(), dom)
+	}
+
+	// A pattern that missed two payments in a row has probably stopped.
+	if today.Sub(lastDay) > 2*time.Duration(ival.max)*24*time.Hour {
+		return RecurringSuggestion{}, false
+	}
+	return s, true
+}
+
+// patternKey normalises a description for grouping: case, digits and
+// punctuation are dropped, so "NETFLIX.COM 8842" and "Netflix.com 1193"
+// match.
+func patternKey(desc string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(desc) {
+		switch {
+		case unicode.IsLetter(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expense(id int32, date, desc string, amount float64) Transaction {
+	return Transaction{ID: id, Type: "expense", Date: makePgDate(day(date)), Description: desc, Amount: makePgNumeric(-amount), Currency: "USD"}
+}
+
+func TestDetectRecurring(t *testing.T) {
+	txs := []Transaction{
+		expense(1, "2025-03-01", "NETFLIX.COM 8842", 15.49),
+		expense(2, "2025-04-01", "Netflix.com 1193", 15.49),
+		expense(3, "2025-05-03", "NETFLIX.COM 7710", 15.49), // slid past a weekend
+		expense(4, "2025-06-01", "NETFLIX.COM 2231", 16.99),
+
+		expense(5, "2025-05-02", "Lawn service", 40),
+		expense(6, "2025-05-16", "Lawn service", 40),
+		expense(7, "2025-05-30", "Lawn service", 42),
+
+		// Same description, but the amount swings too much.
+		expense(8, "2025-03-10", "Grocery", 80),
+		expense(9, "2025-04-10", "Grocery", 140),
+		expense(10, "2025-05-10", "Grocery", 95),
+
+		// Regular, but already a recurring.
+		expense(11, "2025-03-05", "Rent", 1200),
+		expense(12, "2025-04-05", "Rent", 1200),
+		expense(13, "2025-05-05", "Rent", 1200),
+	}
+	existing := []Recurring{{Description: "rent", Type: "expense"}}
+
+	got := detectRecurring(txs, existing, day("2025-06-10"))
+	require.Len(t, got, 2)
+
+	netflix := got[0]
+	assert.Equal(t, "NETFLIX.COM 2231", netflix.Description)
+	assert.Equal(t, "monthly", netflix.Interval)
+	assert.Equal(t, 15.49, netflix.Amount)
+	require.NotNil(t, netflix.DayOfMonth)
+	assert.Equal(t, 1, *netflix.DayOfMonth)
+	assert.Equal(t, day("2025-07-01"), netflix.StartDate)
+	assert.Equal(t, []int32{1, 2, 3, 4}, netflix.TransactionIDs)
+	assert.Len(t, netflix.ID, 12)
+
+	lawn := got[1]
+	assert.Equal(t, "biweekly", lawn.Interval)
+	require.NotNil(t, lawn.DayOfWeek)
+	assert.Equal(t, 5, *lawn.DayOfWeek)
+	assert.Equal(t, day("2025-06-13"), lawn.StartDate)
+
+	// IDs are stable between calls so a suggestion can be accepted later.
+	again := detectRecurring(txs, existing, day("2025-06-10"))
+	assert.Equal(t, netflix.ID, again[0].ID)
+
+	// Long after the last payment the pattern counts as stopped.
+	assert.Empty(t, detectRecurring(txs[4:7], nil, day("2025-08-01")))
+}
+
+func TestPatternKey(t *testing.T) {
+	assert.Equal(t, "netflix com", patternKey("NETFLIX.COM #8842"))
+	assert.Equal(t, "lawn service", patternKey("  Lawn   Service 05/16 "))
+	assert.Equal(t, "", patternKey("12345"))
+}
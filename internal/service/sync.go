@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/jdelles/currentz/internal/database"
+)
+
+const (
+	// DefaultSyncLimit is how many changed records Sync returns at once
+	// unless asked for another number.
+	DefaultSyncLimit = 500
+	// MaxSyncLimit bounds the records in one Sync.
+	MaxSyncLimit = 5000
+)
+
+// ErrStaleCursor is returned for a cursor this database never handed out,
+// as after a client syncs with another server or the data is restored from
+// an older copy. The client should start over from cursor 0.
+var ErrStaleCursor = errors.New("sync cursor is ahead of the server")
+
+// Setting is one stored setting, as backups carry them.
+type Setting = database.Settings
+
+// SyncResult is what changed after a cursor, and the cursor to send next
+// time. Records created after the cursor are in Created, ones that already
+// existed in Updated, and removed ones are listed in Deleted by ID, or by
+// key for settings.
+type SyncResult struct {
+	Cursor int64 `json:"cursor"`
+	// More is true when there were more changes than the limit; ask again
+	// from Cursor for the rest.
+	More         bool                            `json:"has_more"`
+	Transactions SyncChanges[Transaction, int32] `json:"transactions"`
+	Recurring    SyncChanges[Recurring, int32]   `json:"recurring"`
+	Settings     SyncChanges[Setting, string]    `json:"settings"`
+}
+
+// SyncChanges are the changes to one kind of record.
+type SyncChanges[T any, K comparable] struct {
+	Created []T `json:"created"`
+	Updated []T `json:"updated"`
+	Deleted []K `json:"deleted"`
+}
+
+// Sync returns the transactions, recurrings and settings changed after the
+// cursor since, at most limit of them, oldest change first. Since 0 returns
+// everything there is, all of it as created, with no tombstones. A record
+// changed several times shows up once, as it is now.
+func (fs *FinanceService) Sync(ctx context.Context, since int64, limit int) (SyncResult, error) {
+	if limit <= 0 {
+		limit = DefaultSyncLimit
+	}
+	limit = min(limit, MaxSyncLimit)
+	if since <= 0 {
+		return fs.syncAll(ctx)
+	}
+
+	var res SyncResult
+	err := fs.withTx(ctx, func(q database.Querier) error {
+		cursor, err := q.GetSyncCursor(ctx)
+		if err != nil {
+			return err
+		}
+		if since > cursor {
+			return ErrStaleCursor
+		}
+		changes, err := q.ListSyncChanges(ctx, database.ListSyncChangesParams{Since: since, RowLimit: int32(limit) + 1})
+		if err != nil {
+			return err
+		}
+		more := len(changes) > limit
+		if more {
+			changes = changes[:limit]
+		}
+		res = newSyncResult(since, more)
+		if len(changes) > 0 {
+			res.Cursor = changes[len(changes)-1].Seq
+		}
+		return fs.loadSyncChanges(ctx, q, since, changes, &res)
+	})
+	if err != nil {
+		return SyncResult{}, err
+	}
+	return res, nil
+}
+
+// syncAll is Sync from the start: every live record.
+func (fs *FinanceService) syncAll(ctx context.Context) (SyncResult, error) {
+	res := newSyncResult(0, false)
+	err := fs.withTx(ctx, func(q database.Querier) error {
+		// The cursor is read first, so changes made while the rest is read
+		// come again next time rather than not at all.
+		var err error
+		if res.Cursor, err = q.GetSyncCursor(ctx); err != nil {
+			return err
+		}
+		if res.Transactions.Created, err = q.GetAllTransactions(ctx); err != nil {
+			return err
+		}
+		if res.Recurring.Created, err = q.ListRecurring(ctx); err != nil {
+			return err
+		}
+		res.Settings.Created, err = q.GetAllSettings(ctx)
+		return err
+	})
+	if err != nil {
+		return SyncResult{}, err
+	}
+	return res, nil
+}
+
+// loadSyncChanges reads the current state of each changed record into res.
+func (fs *FinanceService) loadSyncChanges(ctx context.Context, q database.Querier, since int64, changes []database.SyncChanges, res *SyncResult) error {
+	type key struct{ entity, id string }
+	created := make(map[key]bool)
+	var txIDs, recIDs []int32
+	var settingKeys []string
+	for _, c := range changes {
+		switch c.Entity {
+		case EntityTransaction, EntityRecurring:
+			id, err := strconv.ParseInt(c.EntityID, 10, 32)
+			if err != nil {
+				continue
+			}
+			if c.Entity == EntityTransaction {
+				txIDs = append(txIDs, int32(id))
+			} else {
+				recIDs = append(recIDs, int32(id))
+			}
+		case EntitySetting:
+			settingKeys = append(settingKeys, c.EntityID)
+		}
+		created[key{c.Entity, c.EntityID}] = c.CreatedSeq > since
+	}
+	isNew := func(entity, id string) bool { return created[key{entity, id}] }
+
+	if len(txIDs) > 0 {
+		txs, err := q.GetTransactionsByIDs(ctx, txIDs)
+		if err != nil {
+			return err
+		}
+		live := make(map[int32]Transaction, len(txs))
+		for _, tx := range txs {
+			if !tx.DeletedAt.Valid {
+				live[tx.ID] = tx
+			}
+		}
+		res.Transactions = splitSyncChanges(txIDs, live, func(id int32) bool {
+			return isNew(EntityTransaction, strconv.Itoa(int(id)))
+		})
+	}
+	if len(recIDs) > 0 {
+		recs, err := q.GetRecurringsByIDs(ctx, recIDs)
+		if err != nil {
+			return err
+		}
+		live := make(map[int32]Recurring, len(recs))
+		for _, r := range recs {
+			if !r.DeletedAt.Valid {
+				live[r.ID] = r
+			}
+		}
+		res.Recurring = splitSyncChanges(recIDs, live, func(id int32) bool {
+			return isNew(EntityRecurring, strconv.Itoa(int(id)))
+		})
+	}
+	if len(settingKeys) > 0 {
+		all, err := q.GetAllSettings(ctx)
+		if err != nil {
+			return err
+		}
+		live := make(map[string]Setting, len(all))
+		for _, st := range all {
+			live[st.Key] = st
+		}
+		res.Settings = splitSyncChanges(settingKeys, live, func(k string) bool { return isNew(EntitySetting, k) })
+	}
+	return nil
+}
+
+// splitSyncChanges sorts the changed records, in the order of ids, into
+// created, updated and, for ids not in live, deleted.
+func splitSyncChanges[T any, K comparable](ids []K, live map[K]T, isNew func(K) bool) SyncChanges[T, K] {
+	out := newSyncChanges[T, K]()
+	for _, id := range ids {
+		rec, ok := live[id]
+		switch {
+		case !ok:
+			out.Deleted = append(out.Deleted, id)
+		case isNew(id):
+			out.Created = append(out.Created, rec)
+		default:
+			out.Updated = append(out.Updated, rec)
+		}
+	}
+	return out
+}
+
+// newSyncResult has empty lists rather than nil ones, so they encode as [].
+func newSyncResult(cursor int64, more bool) SyncResult {
+	return SyncResult{
+		Cursor:       cursor,
+		More:         more,
+		Transactions: newSyncChanges[Transaction, int32](),
+		Recurring:    newSyncChanges[Recurring, int32](),
+		Settings:     newSyncChanges[Setting, string](),
+	}
+}
+
+func newSyncChanges[T any, K comparable]() SyncChanges[T, K] {
+	return SyncChanges[T, K]{Created: []T{}, Updated: []T{}, Deleted: []K{}}
+}
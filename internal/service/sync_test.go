@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/memdb"
+)
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+
+	kept, _, err := fs.RecordExpense(ctx, ExpenseInput{Date: day("2025-03-01"), Amount: 10, Description: "Coffee"})
+	require.NoError(t, err)
+	gone, _, err := fs.RecordExpense(ctx, ExpenseInput{Date: day("2025-03-02"), Amount: 20, Description: "Lunch"})
+	require.NoError(t, err)
+	rent, err := fs.CreateRecurringSimple(ctx, RecurringInput{
+		Description: "Rent", Type: "expense", Amount: 1200, StartDate: day("2025-01-01"), Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, fs.SetStartingBalance(ctx, 500))
+
+	all, err := fs.Sync(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, all.Transactions.Created, 2)
+	assert.Len(t, all.Recurring.Created, 1)
+	assert.NotEmpty(t, all.Settings.Created)
+	assert.Positive(t, all.Cursor)
+
+	none, err := fs.Sync(ctx, all.Cursor, 0)
+	require.NoError(t, err)
+	assert.Equal(t, all.Cursor, none.Cursor)
+	assert.Empty(t, none.Transactions.Created)
+	assert.Empty(t, none.Transactions.Deleted)
+
+	added, _, err := fs.RecordExpense(ctx, ExpenseInput{Date: day("2025-03-03"), Amount: 5, Description: "Tea"})
+	require.NoError(t, err)
+	_, err = fs.SetTransactionStatus(ctx, kept.ID, StatusPending)
+	require.NoError(t, err)
+	require.NoError(t, fs.DeleteTransaction(ctx, gone.ID))
+	require.NoError(t, fs.SetRecurringActive(ctx, rent.ID, false))
+	require.NoError(t, fs.SetStartingBalance(ctx, 750))
+
+	delta, err := fs.Sync(ctx, all.Cursor, 0)
+	require.NoError(t, err)
+	require.Len(t, delta.Transactions.Created, 1)
+	assert.Equal(t, added.ID, delta.Transactions.Created[0].ID)
+	require.Len(t, delta.Transactions.Updated, 1)
+	assert.Equal(t, StatusPending, delta.Transactions.Updated[0].Status)
+	assert.Equal(t, []int32{gone.ID}, delta.Transactions.Deleted)
+	require.Len(t, delta.Recurring.Updated, 1)
+	assert.False(t, delta.Recurring.Updated[0].Active)
+	require.Len(t, delta.Settings.Updated, 1)
+	assert.Equal(t, "750.00", delta.Settings.Updated[0].Value)
+	assert.False(t, delta.More)
+
+	// Paging picks up where the last page stopped.
+	page, err := fs.Sync(ctx, all.Cursor, 2)
+	require.NoError(t, err)
+	assert.True(t, page.More)
+	rest, err := fs.Sync(ctx, page.Cursor, 100)
+	require.NoError(t, err)
+	assert.False(t, rest.More)
+	assert.Equal(t, delta.Cursor, rest.Cursor)
+
+	_, err = fs.Sync(ctx, delta.Cursor+1, 0)
+	assert.ErrorIs(t, err, ErrStaleCursor)
+}
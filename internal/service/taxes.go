@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+const taxRateSetting = "tax_rate"
+
+// TaxEstimate compares the income tax owed for a year against what has been
+// withheld from tagged paychecks, projected out to year end.
+type TaxEstimate struct {
+	Year              int       `json:"year"`
+	AsOf              time.Time `json:"as_of"`
+	Rate              float64   `json:"rate"`
+	GrossToDate       float64   `json:"gross_to_date"`
+	WithheldToDate    float64   `json:"withheld_to_date"`
+	ProjectedGross    float64   `json:"projected_gross"`
+	ProjectedOwed     float64   `json:"projected_owed"`
+	ProjectedWithheld float64   `json:"projected_withheld"`
+	// ProjectedSurprise is positive when a balance will be due at filing
+	// time and negative when a refund is expected.
+	ProjectedSurprise float64   `json:"projected_surprise"`
+	SettlementDate    time.Time `json:"settlement_date"`
+}
+
+// SetTransactionTax tags an income transaction with its gross pay and the
+// tax withheld from it.
+func (fs *FinanceService) SetTransactionTax(ctx context.Context, id int32, gross, withheld float64) error {
+	tx, err := fs.db.GetTransactionByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("transaction %d not found: %w", id, err)
+	}
+	if tx.Type != "income" {
+		return fmt.Errorf("transaction %d is not income", id)
+	}
+	if gross < 0 || withheld < 0 || withheld > gross {
+		return fmt.Errorf("invalid tax detail: gross %.2f, withheld %.2f", gross, withheld)
+	}
+	detail := database.UpsertIncomeTaxDetailParams{
+		TransactionID: id,
+		GrossAmount:   makePgNumeric(gross),
+		TaxWithheld:   makePgNumeric(withheld),
+	}
+	if err := fs.db.UpsertIncomeTaxDetail(ctx, detail); err != nil {
+		return err
+	}
+	fs.audit(ctx, AuditUpdate, EntityIncomeTaxDetail, id, nil, detail)
+	fs.events.Publish(ctx, EventTransactionUpdated, map[string]int32{"id": id})
+	return nil
+}
+
+// SetTaxRate stores the effective annual income tax rate (0.22 = 22%).
+func (fs *FinanceService) SetTaxRate(ctx context.Context, rate float64) error {
+	if rate < 0 || rate >= 1 {
+		return fmt.Errorf("invalid tax rate %.4f (expected 0 <= rate < 1)", rate)
+	}
+	if err := fs.updateSetting(ctx, taxRateSetting, strconv.FormatFloat(rate, 'f', -1, 64)); err != nil {
+		return err
+	}
+	fs.events.Publish(ctx, EventSettingsChanged, map[string]float64{"tax_rate": rate})
+	return nil
+}
+
+func (fs *FinanceService) taxRate(ctx context.Context) (float64, bool) {
+	value, err := fs.db.GetSetting(ctx, taxRateSetting)
+	if err != nil {
+		return 0, false
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate <= 0 {
+		return 0, false
+	}
+	return rate, true
+}
+
+// EstimateTaxes projects the year-end tax position for the given year by
+// annualizing the gross pay and withholding tagged so far.
+func (fs *FinanceService) EstimateTaxes(ctx context.Context, year int) (TaxEstimate, error) {
+	rate, ok := fs.taxRate(ctx)
+	if !ok {
+		return TaxEstimate{}, fmt.Errorf("tax rate not configured")
+	}
+	return fs.estimateTaxes(ctx, year, rate, fs.Today(ctx))
+}
+
+func (fs *FinanceService) estimateTaxes(ctx context.Context, year int, rate float64, now time.Time) (TaxEstimate, error) {
+	yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+	asOf := truncateDay(now)
+	if asOf.After(yearEnd) {
+		asOf = yearEnd
+	}
+
+	est := TaxEstimate{
+		Year:           year,
+		AsOf:           asOf,
+		Rate:           rate,
+		SettlementDate: time.Date(year+1, 4, 15, 0, 0, 0, 0, time.UTC),
+	}
+	if asOf.Before(yearStart) {
+		return est, nil
+	}
+
+	totals, err := fs.db.GetTaxTotalsBetween(ctx, database.GetTaxTotalsBetweenParams{
+		StartDate: makePgDate(yearStart),
+		EndDate:   makePgDate(asOf),
+	})
+	if err != nil {
+		return TaxEstimate{}, err
+	}
+	gross, err := NumericToMoney(totals.TotalGross)
+	if err != nil {
+		return TaxEstimate{}, err
+	}
+	withheld, err := NumericToMoney(totals.TotalWithheld)
+	if err != nil {
+		return TaxEstimate{}, err
+	}
+
+	elapsed := asOf.Sub(yearStart).Hours()/24 + 1
+	total := yearEnd.Sub(yearStart).Hours()/24 + 1
+	factor := total / elapsed
+
+	projectedGross := gross.Mul(factor)
+	projectedWithheld := withheld.Mul(factor)
+	projectedOwed := projectedGross.Mul(rate)
+
+	est.GrossToDate = gross.Float64()
+	est.WithheldToDate = withheld.Float64()
+	est.ProjectedGross = projectedGross.Float64()
+	est.ProjectedWithheld = projectedWithheld.Float64()
+	est.ProjectedOwed = projectedOwed.Float64()
+	est.ProjectedSurprise = projectedOwed.Sub(projectedWithheld).Float64()
+	return est, nil
+}
+
+// applyTaxSettlements adds the projected filing-day payment or refund to
+// the daily deltas when a settlement date falls inside the window.
+func (fs *FinanceService) applyTaxSettlements(ctx context.Context, daily map[time.Time]money.Money, start, end time.Time) error {
+	rate, ok := fs.taxRate(ctx)
+	if !ok {
+		return nil
+	}
+	now := fs.Today(ctx)
+	for y := start.Year(); y <= end.Year(); y++ {
+		settle := time.Date(y, 4, 15, 0, 0, 0, 0, time.UTC)
+		if settle.Before(start) || settle.After(end) {
+			continue
+		}
+		est, err := fs.estimateTaxes(ctx, y-1, rate, now)
+		if err != nil {
+			return err
+		}
+		daily[settle] = daily[settle].Sub(money.FromFloat(est.ProjectedSurprise))
+	}
+	return nil
+}
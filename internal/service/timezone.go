@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const timezoneSetting = "timezone"
+
+// Timezone is the IANA name of the zone that decides which calendar day it
+// is, e.g. "America/Los_Angeles". It is the default one, or "UTC", until
+// set.
+func (fs *FinanceService) Timezone(ctx context.Context) (string, error) {
+	value, err := fs.db.GetSetting(ctx, timezoneSetting)
+	if err != nil || value == "" {
+		if d := fs.settingDefaults().Timezone; d != "" {
+			return d, nil
+		}
+		return "UTC", nil
+	}
+	return value, nil
+}
+
+// SetTimezone stores the user's zone after checking it is a known IANA name.
+func (fs *FinanceService) SetTimezone(ctx context.Context, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("timezone is required")
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown timezone %q", name)
+	}
+	if err := fs.updateSetting(ctx, timezoneSetting, name); err != nil {
+		return err
+	}
+	fs.events.Publish(ctx, EventSettingsChanged, map[string]string{"timezone": name})
+	return nil
+}
+
+// Location is the user's zone, falling back to UTC if the stored name can
+// no longer be loaded.
+func (fs *FinanceService) Location(ctx context.Context) *time.Location {
+	name, _ := fs.Timezone(ctx)
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Today is the current calendar day in the user's zone.
+func (fs *FinanceService) Today(ctx context.Context) time.Time {
+	return LocalDay(time.Now(), fs.Location(ctx))
+}
+
+// LocalDay returns the calendar day t falls on in loc. Days are represented
+// as midnight UTC, the same way dates come back from the database, so they
+// can be compared and used as map keys directly.
+func LocalDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalDay(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	late := time.Date(2025, 6, 2, 5, 30, 0, 0, time.UTC) // 22:30 on Jun 1 in LA
+
+	assert.Equal(t, day("2025-06-01"), LocalDay(late, la))
+	assert.Equal(t, day("2025-06-02"), LocalDay(late, time.UTC))
+	assert.Equal(t, day("2025-06-02"), LocalDay(late, tokyo))
+	assert.Equal(t, time.UTC, LocalDay(late, tokyo).Location())
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// Ways TopSpending can group expenses.
+const (
+	TopByPayee       = "payee"
+	TopByDescription = "description"
+)
+
+// TopSpendingFilter selects the expenses TopSpending ranks.
+type TopSpendingFilter struct {
+	// By is TopByPayee or TopByDescription.
+	By    string
+	Start time.Time
+	End   time.Time
+	// Limit is how many to return; zero or less returns them all.
+	Limit int
+	// IncludeRecurring counts recurring occurrences in the range, as
+	// AggregateFilter.IncludeRecurring does.
+	IncludeRecurring bool
+}
+
+// SpendingSink is one payee or description and what went to it, as a
+// positive amount in the base currency.
+type SpendingSink struct {
+	Name  string  `json:"name"`
+	Count int64   `json:"count"`
+	Spent float64 `json:"spent"`
+	// Percent is Spent as a share of all the spending in the range, to
+	// one decimal place.
+	Percent float64 `json:"percent"`
+}
+
+// TopSpending is the biggest spending sinks in a date range, largest first.
+type TopSpending struct {
+	By    string         `json:"by"`
+	Start time.Time      `json:"start"`
+	End   time.Time      `json:"end"`
+	Top   []SpendingSink `json:"top"`
+	// Count and Spent cover every expense in the range, not just the
+	// ones in Top.
+	Count int64   `json:"count"`
+	Spent float64 `json:"spent"`
+}
+
+// TopSpending ranks where money went from filter.Start to filter.End.
+// Descriptions may be encrypted at rest, so they are grouped here rather
+// than in SQL, once each amount is converted to the base currency.
+func (fs *FinanceService) TopSpending(ctx context.Context, filter TopSpendingFilter) (TopSpending, error) {
+	if filter.By != TopByPayee && filter.By != TopByDescription {
+		return TopSpending{}, fmt.Errorf("%w: %q (expected payee or description)", ErrInvalidGrouping, filter.By)
+	}
+	if filter.End.Before(filter.Start) {
+		return TopSpending{}, fmt.Errorf("end %s is before start %s", filter.End.Format("2006-01-02"), filter.Start.Format("2006-01-02"))
+	}
+	list := fs.GetTransactionsBetween
+	if filter.IncludeRecurring {
+		list = fs.GetTransactionsWithRecurringsBetween
+	}
+	txs, err := list(ctx, filter.Start, filter.End)
+	if err != nil {
+		return TopSpending{}, err
+	}
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return TopSpending{}, err
+	}
+	top, err := rankSpending(txs, filter.By, filter.Limit, conv)
+	if err != nil {
+		return TopSpending{}, err
+	}
+	top.Start, top.End = filter.Start, filter.End
+	return top, nil
+}
+
+// rankSpending groups the expenses in txs, which are in date order, by
+// payee or description. A group is named after its latest expense.
+func rankSpending(txs []ListedTransaction, by string, limit int, conv converter) (TopSpending, error) {
+	type sink struct {
+		name  string
+		count int64
+		spent money.Money
+	}
+	var (
+		sinks []*sink
+		count int64
+		total money.Money
+	)
+	index := make(map[string]*sink)
+	for _, tx := range txs {
+		if tx.Type != "expense" {
+			continue
+		}
+		name := strings.TrimSpace(tx.Description)
+		key := name
+		if by == TopByPayee {
+			name = payeeOf(name)
+			key = patternKey(name)
+		}
+		amt, err := conv.toBase(tx.Currency, numericOrZero(tx.Amount))
+		if err != nil {
+			return TopSpending{}, err
+		}
+		s := index[key]
+		if s == nil {
+			s = &sink{}
+			index[key] = s
+			sinks = append(sinks, s)
+		}
+		spent := amt.Neg()
+		s.name = name
+		s.count++
+		s.spent = s.spent.Add(spent)
+		count++
+		total = total.Add(spent)
+	}
+
+	slices.SortStableFunc(sinks, func(a, b *sink) int {
+		return cmp.Or(
+			cmp.Compare(b.spent, a.spent),
+			cmp.Compare(b.count, a.count),
+			strings.Compare(a.name, b.name),
+		)
+	})
+	if limit > 0 && len(sinks) > limit {
+		sinks = sinks[:limit]
+	}
+
+	out := TopSpending{By: by, Top: make([]SpendingSink, len(sinks)), Count: count, Spent: total.Float64()}
+	for i, s := range sinks {
+		out.Top[i] = SpendingSink{Name: s.name, Count: s.count, Spent: s.spent.Float64()}
+		if total != 0 {
+			out.Top[i].Percent = math.Round(float64(s.spent)/float64(total)*1000) / 10
+		}
+	}
+	return out, nil
+}
+
+// payeeOf is the payee part of a description. Imports record a payee and
+// memo as "payee - memo".
+func payeeOf(desc string) string {
+	payee, _, _ := strings.Cut(desc, " - ")
+	return strings.TrimSpace(payee)
+}
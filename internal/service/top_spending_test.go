@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jdelles/currentz/internal/memdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankSpendingByPayee(t *testing.T) {
+	txs := OneOffs([]Transaction{
+		{Type: "expense", Description: "STARBUCKS #123 - latte", Amount: makePgNumeric(-5)},
+		{Type: "income", Description: "Paycheck", Amount: makePgNumeric(3000)},
+		{Type: "expense", Description: "Grocer", Amount: makePgNumeric(-80)},
+		{Type: "expense", Description: "Starbucks #98 - beans", Amount: makePgNumeric(-15)},
+		{Type: "expense", Description: "Cinema", Amount: makePgNumeric(-20)},
+	})
+
+	usd := converter{base: "USD"}
+	got, err := rankSpending(txs, TopByPayee, 2, usd)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), got.Count)
+	assert.Equal(t, 120.0, got.Spent)
+	assert.Equal(t, []SpendingSink{
+		{Name: "Grocer", Count: 1, Spent: 80, Percent: 66.7},
+		// A tie on spending goes to the one spent at more often.
+		{Name: "Starbucks #98", Count: 2, Spent: 20, Percent: 16.7},
+	}, got.Top)
+
+	byDescription, err := rankSpending(txs, TopByDescription, 0, usd)
+	require.NoError(t, err)
+	assert.Len(t, byDescription.Top, 4)
+}
+
+func TestRankSpendingConvertsCurrencies(t *testing.T) {
+	txs := OneOffs([]Transaction{
+		{Type: "expense", Description: "Hotel", Amount: makePgNumeric(-100), Currency: "EUR"},
+		{Type: "expense", Description: "Grocer", Amount: makePgNumeric(-150), Currency: "USD"},
+		{Type: "expense", Description: "Hotel", Amount: makePgNumeric(-50), Currency: "USD"},
+	})
+	conv := converter{base: "USD", rates: map[string]float64{"EUR": 2}}
+
+	got, err := rankSpending(txs, TopByDescription, 0, conv)
+	require.NoError(t, err)
+	assert.Equal(t, 400.0, got.Spent)
+	assert.Equal(t, []SpendingSink{
+		{Name: "Hotel", Count: 2, Spent: 250, Percent: 62.5},
+		{Name: "Grocer", Count: 1, Spent: 150, Percent: 37.5},
+	}, got.Top)
+
+	_, err = rankSpending(txs, TopByDescription, 0, converter{base: "USD"})
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+}
+
+func TestTopSpending(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+	start, end := day("2025-09-01"), day("2025-09-30")
+
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-03"), 60, "Groceries", "USD"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-09-17"), 70, "Groceries", "USD"))
+	require.NoError(t, fs.AddExpense(ctx, day("2025-10-01"), 5000, "Next month", "USD"))
+	_, err := fs.CreateRecurringSimple(ctx, RecurringInput{
+		Description: "Rent", Type: "expense", Amount: 1000, StartDate: day("2025-01-15"), Interval: "monthly", Active: true,
+	})
+	require.NoError(t, err)
+
+	recorded, err := fs.TopSpending(ctx, TopSpendingFilter{By: TopByDescription, Start: start, End: end, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, recorded.Top, 1)
+	assert.Equal(t, SpendingSink{Name: "Groceries", Count: 2, Spent: 130, Percent: 100}, recorded.Top[0])
+
+	all, err := fs.TopSpending(ctx, TopSpendingFilter{By: TopByPayee, Start: start, End: end, Limit: 10, IncludeRecurring: true})
+	require.NoError(t, err)
+	require.Len(t, all.Top, 2)
+	assert.Equal(t, "Rent", all.Top[0].Name)
+	assert.Equal(t, 1130.0, all.Spent)
+
+	_, err = fs.TopSpending(ctx, TopSpendingFilter{By: "merchant", Start: start, End: end})
+	assert.ErrorIs(t, err, ErrInvalidGrouping)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TrashRetention is how long soft-deleted rows stay restorable before the
+// purge job removes them for good.
+const TrashRetention = 30 * 24 * time.Hour
+
+// Trash holds soft-deleted rows that can still be restored.
+type Trash struct {
+	Transactions []Transaction `json:"transactions"`
+	Recurring    []Recurring   `json:"recurring"`
+}
+
+func (fs *FinanceService) ListTrash(ctx context.Context) (Trash, error) {
+	txs, err := fs.db.ListDeletedTransactions(ctx)
+	if err != nil {
+		return Trash{}, err
+	}
+	recs, err := fs.db.ListDeletedRecurring(ctx)
+	if err != nil {
+		return Trash{}, err
+	}
+	return Trash{Transactions: txs, Recurring: recs}, nil
+}
+
+func (fs *FinanceService) RestoreTransaction(ctx context.Context, id int32) (Transaction, error) {
+	tx, err := fs.db.RestoreTransaction(ctx, id)
+	if err != nil {
+		return Transaction{}, err
+	}
+	fs.audit(ctx, AuditUpdate, EntityTransaction, id, map[string]bool{"deleted": true}, tx)
+	fs.events.Publish(ctx, EventTransactionUpdated, tx)
+	return tx, nil
+}
+
+func (fs *FinanceService) RestoreRecurring(ctx context.Context, id int32) (Recurring, error) {
+	rec, err := fs.db.RestoreRecurring(ctx, id)
+	if err != nil {
+		return Recurring{}, err
+	}
+	fs.audit(ctx, AuditUpdate, EntityRecurring, id, map[string]bool{"deleted": true}, rec)
+	fs.events.Publish(ctx, EventRecurringUpdated, rec)
+	return rec, nil
+}
+
+// PurgeTrash permanently removes rows soft-deleted before cutoff and
+// returns how many were removed.
+func (fs *FinanceService) PurgeTrash(ctx context.Context, cutoff time.Time) (int64, error) {
+	ts := pgtype.Timestamp{Time: cutoff, Valid: true}
+	blobs, err := fs.purgeableBlobs(ctx, ts)
+	if err != nil {
+		return 0, err
+	}
+	txs, err := fs.db.PurgeDeletedTransactions(ctx, ts)
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range blobs {
+		fs.deleteBlob(ctx, key)
+	}
+	recs, err := fs.db.PurgeDeletedRecurring(ctx, ts)
+	if err != nil {
+		return txs, err
+	}
+	return txs + recs, nil
+}
+
+// StartTrashPurge runs PurgeTrash on every household every interval until
+// ctx is cancelled, removing anything older than TrashRetention.
+func (fs *FinanceService) StartTrashPurge(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			cutoff := time.Now().UTC().Add(-TrashRetention)
+			var n int64
+			err := fs.forEachHousehold(ctx, func(ctx context.Context) error {
+				purged, err := fs.PurgeTrash(ctx, cutoff)
+				n += purged
+				return err
+			})
+			if err != nil {
+				log.Printf("trash purge failed: %v", err)
+			} else if n > 0 {
+				log.Printf("trash purge removed %d rows", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jdelles/currentz/internal/auth"
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/internal/household"
+)
+
+// EntityUser is the audit entity for user accounts.
+const EntityUser = "user"
+
+const (
+	// AccessTokenTTL is how long an access token works for. Clients get a
+	// new one from their refresh token.
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a refresh token works for. Each refresh
+	// swaps it for a new one, so a session lasts while it is in use.
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// Roles, from the most access to the least. Owners can do anything,
+// including managing users, backups and settings only they should touch;
+// editors change the household's data; viewers only read it.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Roles lists the roles, from the most access to the least.
+var Roles = []string{RoleOwner, RoleEditor, RoleViewer}
+
+var roleRank = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+const maxUsernameLen = 100
+
+var (
+	// ErrInvalidUser is returned for a bad username, password or role, and
+	// for removing the last owner.
+	ErrInvalidUser = errors.New("invalid user")
+	// ErrUserNotFound is returned for IDs with no user.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidCredentials is returned by Login, whether the username or
+	// the password was wrong.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidToken is returned for an access or refresh token that is
+	// malformed, expired, revoked or whose user is gone.
+	ErrInvalidToken = auth.ErrInvalidToken
+)
+
+// User is a person who can log in. The password hash never leaves the
+// service.
+type User struct {
+	ID        int32     `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HasRole reports whether u has at least role's access.
+func (u User) HasRole(role string) bool {
+	return RoleAllows(u.Role, role)
+}
+
+// RoleAllows reports whether role has at least need's access.
+func RoleAllows(role, need string) bool {
+	return roleRank[need] > 0 && roleRank[role] >= roleRank[need]
+}
+
+// Session is what logging in or refreshing hands the client.
+type Session struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// ExpiresIn is the access token's lifetime in seconds.
+	ExpiresIn        int       `json:"expires_in"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             User      `json:"user"`
+}
+
+type userKey struct{}
+
+// WithUser tags ctx with the logged-in user a request is made by.
+func WithUser(ctx context.Context, u User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFromContext is the user WithUser tagged ctx with; ok is false for
+// requests made without logging in.
+func UserFromContext(ctx context.Context) (u User, ok bool) {
+	u, ok = ctx.Value(userKey{}).(User)
+	return u, ok
+}
+
+// SetAuthSecret sets the key access tokens are signed with. Tokens signed
+// with another key stop working.
+func (fs *FinanceService) SetAuthSecret(secret []byte) error {
+	if len(secret) < auth.MinSecretLen {
+		return auth.ErrWeakSecret
+	}
+	fs.authSecret = secret
+	return nil
+}
+
+func (fs *FinanceService) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := fs.db.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]User, len(rows))
+	for i, row := range rows {
+		users[i] = userFromRow(row)
+	}
+	return users, nil
+}
+
+func (fs *FinanceService) GetUser(ctx context.Context, id int32) (User, error) {
+	row, err := fs.db.GetUserByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, fmt.Errorf("%w: %d", ErrUserNotFound, id)
+	} else if err != nil {
+		return User{}, err
+	}
+	return userFromRow(row), nil
+}
+
+// CreateUser adds a user who can log in with username and password. Role
+// is both their role on the server, where owners manage users, and in the
+// household ctx works on, which they join.
+func (fs *FinanceService) CreateUser(ctx context.Context, username, password, role string) (User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || len(username) > maxUsernameLen {
+		return User{}, fmt.Errorf("%w: username must be 1 to %d characters", ErrInvalidUser, maxUsernameLen)
+	}
+	if err := validateRole(role); err != nil {
+		return User{}, err
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: %w", ErrInvalidUser, err)
+	}
+	row, err := fs.db.CreateUser(ctx, database.CreateUserParams{Username: username, PasswordHash: hash, Role: role})
+	if err != nil {
+		return User{}, userError(username, err)
+	}
+	u := userFromRow(row)
+	fs.audit(ctx, AuditCreate, EntityUser, u.ID, nil, u)
+	if _, err := fs.AddHouseholdMember(ctx, household.FromContext(ctx), u.ID, role); err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+// SetUserPassword changes a user's password and logs them out everywhere.
+func (fs *FinanceService) SetUserPassword(ctx context.Context, id int32, password string) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidUser, err)
+	}
+	n, err := fs.db.SetUserPassword(ctx, database.SetUserPasswordParams{ID: id, PasswordHash: hash})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrUserNotFound, id)
+	}
+	if err := fs.db.RevokeUserRefreshTokens(ctx, id); err != nil {
+		return err
+	}
+	fs.audit(ctx, AuditUpdate, EntityUser, id, nil, map[string]bool{"password_changed": true})
+	return nil
+}
+
+// SetUserRole changes what a user may do on the server; their roles in
+// households are their memberships'. It takes effect on their next request.
+// There is always at least one owner left.
+func (fs *FinanceService) SetUserRole(ctx context.Context, id int32, role string) (User, error) {
+	if err := validateRole(role); err != nil {
+		return User{}, err
+	}
+	before, err := fs.GetUser(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+	if before.Role == role {
+		return before, nil
+	}
+	if err := fs.keepAnOwner(ctx, before); err != nil {
+		return User{}, err
+	}
+	if _, err := fs.db.SetUserRole(ctx, database.SetUserRoleParams{ID: id, Role: role}); err != nil {
+		return User{}, err
+	}
+	after := before
+	after.Role = role
+	fs.audit(ctx, AuditUpdate, EntityUser, id, before, after)
+	return after, nil
+}
+
+// DeleteUser removes a user, their refresh tokens and their household
+// memberships. Access tokens they hold stop working at once. The last
+// owner, of the server or of a household, can't be removed.
+func (fs *FinanceService) DeleteUser(ctx context.Context, id int32) error {
+	u, err := fs.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := fs.keepAnOwner(ctx, u); err != nil {
+		return err
+	}
+	households, err := fs.UserHouseholds(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, h := range households {
+		m := HouseholdMember{UserID: u.ID, Username: u.Username, Role: h.Role}
+		if err := fs.keepAHouseholdOwner(ctx, h.ID, m); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidUser, err)
+		}
+	}
+	if _, err := fs.db.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+	fs.audit(ctx, AuditDelete, EntityUser, id, u, nil)
+	return nil
+}
+
+// keepAnOwner refuses to take u's owner role away if nobody else has it,
+// which would leave no one able to manage users.
+func (fs *FinanceService) keepAnOwner(ctx context.Context, u User) error {
+	if u.Role != RoleOwner {
+		return nil
+	}
+	owners, err := fs.db.CountUsersByRole(ctx, RoleOwner)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return fmt.Errorf("%w: %s is the last owner", ErrInvalidUser, u.Username)
+	}
+	return nil
+}
+
+// Login checks a username and password and starts a session.
+func (fs *FinanceService) Login(ctx context.Context, username, password string) (Session, error) {
+	row, err := fs.db.GetUserByUsername(ctx, strings.TrimSpace(username))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Session{}, err
+	}
+	// An unknown user is checked against no hash, so it takes as long to
+	// turn away as a wrong password.
+	if !auth.CheckPassword(row.PasswordHash, password) {
+		return Session{}, ErrInvalidCredentials
+	}
+	// Logins are rare enough to tidy up after.
+	if _, err := fs.db.DeleteExpiredRefreshTokens(ctx, timestamp(time.Now())); err != nil {
+		return Session{}, err
+	}
+	// The family ties together the refresh tokens this login leads to.
+	_, family := auth.NewRefreshToken()
+	return fs.newSession(ctx, userFromRow(row), family)
+}
+
+// Refresh swaps a refresh token for a new session. Each refresh token
+// works once: presenting one again means it was stolen, or the client
+// is confused, so every token descended from the same login is revoked.
+func (fs *FinanceService) Refresh(ctx context.Context, refreshToken string) (Session, error) {
+	tok, err := fs.db.GetRefreshToken(ctx, auth.HashToken(refreshToken))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Session{}, ErrInvalidToken
+	} else if err != nil {
+		return Session{}, err
+	}
+	if tok.RevokedAt.Valid {
+		if err := fs.db.RevokeRefreshTokenFamily(ctx, tok.Family); err != nil {
+			return Session{}, err
+		}
+		return Session{}, ErrInvalidToken
+	}
+	if !time.Now().Before(tok.ExpiresAt.Time) {
+		return Session{}, ErrInvalidToken
+	}
+	// Another refresh with the same token may have got here first; only
+	// one of them can revoke it.
+	n, err := fs.db.RevokeRefreshToken(ctx, tok.TokenHash)
+	if err != nil {
+		return Session{}, err
+	}
+	if n == 0 {
+		if err := fs.db.RevokeRefreshTokenFamily(ctx, tok.Family); err != nil {
+			return Session{}, err
+		}
+		return Session{}, ErrInvalidToken
+	}
+	row, err := fs.db.GetUserByID(ctx, tok.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Session{}, ErrInvalidToken
+	} else if err != nil {
+		return Session{}, err
+	}
+	return fs.newSession(ctx, userFromRow(row), tok.Family)
+}
+
+// Logout ends the session a refresh token belongs to. An unknown token is
+// not an error, so logging out twice is harmless. The session's access
+// token works until it expires.
+func (fs *FinanceService) Logout(ctx context.Context, refreshToken string) error {
+	tok, err := fs.db.GetRefreshToken(ctx, auth.HashToken(refreshToken))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return fs.db.RevokeRefreshTokenFamily(ctx, tok.Family)
+}
+
+// Authenticate checks an access token and returns the user it was issued
+// to.
+func (fs *FinanceService) Authenticate(ctx context.Context, accessToken string) (User, error) {
+	claims, err := auth.Verify(accessToken, fs.authSecret, time.Now())
+	if err != nil {
+		return User{}, ErrInvalidToken
+	}
+	id, err := strconv.ParseInt(claims.Subject, 10, 32)
+	if err != nil {
+		return User{}, ErrInvalidToken
+	}
+	row, err := fs.db.GetUserByID(ctx, int32(id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, ErrInvalidToken
+	} else if err != nil {
+		return User{}, err
+	}
+	return userFromRow(row), nil
+}
+
+// newSession issues an access token and a refresh token in family to u.
+func (fs *FinanceService) newSession(ctx context.Context, u User, family string) (Session, error) {
+	now := time.Now()
+	access, err := auth.Sign(auth.Claims{
+		Subject:   strconv.Itoa(int(u.ID)),
+		Username:  u.Username,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(AccessTokenTTL).Unix(),
+	}, fs.authSecret)
+	if err != nil {
+		return Session{}, err
+	}
+	refresh, hash := auth.NewRefreshToken()
+	expires := now.Add(RefreshTokenTTL)
+	if err := fs.db.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		TokenHash: hash,
+		UserID:    u.ID,
+		Family:    family,
+		ExpiresAt: timestamp(expires),
+	}); err != nil {
+		return Session{}, err
+	}
+	return Session{
+		AccessToken:      access,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(AccessTokenTTL / time.Second),
+		RefreshToken:     refresh,
+		RefreshExpiresAt: expires.UTC(),
+		User:             u,
+	}, nil
+}
+
+func userFromRow(row database.Users) User {
+	return User{ID: row.ID, Username: row.Username, Role: row.Role, CreatedAt: row.CreatedAt.Time}
+}
+
+func validateRole(role string) error {
+	if roleRank[role] == 0 {
+		return fmt.Errorf("%w: role %q (expected %s)", ErrInvalidUser, role, strings.Join(Roles, ", "))
+	}
+	return nil
+}
+
+// userError turns a clash on the unique username into ErrInvalidUser.
+func userError(username string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("%w: username %q is taken", ErrInvalidUser, username)
+	}
+	return err
+}
+
+// timestamp is t as stored in a TIMESTAMP column, in UTC.
+func timestamp(t time.Time) pgtype.Timestamp {
+	return pgtype.Timestamp{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/database"
+	"github.com/jdelles/currentz/pkg/money"
+)
+
+// ErrInvalidMonth is returned for a variance month that hasn't started.
+var ErrInvalidMonth = errors.New("invalid month")
+
+// VarianceAmounts compares planned and recorded money for a month, in the
+// base currency. Expenses are negative, so a positive Variance (Actual
+// minus Projected) is always in your favour: more income or less spending
+// than planned.
+type VarianceAmounts struct {
+	Projected float64 `json:"projected"`
+	Actual    float64 `json:"actual"`
+	Variance  float64 `json:"variance"`
+}
+
+// CategoryVariance is one category's line in a VarianceReport. Category is
+// empty for uncategorized money, which includes all recurring occurrences.
+type CategoryVariance struct {
+	Category string `json:"category"`
+	VarianceAmounts
+}
+
+// VarianceReport compares the forecast for a calendar month, as it stood
+// on its first day, with the transactions recorded for it since.
+type VarianceReport struct {
+	Month string `json:"month"`
+	// Complete is false while the month is still under way.
+	Complete   bool               `json:"complete"`
+	Categories []CategoryVariance `json:"categories"`
+	Total      VarianceAmounts    `json:"total"`
+}
+
+// MonthVariance reports how far the recorded transactions for the month
+// containing month strayed from plan. The plan is what GET /api/forecast
+// would have shown on the month's first day: the transactions known then
+// plus recurring occurrences, replayed from the audit log as ForecastAsOf
+// does. Categories match case-insensitively and are ordered by the size of
+// their variance, largest first. Amounts are converted at today's rates.
+func (fs *FinanceService) MonthVariance(ctx context.Context, month time.Time) (VarianceReport, error) {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	today := fs.Today(ctx)
+	if first.After(today) {
+		return VarianceReport{}, fmt.Errorf("%w: %s hasn't started", ErrInvalidMonth, first.Format("2006-01"))
+	}
+
+	state, _, err := fs.knownAsOf(ctx, first.AddDate(0, 0, 1))
+	if err != nil {
+		return VarianceReport{}, err
+	}
+	var projected []Transaction
+	for _, tx := range state.Transactions {
+		if d := tx.Date.Time; !d.Before(first) && !d.After(last) {
+			projected = append(projected, tx)
+		}
+	}
+	for _, r := range state.Recurring {
+		projected = append(projected, expandOne(r, first, last)...)
+	}
+
+	actual, err := fs.db.GetTransactionsByDateRange(ctx, database.GetTransactionsByDateRangeParams{
+		Date:   makePgDate(first),
+		Date_2: makePgDate(last),
+	})
+	if err != nil {
+		return VarianceReport{}, err
+	}
+
+	conv, err := fs.converter(ctx)
+	if err != nil {
+		return VarianceReport{}, err
+	}
+	return compareMonth(first, projected, actual, conv, last.Before(today))
+}
+
+func compareMonth(first time.Time, projected, actual []Transaction, conv converter, complete bool) (VarianceReport, error) {
+	type sums struct {
+		name              string
+		projected, actual money.Money
+	}
+	var (
+		lines []*sums
+		total sums
+	)
+	index := make(map[string]*sums)
+	add := func(tx Transaction, planned bool) error {
+		amt, err := NumericToMoney(tx.Amount)
+		if err != nil {
+			return nil
+		}
+		if amt, err = conv.toBase(tx.Currency, amt); err != nil {
+			return err
+		}
+		name := strings.TrimSpace(tx.Category.String)
+		key := strings.ToLower(name)
+		line, ok := index[key]
+		if !ok {
+			line = &sums{name: name}
+			index[key] = line
+			lines = append(lines, line)
+		}
+		for _, s := range []*sums{line, &total} {
+			if planned {
+				s.projected = s.projected.Add(amt)
+			} else {
+				s.actual = s.actual.Add(amt)
+			}
+		}
+		return nil
+	}
+	for _, tx := range projected {
+		if err := add(tx, true); err != nil {
+			return VarianceReport{}, err
+		}
+	}
+	for _, tx := range actual {
+		if err := add(tx, false); err != nil {
+			return VarianceReport{}, err
+		}
+	}
+
+	amounts := func(s sums) VarianceAmounts {
+		return VarianceAmounts{
+			Projected: s.projected.Float64(),
+			Actual:    s.actual.Float64(),
+			Variance:  s.actual.Sub(s.projected).Float64(),
+		}
+	}
+	report := VarianceReport{
+		Month:      first.Format("2006-01"),
+		Complete:   complete,
+		Categories: make([]CategoryVariance, 0, len(lines)),
+		Total:      amounts(total),
+	}
+	for _, line := range lines {
+		report.Categories = append(report.Categories, CategoryVariance{Category: line.name, VarianceAmounts: amounts(*line)})
+	}
+	sort.SliceStable(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i], report.Categories[j]
+		if math.Abs(a.Variance) != math.Abs(b.Variance) {
+			return math.Abs(a.Variance) > math.Abs(b.Variance)
+		}
+		return strings.ToLower(a.Category) < strings.ToLower(b.Category)
+	})
+	return report, nil
+}
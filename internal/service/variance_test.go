@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareMonth(t *testing.T) {
+	first := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	tx := func(amount float64, category, currency string) Transaction {
+		return Transaction{
+			Amount:   makePgNumeric(amount),
+			Category: pgtype.Text{String: category, Valid: category != ""},
+			Currency: currency,
+		}
+	}
+	conv := converter{base: "USD", rates: map[string]float64{"EUR": 2}}
+
+	projected := []Transaction{
+		tx(3000, "", ""),  // paycheck recurring
+		tx(-1200, "", ""), // rent recurring
+		tx(-400, "Groceries", ""),
+		tx(-50, "Travel", "EUR"),
+	}
+	actual := []Transaction{
+		tx(3000, "", ""),
+		tx(-250, "groceries", ""),
+		tx(-200, "Groceries", ""),
+		tx(-100, "Travel", "EUR"),
+		tx(-30, "Coffee", ""),
+	}
+
+	report, err := compareMonth(first, projected, actual, conv, true)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-09", report.Month)
+	assert.True(t, report.Complete)
+
+	assert.Equal(t, []CategoryVariance{
+		{Category: "", VarianceAmounts: VarianceAmounts{Projected: 1800, Actual: 3000, Variance: 1200}},
+		{Category: "Travel", VarianceAmounts: VarianceAmounts{Projected: -100, Actual: -200, Variance: -100}},
+		{Category: "Groceries", VarianceAmounts: VarianceAmounts{Projected: -400, Actual: -450, Variance: -50}},
+		{Category: "Coffee", VarianceAmounts: VarianceAmounts{Projected: 0, Actual: -30, Variance: -30}},
+	}, report.Categories)
+	assert.Equal(t, VarianceAmounts{Projected: 1300, Actual: 2320, Variance: 1020}, report.Total)
+
+	_, err = compareMonth(first, nil, []Transaction{tx(-5, "", "JPY")}, conv, false)
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrVersionConflict is returned for an edit based on an old version of a
+// transaction or recurring: someone else changed it in the meantime. Read
+// it again, and redo the edit if it still makes sense.
+var ErrVersionConflict = errors.New("changed since it was read")
+
+type versionKey struct{}
+
+// WithVersion makes edits under ctx apply only to a record still at
+// version, the one the caller last read. Without it an edit applies to
+// whatever version is current.
+func WithVersion(ctx context.Context, version int32) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+// ExpectedVersion returns the version set with WithVersion, if any.
+func ExpectedVersion(ctx context.Context) (int32, bool) {
+	version, ok := ctx.Value(versionKey{}).(int32)
+	return version, ok
+}
+
+// checkVersion fails unless current is the version ctx expects.
+func checkVersion(ctx context.Context, entity string, id, current int32) error {
+	if want, ok := ExpectedVersion(ctx); ok && want != current {
+		return fmt.Errorf("%w: %s %d is at version %d, not %d", ErrVersionConflict, entity, id, current, want)
+	}
+	return nil
+}
+
+// lostRace is the error for an update that found its record gone or
+// changed after it was read.
+func lostRace(entity string, id int32) error {
+	return fmt.Errorf("%w: %s %d", ErrVersionConflict, entity, id)
+}
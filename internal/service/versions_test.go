@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/memdb"
+)
+
+func TestVersionedEdits(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFinanceService(memdb.New())
+
+	tx, _, err := fs.RecordExpense(ctx, ExpenseInput{Date: day("2025-03-01"), Amount: 10, Description: "Check"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), tx.Version)
+
+	pending, err := fs.SetTransactionStatus(WithVersion(ctx, 1), tx.ID, StatusPending)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), pending.Version)
+	_, err = fs.SetTransactionStatus(WithVersion(ctx, 1), tx.ID, StatusCleared)
+	assert.ErrorIs(t, err, ErrVersionConflict, "based on the version before the last edit")
+	_, err = fs.SetTransactionStatus(ctx, tx.ID, StatusCleared)
+	assert.NoError(t, err, "no version to check")
+
+	lessons, err := fs.CreateRecurringSimple(ctx, RecurringInput{
+		Description: "Lessons", Type: "expense", Amount: 35, StartDate: day("2025-01-06"), Interval: "weekly", Active: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, fs.SetRecurringActive(WithVersion(ctx, lessons.Version), lessons.ID, false))
+	moved, err := fs.ReanchorRecurring(WithVersion(ctx, lessons.Version+1), lessons.ID, ReanchorInput{ShiftWeeks: 1})
+	require.NoError(t, err)
+	assert.Equal(t, lessons.Version+2, moved.Version)
+
+	_, err = fs.PauseRecurring(WithVersion(ctx, lessons.Version), lessons.ID, nil, nil)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	err = fs.SetRecurringActive(WithVersion(ctx, 1), 999, true)
+	assert.ErrorIs(t, err, ErrRecurringNotFound)
+
+	// Deleting and restoring count as edits too.
+	require.NoError(t, fs.DeleteTransaction(ctx, tx.ID))
+	restored, err := fs.RestoreTransaction(ctx, tx.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), restored.Version)
+}
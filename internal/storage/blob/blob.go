@@ -0,0 +1,88 @@
+// Package blob stores files, such as receipts, outside the database. A Store
+// maps slash-separated keys like "attachments/3f9c…" to bytes; what the
+// bytes mean is up to the caller, which keeps its own record of each key.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for a key with nothing stored under it.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a place to keep blobs. Implementations are safe for concurrent
+// use.
+type Store interface {
+	// Put stores everything read from r under key, replacing what was
+	// there. If reading r fails, nothing is stored.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the blob under key. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key. Deleting a missing key is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+	// List describes the blobs whose keys start with prefix, by key.
+	List(ctx context.Context, prefix string) ([]Info, error)
+}
+
+// Info describes a stored blob.
+type Info struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+}
+
+// Presigner is a Store that can hand out temporary download links, so
+// clients fetch large files from the store directly instead of through
+// the server.
+type Presigner interface {
+	PresignGet(ctx context.Context, key string, expires time.Duration, opts DownloadOptions) (string, error)
+}
+
+// DownloadOptions set the headers a presigned download is served with.
+type DownloadOptions struct {
+	// Filename makes the download save under this name.
+	Filename    string
+	ContentType string
+}
+
+// ValidKey reports whether key is usable with every Store: non-empty
+// slash-separated segments of letters, digits, '-', '_' and '.', none of
+// them "." or "..".
+func ValidKey(key string) bool {
+	if key == "" || len(key) > 512 {
+		return false
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return false
+		}
+		for _, c := range seg {
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+				c == '-', c == '_', c == '.':
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// contentDisposition is the header value that saves a download as filename.
+func contentDisposition(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
+
+func checkKey(key string) error {
+	if !ValidKey(key) {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStores(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	for name, store := range map[string]Store{"local": local, "memory": NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, store.Put(ctx, "attachments/a1", strings.NewReader("receipt")))
+			require.NoError(t, store.Put(ctx, "attachments/a1", strings.NewReader("receipt v2")))
+
+			rc, err := store.Get(ctx, "attachments/a1")
+			require.NoError(t, err)
+			data, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+			assert.Equal(t, "receipt v2", string(data))
+
+			require.NoError(t, store.Put(ctx, "backups/b1.json", strings.NewReader("{}")))
+			infos, err := store.List(ctx, "attachments/")
+			require.NoError(t, err)
+			require.Len(t, infos, 1)
+			assert.Equal(t, "attachments/a1", infos[0].Key)
+			assert.Equal(t, int64(len("receipt v2")), infos[0].Size)
+
+			require.NoError(t, store.Delete(ctx, "attachments/a1"))
+			require.NoError(t, store.Delete(ctx, "attachments/a1"), "deleting twice is fine")
+			_, err = store.Get(ctx, "attachments/a1")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			failing := io.MultiReader(strings.NewReader("part"), errReader{})
+			assert.Error(t, store.Put(ctx, "attachments/a2", failing))
+			_, err = store.Get(ctx, "attachments/a2")
+			assert.ErrorIs(t, err, ErrNotFound, "a failed put stores nothing")
+
+			assert.Error(t, store.Put(ctx, "../escape", strings.NewReader("x")))
+		})
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"attachments/3f9c.pdf": true,
+		"a":                    true,
+		"":                     false,
+		"/abs":                 false,
+		"a//b":                 false,
+		"a/../b":               false,
+		"a/./b":                false,
+		`a\b`:                  false,
+		"a b":                  false,
+	} {
+		assert.Equal(t, want, ValidKey(key), key)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
@@ -0,0 +1,25 @@
+package blob
+
+import "fmt"
+
+// Config chooses and configures a Store.
+type Config struct {
+	// Backend is "local", "s3" or "memory".
+	Backend string
+	// Dir is where the local backend keeps files.
+	Dir string
+	S3  S3Config
+}
+
+// Open returns the Store cfg describes.
+func Open(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "local":
+		return NewLocal(cfg.Dir)
+	case "s3":
+		return NewS3(cfg.S3)
+	case "memory":
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown blob backend %q (expected local, s3 or memory)", cfg.Backend)
+}
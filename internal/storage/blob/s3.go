@@ -0,0 +1,301 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jdelles/currentz/internal/sigv4"
+)
+
+// S3Config points an S3 store at a bucket on AWS or an S3-compatible
+// service such as MinIO.
+type S3Config struct {
+	// Endpoint is the service URL, e.g. "http://minio:9000". Empty means
+	// AWS in Region.
+	Endpoint string
+	Region   string
+	Bucket   string
+
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken goes with temporary credentials.
+	SessionToken string
+
+	// PathStyle addresses the bucket as endpoint/bucket/key, as MinIO and
+	// most S3-compatible services expect, instead of bucket.endpoint/key.
+	PathStyle bool
+}
+
+// S3 keeps blobs as objects in an S3 bucket. Requests are signed with AWS
+// Signature Version 4.
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	// now is the signing clock, replaced in tests.
+	now func() time.Time
+}
+
+var (
+	_ Store     = (*S3)(nil)
+	_ Presigner = (*S3)(nil)
+)
+
+// NewS3 checks cfg and returns a store for its bucket. It doesn't contact
+// the service.
+func NewS3(cfg S3Config) (*S3, error) {
+	switch {
+	case cfg.Bucket == "":
+		return nil, errors.New("s3: bucket is required")
+	case cfg.Region == "":
+		return nil, errors.New("s3: region is required")
+	case cfg.AccessKeyID == "" || cfg.SecretAccessKey == "":
+		return nil, errors.New("s3: access key ID and secret access key are required")
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", cfg.Endpoint)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return &S3{cfg: cfg, endpoint: u, client: &http.Client{}, now: time.Now}, nil
+}
+
+// Put sends the object with its length and checksum, which S3 needs up
+// front, so r is first copied to a temporary file.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp("", "currentz-s3-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key, nil).String(), io.NopCloser(tmp))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	s.sign(req, hex.EncodeToString(h.Sum(nil)))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp, key)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key, nil))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s3Error(resp, key)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(key, nil))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// S3 answers 204 whether or not the key existed; some compatible
+	// services say 404 instead.
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error(resp, key)
+	}
+	return nil
+}
+
+// List pages through ListObjectsV2.
+func (s *S3) List(ctx context.Context, prefix string) ([]Info, error) {
+	var out []Info
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, s.objectURL("", q))
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Contents []struct {
+				Key          string
+				Size         int64
+				LastModified time.Time
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = s3Error(resp, prefix)
+		} else {
+			err = xml.NewDecoder(resp.Body).Decode(&page)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range page.Contents {
+			out = append(out, Info{Key: c.Key, Size: c.Size, Modified: c.LastModified})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// PresignGet returns a URL anyone can download key from until it expires,
+// without credentials. S3 caps expiry at seven days.
+func (s *S3) PresignGet(ctx context.Context, key string, expires time.Duration, opts DownloadOptions) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	if expires <= 0 || expires > 7*24*time.Hour {
+		return "", fmt.Errorf("s3: presigned URL expiry %s out of range (1s to 7 days)", expires)
+	}
+	signer := s.signer()
+	t := signer.Time()
+	q := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.cfg.AccessKeyID + "/" + signer.Scope(t)},
+		"X-Amz-Date":          {t.Format(sigv4.DateFormat)},
+		"X-Amz-Expires":       {strconv.Itoa(int(expires.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	if s.cfg.SessionToken != "" {
+		q.Set("X-Amz-Security-Token", s.cfg.SessionToken)
+	}
+	if opts.Filename != "" {
+		q.Set("response-content-disposition", contentDisposition(opts.Filename))
+	}
+	if opts.ContentType != "" {
+		q.Set("response-content-type", opts.ContentType)
+	}
+	u := s.objectURL(key, q)
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		sigv4.CanonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	u.RawQuery += "&X-Amz-Signature=" + signer.Signature(t, canonical)
+	return u.String(), nil
+}
+
+func (s *S3) do(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, sigv4.EmptySHA256)
+	return s.client.Do(req)
+}
+
+// objectURL addresses key in the bucket; an empty key is the bucket itself.
+func (s *S3) objectURL(key string, q url.Values) *url.URL {
+	u := *s.endpoint
+	p := u.Path + "/"
+	if s.cfg.PathStyle {
+		p += s.cfg.Bucket + "/"
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	p += key
+	u.Path = p
+	u.RawPath = escapePath(p)
+	if q != nil {
+		u.RawQuery = sigv4.CanonicalQuery(q)
+	}
+	return &u
+}
+
+// sign adds the Authorization header for a request whose body hashes to
+// payloadHash.
+func (s *S3) sign(req *http.Request, payloadHash string) {
+	s.signer().Sign(req, payloadHash)
+}
+
+func (s *S3) signer() sigv4.Signer {
+	return sigv4.Signer{
+		AccessKeyID:     s.cfg.AccessKeyID,
+		SecretAccessKey: s.cfg.SecretAccessKey,
+		SessionToken:    s.cfg.SessionToken,
+		Region:          s.cfg.Region,
+		Service:         "s3",
+		Now:             s.now,
+	}
+}
+
+// escapePath escapes each segment of p, keeping the slashes.
+func escapePath(p string) string {
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		segs[i] = sigv4.URIEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
+
+// s3Error turns an error response into an error, ErrNotFound for a
+// missing key.
+func s3Error(resp *http.Response, key string) error {
+	var body struct {
+		Code    string
+		Message string
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = xml.Unmarshal(data, &body)
+	if resp.StatusCode == http.StatusNotFound && (body.Code == "" || body.Code == "NoSuchKey") {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if body.Code == "" {
+		body.Code = resp.Status
+	}
+	return fmt.Errorf("s3: %s %s: %s %s", resp.Request.Method, key, body.Code, body.Message)
+}
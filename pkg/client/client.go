@@ -0,0 +1,278 @@
+// Package client is a typed Go client for the Currentz HTTP API. Its
+// methods mirror the service layer's, so code written against FinanceService
+// runs the same on a server reached over HTTP as on a local database, and
+// tests can swap in a fake.
+//
+//	c, err := client.New("https://currentz.example.com", client.Options{APIKey: key})
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//	forecast, err := c.Forecast(ctx, 0, 30)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for Options.
+const (
+	DefaultTimeout   = 30 * time.Second
+	DefaultRetries   = 3
+	DefaultRetryWait = 250 * time.Millisecond
+	maxRetryWait     = 5 * time.Second
+)
+
+// Error is a non-2xx response from the server.
+type Error struct {
+	StatusCode int
+	Message    string
+	// RequestID names the request in the server's logs, if it said.
+	RequestID string
+
+	retryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("server returned %d: %s (request %s)", e.StatusCode, e.Message, e.RequestID)
+	}
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the request may succeed if sent again later.
+func (e *Error) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Options configures a Client. The zero value talks to an open server with
+// the default timeout and retries.
+type Options struct {
+	// APIKey, or a user's access token, is sent as a bearer token when set.
+	APIKey string
+	// HTTPClient sends the requests. Nil uses one with DefaultTimeout.
+	HTTPClient *http.Client
+	// Retries is how many more times a GET, PUT or DELETE is sent after a
+	// network error or a temporary server error. Zero uses DefaultRetries
+	// and a negative number turns retries off. POSTs are never retried, as
+	// they may have been applied before the connection dropped.
+	Retries int
+	// RetryWait is the wait before the first retry, doubling up to 5 seconds
+	// after each one. A Retry-After header from the server takes its place.
+	// Zero uses DefaultRetryWait.
+	RetryWait time.Duration
+	// Household picks which household requests work on. Zero leaves it to
+	// the server.
+	Household int32
+	// Actor names the client in the server's audit log, e.g. "cli". Changes
+	// made with a user's token are attributed to the user instead.
+	Actor string
+}
+
+// Client talks to one Currentz server. It is safe for concurrent use.
+type Client struct {
+	baseURL   *url.URL
+	apiKey    string
+	http      *http.Client
+	retries   int
+	retryWait time.Duration
+	household int32
+	actor     string
+
+	mu  sync.Mutex
+	loc *time.Location
+}
+
+// New returns a client for the server at baseURL, e.g.
+// "https://currentz.example.com".
+func New(baseURL string, opts Options) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q: expected http(s)://host[:port]", baseURL)
+	}
+	c := &Client{
+		baseURL:   u,
+		apiKey:    opts.APIKey,
+		http:      opts.HTTPClient,
+		retries:   opts.Retries,
+		retryWait: opts.RetryWait,
+		household: opts.Household,
+		actor:     opts.Actor,
+	}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: DefaultTimeout}
+	}
+	switch {
+	case c.retries == 0:
+		c.retries = DefaultRetries
+	case c.retries < 0:
+		c.retries = 0
+	}
+	if c.retryWait <= 0 {
+		c.retryWait = DefaultRetryWait
+	}
+	return c, nil
+}
+
+// Close releases idle connections.
+func (c *Client) Close() error {
+	c.http.CloseIdleConnections()
+	return nil
+}
+
+// do sends a request and decodes a JSON response into out, if non-nil.
+// body is encoded as JSON unless it is an io.Reader.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// send performs a request, retrying it if it is safe to, and returns the
+// response if it succeeded. The caller closes the body.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	var (
+		data        []byte
+		reader      io.Reader
+		contentType string
+	)
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+		contentType = "application/octet-stream"
+	default:
+		var err error
+		if data, err = json.Marshal(b); err != nil {
+			return nil, err
+		}
+		contentType = "application/json"
+	}
+
+	retries := c.retries
+	if method == http.MethodPost || reader != nil {
+		retries = 0
+	}
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		if data != nil {
+			reader = bytes.NewReader(data)
+		}
+		resp, err := c.attempt(ctx, method, u.String(), reader, contentType)
+		if err == nil || attempt == retries || !retryable(ctx, err) {
+			return resp, err
+		}
+		delay := wait
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.retryAfter > 0 {
+			delay = apiErr.retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+		wait = min(2*wait, maxRetryWait)
+	}
+}
+
+// attempt sends the request once.
+func (c *Client) attempt(ctx context.Context, method, target string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if c.household != 0 {
+		req.Header.Set("X-Household", strconv.Itoa(int(c.household)))
+	}
+	if c.actor != "" {
+		req.Header.Set("X-Actor", c.actor)
+	}
+	if tag, ok := ctx.Value(ifMatchKey{}).(string); ok {
+		req.Header.Set("If-Match", tag)
+	}
+	if ctx.Value(dryRunKey{}) != nil {
+		req.Header.Set("X-Dry-Run", "true")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	apiErr := &Error{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+	var e struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&e); err == nil && e.Error != "" {
+		apiErr.Message = e.Error
+	} else {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		apiErr.retryAfter = min(time.Duration(secs)*time.Second, maxRetryWait)
+	}
+	return nil, apiErr
+}
+
+// retryable reports whether a failed attempt is worth repeating: the server
+// said to try again later, or the request never got an answer.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	return true
+}
+
+func day(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func idPath(format string, id int32) string {
+	return fmt.Sprintf(format, id)
+}
@@ -0,0 +1,317 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdelles/currentz/internal/api"
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// The server's service interface covers everything FinanceService does, with
+// the same signatures.
+var _ FinanceService = api.FinanceServiceInterface(nil)
+
+// fakeService answers the handful of calls these tests make. Anything else
+// hits the nil embedded interface and panics, which fails the test.
+type fakeService struct {
+	api.FinanceServiceInterface
+	balance  float64
+	expenses []service.ExpenseInput
+	days     int
+	opts     service.ForecastOptions
+	version  int32
+	dryRuns  int
+}
+
+// DryRun counts dry runs and puts the balance back afterwards.
+func (f *fakeService) DryRun(ctx context.Context, fn func(context.Context) error) error {
+	f.dryRuns++
+	balance := f.balance
+	defer func() { f.balance = balance }()
+	return fn(ctx)
+}
+
+func (f *fakeService) OnEvent(fn func(service.Event)) {}
+
+func (f *fakeService) GetStartingBalance(ctx context.Context) (float64, error) {
+	return f.balance, nil
+}
+
+func (f *fakeService) SetStartingBalance(ctx context.Context, balance float64) error {
+	f.balance = balance
+	return nil
+}
+
+func (f *fakeService) Location(ctx context.Context) *time.Location {
+	return time.UTC
+}
+
+func (f *fakeService) RecordExpense(ctx context.Context, in service.ExpenseInput) (service.Transaction, []service.Warning, error) {
+	f.expenses = append(f.expenses, in)
+	tx := service.Transaction{ID: 7, Description: in.Description, Type: "expense"}
+	tx.Date = pgtype.Date{Time: in.Date, Valid: true}
+	return tx, []service.Warning{{Code: "budget_exceeded", Message: "over budget"}}, nil
+}
+
+func (f *fakeService) DeleteTransaction(ctx context.Context, id int32) error {
+	return fmt.Errorf("%w: %d", service.ErrTransactionNotFound, id)
+}
+
+// SetTransactionStatus treats transactions as being at version 2.
+func (f *fakeService) SetTransactionStatus(ctx context.Context, id int32, status string) (service.Transaction, error) {
+	f.version, _ = service.ExpectedVersion(ctx)
+	if f.version != 0 && f.version != 2 {
+		return service.Transaction{}, fmt.Errorf("%w: transaction %d", service.ErrVersionConflict, id)
+	}
+	return service.Transaction{ID: id, Status: status, Version: 3}, nil
+}
+
+func (f *fakeService) Sync(ctx context.Context, since int64, limit int) (service.SyncResult, error) {
+	if since > 10 {
+		return service.SyncResult{}, service.ErrStaleCursor
+	}
+	return service.SyncResult{Cursor: 10, More: limit == 1}, nil
+}
+
+func (f *fakeService) ForecastWithOptions(ctx context.Context, startingBalance float64, days int, opts service.ForecastOptions) ([]service.DailyCashFlow, error) {
+	f.opts = opts
+	return f.Forecast(ctx, startingBalance, days)
+}
+
+func (f *fakeService) ForecastSnapshotDates(ctx context.Context) ([]time.Time, error) {
+	return []time.Time{time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}, nil
+}
+
+func (f *fakeService) Forecast(ctx context.Context, startingBalance float64, days int) ([]service.DailyCashFlow, error) {
+	f.days = days
+	fc := make([]service.DailyCashFlow, days)
+	for i := range fc {
+		fc[i] = service.DailyCashFlow{Date: time.Date(2025, 6, 1+i, 0, 0, 0, 0, time.UTC), Balance: startingBalance}
+	}
+	return fc, nil
+}
+
+// GetTransactionsWithRecurringsBetween returns a recorded transaction and
+// an occurrence of recurring 3.
+func (f *fakeService) GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]service.ListedTransaction, error) {
+	recorded, _ := f.GetTransactionsBetween(ctx, start, end)
+	occurrence := pgtype.Date{Time: end, Valid: true}
+	return append(recorded, service.ListedTransaction{
+		Transaction:    service.Transaction{Description: "Rent", Type: "expense", Date: occurrence},
+		Source:         service.SourceRecurring,
+		RecurringID:    pgtype.Int4{Int32: 3, Valid: true},
+		OccurrenceDate: occurrence,
+	}), nil
+}
+
+func (f *fakeService) GetTransactionsBetween(ctx context.Context, start, end time.Time) ([]service.ListedTransaction, error) {
+	return service.OneOffs([]service.Transaction{
+		{ID: 7, Description: "Lunch", Type: "expense", Date: pgtype.Date{Time: start, Valid: true}},
+	}), nil
+}
+
+func newTestClient(t *testing.T, fake *fakeService, key string) *Client {
+	t.Helper()
+	server := api.NewAPIServer(fake)
+	server.SetAPIKey("s3cret")
+	ts := httptest.NewServer(server.SetupRoutes())
+	t.Cleanup(ts.Close)
+
+	c, err := New(ts.URL+"/", Options{APIKey: key})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	fake := &fakeService{}
+	c := newTestClient(t, fake, "s3cret")
+	ctx := context.Background()
+
+	require.NoError(t, c.SetStartingBalance(ctx, 1234.5))
+	balance, err := c.GetStartingBalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1234.5, balance)
+
+	date := time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC)
+	tx, warnings, err := c.RecordExpense(ctx, service.ExpenseInput{Date: date, Amount: 12.5, Description: "Lunch", Category: "food"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), tx.ID)
+	assert.Equal(t, date, tx.Date.Time)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "over budget", warnings[0].Message)
+	require.Len(t, fake.expenses, 1)
+	assert.Equal(t, "food", fake.expenses[0].Category)
+	assert.Equal(t, 12.5, fake.expenses[0].Amount)
+
+	fc, err := c.Forecast(ctx, 0, 14)
+	require.NoError(t, err)
+	assert.Equal(t, 14, fake.days)
+	require.Len(t, fc, 14)
+	assert.Equal(t, 1234.5, fc[13].Balance)
+}
+
+func TestClientTransactionsBetween(t *testing.T) {
+	c := newTestClient(t, &fakeService{}, "s3cret")
+	ctx := context.Background()
+	start, end := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	all, err := c.GetTransactionsWithRecurringsBetween(ctx, start, end)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, SourceOneOff, all[0].Source)
+	assert.False(t, all[0].RecurringID.Valid)
+	assert.Equal(t, SourceRecurring, all[1].Source)
+	assert.Equal(t, int32(3), all[1].RecurringID.Int32)
+	assert.Equal(t, end, all[1].OccurrenceDate.Time)
+
+	recorded, err := c.GetTransactionsBetween(ctx, start, end)
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, int32(7), recorded[0].ID)
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+
+	c := newTestClient(t, &fakeService{}, "s3cret")
+	err := c.DeleteTransaction(ctx, 42)
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "42")
+	assert.NotEmpty(t, apiErr.RequestID)
+	assert.Contains(t, apiErr.Error(), "(request "+apiErr.RequestID+")")
+
+	c = newTestClient(t, &fakeService{}, "wrong")
+	_, err = c.GetStartingBalance(ctx)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestClientSync(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, &fakeService{}, "s3cret")
+
+	res, err := c.Sync(ctx, 4, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), res.Cursor)
+	assert.True(t, res.More)
+
+	_, err = c.Sync(ctx, 11, 0)
+	assert.ErrorIs(t, err, ErrStaleCursor)
+}
+
+func TestClientVersions(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeService{}
+	c := newTestClient(t, fake, "s3cret")
+
+	tx, err := c.SetTransactionStatus(WithVersion(ctx, 2), 5, StatusCleared)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fake.version)
+	assert.Equal(t, int32(3), tx.Version)
+
+	_, err = c.SetTransactionStatus(WithVersion(ctx, 1), 5, StatusCleared)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	_, err = c.SetTransactionStatus(ctx, 5, StatusPending)
+	require.NoError(t, err)
+	assert.Zero(t, fake.version, "no version sends If-Match: *")
+}
+
+func TestClientDryRun(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeService{balance: 100}
+	c := newTestClient(t, fake, "s3cret")
+
+	err := c.DryRun(ctx, func(ctx context.Context) error {
+		_, err := c.GetStartingBalance(ctx)
+		require.NoError(t, err)
+		return c.SetStartingBalance(ctx, 50)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, fake.dryRuns, "only the change is a dry run")
+	assert.Equal(t, 100.0, fake.balance)
+}
+
+func TestNewRejectsBadURL(t *testing.T) {
+	for _, u := range []string{"", "localhost:8080", "ftp://host"} {
+		_, err := New(u, Options{})
+		assert.Error(t, err, u)
+	}
+}
+
+func TestClientForecastEndpoints(t *testing.T) {
+	fake := &fakeService{}
+	c := newTestClient(t, fake, "s3cret")
+	ctx := context.Background()
+
+	fc, err := c.Calculate90DayForecast(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 90, fake.days)
+	assert.Len(t, fc, 90)
+
+	_, err = c.ForecastWithOptions(ctx, 0, 30, ForecastOptions{ExcludePending: true})
+	require.NoError(t, err)
+	assert.Equal(t, 30, fake.days)
+	assert.Equal(t, ForecastOptions{ExcludePending: true}, fake.opts)
+
+	dates, err := c.ForecastSnapshotDates(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}, dates)
+
+	_, err = c.AggregateTransactions(ctx, AggregateFilter{Start: &dates[0]})
+	assert.Error(t, err)
+}
+
+func TestClientRetries(t *testing.T) {
+	var gets, posts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2", r.Header.Get("X-Household"))
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gets++
+		if gets < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"balance": 10}`))
+	}))
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	c, err := New(ts.URL, Options{RetryWait: time.Millisecond, Household: 2})
+	require.NoError(t, err)
+	balance, err := c.GetStartingBalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, balance)
+	assert.Equal(t, 3, gets)
+
+	// A POST may have been applied, so it is only sent once.
+	err = c.AddIncome(ctx, time.Now(), 5, "Gift", "")
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, 1, posts)
+
+	gets = 0
+	c, err = New(ts.URL, Options{Retries: -1, Household: 2})
+	require.NoError(t, err)
+	_, err = c.GetStartingBalance(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, gets)
+}
@@ -0,0 +1,13 @@
+package client
+
+import "context"
+
+type dryRunKey struct{}
+
+// DryRun runs fn with the changes it asks for sent as dry runs: the server
+// checks and answers each one as usual, then keeps nothing. Each request is
+// a dry run of its own, so unlike the service's DryRun, later requests in
+// fn don't see what earlier ones would have changed.
+func (c *Client) DryRun(ctx context.Context, fn func(context.Context) error) error {
+	return fn(context.WithValue(ctx, dryRunKey{}, true))
+}
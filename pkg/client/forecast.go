@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// Calculate90DayForecast projects the balance for the next 90 days.
+func (c *Client) Calculate90DayForecast(ctx context.Context, startingBalance float64) ([]DailyCashFlow, error) {
+	return c.Forecast(ctx, startingBalance, 90)
+}
+
+// Forecast projects the balance for days days. The server always starts
+// from its own stored balance, so startingBalance is ignored; callers pass
+// that balance anyway.
+func (c *Client) Forecast(ctx context.Context, startingBalance float64, days int) ([]DailyCashFlow, error) {
+	return c.ForecastWithOptions(ctx, startingBalance, days, ForecastOptions{})
+}
+
+// ForecastWithOptions is Forecast with opts applied.
+func (c *Client) ForecastWithOptions(ctx context.Context, startingBalance float64, days int, opts ForecastOptions) ([]DailyCashFlow, error) {
+	var fc []DailyCashFlow
+	err := c.do(ctx, http.MethodGet, "/api/forecast", forecastQuery(days, opts), nil, &fc)
+	return fc, err
+}
+
+// ForecastAll projects every account's balance, and the total, for days
+// days. Like Forecast, it starts from the server's stored balance.
+func (c *Client) ForecastAll(ctx context.Context, startingBalance float64, days int, opts ForecastOptions) (AllForecasts, error) {
+	var all AllForecasts
+	err := c.do(ctx, http.MethodGet, "/api/forecast/all", forecastQuery(days, opts), nil, &all)
+	return all, err
+}
+
+func forecastQuery(days int, opts ForecastOptions) url.Values {
+	if days <= 0 {
+		days = 90
+	}
+	q := url.Values{"days": {strconv.Itoa(days)}}
+	if opts.ExcludePending {
+		q.Set("include_pending", "false")
+	}
+	if opts.Envelopes {
+		q.Set("available", "true")
+	}
+	return q
+}
+
+// ForecastAsOf replays the forecast as it would have looked on asOf.
+func (c *Client) ForecastAsOf(ctx context.Context, asOf time.Time) ([]DailyCashFlow, error) {
+	var fc []DailyCashFlow
+	err := c.do(ctx, http.MethodGet, "/api/forecast", url.Values{"as_of": {day(asOf)}}, nil, &fc)
+	return fc, err
+}
+
+// ForecastHistory returns the forecast snapshot taken on day.
+func (c *Client) ForecastHistory(ctx context.Context, day time.Time) (ForecastSnapshot, error) {
+	var snapshot ForecastSnapshot
+	q := url.Values{"date": {day.Format("2006-01-02")}}
+	err := c.do(ctx, http.MethodGet, "/api/forecast/history", q, nil, &snapshot)
+	return snapshot, err
+}
+
+// ForecastSnapshotDates lists the days that have a forecast snapshot.
+func (c *Client) ForecastSnapshotDates(ctx context.Context) ([]time.Time, error) {
+	var resp struct {
+		Dates []string `json:"dates"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/forecast/history", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	days := make([]time.Time, len(resp.Dates))
+	for i, s := range resp.Dates {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, err
+		}
+		days[i] = d
+	}
+	return days, nil
+}
+
+// FindLowestPoint is computed locally; it needs no server data.
+func (c *Client) FindLowestPoint(forecast []DailyCashFlow) (DailyCashFlow, int) {
+	return service.LowestPoint(forecast)
+}
+
+// RecurringContributions totals what each recurring adds to or takes from
+// the next days days.
+func (c *Client) RecurringContributions(ctx context.Context, days int) ([]RecurringContribution, error) {
+	var rc []RecurringContribution
+	q := url.Values{"days": {strconv.Itoa(days)}}
+	err := c.do(ctx, http.MethodGet, "/api/forecast/contributions", q, nil, &rc)
+	return rc, err
+}
+
+// MonthlyOutlook summarizes the next months months.
+func (c *Client) MonthlyOutlook(ctx context.Context, months int) ([]MonthOutlook, error) {
+	var outlook []MonthOutlook
+	q := url.Values{"months": {strconv.Itoa(months)}}
+	err := c.do(ctx, http.MethodGet, "/api/forecast/monthly", q, nil, &outlook)
+	return outlook, err
+}
+
+// ForecastDays is the server's default forecast horizon, or the built-in
+// default if the settings can't be fetched.
+func (c *Client) ForecastDays(ctx context.Context) int {
+	var settings struct {
+		ForecastDays int `json:"forecast_days"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/settings", nil, nil, &settings); err != nil || settings.ForecastDays < 1 {
+		return service.DefaultForecastDays
+	}
+	return settings.ForecastDays
+}
+
+// Today returns the current day in the server's configured timezone,
+// falling back to UTC if the timezone can't be fetched.
+func (c *Client) Today(ctx context.Context) time.Time {
+	return service.LocalDay(time.Now(), c.Location(ctx))
+}
+
+// Location is the server's configured timezone, or UTC if it can't be
+// fetched. It is fetched once per client.
+func (c *Client) Location(ctx context.Context) *time.Location {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loc != nil {
+		return c.loc
+	}
+	var resp struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/timezone", nil, nil, &resp); err != nil {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(resp.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	c.loc = loc
+	return loc
+}
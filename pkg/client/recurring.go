@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RecurringSummaries lists recurrings with their schedule metadata.
+func (c *Client) RecurringSummaries(ctx context.Context) ([]RecurringSummary, error) {
+	var rs []RecurringSummary
+	err := c.do(ctx, http.MethodGet, "/api/recurring", nil, nil, &rs)
+	return rs, err
+}
+
+type recurringRequest struct {
+	Description string  `json:"description"`
+	Type        string  `json:"type"`
+	Amount      float64 `json:"amount"`
+	StartDate   string  `json:"start_date"`
+	Interval    string  `json:"interval"`
+	DayOfWeek   *int    `json:"day_of_week,omitempty"`
+	DayOfMonth  *int    `json:"day_of_month,omitempty"`
+	EndDate     *string `json:"end_date,omitempty"`
+	Active      bool    `json:"active"`
+	Currency    string  `json:"currency,omitempty"`
+	Prorate     bool    `json:"prorate,omitempty"`
+	// OccurrenceCount ends the recurring after that many occurrences.
+	OccurrenceCount *int `json:"occurrence_count,omitempty"`
+	// WeekOfMonth puts a monthly recurring on the nth day_of_week of the
+	// month (1-4, or -1 for the last).
+	WeekOfMonth *int `json:"week_of_month,omitempty"`
+	// BusinessDay moves a monthly occurrence off a weekend to the Friday
+	// before.
+	BusinessDay bool `json:"business_day,omitempty"`
+	// RRule is an iCalendar recurrence rule used instead of interval.
+	RRule string `json:"rrule,omitempty"`
+	// Category and AccountID are given to every occurrence.
+	Category  string `json:"category,omitempty"`
+	AccountID *int32 `json:"account_id,omitempty"`
+}
+
+// CreateRecurringSimple creates a recurring transaction.
+func (c *Client) CreateRecurringSimple(ctx context.Context, in RecurringInput) (Recurring, error) {
+	req := recurringRequest{
+		Description:     in.Description,
+		Type:            in.Type,
+		Amount:          in.Amount,
+		StartDate:       day(in.StartDate),
+		Interval:        in.Interval,
+		DayOfWeek:       in.DayOfWeek,
+		DayOfMonth:      in.DayOfMonth,
+		Active:          in.Active,
+		Currency:        in.Currency,
+		Prorate:         in.Prorate,
+		OccurrenceCount: in.OccurrenceCount,
+		WeekOfMonth:     in.WeekOfMonth,
+		BusinessDay:     in.BusinessDay,
+		RRule:           in.RRule,
+		Category:        in.Category,
+		AccountID:       in.AccountID,
+	}
+	if in.EndDate != nil {
+		end := day(*in.EndDate)
+		req.EndDate = &end
+	}
+	var r Recurring
+	err := c.do(ctx, http.MethodPost, "/api/recurring", nil, req, &r)
+	return r, err
+}
+
+// ImportRecurring creates each recurring in order, stopping at the first
+// failure. Unlike a local import, rows are only validated as they are sent.
+func (c *Client) ImportRecurring(ctx context.Context, inputs []RecurringInput) ([]Recurring, error) {
+	created := make([]Recurring, 0, len(inputs))
+	for i, in := range inputs {
+		r, err := c.CreateRecurringSimple(ctx, in)
+		if err != nil {
+			return created, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		created = append(created, r)
+	}
+	return created, nil
+}
+
+// ImportRecurringCSV uploads a CSV file of recurrings. The server checks
+// every row before creating any, and with dryRun creates none.
+func (c *Client) ImportRecurringCSV(ctx context.Context, r io.Reader, dryRun bool) (RecurringImportResult, error) {
+	var result RecurringImportResult
+	q := url.Values{"dry_run": {strconv.FormatBool(dryRun)}}
+	err := c.do(ctx, http.MethodPost, "/api/recurring/import", q, r, &result)
+	return result, err
+}
+
+// DeleteRecurring moves a recurring to the trash.
+func (c *Client) DeleteRecurring(ctx context.Context, id int32) error {
+	return c.do(ctx, http.MethodDelete, idPath("/api/recurring/%d", id), nil, nil, nil)
+}
+
+// SetRecurringActive turns a recurring on or off.
+func (c *Client) SetRecurringActive(ctx context.Context, id int32, active bool) error {
+	return c.edit(ctx, http.MethodPut, idPath("/api/recurring/%d/active", id), map[string]bool{"active": active}, nil)
+}
+
+// ReanchorRecurring moves a weekly or biweekly schedule.
+func (c *Client) ReanchorRecurring(ctx context.Context, id int32, in ReanchorInput) (Recurring, error) {
+	req := struct {
+		AnchorDate *string `json:"anchor_date,omitempty"`
+		ShiftWeeks int     `json:"shift_weeks,omitempty"`
+	}{ShiftWeeks: in.ShiftWeeks}
+	if in.AnchorDate != nil {
+		d := day(*in.AnchorDate)
+		req.AnchorDate = &d
+	}
+	var r Recurring
+	err := c.edit(ctx, http.MethodPut, idPath("/api/recurring/%d/anchor", id), req, &r)
+	return r, err
+}
+
+// PauseRecurring skips a recurring's occurrences from from through until.
+// With both nil it lifts the pause instead.
+func (c *Client) PauseRecurring(ctx context.Context, id int32, from, until *time.Time) (Recurring, error) {
+	var r Recurring
+	path := idPath("/api/recurring/%d/pause", id)
+	if from == nil && until == nil {
+		err := c.edit(ctx, http.MethodDelete, path, nil, &r)
+		return r, err
+	}
+	if from == nil || until == nil {
+		return r, fmt.Errorf("set both paused_from and paused_until, or neither")
+	}
+	req := map[string]string{"paused_from": day(*from), "paused_until": day(*until)}
+	err := c.edit(ctx, http.MethodPut, path, req, &r)
+	return r, err
+}
@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// GetStartingBalance returns the server's starting balance.
+func (c *Client) GetStartingBalance(ctx context.Context) (float64, error) {
+	var resp struct {
+		Balance float64 `json:"balance"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/balance", nil, nil, &resp)
+	return resp.Balance, err
+}
+
+// SetStartingBalance replaces the server's starting balance.
+func (c *Client) SetStartingBalance(ctx context.Context, balance float64) error {
+	return c.do(ctx, http.MethodPut, "/api/balance", nil, map[string]float64{"balance": balance}, nil)
+}
+
+type transactionRequest struct {
+	Date        string  `json:"date"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+	Currency    string  `json:"currency,omitempty"`
+	Category    string  `json:"category,omitempty"`
+	AccountID   *int32  `json:"account_id,omitempty"`
+}
+
+// AddIncome records a one-off income.
+func (c *Client) AddIncome(ctx context.Context, date time.Time, amount float64, description, currency string) error {
+	return c.do(ctx, http.MethodPost, "/api/transactions/income", nil, transactionRequest{
+		Date:        day(date),
+		Amount:      amount,
+		Description: description,
+		Currency:    currency,
+	}, nil)
+}
+
+// RecordExpense records a one-off expense and returns any budget or
+// low-balance warnings it triggered.
+func (c *Client) RecordExpense(ctx context.Context, in ExpenseInput) (Transaction, []Warning, error) {
+	var resp struct {
+		Transaction Transaction `json:"transaction"`
+		Warnings    []Warning   `json:"warnings"`
+	}
+	err := c.do(ctx, http.MethodPost, "/api/transactions/expense", nil, transactionRequest{
+		Date:        day(in.Date),
+		Amount:      in.Amount,
+		Description: in.Description,
+		Currency:    in.Currency,
+		Category:    in.Category,
+		AccountID:   in.AccountID,
+	}, &resp)
+	return resp.Transaction, resp.Warnings, err
+}
+
+// GetAllTransactions lists every recorded transaction.
+func (c *Client) GetAllTransactions(ctx context.Context) ([]Transaction, error) {
+	var txs []Transaction
+	err := c.do(ctx, http.MethodGet, "/api/transactions", nil, nil, &txs)
+	return txs, err
+}
+
+// GetTransactionsByStatus lists recorded transactions that are pending or
+// cleared.
+func (c *Client) GetTransactionsByStatus(ctx context.Context, status string) ([]Transaction, error) {
+	var txs []Transaction
+	err := c.do(ctx, http.MethodGet, "/api/transactions", url.Values{"status": {status}}, nil, &txs)
+	return txs, err
+}
+
+// SetTransactionStatus marks a transaction pending or cleared.
+func (c *Client) SetTransactionStatus(ctx context.Context, id int32, status string) (Transaction, error) {
+	var tx Transaction
+	err := c.edit(ctx, http.MethodPut, idPath("/api/transactions/%d/status", id), map[string]string{"status": status}, &tx)
+	return tx, err
+}
+
+// DeleteTransaction moves a transaction to the trash.
+func (c *Client) DeleteTransaction(ctx context.Context, id int32) error {
+	return c.do(ctx, http.MethodDelete, idPath("/api/transactions/%d", id), nil, nil, nil)
+}
+
+// GetTransactionsWithRecurringsBetween lists recorded transactions and
+// recurring occurrences from start to end inclusive.
+func (c *Client) GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]ListedTransaction, error) {
+	var txs []ListedTransaction
+	q := url.Values{"start": {day(start)}, "end": {day(end)}}
+	err := c.do(ctx, http.MethodGet, "/api/transactions/between", q, nil, &txs)
+	return txs, err
+}
+
+// GetTransactionsBetween lists only the recorded transactions from start
+// to end inclusive.
+func (c *Client) GetTransactionsBetween(ctx context.Context, start, end time.Time) ([]ListedTransaction, error) {
+	var txs []ListedTransaction
+	q := url.Values{"start": {day(start)}, "end": {day(end)}, "include_recurring": {"false"}}
+	err := c.do(ctx, http.MethodGet, "/api/transactions/between", q, nil, &txs)
+	return txs, err
+}
+
+// GetUpcomingTransactions lists the next days days of transactions.
+func (c *Client) GetUpcomingTransactions(ctx context.Context, days int) ([]ListedTransaction, error) {
+	var txs []ListedTransaction
+	q := url.Values{"days": {strconv.Itoa(days)}}
+	err := c.do(ctx, http.MethodGet, "/api/transactions/upcoming", q, nil, &txs)
+	return txs, err
+}
+
+// UpcomingWindow is the range GetUpcomingTransactions covers: today in the
+// server's timezone through days days later.
+func (c *Client) UpcomingWindow(ctx context.Context, days int) (time.Time, time.Time) {
+	start := c.Today(ctx)
+	return start, start.AddDate(0, 0, days)
+}
+
+// errUnsupportedFilter is returned for aggregate filters no endpoint takes.
+var errUnsupportedFilter = errors.New("aggregates over a date range need both start and end and include recurrings")
+
+// AggregateTransactions totals transactions on the server. The API offers
+// totals for a status, or for a date range with recurrings included;
+// other filters return an error.
+func (c *Client) AggregateTransactions(ctx context.Context, filter AggregateFilter) (TransactionAggregates, error) {
+	var agg TransactionAggregates
+	q := url.Values{"aggregates": {"only"}}
+	path := "/api/transactions"
+	switch {
+	case filter.Start == nil && filter.End == nil && !filter.IncludeRecurring:
+		if filter.Status != "" {
+			q.Set("status", filter.Status)
+		}
+	case filter.Start != nil && filter.End != nil && filter.IncludeRecurring && filter.Status == "":
+		path = "/api/transactions/between"
+		q.Set("start", day(*filter.Start))
+		q.Set("end", day(*filter.End))
+	default:
+		return agg, errUnsupportedFilter
+	}
+	err := c.do(ctx, http.MethodGet, path, q, nil, &agg)
+	return agg, err
+}
+
+// ImportTransactions uploads an export file. With dryRun nothing is saved.
+func (c *Client) ImportTransactions(ctx context.Context, format string, r io.Reader, dryRun bool) (ImportResult, error) {
+	var result ImportResult
+	q := url.Values{"format": {format}, "dry_run": {strconv.FormatBool(dryRun)}}
+	err := c.do(ctx, http.MethodPost, "/api/transactions/import", q, r, &result)
+	return result, err
+}
+
+// ExportTransactions writes recorded transactions as YNAB or Mint CSV to w.
+func (c *Client) ExportTransactions(ctx context.Context, format string, w io.Writer, start, end *time.Time) error {
+	q := url.Values{"format": {format}}
+	if start != nil {
+		q.Set("start", day(*start))
+	}
+	if end != nil {
+		q.Set("end", day(*end))
+	}
+	resp, err := c.send(ctx, http.MethodGet, "/api/transactions/export", q, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// Backup downloads every row as one document.
+func (c *Client) Backup(ctx context.Context) (Backup, error) {
+	var b Backup
+	err := c.do(ctx, http.MethodGet, "/api/backup", nil, nil, &b)
+	return b, err
+}
+
+// Restore replaces all of the server's data with b.
+func (c *Client) Restore(ctx context.Context, b Backup) (RestoreSummary, error) {
+	var summary RestoreSummary
+	err := c.do(ctx, http.MethodPost, "/api/restore", nil, b, &summary)
+	return summary, err
+}
+
+// ErrStaleCursor is returned by Sync when the server doesn't know the
+// cursor; sync again from 0.
+var ErrStaleCursor = service.ErrStaleCursor
+
+// Sync returns the transactions, recurrings and settings changed after the
+// cursor since, or everything for 0, at most limit of them (0 for the
+// server's default).
+func (c *Client) Sync(ctx context.Context, since int64, limit int) (SyncResult, error) {
+	q := url.Values{"since": {strconv.FormatInt(since, 10)}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var res SyncResult
+	err := c.do(ctx, http.MethodGet, "/api/sync", q, nil, &res)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusGone {
+		return SyncResult{}, fmt.Errorf("%w: %s", ErrStaleCursor, apiErr.Message)
+	}
+	return res, err
+}
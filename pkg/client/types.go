@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// The API's types are the service layer's. They are named here so that
+// programs outside this module, which can't import it, can use them.
+type (
+	Transaction           = service.Transaction
+	ListedTransaction     = service.ListedTransaction
+	TransactionAggregates = service.TransactionAggregates
+	AggregateFilter       = service.AggregateFilter
+	ExpenseInput          = service.ExpenseInput
+	Warning               = service.Warning
+	ImportResult          = service.ImportResult
+	RecurringImportResult = service.RecurringImportResult
+	Recurring             = service.Recurring
+	RecurringInput        = service.RecurringInput
+	RecurringSummary      = service.RecurringSummary
+	ReanchorInput         = service.ReanchorInput
+	DailyCashFlow         = service.DailyCashFlow
+	ForecastOptions       = service.ForecastOptions
+	ForecastSnapshot      = service.ForecastSnapshot
+	AccountForecast       = service.AccountForecast
+	AllForecasts          = service.AllForecasts
+	RecurringContribution = service.RecurringContribution
+	MonthOutlook          = service.MonthOutlook
+	Backup                = service.Backup
+	RestoreSummary        = service.RestoreSummary
+	SyncResult            = service.SyncResult
+)
+
+// Transaction statuses.
+const (
+	StatusPending = service.StatusPending
+	StatusCleared = service.StatusCleared
+)
+
+// Where a listed transaction comes from.
+const (
+	SourceOneOff    = service.SourceOneOff
+	SourceRecurring = service.SourceRecurring
+)
+
+// FinanceService is the part of the server's service layer the client
+// covers: balance, transactions, recurrings and forecasts. The server's own
+// service satisfies it as well as *Client, so code that depends on it can
+// run against either, or against a fake in tests.
+type FinanceService interface {
+	GetStartingBalance(ctx context.Context) (float64, error)
+	SetStartingBalance(ctx context.Context, balance float64) error
+
+	GetAllTransactions(ctx context.Context) ([]Transaction, error)
+	GetTransactionsByStatus(ctx context.Context, status string) ([]Transaction, error)
+	SetTransactionStatus(ctx context.Context, id int32, status string) (Transaction, error)
+	AddIncome(ctx context.Context, date time.Time, amount float64, description, currency string) error
+	RecordExpense(ctx context.Context, in ExpenseInput) (Transaction, []Warning, error)
+	DeleteTransaction(ctx context.Context, id int32) error
+	GetTransactionsWithRecurringsBetween(ctx context.Context, start, end time.Time) ([]ListedTransaction, error)
+	GetTransactionsBetween(ctx context.Context, start, end time.Time) ([]ListedTransaction, error)
+	GetUpcomingTransactions(ctx context.Context, days int) ([]ListedTransaction, error)
+	UpcomingWindow(ctx context.Context, days int) (time.Time, time.Time)
+	AggregateTransactions(ctx context.Context, filter AggregateFilter) (TransactionAggregates, error)
+	ImportTransactions(ctx context.Context, format string, r io.Reader, dryRun bool) (ImportResult, error)
+	ExportTransactions(ctx context.Context, format string, w io.Writer, start, end *time.Time) error
+
+	RecurringSummaries(ctx context.Context) ([]RecurringSummary, error)
+	CreateRecurringSimple(ctx context.Context, in RecurringInput) (Recurring, error)
+	ImportRecurringCSV(ctx context.Context, r io.Reader, dryRun bool) (RecurringImportResult, error)
+	DryRun(ctx context.Context, fn func(context.Context) error) error
+	DeleteRecurring(ctx context.Context, id int32) error
+	SetRecurringActive(ctx context.Context, id int32, active bool) error
+	ReanchorRecurring(ctx context.Context, id int32, in ReanchorInput) (Recurring, error)
+	PauseRecurring(ctx context.Context, id int32, from, until *time.Time) (Recurring, error)
+
+	Calculate90DayForecast(ctx context.Context, startingBalance float64) ([]DailyCashFlow, error)
+	Forecast(ctx context.Context, startingBalance float64, days int) ([]DailyCashFlow, error)
+	ForecastWithOptions(ctx context.Context, startingBalance float64, days int, opts ForecastOptions) ([]DailyCashFlow, error)
+	ForecastAll(ctx context.Context, startingBalance float64, days int, opts ForecastOptions) (AllForecasts, error)
+	ForecastAsOf(ctx context.Context, asOf time.Time) ([]DailyCashFlow, error)
+	ForecastHistory(ctx context.Context, day time.Time) (ForecastSnapshot, error)
+	ForecastSnapshotDates(ctx context.Context) ([]time.Time, error)
+	FindLowestPoint(forecast []DailyCashFlow) (DailyCashFlow, int)
+	RecurringContributions(ctx context.Context, days int) ([]RecurringContribution, error)
+	MonthlyOutlook(ctx context.Context, months int) ([]MonthOutlook, error)
+	ForecastDays(ctx context.Context) int
+
+	Location(ctx context.Context) *time.Location
+	Today(ctx context.Context) time.Time
+
+	Backup(ctx context.Context) (Backup, error)
+	Restore(ctx context.Context, b Backup) (RestoreSummary, error)
+
+	Sync(ctx context.Context, since int64, limit int) (SyncResult, error)
+}
+
+var (
+	_ FinanceService = (*Client)(nil)
+	_ FinanceService = (*service.FinanceService)(nil)
+)
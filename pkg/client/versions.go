@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jdelles/currentz/internal/service"
+)
+
+// ErrVersionConflict is returned by an edit made with WithVersion when the
+// record has changed since that version.
+var ErrVersionConflict = service.ErrVersionConflict
+
+// WithVersion makes SetTransactionStatus, SetRecurringActive,
+// ReanchorRecurring and PauseRecurring calls under ctx apply only to a
+// record still at version, the one last read. Without it they apply to
+// whatever version is current, as on the server's own service.
+func WithVersion(ctx context.Context, version int32) context.Context {
+	return service.WithVersion(ctx, version)
+}
+
+type ifMatchKey struct{}
+
+// edit sends a change to one record with If-Match set to the version in
+// ctx, or to * for any version.
+func (c *Client) edit(ctx context.Context, method, path string, body, out interface{}) error {
+	tag := "*"
+	if version, ok := service.ExpectedVersion(ctx); ok {
+		tag = fmt.Sprintf(`"%d"`, version)
+	}
+	err := c.do(context.WithValue(ctx, ifMatchKey{}, tag), method, path, nil, body, out)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		return fmt.Errorf("%w: %s", ErrVersionConflict, apiErr.Message)
+	}
+	return err
+}
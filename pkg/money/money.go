@@ -0,0 +1,141 @@
+// Package money does arithmetic on amounts held as whole cents, so sums and
+// splits come out exact instead of drifting the way float64 totals do.
+package money
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in cents of some currency. The currency itself is
+// tracked by the caller.
+type Money int64
+
+// FromCents returns c cents.
+func FromCents(c int64) Money { return Money(c) }
+
+// FromFloat converts a float amount such as 12.345 to the nearest cent,
+// rounding halves away from zero.
+func FromFloat(f float64) Money {
+	return Money(math.Round(f * 100))
+}
+
+// Parse reads a plain decimal amount like "-1234.5" or "0.07". Digits past
+// the second decimal place are rounded. Locale-formatted input ("1.234,56")
+// belongs in the amount package.
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || w < 0 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	for _, c := range frac {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+
+	cents := w * 100
+	padded := frac + "00"
+	c, _ := strconv.ParseInt(padded[:2], 10, 64)
+	cents += c
+	if len(frac) > 2 && frac[2] >= '5' {
+		cents++
+	}
+	if neg {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+// Cents returns m as a count of cents.
+func (m Money) Cents() int64 { return int64(m) }
+
+// Float64 returns m in whole units, for JSON and display.
+func (m Money) Float64() float64 { return float64(m) / 100 }
+
+// String formats m with two decimal places and no grouping, e.g. "-1234.50".
+func (m Money) String() string {
+	sign := ""
+	c := int64(m)
+	if c < 0 {
+		sign = "-"
+		c = -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
+
+func (m Money) Add(o Money) Money { return m + o }
+
+func (m Money) Sub(o Money) Money { return m - o }
+
+// Mul scales m by f, such as an exchange or tax rate, rounding to the
+// nearest cent.
+func (m Money) Mul(f float64) Money {
+	return Money(math.Round(float64(m) * f))
+}
+
+func (m Money) Neg() Money { return -m }
+
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Allocate splits m in proportion to weights so that the parts add up to m
+// exactly. Cents left over from rounding down go to the first parts, one
+// each. It returns nil if there are no weights or they sum to zero.
+func (m Money) Allocate(weights ...int) []Money {
+	total := 0
+	for _, w := range weights {
+		if w < 0 {
+			return nil
+		}
+		total += w
+	}
+	if total == 0 {
+		return nil
+	}
+
+	parts := make([]Money, len(weights))
+	left := m
+	for i, w := range weights {
+		parts[i] = Money(int64(m) * int64(w) / int64(total))
+		left -= parts[i]
+	}
+	step := Money(1)
+	if left < 0 {
+		step = -1
+	}
+	for i := 0; left != 0; i = (i + 1) % len(parts) {
+		if weights[i] == 0 {
+			continue
+		}
+		parts[i] += step
+		left -= step
+	}
+	return parts
+}
+
+// Sum adds up amounts.
+func Sum(amounts ...Money) Money {
+	var total Money
+	for _, a := range amounts {
+		total += a
+	}
+	return total
+}
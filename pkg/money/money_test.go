@@ -0,0 +1,62 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromFloat(t *testing.T) {
+	assert.Equal(t, Money(1235), FromFloat(12.345))
+	assert.Equal(t, Money(-1235), FromFloat(-12.345))
+	assert.Equal(t, Money(30), FromFloat(0.1+0.2))
+	assert.Equal(t, 0.3, FromFloat(0.1).Add(FromFloat(0.2)).Float64())
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+	}{
+		{"1234.5", 123450},
+		{"-0.07", -7},
+		{".5", 50},
+		{"+3", 300},
+		{"1.999", 200},
+		{" 42.00 ", 4200},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, in := range []string{"", "-", ".", "1.2a", "1,234.00", "--1"} {
+		_, err := Parse(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "0.00", Money(0).String())
+	assert.Equal(t, "-1234.50", Money(-123450).String())
+	assert.Equal(t, "0.07", Money(7).String())
+}
+
+func TestMul(t *testing.T) {
+	assert.Equal(t, Money(1080), FromCents(1000).Mul(1.08))
+	assert.Equal(t, Money(-333), FromCents(-1000).Mul(1.0/3))
+}
+
+func TestAllocate(t *testing.T) {
+	assert.Equal(t, []Money{34, 33, 33}, FromCents(100).Allocate(1, 1, 1))
+	assert.Equal(t, []Money{-34, -33, -33}, FromCents(-100).Allocate(1, 1, 1))
+	assert.Equal(t, []Money{75, 0, 25}, FromCents(100).Allocate(3, 0, 1))
+	assert.Equal(t, []Money{6667, 3333}, FromCents(10000).Allocate(2, 1))
+	assert.Nil(t, FromCents(100).Allocate())
+	assert.Nil(t, FromCents(100).Allocate(0, 0))
+
+	parts := FromCents(1001).Allocate(1, 2, 3, 4)
+	assert.Equal(t, Money(1001), Sum(parts...))
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS income_tax_details (
+    transaction_id INT PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE,
+    gross_amount   NUMERIC(12,2) NOT NULL,  -- pre-withholding pay for the income transaction
+    tax_withheld   NUMERIC(12,2) NOT NULL   -- income tax withheld from that pay
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS income_tax_details;
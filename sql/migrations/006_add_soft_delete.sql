@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_deleted_at ON transactions(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_recurring_deleted_at ON recurring_transactions(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_recurring_deleted_at;
+DROP INDEX IF EXISTS idx_transactions_deleted_at;
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS deleted_at;
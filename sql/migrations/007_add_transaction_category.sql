@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS category TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_category ON transactions(category);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_category;
+ALTER TABLE transactions DROP COLUMN IF EXISTS category;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Checking and savings accounts are part of the cash balance the forecast
+-- runs on. Credit card purchases are paid from cash on the statement's due
+-- date, so cards need the day each statement closes and the day it is due.
+CREATE TABLE IF NOT EXISTS accounts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('checking', 'savings', 'credit_card')),
+    statement_day INT CHECK (statement_day BETWEEN 1 AND 31),
+    due_day INT CHECK (due_day BETWEEN 1 AND 31),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (type <> 'credit_card' OR (statement_day IS NOT NULL AND due_day IS NOT NULL))
+);
+
+-- NULL means the transaction moved cash directly.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS account_id INT REFERENCES accounts(id);
+
+-- +goose Down
+ALTER TABLE transactions DROP COLUMN IF EXISTS account_id;
+DROP TABLE IF EXISTS accounts;
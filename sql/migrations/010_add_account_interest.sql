@@ -0,0 +1,13 @@
+-- +goose Up
+-- balance is the account's current balance, already counted in the starting
+-- balance; the forecast uses it only to work out interest. apy is a fraction
+-- (0.045 for 4.5%) and NULL means the account earns nothing.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS balance NUMERIC(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS apy NUMERIC(7,6) CHECK (apy >= 0 AND apy < 1);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS compounding VARCHAR(10) NOT NULL DEFAULT 'monthly'
+    CHECK (compounding IN ('daily', 'monthly'));
+
+-- +goose Down
+ALTER TABLE accounts DROP COLUMN IF EXISTS compounding;
+ALTER TABLE accounts DROP COLUMN IF EXISTS apy;
+ALTER TABLE accounts DROP COLUMN IF EXISTS balance;
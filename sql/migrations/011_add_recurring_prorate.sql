@@ -0,0 +1,11 @@
+-- +goose Up
+-- prorate spreads a yearly recurring over the twelve months leading up to
+-- each due date in the monthly view. The cash forecast still takes the full
+-- amount on the due date.
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS prorate BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE recurring_transactions ADD CONSTRAINT recurring_prorate_yearly
+    CHECK (NOT prorate OR "interval" = 'yearly');
+
+-- +goose Down
+ALTER TABLE recurring_transactions DROP CONSTRAINT IF EXISTS recurring_prorate_yearly;
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS prorate;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- A recurring skips every occurrence from paused_from through paused_until,
+-- both inclusive, and resumes on its normal schedule afterwards.
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS paused_from DATE;
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS paused_until DATE;
+ALTER TABLE recurring_transactions ADD CONSTRAINT recurring_pause_range
+    CHECK ((paused_from IS NULL) = (paused_until IS NULL) AND paused_from <= paused_until);
+
+-- +goose Down
+ALTER TABLE recurring_transactions DROP CONSTRAINT IF EXISTS recurring_pause_range;
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS paused_until;
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS paused_from;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- A pending transaction has been made but hasn't cleared the bank yet, like
+-- a check that hasn't been cashed, so the bank balance doesn't reflect it.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status VARCHAR(10) NOT NULL DEFAULT 'cleared'
+    CHECK (status IN ('pending', 'cleared'));
+
+CREATE INDEX IF NOT EXISTS idx_transactions_pending ON transactions(date) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_pending;
+ALTER TABLE transactions DROP COLUMN IF EXISTS status;
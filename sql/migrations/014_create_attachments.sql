@@ -0,0 +1,17 @@
+-- +goose Up
+-- Files kept with a transaction, such as receipts. The bytes live in blob
+-- storage under storage_key; this table only records what they are.
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_transaction ON attachments(transaction_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS attachments;
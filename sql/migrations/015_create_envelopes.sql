@@ -0,0 +1,17 @@
+-- +goose Up
+-- Envelopes earmark part of the balance for a future expense, such as car
+-- repairs. monthly_amount is set aside on start_date and on the same day of
+-- every month after, up to target if there is one; expenses in category
+-- spend it down.
+CREATE TABLE IF NOT EXISTS envelopes (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    monthly_amount NUMERIC(12,2) NOT NULL CHECK (monthly_amount > 0),
+    target NUMERIC(12,2) CHECK (target > 0),
+    start_date DATE NOT NULL,
+    category TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS envelopes;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Income transactions earned from self-employment, which owe estimated tax
+-- each quarter instead of having it withheld.
+CREATE TABLE IF NOT EXISTS self_employment_income (
+    transaction_id INT PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS self_employment_income;
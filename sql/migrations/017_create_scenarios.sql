@@ -0,0 +1,13 @@
+-- +goose Up
+-- Saved what-if scenarios. definition holds the hypothetical transactions
+-- and recurrings as JSON; they are laid over the real data each time the
+-- scenario is forecast, so they never touch the real tables.
+CREATE TABLE IF NOT EXISTS scenarios (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    definition JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS scenarios;
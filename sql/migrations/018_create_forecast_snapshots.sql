@@ -0,0 +1,13 @@
+-- +goose Up
+-- One copy of the computed forecast per day, so the outlook on a past day
+-- can be looked at again. The forecast column holds the daily balances as
+-- JSON and is overwritten through the day, leaving the last one taken.
+CREATE TABLE IF NOT EXISTS forecast_snapshots (
+    snapshot_date DATE PRIMARY KEY,
+    starting_balance NUMERIC(12,2) NOT NULL,
+    forecast JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS forecast_snapshots;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Where notifications go. config holds what the channel type needs to
+-- deliver, such as a webhook URL or bot token, as JSON.
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('slack', 'telegram')),
+    config JSONB NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Notifications already delivered, so each is sent once however often the
+-- job runs. key names the occurrence, such as the day or the bill.
+CREATE TABLE IF NOT EXISTS notifications_sent (
+    kind VARCHAR(30) NOT NULL,
+    key VARCHAR(100) NOT NULL,
+    sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (kind, key)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notifications_sent;
+DROP TABLE IF EXISTS notification_channels;
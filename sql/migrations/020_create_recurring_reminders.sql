@@ -0,0 +1,10 @@
+-- +goose Up
+-- How many days before each occurrence of a recurring to send a reminder
+-- through the notification channels.
+CREATE TABLE IF NOT EXISTS recurring_reminders (
+    recurring_id INT PRIMARY KEY REFERENCES recurring_transactions(id) ON DELETE CASCADE,
+    days_before INT NOT NULL CHECK (days_before BETWEEN 0 AND 60)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS recurring_reminders;
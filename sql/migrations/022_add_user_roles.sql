@@ -0,0 +1,8 @@
+-- +goose Up
+-- What a user may do: owners everything, editors change the household's
+-- data, viewers only read it. Users from before roles keep full access.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(10) NOT NULL DEFAULT 'owner'
+    CHECK (role IN ('owner', 'editor', 'viewer'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS role;
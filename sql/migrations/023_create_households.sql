@@ -0,0 +1,140 @@
+-- +goose Up
+-- Households share one database: every table of a household's data gets a
+-- household_id, and row security keeps each connection to the household it
+-- set in currentz.household, the first one when it set none. Queries never
+-- name the column: the default fills it in and the policies filter on it, so
+-- it is only ever added by the dynamic SQL below and the generated models
+-- don't change.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION current_household() RETURNS INT
+LANGUAGE sql STABLE AS $$
+    SELECT COALESCE(NULLIF(current_setting('currentz.household', true), '')::INT, 1)
+$$;
+-- +goose StatementEnd
+
+CREATE TABLE IF NOT EXISTS households (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The data from before households belongs to the first one.
+INSERT INTO households (name) SELECT 'Home' WHERE NOT EXISTS (SELECT 1 FROM households);
+
+-- Who belongs to which household, and what they may do in it.
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id INT NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (household_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_household_members_user_id ON household_members(user_id);
+
+INSERT INTO household_members (household_id, user_id, role)
+SELECT 1, id, role FROM users
+ON CONFLICT DO NOTHING;
+
+-- Invitations to join a household, by SHA-256 of the token. Each is used
+-- once.
+CREATE TABLE IF NOT EXISTS household_invitations (
+    id SERIAL PRIMARY KEY,
+    household_id INT NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    invited_by INT REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_by INT REFERENCES users(id) ON DELETE SET NULL,
+    accepted_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_household_invitations_household_id ON household_invitations(household_id);
+
+-- +goose StatementBegin
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'transactions', 'recurring_transactions', 'recurring_reminders',
+        'accounts', 'settings', 'exchange_rates', 'income_tax_details',
+        'self_employment_income', 'attachments', 'envelopes', 'scenarios',
+        'forecast_snapshots', 'notification_channels', 'notifications_sent',
+        'audit_log'
+    ] LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS household_id INT NOT NULL
+            DEFAULT current_household() REFERENCES households(id) ON DELETE CASCADE', t);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I(household_id)', 'idx_' || t || '_household_id', t);
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('DROP POLICY IF EXISTS household_isolation ON %I', t);
+        EXECUTE format('CREATE POLICY household_isolation ON %I
+            USING (household_id = current_household())
+            WITH CHECK (household_id = current_household())', t);
+    END LOOP;
+
+    -- Natural keys are per household now, under the same names.
+    EXECUTE 'ALTER TABLE settings DROP CONSTRAINT settings_pkey,
+        ADD CONSTRAINT settings_pkey PRIMARY KEY (household_id, key)';
+    EXECUTE 'ALTER TABLE exchange_rates DROP CONSTRAINT exchange_rates_pkey,
+        ADD CONSTRAINT exchange_rates_pkey PRIMARY KEY (household_id, currency)';
+    EXECUTE 'ALTER TABLE forecast_snapshots DROP CONSTRAINT forecast_snapshots_pkey,
+        ADD CONSTRAINT forecast_snapshots_pkey PRIMARY KEY (household_id, snapshot_date)';
+    EXECUTE 'ALTER TABLE notifications_sent DROP CONSTRAINT notifications_sent_pkey,
+        ADD CONSTRAINT notifications_sent_pkey PRIMARY KEY (household_id, kind, key)';
+    EXECUTE 'ALTER TABLE envelopes DROP CONSTRAINT envelopes_name_key,
+        ADD CONSTRAINT envelopes_name_key UNIQUE (household_id, name)';
+    EXECUTE 'ALTER TABLE scenarios DROP CONSTRAINT scenarios_name_key,
+        ADD CONSTRAINT scenarios_name_key UNIQUE (household_id, name)';
+    EXECUTE 'ALTER TABLE notification_channels DROP CONSTRAINT notification_channels_name_key,
+        ADD CONSTRAINT notification_channels_name_key UNIQUE (household_id, name)';
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Going back keeps only the first household's data.
+ALTER TABLE notification_channels DROP CONSTRAINT notification_channels_name_key;
+ALTER TABLE scenarios DROP CONSTRAINT scenarios_name_key;
+ALTER TABLE envelopes DROP CONSTRAINT envelopes_name_key;
+ALTER TABLE notifications_sent DROP CONSTRAINT notifications_sent_pkey;
+ALTER TABLE forecast_snapshots DROP CONSTRAINT forecast_snapshots_pkey;
+ALTER TABLE exchange_rates DROP CONSTRAINT exchange_rates_pkey;
+ALTER TABLE settings DROP CONSTRAINT settings_pkey;
+
+-- +goose StatementBegin
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'transactions', 'recurring_transactions', 'recurring_reminders',
+        'accounts', 'settings', 'exchange_rates', 'income_tax_details',
+        'self_employment_income', 'attachments', 'envelopes', 'scenarios',
+        'forecast_snapshots', 'notification_channels', 'notifications_sent',
+        'audit_log'
+    ] LOOP
+        EXECUTE format('DROP POLICY IF EXISTS household_isolation ON %I', t);
+        EXECUTE format('ALTER TABLE %I NO FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('DELETE FROM %I WHERE household_id <> 1', t);
+        EXECUTE format('ALTER TABLE %I DROP COLUMN household_id', t);
+    END LOOP;
+END
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE settings ADD CONSTRAINT settings_pkey PRIMARY KEY (key);
+ALTER TABLE exchange_rates ADD CONSTRAINT exchange_rates_pkey PRIMARY KEY (currency);
+ALTER TABLE forecast_snapshots ADD CONSTRAINT forecast_snapshots_pkey PRIMARY KEY (snapshot_date);
+ALTER TABLE notifications_sent ADD CONSTRAINT notifications_sent_pkey PRIMARY KEY (kind, key);
+ALTER TABLE envelopes ADD CONSTRAINT envelopes_name_key UNIQUE (name);
+ALTER TABLE scenarios ADD CONSTRAINT scenarios_name_key UNIQUE (name);
+ALTER TABLE notification_channels ADD CONSTRAINT notification_channels_name_key UNIQUE (name);
+
+DROP TABLE IF EXISTS household_invitations;
+DROP TABLE IF EXISTS household_members;
+DROP TABLE IF EXISTS households;
+DROP FUNCTION IF EXISTS current_household();
@@ -0,0 +1,76 @@
+-- +goose Up
+-- The last change to each transaction, recurring and setting, numbered
+-- from one sequence, so offline clients can ask for what changed after the
+-- last number they saw. Triggers keep it, so writes from the CLI, imports
+-- and restores count as well as the API's. Rows stay when their record is
+-- deleted, as its tombstone.
+CREATE SEQUENCE IF NOT EXISTS sync_seq;
+
+CREATE TABLE IF NOT EXISTS sync_changes (
+    entity VARCHAR(20) NOT NULL,
+    entity_id TEXT NOT NULL,
+    seq BIGINT NOT NULL,
+    -- seq when the record was inserted, to tell new records from changed
+    -- ones.
+    created_seq BIGINT NOT NULL
+);
+
+-- Per household, as in 023, without the generated models seeing the column.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    EXECUTE 'ALTER TABLE sync_changes ADD COLUMN IF NOT EXISTS household_id INT NOT NULL
+        DEFAULT current_household() REFERENCES households(id) ON DELETE CASCADE';
+    EXECUTE 'ALTER TABLE sync_changes ADD CONSTRAINT sync_changes_pkey PRIMARY KEY (household_id, entity, entity_id)';
+    EXECUTE 'CREATE INDEX IF NOT EXISTS idx_sync_changes_seq ON sync_changes(household_id, seq)';
+    EXECUTE 'ALTER TABLE sync_changes ENABLE ROW LEVEL SECURITY';
+    EXECUTE 'ALTER TABLE sync_changes FORCE ROW LEVEL SECURITY';
+    EXECUTE 'CREATE POLICY household_isolation ON sync_changes
+        USING (household_id = current_household())
+        WITH CHECK (household_id = current_household())';
+END
+$$;
+-- +goose StatementEnd
+
+-- note_sync_change(entity, key column) records a write to the row.
+-- Writers in a household take turns from their first change until they
+-- commit, so numbers become visible in order: a client that has seen 10
+-- can't later miss a 9 that was still being written.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION note_sync_change() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+    r RECORD;
+    s BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        r := OLD;
+    ELSE
+        r := NEW;
+    END IF;
+    PERFORM pg_advisory_xact_lock(hashtext('sync_changes'), r.household_id);
+    s := nextval('sync_seq');
+    INSERT INTO sync_changes (household_id, entity, entity_id, seq, created_seq)
+    VALUES (r.household_id, TG_ARGV[0], to_jsonb(r) ->> TG_ARGV[1], s, s)
+    ON CONFLICT (household_id, entity, entity_id) DO UPDATE
+    SET seq = EXCLUDED.seq,
+        created_seq = CASE WHEN TG_OP = 'INSERT' THEN EXCLUDED.created_seq ELSE sync_changes.created_seq END;
+    RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER transactions_sync AFTER INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION note_sync_change('transaction', 'id');
+CREATE TRIGGER recurring_transactions_sync AFTER INSERT OR UPDATE OR DELETE ON recurring_transactions
+    FOR EACH ROW EXECUTE FUNCTION note_sync_change('recurring', 'id');
+CREATE TRIGGER settings_sync AFTER INSERT OR UPDATE OR DELETE ON settings
+    FOR EACH ROW EXECUTE FUNCTION note_sync_change('setting', 'key');
+
+-- +goose Down
+DROP TRIGGER IF EXISTS settings_sync ON settings;
+DROP TRIGGER IF EXISTS recurring_transactions_sync ON recurring_transactions;
+DROP TRIGGER IF EXISTS transactions_sync ON transactions;
+DROP FUNCTION IF EXISTS note_sync_change();
+DROP TABLE IF EXISTS sync_changes;
+DROP SEQUENCE IF EXISTS sync_seq;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- How many times each transaction and recurring has been written, so an
+-- edit can say which version it was based on and be refused if someone else
+-- changed the record since.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS version;
+ALTER TABLE transactions DROP COLUMN IF EXISTS version;
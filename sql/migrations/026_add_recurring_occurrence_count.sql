@@ -0,0 +1,8 @@
+-- +goose Up
+-- Ends a recurring after this many occurrences, as for an installment plan,
+-- whether or not it also has an end date. Paused occurrences don't count.
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS occurrence_count INT
+    CONSTRAINT recurring_occurrence_count_positive CHECK (occurrence_count > 0);
+
+-- +goose Down
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS occurrence_count;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Monthly rules beyond a fixed day of the month. week_of_month picks the
+-- nth day_of_week of the month (1-4, or -1 for the last), as in "the 2nd
+-- Friday". business_day moves an occurrence that lands on a weekend back to
+-- the Friday before, so day_of_month 31 with it is the last business day.
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS week_of_month INT
+    CONSTRAINT recurring_week_of_month_range CHECK (week_of_month BETWEEN 1 AND 4 OR week_of_month = -1);
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS business_day BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS business_day;
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS week_of_month;
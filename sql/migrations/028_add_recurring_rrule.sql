@@ -0,0 +1,12 @@
+-- +goose Up
+-- A recurring can follow an iCalendar RRULE (RFC 5545) instead of one of
+-- the fixed intervals. Its interval is then 'custom' and the rule alone
+-- decides which days it falls on.
+ALTER TYPE recurrence_interval ADD VALUE IF NOT EXISTS 'custom';
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS rrule TEXT
+    CONSTRAINT recurring_rrule_custom CHECK ((rrule IS NOT NULL) = ("interval"::text = 'custom'));
+
+-- +goose Down
+-- Postgres can't drop a value from an enum, so 'custom' stays; recurrings
+-- using it lose their rule and no longer expand.
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS rrule;
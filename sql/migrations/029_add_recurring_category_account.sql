@@ -0,0 +1,10 @@
+-- +goose Up
+-- A recurring can carry the same category and account a one-off does, and
+-- its occurrences inherit them. NULL keeps the old behaviour: uncategorized,
+-- moving cash directly.
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS category TEXT;
+ALTER TABLE recurring_transactions ADD COLUMN IF NOT EXISTS account_id INT REFERENCES accounts(id);
+
+-- +goose Down
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS account_id;
+ALTER TABLE recurring_transactions DROP COLUMN IF EXISTS category;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- Cleared transactions older than a household's retention period, moved
+-- out of transactions by the archival job so the tables the forecast reads
+-- stay small. Rows keep the id they had, which the shared sequence never
+-- hands out again.
+CREATE TABLE IF NOT EXISTS archived_transactions (
+    id INT NOT NULL,
+    date DATE NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    description TEXT NOT NULL,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('income', 'expense')),
+    created_at TIMESTAMP,
+    category TEXT,
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    account_id INT REFERENCES accounts(id) ON DELETE SET NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per household, as in 023, without the generated models seeing the column.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    EXECUTE 'ALTER TABLE archived_transactions ADD COLUMN IF NOT EXISTS household_id INT NOT NULL
+        DEFAULT current_household() REFERENCES households(id) ON DELETE CASCADE';
+    EXECUTE 'ALTER TABLE archived_transactions ADD CONSTRAINT archived_transactions_pkey PRIMARY KEY (household_id, id)';
+    EXECUTE 'CREATE INDEX IF NOT EXISTS idx_archived_transactions_date ON archived_transactions(household_id, date)';
+    EXECUTE 'ALTER TABLE archived_transactions ENABLE ROW LEVEL SECURITY';
+    EXECUTE 'ALTER TABLE archived_transactions FORCE ROW LEVEL SECURITY';
+    EXECUTE 'CREATE POLICY household_isolation ON archived_transactions
+        USING (household_id = current_household())
+        WITH CHECK (household_id = current_household())';
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Archived rows go back where they came from rather than being lost.
+INSERT INTO transactions (id, date, amount, description, type, created_at, category, currency, account_id, household_id)
+SELECT id, date, amount, description, type, created_at, category, currency, account_id, household_id
+FROM archived_transactions
+ON CONFLICT (id) DO NOTHING;
+DROP TABLE IF EXISTS archived_transactions;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Covers the spending heatmap: live expenses by date with their amounts
+-- and currencies, so it is answered from the index alone. Behind household_id like the
+-- rest of 023, without the generated models seeing the column.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    EXECUTE 'CREATE INDEX IF NOT EXISTS idx_transactions_spending ON transactions(household_id, date)
+        INCLUDE (amount, currency) WHERE deleted_at IS NULL AND type = ''expense''';
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_spending;
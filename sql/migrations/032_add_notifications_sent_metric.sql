@@ -0,0 +1,8 @@
+-- +goose Up
+-- The figure a notification reported, such as the lowest projected
+-- balance, so the next of its kind can wait until it has moved by more than
+-- the notification_min_change setting. NULL when it reported none.
+ALTER TABLE notifications_sent ADD COLUMN IF NOT EXISTS metric NUMERIC(12,2);
+
+-- +goose Down
+ALTER TABLE notifications_sent DROP COLUMN IF EXISTS metric;
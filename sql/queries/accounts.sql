@@ -0,0 +1,19 @@
+-- name: CreateAccount :one
+INSERT INTO accounts (name, type, statement_day, due_day)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, type, statement_day, due_day, created_at, balance, apy, compounding;
+
+-- name: ListAccounts :many
+SELECT id, name, type, statement_day, due_day, created_at, balance, apy, compounding FROM accounts ORDER BY id;
+
+-- name: GetAccountByID :one
+SELECT id, name, type, statement_day, due_day, created_at, balance, apy, compounding FROM accounts WHERE id = $1;
+
+-- name: SetAccountInterest :one
+UPDATE accounts
+SET balance = $1, apy = $2, compounding = $3
+WHERE id = $4
+RETURNING id, name, type, statement_day, due_day, created_at, balance, apy, compounding;
+
+-- name: DeleteAccount :exec
+DELETE FROM accounts WHERE id = $1;
@@ -0,0 +1,34 @@
+-- name: ArchiveTransactions :execrows
+-- Moves cleared transactions dated before the cutoff into the archive.
+-- Pending ones still count toward the forecast, and ones with attachments
+-- keep them, so both stay.
+WITH moved AS (
+  DELETE FROM transactions t
+  WHERE t.date < sqlc.arg(cutoff)
+    AND t.deleted_at IS NULL
+    AND t.status = 'cleared'
+    AND NOT EXISTS (SELECT 1 FROM attachments a WHERE a.transaction_id = t.id)
+  RETURNING t.id, t.date, t.amount, t.description, t.type, t.created_at, t.category, t.currency, t.account_id
+)
+INSERT INTO archived_transactions (id, date, amount, description, type, created_at, category, currency, account_id)
+SELECT id, date, amount, description, type, created_at, category, currency, account_id
+FROM moved;
+
+-- name: ListArchivedTransactions :many
+SELECT id, date, amount, description, type, created_at, category, currency, account_id, archived_at
+FROM archived_transactions
+WHERE (sqlc.narg(start_date)::date IS NULL OR date >= sqlc.narg(start_date))
+  AND (sqlc.narg(end_date)::date IS NULL OR date <= sqlc.narg(end_date))
+ORDER BY date ASC, id ASC;
+
+-- name: ListArchivedTransactionsForBackup :many
+SELECT id, date, amount, description, type, created_at, category, currency, account_id, archived_at
+FROM archived_transactions
+ORDER BY id;
+
+-- name: DeleteAllArchivedTransactions :exec
+DELETE FROM archived_transactions;
+
+-- name: InsertBackupArchivedTransaction :exec
+INSERT INTO archived_transactions (id, date, amount, description, type, created_at, category, currency, account_id, archived_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
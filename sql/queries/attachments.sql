@@ -0,0 +1,27 @@
+-- name: CreateAttachment :one
+INSERT INTO attachments (transaction_id, filename, content_type, size, storage_key)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, transaction_id, filename, content_type, size, storage_key, created_at;
+
+-- name: ListAttachments :many
+SELECT id, transaction_id, filename, content_type, size, storage_key, created_at
+FROM attachments
+WHERE transaction_id = $1
+ORDER BY id;
+
+-- name: GetAttachment :one
+SELECT id, transaction_id, filename, content_type, size, storage_key, created_at
+FROM attachments
+WHERE id = $1 AND transaction_id = $2;
+
+-- name: DeleteAttachment :execrows
+DELETE FROM attachments WHERE id = $1 AND transaction_id = $2;
+
+-- name: ListPurgeableAttachments :many
+-- Attachments whose transactions PurgeDeletedTransactions is about to
+-- remove, so their blobs can be removed too.
+SELECT a.id, a.transaction_id, a.filename, a.content_type, a.size, a.storage_key, a.created_at
+FROM attachments a
+JOIN transactions t ON t.id = a.transaction_id
+WHERE t.deleted_at IS NOT NULL AND t.deleted_at < sqlc.arg(cutoff)
+ORDER BY a.id;
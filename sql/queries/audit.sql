@@ -0,0 +1,19 @@
+-- name: CreateAuditEntry :exec
+INSERT INTO audit_log (actor, action, entity, entity_id, before_data, after_data)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListAuditEntries :many
+SELECT * FROM audit_log
+WHERE (sqlc.narg(entity)::text IS NULL OR entity = sqlc.narg(entity))
+  AND (sqlc.narg(entity_id)::text IS NULL OR entity_id = sqlc.narg(entity_id))
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(actor)::text IS NULL OR actor = sqlc.narg(actor))
+  AND (sqlc.narg(since)::timestamp IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until))
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListAuditHistory :many
+SELECT * FROM audit_log
+WHERE entity = ANY(sqlc.arg(entities)::text[])
+ORDER BY id;
@@ -0,0 +1,136 @@
+-- name: ListTransactionsForBackup :many
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+ORDER BY id;
+
+-- name: ListRecurringForBackup :many
+SELECT id, description, type, amount, start_date, interval, day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate, paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id
+FROM recurring_transactions
+ORDER BY id;
+
+-- name: ListIncomeTaxDetails :many
+SELECT transaction_id, gross_amount, tax_withheld
+FROM income_tax_details
+ORDER BY transaction_id;
+
+-- name: ListSelfEmploymentIncome :many
+SELECT transaction_id
+FROM self_employment_income
+ORDER BY transaction_id;
+
+-- name: DeleteAllTransactions :exec
+DELETE FROM transactions;
+
+-- name: DeleteAllRecurring :exec
+DELETE FROM recurring_transactions;
+
+-- name: DeleteAllSettings :exec
+DELETE FROM settings;
+
+-- name: DeleteAllAccounts :exec
+DELETE FROM accounts;
+
+-- name: DeleteAllExchangeRates :exec
+DELETE FROM exchange_rates;
+
+-- name: InsertBackupTransaction :exec
+INSERT INTO transactions (id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+
+-- name: InsertBackupRecurring :exec
+INSERT INTO recurring_transactions (
+  id, description, type, amount, start_date, "interval",
+  day_of_week, day_of_month, end_date, active, deleted_at, currency, prorate,
+  paused_from, paused_until, version, occurrence_count, week_of_month, business_day, rrule, category, account_id
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22);
+
+-- name: ListAccountsForBackup :many
+SELECT id, name, type, statement_day, due_day, created_at, balance, apy, compounding
+FROM accounts
+ORDER BY id;
+
+-- name: InsertBackupAccount :exec
+INSERT INTO accounts (id, name, type, statement_day, due_day, created_at, balance, apy, compounding)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: ListExchangeRatesForBackup :many
+SELECT currency, rate, updated_at
+FROM exchange_rates
+ORDER BY currency;
+
+-- name: InsertBackupExchangeRate :exec
+INSERT INTO exchange_rates (currency, rate, updated_at)
+VALUES ($1, $2, $3);
+
+-- name: InsertBackupSetting :exec
+INSERT INTO settings (key, value, updated_at)
+VALUES ($1, $2, $3);
+
+-- The sequences are shared by every household, so these only ever move
+-- them forward.
+
+-- name: ResetTransactionsSequence :exec
+SELECT setval(pg_get_serial_sequence('transactions', 'id'),
+    GREATEST(COALESCE(MAX(id), 0) + 1, nextval(pg_get_serial_sequence('transactions', 'id'))), false)
+FROM (SELECT id FROM transactions UNION ALL SELECT id FROM archived_transactions) AS ids;
+
+-- name: ResetRecurringSequence :exec
+SELECT setval(pg_get_serial_sequence('recurring_transactions', 'id'),
+    GREATEST(COALESCE(MAX(id), 0) + 1, nextval(pg_get_serial_sequence('recurring_transactions', 'id'))), false)
+FROM recurring_transactions;
+
+-- name: ResetAccountsSequence :exec
+SELECT setval(pg_get_serial_sequence('accounts', 'id'),
+    GREATEST(COALESCE(MAX(id), 0) + 1, nextval(pg_get_serial_sequence('accounts', 'id'))), false)
+FROM accounts;
+
+-- name: ListEnvelopesForBackup :many
+SELECT id, name, monthly_amount, target, start_date, category, created_at
+FROM envelopes
+ORDER BY id;
+
+-- name: DeleteAllEnvelopes :exec
+DELETE FROM envelopes;
+
+-- name: InsertBackupEnvelope :exec
+INSERT INTO envelopes (id, name, monthly_amount, target, start_date, category, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ResetEnvelopesSequence :exec
+SELECT setval(pg_get_serial_sequence('envelopes', 'id'),
+    GREATEST(COALESCE(MAX(id), 0) + 1, nextval(pg_get_serial_sequence('envelopes', 'id'))), false)
+FROM envelopes;
+
+-- name: ListScenariosForBackup :many
+SELECT id, name, definition, created_at
+FROM scenarios
+ORDER BY id;
+
+-- name: DeleteAllScenarios :exec
+DELETE FROM scenarios;
+
+-- name: InsertBackupScenario :exec
+INSERT INTO scenarios (id, name, definition, created_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: ResetScenariosSequence :exec
+SELECT setval(pg_get_serial_sequence('scenarios', 'id'),
+    GREATEST(COALESCE(MAX(id), 0) + 1, nextval(pg_get_serial_sequence('scenarios', 'id'))), false)
+FROM scenarios;
+
+-- name: ListNotificationChannelsForBackup :many
+SELECT id, name, type, config, enabled, created_at
+FROM notification_channels
+ORDER BY id;
+
+-- name: DeleteAllNotificationChannels :exec
+DELETE FROM notification_channels;
+
+-- name: InsertBackupNotificationChannel :exec
+INSERT INTO notification_channels (id, name, type, config, enabled, created_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ResetNotificationChannelsSequence :exec
+SELECT setval(pg_get_serial_sequence('notification_channels', 'id'),
+    GREATEST(COALESCE(MAX(id), 0) + 1, nextval(pg_get_serial_sequence('notification_channels', 'id'))), false)
+FROM notification_channels;
@@ -0,0 +1,12 @@
+-- name: ListExchangeRates :many
+SELECT currency, rate, updated_at FROM exchange_rates ORDER BY currency;
+
+-- name: UpsertExchangeRate :one
+INSERT INTO exchange_rates (currency, rate, updated_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT ON CONSTRAINT exchange_rates_pkey
+DO UPDATE SET rate = $2, updated_at = CURRENT_TIMESTAMP
+RETURNING currency, rate, updated_at;
+
+-- name: DeleteExchangeRate :execrows
+DELETE FROM exchange_rates WHERE currency = $1;
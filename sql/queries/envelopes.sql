@@ -0,0 +1,23 @@
+-- name: CreateEnvelope :one
+INSERT INTO envelopes (name, monthly_amount, target, start_date, category)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, name, monthly_amount, target, start_date, category, created_at;
+
+-- name: ListEnvelopes :many
+SELECT id, name, monthly_amount, target, start_date, category, created_at
+FROM envelopes
+ORDER BY id;
+
+-- name: GetEnvelope :one
+SELECT id, name, monthly_amount, target, start_date, category, created_at
+FROM envelopes
+WHERE id = $1;
+
+-- name: UpdateEnvelope :one
+UPDATE envelopes
+SET name = $1, monthly_amount = $2, target = $3, start_date = $4, category = $5
+WHERE id = $6
+RETURNING id, name, monthly_amount, target, start_date, category, created_at;
+
+-- name: DeleteEnvelope :execrows
+DELETE FROM envelopes WHERE id = $1;
@@ -0,0 +1,17 @@
+-- name: UpsertForecastSnapshot :exec
+INSERT INTO forecast_snapshots (snapshot_date, starting_balance, forecast)
+VALUES ($1, $2, $3)
+ON CONFLICT ON CONSTRAINT forecast_snapshots_pkey DO UPDATE
+SET starting_balance = EXCLUDED.starting_balance,
+    forecast = EXCLUDED.forecast,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: GetForecastSnapshot :one
+SELECT snapshot_date, starting_balance, forecast, created_at
+FROM forecast_snapshots
+WHERE snapshot_date = $1;
+
+-- name: ListForecastSnapshotDates :many
+SELECT snapshot_date
+FROM forecast_snapshots
+ORDER BY snapshot_date;
@@ -0,0 +1,80 @@
+-- name: CreateHousehold :one
+INSERT INTO households (name)
+VALUES ($1)
+RETURNING id, name, created_at;
+
+-- name: GetHousehold :one
+SELECT id, name, created_at
+FROM households
+WHERE id = $1;
+
+-- name: ListHouseholds :many
+SELECT id, name, created_at
+FROM households
+ORDER BY id;
+
+-- name: DeleteHousehold :execrows
+DELETE FROM households WHERE id = $1;
+
+-- name: ListUserHouseholds :many
+SELECT h.id, h.name, h.created_at, m.role
+FROM households h
+JOIN household_members m ON m.household_id = h.id
+WHERE m.user_id = $1
+ORDER BY h.id;
+
+-- name: AddHouseholdMember :one
+INSERT INTO household_members (household_id, user_id, role)
+VALUES ($1, $2, $3)
+RETURNING household_id, user_id, role, created_at;
+
+-- name: GetHouseholdMember :one
+SELECT household_id, user_id, role, created_at
+FROM household_members
+WHERE household_id = $1 AND user_id = $2;
+
+-- name: ListHouseholdMembers :many
+SELECT m.user_id, u.username, m.role, m.created_at
+FROM household_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.household_id = $1
+ORDER BY m.user_id;
+
+-- name: SetHouseholdMemberRole :execrows
+UPDATE household_members SET role = $3
+WHERE household_id = $1 AND user_id = $2;
+
+-- name: CountHouseholdOwners :one
+SELECT COUNT(*) FROM household_members
+WHERE household_id = $1 AND role = 'owner';
+
+-- name: RemoveHouseholdMember :execrows
+DELETE FROM household_members
+WHERE household_id = $1 AND user_id = $2;
+
+-- name: CreateHouseholdInvitation :one
+INSERT INTO household_invitations (household_id, role, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, household_id, role, token_hash, invited_by, expires_at, accepted_by, accepted_at, created_at;
+
+-- name: GetHouseholdInvitationByToken :one
+SELECT id, household_id, role, token_hash, invited_by, expires_at, accepted_by, accepted_at, created_at
+FROM household_invitations
+WHERE token_hash = $1;
+
+-- name: ListHouseholdInvitations :many
+SELECT id, household_id, role, token_hash, invited_by, expires_at, accepted_by, accepted_at, created_at
+FROM household_invitations
+WHERE household_id = $1
+ORDER BY id;
+
+-- name: AcceptHouseholdInvitation :execrows
+-- Accepting an invitation that was already used changes nothing, so the
+-- second of two racing accepts sees 0 rows.
+UPDATE household_invitations
+SET accepted_by = $2, accepted_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND accepted_at IS NULL;
+
+-- name: DeleteHouseholdInvitation :execrows
+DELETE FROM household_invitations
+WHERE household_id = $1 AND id = $2;
@@ -0,0 +1,41 @@
+-- name: CreateNotificationChannel :one
+INSERT INTO notification_channels (name, type, config, enabled)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, type, config, enabled, created_at;
+
+-- name: ListNotificationChannels :many
+SELECT id, name, type, config, enabled, created_at
+FROM notification_channels
+ORDER BY id;
+
+-- name: GetNotificationChannel :one
+SELECT id, name, type, config, enabled, created_at
+FROM notification_channels
+WHERE id = $1;
+
+-- name: UpdateNotificationChannel :one
+UPDATE notification_channels
+SET name = $1, type = $2, config = $3, enabled = $4
+WHERE id = $5
+RETURNING id, name, type, config, enabled, created_at;
+
+-- name: DeleteNotificationChannel :execrows
+DELETE FROM notification_channels WHERE id = $1;
+
+-- name: LastNotificationMetric :one
+-- The figure the latest notification of kind reported, leaving out those
+-- that reported none.
+SELECT metric FROM notifications_sent
+WHERE kind = $1 AND metric IS NOT NULL
+ORDER BY sent_at DESC, key DESC
+LIMIT 1;
+
+-- name: NotificationSent :one
+SELECT EXISTS (
+    SELECT 1 FROM notifications_sent WHERE kind = $1 AND key = $2
+);
+
+-- name: RecordNotification :exec
+INSERT INTO notifications_sent (kind, key, metric)
+VALUES ($1, $2, $3)
+ON CONFLICT ON CONSTRAINT notifications_sent_pkey DO NOTHING;
@@ -8,7 +8,15 @@ INSERT INTO recurring_transactions (
   day_of_week,
   day_of_month,
   end_date,
-  active
+  active,
+  currency,
+  prorate,
+  occurrence_count,
+  week_of_month,
+  business_day,
+  rrule,
+  category,
+  account_id
 ) VALUES (
   sqlc.arg(description),
   sqlc.arg(type),
@@ -18,23 +26,61 @@ INSERT INTO recurring_transactions (
   sqlc.arg(day_of_week),
   sqlc.arg(day_of_month),
   sqlc.arg(end_date),
-  sqlc.arg(active)
+  sqlc.arg(active),
+  sqlc.arg(currency),
+  sqlc.arg(prorate),
+  sqlc.narg(occurrence_count),
+  sqlc.narg(week_of_month),
+  sqlc.arg(business_day),
+  sqlc.narg(rrule),
+  sqlc.narg(category),
+  sqlc.narg(account_id)
 )
 RETURNING *;
 
 -- name: GetRecurringByID :one
-SELECT * FROM recurring_transactions WHERE id = sqlc.arg(id);
+SELECT * FROM recurring_transactions WHERE id = sqlc.arg(id) AND deleted_at IS NULL;
 
 -- name: ListRecurring :many
-SELECT * FROM recurring_transactions ORDER BY id;
+SELECT * FROM recurring_transactions WHERE deleted_at IS NULL ORDER BY id;
 
--- name: DeleteRecurring :exec
-DELETE FROM recurring_transactions WHERE id = sqlc.arg(id);
+-- name: ListRecurringSorted :many
+-- Live recurrings sorted on sort_key (date, meaning start_date, amount or
+-- description) either way, or by id alone when it is empty. Ties are
+-- broken and pages taken as in ListTransactionsSorted.
+SELECT * FROM recurring_transactions
+WHERE deleted_at IS NULL
+  AND CASE
+    WHEN sqlc.arg(sort_key)::text = 'date' AND sqlc.arg(descending)::bool THEN (start_date, id) < (sqlc.narg(after_date)::date, sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'date' THEN (start_date, id) > (sqlc.narg(after_date)::date, sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'amount' AND sqlc.arg(descending)::bool THEN (amount, id) < (sqlc.narg(after_amount)::numeric, sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'amount' THEN (amount, id) > (sqlc.narg(after_amount)::numeric, sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'description' AND sqlc.arg(descending)::bool THEN (description COLLATE "C", id) < (sqlc.narg(after_description)::text COLLATE "C", sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'description' THEN (description COLLATE "C", id) > (sqlc.narg(after_description)::text COLLATE "C", sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(descending)::bool THEN id < sqlc.narg(after_id)::int
+    ELSE id > sqlc.narg(after_id)::int
+  END IS NOT FALSE
+ORDER BY
+  CASE WHEN sqlc.arg(sort_key)::text = 'date' AND NOT sqlc.arg(descending)::bool THEN start_date END ASC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'date' AND sqlc.arg(descending)::bool THEN start_date END DESC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'amount' AND NOT sqlc.arg(descending)::bool THEN amount END ASC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'amount' AND sqlc.arg(descending)::bool THEN amount END DESC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'description' AND NOT sqlc.arg(descending)::bool THEN description COLLATE "C" END ASC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'description' AND sqlc.arg(descending)::bool THEN description COLLATE "C" END DESC,
+  CASE WHEN NOT sqlc.arg(descending)::bool THEN id END ASC,
+  CASE WHEN sqlc.arg(descending)::bool THEN id END DESC
+LIMIT sqlc.narg(page_size)::int;
 
--- name: SetRecurringActive :exec
+-- name: DeleteRecurring :execrows
 UPDATE recurring_transactions
-SET active = sqlc.arg(active)
-WHERE id = sqlc.arg(id);
+SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL;
+
+-- name: SetRecurringActive :one
+UPDATE recurring_transactions
+SET active = sqlc.arg(active), version = version + 1
+WHERE id = sqlc.arg(id) AND version = sqlc.arg(version) AND deleted_at IS NULL
+RETURNING *;
 
 -- name: UpdateRecurring :one
 UPDATE recurring_transactions
@@ -47,9 +93,37 @@ SET
   day_of_week  = sqlc.arg(day_of_week),
   day_of_month = sqlc.arg(day_of_month),
   end_date     = sqlc.arg(end_date),
-  active       = sqlc.arg(active)
-WHERE id = sqlc.arg(id)
+  active       = sqlc.arg(active),
+  currency     = sqlc.arg(currency),
+  prorate      = sqlc.arg(prorate),
+  occurrence_count = sqlc.narg(occurrence_count),
+  week_of_month = sqlc.narg(week_of_month),
+  business_day = sqlc.arg(business_day),
+  rrule        = sqlc.narg(rrule),
+  category     = sqlc.narg(category),
+  account_id   = sqlc.narg(account_id),
+  version      = version + 1
+WHERE id = sqlc.arg(id) AND version = sqlc.arg(version) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SetRecurringPause :one
+UPDATE recurring_transactions
+SET paused_from = sqlc.arg(paused_from), paused_until = sqlc.arg(paused_until), version = version + 1
+WHERE id = sqlc.arg(id) AND version = sqlc.arg(version) AND deleted_at IS NULL
 RETURNING *;
 
 -- name: ListActiveRecurring :many
-SELECT * FROM recurring_transactions WHERE active = TRUE;
+SELECT * FROM recurring_transactions WHERE active = TRUE AND deleted_at IS NULL;
+
+-- name: ListDeletedRecurring :many
+SELECT * FROM recurring_transactions WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC;
+
+-- name: RestoreRecurring :one
+UPDATE recurring_transactions
+SET deleted_at = NULL, version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeDeletedRecurring :execrows
+DELETE FROM recurring_transactions
+WHERE deleted_at IS NOT NULL AND deleted_at < sqlc.arg(cutoff);
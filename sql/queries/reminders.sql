@@ -0,0 +1,13 @@
+-- name: SetRecurringReminder :one
+INSERT INTO recurring_reminders (recurring_id, days_before)
+VALUES ($1, $2)
+ON CONFLICT (recurring_id) DO UPDATE SET days_before = EXCLUDED.days_before
+RETURNING recurring_id, days_before;
+
+-- name: DeleteRecurringReminder :execrows
+DELETE FROM recurring_reminders WHERE recurring_id = $1;
+
+-- name: ListRecurringReminders :many
+SELECT recurring_id, days_before
+FROM recurring_reminders
+ORDER BY recurring_id;
@@ -0,0 +1,23 @@
+-- name: CreateScenario :one
+INSERT INTO scenarios (name, definition)
+VALUES ($1, $2)
+RETURNING id, name, definition, created_at;
+
+-- name: ListScenarios :many
+SELECT id, name, definition, created_at
+FROM scenarios
+ORDER BY id;
+
+-- name: GetScenario :one
+SELECT id, name, definition, created_at
+FROM scenarios
+WHERE id = $1;
+
+-- name: UpdateScenario :one
+UPDATE scenarios
+SET name = $1, definition = $2
+WHERE id = $3
+RETURNING id, name, definition, created_at;
+
+-- name: DeleteScenario :execrows
+DELETE FROM scenarios WHERE id = $1;
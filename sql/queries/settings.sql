@@ -4,7 +4,7 @@ SELECT value FROM settings WHERE key = $1;
 -- name: UpdateSetting :exec
 INSERT INTO settings (key, value, updated_at)
 VALUES ($1, $2, CURRENT_TIMESTAMP)
-ON CONFLICT (key)
+ON CONFLICT ON CONSTRAINT settings_pkey
 DO UPDATE SET value = $2, updated_at = CURRENT_TIMESTAMP;
 
 -- name: DeleteSetting :exec
@@ -0,0 +1,24 @@
+-- name: ListSyncChanges :many
+-- The records changed after since, oldest change first.
+SELECT entity, entity_id, seq, created_seq
+FROM sync_changes
+WHERE seq > sqlc.arg(since)
+ORDER BY seq
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetSyncCursor :one
+SELECT COALESCE(MAX(seq), 0)::BIGINT AS cursor
+FROM sync_changes;
+
+-- name: GetTransactionsByIDs :many
+-- Deleted ones included, so sync can tell them apart from missing ones.
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE id = ANY(sqlc.arg(ids)::int[])
+ORDER BY id;
+
+-- name: GetRecurringsByIDs :many
+-- Deleted ones included, as for GetTransactionsByIDs.
+SELECT * FROM recurring_transactions
+WHERE id = ANY(sqlc.arg(ids)::int[])
+ORDER BY id;
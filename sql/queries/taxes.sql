@@ -0,0 +1,31 @@
+-- name: UpsertIncomeTaxDetail :exec
+INSERT INTO income_tax_details (transaction_id, gross_amount, tax_withheld)
+VALUES ($1, $2, $3)
+ON CONFLICT (transaction_id)
+DO UPDATE SET gross_amount = $2, tax_withheld = $3;
+
+-- name: GetTaxTotalsBetween :one
+SELECT
+  COALESCE(SUM(d.gross_amount), 0)::numeric AS total_gross,
+  COALESCE(SUM(d.tax_withheld), 0)::numeric AS total_withheld
+FROM income_tax_details d
+JOIN transactions t ON t.id = d.transaction_id
+WHERE t.date BETWEEN sqlc.arg(start_date) AND sqlc.arg(end_date)
+  AND t.deleted_at IS NULL;
+
+-- name: TagSelfEmploymentIncome :exec
+INSERT INTO self_employment_income (transaction_id)
+VALUES ($1)
+ON CONFLICT (transaction_id) DO NOTHING;
+
+-- name: UntagSelfEmploymentIncome :execrows
+DELETE FROM self_employment_income
+WHERE transaction_id = $1;
+
+-- name: GetSelfEmploymentIncomeBetween :many
+SELECT t.id, t.date, t.amount, t.currency
+FROM self_employment_income s
+JOIN transactions t ON t.id = s.transaction_id
+WHERE t.date BETWEEN sqlc.arg(start_date) AND sqlc.arg(end_date)
+  AND t.deleted_at IS NULL
+ORDER BY t.date, t.id;
@@ -1,28 +1,160 @@
--- name: CreateTransaction :exec
-INSERT INTO transactions (date, amount, description, type)
-VALUES ($1, $2, $3, $4);
+-- name: CreateTransaction :one
+INSERT INTO transactions (date, amount, description, type, category, currency, account_id, status)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version;
 
 -- name: GetAllTransactions :many
-SELECT id, date, amount, description, type, created_at
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
 FROM transactions
+WHERE deleted_at IS NULL
 ORDER BY date ASC;
 
 -- name: GetTransactionsByDateRange :many
-SELECT id, date, amount, description, type, created_at
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
 FROM transactions
 WHERE date BETWEEN $1 AND $2
+  AND deleted_at IS NULL
 ORDER BY date ASC;
 
--- name: DeleteTransaction :exec
-DELETE FROM transactions WHERE id = $1;
+-- name: DeleteTransaction :execrows
+UPDATE transactions
+SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetTransactionByID :one
-SELECT id, date, amount, description, type, created_at
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
 FROM transactions
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetTransactionsByType :many
-SELECT id, date, amount, description, type, created_at
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
 FROM transactions
-WHERE type = $1
-ORDER BY date ASC;
\ No newline at end of file
+WHERE type = $1 AND deleted_at IS NULL
+ORDER BY date ASC;
+
+-- name: GetTransactionsByStatus :many
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE status = $1 AND deleted_at IS NULL
+ORDER BY date ASC;
+
+-- name: ListTransactionsSorted :many
+-- Live transactions, of one status when given, sorted on sort_key (date,
+-- amount or description) either way. id breaks ties in the same direction,
+-- so equal keys always come back in the same order. Descriptions compare
+-- byte by byte, as Go's strings do. Pages are keyset ones: given after_id
+-- and the sort key of the row it names, rows start just after that row,
+-- and page_size caps how many come back.
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND CASE
+    WHEN sqlc.arg(sort_key)::text = 'date' AND sqlc.arg(descending)::bool THEN (date, id) < (sqlc.narg(after_date)::date, sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'date' THEN (date, id) > (sqlc.narg(after_date)::date, sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'amount' AND sqlc.arg(descending)::bool THEN (amount, id) < (sqlc.narg(after_amount)::numeric, sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'amount' THEN (amount, id) > (sqlc.narg(after_amount)::numeric, sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'description' AND sqlc.arg(descending)::bool THEN (description COLLATE "C", id) < (sqlc.narg(after_description)::text COLLATE "C", sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(sort_key)::text = 'description' THEN (description COLLATE "C", id) > (sqlc.narg(after_description)::text COLLATE "C", sqlc.narg(after_id)::int)
+    WHEN sqlc.arg(descending)::bool THEN id < sqlc.narg(after_id)::int
+    ELSE id > sqlc.narg(after_id)::int
+  END IS NOT FALSE
+ORDER BY
+  CASE WHEN sqlc.arg(sort_key)::text = 'date' AND NOT sqlc.arg(descending)::bool THEN date END ASC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'date' AND sqlc.arg(descending)::bool THEN date END DESC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'amount' AND NOT sqlc.arg(descending)::bool THEN amount END ASC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'amount' AND sqlc.arg(descending)::bool THEN amount END DESC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'description' AND NOT sqlc.arg(descending)::bool THEN description COLLATE "C" END ASC,
+  CASE WHEN sqlc.arg(sort_key)::text = 'description' AND sqlc.arg(descending)::bool THEN description COLLATE "C" END DESC,
+  CASE WHEN NOT sqlc.arg(descending)::bool THEN id END ASC,
+  CASE WHEN sqlc.arg(descending)::bool THEN id END DESC
+LIMIT sqlc.narg(page_size)::int;
+
+-- name: GetPendingTransactionsBefore :many
+-- Pending transactions from before the forecast starts. The bank balance
+-- doesn't include them yet, so the forecast still has to.
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE status = 'pending' AND deleted_at IS NULL AND date < $1
+ORDER BY date ASC;
+
+-- name: SetTransactionStatus :one
+UPDATE transactions
+SET status = $2, version = version + 1
+WHERE id = $1 AND version = $3 AND deleted_at IS NULL
+RETURNING id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version;
+
+-- name: ListDeletedTransactions :many
+SELECT id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: RestoreTransaction :one
+UPDATE transactions
+SET deleted_at = NULL, version = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version;
+
+-- name: PurgeDeletedTransactions :execrows
+DELETE FROM transactions
+WHERE deleted_at IS NOT NULL AND deleted_at < sqlc.arg(cutoff);
+
+-- name: GetTransactionAggregatesByCurrency :many
+-- Totals kept apart by currency, to be converted to the base currency
+-- before they are added up. A NULL start_date or end_date leaves that side
+-- of the range open, and a NULL status takes every status.
+SELECT
+  currency,
+  COUNT(*) AS count,
+  COALESCE(SUM(amount) FILTER (WHERE type = 'income'), 0)::numeric AS total_income,
+  COALESCE(SUM(amount) FILTER (WHERE type = 'expense'), 0)::numeric AS total_expense
+FROM transactions
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(start_date)::date IS NULL OR date >= sqlc.narg(start_date))
+  AND (sqlc.narg(end_date)::date IS NULL OR date <= sqlc.narg(end_date))
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+GROUP BY currency
+ORDER BY currency ASC;
+
+-- name: GetLargestExpenses :many
+-- The biggest expense in each currency from start_date to end_date, the
+-- earliest of any tied for it. Which is biggest overall depends on the
+-- exchange rates.
+SELECT DISTINCT ON (currency) id, date, amount, description, type, created_at, deleted_at, category, currency, account_id, status, version
+FROM transactions
+WHERE deleted_at IS NULL
+  AND type = 'expense'
+  AND date BETWEEN sqlc.arg(start_date) AND sqlc.arg(end_date)
+ORDER BY currency ASC, amount ASC, date ASC, id ASC;
+
+-- name: GetSpendingHeatmap :many
+-- Spending per day from start_date to end_date, with the ISO week and
+-- weekday (1 for Monday) each day falls in, for the spending heatmap. Days
+-- are kept apart by currency for conversion. idx_transactions_spending
+-- covers it.
+SELECT
+  date,
+  currency,
+  EXTRACT(ISOYEAR FROM date)::int AS iso_year,
+  EXTRACT(WEEK FROM date)::int AS week,
+  EXTRACT(ISODOW FROM date)::int AS weekday,
+  COUNT(*) AS count,
+  (-SUM(amount))::numeric AS spent
+FROM transactions
+WHERE deleted_at IS NULL
+  AND type = 'expense'
+  AND date BETWEEN sqlc.arg(start_date) AND sqlc.arg(end_date)
+GROUP BY date, currency
+ORDER BY date ASC, currency ASC;
+
+-- name: GetDailyTransactionSums :many
+-- Per-day totals for the forecast, kept apart by currency for conversion
+-- and by account for card due dates and interest.
+SELECT date, currency, account_id, SUM(amount)::numeric AS total
+FROM transactions
+WHERE deleted_at IS NULL
+  AND date BETWEEN sqlc.arg(start_date) AND sqlc.arg(end_date)
+  AND (sqlc.arg(include_pending)::boolean OR status = 'cleared')
+GROUP BY date, currency, account_id
+ORDER BY date ASC;
@@ -0,0 +1,60 @@
+-- name: CreateUser :one
+INSERT INTO users (username, password_hash, role)
+VALUES ($1, $2, $3)
+RETURNING id, username, password_hash, created_at, role;
+
+-- name: GetUserByID :one
+SELECT id, username, password_hash, created_at, role
+FROM users
+WHERE id = $1;
+
+-- name: GetUserByUsername :one
+SELECT id, username, password_hash, created_at, role
+FROM users
+WHERE username = $1;
+
+-- name: ListUsers :many
+SELECT id, username, password_hash, created_at, role
+FROM users
+ORDER BY id;
+
+-- name: SetUserPassword :execrows
+UPDATE users SET password_hash = $2 WHERE id = $1;
+
+-- name: SetUserRole :execrows
+UPDATE users SET role = $2 WHERE id = $1;
+
+-- name: CountUsersByRole :one
+SELECT COUNT(*) FROM users WHERE role = $1;
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1;
+
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, user_id, family, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetRefreshToken :one
+SELECT token_hash, user_id, family, expires_at, revoked_at, created_at
+FROM refresh_tokens
+WHERE token_hash = $1;
+
+-- name: RevokeRefreshToken :execrows
+-- Revoking an already revoked token changes nothing, so a refresh that
+-- lost a race sees 0 rows.
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE token_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE family = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteExpiredRefreshTokens :execrows
+DELETE FROM refresh_tokens WHERE expires_at < $1;